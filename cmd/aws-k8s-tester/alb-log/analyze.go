@@ -0,0 +1,57 @@
+package alblog
+
+import (
+	"fmt"
+	"os"
+
+	alblog "github.com/aws/aws-k8s-tester/internal/alb-log"
+
+	"github.com/spf13/cobra"
+)
+
+var analyzeHTML string
+
+func newAnalyze() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "analyze [list of ALB access log files to analyze]",
+		Short: "Analyzes raw ALB access log files for latency percentiles, status codes, and time series",
+		Run:   analyzeFunc,
+	}
+	cmd.PersistentFlags().StringVar(&analyzeHTML, "html", "", "HTML report output path (optional)")
+	return cmd
+}
+
+func analyzeFunc(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "expected at least 1 argument but got %v\n", args)
+		os.Exit(1)
+	}
+
+	var logs []alblog.Log
+	for _, p := range args {
+		ls, err := alblog.Parse(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to parse %q (%v)\n", p, err)
+			os.Exit(1)
+		}
+		logs = append(logs, ls...)
+	}
+	an := alblog.Analyze(logs)
+	fmt.Print(an.String())
+
+	if output != "" {
+		ps, err := an.SaveCSV(output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to save CSV (%v)\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Saved analysis to %q\n", ps)
+	}
+	if analyzeHTML != "" {
+		if err := an.SaveHTML(analyzeHTML); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to save HTML report (%v)\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Saved HTML report to %q\n", analyzeHTML)
+	}
+}
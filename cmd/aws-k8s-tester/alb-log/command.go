@@ -15,6 +15,7 @@ func NewCommand() *cobra.Command {
 	}
 	rootCmd.PersistentFlags().StringVar(&output, "output", "", "output file path")
 	rootCmd.AddCommand(
+		newAnalyze(),
 		newConvertToCSV(),
 		newCountTargets(),
 		newMergeRaw(),
@@ -0,0 +1,56 @@
+package ec2
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-k8s-tester/internal/ec2"
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+
+	"github.com/spf13/cobra"
+)
+
+func newCheck() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check <subcommand>",
+		Short: "Check commands",
+	}
+	cmd.AddCommand(newCheckSpot())
+	return cmd
+}
+
+func newCheckSpot() *cobra.Command {
+	return &cobra.Command{
+		Use:   "spot",
+		Short: "Check Spot interruptions, and replace interrupted instances if configured",
+		Run:   checkSpotFunc,
+	}
+}
+
+func checkSpotFunc(cmd *cobra.Command, args []string) {
+	if !fileutil.Exist(path) {
+		fmt.Fprintf(os.Stderr, "cannot find configuration %q\n", path)
+		os.Exit(1)
+	}
+
+	cfg, err := ec2config.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+
+	var dp ec2.Deployer
+	dp, err = ec2.NewDeployer(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create EC2 deployer %v\n", err)
+		os.Exit(1)
+	}
+
+	if err = dp.CheckSpotInterruptions(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to check Spot interruptions %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("'aws-k8s-tester ec2 check spot' success")
+}
@@ -12,6 +12,7 @@ func NewCommand() *cobra.Command {
 	}
 	cmd.PersistentFlags().StringVarP(&path, "path", "p", "", "ec2 test configuration file path")
 	cmd.AddCommand(
+		newCheck(),
 		newCreate(),
 		newDelete(),
 	)
@@ -3,6 +3,7 @@ package eks
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"os/signal"
@@ -16,6 +17,7 @@ import (
 	humanize "github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
+	"sigs.k8s.io/yaml"
 )
 
 func newIngress() *cobra.Command {
@@ -39,6 +41,7 @@ func newIngressServer() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&ingressServerPort, "port", ":32030", "specify the ingress test server port")
 	cmd.PersistentFlags().IntVar(&ingressServerRoutes, "routes", 3, "specify the number of routes (e.g. /ingress-test-00001, /ingress-test-00002, and so on)")
 	cmd.PersistentFlags().IntVar(&ingressServerResponseSize, "response-size", 40*1024, "specify the server response size")
+	cmd.PersistentFlags().StringVar(&ingressServerRouteSpec, "route-spec", "", "YAML file path that maps routes to default behaviors in query parameter syntax (e.g. '/ingress-test-0000000: delay=100ms&error-rate=0.1')")
 	return cmd
 }
 
@@ -46,6 +49,7 @@ var (
 	ingressServerPort         string
 	ingressServerRoutes       int
 	ingressServerResponseSize int
+	ingressServerRouteSpec    string
 )
 
 func ingressServerFunc(cmd *cobra.Command, args []string) {
@@ -65,15 +69,29 @@ func ingressServerFunc(cmd *cobra.Command, args []string) {
 	notifier := make(chan os.Signal, 1)
 	signal.Notify(notifier, syscall.SIGINT, syscall.SIGTERM)
 
+	var spec map[string]string
+	if ingressServerRouteSpec != "" {
+		d, derr := ioutil.ReadFile(ingressServerRouteSpec)
+		if derr != nil {
+			fmt.Fprintf(os.Stderr, "failed to read route spec %q (%v)\n", ingressServerRouteSpec, derr)
+			os.Exit(1)
+		}
+		if err = yaml.Unmarshal(d, &spec); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to parse route spec %q (%v)\n", ingressServerRouteSpec, err)
+			os.Exit(1)
+		}
+	}
+
 	rootCtx, rootCancel := context.WithCancel(context.Background())
 	var mux *http.ServeMux
-	mux, err = server.NewMux(rootCtx, lg, ingressServerRoutes, ingressServerResponseSize)
+	mux, err = server.NewMuxWithSpec(rootCtx, lg, ingressServerRoutes, ingressServerResponseSize, spec)
 	if err != nil {
-		panic(err)
+		fmt.Fprintf(os.Stderr, "failed to create ingress server (%v)\n", err)
+		os.Exit(1)
 	}
 	srv := &http.Server{
 		Addr:    ingressServerPort,
-		Handler: mux,
+		Handler: server.H2C(mux),
 	}
 	errc := make(chan error)
 	go func() {
@@ -97,10 +115,15 @@ func newIngressClient() *cobra.Command {
 		Short: "Runs ingress test client",
 		Run:   ingressClientFunc,
 	}
-	cmd.PersistentFlags().StringVar(&ingressClientEp, "endpoint", "", "ingress-test server endpoint")
+	cmd.PersistentFlags().StringVar(&ingressClientEp, "endpoint", "", "ingress-test server endpoint, either URL or host[:port]")
 	cmd.PersistentFlags().IntVar(&ingressClientRoutes, "routes", 10, "total number of routes")
 	cmd.PersistentFlags().IntVar(&ingressClientClients, "clients", 100, "total number of concurrent clients")
-	cmd.PersistentFlags().IntVar(&ingressClientRequests, "requests", 5000, "total number of requests")
+	cmd.PersistentFlags().IntVar(&ingressClientRequests, "requests", 5000, "total number of requests (ignored in 'open-loop' mode)")
+	cmd.PersistentFlags().StringVar(&ingressClientMode, "mode", client.ModeClosedLoop, "'closed-loop' to run concurrent clients until requests are exhausted, 'open-loop' to send requests at the target rate")
+	cmd.PersistentFlags().Float64Var(&ingressClientStartQPS, "start-qps", 100, "target QPS at the beginning of 'open-loop' mode")
+	cmd.PersistentFlags().Float64Var(&ingressClientEndQPS, "end-qps", 0, "target QPS at the end of 'open-loop' mode (0 for constant rate)")
+	cmd.PersistentFlags().IntVar(&ingressClientSteps, "steps", 0, "number of rate steps from start to end QPS in 'open-loop' mode (0 for linear ramp, at least 2 for steps)")
+	cmd.PersistentFlags().DurationVar(&ingressClientDuration, "duration", time.Minute, "total duration of 'open-loop' mode")
 	cmd.PersistentFlags().StringVar(&ingressClientResultPath, "result-path", "", "file path to output results in encoded 'eksconfig.Config' YAML")
 	return cmd
 }
@@ -110,6 +133,11 @@ var (
 	ingressClientRoutes     int
 	ingressClientClients    int
 	ingressClientRequests   int
+	ingressClientMode       string
+	ingressClientStartQPS   float64
+	ingressClientEndQPS     float64
+	ingressClientSteps      int
+	ingressClientDuration   time.Duration
 	ingressClientResultPath string
 )
 
@@ -126,7 +154,21 @@ func ingressClientFunc(cmd *cobra.Command, args []string) {
 	}
 
 	// send loads from client to server
-	cli, err := client.New(lg, ingressClientEp, ingressClientRoutes, ingressClientClients, ingressClientRequests)
+	var cli *client.Client
+	switch ingressClientMode {
+	case client.ModeClosedLoop:
+		cli, err = client.New(lg, ingressClientEp, ingressClientRoutes, ingressClientClients, ingressClientRequests)
+	case client.ModeOpenLoop:
+		cli, err = client.NewOpenLoop(lg, ingressClientEp, ingressClientRoutes, ingressClientClients, client.Schedule{
+			StartQPS: ingressClientStartQPS,
+			EndQPS:   ingressClientEndQPS,
+			Steps:    ingressClientSteps,
+			Duration: ingressClientDuration,
+		})
+	default:
+		fmt.Fprintf(os.Stderr, "invalid ingress client mode %q\n", ingressClientMode)
+		os.Exit(1)
+	}
 	if err != nil {
 		lg.Fatal("failed to create client", zap.Error(err))
 	}
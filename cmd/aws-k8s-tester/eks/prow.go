@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -13,6 +14,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/aws/aws-k8s-tester/internal/prow"
 	"github.com/aws/aws-k8s-tester/internal/prow/status"
 	"github.com/aws/aws-k8s-tester/pkg/fileutil"
 
@@ -27,6 +29,7 @@ func newProw() *cobra.Command {
 	}
 	ac.AddCommand(
 		newProwStatus(),
+		newProwCoverage(),
 	)
 	return ac
 }
@@ -53,10 +56,43 @@ func newProwStatusServe() *cobra.Command {
 		Run:   prowStatusServeFunc,
 	}
 	cmd.PersistentFlags().StringVar(&prowStatusServePort, "port", ":32010", "port to serve /eks-test-status-upstream")
+	cmd.PersistentFlags().DurationVar(&prowStatusServeRefreshInterval, "refresh-interval", 3*time.Hour, "interval to refresh jobs in background")
+	cmd.PersistentFlags().DurationVar(&prowStatusServeStaleThreshold, "stale-threshold", 0, "age of the last successful refresh after which readiness reports stale data (0 for twice the refresh interval)")
+	cmd.PersistentFlags().StringVar(&prowStatusServeCacheDir, "cache-dir", "", "directory to persist the last good snapshot, to serve immediately on restarts")
+	addProwStatusResultFlags(cmd)
 	return cmd
 }
 
-var prowStatusServePort string
+var (
+	prowStatusServePort            string
+	prowStatusServeRefreshInterval time.Duration
+	prowStatusServeStaleThreshold  time.Duration
+	prowStatusServeCacheDir        string
+)
+
+var (
+	prowStatusResultURL    string
+	prowStatusResultDir    string
+	prowStatusResultBuilds int
+)
+
+func addProwStatusResultFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&prowStatusResultURL, "result-url", prow.DefaultResultURL, "base URL of Prow job artifacts (finished.json, started.json)")
+	cmd.PersistentFlags().StringVar(&prowStatusResultDir, "result-dir", "", "local directory of Prow job artifacts in bucket layout, instead of 'result-url'")
+	cmd.PersistentFlags().IntVar(&prowStatusResultBuilds, "result-builds", 0, "number of latest builds to fetch per job (0 to disable job results)")
+}
+
+func prowStatusResultConfig() status.ResultConfig {
+	rc := status.ResultConfig{Builds: prowStatusResultBuilds}
+	if rc.Builds > 0 {
+		if prowStatusResultDir != "" {
+			rc.Source = prow.NewLocalResultSource(prowStatusResultDir)
+		} else {
+			rc.Source = prow.NewHTTPResultSource(prowStatusResultURL)
+		}
+	}
+	return rc
+}
 
 func prowStatusServeFunc(cmd *cobra.Command, args []string) {
 	lg, err := zap.NewProduction()
@@ -78,7 +114,12 @@ func prowStatusServeFunc(cmd *cobra.Command, args []string) {
 	rootCtx, rootCancel := context.WithCancel(context.Background())
 	srv := &http.Server{
 		Addr:    prowStatusServePort,
-		Handler: status.NewMux(rootCtx, lg),
+		Handler: status.NewMuxWithConfig(rootCtx, lg, status.Config{
+			Results:         prowStatusResultConfig(),
+			RefreshInterval: prowStatusServeRefreshInterval,
+			StaleThreshold:  prowStatusServeStaleThreshold,
+			CacheDir:        prowStatusServeCacheDir,
+		}),
 	}
 	errc := make(chan error)
 	go func() {
@@ -107,10 +148,21 @@ func newProwStatusGet() *cobra.Command {
 		os.Exit(1)
 	}
 	cmd.PersistentFlags().StringVar(&prowStatusGetDataDir, "data-dir", filepath.Join(wd, "data"), "target directory to output test status")
+	cmd.PersistentFlags().StringVar(&prowStatusGetType, "type", "", "only output jobs of the type (e.g. pre-submit, post-submit, periodic)")
+	cmd.PersistentFlags().StringVar(&prowStatusGetCategory, "category", "", "only output jobs in the category")
+	cmd.PersistentFlags().StringVar(&prowStatusGetProvider, "provider", "", "only output jobs of the provider (e.g. AWS, GCP, Not-Categorized)")
+	cmd.PersistentFlags().StringVar(&prowStatusGetBranch, "branch", "", "only output jobs that run with the branch")
+	addProwStatusResultFlags(cmd)
 	return cmd
 }
 
-var prowStatusGetDataDir string
+var (
+	prowStatusGetDataDir  string
+	prowStatusGetType     string
+	prowStatusGetCategory string
+	prowStatusGetProvider string
+	prowStatusGetBranch   string
+)
 
 /*
 go install -v ./cmd/aws-k8s-tester
@@ -131,7 +183,9 @@ func prowStatusGetFunc(cmd *cobra.Command, args []string) {
 	now := time.Now().UTC()
 	p := filepath.Join(prowStatusGetDataDir, fmt.Sprintf("prow-status-%d%02d%02d", now.Year(), now.Month(), now.Day()))
 
-	mux := status.NewMux(context.Background(), zap.NewExample())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mux := status.NewMuxWithConfig(ctx, zap.NewExample(), status.Config{Results: prowStatusResultConfig()})
 	ts := httptest.NewServer(mux)
 	defer ts.Close()
 
@@ -168,5 +222,136 @@ func prowStatusGetFunc(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	fmt.Printf("saved to %q and %q\n", p+".html", p+".txt")
+	query := url.Values{}
+	for k, v := range map[string]string{
+		"type":     prowStatusGetType,
+		"category": prowStatusGetCategory,
+		"provider": prowStatusGetProvider,
+		"branch":   prowStatusGetBranch,
+	} {
+		if v != "" {
+			query.Set(k, v)
+		}
+	}
+	for _, format := range []string{"json", "csv"} {
+		query.Set("format", format)
+		resp, err = http.Get(ts.URL + status.PathAPIJobs + "?" + query.Encode())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to get %q (%v)\n", status.PathAPIJobs, err)
+			os.Exit(1)
+		}
+		d, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read %q (%v)\n", status.PathAPIJobs, err)
+			os.Exit(1)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			fmt.Fprintf(os.Stderr, "failed to get %q (%s, %q)\n", status.PathAPIJobs, resp.Status, string(d))
+			os.Exit(1)
+		}
+		if err = ioutil.WriteFile(p+".jobs."+format, d, 0600); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write %q (%v)\n", p+".jobs."+format, err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("saved to %q, %q, %q, and %q\n", p+".html", p+".txt", p+".jobs.json", p+".jobs.csv")
+}
+
+func newProwCoverage() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "coverage",
+		Short: "Report AWS coverage gaps against GCP upstream jobs, and diff against the previous snapshot",
+		Run:   prowCoverageFunc,
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to get working directory %v\n", err)
+		os.Exit(1)
+	}
+	cmd.PersistentFlags().StringVar(&prowCoverageSnapshotDir, "snapshot-dir", filepath.Join(wd, "data", "prow-snapshots"), "directory to store job snapshots keyed on test-infra git commit")
+	cmd.PersistentFlags().StringVar(&prowCoverageOutput, "output", "", "file path to output the coverage report (empty to only print)")
+	return cmd
+}
+
+var (
+	prowCoverageSnapshotDir string
+	prowCoverageOutput      string
+)
+
+/*
+go install -v ./cmd/aws-k8s-tester
+
+aws-k8s-tester \
+  eks \
+  prow \
+  coverage \
+  --snapshot-dir ./data/prow-snapshots
+*/
+func prowCoverageFunc(cmd *cobra.Command, args []string) {
+	lg, err := zap.NewProduction()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create logger (%v)\n", err)
+		os.Exit(1)
+	}
+
+	var git prow.Git
+	git, err = prow.FetchGit("https://github.com/kubernetes/test-infra", "master")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to fetch 'test-infra' git (%v)\n", err)
+		os.Exit(1)
+	}
+	// download the jobs of the fetched commit, in case upstream has moved on
+	dir, paths, err := prow.DownloadJobsUpstreamAt(lg, git.CommitSHA)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to download 'test-infra' git (%v)\n", err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(dir)
+	var jobs prow.Jobs
+	jobs, _, _, err = prow.LoadJobs(lg, paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load jobs (%v)\n", err)
+		os.Exit(1)
+	}
+	cur := prow.Snapshot{Git: git, Jobs: jobs}
+
+	// find the latest snapshot of a different commit, before saving current one
+	var prev *prow.Snapshot
+	var ss []prow.Snapshot
+	ss, err = prow.LoadSnapshots(prowCoverageSnapshotDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load snapshots (%v)\n", err)
+		os.Exit(1)
+	}
+	for i := len(ss) - 1; i >= 0; i-- {
+		if ss[i].Git.CommitSHA != git.CommitSHA {
+			prev = &ss[i]
+			break
+		}
+	}
+	var p string
+	p, err = prow.SaveSnapshot(prowCoverageSnapshotDir, cur)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to save snapshot (%v)\n", err)
+		os.Exit(1)
+	}
+	lg.Info("saved snapshot", zap.String("path", p), zap.String("commit", git.CommitSHA))
+
+	report := cur.Coverage().String()
+	if prev != nil {
+		report += "\n" + prow.DiffSnapshots(*prev, cur).String()
+	} else {
+		report += "\nno previous snapshot to compare\n"
+	}
+	fmt.Println(report)
+
+	if prowCoverageOutput != "" {
+		if err = ioutil.WriteFile(prowCoverageOutput, []byte(report), 0600); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write %q (%v)\n", prowCoverageOutput, err)
+			os.Exit(1)
+		}
+		fmt.Printf("saved to %q\n", prowCoverageOutput)
+	}
 }
@@ -20,6 +20,8 @@ func newTest() *cobra.Command {
 		newTestGetWorkerNodeLogs(),
 		newTestDumpClusterLogs(),
 		newTestALB(),
+		newTestConformance(),
+		newTestCNI(),
 	)
 	return cmd
 }
@@ -201,3 +203,75 @@ func testALBMetrics(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 }
+
+func newTestConformance() *cobra.Command {
+	return &cobra.Command{
+		Use:   "conformance [artifact-directory]",
+		Short: "Runs Kubernetes conformance tests, and downloads the results to the artifact directory",
+		Run:   testConformance,
+	}
+}
+
+func testConformance(cmd *cobra.Command, args []string) {
+	if path == "" {
+		fmt.Fprintln(os.Stderr, "'--path' flag is not specified")
+		os.Exit(1)
+	}
+	if len(args) > 1 {
+		fmt.Fprintf(os.Stderr, "expected at most 1 argument, got %v\n", args)
+		os.Exit(1)
+	}
+	dir := ""
+	if len(args) == 1 {
+		dir = args[0]
+	}
+
+	cfg, err := eksconfig.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+	var tester ekstester.Tester
+	tester, err = eks.NewTester(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create EKS deployer %v\n", err)
+		os.Exit(1)
+	}
+
+	if err = tester.TestConformance(dir); err != nil {
+		fmt.Fprintf(os.Stderr, "failed conformance test %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func newTestCNI() *cobra.Command {
+	return &cobra.Command{
+		Use:   "cni",
+		Short: "Runs VPC CNI pod connectivity, IP allocation, SNAT, and IP warm pool tests",
+		Run:   testCNI,
+	}
+}
+
+func testCNI(cmd *cobra.Command, args []string) {
+	if path == "" {
+		fmt.Fprintln(os.Stderr, "'--path' flag is not specified")
+		os.Exit(1)
+	}
+
+	cfg, err := eksconfig.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+	var tester ekstester.Tester
+	tester, err = eks.NewTester(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create EKS deployer %v\n", err)
+		os.Exit(1)
+	}
+
+	if err = tester.TestCNI(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed CNI test %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -50,11 +50,13 @@ func NewCommand() *cobra.Command {
 	rootCmd.PersistentFlags().StringVar(&output, "output", "", "output file path")
 
 	rootCmd.PersistentFlags().BoolVar(&outputCSV, "output-csv", true, "'true' to output results in CSV")
-	rootCmd.PersistentFlags().BoolVar(&outputS3Upload, "output-s3-upload", false, "'true' to upload wrk outputs")
+	rootCmd.PersistentFlags().BoolVar(&outputStdout, "output-stdout", false, "'true' to also print raw wrk outputs to stdout, wrapped with markers for coordinators")
+	rootCmd.PersistentFlags().BoolVar(&outputS3Upload, "output-s3-upload", true, "'false' to skip uploading wrk outputs to S3")
 	rootCmd.PersistentFlags().StringVar(&outputS3UploadDir, "output-s3-upload-directory", "test", "directory to upload output file")
 	rootCmd.PersistentFlags().StringVar(&outputS3UploadRegion, "output-s3-upload-region", "us-west-2", "AWS region for S3 uploads")
 
 	rootCmd.PersistentFlags().IntVar(&wrkCfg.StartAtMinute, "start-at-minute", 0, "minute to start the command (temporary dumb feature to be removed after batch integration...)")
+	rootCmd.PersistentFlags().StringVar(&startAt, "start-at", "", "RFC3339 timestamp to start the command (e.g. to start multiple workers in lockstep)")
 
 	rootCmd.PersistentFlags().StringVar(&wrkCfg.Endpoint, "endpoint", "", "wrk command endpoint")
 	rootCmd.PersistentFlags().IntVar(&wrkCfg.Threads, "threads", 2, "number of threads")
@@ -69,10 +71,12 @@ func NewCommand() *cobra.Command {
 var (
 	output               string
 	outputCSV            bool
+	outputStdout         bool
 	outputS3Upload       bool
 	outputS3UploadDir    string
 	outputS3UploadRegion string
 	wrkCfg               wrk.Config
+	startAt              string
 	runInEC2             bool
 )
 
@@ -88,12 +92,22 @@ func runFunc(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 	wrkCfg.Logger = lg
+	if startAt != "" {
+		wrkCfg.StartAt, err = time.Parse(time.RFC3339, startAt)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to parse --start-at %q (%v)\n", startAt, err)
+			os.Exit(1)
+		}
+	}
 
 	rs, err := wrk.Run(wrkCfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to run wrk (%v)\n", err)
 		os.Exit(1)
 	}
+	if outputStdout {
+		fmt.Print(wrk.Wrap(rs.Output))
+	}
 
 	if outputCSV {
 		if err = wrk.ToCSV(output, rs); err != nil {
@@ -117,6 +131,10 @@ func runFunc(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if !outputS3Upload {
+		return
+	}
+
 	awsCfg := &awsapi.Config{
 		Logger:        lg,
 		DebugAPICalls: false,
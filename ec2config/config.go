@@ -2,6 +2,7 @@
 package ec2config
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -13,8 +14,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aws/aws-k8s-tester/ec2config/osprofile"
 	"github.com/aws/aws-k8s-tester/ec2config/plugins"
 	ec2types "github.com/aws/aws-k8s-tester/pkg/awsapi/ec2"
+	"github.com/aws/aws-k8s-tester/pkg/envutil"
 	"sigs.k8s.io/yaml"
 )
 
@@ -50,6 +53,26 @@ type Config struct {
 	// Set 0 to not expire.
 	UploadBucketExpireDays int `json:"upload-bucket-expire-days"`
 
+	// ArtifactStore is the storage backend for uploaded files
+	// (configs, kubeconfigs, logs, and test outputs).
+	// "s3" uploads to the S3 bucket named by tag.
+	// "local" copies to "ArtifactStoreDir", without any bucket.
+	// "http" uploads with HTTP PUT requests to "ArtifactStoreURL" (e.g. GCS XML API),
+	// with "Authorization" header from "AWS_K8S_TESTER_ARTIFACT_STORE_AUTHORIZATION".
+	ArtifactStore string `json:"artifact-store"`
+	// ArtifactStoreDir is the directory for "local" artifact store.
+	// If empty, it is set to a temporary directory named by cluster name.
+	ArtifactStoreDir string `json:"artifact-store-dir"`
+	// ArtifactStoreURL is the base URL for "http" artifact store.
+	// e.g. "https://storage.googleapis.com/my-bucket"
+	ArtifactStoreURL string `json:"artifact-store-url"`
+	// ArtifactManifestPath is the manifest file path that lists all uploaded artifacts.
+	// Must be left empty.
+	// This will be overwritten by cluster name.
+	ArtifactManifestPath       string `json:"artifact-manifest-path"`
+	ArtifactManifestPathBucket string `json:"artifact-manifest-path-bucket"`
+	ArtifactManifestPathURL    string `json:"artifact-manifest-path-url"`
+
 	// Tag is the tag used for all cloudformation stacks.
 	Tag string `json:"tag"`
 	// Tags to add additional tags to the EC2 instances.
@@ -75,9 +98,20 @@ type Config struct {
 	ConfigPathURL    string    `json:"config-path-url"`    // read-only to user
 	UpdatedAt        time.Time `json:"updated-at"`         // read-only to user
 
+	// OSProfile is the operating system profile of the image,
+	// "amazon-linux-2", "ubuntu", "container-optimized", or "custom".
+	// It selects the package manager, default user name, container runtime
+	// install, systemd paths, and log locations for plugins and deployers.
+	// If empty, it is inferred from "UserName" ("ubuntu" or "amazon-linux-2").
+	OSProfile string `json:"os-profile"`
 	// ImageID is the Amazon Machine Image (AMI).
+	// If empty, the latest AMI of the OS profile and the instance type
+	// architecture is looked up on creation. The default AMI is Amazon Linux 2
+	// x86_64, so it is reset for other OS profiles or arm64 instance types.
+	// Must be specified for "container-optimized" and "custom" OS profiles.
 	ImageID string `json:"image-id"`
 	// UserName is the user name used for running init scripts or SSH access.
+	// If empty, the default user name of the OS profile is used.
 	UserName string `json:"user-name"`
 	// Plugins is the list of plugins.
 	Plugins []string `json:"plugins"`
@@ -98,6 +132,9 @@ type Config struct {
 
 	// InstanceType is the instance type.
 	InstanceType string `json:"instance-type"`
+	// Architecture is the processor architecture of the instance type,
+	// "x86_64" or "arm64". Plugins download the release artifacts of it.
+	Architecture string `json:"architecture"` // read-only to user
 	// ClusterSize is the number of EC2 instances to create.
 	ClusterSize int `json:"cluster-size"`
 
@@ -126,6 +163,14 @@ type Config struct {
 	// RouteTableIDs is the list of route table IDs.
 	RouteTableIDs []string `json:"route-table-ids"`
 
+	// EnableIPv6 is true to run instances in dual-stack subnets.
+	// The created VPC requests an Amazon-provided IPv6 CIDR block,
+	// and each subnet gets a /64 block to auto-assign IPv6 addresses.
+	// For an existing VPC, its subnets must already have IPv6 CIDR blocks.
+	EnableIPv6 bool `json:"enable-ipv6"`
+	// VPCIPv6CIDR is the IPv6 CIDR block of the created VPC.
+	VPCIPv6CIDR string `json:"vpc-ipv6-cidr"` // read-only to user
+
 	// SubnetIDs is a list of subnet IDs to use.
 	// If empty, it will fetch subnets from a given or created VPC.
 	// And randomly assign them to instances.
@@ -133,6 +178,7 @@ type Config struct {
 	SubnetIDToAvailabilityZone map[string]string `json:"subnet-id-to-availability-zone"` // read-only to user
 
 	// IngressRulesTCP is a map from TCP port range to CIDR to allow via security groups.
+	// The CIDR can be IPv6 (e.g. "::/0") when "EnableIPv6" is true.
 	IngressRulesTCP map[string]string `json:"ingress-rules-tcp"`
 
 	// SecurityGroupIDs is the list of security group IDs.
@@ -153,6 +199,118 @@ type Config struct {
 
 	// CustomScript is executed at the end of EC2 init script.
 	CustomScript string `json:"custom-script"`
+
+	// Spot defines Spot instance options.
+	// Requires "UseCloudFormation", since Spot instances are launched
+	// by an Auto Scaling group with a mixed instances policy.
+	Spot Spot `json:"spot"`
+	// InterruptedInstances is a set of EC2 instances that have been replaced
+	// after Spot interruption notices.
+	InterruptedInstances map[string]Instance `json:"interrupted-instances,omitempty"` // read-only to user
+
+	// UseCloudFormation is true to create the VPC, subnets, security group,
+	// and instances in one CloudFormation stack, where instances are launched
+	// by an Auto Scaling group with a launch template. Then, adding or deleting
+	// an instance updates the Auto Scaling group capacity, and terminating
+	// the cluster deletes the stack at once. Key pair is still created outside
+	// of the stack.
+	UseCloudFormation bool `json:"use-cloudformation"`
+	// CFStackName is the name of CloudFormation stack.
+	CFStackName string `json:"cf-stack-name"` // read-only to user
+	// CFStackID is the ID of CloudFormation stack.
+	CFStackID string `json:"cf-stack-id"` // read-only to user
+	// CFStackStatus is the last status of CloudFormation stack.
+	CFStackStatus string `json:"cf-stack-status"` // read-only to user
+	// CFStackEvents is the list of CloudFormation stack events, oldest first.
+	CFStackEvents []CFStackEvent `json:"cf-stack-events"` // read-only to user
+	// ASGName is the name of Auto Scaling group in the stack.
+	ASGName string `json:"asg-name"` // read-only to user
+	// LaunchTemplateID is the ID of launch template in the stack.
+	LaunchTemplateID string `json:"launch-template-id"` // read-only to user
+}
+
+// CFStackEvent represents a CloudFormation stack event.
+type CFStackEvent struct {
+	EventID              string    `json:"event-id"`
+	Timestamp            time.Time `json:"timestamp"`
+	LogicalResourceID    string    `json:"logical-resource-id"`
+	ResourceType         string    `json:"resource-type"`
+	ResourceStatus       string    `json:"resource-status"`
+	ResourceStatusReason string    `json:"resource-status-reason,omitempty"`
+}
+
+// Spot defines Spot instance options of an Auto Scaling group,
+// which launches On-Demand and Spot instances with a mixed instances policy.
+// https://docs.aws.amazon.com/autoscaling/ec2/userguide/asg-purchase-options.html
+type Spot struct {
+	// Enable is true to launch Spot instances.
+	Enable bool `json:"enable"`
+	// InstanceTypes is the list of fallback instance types, in addition to
+	// the primary instance type. On-Demand instances are launched with
+	// the primary instance type first, then with fallbacks in order.
+	// Spot instances are launched with any type in the lowest-priced pools.
+	InstanceTypes []string `json:"instance-types,omitempty"`
+	// OnDemandBaseCapacity is the minimum number of On-Demand instances.
+	OnDemandBaseCapacity int `json:"on-demand-base-capacity"`
+	// OnDemandPercentageAboveBaseCapacity is the percentage of On-Demand instances
+	// for the capacity beyond "OnDemandBaseCapacity". Set 0 to launch only Spot
+	// instances above the base capacity.
+	OnDemandPercentageAboveBaseCapacity int `json:"on-demand-percentage-above-base-capacity"`
+	// InstancePools is the number of lowest-priced Spot pools
+	// to allocate Spot capacity across (1-20).
+	// If empty, set default value.
+	InstancePools int `json:"instance-pools,omitempty"`
+	// MaxPrice is the maximum hourly price to pay for Spot instances (e.g. "0.05").
+	// If empty, it is the On-Demand price.
+	MaxPrice string `json:"max-price,omitempty"`
+	// ReplaceInterrupted is true to terminate instances with Spot interruption
+	// notices in advance, so that the Auto Scaling group launches replacements
+	// without waiting for EC2 to reclaim them.
+	ReplaceInterrupted bool `json:"replace-interrupted"`
+}
+
+// ValidateAndSetDefaults validates Spot options for the primary instance type.
+func (sp *Spot) ValidateAndSetDefaults(instanceType string) error {
+	if !sp.Enable {
+		return nil
+	}
+	seen := map[string]struct{}{instanceType: {}}
+	for _, tp := range sp.InstanceTypes {
+		v, ok := ec2types.InstanceTypes[tp]
+		if !ok {
+			return fmt.Errorf("unexpected Spot InstanceTypes %q", tp)
+		}
+		// all instances in the group boot from the same AMI
+		if pv, ok := ec2types.InstanceTypes[instanceType]; ok && pv.Architecture != v.Architecture {
+			return fmt.Errorf("Spot InstanceTypes %q architecture %q does not match %q architecture %q", tp, v.Architecture, instanceType, pv.Architecture)
+		}
+		if _, ok := seen[tp]; ok {
+			return fmt.Errorf("duplicate Spot InstanceTypes %q", tp)
+		}
+		seen[tp] = struct{}{}
+	}
+	// mixed instances policy allows up to 20 instance type overrides
+	if len(seen) > 20 {
+		return fmt.Errorf("too many Spot InstanceTypes %d (must be <= 19 in addition to the primary instance type)", len(sp.InstanceTypes))
+	}
+	if sp.OnDemandBaseCapacity < 0 {
+		return fmt.Errorf("unexpected Spot OnDemandBaseCapacity %d", sp.OnDemandBaseCapacity)
+	}
+	if sp.OnDemandPercentageAboveBaseCapacity < 0 || sp.OnDemandPercentageAboveBaseCapacity > 100 {
+		return fmt.Errorf("unexpected Spot OnDemandPercentageAboveBaseCapacity %d (must be 0-100)", sp.OnDemandPercentageAboveBaseCapacity)
+	}
+	if sp.InstancePools == 0 {
+		sp.InstancePools = 2
+	}
+	if sp.InstancePools < 1 || sp.InstancePools > 20 {
+		return fmt.Errorf("unexpected Spot InstancePools %d (must be 1-20)", sp.InstancePools)
+	}
+	if sp.MaxPrice != "" {
+		if f, err := strconv.ParseFloat(sp.MaxPrice, 64); err != nil || f <= 0 {
+			return fmt.Errorf("unexpected Spot MaxPrice %q", sp.MaxPrice)
+		}
+	}
+	return nil
 }
 
 // Instance represents an EC2 instance.
@@ -166,6 +324,7 @@ type Instance struct {
 	PrivateIP           string               `json:"private-ip"`
 	PublicDNSName       string               `json:"public-dns-name"`
 	PublicIP            string               `json:"public-ip"`
+	IPv6Addresses       []string             `json:"ipv6-addresses"`
 	State               State                `json:"state"`
 	SubnetID            string               `json:"subnet-id"`
 	VPCID               string               `json:"vpc-id"`
@@ -175,6 +334,23 @@ type Instance struct {
 	RootDeviceType      string               `json:"root-device-type"`
 	SecurityGroups      []SecurityGroup      `json:"security-groups"`
 	LaunchTime          time.Time            `json:"launch-time"`
+
+	// InstanceLifecycle is "spot" for Spot instances, empty for On-Demand instances.
+	InstanceLifecycle     string `json:"instance-lifecycle,omitempty"`
+	SpotInstanceRequestID string `json:"spot-instance-request-id,omitempty"`
+	// SpotInterruption is the Spot interruption notice, if any.
+	SpotInterruption *SpotInterruption `json:"spot-interruption,omitempty"`
+}
+
+// SpotInterruption defines a Spot interruption notice.
+// https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/spot-bid-status.html
+type SpotInterruption struct {
+	// Code is the Spot request status code (e.g. "marked-for-termination").
+	Code       string    `json:"code"`
+	Message    string    `json:"message"`
+	UpdateTime time.Time `json:"update-time"`
+	// Replaced is true once the instance has been terminated for replacement.
+	Replaced bool `json:"replaced"`
 }
 
 // Placement defines EC2 placement.
@@ -241,6 +417,7 @@ var defaultConfig = Config{
 	LogOutputs:             []string{"stderr"},
 	UploadTesterLogs:       false,
 	UploadBucketExpireDays: 2,
+	ArtifactStore:          "s3",
 
 	// TODO: use Amazon EKS-optimized AMI, https://docs.aws.amazon.com/eks/latest/userguide/eks-optimized-ami.html
 
@@ -381,7 +558,7 @@ func (cfg *Config) UpdateFromEnvs() error {
 	}
 	*cfg = cc
 
-	return nil
+	return envutil.UpdateFromEnvs(cfg.EnvPrefix+"SPOT_", &cfg.Spot)
 }
 
 // ValidateAndSetDefaults returns an error for invalid configurations.
@@ -394,16 +571,37 @@ func (cfg *Config) ValidateAndSetDefaults() (err error) {
 	if cfg.AWSRegion == "" {
 		return errors.New("empty AWSRegion")
 	}
+	if cfg.OSProfile == "" {
+		cfg.OSProfile = osprofile.Infer(cfg.UserName)
+	}
+	p, err := osprofile.Get(cfg.OSProfile)
+	if err != nil {
+		return err
+	}
+	if cfg.UserName == "" {
+		cfg.UserName = p.UserName
+	}
 	if cfg.UserName == "" {
 		return errors.New("empty UserName")
 	}
-	if cfg.ImageID == "" {
-		return errors.New("empty ImageID")
+	if cfg.InstanceType == "" {
+		return errors.New("empty InstanceType")
+	}
+	iv, ok := ec2types.InstanceTypes[cfg.InstanceType]
+	if !ok {
+		return fmt.Errorf("unexpected InstanceType %q", cfg.InstanceType)
+	}
+	cfg.Architecture = iv.Architecture
+	if (cfg.Architecture != "x86_64" || p.Name != osprofile.AmazonLinux2) && cfg.ImageID == defaultConfig.ImageID {
+		cfg.ImageID = ""
+	}
+	if cfg.ImageID == "" && (p.ImageOwner == "" || p.ImageNames[cfg.Architecture] == "") {
+		return fmt.Errorf("empty ImageID for OS profile %q with architecture %q", p.Name, cfg.Architecture)
 	}
 
 	if len(cfg.Plugins) > 0 && !cfg.InitScriptCreated {
 		txt := cfg.InitScript
-		cfg.InitScript, err = plugins.Create(cfg.UserName, cfg.CustomScript, cfg.Plugins)
+		cfg.InitScript, err = plugins.Create(p, cfg.UserName, ec2types.GoArch(cfg.InstanceType), cfg.CustomScript, cfg.Plugins)
 		if err != nil {
 			return err
 		}
@@ -411,9 +609,6 @@ func (cfg *Config) ValidateAndSetDefaults() (err error) {
 		cfg.InitScriptCreated = true
 	}
 
-	if cfg.InstanceType == "" {
-		return errors.New("empty InstanceType")
-	}
 	if cfg.ClusterSize < 1 {
 		return errors.New("unexpected ClusterSize")
 	}
@@ -449,6 +644,22 @@ func (cfg *Config) ValidateAndSetDefaults() (err error) {
 	}
 	cfg.LogOutputToUploadPathBucket = filepath.Join(cfg.ClusterName, "a8-ec2.log")
 
+	switch cfg.ArtifactStore {
+	case "s3":
+	case "local":
+		if cfg.ArtifactStoreDir == "" {
+			cfg.ArtifactStoreDir = filepath.Join(os.TempDir(), cfg.ClusterName+"-artifacts")
+		}
+	case "http":
+		if cfg.ArtifactStoreURL == "" {
+			return errors.New("'http' artifact store requires ArtifactStoreURL")
+		}
+	default:
+		return fmt.Errorf("artifact store %q is not supported", cfg.ArtifactStore)
+	}
+	cfg.ArtifactManifestPath = cfg.ConfigPath + ".artifacts.json"
+	cfg.ArtifactManifestPathBucket = filepath.Join(cfg.ClusterName, "artifacts.json")
+
 	if cfg.KeyName == "" {
 		cfg.KeyName = cfg.ClusterName
 	}
@@ -464,13 +675,65 @@ func (cfg *Config) ValidateAndSetDefaults() (err error) {
 		os.RemoveAll(cfg.KeyPath)
 	}
 
-	if _, ok := ec2types.InstanceTypes[cfg.InstanceType]; !ok {
-		return fmt.Errorf("unexpected InstanceType %q", cfg.InstanceType)
+	if err = cfg.Spot.ValidateAndSetDefaults(cfg.InstanceType); err != nil {
+		return err
+	}
+	if cfg.Spot.Enable && !cfg.UseCloudFormation {
+		return errors.New("Spot requires UseCloudFormation")
+	}
+
+	if cfg.UseCloudFormation {
+		if cfg.CFStackName == "" {
+			cfg.CFStackName = cfg.ClusterName + "-stack"
+		}
+		// user data is embedded in the launch template
+		// https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/user-data.html
+		if n := base64.StdEncoding.EncodedLen(len(cfg.InitScript)); n > 16*1024 {
+			return fmt.Errorf("InitScript is too large for launch template user data (%d bytes base64-encoded, must be <= 16 KB)", n)
+		}
 	}
 
 	return nil
 }
 
+// Profile returns the OS profile of the configuration.
+// It falls back to the profile inferred from "UserName" if "OSProfile" is invalid.
+func (cfg *Config) Profile() *osprofile.Profile {
+	p, err := osprofile.Get(cfg.OSProfile)
+	if err != nil {
+		p, _ = osprofile.Get(osprofile.Infer(cfg.UserName))
+	}
+	return p
+}
+
+// HasPlugin returns true if the plugins include the OS-independent
+// plugin name without version (e.g. "install-kubeadm").
+func (cfg *Config) HasPlugin(key string) bool {
+	for _, v := range cfg.Plugins {
+		if plugins.Key(v) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidatePlugins returns an error if the plugins do not update the OS,
+// install the container runtime, or install Kubernetes with the "install"
+// plugin (e.g. "install-kubeadm"), as required by the OS profile.
+func (cfg *Config) ValidatePlugins(rt *osprofile.ContainerRuntime, install string) error {
+	p := cfg.Profile()
+	if p.UpdateScript != "" && !cfg.HasPlugin("update") {
+		return fmt.Errorf("Plugin 'update' not found for OS profile %q", p.Name)
+	}
+	if !p.ContainerRuntimePreinstalled && !cfg.HasPlugin(rt.Plugin) {
+		return fmt.Errorf("Plugin %q not found for container runtime %q", rt.Plugin, rt.Name)
+	}
+	if !cfg.HasPlugin(install) {
+		return fmt.Errorf("Plugin %q not found", install)
+	}
+	return nil
+}
+
 // Load loads configuration from YAML.
 //
 // Example usage:
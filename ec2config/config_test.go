@@ -2,9 +2,13 @@ package ec2config
 
 import (
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/aws/aws-k8s-tester/ec2config/osprofile"
 )
 
 func TestEnv(t *testing.T) {
@@ -18,6 +22,8 @@ func TestEnv(t *testing.T) {
 	os.Setenv("AWS_K8S_TESTER_EC2_LOG_DEBUG", "false")
 	os.Setenv("AWS_K8S_TESTER_EC2_UPLOAD_AWS_TESTER_LOGS", "false")
 	os.Setenv("AWS_K8S_TESTER_EC2_UPLOAD_BUCKET_EXPIRE_DAYS", "3")
+	os.Setenv("AWS_K8S_TESTER_EC2_ARTIFACT_STORE", "local")
+	os.Setenv("AWS_K8S_TESTER_EC2_ARTIFACT_STORE_DIR", "/tmp/artifacts")
 	os.Setenv("AWS_K8S_TESTER_EC2_VPC_ID", "aaa")
 	os.Setenv("AWS_K8S_TESTER_EC2_PLUGINS", "update-amazon-linux-2,install-go-1.11.3")
 	os.Setenv("AWS_K8S_TESTER_EC2_INSTANCE_TYPE", "m5d.2xlarge")
@@ -31,6 +37,12 @@ func TestEnv(t *testing.T) {
 	os.Setenv("AWS_K8S_TESTER_EC2_TAGS", "kubernetes.io/cluster/a8-ec2-190222-9dxccww=owned")
 	os.Setenv("AWS_K8S_TESTER_EC2_INGRESS_RULES_TCP", "22=0.0.0.0/0,2379-2380=192.168.0.0/8")
 	os.Setenv("AWS_K8S_TESTER_EC2_VPC_CIDR", "192.168.0.0/8")
+	os.Setenv("AWS_K8S_TESTER_EC2_ENABLE_IPV6", "true")
+	os.Setenv("AWS_K8S_TESTER_EC2_USE_CLOUDFORMATION", "true")
+	os.Setenv("AWS_K8S_TESTER_EC2_SPOT_ENABLE", "true")
+	os.Setenv("AWS_K8S_TESTER_EC2_SPOT_INSTANCE_TYPES", "m5d.xlarge,c5.2xlarge")
+	os.Setenv("AWS_K8S_TESTER_EC2_SPOT_ON_DEMAND_BASE_CAPACITY", "1")
+	os.Setenv("AWS_K8S_TESTER_EC2_SPOT_MAX_PRICE", "0.1")
 	os.Setenv("AWS_K8S_TESTER_EC2_INSTANCE_PROFILE_NAME", "aws-k8s-tester-ec2")
 
 	defer func() {
@@ -42,6 +54,8 @@ func TestEnv(t *testing.T) {
 		os.Unsetenv("AWS_K8S_TESTER_EC2_LOG_DEBUG")
 		os.Unsetenv("AWS_K8S_TESTER_EC2_UPLOAD_AWS_TESTER_LOGS")
 		os.Unsetenv("AWS_K8S_TESTER_EC2_UPLOAD_BUCKET_EXPIRE_DAYS")
+		os.Unsetenv("AWS_K8S_TESTER_EC2_ARTIFACT_STORE")
+		os.Unsetenv("AWS_K8S_TESTER_EC2_ARTIFACT_STORE_DIR")
 		os.Unsetenv("AWS_K8S_TESTER_EC2_VPC_ID")
 		os.Unsetenv("AWS_K8S_TESTER_EC2_PLUGINS")
 		os.Unsetenv("AWS_K8S_TESTER_EC2_INSTANCE_TYPE")
@@ -55,6 +69,12 @@ func TestEnv(t *testing.T) {
 		os.Unsetenv("AWS_K8S_TESTER_EC2_TAGS")
 		os.Unsetenv("AWS_K8S_TESTER_EC2_INGRESS_RULES_TCP")
 		os.Unsetenv("AWS_K8S_TESTER_EC2_VPC_CIDR")
+		os.Unsetenv("AWS_K8S_TESTER_EC2_ENABLE_IPV6")
+		os.Unsetenv("AWS_K8S_TESTER_EC2_USE_CLOUDFORMATION")
+		os.Unsetenv("AWS_K8S_TESTER_EC2_SPOT_ENABLE")
+		os.Unsetenv("AWS_K8S_TESTER_EC2_SPOT_INSTANCE_TYPES")
+		os.Unsetenv("AWS_K8S_TESTER_EC2_SPOT_ON_DEMAND_BASE_CAPACITY")
+		os.Unsetenv("AWS_K8S_TESTER_EC2_SPOT_MAX_PRICE")
 		os.Unsetenv("AWS_K8S_TESTER_EC2_INSTANCE_PROFILE_NAME")
 	}()
 
@@ -86,6 +106,12 @@ func TestEnv(t *testing.T) {
 	if cfg.UploadBucketExpireDays != 3 {
 		t.Fatalf("UploadBucketExpireDays expected 3, got %d", cfg.UploadBucketExpireDays)
 	}
+	if cfg.ArtifactStore != "local" {
+		t.Fatalf("ArtifactStore expected \"local\", got %q", cfg.ArtifactStore)
+	}
+	if cfg.ArtifactStoreDir != "/tmp/artifacts" {
+		t.Fatalf("ArtifactStoreDir expected \"/tmp/artifacts\", got %q", cfg.ArtifactStoreDir)
+	}
 	if cfg.VPCID != "aaa" {
 		t.Fatalf("VPCID unexpected %q", cfg.VPCID)
 	}
@@ -132,7 +158,152 @@ func TestEnv(t *testing.T) {
 	if cfg.VPCCIDR != "192.168.0.0/8" {
 		t.Fatalf("VPCCIDR expected '192.168.0.0/8', got %q", cfg.VPCCIDR)
 	}
+	if !cfg.EnableIPv6 {
+		t.Fatalf("EnableIPv6 expected true, got %v", cfg.EnableIPv6)
+	}
+	if !cfg.UseCloudFormation {
+		t.Fatalf("UseCloudFormation expected true, got %v", cfg.UseCloudFormation)
+	}
+	expSpot := Spot{
+		Enable:               true,
+		InstanceTypes:        []string{"m5d.xlarge", "c5.2xlarge"},
+		OnDemandBaseCapacity: 1,
+		MaxPrice:             "0.1",
+	}
+	if !reflect.DeepEqual(cfg.Spot, expSpot) {
+		t.Fatalf("Spot expected %+v, got %+v", expSpot, cfg.Spot)
+	}
 	if cfg.InstanceProfileName != "aws-k8s-tester-ec2" {
 		t.Fatalf("InstanceProfileName expected 'aws-k8s-tester-ec2', got %q", cfg.InstanceProfileName)
 	}
 }
+
+func TestSpot(t *testing.T) {
+	tests := []struct {
+		spot  Spot
+		valid bool
+	}{
+		{Spot{}, true},
+		{Spot{Enable: true}, true},
+		{Spot{Enable: true, InstanceTypes: []string{"m5.xlarge", "c5.large"}, OnDemandPercentageAboveBaseCapacity: 50, MaxPrice: "0.05"}, true},
+		{Spot{Enable: true, InstanceTypes: []string{"m5.large"}}, false},
+		{Spot{Enable: true, InstanceTypes: []string{"c5.large", "c5.large"}}, false},
+		{Spot{Enable: true, InstanceTypes: []string{"unknown"}}, false},
+		{Spot{Enable: true, InstanceTypes: []string{"a1.large"}}, false},
+		{Spot{Enable: true, OnDemandBaseCapacity: -1}, false},
+		{Spot{Enable: true, OnDemandPercentageAboveBaseCapacity: 101}, false},
+		{Spot{Enable: true, InstancePools: 21}, false},
+		{Spot{Enable: true, MaxPrice: "free"}, false},
+	}
+	for i, tt := range tests {
+		sp := tt.spot
+		err := sp.ValidateAndSetDefaults("m5.large")
+		if (err == nil) != tt.valid {
+			t.Fatalf("#%d: expected valid %v, got %v", i, tt.valid, err)
+		}
+		if err == nil && sp.Enable && sp.InstancePools != 2 {
+			t.Fatalf("#%d: expected default InstancePools 2, got %d", i, sp.InstancePools)
+		}
+	}
+}
+
+func TestArchitecture(t *testing.T) {
+	cfg := NewDefault()
+	cfg.InstanceType = "a1.large"
+	cfg.Plugins = []string{"update-amazon-linux-2", "install-go-1.11.4"}
+	cfg.ConfigPath = filepath.Join(os.TempDir(), randString(10)+".yaml")
+	defer os.RemoveAll(cfg.ConfigPath)
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Architecture != "arm64" {
+		t.Fatalf("unexpected Architecture %q", cfg.Architecture)
+	}
+	if cfg.ImageID != "" {
+		t.Fatalf("expected empty ImageID to look up arm64 AMI, got %q", cfg.ImageID)
+	}
+	if !strings.Contains(cfg.InitScript, "linux-arm64.tar.gz") {
+		t.Fatalf("expected arm64 Go download in %q", cfg.InitScript)
+	}
+}
+
+func TestOSProfile(t *testing.T) {
+	cfg := NewDefault()
+	cfg.UserName = ""
+	cfg.OSProfile = "ubuntu"
+	cfg.Plugins = []string{"update", "install-start-docker", "install-kubeadm-1.13.1"}
+	cfg.ConfigPath = filepath.Join(os.TempDir(), randString(10)+".yaml")
+	defer os.RemoveAll(cfg.ConfigPath)
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.UserName != "ubuntu" {
+		t.Fatalf("unexpected UserName %q", cfg.UserName)
+	}
+	if cfg.ImageID != "" {
+		t.Fatalf("expected empty ImageID to look up Ubuntu AMI, got %q", cfg.ImageID)
+	}
+	if !strings.Contains(cfg.InitScript, "sudo apt-get install -y cri-tools") {
+		t.Fatalf("expected apt install in %q", cfg.InitScript)
+	}
+	if cfg.Profile().PackageManager != "apt" {
+		t.Fatalf("unexpected profile %+v", cfg.Profile())
+	}
+	if !cfg.HasPlugin("install-kubeadm") || cfg.HasPlugin("install-kubernetes") {
+		t.Fatalf("unexpected plugins %v", cfg.Plugins)
+	}
+
+	cfg = NewDefault()
+	cfg.UserName = "ubuntu"
+	cfg.Plugins = []string{"update-ubuntu"}
+	cfg.ConfigPath = filepath.Join(os.TempDir(), randString(10)+".yaml")
+	defer os.RemoveAll(cfg.ConfigPath)
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.OSProfile != "ubuntu" {
+		t.Fatalf("expected inferred OSProfile 'ubuntu', got %q", cfg.OSProfile)
+	}
+
+	cfg = NewDefault()
+	cfg.UserName = ""
+	cfg.OSProfile = "custom"
+	cfg.Plugins = nil
+	cfg.ImageID = "ami-123"
+	if err := cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for empty UserName in custom OS profile")
+	}
+
+	cfg = NewDefault()
+	cfg.OSProfile = "container-optimized"
+	cfg.Plugins = nil
+	if err := cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for empty ImageID in container-optimized OS profile")
+	}
+}
+
+func TestValidatePlugins(t *testing.T) {
+	rt, err := osprofile.GetContainerRuntime(osprofile.Containerd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := NewDefault()
+	cfg.Plugins = []string{"update", "install-start-containerd", "install-kubeadm-1.13.1"}
+	if err = cfg.ValidatePlugins(rt, "install-kubeadm"); err != nil {
+		t.Fatal(err)
+	}
+	if err = cfg.ValidatePlugins(rt, "install-kubernetes"); err == nil {
+		t.Fatal("expected error for missing 'install-kubernetes' plugin")
+	}
+
+	cfg.Plugins = []string{"update", "install-start-docker", "install-kubeadm-1.13.1"}
+	if err = cfg.ValidatePlugins(rt, "install-kubeadm"); err == nil {
+		t.Fatal("expected error for missing 'install-start-containerd' plugin")
+	}
+
+	cfg.Plugins = []string{"install-start-containerd", "install-kubeadm-1.13.1"}
+	if err = cfg.ValidatePlugins(rt, "install-kubeadm"); err == nil {
+		t.Fatal("expected error for missing 'update' plugin")
+	}
+}
@@ -0,0 +1,209 @@
+// Package osprofile defines operating system profiles of EC2 images,
+// so that plugins and deployers select OS specific commands and paths
+// from one place.
+package osprofile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	ec2types "github.com/aws/aws-k8s-tester/pkg/awsapi/ec2"
+)
+
+const (
+	// AmazonLinux2 is the Amazon Linux 2 profile.
+	AmazonLinux2 = "amazon-linux-2"
+	// Ubuntu is the Ubuntu profile.
+	Ubuntu = "ubuntu"
+	// ContainerOptimized is the profile of minimal, container-optimized
+	// images (e.g. Bottlerocket) that ship with a container runtime and
+	// Kubernetes dependencies, and are updated by replacing the image.
+	ContainerOptimized = "container-optimized"
+	// Custom is the profile of custom images, where init scripts must be
+	// provided by "CustomScript" and the user name must be specified.
+	Custom = "custom"
+)
+
+// Profile defines OS specific commands and paths.
+type Profile struct {
+	// Name is the profile name.
+	Name string
+	// UserName is the default user name for SSH access.
+	UserName string
+	// PackageManager is either "yum" or "apt".
+	// Empty if packages cannot be installed.
+	PackageManager string
+
+	// UpdateScript updates the OS and installs common tools.
+	// Empty if not supported.
+	UpdateScript string
+	// ContainerRuntimeScripts maps a container runtime name to the script
+	// that installs and starts it. Empty if the container runtime is
+	// preinstalled or not supported.
+	ContainerRuntimeScripts map[string]string
+	// ContainerRuntimePreinstalled is true if the image ships with a container runtime.
+	ContainerRuntimePreinstalled bool
+
+	// SystemdUnitDir is the directory to write systemd unit files.
+	SystemdUnitDir string
+	// InitLogPath is the output log file of init scripts (EC2 user data).
+	InitLogPath string
+	// LogPaths is the list of OS log files to fetch, in addition to systemd journal.
+	LogPaths []string
+
+	// ImageOwner is the owner of the images to look up, when image ID is not specified.
+	// Empty if the image ID must be specified.
+	ImageOwner string
+	// ImageNames maps an architecture ("x86_64" or "arm64") to the image name pattern.
+	ImageNames map[string]string
+}
+
+var profiles = map[string]Profile{
+	AmazonLinux2: {
+		Name:           AmazonLinux2,
+		UserName:       "ec2-user",
+		PackageManager: "yum",
+		UpdateScript:   updateAmazonLinux2,
+		ContainerRuntimeScripts: map[string]string{
+			Docker:     installStartDockerAmazonLinux2,
+			Containerd: installStartContainerdAmazonLinux2,
+			CRIO:       installStartCRIOAmazonLinux2,
+		},
+		SystemdUnitDir: "/etc/systemd/system",
+		InitLogPath:    "/var/log/cloud-init-output.log",
+		LogPaths:       []string{"/var/log/cloud-init-output.log", "/var/log/messages"},
+		ImageOwner:     ec2types.AmazonOwner,
+		ImageNames: map[string]string{
+			"x86_64": ec2types.AmazonLinux2ImageName("x86_64"),
+			"arm64":  ec2types.AmazonLinux2ImageName("arm64"),
+		},
+	},
+	Ubuntu: {
+		Name:           Ubuntu,
+		UserName:       "ubuntu",
+		PackageManager: "apt",
+		UpdateScript:   updateUbuntu,
+		ContainerRuntimeScripts: map[string]string{
+			Docker:     installStartDockerUbuntu,
+			Containerd: installStartContainerdUbuntu,
+			CRIO:       installStartCRIOUbuntu,
+		},
+		SystemdUnitDir: "/etc/systemd/system",
+		InitLogPath:    "/var/log/cloud-init-output.log",
+		LogPaths:       []string{"/var/log/cloud-init-output.log", "/var/log/syslog"},
+		// Canonical
+		ImageOwner: "099720109477",
+		ImageNames: map[string]string{
+			"x86_64": "ubuntu/images/hvm-ssd/ubuntu-bionic-18.04-amd64-server-*",
+			"arm64":  "ubuntu/images/hvm-ssd/ubuntu-bionic-18.04-arm64-server-*",
+		},
+	},
+	ContainerOptimized: {
+		Name:                         ContainerOptimized,
+		UserName:                     "ec2-user",
+		ContainerRuntimePreinstalled: true,
+		SystemdUnitDir:               "/etc/systemd/system",
+		InitLogPath:                  "/var/log/cloud-init-output.log",
+	},
+	Custom: {
+		Name:           Custom,
+		SystemdUnitDir: "/etc/systemd/system",
+		InitLogPath:    "/var/log/cloud-init-output.log",
+		LogPaths:       []string{"/var/log/cloud-init-output.log"},
+	},
+}
+
+// Get returns a copy of the profile.
+func Get(name string) (*Profile, error) {
+	p, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown OS profile %q (must be one of %s)", name, strings.Join(Names(), ", "))
+	}
+	return &p, nil
+}
+
+// Names returns all profile names in order.
+func Names() (ss []string) {
+	for k := range profiles {
+		ss = append(ss, k)
+	}
+	sort.Strings(ss)
+	return ss
+}
+
+// Infer returns the profile name for configurations without one,
+// based on the user name.
+func Infer(userName string) string {
+	if userName == "ubuntu" {
+		return Ubuntu
+	}
+	return AmazonLinux2
+}
+
+// KubernetesDependencies returns the script to install Kubernetes node
+// dependencies (e.g. "kubernetes-cni") from the upstream package repository,
+// with the architecture "amd64" or "arm64".
+// https://kubernetes.io/docs/setup/independent/install-kubeadm/
+func (p *Profile) KubernetesDependencies(arch string) (string, error) {
+	switch p.PackageManager {
+	case "yum":
+		repoArch := "x86_64"
+		if arch == "arm64" {
+			repoArch = "aarch64"
+		}
+		return fmt.Sprintf(kubernetesDependenciesYum, repoArch), nil
+	case "apt":
+		return kubernetesDependenciesApt, nil
+	}
+	if p.ContainerRuntimePreinstalled {
+		return "\n# Kubernetes dependencies are preinstalled in the image\n", nil
+	}
+	return "", fmt.Errorf("OS profile %q does not support installing Kubernetes dependencies", p.Name)
+}
+
+const kubernetesDependenciesYum = `
+cat <<EOF > /tmp/kubernetes.repo
+[kubernetes]
+name=Kubernetes
+baseurl=https://packages.cloud.google.com/yum/repos/kubernetes-el7-%s
+enabled=1
+gpgcheck=1
+repo_gpgcheck=0
+gpgkey=https://packages.cloud.google.com/yum/doc/yum-key.gpg https://packages.cloud.google.com/yum/doc/rpm-package-key.gpg
+exclude=kube*
+EOF
+sudo cp /tmp/kubernetes.repo /etc/yum.repos.d/kubernetes.repo
+
+cat <<EOF > /tmp/k8s.conf
+net.bridge.bridge-nf-call-ip6tables = 1
+net.bridge.bridge-nf-call-iptables = 1
+EOF
+sudo cp /tmp/k8s.conf /etc/sysctl.d/k8s.conf
+
+sudo sysctl --system
+sudo sysctl net.bridge.bridge-nf-call-iptables=1
+
+# Set SELinux in permissive mode (effectively disabling it)
+setenforce 0
+sudo sed -i 's/^SELINUX=enforcing$/SELINUX=permissive/' /etc/selinux/config
+
+sudo yum install -y cri-tools ebtables kubernetes-cni socat iproute-tc
+`
+
+const kubernetesDependenciesApt = `
+curl -s https://packages.cloud.google.com/apt/doc/apt-key.gpg | sudo apt-key add -
+echo "deb https://apt.kubernetes.io/ kubernetes-xenial main" | sudo tee /etc/apt/sources.list.d/kubernetes.list
+
+cat <<EOF > /tmp/k8s.conf
+net.bridge.bridge-nf-call-ip6tables = 1
+net.bridge.bridge-nf-call-iptables = 1
+EOF
+sudo cp /tmp/k8s.conf /etc/sysctl.d/k8s.conf
+
+sudo sysctl --system
+sudo sysctl net.bridge.bridge-nf-call-iptables=1
+
+sudo apt-get update -y
+sudo apt-get install -y cri-tools ebtables kubernetes-cni socat iproute2
+`
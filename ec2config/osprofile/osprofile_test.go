@@ -0,0 +1,126 @@
+package osprofile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	for _, name := range Names() {
+		p, err := Get(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Name != name {
+			t.Fatalf("expected %q, got %q", name, p.Name)
+		}
+		if p.SystemdUnitDir == "" || p.InitLogPath == "" {
+			t.Fatalf("%q expected systemd and init log paths, got %+v", name, p)
+		}
+	}
+	if _, err := Get("windows"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// returns a copy
+	p, _ := Get(AmazonLinux2)
+	p.UserName = "root"
+	if p, _ = Get(AmazonLinux2); p.UserName != "ec2-user" {
+		t.Fatalf("unexpected UserName %q", p.UserName)
+	}
+}
+
+func TestInfer(t *testing.T) {
+	tests := map[string]string{
+		"ubuntu":   Ubuntu,
+		"ec2-user": AmazonLinux2,
+		"":         AmazonLinux2,
+	}
+	for userName, exp := range tests {
+		if name := Infer(userName); name != exp {
+			t.Fatalf("%q expected %q, got %q", userName, exp, name)
+		}
+	}
+}
+
+func TestKubernetesDependencies(t *testing.T) {
+	tests := []struct {
+		name  string
+		arch  string
+		exp   string
+		valid bool
+	}{
+		{AmazonLinux2, "amd64", "kubernetes-el7-x86_64", true},
+		{AmazonLinux2, "arm64", "kubernetes-el7-aarch64", true},
+		{Ubuntu, "amd64", "sudo apt-get install -y cri-tools", true},
+		{ContainerOptimized, "amd64", "preinstalled", true},
+		{Custom, "amd64", "", false},
+	}
+	for i, tt := range tests {
+		p, err := Get(tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := p.KubernetesDependencies(tt.arch)
+		if (err == nil) != tt.valid {
+			t.Fatalf("#%d: expected valid %v, got %v", i, tt.valid, err)
+		}
+		if !strings.Contains(s, tt.exp) {
+			t.Fatalf("#%d: expected %q in %q", i, tt.exp, s)
+		}
+	}
+}
+
+func TestContainerRuntime(t *testing.T) {
+	for _, name := range ContainerRuntimeNames() {
+		rt, err := GetContainerRuntime(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, ok := FindContainerRuntime(rt.Plugin)
+		if !ok || v.Name != name {
+			t.Fatalf("%q expected plugin %q, got %+v", name, rt.Plugin, v)
+		}
+		if (rt.Endpoint == "") != (name == Docker) {
+			t.Fatalf("%q unexpected endpoint %q", name, rt.Endpoint)
+		}
+		for _, pn := range []string{AmazonLinux2, Ubuntu} {
+			p, err := Get(pn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = p.InstallContainerRuntime(name, "1.17.3"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if _, err := GetContainerRuntime("rkt"); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, ok := FindContainerRuntime("install-wrk"); ok {
+		t.Fatal("unexpected container runtime plugin")
+	}
+
+	p, err := Get(ContainerOptimized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.InstallContainerRuntime(Containerd, ""); err == nil {
+		t.Fatal("expected error for preinstalled container runtime")
+	}
+
+	p, err = Get(Ubuntu)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := p.InstallContainerRuntime(CRIO, "v1.18.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(s, "CRIO_VERSION=1.18\n") {
+		t.Fatalf("expected CRI-O version 1.18 in %q", s)
+	}
+	if _, err = p.InstallContainerRuntime(CRIO, ""); err == nil {
+		t.Fatal("expected error for CRI-O without Kubernetes version")
+	}
+}
@@ -0,0 +1,121 @@
+package osprofile
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/blang/semver"
+)
+
+const (
+	// Docker is the Docker container runtime, with the kubelet built-in dockershim.
+	Docker = "docker"
+	// Containerd is the containerd container runtime, with its CRI plugin.
+	Containerd = "containerd"
+	// CRIO is the CRI-O container runtime.
+	CRIO = "cri-o"
+)
+
+// ContainerRuntime defines container runtime specific plugins,
+// kubelet CRI endpoints, and services.
+type ContainerRuntime struct {
+	// Name is the container runtime name.
+	Name string
+	// Plugin is the EC2 plugin to install and start the container runtime.
+	Plugin string
+	// Service is the systemd service name of the container runtime.
+	Service string
+	// Endpoint is the CRI socket for "kubelet --container-runtime-endpoint"
+	// and "kubeadm --cri-socket". Empty for the kubelet built-in dockershim.
+	Endpoint string
+	// CgroupDriver is the cgroup driver that the install script configures
+	// the container runtime with, and kubelet must match.
+	// Empty to keep the kubelet default.
+	CgroupDriver string
+	// ListCommand lists running containers on the host.
+	ListCommand string
+}
+
+var containerRuntimes = map[string]ContainerRuntime{
+	Docker: {
+		Name:        Docker,
+		Plugin:      "install-start-docker",
+		Service:     "docker",
+		ListCommand: "sudo docker ps",
+	},
+	Containerd: {
+		Name:         Containerd,
+		Plugin:       "install-start-containerd",
+		Service:      "containerd",
+		Endpoint:     "unix:///run/containerd/containerd.sock",
+		CgroupDriver: "systemd",
+		ListCommand:  "sudo crictl --runtime-endpoint unix:///run/containerd/containerd.sock ps",
+	},
+	CRIO: {
+		Name:         CRIO,
+		Plugin:       "install-start-cri-o",
+		Service:      "crio",
+		Endpoint:     "unix:///var/run/crio/crio.sock",
+		CgroupDriver: "systemd",
+		ListCommand:  "sudo crictl --runtime-endpoint unix:///var/run/crio/crio.sock ps",
+	},
+}
+
+// GetContainerRuntime returns a copy of the container runtime.
+func GetContainerRuntime(name string) (*ContainerRuntime, error) {
+	rt, ok := containerRuntimes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown container runtime %q (must be one of %s)", name, strings.Join(ContainerRuntimeNames(), ", "))
+	}
+	return &rt, nil
+}
+
+// ContainerRuntimeNames returns all container runtime names in order.
+func ContainerRuntimeNames() (ss []string) {
+	for k := range containerRuntimes {
+		ss = append(ss, k)
+	}
+	sort.Strings(ss)
+	return ss
+}
+
+// FindContainerRuntime returns the container runtime installed by
+// the OS-independent plugin name (e.g. "install-start-containerd").
+func FindContainerRuntime(plugin string) (*ContainerRuntime, bool) {
+	for _, rt := range containerRuntimes {
+		if rt.Plugin == plugin {
+			return &rt, true
+		}
+	}
+	return nil, false
+}
+
+// InstallContainerRuntime returns the script to install and start the container runtime.
+// The Kubernetes version (e.g. "1.17.3") is required for CRI-O,
+// which is released with the same major and minor versions.
+func (p *Profile) InstallContainerRuntime(name, kubernetesVersion string) (string, error) {
+	if _, err := GetContainerRuntime(name); err != nil {
+		return "", err
+	}
+	s, ok := p.ContainerRuntimeScripts[name]
+	if !ok {
+		return "", fmt.Errorf("OS profile %q does not support installing container runtime %q", p.Name, name)
+	}
+	if name != CRIO {
+		return s, nil
+	}
+
+	ver, err := semver.ParseTolerant(kubernetesVersion)
+	if err != nil {
+		return "", fmt.Errorf("container runtime %q requires Kubernetes version, got %q (%v)", name, kubernetesVersion, err)
+	}
+	tpl := template.Must(template.New(name).Parse(s))
+	buf := bytes.NewBuffer(nil)
+	if err = tpl.Execute(buf, struct{ Version string }{fmt.Sprintf("%d.%d", ver.Major, ver.Minor)}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
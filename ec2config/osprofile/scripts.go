@@ -0,0 +1,312 @@
+package osprofile
+
+// https://github.com/awslabs/amazon-eks-ami/blob/master/install-worker.sh
+const updateAmazonLinux2 = `
+
+################################## update Amazon Linux 2
+
+export HOME=/home/ec2-user
+export GOPATH=/home/ec2-user/go
+
+sudo yum update -y \
+  && sudo yum install -y \
+  gcc \
+  zlib-devel \
+  openssl-devel \
+  ncurses-devel \
+  git \
+  wget \
+  jq \
+  tar \
+  curl \
+  unzip \
+  screen \
+  mercurial \
+  aws-cfn-bootstrap \
+  awscli \
+  chrony \
+  conntrack \
+  nfs-utils \
+  socat
+
+# Make sure Amazon Time Sync Service starts on boot.
+sudo chkconfig chronyd on
+
+# Make sure that chronyd syncs RTC clock to the kernel.
+cat <<EOF | sudo tee -a /etc/chrony.conf
+# This directive enables kernel synchronisation (every 11 minutes) of the
+# real-time clock. Note that it can’t be used along with the 'rtcfile' directive.
+rtcsync
+EOF
+
+
+sudo mkdir -p /home/ec2-user/.aws/
+sudo chown -R ec2-user:ec2-user /home/ec2-user/.aws
+chown -R $(id -u):$(id -g) /home/ec2-user/.aws
+
+
+################################################################################
+### iptables ###################################################################
+################################################################################
+
+# Enable forwarding via iptables
+sudo bash -c "/sbin/iptables-save > /etc/sysconfig/iptables"
+
+sudo mv $TEMPLATE_DIR/iptables-restore.service /etc/systemd/system/iptables-restore.service
+
+sudo systemctl daemon-reload
+sudo systemctl enable iptables-restore
+
+`
+const updateUbuntu = `
+
+################################## update Ubuntu
+
+export HOME=/home/ubuntu
+export GOPATH=/home/ubuntu/go
+
+apt-get -y update \
+  && apt-get -y install \
+  build-essential \
+  gcc \
+  jq \
+  file \
+  apt-utils \
+  pkg-config \
+  software-properties-common \
+  apt-transport-https \
+  ca-certificates \
+  libssl-dev \
+  gnupg2 \
+  sudo \
+  bash \
+  curl \
+  wget \
+  tar \
+  git \
+  screen \
+  mercurial \
+  openssh-client \
+  rsync \
+  unzip \
+  wget \
+  xz-utils \
+  zip \
+  zlib1g-dev \
+  lsb-release \
+  python3 \
+  python3-pip \
+  python3-setuptools \
+  && apt-get clean \
+  && pip3 install awscli --no-cache-dir --upgrade \
+  && which aws && aws --version \
+  && apt-get -y install \
+  python \
+  python-dev \
+  python-openssl \
+  python-pip \
+  && pip install --upgrade pip setuptools wheel
+
+##################################
+
+`
+
+// https://docs.aws.amazon.com/AmazonECS/latest/developerguide/docker-basics.html
+// https://kubernetes.io/docs/setup/cri/#docker
+const installStartDockerAmazonLinux2 = `
+
+################################## install Docker on Amazon Linux 2
+
+sudo yum install -y yum-utils device-mapper-persistent-data lvm2
+sudo amazon-linux-extras install docker -y
+
+sudo systemctl daemon-reload
+sudo systemctl enable docker || true
+sudo systemctl start docker || true
+sudo systemctl restart docker || true
+
+sudo systemctl status docker --full --no-pager || true
+sudo usermod -aG docker ec2-user || true
+
+# su - ec2-user
+# or logout and login to use docker without 'sudo'
+id -nG
+sudo docker version
+sudo docker info
+
+##################################
+
+`
+
+/*
+sudo yum update -y
+sudo yum install -y docker
+sudo yum install -y yum-utils device-mapper-persistent-data lvm2
+
+sudo yum-config-manager \
+  --add-repo \
+  https://download.docker.com/linux/centos/docker-ce.repo
+
+sudo yum update && sudo yum install -y docker-ce-18.06.1.ce
+sudo mkdir -p /etc/docker
+
+cat > /etc/docker/daemon.json <<EOF
+{
+  "exec-opts": ["native.cgroupdriver=systemd"],
+  "log-driver": "json-file",
+  "log-opts": {
+    "max-size": "100m"
+  },
+  "storage-driver": "overlay2",
+  "storage-opts": [
+    "overlay2.override_kernel_check=true"
+  ]
+}
+EOF
+mkdir -p /etc/systemd/system/docker.service.d
+*/
+
+const installStartDockerUbuntu = `
+
+################################## install Docker on Ubuntu
+sudo apt update -y
+sudo apt install -y apt-transport-https ca-certificates curl software-properties-common
+
+curl -fsSL https://download.docker.com/linux/ubuntu/gpg | sudo apt-key add -
+sudo add-apt-repository "deb [arch=$(dpkg --print-architecture)] https://download.docker.com/linux/ubuntu $(lsb_release -cs) stable"
+
+sudo apt update -y
+apt-cache policy docker-ce || true
+sudo apt install -y docker-ce
+
+sudo systemctl start docker || true
+sudo systemctl status docker --full --no-pager || true
+sudo usermod -aG docker ubuntu || true
+
+# su - ubuntu
+# or logout and login to use docker without 'sudo'
+id -nG
+sudo docker version
+sudo docker info
+
+##################################
+
+`
+
+// https://kubernetes.io/docs/setup/production-environment/container-runtimes/#containerd
+const containerRuntimePrerequisites = `
+cat <<EOF > /tmp/containerd.conf
+overlay
+br_netfilter
+EOF
+sudo cp /tmp/containerd.conf /etc/modules-load.d/containerd.conf
+sudo modprobe overlay
+sudo modprobe br_netfilter
+
+cat <<EOF > /tmp/99-kubernetes-cri.conf
+net.bridge.bridge-nf-call-iptables  = 1
+net.ipv4.ip_forward                 = 1
+net.bridge.bridge-nf-call-ip6tables = 1
+EOF
+sudo cp /tmp/99-kubernetes-cri.conf /etc/sysctl.d/99-kubernetes-cri.conf
+sudo sysctl --system
+`
+
+const configureStartContainerd = `
+sudo mkdir -p /etc/containerd
+containerd config default | sudo tee /etc/containerd/config.toml
+# use systemd cgroup driver, to match kubelet "--cgroup-driver=systemd"
+sudo sed -i 's/SystemdCgroup = false/SystemdCgroup = true/' /etc/containerd/config.toml
+
+sudo systemctl daemon-reload
+sudo systemctl enable containerd || true
+sudo systemctl restart containerd || true
+sudo systemctl status containerd --full --no-pager || true
+
+sudo ctr version
+`
+
+const installStartContainerdAmazonLinux2 = `
+
+################################## install containerd on Amazon Linux 2
+` + containerRuntimePrerequisites + `
+sudo yum install -y yum-utils device-mapper-persistent-data lvm2
+sudo yum install -y containerd
+` + configureStartContainerd + `
+##################################
+
+`
+
+const installStartContainerdUbuntu = `
+
+################################## install containerd on Ubuntu
+` + containerRuntimePrerequisites + `
+sudo apt update -y
+sudo apt install -y apt-transport-https ca-certificates curl software-properties-common
+
+curl -fsSL https://download.docker.com/linux/ubuntu/gpg | sudo apt-key add -
+sudo add-apt-repository "deb [arch=$(dpkg --print-architecture)] https://download.docker.com/linux/ubuntu $(lsb_release -cs) stable"
+
+sudo apt update -y
+sudo apt install -y containerd.io
+` + configureStartContainerd + `
+##################################
+
+`
+
+// https://kubernetes.io/docs/setup/production-environment/container-runtimes/#cri-o
+const configureStartCRIO = `
+# use systemd cgroup driver, to match kubelet "--cgroup-driver=systemd"
+sudo mkdir -p /etc/crio/crio.conf.d
+cat <<EOF > /tmp/02-cgroup-manager.conf
+[crio.runtime]
+conmon_cgroup = "pod"
+cgroup_manager = "systemd"
+EOF
+sudo cp /tmp/02-cgroup-manager.conf /etc/crio/crio.conf.d/02-cgroup-manager.conf
+
+sudo systemctl daemon-reload
+sudo systemctl enable crio || true
+sudo systemctl restart crio || true
+sudo systemctl status crio --full --no-pager || true
+
+sudo crictl --runtime-endpoint unix:///var/run/crio/crio.sock version
+`
+
+// CRI-O minor versions follow Kubernetes, so "{{ .Version }}" is set to
+// the Kubernetes major and minor version (e.g. "1.17").
+const installStartCRIOAmazonLinux2 = `
+
+################################## install CRI-O on Amazon Linux 2
+` + containerRuntimePrerequisites + `
+CRIO_VERSION={{ .Version }}
+OS=CentOS_7
+
+sudo curl -L -o /etc/yum.repos.d/devel:kubic:libcontainers:stable.repo \
+  https://download.opensuse.org/repositories/devel:/kubic:/libcontainers:/stable/${OS}/devel:kubic:libcontainers:stable.repo
+sudo curl -L -o /etc/yum.repos.d/devel:kubic:libcontainers:stable:cri-o:${CRIO_VERSION}.repo \
+  https://download.opensuse.org/repositories/devel:kubic:libcontainers:stable:cri-o:${CRIO_VERSION}/${OS}/devel:kubic:libcontainers:stable:cri-o:${CRIO_VERSION}.repo
+sudo yum install -y cri-o
+` + configureStartCRIO + `
+##################################
+
+`
+
+const installStartCRIOUbuntu = `
+
+################################## install CRI-O on Ubuntu
+` + containerRuntimePrerequisites + `
+CRIO_VERSION={{ .Version }}
+OS=xUbuntu_18.04
+
+echo "deb https://download.opensuse.org/repositories/devel:/kubic:/libcontainers:/stable/${OS}/ /" | sudo tee /etc/apt/sources.list.d/devel:kubic:libcontainers:stable.list
+echo "deb http://download.opensuse.org/repositories/devel:/kubic:/libcontainers:/stable:/cri-o:/${CRIO_VERSION}/${OS}/ /" | sudo tee /etc/apt/sources.list.d/devel:kubic:libcontainers:stable:cri-o:${CRIO_VERSION}.list
+curl -L https://download.opensuse.org/repositories/devel:/kubic:/libcontainers:/stable/${OS}/Release.key | sudo apt-key add -
+curl -L https://download.opensuse.org/repositories/devel:kubic:libcontainers:stable:cri-o:${CRIO_VERSION}/${OS}/Release.key | sudo apt-key add -
+
+sudo apt update -y
+sudo apt install -y cri-o cri-o-runc
+` + configureStartCRIO + `
+##################################
+
+`
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/aws/aws-k8s-tester/ec2config/osprofile"
 	etcdplugin "github.com/aws/aws-k8s-tester/etcdconfig/plugins"
 	kubeadmplugin "github.com/aws/aws-k8s-tester/kubeadmconfig/plugins"
 	kubernetesplugin "github.com/aws/aws-k8s-tester/kubernetesconfig/plugins"
@@ -33,28 +34,111 @@ func (ss scripts) Swap(i, j int)      { ss[i], ss[j] = ss[j], ss[i] }
 func (ss scripts) Less(i, j int) bool { return keyPriorities[ss[i].key] < keyPriorities[ss[j].key] }
 
 var keyPriorities = map[string]int{ // in the order of:
-	"update-amazon-linux-2":               1,
-	"update-ubuntu":                       2,
-	"install-go":                          3,
-	"install-go-amazon-linux-2":           4,
-	"install-csi":                         5,
-	"install-etcd":                        6,
-	"install-aws-k8s-tester":              7,
-	"install-wrk":                         8,
-	"install-alb":                         9,
-	"install-start-docker-amazon-linux-2": 10,
-	"install-start-docker-ubuntu":         11,
-	"install-kubeadm-amazon-linux-2":      12,
-	"install-kubernetes-amazon-linux-2":   13,
+	"update":                    1,
+	"install-go":                2,
+	"install-go-amazon-linux-2": 3,
+	"install-csi":               4,
+	"install-etcd":              5,
+	"install-aws-k8s-tester":    6,
+	"install-wrk":               7,
+	"install-alb":               8,
+	"install-start-docker":      9,
+	"install-start-containerd":  10,
+	"install-start-cri-o":       11,
+	"install-kubeadm":           12,
+	"install-kubernetes":        13,
 }
 
-func convertToScript(userName, plugin string) (script, error) {
-	switch {
-	case plugin == "update-amazon-linux-2":
-		return script{key: "update-amazon-linux-2", data: updateAmazonLinux2}, nil
+// legacyProfiles maps OS specific plugin names, prior to OS profiles,
+// to their profiles and OS-independent plugin names.
+var legacyProfiles = map[string]struct{ profile, plugin string }{
+	"update-amazon-linux-2":               {osprofile.AmazonLinux2, "update"},
+	"update-ubuntu":                       {osprofile.Ubuntu, "update"},
+	"install-start-docker-amazon-linux-2": {osprofile.AmazonLinux2, "install-start-docker"},
+	"install-start-docker-ubuntu":         {osprofile.Ubuntu, "install-start-docker"},
+	"install-kubeadm-amazon-linux-2":      {osprofile.AmazonLinux2, "install-kubeadm"},
+	"install-kubernetes-amazon-linux-2":   {osprofile.AmazonLinux2, "install-kubernetes"},
+}
+
+// Key returns the OS-independent plugin name without version
+// (e.g. "install-kubeadm" for "install-kubeadm-amazon-linux-2-1.13.1").
+func Key(plugin string) string {
+	for k, v := range legacyProfiles {
+		if plugin == k || strings.HasPrefix(plugin, k+"-") {
+			return v.plugin
+		}
+	}
+	key := plugin
+	for k := range keyPriorities {
+		if plugin == k || strings.HasPrefix(plugin, k+"-") {
+			// longest match (e.g. "install-go-amazon-linux-2" over "install-go")
+			if key == plugin || len(k) > len(key) {
+				key = k
+			}
+		}
+	}
+	return key
+}
+
+// pluginVersion returns the version of the first plugin with the
+// OS-independent plugin name (e.g. "1.13.1" for "install-kubeadm"
+// and "install-kubeadm-amazon-linux-2-1.13.1"), or empty if not found.
+func pluginVersion(plugins []string, key string) string {
+	for _, plugin := range plugins {
+		if Key(plugin) != key {
+			continue
+		}
+		pfx := key
+		for k, v := range legacyProfiles {
+			if v.plugin == key && (plugin == k || strings.HasPrefix(plugin, k+"-")) {
+				pfx = k
+				break
+			}
+		}
+		return strings.TrimPrefix(strings.TrimPrefix(plugin, pfx), "-")
+	}
+	return ""
+}
+
+// resolve returns the OS-independent plugin name with version,
+// and the OS profile of a legacy plugin name, or the given profile.
+func resolve(p *osprofile.Profile, plugin string) (string, *osprofile.Profile, error) {
+	for k, v := range legacyProfiles {
+		if plugin != k && !strings.HasPrefix(plugin, k+"-") {
+			continue
+		}
+		lp, err := osprofile.Get(v.profile)
+		if err != nil {
+			return "", nil, err
+		}
+		if lp.Name != p.Name {
+			return "", nil, fmt.Errorf("plugin %q is not supported by OS profile %q", plugin, p.Name)
+		}
+		return v.plugin + strings.TrimPrefix(plugin, k), lp, nil
+	}
+	return plugin, p, nil
+}
 
-	case plugin == "update-ubuntu":
-		return script{key: "update-ubuntu", data: updateUbuntu}, nil
+func convertToScript(p *osprofile.Profile, rt *osprofile.ContainerRuntime, userName, arch, kubernetesVersion, plugin string) (script, error) {
+	plugin, p, err := resolve(p, plugin)
+	if err != nil {
+		return script{}, err
+	}
+
+	if v, ok := osprofile.FindContainerRuntime(Key(plugin)); ok {
+		s, err := p.InstallContainerRuntime(v.Name, kubernetesVersion)
+		if err != nil {
+			return script{}, err
+		}
+		return script{key: v.Plugin, data: s}, nil
+	}
+
+	switch {
+	case plugin == "update":
+		if p.UpdateScript == "" {
+			return script{}, fmt.Errorf("plugin %q is not supported by OS profile %q", plugin, p.Name)
+		}
+		return script{key: "update", data: p.UpdateScript}, nil
 
 	case strings.HasPrefix(plugin, "install-go-amazon-linux-2-"):
 		goVer := strings.Replace(plugin, "install-go-amazon-linux-2-", "", -1)
@@ -77,6 +161,7 @@ func convertToScript(userName, plugin string) (script, error) {
 		s, err := createInstallGoLinux(goInfo{
 			UserName:  userName,
 			GoVersion: goVer,
+			Arch:      arch,
 		})
 		if err != nil {
 			return script{}, err
@@ -103,7 +188,7 @@ func convertToScript(userName, plugin string) (script, error) {
 
 	case strings.HasPrefix(plugin, "install-etcd-"):
 		id := strings.Replace(plugin, "install-etcd-", "", -1)
-		s, err := etcdplugin.CreateInstallScript(id)
+		s, err := etcdplugin.CreateInstallScript(id, arch)
 		if err != nil {
 			return script{}, err
 		}
@@ -148,43 +233,52 @@ make server
 		}
 		return script{key: "install-alb", data: s}, nil
 
-	case plugin == "install-start-docker-amazon-linux-2":
-		return script{
-			key:  plugin,
-			data: installStartDockerAmazonLinux2,
-		}, nil
-
-	case plugin == "install-start-docker-ubuntu":
-		return script{
-			key:  plugin,
-			data: installStartDockerUbuntu,
-		}, nil
-
-	case strings.HasPrefix(plugin, "install-kubeadm-amazon-linux-2-"):
-		id := strings.Replace(plugin, "install-kubeadm-amazon-linux-2-", "", -1)
-		s, err := kubeadmplugin.CreateInstall(id)
+	case strings.HasPrefix(plugin, "install-kubeadm-"):
+		id := strings.Replace(plugin, "install-kubeadm-", "", -1)
+		s, err := kubeadmplugin.CreateInstall(p, rt, id, arch)
 		if err != nil {
 			return script{}, err
 		}
-		return script{key: "install-kubeadm-amazon-linux-2", data: s}, nil
+		return script{key: "install-kubeadm", data: s}, nil
 
-	case plugin == "install-kubernetes-amazon-linux-2":
-		return script{key: "install-kubernetes-amazon-linux-2", data: kubernetesplugin.CreateInstall()}, nil
+	case plugin == "install-kubernetes":
+		s, err := kubernetesplugin.CreateInstall(p, arch)
+		if err != nil {
+			return script{}, err
+		}
+		return script{key: "install-kubernetes", data: s}, nil
 	}
 
 	return script{}, fmt.Errorf("unknown plugin %q", plugin)
 }
 
-// Create returns the plugin.
-func Create(userName, customScript string, plugins []string) (data string, err error) {
-	sts := make([]script, 0, len(plugins))
+// Create returns the plugin for the OS profile.
+// The architecture is either "amd64" or "arm64", for release downloads.
+// OS specific plugin names (e.g. "update-amazon-linux-2") must match the profile.
+// Kubernetes services depend on the container runtime plugin (e.g.
+// "install-start-containerd"), or Docker if none.
+// CRI-O is installed with the version of its plugin (e.g. "install-start-cri-o-1.17"),
+// or the Kubernetes version of "install-kubeadm" plugin (e.g. "install-kubeadm-1.17.3").
+func Create(p *osprofile.Profile, userName, arch, customScript string, plugins []string) (data string, err error) {
+	rt, err := osprofile.GetContainerRuntime(osprofile.Docker)
+	if err != nil {
+		return "", err
+	}
 	for _, plugin := range plugins {
-		if plugin == "update-ubuntu" {
-			if userName != "ubuntu" {
-				return "", fmt.Errorf("'update-ubuntu' requires 'ubuntu' user name, got %q", userName)
-			}
+		if v, ok := osprofile.FindContainerRuntime(Key(plugin)); ok {
+			rt = v
+			break
 		}
-		script, err := convertToScript(userName, plugin)
+	}
+
+	ver := pluginVersion(plugins, rt.Plugin)
+	if ver == "" {
+		ver = pluginVersion(plugins, "install-kubeadm")
+	}
+
+	sts := make([]script, 0, len(plugins))
+	for _, plugin := range plugins {
+		script, err := convertToScript(p, rt, userName, arch, ver, plugin)
 		if err != nil {
 			return "", err
 		}
@@ -201,117 +295,6 @@ func Create(userName, customScript string, plugins []string) (data string, err e
 	return data, nil
 }
 
-// https://github.com/awslabs/amazon-eks-ami/blob/master/install-worker.sh
-const updateAmazonLinux2 = `
-
-################################## update Amazon Linux 2
-
-export HOME=/home/ec2-user
-export GOPATH=/home/ec2-user/go
-
-sudo yum update -y \
-  && sudo yum install -y \
-  gcc \
-  zlib-devel \
-  openssl-devel \
-  ncurses-devel \
-  git \
-  wget \
-  jq \
-  tar \
-  curl \
-  unzip \
-  screen \
-  mercurial \
-  aws-cfn-bootstrap \
-  awscli \
-  chrony \
-  conntrack \
-  nfs-utils \
-  socat
-
-# Make sure Amazon Time Sync Service starts on boot.
-sudo chkconfig chronyd on
-
-# Make sure that chronyd syncs RTC clock to the kernel.
-cat <<EOF | sudo tee -a /etc/chrony.conf
-# This directive enables kernel synchronisation (every 11 minutes) of the
-# real-time clock. Note that it can’t be used along with the 'rtcfile' directive.
-rtcsync
-EOF
-
-
-sudo mkdir -p /home/ec2-user/.aws/
-sudo chown -R ec2-user:ec2-user /home/ec2-user/.aws
-chown -R $(id -u):$(id -g) /home/ec2-user/.aws
-
-
-################################################################################
-### iptables ###################################################################
-################################################################################
-
-# Enable forwarding via iptables
-sudo bash -c "/sbin/iptables-save > /etc/sysconfig/iptables"
-
-sudo mv $TEMPLATE_DIR/iptables-restore.service /etc/systemd/system/iptables-restore.service
-
-sudo systemctl daemon-reload
-sudo systemctl enable iptables-restore
-
-`
-const updateUbuntu = `
-
-################################## update Ubuntu
-
-export HOME=/home/ubuntu
-export GOPATH=/home/ubuntu/go
-
-apt-get -y update \
-  && apt-get -y install \
-  build-essential \
-  gcc \
-  jq \
-  file \
-  apt-utils \
-  pkg-config \
-  software-properties-common \
-  apt-transport-https \
-  ca-certificates \
-  libssl-dev \
-  gnupg2 \
-  sudo \
-  bash \
-  curl \
-  wget \
-  tar \
-  git \
-  screen \
-  mercurial \
-  openssh-client \
-  rsync \
-  unzip \
-  wget \
-  xz-utils \
-  zip \
-  zlib1g-dev \
-  lsb-release \
-  python3 \
-  python3-pip \
-  python3-setuptools \
-  && apt-get clean \
-  && pip3 install awscli --no-cache-dir --upgrade \
-  && which aws && aws --version \
-  && apt-get -y install \
-  python \
-  python-dev \
-  python-openssl \
-  python-pip \
-  && pip install --upgrade pip setuptools wheel
-
-##################################
-
-`
-
 func createInstallGoAmazonLinux2(g goInfo) (string, error) {
 	tpl := template.Must(template.New("installGoAmazonLinux2Template").Parse(installGoAmazonLinux2Template))
 	buf := bytes.NewBuffer(nil)
@@ -333,6 +316,7 @@ func createInstallGoLinux(g goInfo) (string, error) {
 type goInfo struct {
 	UserName  string
 	GoVersion string
+	Arch      string
 }
 
 const installGoAmazonLinux2Template = `
@@ -379,7 +363,7 @@ GO_VERSION={{ .GoVersion }}
 GOOGLE_URL=https://storage.googleapis.com/golang
 DOWNLOAD_URL=${GOOGLE_URL}
 
-sudo curl -s ${DOWNLOAD_URL}/go$GO_VERSION.linux-amd64.tar.gz | sudo tar -v -C /usr/local/ -xz
+sudo curl -s ${DOWNLOAD_URL}/go$GO_VERSION.linux-{{ .Arch }}.tar.gz | sudo tar -v -C /usr/local/ -xz
 
 export GOPATH=/home/{{ .UserName }}/go
 mkdir -p ${GOPATH}/bin/
@@ -419,6 +403,7 @@ func createInstallEtcd(g etcdInfo) (string, error) {
 
 type etcdInfo struct {
 	Version string
+	Arch    string
 }
 
 const installEtcdTemplate = `
@@ -432,12 +417,12 @@ GOOGLE_URL=https://storage.googleapis.com/etcd
 GITHUB_URL=https://github.com/etcd-io/etcd/releases/download
 DOWNLOAD_URL=${GOOGLE_URL}
 
-rm -f /tmp/etcd-${ETCD_VER}-linux-amd64.tar.gz
+rm -f /tmp/etcd-${ETCD_VER}-linux-{{ .Arch }}.tar.gz
 rm -rf /tmp/etcd-download-test && mkdir -p /tmp/etcd-download-test
 
-curl -L ${DOWNLOAD_URL}/${ETCD_VER}/etcd-${ETCD_VER}-linux-amd64.tar.gz -o /tmp/etcd-${ETCD_VER}-linux-amd64.tar.gz
-tar xzvf /tmp/etcd-${ETCD_VER}-linux-amd64.tar.gz -C /tmp/etcd-download-test --strip-components=1
-rm -f /tmp/etcd-${ETCD_VER}-linux-amd64.tar.gz
+curl -L ${DOWNLOAD_URL}/${ETCD_VER}/etcd-${ETCD_VER}-linux-{{ .Arch }}.tar.gz -o /tmp/etcd-${ETCD_VER}-linux-{{ .Arch }}.tar.gz
+tar xzvf /tmp/etcd-${ETCD_VER}-linux-{{ .Arch }}.tar.gz -C /tmp/etcd-download-test --strip-components=1
+rm -f /tmp/etcd-${ETCD_VER}-linux-{{ .Arch }}.tar.gz
 
 sudo cp /tmp/etcd-download-test/etcd /usr/bin/etcd
 sudo cp /tmp/etcd-download-test/etcdctl /usr/bin/etcdctl
@@ -550,85 +535,3 @@ pwd
 ##################################
 
 `
-
-// https://docs.aws.amazon.com/AmazonECS/latest/developerguide/docker-basics.html
-// https://kubernetes.io/docs/setup/cri/#docker
-const installStartDockerAmazonLinux2 = `
-
-################################## install Docker on Amazon Linux 2
-
-sudo yum install -y yum-utils device-mapper-persistent-data lvm2
-sudo amazon-linux-extras install docker -y
-
-sudo systemctl daemon-reload
-sudo systemctl enable docker || true
-sudo systemctl start docker || true
-sudo systemctl restart docker || true
-
-sudo systemctl status docker --full --no-pager || true
-sudo usermod -aG docker ec2-user || true
-
-# su - ec2-user
-# or logout and login to use docker without 'sudo'
-id -nG
-sudo docker version
-sudo docker info
-
-##################################
-
-`
-
-/*
-sudo yum update -y
-sudo yum install -y docker
-sudo yum install -y yum-utils device-mapper-persistent-data lvm2
-
-sudo yum-config-manager \
-  --add-repo \
-  https://download.docker.com/linux/centos/docker-ce.repo
-
-sudo yum update && sudo yum install -y docker-ce-18.06.1.ce
-sudo mkdir -p /etc/docker
-
-cat > /etc/docker/daemon.json <<EOF
-{
-  "exec-opts": ["native.cgroupdriver=systemd"],
-  "log-driver": "json-file",
-  "log-opts": {
-    "max-size": "100m"
-  },
-  "storage-driver": "overlay2",
-  "storage-opts": [
-    "overlay2.override_kernel_check=true"
-  ]
-}
-EOF
-mkdir -p /etc/systemd/system/docker.service.d
-*/
-
-const installStartDockerUbuntu = `
-
-################################## install Docker on Ubuntu
-sudo apt update -y
-sudo apt install -y apt-transport-https ca-certificates curl software-properties-common
-
-curl -fsSL https://download.docker.com/linux/ubuntu/gpg | sudo apt-key add -
-sudo add-apt-repository "deb [arch=amd64] https://download.docker.com/linux/ubuntu $(lsb_release -cs) stable"
-
-sudo apt update -y
-apt-cache policy docker-ce || true
-sudo apt install -y docker-ce
-
-sudo systemctl start docker || true
-sudo systemctl status docker --full --no-pager || true
-sudo usermod -aG docker ubuntu || true
-
-# su - ubuntu
-# or logout and login to use docker without 'sudo'
-id -nG
-sudo docker version
-sudo docker info
-
-##################################
-
-`
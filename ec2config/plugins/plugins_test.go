@@ -2,7 +2,10 @@ package plugins
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/aws/aws-k8s-tester/ec2config/osprofile"
 )
 
 func Test_createInstall(t *testing.T) {
@@ -45,8 +48,14 @@ make server
 }
 
 func TestPlugins(t *testing.T) {
+	p, err := osprofile.Get(osprofile.AmazonLinux2)
+	if err != nil {
+		t.Fatal(err)
+	}
 	script, err := Create(
+		p,
 		"ubuntu",
+		"amd64",
 		"",
 		[]string{
 			"install-csi-101",
@@ -60,3 +69,179 @@ func TestPlugins(t *testing.T) {
 	}
 	fmt.Println(script)
 }
+
+func TestPluginsArch(t *testing.T) {
+	p, err := osprofile.Get(osprofile.AmazonLinux2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := Create(
+		p,
+		"ec2-user",
+		"arm64",
+		"",
+		[]string{
+			"install-go-1.11.4",
+			"install-etcd-3.3.10",
+			"install-kubeadm-amazon-linux-2-1.13.1",
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"go$GO_VERSION.linux-arm64.tar.gz",
+		"etcd-${ETCD_VER}-linux-arm64.tar.gz",
+		"/bin/linux/arm64/{kubeadm,kubelet,kubectl}",
+	} {
+		if !strings.Contains(script, s) {
+			t.Fatalf("expected %q in %q", s, script)
+		}
+	}
+	if strings.Contains(script, "amd64") {
+		t.Fatalf("unexpected amd64 in %q", script)
+	}
+}
+
+func TestPluginsOSProfile(t *testing.T) {
+	p, err := osprofile.Get(osprofile.Ubuntu)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := Create(
+		p,
+		"ubuntu",
+		"amd64",
+		"",
+		[]string{
+			"install-kubeadm-1.13.1",
+			"install-start-docker",
+			"update",
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"sudo apt-get install -y cri-tools",
+		"download.docker.com/linux/ubuntu",
+		"install kubeadm on ubuntu",
+	} {
+		if !strings.Contains(script, s) {
+			t.Fatalf("expected %q in %q", s, script)
+		}
+	}
+	if strings.Index(script, "download.docker.com") > strings.Index(script, "install kubeadm") {
+		t.Fatalf("expected docker installed before kubeadm in %q", script)
+	}
+
+	if _, err = Create(p, "ubuntu", "amd64", "", []string{"update-amazon-linux-2"}); err == nil {
+		t.Fatal("expected error for Amazon Linux 2 plugin on Ubuntu")
+	}
+
+	p, err = osprofile.Get(osprofile.ContainerOptimized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Create(p, "ec2-user", "amd64", "", []string{"install-start-docker"}); err == nil {
+		t.Fatal("expected error for preinstalled container runtime")
+	}
+	if _, err = Create(p, "ec2-user", "amd64", "", []string{"install-kubeadm-1.13.1"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPluginsContainerRuntime(t *testing.T) {
+	p, err := osprofile.Get(osprofile.AmazonLinux2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := Create(
+		p,
+		"ec2-user",
+		"amd64",
+		"",
+		[]string{
+			"install-kubeadm-1.13.1",
+			"install-start-containerd",
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"sudo yum install -y containerd",
+		"SystemdCgroup = true",
+		"After=containerd.service",
+	} {
+		if !strings.Contains(script, s) {
+			t.Fatalf("expected %q in %q", s, script)
+		}
+	}
+	if strings.Contains(script, "docker") {
+		t.Fatalf("unexpected docker in %q", script)
+	}
+
+	p, err = osprofile.Get(osprofile.Ubuntu)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err = Create(p, "ubuntu", "amd64", "", []string{"install-start-cri-o-1.17"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(script, "sudo apt install -y cri-o") {
+		t.Fatalf("expected CRI-O install in %q", script)
+	}
+	if !strings.Contains(script, "CRIO_VERSION=1.17\n") {
+		t.Fatalf("expected CRI-O version 1.17 in %q", script)
+	}
+
+	// CRI-O version follows kubeadm
+	script, err = Create(p, "ubuntu", "amd64", "", []string{"install-start-cri-o", "install-kubeadm-1.18.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(script, "CRIO_VERSION=1.18\n") {
+		t.Fatalf("expected CRI-O version 1.18 in %q", script)
+	}
+
+	if _, err = Create(p, "ubuntu", "amd64", "", []string{"install-start-cri-o", "install-kubernetes"}); err == nil {
+		t.Fatal("expected error for CRI-O without Kubernetes version")
+	}
+}
+
+func TestPluginVersion(t *testing.T) {
+	plugins := []string{"update-amazon-linux-2", "install-start-cri-o", "install-kubeadm-amazon-linux-2-1.13.1"}
+	if ver := pluginVersion(plugins, "install-kubeadm"); ver != "1.13.1" {
+		t.Fatalf("unexpected version %q", ver)
+	}
+	if ver := pluginVersion(plugins, "install-start-cri-o"); ver != "" {
+		t.Fatalf("unexpected version %q", ver)
+	}
+	if ver := pluginVersion([]string{"install-start-cri-o-1.17"}, "install-start-cri-o"); ver != "1.17" {
+		t.Fatalf("unexpected version %q", ver)
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := map[string]string{
+		"update-amazon-linux-2":                 "update",
+		"update-ubuntu":                         "update",
+		"update":                                "update",
+		"install-start-docker-ubuntu":           "install-start-docker",
+		"install-kubeadm-amazon-linux-2-1.13.1": "install-kubeadm",
+		"install-kubeadm-1.13.1":                "install-kubeadm",
+		"install-kubernetes-amazon-linux-2":     "install-kubernetes",
+		"install-go-amazon-linux-2-1.11.5":      "install-go-amazon-linux-2",
+		"install-go-1.11.5":                     "install-go",
+		"install-etcd-3.3.10":                   "install-etcd",
+		"install-start-cri-o-1.17":              "install-start-cri-o",
+		"unknown":                               "unknown",
+	}
+	for plugin, exp := range tests {
+		if key := Key(plugin); key != exp {
+			t.Fatalf("%q expected %q, got %q", plugin, exp, key)
+		}
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/bits"
 	"math/rand"
+	"net"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -17,6 +19,7 @@ import (
 
 	"github.com/aws/aws-k8s-tester/ec2config"
 	"github.com/aws/aws-k8s-tester/pkg/awsapi/ec2"
+	"github.com/aws/aws-k8s-tester/pkg/envutil"
 	"k8s.io/client-go/util/homedir"
 	"sigs.k8s.io/yaml"
 )
@@ -107,10 +110,13 @@ type Config struct {
 	EnableWorkerNodePrivilegedPortAccess bool `json:"enable-worker-node-privileged-port-access"`
 
 	// VPCID is the VPC ID.
+	// Must be specified if "VPC.UseExisting" is true.
 	VPCID string `json:"vpc-id"`
 	// SubnetIDs is the subnet IDs.
+	// Must be specified if "VPC.UseExisting" is true.
 	SubnetIDs []string `json:"subnet-ids"`
 	// SecurityGroupID is the default security group ID.
+	// Must be specified if "VPC.UseExisting" is true.
 	SecurityGroupID string `json:"security-group-id"`
 
 	// WorkerNodePrivateKeyPath is the file path to store node group key pair private key.
@@ -119,6 +125,9 @@ type Config struct {
 	WorkerNodePrivateKeyPath string `json:"worker-node-private-key-path,omitempty"`
 	// WorkerNodeAMI is the Amazon EKS worker node AMI ID for the specified Region.
 	// Reference https://docs.aws.amazon.com/eks/latest/userguide/getting-started.html.
+	// If empty, the latest Amazon EKS-optimized AMI of the Kubernetes version
+	// and the worker node instance type architecture is looked up on creation.
+	// The default AMI is x86_64, so it is reset for arm64 instance types.
 	WorkerNodeAMI string `json:"worker-node-ami,omitempty"`
 	// WorkerNodeInstanceType is the EC2 instance type for worker nodes.
 	WorkerNodeInstanceType string `json:"worker-node-instance-type,omitempty"`
@@ -133,6 +142,15 @@ type Config struct {
 	// If empty, set default value.
 	WorkerNodeVolumeSizeGB int `json:"worker-node-volume-size-gb,omitempty"`
 
+	// WorkerNodeCFTemplatePath is the local worker node group CloudFormation template
+	// file path, to use instead of the embedded template.
+	// The template must output "NodeInstanceRole" and "NodeSecurityGroup".
+	WorkerNodeCFTemplatePath string `json:"worker-node-cf-template-path,omitempty"`
+	// WorkerNodeCFTemplateVersion is the version of embedded worker node group
+	// CloudFormation template (e.g. "2019-02-11").
+	// If empty, set default version for the Kubernetes version.
+	WorkerNodeCFTemplateVersion string `json:"worker-node-cf-template-version,omitempty"`
+
 	// KubernetesVersion is the version of Kubernetes cluster.
 	// If empty, set default version.
 	KubernetesVersion string `json:"kubernetes-version,omitempty"`
@@ -166,10 +184,40 @@ type Config struct {
 	UploadKubeConfig bool `json:"upload-kubeconfig"`
 	// UploadWorkerNodeLogs is true to auto-upload worker node log files.
 	UploadWorkerNodeLogs bool `json:"upload-worker-node-logs"`
+	// UploadDiagnostics is true to auto-upload cluster diagnostics
+	// (pod logs, events, resource descriptions) collected via Kubernetes API.
+	UploadDiagnostics bool `json:"upload-diagnostics"`
 	// UploadBucketExpireDays is the number of days for a S3 bucket to expire.
 	// Set 0 to not expire.
 	UploadBucketExpireDays int `json:"upload-bucket-expire-days"`
 
+	// ArtifactStore is the storage backend for uploaded files
+	// (configs, kubeconfigs, logs, and test outputs).
+	// "s3" uploads to the S3 bucket named by tag.
+	// "local" copies to "ArtifactStoreDir", without any bucket.
+	// "http" uploads with HTTP PUT requests to "ArtifactStoreURL" (e.g. GCS XML API),
+	// with "Authorization" header from "AWS_K8S_TESTER_ARTIFACT_STORE_AUTHORIZATION".
+	ArtifactStore string `json:"artifact-store"`
+	// ArtifactStoreDir is the directory for "local" artifact store.
+	// If empty, it is set to a temporary directory named by cluster name.
+	ArtifactStoreDir string `json:"artifact-store-dir,omitempty"`
+	// ArtifactStoreURL is the base URL for "http" artifact store.
+	// e.g. "https://storage.googleapis.com/my-bucket"
+	ArtifactStoreURL string `json:"artifact-store-url,omitempty"`
+	// ArtifactManifestPath is the manifest file path that lists all uploaded artifacts.
+	// Must be left empty.
+	// This will be overwritten by cluster name.
+	ArtifactManifestPath       string `json:"artifact-manifest-path,omitempty"`
+	ArtifactManifestPathBucket string `json:"artifact-manifest-path-bucket,omitempty"`
+	ArtifactManifestPathURL    string `json:"artifact-manifest-path-url,omitempty"`
+	// DiagnosticsOutputToUploadPath is the tarball path of cluster diagnostics
+	// collected via Kubernetes API.
+	// Must be left empty.
+	// This will be overwritten by cluster name.
+	DiagnosticsOutputToUploadPath       string `json:"diagnostics-output-to-upload-path,omitempty"`
+	DiagnosticsOutputToUploadPathBucket string `json:"diagnostics-output-to-upload-path-bucket,omitempty"`
+	DiagnosticsOutputToUploadPathURL    string `json:"diagnostics-output-to-upload-path-url,omitempty"`
+
 	// UpdatedAt is the timestamp when the configuration has been updated.
 	// Read only to 'Config' struct users.
 	UpdatedAt time.Time `json:"updated-at,omitempty"` // read-only to user
@@ -183,6 +231,25 @@ type Config struct {
 	// Deployer is expected to keep this in sync.
 	// Read-only to kubetest.
 	ALBIngressController *ALBIngressController `json:"alb-ingress-controller,omitempty"`
+
+	// Conformance is the Kubernetes conformance test configuration and its results.
+	// Deployer is expected to keep this in sync.
+	// Read-only to kubetest.
+	Conformance *Conformance `json:"conformance,omitempty"`
+
+	// CNI is the Amazon VPC CNI plugin configuration and its test results.
+	// Deployer is expected to keep this in sync.
+	// Read-only to kubetest.
+	CNI *CNI `json:"cni,omitempty"`
+
+	// VPC is the VPC topology configuration.
+	// Read-only to kubetest.
+	VPC *VPC `json:"vpc,omitempty"`
+
+	// Spot is the Spot instance configuration of worker node group,
+	// where "WorkerNodeInstanceType" is the primary instance type.
+	// Read-only to kubetest.
+	Spot *ec2config.Spot `json:"spot,omitempty"`
 }
 
 // ClusterState contains EKS cluster specific states.
@@ -233,8 +300,12 @@ type ClusterState struct {
 	// WorkerNodes is a list of worker nodes.
 	WorkerNodes map[string]ec2config.Instance `json:"worker-nodes,omitempty"`
 
-	// WorkerNodeLogs is a list of worker node log file paths, fetched via SSH.
+	// WorkerNodeLogs is a list of worker node log file paths, fetched via SSH,
+	// or via a privileged DaemonSet if node SSH is disabled.
 	WorkerNodeLogs map[string]string `json:"worker-node-logs,omitempty"`
+	// InterruptedWorkerNodes is a list of worker nodes that have been
+	// replaced after Spot interruption notices.
+	InterruptedWorkerNodes map[string]ec2config.Instance `json:"interrupted-worker-nodes,omitempty"`
 
 	// CFStackWorkerNodeGroupName is the name of cloudformation stack for worker node group.
 	CFStackWorkerNodeGroupName string `json:"cf-stack-worker-node-group-name,omitempty"`
@@ -287,6 +358,12 @@ type ALBIngressController struct {
 	TestScalabilityMinutes int `json:"test-scalability-minutes"`
 	// TestMetrics is true to run metrics tests.
 	TestMetrics bool `json:"test-metrics"`
+	// TestLoadGenerators is the number of load generator pods to run scalability tests from.
+	// If zero, scalability tests run from the tester itself.
+	// Otherwise, each pod runs "aws-k8s-tester wrk" with "TestClients" connections,
+	// and the results are merged into one report.
+	// Requires "AWSK8sTesterImage".
+	TestLoadGenerators int `json:"test-load-generators,omitempty"`
 	// TestServerReplicas is the number of ingress test server pods to deploy.
 	TestServerReplicas int `json:"test-server-replicas,omitempty"`
 	// TestServerRoutes is the number of ALB Ingress Controller routes to test.
@@ -301,6 +378,23 @@ type ALBIngressController struct {
 	// TestClientRequests is the number of ALB Ingress Controller test requests.
 	// This is ignored when test mode is nginx (because it will use "wrk" for QPS tests).
 	TestClientRequests int `json:"test-client-requests,omitempty"`
+	// TestClientMode is either "closed-loop" or "open-loop".
+	// "closed-loop" runs "TestClients" concurrent clients until "TestClientRequests" is exhausted.
+	// "open-loop" issues requests at the target rate regardless of response time,
+	// and measures latency from the intended send time.
+	// Only supported when ALB Ingress "TestMode" is "ingress-test-server".
+	TestClientMode string `json:"test-client-mode,omitempty"`
+	// TestClientOpenLoopStartQPS is the target QPS at the beginning of open-loop tests.
+	TestClientOpenLoopStartQPS float64 `json:"test-client-open-loop-start-qps,omitempty"`
+	// TestClientOpenLoopEndQPS is the target QPS at the end of open-loop tests.
+	// If zero, the rate is constant at "TestClientOpenLoopStartQPS".
+	TestClientOpenLoopEndQPS float64 `json:"test-client-open-loop-end-qps,omitempty"`
+	// TestClientOpenLoopSteps is the number of rate steps from start to end QPS.
+	// If zero, the rate ramps linearly. Must not be 1 to change the rate.
+	TestClientOpenLoopSteps int `json:"test-client-open-loop-steps,omitempty"`
+	// TestClientOpenLoopMinutes is the number of minutes to send open-loop test workloads.
+	// If zero, it is set to "TestScalabilityMinutes".
+	TestClientOpenLoopMinutes int `json:"test-client-open-loop-minutes,omitempty"`
 	// TestResponseSize is the response payload size.
 	// Ingress test server always returns '0' x response size.
 	// Supports up to 500 KB.
@@ -314,11 +408,26 @@ type ALBIngressController struct {
 	TestResultQPS float64 `json:"test-result-qps,omitempty"`
 	// TestResultFailures is the number of failed requests of last test run.
 	TestResultFailures int64 `json:"test-result-failures,omitempty"`
+	// TestAccessLogsTimeout is the timeout to wait for ALB access logs of the
+	// scalability test, to cross-check with client-side results. ALB delivers
+	// access logs every 5 minutes. Zero skips the check, and up to 1 hour.
+	// Only used when "LogAccess" is true.
+	TestAccessLogsTimeout time.Duration `json:"test-access-logs-timeout,omitempty"`
+	// TestResultAccessLogRequests is the number of requests of last test run,
+	// as recorded by ALB access logs. Only populated when "LogAccess" is true.
+	TestResultAccessLogRequests int64 `json:"test-result-access-log-requests,omitempty"`
+	// TestResultAccessLogErrors is the number of requests with ELB 5xx of last test run,
+	// as recorded by ALB access logs. Only populated when "LogAccess" is true.
+	TestResultAccessLogErrors int64 `json:"test-result-access-log-errors,omitempty"`
 
 	// IngressTestServerDeploymentServiceSpecPath is the file path to test pod deployment and service YAML spec.
 	IngressTestServerDeploymentServiceSpecPath       string `json:"ingress-test-server-deployment-service-spec-path,omitempty"`
 	IngressTestServerDeploymentServiceSpecPathBucket string `json:"ingress-test-server-deployment-service-spec-path-bucket,omitempty"`
 	IngressTestServerDeploymentServiceSpecPathURL    string `json:"ingress-test-server-deployment-service-spec-path-url,omitempty"`
+	// LoadGeneratorSpecPath is the file path to load generator job YAML spec.
+	LoadGeneratorSpecPath       string `json:"load-generator-spec-path,omitempty"`
+	LoadGeneratorSpecPathBucket string `json:"load-generator-spec-path-bucket,omitempty"`
+	LoadGeneratorSpecPathURL    string `json:"load-generator-spec-path-url,omitempty"`
 	// IngressControllerSpecPath is the file path to ALB Ingress Controller YAML spec.
 	IngressControllerSpecPath       string `json:"ingress-controller-spec-path,omitempty"`
 	IngressControllerSpecPathBucket string `json:"ingress-controller-spec-path-bucket,omitempty"`
@@ -379,6 +488,166 @@ type ALBIngressController struct {
 	MetricsOutputToUploadPath       string `json:"metrics-output-to-upload-path,omitempty"`
 	MetricsOutputToUploadPathBucket string `json:"metrics-output-to-upload-path-bucket,omitempty"`
 	MetricsOutputToUploadPathURL    string `json:"metrics-output-to-upload-path-url,omitempty"`
+	// AccessLogsOutputToUploadPath is the ALB access logs of the scalability
+	// test, in CSV, to upload to cloud storage. Only written when "LogAccess" is true.
+	// Must be left empty.
+	// This will be overwritten by cluster name.
+	AccessLogsOutputToUploadPath       string `json:"access-logs-output-to-upload-path,omitempty"`
+	AccessLogsOutputToUploadPathBucket string `json:"access-logs-output-to-upload-path-bucket,omitempty"`
+	AccessLogsOutputToUploadPathURL    string `json:"access-logs-output-to-upload-path-url,omitempty"`
+}
+
+// Conformance defines Kubernetes conformance test configuration and its results.
+type Conformance struct {
+	// Image is the conformance e2e container image, which runs "e2e.test"
+	// with "E2E_FOCUS", "E2E_SKIP", and "E2E_PARALLEL" environmental variables,
+	// and writes the e2e log and junit results to "RESULTS_DIR".
+	// If empty, it is set based on the Kubernetes version.
+	// See https://github.com/heptio/kube-conformance.
+	Image string `json:"image,omitempty"`
+	// Focus is the ginkgo focus regex.
+	Focus string `json:"focus,omitempty"`
+	// Skip is the ginkgo skip regex.
+	Skip string `json:"skip,omitempty"`
+	// Parallel is true to run ginkgo tests in parallel.
+	Parallel bool `json:"parallel"`
+	// Timeout is the timeout to wait for the test completion.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// UploadResults is true to auto-upload the e2e log and junit results
+	// to "ResultsDirBucket", independent of "UploadTesterLogs".
+	UploadResults bool `json:"upload-results"`
+
+	// ResultsDir is the directory to download the e2e log and junit results.
+	// Must be left empty.
+	// This will be overwritten by cluster name.
+	ResultsDir       string `json:"results-dir,omitempty"`
+	ResultsDirBucket string `json:"results-dir-bucket,omitempty"`
+	ResultsDirURL    string `json:"results-dir-url,omitempty"`
+
+	// TestResultStatus is the status of last test run, either "Passed" or "Failed".
+	TestResultStatus string `json:"test-result-status,omitempty"`
+	// TestResultTotal is the number of test cases in the junit results of last test run.
+	TestResultTotal int `json:"test-result-total,omitempty"`
+	// TestResultPassed is the number of passed test cases of last test run.
+	TestResultPassed int `json:"test-result-passed,omitempty"`
+	// TestResultFailed is the number of failed test cases of last test run.
+	TestResultFailed int `json:"test-result-failed,omitempty"`
+	// TestResultSkipped is the number of skipped test cases of last test run.
+	TestResultSkipped int `json:"test-result-skipped,omitempty"`
+	// TestResultFailedTests is the list of failed test case names of last test run.
+	TestResultFailedTests []string `json:"test-result-failed-tests,omitempty"`
+	// TestResultTook is the duration of last test run.
+	TestResultTook string `json:"test-result-took,omitempty"`
+}
+
+// VPC defines VPC topology for EKS cluster.
+type VPC struct {
+	// UseExisting is true to use the existing VPC "VPCID" with subnets "SubnetIDs"
+	// and security group "SecurityGroupID", instead of creating a VPC stack.
+	// The existing VPC is validated for subnet tags and routes,
+	// and never deleted.
+	UseExisting bool `json:"use-existing"`
+
+	// CIDR is the CIDR block of VPC stack.
+	CIDR string `json:"cidr,omitempty"`
+	// AvailabilityZones is the number of availability zones for subnets.
+	// EKS requires subnets in at least 2 availability zones.
+	AvailabilityZones int `json:"availability-zones,omitempty"`
+	// PublicSubnetsPerAZ is the number of public subnets per availability zone.
+	PublicSubnetsPerAZ int `json:"public-subnets-per-az"`
+	// PrivateSubnetsPerAZ is the number of private subnets per availability zone,
+	// routed to the internet through NAT gateways in public subnets.
+	PrivateSubnetsPerAZ int `json:"private-subnets-per-az"`
+	// NATGatewayPerAZ is true to create a NAT gateway in every availability zone.
+	// Otherwise, all private subnets share one NAT gateway.
+	NATGatewayPerAZ bool `json:"nat-gateway-per-az"`
+
+	// EnableIPv6 is true to create dual-stack subnets.
+	// The VPC stack requests an Amazon-provided /56 IPv6 CIDR block,
+	// assigns a /64 block to each subnet, and routes IPv6 traffic of
+	// private subnets through an egress-only internet gateway.
+	// ALB Ingress Controller then creates dual-stack load balancers.
+	// For an existing VPC, all subnets must have IPv6 CIDR blocks.
+	EnableIPv6 bool `json:"enable-ipv6"`
+
+	// PrivateWorkerNodes is true to create worker nodes in private subnets only.
+	PrivateWorkerNodes bool `json:"private-worker-nodes"`
+	// EndpointPrivateAccess is true to enable private access to
+	// the Kubernetes API server endpoint within the VPC.
+	EndpointPrivateAccess bool `json:"endpoint-private-access"`
+	// DisableEndpointPublicAccess is true to disable public access to
+	// the Kubernetes API server endpoint. Then, the tester must run
+	// within the VPC to reach the cluster.
+	DisableEndpointPublicAccess bool `json:"disable-endpoint-public-access"`
+
+	// PublicSubnetIDs is the public subnet IDs.
+	PublicSubnetIDs []string `json:"public-subnet-ids,omitempty"` // read-only to user
+	// PrivateSubnetIDs is the private subnet IDs.
+	PrivateSubnetIDs []string `json:"private-subnet-ids,omitempty"` // read-only to user
+}
+
+// CNI defines Amazon VPC CNI plugin configuration and its test results.
+type CNI struct {
+	// ManifestPath is the local CNI manifest file path to apply.
+	// Takes precedence over "ManifestVersion".
+	ManifestPath string `json:"manifest-path,omitempty"`
+	// ManifestVersion is the pinned amazon-vpc-cni-k8s release version
+	// (e.g. "v1.3.0"), whose manifest is downloaded from GitHub.
+	// If both "ManifestPath" and "ManifestVersion" are empty,
+	// the CNI plugin installed by EKS is not updated.
+	// See https://github.com/aws/amazon-vpc-cni-k8s/releases.
+	ManifestVersion string `json:"manifest-version,omitempty"`
+	// ManifestURL is the manifest URL resolved from "ManifestVersion".
+	ManifestURL string `json:"manifest-url,omitempty"` // read-only to user
+
+	// Test is true to run CNI tests after worker nodes are ready.
+	Test bool `json:"test"`
+	// TestImage is the container image for CNI test pods,
+	// which must have "wget" and "httpd".
+	TestImage string `json:"test-image,omitempty"`
+	// TestSNATURL is the URL that returns the source public IP,
+	// to check SNAT of pod traffic to the internet.
+	TestSNATURL string `json:"test-snat-url,omitempty"`
+	// TestTimeout is the timeout for each CNI test.
+	TestTimeout time.Duration `json:"test-timeout,omitempty"`
+
+	// TestResultPodConnectivity is the pod-to-pod connectivity test result.
+	TestResultPodConnectivity string `json:"test-result-pod-connectivity,omitempty"`
+	// TestResultIPAllocation is the pod IP allocation test result.
+	TestResultIPAllocation string `json:"test-result-ip-allocation,omitempty"`
+	// TestResultSNAT is the SNAT test result.
+	TestResultSNAT string `json:"test-result-snat,omitempty"`
+	// TestResultWarmPool is the IP warm pool test result.
+	TestResultWarmPool string `json:"test-result-warm-pool,omitempty"`
+	// TestResultWarmPoolScaleUpTook is the duration that took to scale
+	// test pods to the node's maximum pods, with all pod IPs assigned.
+	TestResultWarmPoolScaleUpTook string `json:"test-result-warm-pool-scale-up-took,omitempty"`
+}
+
+// cniManifestURL returns the manifest URL of the amazon-vpc-cni-k8s release,
+// where manifests are stored under "config/<major.minor>".
+func cniManifestURL(ver string) (string, error) {
+	ss := strings.Split(strings.TrimPrefix(ver, "v"), ".")
+	if !strings.HasPrefix(ver, "v") || len(ss) != 3 {
+		return "", fmt.Errorf("CNI ManifestVersion %q is not valid (e.g. 'v1.3.0')", ver)
+	}
+	return fmt.Sprintf(
+		"https://raw.githubusercontent.com/aws/amazon-vpc-cni-k8s/%s/config/v%s.%s/aws-k8s-cni.yaml",
+		ver, ss[0], ss[1],
+	), nil
+}
+
+const (
+	// defaultConformanceFocus runs conformance tests only.
+	defaultConformanceFocus = `\[Conformance\]`
+	// defaultConformanceSkip skips alpha, disruptive, feature-gated, and flaky tests.
+	defaultConformanceSkip = `Alpha|\[(Disruptive|Feature:[^\]]+|Flaky)\]`
+)
+
+// conformanceImages maps each EKS Kubernetes version to its conformance image.
+var conformanceImages = map[string]string{
+	"1.10": "gcr.io/heptio-images/kube-conformance:v1.10.11",
+	"1.11": "gcr.io/heptio-images/kube-conformance:v1.11.5",
 }
 
 // NewDefault returns a copy of the default configuration.
@@ -395,6 +664,11 @@ func init() {
 		defaultConfig.KubectlDownloadURL = strings.Replace(defaultConfig.KubectlDownloadURL, "linux", "darwin", -1)
 		defaultConfig.AWSIAMAuthenticatorDownloadURL = strings.Replace(defaultConfig.AWSIAMAuthenticatorDownloadURL, "linux", "darwin", -1)
 	}
+	if runtime.GOARCH == "arm64" {
+		defaultConfig.AWSK8sTesterDownloadURL = strings.Replace(defaultConfig.AWSK8sTesterDownloadURL, "amd64", "arm64", -1)
+		defaultConfig.KubectlDownloadURL = strings.Replace(defaultConfig.KubectlDownloadURL, "amd64", "arm64", -1)
+		defaultConfig.AWSIAMAuthenticatorDownloadURL = strings.Replace(defaultConfig.AWSIAMAuthenticatorDownloadURL, "amd64", "arm64", -1)
+	}
 	sshDir := filepath.Join(homedir.HomeDir(), ".ssh")
 	if err := os.MkdirAll(sshDir, 0700); err != nil {
 		panic(fmt.Errorf("failed to mkdir %q (%v)", sshDir, err))
@@ -464,7 +738,9 @@ var defaultConfig = Config{
 	UploadTesterLogs:       false,
 	UploadKubeConfig:       false,
 	UploadWorkerNodeLogs:   false,
+	UploadDiagnostics:      false,
 	UploadBucketExpireDays: 2,
+	ArtifactStore:          "s3",
 
 	ClusterState: &ClusterState{},
 	ALBIngressController: &ALBIngressController{
@@ -486,10 +762,37 @@ var defaultConfig = Config{
 		TestServerRoutes:         1,
 		TestClients:              200,
 		TestClientRequests:       20000,
+		TestClientMode:           "closed-loop",
 		TestResponseSize:         40 * 1024, // 40 KB
 		TestClientErrorThreshold: 10,
+		TestAccessLogsTimeout:    15 * time.Minute,
 		TestExpectQPS:            20000,
 	},
+	Conformance: &Conformance{
+		Focus:         defaultConformanceFocus,
+		Skip:          defaultConformanceSkip,
+		Timeout:       3 * time.Hour,
+		UploadResults: true,
+	},
+	CNI: &CNI{
+		ManifestVersion: "v1.3.0",
+		Test:            false,
+		TestImage:       "busybox:1.29",
+		TestSNATURL:     "http://checkip.amazonaws.com",
+		TestTimeout:     10 * time.Minute,
+	},
+	VPC: &VPC{
+		UseExisting:         false,
+		CIDR:                "192.168.0.0/16",
+		AvailabilityZones:   3,
+		PublicSubnetsPerAZ:  1,
+		PrivateSubnetsPerAZ: 0,
+	},
+	Spot: &ec2config.Spot{
+		Enable:             false,
+		InstancePools:      2,
+		ReplaceInterrupted: true,
+	},
 }
 
 // Load loads configuration from YAML.
@@ -522,6 +825,18 @@ func Load(p string) (cfg *Config, err error) {
 	if cfg.ALBIngressController == nil {
 		cfg.ALBIngressController = &ALBIngressController{}
 	}
+	if cfg.Conformance == nil {
+		cfg.Conformance = &Conformance{}
+	}
+	if cfg.CNI == nil {
+		cfg.CNI = &CNI{}
+	}
+	if cfg.VPC == nil {
+		cfg.VPC = &VPC{}
+	}
+	if cfg.Spot == nil {
+		cfg.Spot = &ec2config.Spot{}
+	}
 
 	if cfg.ConfigPath != p {
 		cfg.ConfigPath = p
@@ -590,8 +905,12 @@ const (
 	maxTestClients = 1000
 	// maxTestClientRequests is the maximum number of requests.
 	maxTestClientRequests = 50000
+	// maxTestLoadGenerators is the maximum number of load generator pods.
+	maxTestLoadGenerators = 100
 	// maxTestResponseSize is the maximum response size for ingress test server.
 	maxTestResponseSize = 500 * 1024 // 500 KB == 4000 Kbit
+	// maxTestAccessLogsTimeout is the maximum wait for ALB access logs.
+	maxTestAccessLogsTimeout = time.Hour
 )
 
 // ValidateAndSetDefaults returns an error for invalid configurations.
@@ -619,15 +938,19 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 	if cfg.ClusterName == "" {
 		return errors.New("ClusterName is empty")
 	}
-	if cfg.WorkerNodeAMI == "" {
-		return errors.New("EKS WorkerNodeAMI is not specified")
-	}
 	if cfg.WorkerNodeInstanceType == "" {
 		return errors.New("EKS WorkerNodeInstanceType is not specified")
 	}
 	if !checkEC2InstanceType(cfg.WorkerNodeInstanceType) {
 		return fmt.Errorf("EKS WorkerNodeInstanceType %q is not valid", cfg.WorkerNodeInstanceType)
 	}
+	if ec2.InstanceTypes[cfg.WorkerNodeInstanceType].Architecture != "x86_64" && cfg.WorkerNodeAMI == defaultConfig.WorkerNodeAMI {
+		cfg.WorkerNodeAMI = ""
+	}
+	// Amazon VPC CNI plugin limits pods by network interfaces
+	if ec2.InstanceTypes[cfg.WorkerNodeInstanceType].MaxPods == 0 {
+		return fmt.Errorf("EKS WorkerNodeInstanceType %q has unknown max pods", cfg.WorkerNodeInstanceType)
+	}
 	if cfg.ALBIngressController != nil && cfg.ALBIngressController.TestServerReplicas > 0 {
 		if !checkMaxPods(cfg.WorkerNodeInstanceType, cfg.WorkerNodeASGMax, cfg.ALBIngressController.TestServerReplicas) {
 			return fmt.Errorf(
@@ -652,6 +975,15 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 	if cfg.WorkerNodeVolumeSizeGB == 0 {
 		cfg.WorkerNodeVolumeSizeGB = defaultWorkderNodeVolumeSizeGB
 	}
+	if cfg.WorkerNodeCFTemplatePath != "" && !exist(cfg.WorkerNodeCFTemplatePath) {
+		return fmt.Errorf("EKS WorkerNodeCFTemplatePath %q does not exist", cfg.WorkerNodeCFTemplatePath)
+	}
+	if cfg.WorkerNodeCFTemplateVersion == "" {
+		cfg.WorkerNodeCFTemplateVersion = workerNodeCFTemplateVersions[cfg.KubernetesVersion]
+	}
+	if !checkWorkerNodeCFTemplateVersion(cfg.WorkerNodeCFTemplateVersion) {
+		return fmt.Errorf("EKS WorkerNodeCFTemplateVersion %q is not valid", cfg.WorkerNodeCFTemplateVersion)
+	}
 	if ok := checkEKSEp(cfg.AWSCustomEndpoint); !ok {
 		return fmt.Errorf("AWSCustomEndpoint %q is not valid", cfg.AWSCustomEndpoint)
 	}
@@ -700,6 +1032,29 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 
 	cfg.KubeConfigPathBucket = filepath.Join(cfg.ClusterName, "kubeconfig")
 
+	switch cfg.ArtifactStore {
+	case "s3":
+	case "local":
+		if cfg.ArtifactStoreDir == "" {
+			cfg.ArtifactStoreDir = filepath.Join(os.TempDir(), cfg.ClusterName+"-artifacts")
+		}
+	case "http":
+		if cfg.ArtifactStoreURL == "" {
+			return errors.New("'http' artifact store requires ArtifactStoreURL")
+		}
+	default:
+		return fmt.Errorf("artifact store %q is not supported", cfg.ArtifactStore)
+	}
+	cfg.ArtifactManifestPath = cfg.ConfigPath + ".artifacts.json"
+	cfg.ArtifactManifestPathBucket = filepath.Join(cfg.ClusterName, "artifacts.json")
+
+	cfg.DiagnosticsOutputToUploadPath = fmt.Sprintf(
+		"%s.%s.diagnostics.tar.gz",
+		cfg.ConfigPath,
+		cfg.ClusterName,
+	)
+	cfg.DiagnosticsOutputToUploadPathBucket = filepath.Join(cfg.ClusterName, "diagnostics.tar.gz")
+
 	cfg.ALBIngressController.IngressTestServerDeploymentServiceSpecPath = fmt.Sprintf(
 		"%s.%s.alb.ingress-test-server.yaml",
 		cfg.ConfigPath,
@@ -710,6 +1065,16 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 		"alb.ingress-test-server.deployment.service.yaml",
 	)
 
+	cfg.ALBIngressController.LoadGeneratorSpecPath = fmt.Sprintf(
+		"%s.%s.alb.load-generator.yaml",
+		cfg.ConfigPath,
+		cfg.ClusterName,
+	)
+	cfg.ALBIngressController.LoadGeneratorSpecPathBucket = filepath.Join(
+		cfg.ClusterName,
+		"alb.load-generator.job.yaml",
+	)
+
 	cfg.ALBIngressController.IngressControllerSpecPath = fmt.Sprintf(
 		"%s.%s.alb.controller.deployment.service.yaml",
 		cfg.ConfigPath,
@@ -749,6 +1114,28 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 		cfg.ClusterName,
 		"alb.metrics.txt",
 	)
+
+	cfg.ALBIngressController.AccessLogsOutputToUploadPath = fmt.Sprintf(
+		"%s.%s.alb.access-logs.csv",
+		cfg.ConfigPath,
+		cfg.ClusterName,
+	)
+	cfg.ALBIngressController.AccessLogsOutputToUploadPathBucket = filepath.Join(
+		cfg.ClusterName,
+		"alb.access-logs.csv",
+	)
+
+	if cfg.Conformance != nil {
+		cfg.Conformance.ResultsDir = fmt.Sprintf(
+			"%s.%s.conformance",
+			cfg.ConfigPath,
+			cfg.ClusterName,
+		)
+		cfg.Conformance.ResultsDirBucket = filepath.Join(
+			cfg.ClusterName,
+			"conformance",
+		)
+	}
 	////////////////////////////////////////////////////////////////////////
 
 	if cfg.AWSCredentialToMountPath != "" && os.Getenv("AWS_SHARED_CREDENTIALS_FILE") == "" {
@@ -863,11 +1250,236 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 		if cfg.ALBIngressController.TestResponseSize > maxTestResponseSize {
 			return fmt.Errorf("cannot create AWS ALB Ingress Controller with test response size %d (> max size %d)", cfg.ALBIngressController.TestResponseSize, maxTestResponseSize)
 		}
+
+		if cfg.ALBIngressController.TestLoadGenerators > 0 {
+			if cfg.AWSK8sTesterImage == "" {
+				return errors.New("load generators require AWSK8sTesterImage")
+			}
+			if cfg.ALBIngressController.TestLoadGenerators > maxTestLoadGenerators {
+				return fmt.Errorf("cannot create AWS ALB Ingress Controller with test load generators %d (> max size %d)", cfg.ALBIngressController.TestLoadGenerators, maxTestLoadGenerators)
+			}
+			if cfg.ALBIngressController.TestClients < 2 {
+				return fmt.Errorf("load generators require at least 2 test clients, got %d", cfg.ALBIngressController.TestClients)
+			}
+		}
+
+		switch cfg.ALBIngressController.TestClientMode {
+		case "":
+			cfg.ALBIngressController.TestClientMode = "closed-loop"
+		case "closed-loop":
+		case "open-loop":
+			if cfg.ALBIngressController.TestMode != "ingress-test-server" {
+				return fmt.Errorf("'open-loop' client mode is not supported with test mode %q", cfg.ALBIngressController.TestMode)
+			}
+			if cfg.ALBIngressController.TestLoadGenerators > 0 {
+				return errors.New("'open-loop' client mode is not supported with load generators")
+			}
+			if cfg.ALBIngressController.TestClientOpenLoopStartQPS <= 0 {
+				return fmt.Errorf("'open-loop' client mode requires start QPS, got %f", cfg.ALBIngressController.TestClientOpenLoopStartQPS)
+			}
+			if cfg.ALBIngressController.TestClientOpenLoopEndQPS == 0 {
+				cfg.ALBIngressController.TestClientOpenLoopEndQPS = cfg.ALBIngressController.TestClientOpenLoopStartQPS
+			}
+			if cfg.ALBIngressController.TestClientOpenLoopSteps == 1 && cfg.ALBIngressController.TestClientOpenLoopEndQPS != cfg.ALBIngressController.TestClientOpenLoopStartQPS {
+				return errors.New("'open-loop' client mode requires at least 2 steps to change QPS")
+			}
+			if cfg.ALBIngressController.TestClientOpenLoopMinutes == 0 {
+				cfg.ALBIngressController.TestClientOpenLoopMinutes = cfg.ALBIngressController.TestScalabilityMinutes
+			}
+			if cfg.ALBIngressController.TestClientOpenLoopMinutes <= 0 {
+				return fmt.Errorf("'open-loop' client mode requires duration, got %d minutes", cfg.ALBIngressController.TestClientOpenLoopMinutes)
+			}
+		default:
+			return fmt.Errorf("ALB Ingress test client mode %q is not supported", cfg.ALBIngressController.TestClientMode)
+		}
+
+		if cfg.ALBIngressController.TestAccessLogsTimeout < 0 || cfg.ALBIngressController.TestAccessLogsTimeout > maxTestAccessLogsTimeout {
+			return fmt.Errorf("ALB Ingress test access logs timeout %v is out of range [0, %v]", cfg.ALBIngressController.TestAccessLogsTimeout, maxTestAccessLogsTimeout)
+		}
+	}
+
+	if cfg.Conformance != nil {
+		cfg.setConformanceDefaults()
+	}
+
+	if cfg.CNI != nil {
+		switch {
+		case cfg.CNI.ManifestPath != "":
+			if !exist(cfg.CNI.ManifestPath) {
+				return fmt.Errorf("CNI ManifestPath %q does not exist", cfg.CNI.ManifestPath)
+			}
+			cfg.CNI.ManifestURL = ""
+		case cfg.CNI.ManifestVersion != "":
+			url, err := cniManifestURL(cfg.CNI.ManifestVersion)
+			if err != nil {
+				return err
+			}
+			cfg.CNI.ManifestURL = url
+		}
+		if cfg.CNI.TestImage == "" {
+			cfg.CNI.TestImage = "busybox:1.29"
+		}
+		if cfg.CNI.TestTimeout == 0 {
+			cfg.CNI.TestTimeout = 10 * time.Minute
+		}
+	}
+
+	if cfg.VPC != nil {
+		if err := cfg.validateVPC(); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Spot != nil {
+		if err := cfg.validateSpot(); err != nil {
+			return err
+		}
 	}
 
 	return cfg.Sync()
 }
 
+func (cfg *Config) setConformanceDefaults() {
+	if cfg.Conformance.Image == "" {
+		cfg.Conformance.Image = conformanceImages[cfg.KubernetesVersion]
+	}
+	if cfg.Conformance.Focus == "" {
+		cfg.Conformance.Focus = defaultConformanceFocus
+	}
+	if cfg.Conformance.Skip == "" {
+		cfg.Conformance.Skip = defaultConformanceSkip
+	}
+	if cfg.Conformance.Timeout == 0 {
+		cfg.Conformance.Timeout = 3 * time.Hour
+	}
+}
+
+func (cfg *Config) validateSpot() error {
+	if err := cfg.Spot.ValidateAndSetDefaults(cfg.WorkerNodeInstanceType); err != nil {
+		return err
+	}
+	if !cfg.Spot.Enable {
+		return nil
+	}
+	if cfg.WorkerNodeCFTemplatePath != "" {
+		return errors.New("Spot is not supported with WorkerNodeCFTemplatePath")
+	}
+	for _, tp := range cfg.Spot.InstanceTypes {
+		if ec2.InstanceTypes[tp].MaxPods == 0 {
+			return fmt.Errorf("Spot InstanceTypes %q has unknown max pods", tp)
+		}
+	}
+	// any fallback instance type may run all test server pods
+	if cfg.ALBIngressController != nil && cfg.ALBIngressController.TestServerReplicas > 0 {
+		for _, tp := range cfg.Spot.InstanceTypes {
+			if !checkMaxPods(tp, cfg.WorkerNodeASGMax, cfg.ALBIngressController.TestServerReplicas) {
+				return fmt.Errorf(
+					"Spot InstanceTypes %q only supports %d pods per node (ASG Max %d, test server replicas %d)",
+					tp,
+					ec2.InstanceTypes[tp].MaxPods,
+					cfg.WorkerNodeASGMax,
+					cfg.ALBIngressController.TestServerReplicas,
+				)
+			}
+		}
+	}
+	return nil
+}
+
+func (cfg *Config) validateVPC() error {
+	if cfg.VPC.UseExisting {
+		if cfg.VPCID == "" {
+			return errors.New("VPC UseExisting requires VPCID")
+		}
+		if len(cfg.SubnetIDs) == 0 {
+			return errors.New("VPC UseExisting requires SubnetIDs")
+		}
+		if cfg.SecurityGroupID == "" {
+			return errors.New("VPC UseExisting requires SecurityGroupID")
+		}
+	} else {
+		if cfg.VPC.CIDR == "" {
+			cfg.VPC.CIDR = "192.168.0.0/16"
+		}
+		if cfg.VPC.AvailabilityZones == 0 {
+			cfg.VPC.AvailabilityZones = 3
+		}
+		if cfg.VPC.PublicSubnetsPerAZ == 0 && cfg.VPC.PrivateSubnetsPerAZ == 0 {
+			cfg.VPC.PublicSubnetsPerAZ = 1
+		}
+		if cfg.VPC.AvailabilityZones < 2 {
+			return fmt.Errorf("VPC AvailabilityZones %d is not valid (EKS requires at least 2)", cfg.VPC.AvailabilityZones)
+		}
+		if cfg.VPC.PublicSubnetsPerAZ < 0 || cfg.VPC.PrivateSubnetsPerAZ < 0 {
+			return fmt.Errorf("VPC subnets per AZ %d and %d is not valid", cfg.VPC.PublicSubnetsPerAZ, cfg.VPC.PrivateSubnetsPerAZ)
+		}
+		if cfg.VPC.PrivateSubnetsPerAZ > 0 && cfg.VPC.PublicSubnetsPerAZ == 0 {
+			return errors.New("VPC private subnets require public subnets for NAT gateways")
+		}
+		if cfg.VPC.PrivateWorkerNodes && cfg.VPC.PrivateSubnetsPerAZ == 0 {
+			return errors.New("VPC PrivateWorkerNodes requires private subnets")
+		}
+		n := cfg.VPC.AvailabilityZones * (cfg.VPC.PublicSubnetsPerAZ + cfg.VPC.PrivateSubnetsPerAZ)
+		if err := checkVPCCIDR(cfg.VPC.CIDR, n); err != nil {
+			return err
+		}
+		if cfg.VPC.EnableIPv6 && n > 256 {
+			return fmt.Errorf("VPC EnableIPv6 supports up to 256 subnets in /56 IPv6 CIDR block, got %d", n)
+		}
+	}
+	if cfg.VPC.PrivateWorkerNodes && cfg.EnableWorkerNodeSSH {
+		return errors.New("VPC PrivateWorkerNodes does not support EnableWorkerNodeSSH (worker nodes have no public IP)")
+	}
+	if cfg.VPC.DisableEndpointPublicAccess && !cfg.VPC.EndpointPrivateAccess {
+		return errors.New("VPC DisableEndpointPublicAccess requires EndpointPrivateAccess")
+	}
+	return nil
+}
+
+// checkVPCCIDR checks that the VPC CIDR block can be split into n subnets.
+// VPC netmask must be between /16 and /28, and so are subnets.
+func checkVPCCIDR(cidr string, n int) error {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return fmt.Errorf("VPC CIDR %q is not valid (%v)", cidr, err)
+	}
+	ones, bits := ipNet.Mask.Size()
+	if bits != 32 || ones < 16 || ones > 28 {
+		return fmt.Errorf("VPC CIDR %q is not valid (netmask must be between /16 and /28)", cidr)
+	}
+	if ones+VPCSubnetNewBits(n) > 28 {
+		return fmt.Errorf("VPC CIDR %q is too small for %d subnets", cidr, n)
+	}
+	return nil
+}
+
+// VPCSubnetNewBits returns the number of additional netmask bits
+// to split the VPC CIDR block into n subnets of equal size.
+func VPCSubnetNewBits(n int) int {
+	return bits.Len(uint(n - 1))
+}
+
+// WorkerNodeSubnetIDs returns the subnet IDs to create worker nodes in.
+func (cfg *Config) WorkerNodeSubnetIDs() []string {
+	if cfg.VPC != nil {
+		if cfg.VPC.PrivateWorkerNodes && len(cfg.VPC.PrivateSubnetIDs) > 0 {
+			return cfg.VPC.PrivateSubnetIDs
+		}
+		if !cfg.VPC.PrivateWorkerNodes && len(cfg.VPC.PublicSubnetIDs) > 0 {
+			return cfg.VPC.PublicSubnetIDs
+		}
+	}
+	return cfg.SubnetIDs
+}
+
+// LoadBalancerSubnetIDs returns the subnet IDs for internet-facing load balancers.
+func (cfg *Config) LoadBalancerSubnetIDs() []string {
+	if cfg.VPC != nil && len(cfg.VPC.PublicSubnetIDs) > 0 {
+		return cfg.VPC.PublicSubnetIDs
+	}
+	return cfg.SubnetIDs
+}
+
 // SetClusterUpTook updates 'ClusterUpTook' field.
 func (cfg *Config) SetClusterUpTook(d time.Duration) {
 	cfg.ClusterState.upTook = d
@@ -881,8 +1493,12 @@ func (cfg *Config) SetIngressUpTook(d time.Duration) {
 }
 
 const (
-	envPfx    = "AWS_K8S_TESTER_EKS_"
-	envPfxALB = "AWS_K8S_TESTER_EKS_ALB_"
+	envPfx            = "AWS_K8S_TESTER_EKS_"
+	envPfxALB         = "AWS_K8S_TESTER_EKS_ALB_"
+	envPfxConformance = "AWS_K8S_TESTER_EKS_CONFORMANCE_"
+	envPfxCNI         = "AWS_K8S_TESTER_EKS_CNI_"
+	envPfxVPC         = "AWS_K8S_TESTER_EKS_VPC_"
+	envPfxSpot        = "AWS_K8S_TESTER_EKS_SPOT_"
 )
 
 // UpdateFromEnvs updates fields from environmental variables.
@@ -987,6 +1603,14 @@ func (cfg *Config) UpdateFromEnvs() error {
 			vv2.Field(i).SetBool(bb)
 
 		case reflect.Int, reflect.Int32, reflect.Int64:
+			if vv2.Field(i).Type() == reflect.TypeOf(time.Duration(0)) {
+				dv, err := time.ParseDuration(sv)
+				if err != nil {
+					return fmt.Errorf("failed to parse %q (%q, %v)", sv, env, err)
+				}
+				vv2.Field(i).SetInt(int64(dv))
+				continue
+			}
 			iv, err := strconv.ParseInt(sv, 10, 64)
 			if err != nil {
 				return fmt.Errorf("failed to parse %q (%q, %v)", sv, env, err)
@@ -1013,6 +1637,38 @@ func (cfg *Config) UpdateFromEnvs() error {
 	}
 	cfg.ALBIngressController = &av
 
+	if cc.Conformance != nil {
+		cv := *cc.Conformance
+		if err := envutil.UpdateFromEnvs(envPfxConformance, &cv); err != nil {
+			return err
+		}
+		cfg.Conformance = &cv
+	}
+
+	if cc.CNI != nil {
+		nv := *cc.CNI
+		if err := envutil.UpdateFromEnvs(envPfxCNI, &nv); err != nil {
+			return err
+		}
+		cfg.CNI = &nv
+	}
+
+	if cc.VPC != nil {
+		vv := *cc.VPC
+		if err := envutil.UpdateFromEnvs(envPfxVPC, &vv); err != nil {
+			return err
+		}
+		cfg.VPC = &vv
+	}
+
+	if cc.Spot != nil {
+		sv := *cc.Spot
+		if err := envutil.UpdateFromEnvs(envPfxSpot, &sv); err != nil {
+			return err
+		}
+		cfg.Spot = &sv
+	}
+
 	return nil
 }
 
@@ -1027,6 +1683,22 @@ var supportedKubernetesVersions = map[string]struct{}{
 	"1.11": {},
 }
 
+// workerNodeCFTemplateVersions maps each EKS Kubernetes version to
+// its default worker node group CloudFormation template version.
+var workerNodeCFTemplateVersions = map[string]string{
+	"1.10": "2018-08-30",
+	"1.11": "2019-02-11",
+}
+
+func checkWorkerNodeCFTemplateVersion(s string) bool {
+	for _, v := range workerNodeCFTemplateVersions {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 func checkRegion(s string) (ok bool) {
 	_, ok = supportedRegions[s]
 	return ok
@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
 )
 
 func TestConfig(t *testing.T) {
@@ -23,6 +25,32 @@ func TestConfig(t *testing.T) {
 	t.Log(err)
 }
 
+func TestWorkerNodeArchitecture(t *testing.T) {
+	cfg := NewDefault()
+	cfg.WorkerNodeInstanceType = "a1.large"
+	cfg.WorkerNodeASGMax = 1
+	cfg.ALBIngressController.TestServerReplicas = 600
+
+	// fails after the architecture check with too many replicas
+	if err := cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error")
+	}
+	if cfg.WorkerNodeAMI != "" {
+		t.Fatalf("expected empty WorkerNodeAMI to look up arm64 AMI, got %q", cfg.WorkerNodeAMI)
+	}
+}
+
+func TestWorkerNodeCFTemplateVersion(t *testing.T) {
+	for ver := range supportedKubernetesVersions {
+		if !checkWorkerNodeCFTemplateVersion(workerNodeCFTemplateVersions[ver]) {
+			t.Fatalf("no worker node CloudFormation template for Kubernetes %q", ver)
+		}
+	}
+	if checkWorkerNodeCFTemplateVersion("2017-01-01") {
+		t.Fatal("expected invalid template version '2017-01-01'")
+	}
+}
+
 func TestEnv(t *testing.T) {
 	cfg := NewDefault()
 
@@ -50,11 +78,15 @@ func TestEnv(t *testing.T) {
 	os.Setenv("AWS_K8S_TESTER_EKS_WORKER_NODE_ASG_MIN", "5")
 	os.Setenv("AWS_K8S_TESTER_EKS_WORKER_NODE_ASG_MAX", "10")
 	os.Setenv("AWS_K8S_TESTER_EKS_WORKER_NODE_ASG_DESIRED_CAPACITY", "7")
+	os.Setenv("AWS_K8S_TESTER_EKS_WORKER_NODE_CF_TEMPLATE_VERSION", "2018-08-30")
 	os.Setenv("AWS_K8S_TESTER_EKS_LOG_DEBUG", "true")
 	os.Setenv("AWS_K8S_TESTER_EKS_UPLOAD_TESTER_LOGS", "true")
 	os.Setenv("AWS_K8S_TESTER_EKS_UPLOAD_KUBECONFIG", "true")
 	os.Setenv("AWS_K8S_TESTER_EKS_UPLOAD_WORKER_NODE_LOGS", "true")
+	os.Setenv("AWS_K8S_TESTER_EKS_UPLOAD_DIAGNOSTICS", "true")
 	os.Setenv("AWS_K8S_TESTER_EKS_UPLOAD_BUCKET_EXPIRE_DAYS", "3")
+	os.Setenv("AWS_K8S_TESTER_EKS_ARTIFACT_STORE", "local")
+	os.Setenv("AWS_K8S_TESTER_EKS_ARTIFACT_STORE_DIR", "/tmp/artifacts")
 	os.Setenv("AWS_K8S_TESTER_EKS_WAIT_BEFORE_DOWN", "2h")
 	os.Setenv("AWS_K8S_TESTER_EKS_ALB_TEST_SCALABILITY_MINUTES", "3")
 	os.Setenv("AWS_K8S_TESTER_EKS_ALB_UPLOAD_TESTER_LOGS", "true")
@@ -64,6 +96,24 @@ func TestEnv(t *testing.T) {
 	os.Setenv("AWS_K8S_TESTER_EKS_ALB_TEST_SCALABILITY", "false")
 	os.Setenv("AWS_K8S_TESTER_EKS_ALB_TEST_METRICS", "false")
 	os.Setenv("AWS_K8S_TESTER_EKS_ALB_INGRESS_CONTROLLER_IMAGE", "quay.io/coreos/alb-ingress-controller:1.0-beta.7")
+	os.Setenv("AWS_K8S_TESTER_EKS_ALB_TEST_CLIENT_MODE", "open-loop")
+	os.Setenv("AWS_K8S_TESTER_EKS_ALB_TEST_CLIENT_OPEN_LOOP_START_QPS", "500.5")
+	os.Setenv("AWS_K8S_TESTER_EKS_ALB_TEST_CLIENT_OPEN_LOOP_STEPS", "5")
+	os.Setenv("AWS_K8S_TESTER_EKS_ALB_TEST_ACCESS_LOGS_TIMEOUT", "30m")
+	os.Setenv("AWS_K8S_TESTER_EKS_CONFORMANCE_FOCUS", `\[sig-network\].*\[Conformance\]`)
+	os.Setenv("AWS_K8S_TESTER_EKS_CONFORMANCE_PARALLEL", "true")
+	os.Setenv("AWS_K8S_TESTER_EKS_CONFORMANCE_TIMEOUT", "90m")
+	os.Setenv("AWS_K8S_TESTER_EKS_CONFORMANCE_UPLOAD_RESULTS", "false")
+	os.Setenv("AWS_K8S_TESTER_EKS_CNI_MANIFEST_VERSION", "v1.2.1")
+	os.Setenv("AWS_K8S_TESTER_EKS_CNI_TEST", "true")
+	os.Setenv("AWS_K8S_TESTER_EKS_CNI_TEST_TIMEOUT", "20m")
+	os.Setenv("AWS_K8S_TESTER_EKS_VPC_CIDR", "10.0.0.0/16")
+	os.Setenv("AWS_K8S_TESTER_EKS_VPC_PRIVATE_SUBNETS_PER_AZ", "2")
+	os.Setenv("AWS_K8S_TESTER_EKS_VPC_PRIVATE_WORKER_NODES", "true")
+	os.Setenv("AWS_K8S_TESTER_EKS_VPC_ENABLE_IPV6", "true")
+	os.Setenv("AWS_K8S_TESTER_EKS_SPOT_ENABLE", "true")
+	os.Setenv("AWS_K8S_TESTER_EKS_SPOT_INSTANCE_TYPES", "m5.xlarge,c5.xlarge")
+	os.Setenv("AWS_K8S_TESTER_EKS_SPOT_ON_DEMAND_PERCENTAGE_ABOVE_BASE_CAPACITY", "25")
 
 	defer func() {
 		os.Unsetenv("AWS_K8S_TESTER_EKS_AWS_K8S_TESTER_DOWNLOAD_URL")
@@ -90,11 +140,15 @@ func TestEnv(t *testing.T) {
 		os.Unsetenv("AWS_K8S_TESTER_EKS_WORKER_NODE_ASG_MIN")
 		os.Unsetenv("AWS_K8S_TESTER_EKS_WORKER_NODE_ASG_MAX")
 		os.Unsetenv("AWS_K8S_TESTER_EKS_WORKER_NODE_ASG_DESIRED_CAPACITY")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_WORKER_NODE_CF_TEMPLATE_VERSION")
 		os.Unsetenv("AWS_K8S_TESTER_EKS_LOG_DEBUG")
 		os.Unsetenv("AWS_K8S_TESTER_EKS_UPLOAD_TESTER_LOGS")
 		os.Unsetenv("AWS_K8S_TESTER_EKS_UPLOAD_KUBECONFIG")
 		os.Unsetenv("AWS_K8S_TESTER_EKS_UPLOAD_WORKER_NODE_LOGS")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_UPLOAD_DIAGNOSTICS")
 		os.Unsetenv("AWS_K8S_TESTER_EKS_UPLOAD_BUCKET_EXPIRE_DAYS")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_ARTIFACT_STORE")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_ARTIFACT_STORE_DIR")
 		os.Unsetenv("AWS_K8S_TESTER_EKS_WAIT_BEFORE_DOWN")
 		os.Unsetenv("AWS_K8S_TESTER_EKS_ALB_TEST_SCALABILITY_MINUTES")
 		os.Unsetenv("AWS_K8S_TESTER_EKS_ALB_UPLOAD_TESTER_LOGS")
@@ -104,6 +158,24 @@ func TestEnv(t *testing.T) {
 		os.Unsetenv("AWS_K8S_TESTER_EKS_ALB_TEST_SCALABILITY")
 		os.Unsetenv("AWS_K8S_TESTER_EKS_ALB_TEST_METRICS")
 		os.Unsetenv("AWS_K8S_TESTER_EKS_ALB_INGRESS_CONTROLLER_IMAGE")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_ALB_TEST_CLIENT_MODE")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_ALB_TEST_CLIENT_OPEN_LOOP_START_QPS")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_ALB_TEST_CLIENT_OPEN_LOOP_STEPS")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_ALB_TEST_ACCESS_LOGS_TIMEOUT")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_CONFORMANCE_FOCUS")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_CONFORMANCE_PARALLEL")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_CONFORMANCE_TIMEOUT")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_CONFORMANCE_UPLOAD_RESULTS")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_CNI_MANIFEST_VERSION")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_CNI_TEST")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_CNI_TEST_TIMEOUT")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_VPC_CIDR")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_VPC_PRIVATE_SUBNETS_PER_AZ")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_VPC_PRIVATE_WORKER_NODES")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_VPC_ENABLE_IPV6")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_SPOT_ENABLE")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_SPOT_INSTANCE_TYPES")
+		os.Unsetenv("AWS_K8S_TESTER_EKS_SPOT_ON_DEMAND_PERCENTAGE_ABOVE_BASE_CAPACITY")
 	}()
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
@@ -179,6 +251,9 @@ func TestEnv(t *testing.T) {
 	if cfg.WorkerNodeASGDesiredCapacity != 7 {
 		t.Fatalf("worker nodes desired capacity expected 7, got %q", cfg.WorkerNodeASGDesiredCapacity)
 	}
+	if cfg.WorkerNodeCFTemplateVersion != "2018-08-30" {
+		t.Fatalf("WorkerNodeCFTemplateVersion expected 2018-08-30, got %q", cfg.WorkerNodeCFTemplateVersion)
+	}
 	if cfg.ALBIngressController.TestScalabilityMinutes != 3 {
 		t.Fatalf("alb target type expected 3, got %d", cfg.ALBIngressController.TestScalabilityMinutes)
 	}
@@ -200,9 +275,18 @@ func TestEnv(t *testing.T) {
 	if !cfg.UploadWorkerNodeLogs {
 		t.Fatalf("UploadWorkerNodeLogs expected true, got %v", cfg.UploadWorkerNodeLogs)
 	}
+	if !cfg.UploadDiagnostics {
+		t.Fatalf("UploadDiagnostics expected true, got %v", cfg.UploadDiagnostics)
+	}
 	if cfg.UploadBucketExpireDays != 3 {
 		t.Fatalf("UploadBucketExpireDays expected 3, got %d", cfg.UploadBucketExpireDays)
 	}
+	if cfg.ArtifactStore != "local" {
+		t.Fatalf("ArtifactStore expected \"local\", got %q", cfg.ArtifactStore)
+	}
+	if cfg.ArtifactStoreDir != "/tmp/artifacts" {
+		t.Fatalf("ArtifactStoreDir expected \"/tmp/artifacts\", got %q", cfg.ArtifactStoreDir)
+	}
 	if cfg.WaitBeforeDown != 2*time.Hour {
 		t.Fatalf("wait before down expected 2h, got %v", cfg.WaitBeforeDown)
 	}
@@ -224,4 +308,177 @@ func TestEnv(t *testing.T) {
 	if cfg.ALBIngressController.TestMetrics {
 		t.Fatalf("cfg.ALBIngressController.TestMetrics expected 'false', got %v", cfg.ALBIngressController.TestMetrics)
 	}
+	if cfg.ALBIngressController.TestClientMode != "open-loop" {
+		t.Fatalf("cfg.ALBIngressController.TestClientMode expected 'open-loop', got %q", cfg.ALBIngressController.TestClientMode)
+	}
+	if cfg.ALBIngressController.TestClientOpenLoopStartQPS != 500.5 {
+		t.Fatalf("cfg.ALBIngressController.TestClientOpenLoopStartQPS expected 500.5, got %v", cfg.ALBIngressController.TestClientOpenLoopStartQPS)
+	}
+	if cfg.ALBIngressController.TestClientOpenLoopSteps != 5 {
+		t.Fatalf("cfg.ALBIngressController.TestClientOpenLoopSteps expected 5, got %d", cfg.ALBIngressController.TestClientOpenLoopSteps)
+	}
+	if cfg.ALBIngressController.TestAccessLogsTimeout != 30*time.Minute {
+		t.Fatalf("cfg.ALBIngressController.TestAccessLogsTimeout expected 30m, got %v", cfg.ALBIngressController.TestAccessLogsTimeout)
+	}
+	if cfg.Conformance.Focus != `\[sig-network\].*\[Conformance\]` {
+		t.Fatalf("unexpected cfg.Conformance.Focus %q", cfg.Conformance.Focus)
+	}
+	if !cfg.Conformance.Parallel {
+		t.Fatalf("cfg.Conformance.Parallel expected 'true', got %v", cfg.Conformance.Parallel)
+	}
+	if cfg.Conformance.Timeout != 90*time.Minute {
+		t.Fatalf("cfg.Conformance.Timeout expected 90m, got %v", cfg.Conformance.Timeout)
+	}
+	if cfg.Conformance.UploadResults {
+		t.Fatalf("cfg.Conformance.UploadResults expected 'false', got %v", cfg.Conformance.UploadResults)
+	}
+	if cfg.Conformance.Skip != `Alpha|\[(Disruptive|Feature:[^\]]+|Flaky)\]` {
+		t.Fatalf("unexpected cfg.Conformance.Skip %q", cfg.Conformance.Skip)
+	}
+	if cfg.CNI.ManifestVersion != "v1.2.1" {
+		t.Fatalf("cfg.CNI.ManifestVersion expected 'v1.2.1', got %q", cfg.CNI.ManifestVersion)
+	}
+	if !cfg.CNI.Test {
+		t.Fatalf("cfg.CNI.Test expected 'true', got %v", cfg.CNI.Test)
+	}
+	if cfg.CNI.TestTimeout != 20*time.Minute {
+		t.Fatalf("cfg.CNI.TestTimeout expected 20m, got %v", cfg.CNI.TestTimeout)
+	}
+	if cfg.VPC.CIDR != "10.0.0.0/16" {
+		t.Fatalf("cfg.VPC.CIDR expected '10.0.0.0/16', got %q", cfg.VPC.CIDR)
+	}
+	if cfg.VPC.PrivateSubnetsPerAZ != 2 {
+		t.Fatalf("cfg.VPC.PrivateSubnetsPerAZ expected 2, got %d", cfg.VPC.PrivateSubnetsPerAZ)
+	}
+	if !cfg.VPC.PrivateWorkerNodes {
+		t.Fatalf("cfg.VPC.PrivateWorkerNodes expected 'true', got %v", cfg.VPC.PrivateWorkerNodes)
+	}
+	if !cfg.VPC.EnableIPv6 {
+		t.Fatalf("cfg.VPC.EnableIPv6 expected 'true', got %v", cfg.VPC.EnableIPv6)
+	}
+	if !cfg.Spot.Enable {
+		t.Fatalf("cfg.Spot.Enable expected 'true', got %v", cfg.Spot.Enable)
+	}
+	if !reflect.DeepEqual(cfg.Spot.InstanceTypes, []string{"m5.xlarge", "c5.xlarge"}) {
+		t.Fatalf("unexpected cfg.Spot.InstanceTypes %v", cfg.Spot.InstanceTypes)
+	}
+	if cfg.Spot.OnDemandPercentageAboveBaseCapacity != 25 {
+		t.Fatalf("cfg.Spot.OnDemandPercentageAboveBaseCapacity expected 25, got %d", cfg.Spot.OnDemandPercentageAboveBaseCapacity)
+	}
+}
+
+func TestVPC(t *testing.T) {
+	tests := []struct {
+		vpc VPC
+		ssh bool
+		ok  bool
+	}{
+		{vpc: VPC{}, ok: true},
+		{vpc: VPC{CIDR: "10.0.0.0/16", AvailabilityZones: 3, PublicSubnetsPerAZ: 1, PrivateSubnetsPerAZ: 2, PrivateWorkerNodes: true}, ok: true},
+		{vpc: VPC{CIDR: "10.0.0.0/16", AvailabilityZones: 1, PublicSubnetsPerAZ: 1}, ok: false},
+		{vpc: VPC{CIDR: "10.0.0.0/16", AvailabilityZones: 2, PrivateSubnetsPerAZ: 1}, ok: false},
+		{vpc: VPC{CIDR: "10.0.0.0/16", AvailabilityZones: 2, PublicSubnetsPerAZ: 1, PrivateWorkerNodes: true}, ok: false},
+		{vpc: VPC{CIDR: "10.0.0.0/16", AvailabilityZones: 2, PublicSubnetsPerAZ: 1, PrivateSubnetsPerAZ: 1, PrivateWorkerNodes: true}, ssh: true, ok: false},
+		{vpc: VPC{CIDR: "10.0.0.0/8", AvailabilityZones: 2, PublicSubnetsPerAZ: 1}, ok: false},
+		{vpc: VPC{CIDR: "10.0.0.0/26", AvailabilityZones: 3, PublicSubnetsPerAZ: 1, PrivateSubnetsPerAZ: 1}, ok: false},
+		{vpc: VPC{CIDR: "10.0.0.0/16", AvailabilityZones: 2, PublicSubnetsPerAZ: 1, DisableEndpointPublicAccess: true}, ok: false},
+		{vpc: VPC{CIDR: "10.0.0.0/16", AvailabilityZones: 2, PublicSubnetsPerAZ: 1, EndpointPrivateAccess: true, DisableEndpointPublicAccess: true}, ok: true},
+		{vpc: VPC{CIDR: "10.0.0.0/16", AvailabilityZones: 3, PublicSubnetsPerAZ: 1, PrivateSubnetsPerAZ: 1, EnableIPv6: true}, ok: true},
+		{vpc: VPC{CIDR: "10.0.0.0/16", AvailabilityZones: 4, PublicSubnetsPerAZ: 32, PrivateSubnetsPerAZ: 33, EnableIPv6: true}, ok: false},
+		{vpc: VPC{UseExisting: true}, ok: false},
+	}
+	for i, tt := range tests {
+		vpc := tt.vpc
+		cfg := &Config{EnableWorkerNodeSSH: tt.ssh, VPC: &vpc}
+		err := cfg.validateVPC()
+		if tt.ok != (err == nil) {
+			t.Fatalf("#%d: expected ok %v, got %v", i, tt.ok, err)
+		}
+	}
+
+	cfg := &Config{VPC: &VPC{}}
+	if err := cfg.validateVPC(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.VPC.CIDR != "192.168.0.0/16" || cfg.VPC.AvailabilityZones != 3 || cfg.VPC.PublicSubnetsPerAZ != 1 {
+		t.Fatalf("unexpected default VPC %+v", cfg.VPC)
+	}
+
+	cfg = &Config{
+		SubnetIDs: []string{"a", "b", "c", "d"},
+		VPC:       &VPC{PublicSubnetIDs: []string{"a", "b"}, PrivateSubnetIDs: []string{"c", "d"}},
+	}
+	if ids := cfg.WorkerNodeSubnetIDs(); !reflect.DeepEqual(ids, []string{"a", "b"}) {
+		t.Fatalf("unexpected worker node subnets %v", ids)
+	}
+	cfg.VPC.PrivateWorkerNodes = true
+	if ids := cfg.WorkerNodeSubnetIDs(); !reflect.DeepEqual(ids, []string{"c", "d"}) {
+		t.Fatalf("unexpected worker node subnets %v", ids)
+	}
+	if ids := cfg.LoadBalancerSubnetIDs(); !reflect.DeepEqual(ids, []string{"a", "b"}) {
+		t.Fatalf("unexpected load balancer subnets %v", ids)
+	}
+}
+
+func TestSpot(t *testing.T) {
+	tests := []struct {
+		spot     ec2config.Spot
+		template string
+		replicas int
+		ok       bool
+	}{
+		{spot: ec2config.Spot{}, template: "custom.yaml", ok: true},
+		{spot: ec2config.Spot{Enable: true, InstanceTypes: []string{"m5.xlarge"}}, ok: true},
+		{spot: ec2config.Spot{Enable: true, InstanceTypes: []string{"m5.large"}}, ok: false},
+		{spot: ec2config.Spot{Enable: true}, template: "custom.yaml", ok: false},
+		{spot: ec2config.Spot{Enable: true, InstanceTypes: []string{"t2.micro"}}, replicas: 50, ok: false},
+		{spot: ec2config.Spot{Enable: true, InstanceTypes: []string{"m5.xlarge"}}, replicas: 50, ok: true},
+	}
+	for i, tt := range tests {
+		sp := tt.spot
+		cfg := &Config{
+			WorkerNodeInstanceType:   "m5.large",
+			WorkerNodeASGMax:         2,
+			WorkerNodeCFTemplatePath: tt.template,
+			ALBIngressController:     &ALBIngressController{TestServerReplicas: tt.replicas},
+			Spot:                     &sp,
+		}
+		err := cfg.validateSpot()
+		if tt.ok != (err == nil) {
+			t.Fatalf("#%d: expected ok %v, got %v", i, tt.ok, err)
+		}
+	}
+}
+
+func TestConformanceDefaults(t *testing.T) {
+	// "conformance" block is not set in YAML
+	cfg := &Config{KubernetesVersion: "1.11", Conformance: &Conformance{}}
+	cfg.setConformanceDefaults()
+	if cfg.Conformance.Focus != defaultConformanceFocus || cfg.Conformance.Skip != defaultConformanceSkip || cfg.Conformance.Timeout != 3*time.Hour {
+		t.Fatalf("unexpected conformance defaults %+v", cfg.Conformance)
+	}
+	if cfg.Conformance.Image != conformanceImages["1.11"] {
+		t.Fatalf("unexpected conformance image %q", cfg.Conformance.Image)
+	}
+
+	cfg.Conformance = &Conformance{Focus: `\[sig-network\]`, Timeout: time.Hour}
+	cfg.setConformanceDefaults()
+	if cfg.Conformance.Focus != `\[sig-network\]` || cfg.Conformance.Timeout != time.Hour {
+		t.Fatalf("unexpected conformance %+v", cfg.Conformance)
+	}
+}
+
+func TestCNIManifestURL(t *testing.T) {
+	url, err := cniManifestURL("v1.3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://raw.githubusercontent.com/aws/amazon-vpc-cni-k8s/v1.3.0/config/v1.3/aws-k8s-cni.yaml" {
+		t.Fatalf("unexpected CNI manifest URL %q", url)
+	}
+	for _, ver := range []string{"1.3.0", "v1.3", "master"} {
+		if _, err = cniManifestURL(ver); err == nil {
+			t.Fatalf("expected error for %q", ver)
+		}
+	}
 }
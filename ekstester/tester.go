@@ -12,6 +12,8 @@ import (
 type Tester interface {
 	Deployer
 	ALB
+	Conformance
+	CNI
 	// UploadToBucketForTests uploads a local file to aws-k8s-tester S3 bucket.
 	UploadToBucketForTests(localPath, remotePath string) error
 }
@@ -57,3 +59,18 @@ type ALB interface {
 	// is serving /metrics endpoint.
 	TestALBMetrics() error
 }
+
+// Conformance defines Kubernetes conformance tester.
+type Conformance interface {
+	// TestConformance runs the upstream Kubernetes conformance e2e suite
+	// in the cluster, and downloads the e2e log and junit results
+	// to the artifact directory.
+	TestConformance(artifactDir string) error
+}
+
+// CNI defines VPC CNI tester.
+type CNI interface {
+	// TestCNI runs pod connectivity, IP allocation, SNAT,
+	// and IP warm pool tests against the VPC CNI.
+	TestCNI() error
+}
@@ -22,6 +22,10 @@ import (
 
 // Config defines etcd test configuration.
 type Config struct {
+	// EnvPrefix is used to update configuration via environmental variables.
+	// The default is "AWS_K8S_TESTER_ETCD_".
+	EnvPrefix string
+
 	// Tag is the tag used for S3 bucket name.
 	// If empty, deployer auto-populates it.
 	Tag string `json:"tag,omitempty"`
@@ -418,6 +422,7 @@ Restart=always
 RestartSec=5s
 LimitNOFILE=40000
 TimeoutStartSec=0
+EnvironmentFile=-/etc/sysconfig/etcd
 
 ExecStart={{ .Exec }} {{ .Flags }}
 
@@ -569,7 +574,7 @@ func init() {
 	// ImageID:  "ami-032509850cf9ee54e"
 	// UserName: "ec2-user"
 	defaultConfig.EC2.Plugins = []string{
-		"update-amazon-linux-2",
+		"update",
 		"install-etcd-3.2.25",
 	}
 	defaultConfig.EC2.ClusterSize = 3
@@ -582,9 +587,9 @@ func init() {
 	}
 
 	defaultConfig.EC2Bastion.Plugins = []string{
-		"update-amazon-linux-2",
+		"update",
 		"install-etcd-3.2.25",
-		"install-go-amazon-linux-2-1.11",
+		"install-go-1.11.4",
 		"install-aws-k8s-tester",
 	}
 	defaultConfig.EC2Bastion.ClusterSize = 1
@@ -605,6 +610,7 @@ func genTag() string {
 }
 
 var defaultConfig = Config{
+	EnvPrefix:      envPfx,
 	WaitBeforeDown: time.Minute,
 	Down:           true,
 
@@ -706,15 +712,24 @@ func (cfg *Config) BackupConfig() (p string, err error) {
 
 const (
 	envPfx                 = "AWS_K8S_TESTER_ETCD_"
-	envPfxCluster          = "AWS_K8S_TESTER_ETCD_CLUSTER_"
 	envPfxEtcdNodes        = "AWS_K8S_TESTER_EC2_ETCD_NODES_"
 	envPfxEtcdBastionNodes = "AWS_K8S_TESTER_EC2_ETCD_BASTION_NODES_"
 )
 
 // UpdateFromEnvs updates fields from environmental variables.
+// If "EnvPrefix" is not the default (e.g. etcd nodes of kubeadm tester),
+// EC2 configurations are updated with "EnvPrefix" + "EC2_" and
+// "EnvPrefix" + "EC2_BASTION_", instead of etcd tester prefixes.
 func (cfg *Config) UpdateFromEnvs() error {
+	if cfg.EnvPrefix == "" {
+		cfg.EnvPrefix = envPfx
+	}
 	cfg.EC2.EnvPrefix = envPfxEtcdNodes
 	cfg.EC2Bastion.EnvPrefix = envPfxEtcdBastionNodes
+	if cfg.EnvPrefix != envPfx {
+		cfg.EC2.EnvPrefix = cfg.EnvPrefix + "EC2_"
+		cfg.EC2Bastion.EnvPrefix = cfg.EnvPrefix + "EC2_BASTION_"
+	}
 
 	if err := cfg.EC2.UpdateFromEnvs(); err != nil {
 		return err
@@ -734,7 +749,7 @@ func (cfg *Config) UpdateFromEnvs() error {
 		jv = strings.Replace(jv, ",omitempty", "", -1)
 		jv = strings.Replace(jv, "-", "_", -1)
 		jv = strings.ToUpper(strings.Replace(jv, "-", "_", -1))
-		env := envPfx + jv
+		env := cc.EnvPrefix + jv
 		if os.Getenv(env) == "" {
 			continue
 		}
@@ -804,7 +819,7 @@ func (cfg *Config) UpdateFromEnvs() error {
 		}
 		jv = strings.Replace(jv, ",omitempty", "", -1)
 		jv = strings.ToUpper(strings.Replace(jv, "-", "_", -1))
-		env := envPfxCluster + jv
+		env := cc.EnvPrefix + "CLUSTER_" + jv
 		if os.Getenv(env) == "" {
 			continue
 		}
@@ -883,29 +898,19 @@ func (cfg *Config) ValidateAndSetDefaults() (err error) {
 		}
 	}
 
-	okAMZLnx, okEtcd := false, false
-	for _, v := range cfg.EC2.Plugins {
-		if v == "update-amazon-linux-2" {
-			okAMZLnx = true
-			continue
-		}
-		if strings.HasPrefix(v, "install-etcd-") {
-			okEtcd = true
-			continue
-		}
-	}
-	if !okAMZLnx {
-		return errors.New("EC2 Plugin 'update-amazon-linux-2' not found")
+	profile := cfg.EC2.Profile()
+	if profile.UpdateScript != "" && !cfg.EC2.HasPlugin("update") {
+		return fmt.Errorf("EC2 Plugin 'update' not found for OS profile %q", profile.Name)
 	}
-	if !okEtcd {
+	if !cfg.EC2.HasPlugin("install-etcd") {
 		return errors.New("EC2 Plugin 'install-etcd' not found")
 	}
 
 	if !cfg.EC2.Wait {
 		return errors.New("Set EC2 Wait to true")
 	}
-	if cfg.EC2.UserName != "ec2-user" {
-		return fmt.Errorf("expected 'ec2-user' user name, got %q", cfg.EC2.UserName)
+	if profile.UserName != "" && cfg.EC2.UserName != profile.UserName {
+		return fmt.Errorf("expected %q user name for OS profile %q, got %q", profile.UserName, profile.Name, cfg.EC2.UserName)
 	}
 
 	if cfg.ClusterSize < 1 || cfg.ClusterSize > 5 {
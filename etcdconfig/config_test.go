@@ -55,6 +55,32 @@ func TestETCD(t *testing.T) {
 	fmt.Println(s)
 }
 
+func TestOSProfile(t *testing.T) {
+	cfg := NewDefault()
+	ec := *cfg.EC2
+	cfg.EC2 = &ec
+	cfg.EC2.OSProfile = "ubuntu"
+	cfg.EC2.UserName = ""
+	cfg.EC2.ImageID = "ami-ubuntu"
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.EC2.UserName != "ubuntu" {
+		t.Fatalf("unexpected UserName %q", cfg.EC2.UserName)
+	}
+
+	cfg.EC2.UserName = "ec2-user"
+	if err := cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for 'ec2-user' with OS profile 'ubuntu'")
+	}
+
+	cfg.EC2.UserName = "ubuntu"
+	cfg.EC2.Plugins = []string{"install-etcd-3.2.25"}
+	if err := cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for missing 'update' plugin")
+	}
+}
+
 func TestEnv(t *testing.T) {
 	cfg := NewDefault()
 
@@ -13,16 +13,17 @@ import (
 
 // CreateInstallScript returns the etcd install script for Linux.
 // Inputs are either: "v3.2.12", "master", "123" (PR number).
-func CreateInstallScript(ver string) (s string, err error) {
+// The architecture is either "amd64" or "arm64", for release downloads.
+func CreateInstallScript(ver, arch string) (s string, err error) {
 	v, err := semver.Make(ver)
 	if err == nil {
-		return createInstallRelase(etcdInfo{Version: v.String()})
+		return createInstallRelase(etcdInfo{Version: v.String(), Arch: arch})
 	}
 	if err != nil && strings.Contains(err.Error(), "Invalid character(s) found in major") && strings.HasPrefix(ver, "v") {
 		ver = ver[1:]
 		v, err = semver.Make(ver)
 		if err == nil {
-			return createInstallRelase(etcdInfo{Version: v.String()})
+			return createInstallRelase(etcdInfo{Version: v.String(), Arch: arch})
 		}
 	}
 
@@ -54,6 +55,7 @@ func createInstallRelase(g etcdInfo) (string, error) {
 
 type etcdInfo struct {
 	Version string
+	Arch    string
 }
 
 const installRelease = `
@@ -69,16 +71,20 @@ GOOGLE_URL=https://storage.googleapis.com/etcd
 GITHUB_URL=https://github.com/etcd-io/etcd/releases/download
 DOWNLOAD_URL=${GOOGLE_URL}
 
-rm -f /tmp/etcd-${ETCD_VER}-linux-amd64.tar.gz
+rm -f /tmp/etcd-${ETCD_VER}-linux-{{ .Arch }}.tar.gz
 rm -rf /tmp/etcd-download-test && mkdir -p /tmp/etcd-download-test
 
-curl -L ${DOWNLOAD_URL}/${ETCD_VER}/etcd-${ETCD_VER}-linux-amd64.tar.gz -o /tmp/etcd-${ETCD_VER}-linux-amd64.tar.gz
-tar xzvf /tmp/etcd-${ETCD_VER}-linux-amd64.tar.gz -C /tmp/etcd-download-test --strip-components=1
-rm -f /tmp/etcd-${ETCD_VER}-linux-amd64.tar.gz
+curl -L ${DOWNLOAD_URL}/${ETCD_VER}/etcd-${ETCD_VER}-linux-{{ .Arch }}.tar.gz -o /tmp/etcd-${ETCD_VER}-linux-{{ .Arch }}.tar.gz
+tar xzvf /tmp/etcd-${ETCD_VER}-linux-{{ .Arch }}.tar.gz -C /tmp/etcd-download-test --strip-components=1
+rm -f /tmp/etcd-${ETCD_VER}-linux-{{ .Arch }}.tar.gz
 
 sudo cp /tmp/etcd-download-test/etcd /usr/bin/etcd
 sudo cp /tmp/etcd-download-test/etcdctl /usr/bin/etcdctl
-
+{{ if eq .Arch "arm64" }}
+# etcd requires explicit opt-in for arm64 (read by etcd.service)
+echo "ETCD_UNSUPPORTED_ARCH=arm64" | sudo tee /etc/sysconfig/etcd
+export ETCD_UNSUPPORTED_ARCH=arm64
+{{ end }}
 /usr/bin/etcd --version
 ETCDCTL_API=3 /usr/bin/etcdctl version
 
@@ -2,26 +2,30 @@ package plugins
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
 func TestCreateInstallScript(t *testing.T) {
-	s1, err := CreateInstallScript("v3.2.12")
+	s1, err := CreateInstallScript("v3.2.12", "amd64")
 	if err != nil {
 		t.Fatal(err)
 	}
 	fmt.Println(s1)
-	s1, err = CreateInstallScript("3.2.12")
+	s1, err = CreateInstallScript("3.2.12", "arm64")
 	if err != nil {
 		t.Fatal(err)
 	}
+	if !strings.Contains(s1, "linux-arm64.tar.gz") || !strings.Contains(s1, "ETCD_UNSUPPORTED_ARCH=arm64") {
+		t.Fatalf("unexpected arm64 install script %q", s1)
+	}
 	fmt.Println(s1)
-	s2, err := CreateInstallScript("master")
+	s2, err := CreateInstallScript("master", "amd64")
 	if err != nil {
 		t.Fatal(err)
 	}
 	fmt.Println(s2)
-	s3, err := CreateInstallScript("9876")
+	s3, err := CreateInstallScript("9876", "amd64")
 	if err != nil {
 		t.Fatal(err)
 	}
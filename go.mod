@@ -4,7 +4,7 @@ require (
 	cloud.google.com/go v0.30.0 // indirect
 	contrib.go.opencensus.io/exporter/ocagent v0.4.2 // indirect
 	github.com/Azure/go-autorest v11.3.1+incompatible // indirect
-	github.com/aws/aws-sdk-go v1.17.5
+	github.com/aws/aws-sdk-go v1.19.10
 	github.com/blang/semver v3.5.1+incompatible
 	github.com/dustin/go-humanize v1.0.0
 	github.com/go-yaml/yaml v2.1.0+incompatible // indirect
@@ -38,6 +38,7 @@ require (
 	go.uber.org/atomic v1.3.2
 	go.uber.org/zap v1.9.1
 	golang.org/x/crypto v0.0.0-20181015023909-0c41d7ab0a0e
+	golang.org/x/net v0.0.0-20180906233101-161cd47e91fd
 	golang.org/x/oauth2 v0.0.0-20181017192945-9dcd33a902f4 // indirect
 	google.golang.org/api v0.0.0-20181021000519-a2651947f503 // indirect
 	google.golang.org/appengine v1.2.0 // indirect
@@ -181,6 +181,40 @@ func ConvertToCSV(output string, ps ...string) error {
 	return csvutil.Save(logHeader, rows, output)
 }
 
+// SaveCSV writes parsed ALB access logs to a CSV file.
+func SaveCSV(output string, logs []Log) error {
+	rows := make([][]string, 0, len(logs))
+	for _, l := range logs {
+		rows = append(rows, []string{
+			l.Type,
+			l.Timestamp,
+			l.ELB,
+			l.ClientPort,
+			l.TargetPort,
+			l.RequestProcessingTime,
+			l.TargetProcessingTime,
+			l.ResponseProcessingTime,
+			l.ELBStatusCode,
+			l.TargetStatusCode,
+			l.ReceivedBytes,
+			l.SentBytes,
+			l.Request,
+			l.UserAgent,
+			l.SSLCipher,
+			l.SSLProtocol,
+			l.TargetGroupARN,
+			l.TraceID,
+			l.DomainName,
+			l.ChosenCertARN,
+			l.MatchedRulePriority,
+			l.RequestCreationTime,
+			l.ActionsExecuted,
+			l.RedirectURL,
+		})
+	}
+	return csvutil.Save(logHeader, rows, output)
+}
+
 func splitLog(l string) (fields []string, err error) {
 	rd := csv.NewReader(strings.NewReader(l))
 
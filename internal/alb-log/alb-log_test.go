@@ -45,4 +45,7 @@ func TestALB(t *testing.T) {
 	if err = ConvertToCSV(output, "alb.log"); err != nil {
 		t.Fatal(err)
 	}
+	if err = SaveCSV(output, logs); err != nil {
+		t.Fatal(err)
+	}
 }
@@ -0,0 +1,354 @@
+package alblog
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/csvutil"
+)
+
+// Analysis is the summary of ALB access logs.
+type Analysis struct {
+	// Total is the total number of log entries.
+	Total int
+	// Errors is the number of requests with ELB 5xx status code.
+	Errors int
+
+	// Targets is the latency summary grouped by target ("ip:port").
+	Targets []Group
+	// Routes is the latency summary grouped by request path.
+	Routes []Group
+
+	// StatusCodes is the number of requests for each pair of
+	// ELB status code and target status code.
+	StatusCodes []StatusCode
+
+	// TimeSeries is the per-second number of requests and errors.
+	TimeSeries []Second
+}
+
+// Group is the latency summary of requests grouped by a key.
+// Latency is the sum of request, target, and response processing times,
+// only counted when the target has responded (ALB reports -1 otherwise).
+type Group struct {
+	Key string
+
+	Requests  int
+	ELB4xx    int
+	ELB5xx    int
+	Target5xx int
+	// NoResponse is the number of requests that target did not respond to
+	// (e.g. connection closed before idle timeout, or target timed out).
+	NoResponse int
+
+	LatencyP50 time.Duration
+	LatencyP90 time.Duration
+	LatencyP99 time.Duration
+	LatencyMax time.Duration
+
+	TargetProcessingP50 time.Duration
+	TargetProcessingP90 time.Duration
+	TargetProcessingP99 time.Duration
+	TargetProcessingMax time.Duration
+}
+
+// StatusCode is the number of requests with an ELB and target status code pair.
+// Target status code is "-" when the target did not respond.
+type StatusCode struct {
+	ELB      string
+	Target   string
+	Requests int
+}
+
+// Second is the number of requests and errors in a one-second window.
+type Second struct {
+	Time      time.Time
+	Requests  int
+	ELB5xx    int
+	Target5xx int
+}
+
+// Analyze summarizes ALB access logs.
+func Analyze(logs []Log) (an Analysis) {
+	an.Total = len(logs)
+
+	targets := make(map[string]*groupBuilder)
+	routes := make(map[string]*groupBuilder)
+	codes := make(map[StatusCode]int)
+	seconds := make(map[int64]*Second)
+
+	for _, l := range logs {
+		target := l.TargetPort
+		if target == "" {
+			target = "-"
+		}
+		if _, ok := targets[target]; !ok {
+			targets[target] = &groupBuilder{g: Group{Key: target}}
+		}
+		targets[target].add(l)
+
+		route := parseRoute(l.Request)
+		if _, ok := routes[route]; !ok {
+			routes[route] = &groupBuilder{g: Group{Key: route}}
+		}
+		routes[route].add(l)
+
+		codes[StatusCode{ELB: l.ELBStatusCode, Target: l.TargetStatusCode}]++
+
+		if is5xx(l.ELBStatusCode) {
+			an.Errors++
+		}
+
+		ts, err := time.Parse(time.RFC3339Nano, l.Timestamp)
+		if err != nil {
+			continue
+		}
+		sec := ts.Truncate(time.Second)
+		sv, ok := seconds[sec.Unix()]
+		if !ok {
+			sv = &Second{Time: sec}
+			seconds[sec.Unix()] = sv
+		}
+		sv.Requests++
+		if is5xx(l.ELBStatusCode) {
+			sv.ELB5xx++
+		}
+		if is5xx(l.TargetStatusCode) {
+			sv.Target5xx++
+		}
+	}
+
+	an.Targets = buildGroups(targets)
+	an.Routes = buildGroups(routes)
+
+	an.StatusCodes = make([]StatusCode, 0, len(codes))
+	for k, v := range codes {
+		k.Requests = v
+		an.StatusCodes = append(an.StatusCodes, k)
+	}
+	sort.Slice(an.StatusCodes, func(i, j int) bool {
+		if an.StatusCodes[i].ELB != an.StatusCodes[j].ELB {
+			return an.StatusCodes[i].ELB < an.StatusCodes[j].ELB
+		}
+		return an.StatusCodes[i].Target < an.StatusCodes[j].Target
+	})
+
+	an.TimeSeries = make([]Second, 0, len(seconds))
+	for _, v := range seconds {
+		an.TimeSeries = append(an.TimeSeries, *v)
+	}
+	sort.Slice(an.TimeSeries, func(i, j int) bool {
+		return an.TimeSeries[i].Time.Before(an.TimeSeries[j].Time)
+	})
+	return an
+}
+
+type groupBuilder struct {
+	g         Group
+	latencies []float64
+	targets   []float64
+}
+
+func (gb *groupBuilder) add(l Log) {
+	gb.g.Requests++
+	switch {
+	case strings.HasPrefix(l.ELBStatusCode, "4"):
+		gb.g.ELB4xx++
+	case is5xx(l.ELBStatusCode):
+		gb.g.ELB5xx++
+	}
+	if is5xx(l.TargetStatusCode) {
+		gb.g.Target5xx++
+	}
+	if l.RequestProcessingTimeSeconds < 0 ||
+		l.TargetProcessingTimeSeconds < 0 ||
+		l.ResponseProcessingTimeSeconds < 0 {
+		gb.g.NoResponse++
+		return
+	}
+	gb.latencies = append(gb.latencies, l.RequestProcessingTimeSeconds+l.TargetProcessingTimeSeconds+l.ResponseProcessingTimeSeconds)
+	gb.targets = append(gb.targets, l.TargetProcessingTimeSeconds)
+}
+
+func buildGroups(m map[string]*groupBuilder) (gs []Group) {
+	gs = make([]Group, 0, len(m))
+	for _, gb := range m {
+		sort.Float64s(gb.latencies)
+		sort.Float64s(gb.targets)
+		gb.g.LatencyP50 = percentile(gb.latencies, 50)
+		gb.g.LatencyP90 = percentile(gb.latencies, 90)
+		gb.g.LatencyP99 = percentile(gb.latencies, 99)
+		gb.g.LatencyMax = percentile(gb.latencies, 100)
+		gb.g.TargetProcessingP50 = percentile(gb.targets, 50)
+		gb.g.TargetProcessingP90 = percentile(gb.targets, 90)
+		gb.g.TargetProcessingP99 = percentile(gb.targets, 99)
+		gb.g.TargetProcessingMax = percentile(gb.targets, 100)
+		gs = append(gs, gb.g)
+	}
+	sort.Slice(gs, func(i, j int) bool { return gs[i].Key < gs[j].Key })
+	return gs
+}
+
+// percentile returns the nearest-rank percentile of sorted seconds.
+func percentile(sorted []float64, pct float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(pct/100.0*float64(len(sorted))+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return time.Duration(sorted[idx] * float64(time.Second))
+}
+
+// parseRoute returns the URL path from the request field.
+// e.g. "GET http://a.elb.amazonaws.com:80/ingress-test HTTP/1.1" returns "/ingress-test".
+func parseRoute(req string) string {
+	fields := strings.Fields(req)
+	if len(fields) < 2 {
+		return "-"
+	}
+	u, err := url.Parse(fields[1])
+	if err != nil {
+		return "-"
+	}
+	if u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
+
+func is5xx(code string) bool {
+	return strings.HasPrefix(code, "5")
+}
+
+func (an Analysis) String() (s string) {
+	s += fmt.Sprintf("Total: %d requests\n", an.Total)
+	s += fmt.Sprintf("Errors: %d requests with ELB 5xx\n", an.Errors)
+	s += "\n"
+	s += "Targets:\n"
+	for _, g := range an.Targets {
+		s += g.String()
+	}
+	s += "\n"
+	s += "Routes:\n"
+	for _, g := range an.Routes {
+		s += g.String()
+	}
+	s += "\n"
+	s += "Status Codes (ELB / Target):\n"
+	for _, c := range an.StatusCodes {
+		s += fmt.Sprintf("%s / %s: %d requests\n", c.ELB, c.Target, c.Requests)
+	}
+	s += "\n"
+	return s
+}
+
+func (g Group) String() string {
+	return fmt.Sprintf(
+		"%s: %d requests (ELB 4xx %d, ELB 5xx %d, target 5xx %d, no response %d), latency p50 %v, p90 %v, p99 %v, max %v\n",
+		g.Key,
+		g.Requests,
+		g.ELB4xx,
+		g.ELB5xx,
+		g.Target5xx,
+		g.NoResponse,
+		g.LatencyP50,
+		g.LatencyP90,
+		g.LatencyP99,
+		g.LatencyMax,
+	)
+}
+
+var groupHeader = []string{
+	"key",
+	"requests",
+	"elb-4xx",
+	"elb-5xx",
+	"target-5xx",
+	"no-response",
+	"latency-p50",
+	"latency-p90",
+	"latency-p99",
+	"latency-max",
+	"target-processing-p50",
+	"target-processing-p90",
+	"target-processing-p99",
+	"target-processing-max",
+}
+
+// SaveCSV writes the analysis to CSV files, with the output path
+// suffixed with ".targets.csv", ".routes.csv", ".status-codes.csv",
+// and ".time-series.csv". It returns the list of written files.
+func (an Analysis) SaveCSV(output string) (ps []string, err error) {
+	groupRows := func(gs []Group) (rows [][]string) {
+		for _, g := range gs {
+			rows = append(rows, []string{
+				g.Key,
+				fmt.Sprintf("%d", g.Requests),
+				fmt.Sprintf("%d", g.ELB4xx),
+				fmt.Sprintf("%d", g.ELB5xx),
+				fmt.Sprintf("%d", g.Target5xx),
+				fmt.Sprintf("%d", g.NoResponse),
+				fmt.Sprintf("%f", g.LatencyP50.Seconds()),
+				fmt.Sprintf("%f", g.LatencyP90.Seconds()),
+				fmt.Sprintf("%f", g.LatencyP99.Seconds()),
+				fmt.Sprintf("%f", g.LatencyMax.Seconds()),
+				fmt.Sprintf("%f", g.TargetProcessingP50.Seconds()),
+				fmt.Sprintf("%f", g.TargetProcessingP90.Seconds()),
+				fmt.Sprintf("%f", g.TargetProcessingP99.Seconds()),
+				fmt.Sprintf("%f", g.TargetProcessingMax.Seconds()),
+			})
+		}
+		return rows
+	}
+
+	p := output + ".targets.csv"
+	if err = csvutil.Save(groupHeader, groupRows(an.Targets), p); err != nil {
+		return nil, err
+	}
+	ps = append(ps, p)
+
+	p = output + ".routes.csv"
+	if err = csvutil.Save(groupHeader, groupRows(an.Routes), p); err != nil {
+		return nil, err
+	}
+	ps = append(ps, p)
+
+	rows := make([][]string, 0, len(an.StatusCodes))
+	for _, c := range an.StatusCodes {
+		rows = append(rows, []string{c.ELB, c.Target, fmt.Sprintf("%d", c.Requests)})
+	}
+	p = output + ".status-codes.csv"
+	if err = csvutil.Save([]string{"elb-status-code", "target-status-code", "requests"}, rows, p); err != nil {
+		return nil, err
+	}
+	ps = append(ps, p)
+
+	rows = make([][]string, 0, len(an.TimeSeries))
+	for _, v := range an.TimeSeries {
+		rows = append(rows, []string{
+			v.Time.Format(time.RFC3339),
+			fmt.Sprintf("%d", v.Requests),
+			fmt.Sprintf("%d", v.ELB5xx),
+			fmt.Sprintf("%d", v.Target5xx),
+		})
+	}
+	p = output + ".time-series.csv"
+	if err = csvutil.Save([]string{"time", "requests", "elb-5xx", "target-5xx"}, rows, p); err != nil {
+		return nil, err
+	}
+	ps = append(ps, p)
+
+	for i := range ps {
+		ps[i], _ = filepath.Abs(ps[i])
+	}
+	return ps, nil
+}
@@ -0,0 +1,147 @@
+package alblog
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnalyze(t *testing.T) {
+	logs, err := Parse("alb.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	an := Analyze(logs)
+	if an.Total != len(logs) {
+		t.Fatalf("total expected %d, got %d", len(logs), an.Total)
+	}
+	if an.Errors != 0 {
+		t.Fatalf("errors expected 0, got %d", an.Errors)
+	}
+	if len(an.Routes) != 1 || an.Routes[0].Requests != len(logs) {
+		t.Fatalf("unexpected routes %+v", an.Routes)
+	}
+	if len(an.StatusCodes) != 1 || an.StatusCodes[0].ELB != "200" || an.StatusCodes[0].Target != "200" {
+		t.Fatalf("unexpected status codes %+v", an.StatusCodes)
+	}
+	n := 0
+	for _, v := range an.TimeSeries {
+		n += v.Requests
+	}
+	if n != len(logs) {
+		t.Fatalf("time series requests expected %d, got %d", len(logs), n)
+	}
+	t.Log(an.String())
+
+	f, err := ioutil.TempFile(os.TempDir(), "alblog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := f.Name()
+	f.Close()
+	os.RemoveAll(output)
+	ps, err := an.SaveCSV(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range ps {
+		os.RemoveAll(p)
+	}
+	if len(ps) != 4 {
+		t.Fatalf("expected 4 CSV files, got %q", ps)
+	}
+	d, err := an.HTML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(d, "ALB Access Log Analysis") {
+		t.Fatalf("unexpected HTML report:\n%s", d)
+	}
+}
+
+func TestAnalyzeErrors(t *testing.T) {
+	logs := []Log{
+		{
+			Timestamp:                     "2018-10-31T07:05:01.100000Z",
+			TargetPort:                    "192.168.1.1:80",
+			RequestProcessingTimeSeconds:  0.001,
+			TargetProcessingTimeSeconds:   0.010,
+			ResponseProcessingTimeSeconds: 0.001,
+			ELBStatusCode:                 "200",
+			TargetStatusCode:              "200",
+			Request:                       "GET http://a.elb.amazonaws.com:80/a HTTP/1.1",
+		},
+		{
+			Timestamp:                     "2018-10-31T07:05:01.900000Z",
+			TargetPort:                    "192.168.1.1:80",
+			RequestProcessingTimeSeconds:  0.001,
+			TargetProcessingTimeSeconds:   0.100,
+			ResponseProcessingTimeSeconds: 0.001,
+			ELBStatusCode:                 "502",
+			TargetStatusCode:              "500",
+			Request:                       "GET http://a.elb.amazonaws.com:80/a?x=1 HTTP/1.1",
+		},
+		{
+			Timestamp:                     "2018-10-31T07:05:02.500000Z",
+			TargetPort:                    "192.168.1.2:80",
+			RequestProcessingTimeSeconds:  -1,
+			TargetProcessingTimeSeconds:   -1,
+			ResponseProcessingTimeSeconds: -1,
+			ELBStatusCode:                 "504",
+			TargetStatusCode:              "-",
+			Request:                       "POST http://a.elb.amazonaws.com:80/b HTTP/1.1",
+		},
+	}
+	an := Analyze(logs)
+	if an.Errors != 2 {
+		t.Fatalf("errors expected 2, got %d", an.Errors)
+	}
+
+	if len(an.Targets) != 2 {
+		t.Fatalf("targets expected 2, got %+v", an.Targets)
+	}
+	g := an.Targets[0]
+	if g.Key != "192.168.1.1:80" || g.Requests != 2 || g.ELB5xx != 1 || g.Target5xx != 1 {
+		t.Fatalf("unexpected target group %+v", g)
+	}
+	if g.LatencyP50 != 12*time.Millisecond || g.LatencyMax != 102*time.Millisecond {
+		t.Fatalf("unexpected latencies %+v", g)
+	}
+	if g.TargetProcessingP99 != 100*time.Millisecond {
+		t.Fatalf("unexpected target processing time %v", g.TargetProcessingP99)
+	}
+	g = an.Targets[1]
+	if g.NoResponse != 1 || g.LatencyMax != 0 {
+		t.Fatalf("unexpected target group %+v", g)
+	}
+
+	if len(an.Routes) != 2 || an.Routes[0].Key != "/a" || an.Routes[0].Requests != 2 {
+		t.Fatalf("unexpected routes %+v", an.Routes)
+	}
+
+	expCodes := []StatusCode{
+		{ELB: "200", Target: "200", Requests: 1},
+		{ELB: "502", Target: "500", Requests: 1},
+		{ELB: "504", Target: "-", Requests: 1},
+	}
+	if len(an.StatusCodes) != len(expCodes) {
+		t.Fatalf("status codes expected %+v, got %+v", expCodes, an.StatusCodes)
+	}
+	for i := range expCodes {
+		if an.StatusCodes[i] != expCodes[i] {
+			t.Fatalf("#%d: status code expected %+v, got %+v", i, expCodes[i], an.StatusCodes[i])
+		}
+	}
+
+	if len(an.TimeSeries) != 2 {
+		t.Fatalf("time series expected 2, got %+v", an.TimeSeries)
+	}
+	if an.TimeSeries[0].Requests != 2 || an.TimeSeries[0].ELB5xx != 1 || an.TimeSeries[0].Target5xx != 1 {
+		t.Fatalf("unexpected time series %+v", an.TimeSeries[0])
+	}
+	if an.TimeSeries[1].Requests != 1 || an.TimeSeries[1].ELB5xx != 1 || an.TimeSeries[1].Target5xx != 0 {
+		t.Fatalf("unexpected time series %+v", an.TimeSeries[1])
+	}
+}
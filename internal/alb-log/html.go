@@ -0,0 +1,142 @@
+package alblog
+
+import (
+	"bytes"
+	"html/template"
+	"io/ioutil"
+)
+
+const htmlHead = `<!DOCTYPE html>
+<html>
+<head>
+<style>
+table {
+	font-family: arial, sans-serif;
+	border-collapse: collapse;
+	width: 100%;
+}
+
+td, th {
+	border: 1px solid #dddddd;
+	text-align: center;
+}
+</style>
+</head>
+`
+
+const tmplGroups = `<table>
+<tr>
+<th>{{.Name}}</th>
+<th>Requests</th>
+<th>ELB 4xx</th>
+<th>ELB 5xx</th>
+<th>Target 5xx</th>
+<th>No Response</th>
+<th>Latency P50</th>
+<th>Latency P90</th>
+<th>Latency P99</th>
+<th>Latency Max</th>
+</tr>
+{{range .Groups}}<tr>
+<td>{{.Key}}</td>
+<td>{{.Requests}}</td>
+<td>{{.ELB4xx}}</td>
+<td>{{.ELB5xx}}</td>
+<td>{{.Target5xx}}</td>
+<td>{{.NoResponse}}</td>
+<td>{{.LatencyP50}}</td>
+<td>{{.LatencyP90}}</td>
+<td>{{.LatencyP99}}</td>
+<td>{{.LatencyMax}}</td>
+</tr>
+{{end}}</table>
+`
+
+const tmplReport = `<body>
+<h2>ALB Access Log Analysis</h2>
+
+<br>
+<b>Total:</b> {{.Total}} requests<br>
+<b>Errors:</b> {{.Errors}} requests with ELB 5xx<br>
+<br>
+
+<h3>Targets</h3>
+{{template "groups" .TargetGroups}}
+<br>
+
+<h3>Routes</h3>
+{{template "groups" .RouteGroups}}
+<br>
+
+<h3>Status Codes</h3>
+<table>
+<tr>
+<th>ELB Status Code</th>
+<th>Target Status Code</th>
+<th>Requests</th>
+</tr>
+{{range .StatusCodes}}<tr>
+<td>{{.ELB}}</td>
+<td>{{.Target}}</td>
+<td>{{.Requests}}</td>
+</tr>
+{{end}}</table>
+<br>
+
+<h3>Time Series</h3>
+<table>
+<tr>
+<th>Time</th>
+<th>Requests</th>
+<th>ELB 5xx</th>
+<th>Target 5xx</th>
+</tr>
+{{range .TimeSeries}}<tr>
+<td>{{.Time.Format "2006-01-02T15:04:05Z07:00"}}</td>
+<td>{{.Requests}}</td>
+<td>{{.ELB5xx}}</td>
+<td>{{.Target5xx}}</td>
+</tr>
+{{end}}</table>
+
+</body>
+</html>
+`
+
+type htmlGroups struct {
+	Name   string
+	Groups []Group
+}
+
+type htmlReport struct {
+	Analysis
+	TargetGroups htmlGroups
+	RouteGroups  htmlGroups
+}
+
+// HTML returns the analysis in HTML.
+func (an Analysis) HTML() (string, error) {
+	tpl := template.Must(template.New("report").Parse(tmplReport))
+	tpl = template.Must(tpl.New("groups").Parse(tmplGroups))
+
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString(htmlHead)
+	err := tpl.ExecuteTemplate(buf, "report", htmlReport{
+		Analysis:     an,
+		TargetGroups: htmlGroups{Name: "Target", Groups: an.Targets},
+		RouteGroups:  htmlGroups{Name: "Route", Groups: an.Routes},
+	})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// SaveHTML writes the analysis HTML report to the output path.
+func (an Analysis) SaveHTML(output string) error {
+	d, err := an.HTML()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(output, []byte(d), 0600)
+}
@@ -13,10 +13,13 @@ import (
 	"github.com/aws/aws-k8s-tester/ec2config"
 	"github.com/aws/aws-k8s-tester/ec2config/plugins"
 	"github.com/aws/aws-k8s-tester/internal/ssh"
+	"github.com/aws/aws-k8s-tester/pkg/artifact"
 	"github.com/aws/aws-k8s-tester/pkg/awsapi"
 	"github.com/aws/aws-k8s-tester/pkg/zaputil"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
 	"github.com/aws/aws-sdk-go/service/ec2"
@@ -39,12 +42,17 @@ type Deployer interface {
 	Stop()
 	// Delete deletes one instance.
 	Delete(id string) error
+	// CheckSpotInterruptions records Spot interruption notices of instances,
+	// and replaces interrupted instances if configured.
+	CheckSpotInterruptions() error
 	// Terminate terminates all EC2 instances in the cluster.
 	Terminate() error
 	// Logger returns the logger.
 	Logger() *zap.Logger
-	// UploadToBucketForTests uploads a local file to aws-k8s-tester S3 bucket.
+	// UploadToBucketForTests uploads a local file to aws-k8s-tester artifact store.
 	UploadToBucketForTests(localPath, remotePath string) error
+	// ArtifactURL returns the URL of the uploaded file in aws-k8s-tester artifact store.
+	ArtifactURL(remotePath string) string
 }
 
 type embedded struct {
@@ -58,14 +66,15 @@ type embedded struct {
 	sts stsiface.STSAPI
 	cf  cloudformationiface.CloudFormationAPI
 	ec2 ec2iface.EC2API
+	as  autoscalingiface.AutoScalingAPI
 
-	s3        s3iface.S3API
-	s3Buckets map[string]struct{}
+	s3    s3iface.S3API
+	store artifact.Store
 }
 
-// TODO: use cloudformation, ASG
-
-// NewDeployer creates a new EKS deployer.
+// NewDeployer creates a new EC2 deployer.
+// If "UseCloudFormation" is true, it returns a deployer
+// backed by a CloudFormation stack and an Auto Scaling group.
 func NewDeployer(cfg *ec2config.Config) (Deployer, error) {
 	if err := cfg.ValidateAndSetDefaults(); err != nil {
 		return nil, err
@@ -79,10 +88,9 @@ func NewDeployer(cfg *ec2config.Config) (Deployer, error) {
 	}
 
 	md := &embedded{
-		stopc:     make(chan struct{}),
-		lg:        lg,
-		cfg:       cfg,
-		s3Buckets: make(map[string]struct{}),
+		stopc: make(chan struct{}),
+		lg:    lg,
+		cfg:   cfg,
 	}
 
 	awsCfg := &awsapi.Config{
@@ -98,6 +106,7 @@ func NewDeployer(cfg *ec2config.Config) (Deployer, error) {
 	md.sts = sts.New(md.ss)
 	md.cf = cloudformation.New(md.ss)
 	md.ec2 = ec2.New(md.ss)
+	md.as = autoscaling.New(md.ss)
 	md.s3 = s3.New(md.ss)
 
 	output, oerr := md.sts.GetCallerIdentity(&sts.GetCallerIdentityInput{})
@@ -120,9 +129,29 @@ func NewDeployer(cfg *ec2config.Config) (Deployer, error) {
 	if len(md.cfg.Tag) > 42 {
 		md.cfg.Tag = md.cfg.Tag[:42]
 	}
-	md.cfg.ConfigPathURL = genS3URL(md.cfg.AWSRegion, md.cfg.Tag, md.cfg.ConfigPathBucket)
-	md.cfg.LogOutputToUploadPathURL = genS3URL(md.cfg.AWSRegion, md.cfg.Tag, md.cfg.LogOutputToUploadPathBucket)
-	md.cfg.KeyPathURL = genS3URL(md.cfg.AWSRegion, md.cfg.Tag, md.cfg.KeyPathBucket)
+
+	tags := map[string]string{}
+	if md.cfg.Tag != "" && md.cfg.ClusterName != "" {
+		tags[md.cfg.Tag] = md.cfg.ClusterName
+	}
+	md.store, err = artifact.New(md.lg, artifact.Config{
+		Kind:       md.cfg.ArtifactStore,
+		S3API:      md.s3,
+		Region:     md.cfg.AWSRegion,
+		Bucket:     md.cfg.Tag,
+		Tags:       tags,
+		ExpireDays: md.cfg.UploadBucketExpireDays,
+		Dir:        md.cfg.ArtifactStoreDir,
+		URL:        md.cfg.ArtifactStoreURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	md.store = artifact.WithManifest(md.store, md.cfg.ArtifactManifestPath, md.cfg.ArtifactManifestPathBucket)
+	md.cfg.ConfigPathURL = md.store.URL(md.cfg.ConfigPathBucket)
+	md.cfg.LogOutputToUploadPathURL = md.store.URL(md.cfg.LogOutputToUploadPathBucket)
+	md.cfg.KeyPathURL = md.store.URL(md.cfg.KeyPathBucket)
+	md.cfg.ArtifactManifestPathURL = md.store.URL(md.cfg.ArtifactManifestPathBucket)
 
 	lg.Info(
 		"created EC2 deployer",
@@ -130,6 +159,9 @@ func NewDeployer(cfg *ec2config.Config) (Deployer, error) {
 		zap.String("aws-k8s-tester-ec2config-path", cfg.ConfigPath),
 		zap.String("request-started", humanize.RelTime(now, time.Now().UTC(), "ago", "from now")),
 	)
+	if md.cfg.UseCloudFormation {
+		return &stackDeployer{embedded: md}, md.cfg.Sync()
+	}
 	return md, md.cfg.Sync()
 }
 
@@ -166,6 +198,9 @@ func (md *embedded) Create() (err error) {
 	}()
 	defer md.cfg.Sync()
 
+	if err = catchStopc(md.lg, md.stopc, md.findImage); err != nil {
+		return err
+	}
 	if err = catchStopc(md.lg, md.stopc, md.createKeyPair); err != nil {
 		return err
 	}
@@ -467,6 +502,10 @@ func (md *embedded) deleteInstances() (err error) {
 	return nil
 }
 
+// CheckSpotInterruptions is no-op, since instances without CloudFormation
+// are all On-Demand instances (see "ec2config.Config.Spot").
+func (md *embedded) CheckSpotInterruptions() error { return nil }
+
 func (md *embedded) Terminate() (err error) {
 	md.mu.Lock()
 	defer md.mu.Unlock()
@@ -834,7 +873,7 @@ func (md *embedded) wait(mm map[string]ec2config.Instance) {
 				select {
 				case <-time.After(5 * time.Second):
 					out, err = sh.Run(
-						"tail -10 /var/log/cloud-init-output.log",
+						"tail -10 "+md.cfg.Profile().InitLogPath,
 						ssh.WithRetry(100, 5*time.Second),
 						ssh.WithTimeout(30*time.Second),
 					)
@@ -938,6 +977,19 @@ func ConvertEC2Instance(iv *ec2.Instance) (instance ec2config.Instance) {
 	if iv.PublicIpAddress != nil {
 		instance.PublicIP = *iv.PublicIpAddress
 	}
+	if iv.InstanceLifecycle != nil {
+		instance.InstanceLifecycle = *iv.InstanceLifecycle
+	}
+	if iv.SpotInstanceRequestId != nil {
+		instance.SpotInstanceRequestID = *iv.SpotInstanceRequestId
+	}
+	for _, ni := range iv.NetworkInterfaces {
+		for _, addr := range ni.Ipv6Addresses {
+			if addr.Ipv6Address != nil {
+				instance.IPv6Addresses = append(instance.IPv6Addresses, *addr.Ipv6Address)
+			}
+		}
+	}
 	for j := range iv.BlockDeviceMappings {
 		instance.BlockDeviceMappings[j] = ec2config.BlockDeviceMapping{
 			DeviceName: *iv.BlockDeviceMappings[j].DeviceName,
@@ -956,9 +1008,3 @@ func ConvertEC2Instance(iv *ec2.Instance) (instance ec2config.Instance) {
 	}
 	return instance
 }
-
-// genS3URL returns S3 URL path.
-// e.g. https://s3-us-west-2.amazonaws.com/aws-k8s-tester-20180925/hello-world
-func genS3URL(region, bucket, s3Path string) string {
-	return fmt.Sprintf("https://s3-%s.amazonaws.com/%s/%s", region, bucket, s3Path)
-}
@@ -0,0 +1,32 @@
+package ec2
+
+import (
+	"fmt"
+
+	ec2types "github.com/aws/aws-k8s-tester/pkg/awsapi/ec2"
+	"go.uber.org/zap"
+)
+
+// findImage looks up the latest AMI of the OS profile
+// and the instance type architecture, if not specified.
+func (md *embedded) findImage() (err error) {
+	if md.cfg.ImageID != "" {
+		return nil
+	}
+	p := md.cfg.Profile()
+	name := p.ImageNames[md.cfg.Architecture]
+	if p.ImageOwner == "" || name == "" {
+		return fmt.Errorf("OS profile %q has no image for architecture %q", p.Name, md.cfg.Architecture)
+	}
+	md.cfg.ImageID, err = ec2types.LatestImageID(md.ec2, p.ImageOwner, name)
+	if err != nil {
+		return err
+	}
+	md.lg.Info("found image",
+		zap.String("image-id", md.cfg.ImageID),
+		zap.String("image-name", name),
+		zap.String("os-profile", p.Name),
+		zap.String("architecture", md.cfg.Architecture),
+	)
+	return md.cfg.Sync()
+}
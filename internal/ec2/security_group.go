@@ -3,6 +3,7 @@ package ec2
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -36,31 +37,30 @@ func (md *embedded) createSecurityGroup() (err error) {
 		zap.Strings("group-id", md.cfg.SecurityGroupIDs),
 	)
 
-	for ports, cidr := range md.cfg.IngressRulesTCP {
-		ps := strings.Split(ports, "-")
-		var fromPort int64
-		fromPort, err = strconv.ParseInt(ps[0], 10, 64)
-		if err != nil {
-			return fmt.Errorf("failed to parse %q", ports)
+	var rules []ingressRule
+	rules, err = parseIngressRules(md.cfg.IngressRulesTCP)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		perm := &ec2.IpPermission{
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int64(rule.FromPort),
+			ToPort:     aws.Int64(rule.ToPort),
 		}
-		toPort := fromPort
-		if len(ps) > 1 {
-			toPort, err = strconv.ParseInt(ps[1], 10, 64)
-			if err != nil {
-				return fmt.Errorf("failed to parse %q", ps[1])
-			}
+		if rule.IPv6 {
+			perm.Ipv6Ranges = []*ec2.Ipv6Range{{CidrIpv6: aws.String(rule.CIDR)}}
+		} else {
+			perm.IpRanges = []*ec2.IpRange{{CidrIp: aws.String(rule.CIDR)}}
 		}
 		_, err = md.ec2.AuthorizeSecurityGroupIngress(&ec2.AuthorizeSecurityGroupIngressInput{
-			GroupId:    output.GroupId,
-			IpProtocol: aws.String("tcp"),
-			CidrIp:     aws.String(cidr),
-			FromPort:   aws.Int64(fromPort),
-			ToPort:     aws.Int64(toPort),
+			GroupId:       output.GroupId,
+			IpPermissions: []*ec2.IpPermission{perm},
 		})
 		if err != nil {
-			return fmt.Errorf("failed to authorize ingress for port %q and cidr %q (%v)", ports, cidr, err)
+			return fmt.Errorf("failed to authorize ingress for port %d-%d and cidr %q (%v)", rule.FromPort, rule.ToPort, rule.CIDR, err)
 		}
-		md.lg.Debug("authorized ingress", zap.String("port", ports), zap.String("cidr", cidr))
+		md.lg.Debug("authorized ingress", zap.Int64("from-port", rule.FromPort), zap.Int64("to-port", rule.ToPort), zap.String("cidr", rule.CIDR))
 	}
 
 	_, err = md.ec2.CreateTags(&ec2.CreateTagsInput{
@@ -122,3 +122,44 @@ func (md *embedded) deleteSecurityGroup() error {
 	}
 	return fmt.Errorf("deleted security group but %q still exists", md.cfg.KeyName)
 }
+
+// ingressRule is a TCP ingress rule of security group.
+type ingressRule struct {
+	FromPort int64
+	ToPort   int64
+	CIDR     string
+	IPv6     bool
+}
+
+// parseIngressRules parses the map from TCP port range (e.g. "22", "2379-2380")
+// to CIDR, and returns the rules sorted by ports.
+func parseIngressRules(m map[string]string) (rules []ingressRule, err error) {
+	for ports, cidr := range m {
+		ps := strings.Split(ports, "-")
+		var fromPort int64
+		fromPort, err = strconv.ParseInt(ps[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q", ports)
+		}
+		toPort := fromPort
+		if len(ps) > 1 {
+			toPort, err = strconv.ParseInt(ps[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %q", ps[1])
+			}
+		}
+		rules = append(rules, ingressRule{
+			FromPort: fromPort,
+			ToPort:   toPort,
+			CIDR:     cidr,
+			IPv6:     strings.Contains(cidr, ":"),
+		})
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].FromPort != rules[j].FromPort {
+			return rules[i].FromPort < rules[j].FromPort
+		}
+		return rules[i].ToPort < rules[j].ToPort
+	})
+	return rules, nil
+}
@@ -0,0 +1,105 @@
+package ec2
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"go.uber.org/zap"
+)
+
+// CheckSpotInterruptions describes the Spot requests of the Spot instances,
+// and records interruption notices in the instances. It returns the sorted IDs
+// of interrupted instances that have not been replaced yet.
+// On-Demand instances are never interrupted, and skipped.
+func CheckSpotInterruptions(svc ec2iface.EC2API, instances map[string]ec2config.Instance) (ids []string, err error) {
+	reqToID := make(map[string]string)
+	for id, iv := range instances {
+		if iv.SpotInstanceRequestID != "" {
+			reqToID[iv.SpotInstanceRequestID] = id
+		}
+	}
+	if len(reqToID) == 0 {
+		return nil, nil
+	}
+	reqs := make([]string, 0, len(reqToID))
+	for req := range reqToID {
+		reqs = append(reqs, req)
+	}
+	sort.Strings(reqs)
+
+	var output *ec2.DescribeSpotInstanceRequestsOutput
+	output, err = svc.DescribeSpotInstanceRequests(&ec2.DescribeSpotInstanceRequestsInput{
+		SpotInstanceRequestIds: aws.StringSlice(reqs),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe Spot requests %v (%v)", reqs, err)
+	}
+	for _, req := range output.SpotInstanceRequests {
+		if req.Status == nil || !isSpotInterrupted(aws.StringValue(req.Status.Code)) {
+			continue
+		}
+		id := reqToID[aws.StringValue(req.SpotInstanceRequestId)]
+		iv, ok := instances[id]
+		if !ok {
+			continue
+		}
+		if iv.SpotInterruption == nil {
+			iv.SpotInterruption = &ec2config.SpotInterruption{}
+		}
+		iv.SpotInterruption.Code = aws.StringValue(req.Status.Code)
+		iv.SpotInterruption.Message = aws.StringValue(req.Status.Message)
+		iv.SpotInterruption.UpdateTime = aws.TimeValue(req.Status.UpdateTime)
+		instances[id] = iv
+		if !iv.SpotInterruption.Replaced {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// isSpotInterrupted returns true if the Spot request status code indicates
+// that EC2 interrupts, or has interrupted, the instance. Stops and terminations
+// requested by the user are not interruptions.
+// https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/spot-bid-status.html
+func isSpotInterrupted(code string) bool {
+	switch {
+	case strings.HasSuffix(code, "-by-user"):
+		return false
+	case strings.HasPrefix(code, "marked-for-"),
+		strings.HasPrefix(code, "instance-terminated-"),
+		strings.HasPrefix(code, "instance-stopped-"):
+		return true
+	}
+	return false
+}
+
+// ReplaceInstances terminates the instances in their Auto Scaling group,
+// without decrementing the desired capacity, so that the Auto Scaling group
+// launches replacement instances.
+func ReplaceInstances(lg *zap.Logger, svc autoscalingiface.AutoScalingAPI, ids []string) error {
+	var errs []string
+	for _, id := range ids {
+		_, err := svc.TerminateInstanceInAutoScalingGroup(&autoscaling.TerminateInstanceInAutoScalingGroupInput{
+			InstanceId:                     aws.String(id),
+			ShouldDecrementDesiredCapacity: aws.Bool(false),
+		})
+		if err != nil {
+			lg.Warn("failed to replace instance", zap.String("instance-id", id), zap.Error(err))
+			errs = append(errs, fmt.Sprintf("%s: %v", id, err))
+			continue
+		}
+		lg.Info("replacing instance", zap.String("instance-id", id))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to replace instances (%s)", strings.Join(errs, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package ec2
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+type fakeSpotEC2 struct {
+	ec2iface.EC2API
+	reqs []*ec2.SpotInstanceRequest
+}
+
+func (f *fakeSpotEC2) DescribeSpotInstanceRequests(*ec2.DescribeSpotInstanceRequestsInput) (*ec2.DescribeSpotInstanceRequestsOutput, error) {
+	return &ec2.DescribeSpotInstanceRequestsOutput{SpotInstanceRequests: f.reqs}, nil
+}
+
+func TestCheckSpotInterruptions(t *testing.T) {
+	now := time.Now().UTC()
+	newReq := func(id, code string) *ec2.SpotInstanceRequest {
+		return &ec2.SpotInstanceRequest{
+			SpotInstanceRequestId: aws.String(id),
+			Status: &ec2.SpotInstanceStatus{
+				Code:       aws.String(code),
+				Message:    aws.String(code),
+				UpdateTime: aws.Time(now),
+			},
+		}
+	}
+	svc := &fakeSpotEC2{reqs: []*ec2.SpotInstanceRequest{
+		newReq("sir-1", "fulfilled"),
+		newReq("sir-2", "marked-for-termination"),
+		newReq("sir-3", "instance-terminated-by-price"),
+		newReq("sir-4", "instance-terminated-by-user"),
+	}}
+	instances := map[string]ec2config.Instance{
+		"i-0": {InstanceID: "i-0"},
+		"i-1": {InstanceID: "i-1", InstanceLifecycle: "spot", SpotInstanceRequestID: "sir-1"},
+		"i-2": {InstanceID: "i-2", InstanceLifecycle: "spot", SpotInstanceRequestID: "sir-2"},
+		"i-3": {InstanceID: "i-3", InstanceLifecycle: "spot", SpotInstanceRequestID: "sir-3", SpotInterruption: &ec2config.SpotInterruption{Replaced: true}},
+		"i-4": {InstanceID: "i-4", InstanceLifecycle: "spot", SpotInstanceRequestID: "sir-4"},
+	}
+	ids, err := CheckSpotInterruptions(svc, instances)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []string{"i-2"}) {
+		t.Fatalf("expected interrupted [i-2], got %v", ids)
+	}
+	for id, code := range map[string]string{"i-2": "marked-for-termination", "i-3": "instance-terminated-by-price"} {
+		si := instances[id].SpotInterruption
+		if si == nil || si.Code != code || !si.UpdateTime.Equal(now) {
+			t.Fatalf("%q expected interruption %q, got %+v", id, code, si)
+		}
+	}
+	for _, id := range []string{"i-0", "i-1", "i-4"} {
+		if si := instances[id].SpotInterruption; si != nil {
+			t.Fatalf("%q expected no interruption, got %+v", id, si)
+		}
+	}
+
+	ids, err = CheckSpotInterruptions(svc, map[string]ec2config.Instance{"i-0": {InstanceID: "i-0"}})
+	if err != nil || len(ids) != 0 {
+		t.Fatalf("expected no interruption for On-Demand instances, got %v (%v)", ids, err)
+	}
+}
+
+func TestIsSpotInterrupted(t *testing.T) {
+	for code, interrupted := range map[string]bool{
+		"fulfilled":                             false,
+		"pending-fulfillment":                   false,
+		"marked-for-termination":                true,
+		"marked-for-stop":                       true,
+		"instance-terminated-by-price":          true,
+		"instance-terminated-no-capacity":       true,
+		"instance-stopped-by-price":             true,
+		"instance-terminated-by-user":           false,
+		"instance-stopped-by-user":              false,
+		"request-canceled-and-instance-running": false,
+	} {
+		if isSpotInterrupted(code) != interrupted {
+			t.Fatalf("%q expected interrupted %v", code, interrupted)
+		}
+	}
+}
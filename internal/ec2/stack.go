@@ -0,0 +1,673 @@
+package ec2
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	humanize "github.com/dustin/go-humanize"
+	"go.uber.org/zap"
+)
+
+// stackDeployer implements Deployer with a CloudFormation stack,
+// where an Auto Scaling group launches instances from a launch template.
+// Adding or deleting an instance changes the Auto Scaling group capacity,
+// and terminating deletes the whole stack at once.
+type stackDeployer struct {
+	*embedded
+}
+
+func (md *stackDeployer) Create() (err error) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+
+	now := time.Now().UTC()
+	md.lg.Info("creating", zap.String("cluster-name", md.cfg.ClusterName), zap.String("stack-name", md.cfg.CFStackName))
+
+	defer func() {
+		if err != nil {
+			md.lg.Warn("reverting EC2 stack creation", zap.Error(err))
+			if derr := md.deleteStack(); derr != nil {
+				md.lg.Warn("failed to revert stack creation", zap.Error(derr))
+			}
+			if md.cfg.KeyCreated {
+				if derr := md.deleteKeyPair(); derr != nil {
+					md.lg.Warn("failed to revert key pair creation", zap.Error(derr))
+				}
+			}
+		}
+	}()
+	defer md.cfg.Sync()
+
+	if err = catchStopc(md.lg, md.stopc, md.findImage); err != nil {
+		return err
+	}
+	if err = catchStopc(md.lg, md.stopc, md.createKeyPair); err != nil {
+		return err
+	}
+	if md.cfg.VPCID != "" && len(md.cfg.SubnetIDs) == 0 {
+		if err = catchStopc(md.lg, md.stopc, md.getSubnets); err != nil {
+			return err
+		}
+	}
+	if err = catchStopc(md.lg, md.stopc, md.createStack); err != nil {
+		return err
+	}
+	if err = catchStopc(md.lg, md.stopc, md.updateInstances); err != nil {
+		return err
+	}
+
+	md.lg.Info("created",
+		zap.String("cluster-name", md.cfg.ClusterName),
+		zap.String("stack-name", md.cfg.CFStackName),
+		zap.String("asg-name", md.cfg.ASGName),
+		zap.Int("cluster-size", md.cfg.ClusterSize),
+		zap.String("request-started", humanize.RelTime(now, time.Now().UTC(), "ago", "from now")),
+	)
+
+	if md.cfg.Wait {
+		md.cfg.Sync()
+		mm := make(map[string]ec2config.Instance, len(md.cfg.Instances))
+		for k, v := range md.cfg.Instances {
+			mm[k] = v
+		}
+		md.wait(mm)
+	}
+
+	if err = md.cfg.Sync(); err != nil {
+		return err
+	}
+	if md.cfg.UploadTesterLogs {
+		if err = md.uploadTesterLogs(); err != nil {
+			md.lg.Warn("failed to upload", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (md *stackDeployer) Add() (err error) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+
+	if md.cfg.CFStackID == "" {
+		return errors.New("cannot add without stack")
+	}
+
+	now := time.Now().UTC()
+	md.lg.Info("creating one EC2 instance", zap.String("cluster-name", md.cfg.ClusterName))
+
+	prev := make(map[string]struct{}, len(md.cfg.Instances))
+	for id := range md.cfg.Instances {
+		prev[id] = struct{}{}
+	}
+	if err = md.updateClusterSize(md.cfg.ClusterSize + 1); err != nil {
+		return err
+	}
+	md.cfg.ClusterSize++
+	if err = md.updateInstances(); err != nil {
+		return err
+	}
+
+	mm := make(map[string]ec2config.Instance, 1)
+	for id, iv := range md.cfg.Instances {
+		if _, ok := prev[id]; !ok {
+			mm[id] = iv
+		}
+	}
+	md.lg.Info("created one EC2 instance",
+		zap.String("cluster-name", md.cfg.ClusterName),
+		zap.Int("cluster-size", md.cfg.ClusterSize),
+		zap.String("request-started", humanize.RelTime(now, time.Now().UTC(), "ago", "from now")),
+	)
+	md.wait(mm)
+
+	return md.cfg.Sync()
+}
+
+func (md *stackDeployer) Delete(id string) (err error) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+
+	if len(md.cfg.Instances) != md.cfg.ClusterSize {
+		return fmt.Errorf("len(Instances) %d != ClusterSize %d", len(md.cfg.Instances), md.cfg.ClusterSize)
+	}
+	if _, ok := md.cfg.Instances[id]; !ok {
+		return fmt.Errorf("failed to delete an instance, id %q not found", id)
+	}
+
+	now := time.Now().UTC()
+	md.lg.Debug("deleting an instance", zap.String("cluster-name", md.cfg.ClusterName), zap.String("instance-id", id))
+
+	// decrement the desired capacity, so that the Auto Scaling group
+	// does not launch a replacement instance
+	_, err = md.as.TerminateInstanceInAutoScalingGroup(&autoscaling.TerminateInstanceInAutoScalingGroupInput{
+		InstanceId:                     aws.String(id),
+		ShouldDecrementDesiredCapacity: aws.Bool(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete an instance %q (%v)", id, err)
+	}
+
+	// wait until the instance is detached from the Auto Scaling group,
+	// which happens after "Terminating" lifecycle states
+	retryStart := time.Now().UTC()
+	for {
+		select {
+		case <-md.stopc:
+			return fmt.Errorf("interrupted while deleting an instance %q", id)
+		default:
+		}
+
+		var ids []string
+		ids, err = md.describeASGInstances(true)
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, v := range ids {
+			if v == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+		if time.Now().UTC().Sub(retryStart) > 5*time.Minute {
+			return fmt.Errorf("instance %q still in Auto Scaling group %q after %v", id, md.cfg.ASGName, time.Now().UTC().Sub(retryStart))
+		}
+		md.lg.Info("deleting an instance", zap.String("instance-id", id))
+		time.Sleep(5 * time.Second)
+	}
+
+	// keep stack parameter in sync with the Auto Scaling group
+	if err = md.updateClusterSize(md.cfg.ClusterSize - 1); err != nil {
+		return err
+	}
+
+	prev := len(md.cfg.Instances)
+	delete(md.cfg.Instances, id)
+	md.cfg.ClusterSize--
+	md.lg.Info(
+		"deleted an instance",
+		zap.String("cluster-name", md.cfg.ClusterName),
+		zap.String("instance-id", id),
+		zap.Int("previous-cluster-size", prev),
+		zap.Int("current-cluster-size", len(md.cfg.Instances)),
+		zap.String("request-started", humanize.RelTime(now, time.Now().UTC(), "ago", "from now")),
+	)
+	return md.cfg.Sync()
+}
+
+func (md *stackDeployer) CheckSpotInterruptions() (err error) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+
+	if !md.cfg.Spot.Enable {
+		return nil
+	}
+
+	var ids []string
+	ids, err = CheckSpotInterruptions(md.ec2, md.cfg.Instances)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		md.lg.Info("no Spot interruption", zap.String("cluster-name", md.cfg.ClusterName))
+		return md.cfg.Sync()
+	}
+	md.lg.Warn("found Spot interruptions", zap.String("cluster-name", md.cfg.ClusterName), zap.Strings("instance-ids", ids))
+	if !md.cfg.Spot.ReplaceInterrupted {
+		return md.cfg.Sync()
+	}
+
+	now := time.Now().UTC()
+	if err = ReplaceInstances(md.lg, md.as, ids); err != nil {
+		return err
+	}
+	if md.cfg.InterruptedInstances == nil {
+		md.cfg.InterruptedInstances = make(map[string]ec2config.Instance)
+	}
+	for _, id := range ids {
+		iv := md.cfg.Instances[id]
+		iv.SpotInterruption.Replaced = true
+		md.cfg.InterruptedInstances[id] = iv
+		delete(md.cfg.Instances, id)
+	}
+	if err = md.cfg.Sync(); err != nil {
+		return err
+	}
+
+	if err = md.updateInstances(); err != nil {
+		return err
+	}
+	md.lg.Info("replaced interrupted Spot instances",
+		zap.String("cluster-name", md.cfg.ClusterName),
+		zap.Strings("instance-ids", ids),
+		zap.String("request-started", humanize.RelTime(now, time.Now().UTC(), "ago", "from now")),
+	)
+	return nil
+}
+
+func (md *stackDeployer) Terminate() (err error) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+
+	now := time.Now().UTC()
+	md.lg.Info("deleting", zap.String("cluster-name", md.cfg.ClusterName), zap.String("stack-name", md.cfg.CFStackName))
+
+	var errs []string
+	if err = md.deleteStack(); err != nil {
+		md.lg.Warn("failed to delete stack", zap.Error(err))
+		errs = append(errs, err.Error())
+	}
+	if md.cfg.KeyCreated {
+		if err = md.deleteKeyPair(); err != nil {
+			md.lg.Warn("failed to delete key pair", zap.Error(err))
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	md.lg.Info("deleted",
+		zap.String("cluster-name", md.cfg.ClusterName),
+		zap.String("request-started", humanize.RelTime(now, time.Now().UTC(), "ago", "from now")),
+	)
+
+	if err = md.cfg.Sync(); err != nil {
+		return err
+	}
+	if md.cfg.UploadTesterLogs {
+		if err = md.uploadTesterLogs(); err != nil {
+			md.lg.Warn("failed to upload", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (md *stackDeployer) createStack() error {
+	if md.cfg.CFStackID != "" {
+		return fmt.Errorf("stack %q already exists", md.cfg.CFStackID)
+	}
+
+	h, _ := os.Hostname()
+	v, err := newEC2Stack(md.cfg, h)
+	if err != nil {
+		return err
+	}
+	s, err := createStackTemplate(v)
+	if err != nil {
+		return err
+	}
+
+	var output *cloudformation.CreateStackOutput
+	output, err = md.cf.CreateStack(&cloudformation.CreateStackInput{
+		StackName: aws.String(md.cfg.CFStackName),
+		Tags: []*cloudformation.Tag{
+			{
+				Key:   aws.String("Name"),
+				Value: aws.String(md.cfg.ClusterName),
+			},
+			{
+				Key:   aws.String("HOSTNAME"),
+				Value: aws.String(h),
+			},
+		},
+		Parameters: []*cloudformation.Parameter{
+			{
+				ParameterKey:   aws.String("ClusterSize"),
+				ParameterValue: aws.String(strconv.Itoa(md.cfg.ClusterSize)),
+			},
+		},
+		TemplateBody: aws.String(s),
+	})
+	if err != nil {
+		return err
+	}
+	md.cfg.CFStackID = *output.StackId
+	md.cfg.Sync()
+	md.lg.Info("creating stack", zap.String("stack-name", md.cfg.CFStackName), zap.String("stack-id", md.cfg.CFStackID))
+
+	if err = md.waitStack("CREATE_COMPLETE", 15*time.Minute); err != nil {
+		return err
+	}
+
+	if len(md.cfg.SubnetIDs) > 0 {
+		var so *ec2.DescribeSubnetsOutput
+		so, err = md.ec2.DescribeSubnets(&ec2.DescribeSubnetsInput{
+			SubnetIds: aws.StringSlice(md.cfg.SubnetIDs),
+		})
+		if err != nil {
+			return err
+		}
+		md.cfg.SubnetIDToAvailabilityZone = make(map[string]string, len(so.Subnets))
+		for _, sv := range so.Subnets {
+			md.cfg.SubnetIDToAvailabilityZone[*sv.SubnetId] = *sv.AvailabilityZone
+		}
+	}
+
+	md.lg.Info("created stack",
+		zap.String("stack-name", md.cfg.CFStackName),
+		zap.String("vpc-id", md.cfg.VPCID),
+		zap.Strings("subnet-ids", md.cfg.SubnetIDs),
+		zap.Strings("security-group-ids", md.cfg.SecurityGroupIDs),
+		zap.String("asg-name", md.cfg.ASGName),
+	)
+	return md.cfg.Sync()
+}
+
+// updateClusterSize updates the stack with the new Auto Scaling group capacity.
+func (md *stackDeployer) updateClusterSize(n int) error {
+	_, err := md.cf.UpdateStack(&cloudformation.UpdateStackInput{
+		StackName:           aws.String(md.cfg.CFStackID),
+		UsePreviousTemplate: aws.Bool(true),
+		Parameters: []*cloudformation.Parameter{
+			{
+				ParameterKey:   aws.String("ClusterSize"),
+				ParameterValue: aws.String(strconv.Itoa(n)),
+			},
+		},
+	})
+	if err != nil {
+		// ValidationError: No updates are to be performed.
+		if strings.Contains(err.Error(), "No updates are to be performed") {
+			return nil
+		}
+		return err
+	}
+	md.lg.Info("updating stack", zap.String("stack-name", md.cfg.CFStackName), zap.Int("cluster-size", n))
+	return md.waitStack("UPDATE_COMPLETE", 10*time.Minute)
+}
+
+func (md *stackDeployer) deleteStack() error {
+	if md.cfg.CFStackID == "" {
+		md.lg.Info("no stack found, nothing to delete", zap.String("stack-name", md.cfg.CFStackName))
+		return nil
+	}
+	if md.cfg.CFStackStatus == "DELETE_COMPLETE" {
+		return nil
+	}
+
+	_, err := md.cf.DeleteStack(&cloudformation.DeleteStackInput{
+		StackName: aws.String(md.cfg.CFStackID),
+	})
+	if err != nil {
+		md.cfg.CFStackStatus = err.Error()
+		return err
+	}
+	md.lg.Info("deleting stack", zap.String("stack-name", md.cfg.CFStackName))
+
+	// instances must terminate before subnets and security group are deleted
+	return md.waitStack("DELETE_COMPLETE", 10*time.Minute+time.Duration(md.cfg.ClusterSize)*10*time.Second)
+}
+
+// waitStack waits until the stack reaches the desired status,
+// records new stack events, and updates the configuration from stack outputs.
+func (md *stackDeployer) waitStack(desired string, timeout time.Duration) error {
+	now := time.Now().UTC()
+	for time.Now().UTC().Sub(now) < timeout {
+		select {
+		case <-md.stopc:
+			return fmt.Errorf("interrupted while waiting for %q", desired)
+		case <-time.After(10 * time.Second):
+		}
+
+		md.updateStackEvents()
+
+		// stack ID works even after the stack is deleted
+		do, err := md.cf.DescribeStacks(&cloudformation.DescribeStacksInput{
+			StackName: aws.String(md.cfg.CFStackID),
+		})
+		if err != nil {
+			md.lg.Warn("failed to describe stack", zap.String("stack-name", md.cfg.CFStackName), zap.Error(err))
+			continue
+		}
+		if len(do.Stacks) != 1 {
+			return fmt.Errorf("%q expects 1 stack, got %v", md.cfg.CFStackName, do.Stacks)
+		}
+
+		md.cfg.CFStackStatus = *do.Stacks[0].StackStatus
+		if md.cfg.CFStackStatus == desired {
+			md.updateStackOutputs(do.Stacks[0].Outputs)
+			md.lg.Info("stack is ready",
+				zap.String("stack-name", md.cfg.CFStackName),
+				zap.String("stack-status", md.cfg.CFStackStatus),
+				zap.String("request-started", humanize.RelTime(now, time.Now().UTC(), "ago", "from now")),
+			)
+			return md.cfg.Sync()
+		}
+		if isStackFailed(md.cfg.CFStackStatus) {
+			md.cfg.Sync()
+			return fmt.Errorf("stack %q failed with %q (%s)", md.cfg.CFStackName, md.cfg.CFStackStatus, lastStackFailure(md.cfg.CFStackEvents))
+		}
+
+		md.lg.Info("waiting for stack",
+			zap.String("stack-name", md.cfg.CFStackName),
+			zap.String("stack-status", md.cfg.CFStackStatus),
+			zap.String("desired-status", desired),
+			zap.String("request-started", humanize.RelTime(now, time.Now().UTC(), "ago", "from now")),
+		)
+		md.cfg.Sync()
+	}
+	return fmt.Errorf("stack %q did not reach %q in %v (last status %q)", md.cfg.CFStackName, desired, timeout, md.cfg.CFStackStatus)
+}
+
+// updateStackEvents records the new stack events in the configuration.
+func (md *stackDeployer) updateStackEvents() {
+	var events []*cloudformation.StackEvent
+	seen := make(map[string]struct{}, len(md.cfg.CFStackEvents))
+	for _, ev := range md.cfg.CFStackEvents {
+		seen[ev.EventID] = struct{}{}
+	}
+	err := md.cf.DescribeStackEventsPages(
+		&cloudformation.DescribeStackEventsInput{StackName: aws.String(md.cfg.CFStackID)},
+		func(output *cloudformation.DescribeStackEventsOutput, lastPage bool) bool {
+			for _, ev := range output.StackEvents {
+				// events are returned in reverse chronological order
+				if _, ok := seen[aws.StringValue(ev.EventId)]; ok {
+					return false
+				}
+				events = append(events, ev)
+			}
+			return true
+		},
+	)
+	if err != nil {
+		md.lg.Warn("failed to describe stack events", zap.String("stack-name", md.cfg.CFStackName), zap.Error(err))
+	}
+
+	n := len(md.cfg.CFStackEvents)
+	md.cfg.CFStackEvents = mergeStackEvents(md.cfg.CFStackEvents, events)
+	for _, ev := range md.cfg.CFStackEvents[n:] {
+		if isStackFailed(ev.ResourceStatus) {
+			md.lg.Warn("stack event",
+				zap.String("logical-resource-id", ev.LogicalResourceID),
+				zap.String("resource-status", ev.ResourceStatus),
+				zap.String("resource-status-reason", ev.ResourceStatusReason),
+			)
+		}
+	}
+}
+
+// updateStackOutputs updates VPC, subnet, security group,
+// and Auto Scaling group from the stack outputs.
+func (md *stackDeployer) updateStackOutputs(ops []*cloudformation.Output) {
+	for _, op := range ops {
+		switch *op.OutputKey {
+		case "VpcId":
+			md.cfg.VPCID = *op.OutputValue
+		case "VpcIpv6CidrBlock":
+			md.cfg.VPCIPv6CIDR = *op.OutputValue
+		case "InternetGatewayId":
+			md.cfg.InternetGatewayID = *op.OutputValue
+		case "RouteTableId":
+			md.cfg.RouteTableIDs = []string{*op.OutputValue}
+		case "SubnetIds":
+			md.cfg.SubnetIDs = strings.Split(*op.OutputValue, ",")
+		case "SecurityGroupIds":
+			md.cfg.SecurityGroupIDs = strings.Split(*op.OutputValue, ",")
+		case "LaunchTemplateId":
+			md.cfg.LaunchTemplateID = *op.OutputValue
+		case "AutoScalingGroupName":
+			md.cfg.ASGName = *op.OutputValue
+		}
+	}
+}
+
+// describeASGInstances returns the IDs of in-service instances in the Auto Scaling group.
+// If "all" is true, it also returns instances in other lifecycle states (e.g. "Terminating").
+func (md *stackDeployer) describeASGInstances(all bool) (ids []string, err error) {
+	var output *autoscaling.DescribeAutoScalingGroupsOutput
+	output, err = md.as.DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: aws.StringSlice([]string{md.cfg.ASGName}),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(output.AutoScalingGroups) != 1 {
+		return nil, fmt.Errorf("expected 1 Auto Scaling group %q, got %d", md.cfg.ASGName, len(output.AutoScalingGroups))
+	}
+	for _, iv := range output.AutoScalingGroups[0].Instances {
+		if all || aws.StringValue(iv.LifecycleState) == autoscaling.LifecycleStateInService {
+			ids = append(ids, *iv.InstanceId)
+		}
+	}
+	return ids, nil
+}
+
+// updateInstances waits until the Auto Scaling group has "ClusterSize"
+// running instances, and records them in the configuration.
+func (md *stackDeployer) updateInstances() (err error) {
+	if md.cfg.ASGName == "" {
+		return errors.New("cannot find instances without Auto Scaling group")
+	}
+
+	now := time.Now().UTC()
+	retryStart := time.Now().UTC()
+	for time.Now().UTC().Sub(retryStart) < 5*time.Minute+time.Duration(md.cfg.ClusterSize)*30*time.Second {
+		select {
+		case <-md.stopc:
+			return errors.New("interrupted while waiting for instances")
+		default:
+		}
+
+		var ids []string
+		ids, err = md.describeASGInstances(false)
+		if err != nil {
+			md.lg.Warn("failed to describe Auto Scaling group", zap.String("asg-name", md.cfg.ASGName), zap.Error(err))
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		if len(ids) != md.cfg.ClusterSize {
+			md.lg.Info("waiting for instances in Auto Scaling group",
+				zap.String("asg-name", md.cfg.ASGName),
+				zap.Int("in-service", len(ids)),
+				zap.Int("cluster-size", md.cfg.ClusterSize),
+			)
+			time.Sleep(10 * time.Second)
+			continue
+		}
+
+		instances := make(map[string]ec2config.Instance, len(ids))
+		if len(ids) > 0 {
+			var output *ec2.DescribeInstancesOutput
+			output, err = md.ec2.DescribeInstances(&ec2.DescribeInstancesInput{
+				InstanceIds: aws.StringSlice(ids),
+			})
+			if err != nil {
+				md.lg.Warn("failed to describe instances", zap.Error(err))
+				time.Sleep(5 * time.Second)
+				continue
+			}
+			for _, rv := range output.Reservations {
+				for _, inst := range rv.Instances {
+					if *inst.State.Name == "running" {
+						instances[*inst.InstanceId] = ConvertEC2Instance(inst)
+					}
+				}
+			}
+		}
+		if len(instances) != md.cfg.ClusterSize {
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		for id, iv := range instances {
+			if prev, ok := md.cfg.Instances[id]; ok {
+				iv.SpotInterruption = prev.SpotInterruption
+				instances[id] = iv
+			} else {
+				md.lg.Info("instance is ready",
+					zap.String("cluster-name", md.cfg.ClusterName),
+					zap.String("instance-id", iv.InstanceID),
+					zap.String("instance-public-ip", iv.PublicIP),
+					zap.String("instance-private-ip", iv.PrivateIP),
+					zap.String("instance-public-dns", iv.PublicDNSName),
+					zap.String("instance-private-dns", iv.PrivateDNSName),
+				)
+			}
+		}
+		md.cfg.Instances = instances
+		md.lg.Info("found EC2 instances",
+			zap.String("asg-name", md.cfg.ASGName),
+			zap.Int("cluster-size", md.cfg.ClusterSize),
+			zap.String("request-started", humanize.RelTime(now, time.Now().UTC(), "ago", "from now")),
+		)
+		return md.cfg.Sync()
+	}
+	return fmt.Errorf("Auto Scaling group %q does not have %d running instances", md.cfg.ASGName, md.cfg.ClusterSize)
+}
+
+// mergeStackEvents appends the new events, returned by the CloudFormation API
+// in reverse chronological order, to the recorded events in chronological order.
+func mergeStackEvents(recorded []ec2config.CFStackEvent, newestFirst []*cloudformation.StackEvent) []ec2config.CFStackEvent {
+	for i := len(newestFirst) - 1; i >= 0; i-- {
+		ev := newestFirst[i]
+		recorded = append(recorded, ec2config.CFStackEvent{
+			EventID:              aws.StringValue(ev.EventId),
+			Timestamp:            aws.TimeValue(ev.Timestamp),
+			LogicalResourceID:    aws.StringValue(ev.LogicalResourceId),
+			ResourceType:         aws.StringValue(ev.ResourceType),
+			ResourceStatus:       aws.StringValue(ev.ResourceStatus),
+			ResourceStatusReason: aws.StringValue(ev.ResourceStatusReason),
+		})
+	}
+	return recorded
+}
+
+// lastStackFailure returns the reason of the first failed resource
+// in the latest stack operation, which usually caused the rollback.
+func lastStackFailure(events []ec2config.CFStackEvent) string {
+	reason := "unknown reason"
+	for i := len(events) - 1; i >= 0; i-- {
+		ev := events[i]
+		if ev.ResourceType == "AWS::CloudFormation::Stack" {
+			switch ev.ResourceStatus {
+			case "CREATE_IN_PROGRESS", "UPDATE_IN_PROGRESS", "DELETE_IN_PROGRESS":
+				return reason
+			}
+		}
+		if strings.HasSuffix(ev.ResourceStatus, "_FAILED") && ev.ResourceStatusReason != "" {
+			reason = fmt.Sprintf("%s: %s", ev.LogicalResourceID, ev.ResourceStatusReason)
+		}
+	}
+	return reason
+}
+
+// isStackFailed returns true if the stack or resource status
+// indicates that the stack operation has failed.
+// https://docs.aws.amazon.com/AWSCloudFormation/latest/APIReference/API_Stack.html
+func isStackFailed(status string) bool {
+	return strings.HasSuffix(status, "_FAILED") || strings.Contains(status, "ROLLBACK")
+}
@@ -0,0 +1,350 @@
+package ec2
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
+)
+
+func createStackTemplate(v ec2Stack) (string, error) {
+	tpl := template.Must(template.New("ec2StackTemplate").Parse(ec2StackTemplate))
+	buf := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buf, v); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// ec2Stack is the CloudFormation stack of EC2 deployer.
+type ec2Stack struct {
+	Description string
+	ClusterName string
+	Hostname    string
+	// Tags is the additional tags for instances.
+	Tags map[string]string
+
+	// CreateVPC is true to create VPC and subnets in the stack.
+	// Otherwise, the stack uses existing "VPCID" and subnets.
+	CreateVPC  bool
+	VPCID      string
+	VPCCIDR    string
+	Subnets    []ec2StackSubnet
+	EnableIPv6 bool
+	// SubnetRefs is the comma-separated subnet references (e.g. "!Ref Subnet01").
+	SubnetRefs string
+
+	// CreateSecurityGroup is true to create a security group in the stack.
+	CreateSecurityGroup bool
+	IngressRules        []ingressRule
+	// SecurityGroupRefs is the comma-separated security group references.
+	SecurityGroupRefs string
+
+	ImageID                  string
+	InstanceType             string
+	KeyName                  string
+	InstanceProfileName      string
+	AssociatePublicIPAddress bool
+	// UserData is the base64-encoded init script.
+	UserData string
+
+	// Spot is non-nil to launch instances with a mixed instances policy.
+	Spot *ec2config.Spot
+}
+
+// ec2StackSubnet is a subnet in the stack.
+type ec2StackSubnet struct {
+	// Name is the CloudFormation logical ID.
+	Name string
+	CIDR string
+}
+
+// newEC2Stack creates the stack from the EC2 configuration.
+// It creates a VPC with 3 subnets, unless "VPCID" is given,
+// and a security group, unless "SecurityGroupIDs" are given.
+func newEC2Stack(cfg *ec2config.Config, hostname string) (ec2Stack, error) {
+	v := ec2Stack{
+		Description:              cfg.ClusterName + "-ec2-stack",
+		ClusterName:              cfg.ClusterName,
+		Hostname:                 hostname,
+		CreateVPC:                cfg.VPCID == "",
+		VPCID:                    cfg.VPCID,
+		VPCCIDR:                  cfg.VPCCIDR,
+		EnableIPv6:               cfg.EnableIPv6,
+		CreateSecurityGroup:      len(cfg.SecurityGroupIDs) == 0,
+		ImageID:                  cfg.ImageID,
+		InstanceType:             cfg.InstanceType,
+		KeyName:                  cfg.KeyName,
+		InstanceProfileName:      cfg.InstanceProfileName,
+		AssociatePublicIPAddress: cfg.AssociatePublicIPAddress,
+		UserData:                 base64.StdEncoding.EncodeToString([]byte(cfg.InitScript)),
+	}
+
+	if cfg.Spot.Enable {
+		sp := cfg.Spot
+		v.Spot = &sp
+	}
+
+	// EC2 rejects duplicate tag keys, skip the ones the template always sets
+	v.Tags = make(map[string]string, len(cfg.Tags))
+	for k, tv := range cfg.Tags {
+		if k == "Name" || k == "kubernetes.io/cluster/"+cfg.ClusterName {
+			continue
+		}
+		v.Tags[k] = tv
+	}
+
+	var refs []string
+	if v.CreateVPC {
+		for i, cidr := range []string{subnetCIDR1, subnetCIDR2, subnetCIDR3} {
+			name := fmt.Sprintf("Subnet%02d", i+1)
+			v.Subnets = append(v.Subnets, ec2StackSubnet{Name: name, CIDR: cidr})
+			refs = append(refs, "!Ref "+name)
+		}
+	} else {
+		if len(cfg.SubnetIDs) == 0 {
+			return ec2Stack{}, fmt.Errorf("VPC %q requires SubnetIDs", cfg.VPCID)
+		}
+		refs = append(refs, cfg.SubnetIDs...)
+	}
+	v.SubnetRefs = strings.Join(refs, ", ")
+
+	if v.CreateSecurityGroup {
+		rules, err := parseIngressRules(cfg.IngressRulesTCP)
+		if err != nil {
+			return ec2Stack{}, err
+		}
+		v.IngressRules = rules
+		v.SecurityGroupRefs = "!Ref SecurityGroup"
+	} else {
+		v.SecurityGroupRefs = strings.Join(cfg.SecurityGroupIDs, ", ")
+	}
+	return v, nil
+}
+
+// https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/aws-resource-ec2-launchtemplate.html
+// https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/aws-properties-as-group.html
+// https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/aws-properties-autoscaling-autoscalinggroup-mixedinstancespolicy.html
+const ec2StackTemplate = `---
+AWSTemplateFormatVersion: '2010-09-09'
+Description: {{ .Description }}
+
+Parameters:
+
+  ClusterSize:
+    Type: Number
+    Description: The number of EC2 instances in the Auto Scaling group
+    MinValue: 0
+
+Resources:
+{{ if .CreateVPC }}
+  VPC:
+    Type: AWS::EC2::VPC
+    Properties:
+      CidrBlock: {{ .VPCCIDR }}
+      EnableDnsSupport: true
+      EnableDnsHostnames: true
+      Tags:
+      - Key: Name
+        Value: {{ .ClusterName }}
+      - Key: HOSTNAME
+        Value: {{ .Hostname }}
+{{ if .EnableIPv6 }}
+  VPCIPv6CidrBlock:
+    Type: AWS::EC2::VPCCidrBlock
+    Properties:
+      VpcId: !Ref VPC
+      AmazonProvidedIpv6CidrBlock: true
+{{ end }}
+  InternetGateway:
+    Type: AWS::EC2::InternetGateway
+    Properties:
+      Tags:
+      - Key: Name
+        Value: {{ .ClusterName }}
+      - Key: HOSTNAME
+        Value: {{ .Hostname }}
+
+  VPCGatewayAttachment:
+    Type: AWS::EC2::VPCGatewayAttachment
+    Properties:
+      InternetGatewayId: !Ref InternetGateway
+      VpcId: !Ref VPC
+
+  RouteTable:
+    Type: AWS::EC2::RouteTable
+    Properties:
+      VpcId: !Ref VPC
+      Tags:
+      - Key: Name
+        Value: {{ .ClusterName }}
+      - Key: HOSTNAME
+        Value: {{ .Hostname }}
+
+  Route:
+    DependsOn: VPCGatewayAttachment
+    Type: AWS::EC2::Route
+    Properties:
+      RouteTableId: !Ref RouteTable
+      DestinationCidrBlock: 0.0.0.0/0
+      GatewayId: !Ref InternetGateway
+{{ if .EnableIPv6 }}
+  RouteIPv6:
+    DependsOn: VPCGatewayAttachment
+    Type: AWS::EC2::Route
+    Properties:
+      RouteTableId: !Ref RouteTable
+      DestinationIpv6CidrBlock: '::/0'
+      GatewayId: !Ref InternetGateway
+{{ end }}{{ range $i, $s := .Subnets }}
+  {{ .Name }}:
+{{- if $.EnableIPv6 }}
+    DependsOn: VPCIPv6CidrBlock
+{{- end }}
+    Type: AWS::EC2::Subnet
+    Properties:
+      VpcId: !Ref VPC
+      CidrBlock: {{ .CIDR }}
+      AvailabilityZone: !Select [ {{ $i }}, !GetAZs '' ]
+      MapPublicIpOnLaunch: {{ $.AssociatePublicIPAddress }}
+{{- if $.EnableIPv6 }}
+      Ipv6CidrBlock: !Select [ {{ $i }}, !Cidr [ !Select [ 0, !GetAtt VPC.Ipv6CidrBlocks ], {{ len $.Subnets }}, 64 ] ]
+      AssignIpv6AddressOnCreation: true
+{{- end }}
+      Tags:
+      - Key: Name
+        Value: {{ $.ClusterName }}-{{ .Name }}
+      - Key: HOSTNAME
+        Value: {{ $.Hostname }}
+
+  {{ .Name }}RouteTableAssociation:
+    Type: AWS::EC2::SubnetRouteTableAssociation
+    Properties:
+      SubnetId: !Ref {{ .Name }}
+      RouteTableId: !Ref RouteTable
+{{ end }}{{ end }}{{ if .CreateSecurityGroup }}
+  SecurityGroup:
+    Type: AWS::EC2::SecurityGroup
+    Properties:
+      GroupName: {{ .ClusterName }}
+      GroupDescription: {{ .ClusterName }}
+      VpcId: {{ if .CreateVPC }}!Ref VPC{{ else }}{{ .VPCID }}{{ end }}
+{{- if .IngressRules }}
+      SecurityGroupIngress:
+{{- range .IngressRules }}
+      - IpProtocol: tcp
+        FromPort: {{ .FromPort }}
+        ToPort: {{ .ToPort }}
+        {{ if .IPv6 }}CidrIpv6{{ else }}CidrIp{{ end }}: '{{ .CIDR }}'
+{{- end }}
+{{- end }}
+      Tags:
+      - Key: Name
+        Value: {{ .ClusterName }}
+      - Key: HOSTNAME
+        Value: {{ .Hostname }}
+{{ end }}
+  LaunchTemplate:
+    Type: AWS::EC2::LaunchTemplate
+    Properties:
+      LaunchTemplateName: {{ .ClusterName }}
+      LaunchTemplateData:
+        ImageId: {{ .ImageID }}
+        InstanceType: {{ .InstanceType }}
+        KeyName: {{ .KeyName }}
+{{- if .InstanceProfileName }}
+        IamInstanceProfile:
+          Name: {{ .InstanceProfileName }}
+{{- end }}
+        UserData: {{ .UserData }}
+        NetworkInterfaces:
+        - DeviceIndex: 0
+          AssociatePublicIpAddress: {{ .AssociatePublicIPAddress }}
+          DeleteOnTermination: true
+{{- if .EnableIPv6 }}
+          Ipv6AddressCount: 1
+{{- end }}
+          Groups: [ {{ .SecurityGroupRefs }} ]
+        TagSpecifications:
+        - ResourceType: instance
+          Tags:
+          - Key: Name
+            Value: {{ .ClusterName }}
+          - Key: kubernetes.io/cluster/{{ .ClusterName }}
+            Value: owned
+{{- range $k, $v := .Tags }}
+          - Key: {{ printf "%q" $k }}
+            Value: {{ printf "%q" $v }}
+{{- end }}
+
+  AutoScalingGroup:
+{{- if .CreateVPC }}
+    DependsOn: Route
+{{- end }}
+    Type: AWS::AutoScaling::AutoScalingGroup
+    Properties:
+      AutoScalingGroupName: {{ .ClusterName }}
+{{- if .Spot }}
+      MixedInstancesPolicy:
+        InstancesDistribution:
+          OnDemandAllocationStrategy: prioritized
+          OnDemandBaseCapacity: {{ .Spot.OnDemandBaseCapacity }}
+          OnDemandPercentageAboveBaseCapacity: {{ .Spot.OnDemandPercentageAboveBaseCapacity }}
+          SpotAllocationStrategy: lowest-price
+          SpotInstancePools: {{ .Spot.InstancePools }}
+{{- if .Spot.MaxPrice }}
+          SpotMaxPrice: '{{ .Spot.MaxPrice }}'
+{{- end }}
+        LaunchTemplate:
+          LaunchTemplateSpecification:
+            LaunchTemplateId: !Ref LaunchTemplate
+            Version: !GetAtt LaunchTemplate.LatestVersionNumber
+          Overrides:
+          - InstanceType: {{ .InstanceType }}
+{{- range .Spot.InstanceTypes }}
+          - InstanceType: {{ . }}
+{{- end }}
+{{- else }}
+      LaunchTemplate:
+        LaunchTemplateId: !Ref LaunchTemplate
+        Version: !GetAtt LaunchTemplate.LatestVersionNumber
+{{- end }}
+      MinSize: '0'
+      MaxSize: !Ref ClusterSize
+      DesiredCapacity: !Ref ClusterSize
+      VPCZoneIdentifier: [ {{ .SubnetRefs }} ]
+      Tags:
+      - Key: HOSTNAME
+        Value: {{ .Hostname }}
+        PropagateAtLaunch: false
+
+Outputs:
+
+  VpcId:
+    Value: {{ if .CreateVPC }}!Ref VPC{{ else }}{{ .VPCID }}{{ end }}
+{{ if and .CreateVPC .EnableIPv6 }}
+  VpcIpv6CidrBlock:
+    Value: !Select [ 0, !GetAtt VPC.Ipv6CidrBlocks ]
+{{ end }}{{ if .CreateVPC }}
+  InternetGatewayId:
+    Value: !Ref InternetGateway
+
+  RouteTableId:
+    Value: !Ref RouteTable
+{{ end }}
+  SubnetIds:
+    Value: !Join [ ",", [ {{ .SubnetRefs }} ] ]
+
+  SecurityGroupIds:
+    Value: !Join [ ",", [ {{ .SecurityGroupRefs }} ] ]
+
+  LaunchTemplateId:
+    Value: !Ref LaunchTemplate
+
+  AutoScalingGroupName:
+    Value: !Ref AutoScalingGroup
+
+`
@@ -0,0 +1,159 @@
+package ec2
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
+	"sigs.k8s.io/yaml"
+)
+
+func TestStackTemplate(t *testing.T) {
+	tests := []struct {
+		cfg       ec2config.Config
+		resources []string
+		outputs   []string
+	}{
+		{
+			cfg: ec2config.Config{
+				VPCCIDR:                  "192.168.0.0/16",
+				IngressRulesTCP:          map[string]string{"22": "0.0.0.0/0", "2379-2380": "::/0"},
+				AssociatePublicIPAddress: true,
+				EnableIPv6:               true,
+				InstanceProfileName:      "test-profile",
+				Tags:                     map[string]string{"kubernetes.io/cluster/test": "owned", "Name": "test", "team": "test"},
+			},
+			resources: []string{"VPC", "VPCIPv6CidrBlock", "RouteIPv6", "Subnet01", "Subnet02", "Subnet03", "SecurityGroup", "LaunchTemplate", "AutoScalingGroup"},
+			outputs:   []string{"VpcId", "VpcIpv6CidrBlock", "InternetGatewayId", "RouteTableId", "SubnetIds", "SecurityGroupIds", "LaunchTemplateId", "AutoScalingGroupName"},
+		},
+		{
+			cfg: ec2config.Config{
+				VPCID:            "vpc-1",
+				SubnetIDs:        []string{"subnet-1", "subnet-2"},
+				SecurityGroupIDs: []string{"sg-1"},
+				Spot: ec2config.Spot{
+					Enable:        true,
+					InstanceTypes: []string{"m5d.large", "c5.xlarge"},
+					InstancePools: 3,
+					MaxPrice:      "0.05",
+				},
+			},
+			resources: []string{"LaunchTemplate", "AutoScalingGroup"},
+			outputs:   []string{"VpcId", "SubnetIds", "SecurityGroupIds", "LaunchTemplateId", "AutoScalingGroupName"},
+		},
+	}
+	for i, tt := range tests {
+		cfg := tt.cfg
+		cfg.ClusterName = "test"
+		cfg.ImageID = "ami-1"
+		cfg.InstanceType = "m5.large"
+		cfg.KeyName = "test-key"
+		cfg.InitScript = "#!/usr/bin/env bash\necho hello\n"
+		v, err := newEC2Stack(&cfg, "hostname")
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		s, err := createStackTemplate(v)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+
+		var tmpl struct {
+			Parameters map[string]interface{} `json:"Parameters"`
+			Resources  map[string]struct {
+				Type       string                 `json:"Type"`
+				Properties map[string]interface{} `json:"Properties"`
+			} `json:"Resources"`
+			Outputs map[string]interface{} `json:"Outputs"`
+		}
+		if err = yaml.Unmarshal([]byte(s), &tmpl); err != nil {
+			t.Fatalf("#%d: %v\n%s", i, err, s)
+		}
+		if _, ok := tmpl.Parameters["ClusterSize"]; !ok {
+			t.Fatalf("#%d: expected ClusterSize parameter", i)
+		}
+		for _, k := range tt.resources {
+			if _, ok := tmpl.Resources[k]; !ok {
+				t.Fatalf("#%d: expected resource %q", i, k)
+			}
+		}
+		if len(tmpl.Resources) != len(tt.resources) && !v.CreateVPC {
+			t.Fatalf("#%d: expected resources %v, got %d", i, tt.resources, len(tmpl.Resources))
+		}
+		if len(tmpl.Outputs) != len(tt.outputs) {
+			t.Fatalf("#%d: expected outputs %v, got %v", i, tt.outputs, tmpl.Outputs)
+		}
+		for _, k := range tt.outputs {
+			if _, ok := tmpl.Outputs[k]; !ok {
+				t.Fatalf("#%d: expected output %q", i, k)
+			}
+		}
+
+		// every reference must resolve to a parameter, resource, or pseudo parameter
+		declared := map[string]struct{}{"ClusterSize": {}}
+		for k := range tmpl.Resources {
+			declared[k] = struct{}{}
+		}
+		for _, m := range refRegex.FindAllStringSubmatch(s, -1) {
+			if strings.HasPrefix(m[1], "AWS::") {
+				continue
+			}
+			if _, ok := declared[m[1]]; !ok {
+				t.Fatalf("#%d: undeclared reference %q", i, m[1])
+			}
+		}
+
+		if v.CreateSecurityGroup {
+			rules, _ := tmpl.Resources["SecurityGroup"].Properties["SecurityGroupIngress"].([]interface{})
+			if len(rules) != 2 {
+				t.Fatalf("#%d: expected 2 ingress rules, got %v", i, rules)
+			}
+			if cidr := rules[1].(map[string]interface{})["CidrIpv6"]; cidr != "::/0" {
+				t.Fatalf("#%d: expected IPv6 ingress rule, got %v", i, rules[1])
+			}
+		}
+		asg := tmpl.Resources["AutoScalingGroup"].Properties
+		mixed, _ := asg["MixedInstancesPolicy"].(map[string]interface{})
+		if (mixed != nil) != cfg.Spot.Enable {
+			t.Fatalf("#%d: expected mixed instances policy %v, got %v", i, cfg.Spot.Enable, asg)
+		}
+		if _, ok := asg["LaunchTemplate"]; ok == cfg.Spot.Enable {
+			t.Fatalf("#%d: launch template must be in mixed instances policy, got %v", i, asg)
+		}
+		if mixed != nil {
+			dist := mixed["InstancesDistribution"].(map[string]interface{})
+			if dist["SpotInstancePools"] != float64(3) || dist["SpotMaxPrice"] != "0.05" {
+				t.Fatalf("#%d: unexpected instances distribution %v", i, dist)
+			}
+			var types []string
+			for _, o := range mixed["LaunchTemplate"].(map[string]interface{})["Overrides"].([]interface{}) {
+				types = append(types, o.(map[string]interface{})["InstanceType"].(string))
+			}
+			if exp := []string{"m5.large", "m5d.large", "c5.xlarge"}; !reflect.DeepEqual(types, exp) {
+				t.Fatalf("#%d: expected instance types %v, got %v", i, exp, types)
+			}
+		}
+		for k := range cfg.Tags {
+			if k != "team" {
+				if _, ok := v.Tags[k]; ok {
+					t.Fatalf("#%d: expected built-in tag %q to be skipped, got %v", i, k, v.Tags)
+				}
+			}
+		}
+		if n := strings.Count(s, "kubernetes.io/cluster/test"); n != 1 {
+			t.Fatalf("#%d: expected 1 cluster tag, got %d\n%s", i, n, s)
+		}
+		if !strings.Contains(s, "UserData: "+v.UserData+"\n") {
+			t.Fatalf("#%d: expected base64-encoded user data", i)
+		}
+	}
+
+	cfg := ec2config.Config{VPCID: "vpc-1"}
+	if _, err := newEC2Stack(&cfg, "hostname"); err == nil {
+		t.Fatal("expected error for existing VPC without subnets")
+	}
+}
+
+var refRegex = regexp.MustCompile(`!(?:Ref|GetAtt) ([A-Za-z0-9:]+)`)
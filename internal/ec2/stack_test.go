@@ -0,0 +1,74 @@
+package ec2
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestStackEvents(t *testing.T) {
+	now := time.Now().UTC()
+	newEvent := func(id, resource, typ, status, reason string) *cloudformation.StackEvent {
+		return &cloudformation.StackEvent{
+			EventId:              aws.String(id),
+			Timestamp:            aws.Time(now),
+			LogicalResourceId:    aws.String(resource),
+			ResourceType:         aws.String(typ),
+			ResourceStatus:       aws.String(status),
+			ResourceStatusReason: aws.String(reason),
+		}
+	}
+
+	events := mergeStackEvents(nil, []*cloudformation.StackEvent{
+		newEvent("2", "VPC", "AWS::EC2::VPC", "CREATE_IN_PROGRESS", ""),
+		newEvent("1", "test-stack", "AWS::CloudFormation::Stack", "CREATE_IN_PROGRESS", "User Initiated"),
+	})
+	events = mergeStackEvents(events, []*cloudformation.StackEvent{
+		newEvent("6", "test-stack", "AWS::CloudFormation::Stack", "ROLLBACK_IN_PROGRESS", "The following resource(s) failed to create: [SecurityGroup, VPC]."),
+		newEvent("5", "SecurityGroup", "AWS::EC2::SecurityGroup", "CREATE_FAILED", "Resource creation cancelled"),
+		newEvent("4", "VPC", "AWS::EC2::VPC", "CREATE_FAILED", "The maximum number of VPCs has been reached."),
+		newEvent("3", "SecurityGroup", "AWS::EC2::SecurityGroup", "CREATE_IN_PROGRESS", ""),
+	})
+	var ids []string
+	for _, ev := range events {
+		ids = append(ids, ev.EventID)
+	}
+	if !reflect.DeepEqual(ids, []string{"1", "2", "3", "4", "5", "6"}) {
+		t.Fatalf("expected events in chronological order, got %v", ids)
+	}
+
+	if reason := lastStackFailure(events); reason != "VPC: The maximum number of VPCs has been reached." {
+		t.Fatalf("unexpected failure reason %q", reason)
+	}
+	events = append(events, ec2config.CFStackEvent{
+		LogicalResourceID: "test-stack",
+		ResourceType:      "AWS::CloudFormation::Stack",
+		ResourceStatus:    "DELETE_IN_PROGRESS",
+	})
+	if reason := lastStackFailure(events); reason != "unknown reason" {
+		t.Fatalf("unexpected failure reason %q", reason)
+	}
+
+	for status, failed := range map[string]bool{
+		"CREATE_COMPLETE":             false,
+		"UPDATE_IN_PROGRESS":          false,
+		"CREATE_FAILED":               true,
+		"ROLLBACK_COMPLETE":           true,
+		"UPDATE_ROLLBACK_COMPLETE":    true,
+		"DELETE_FAILED":               true,
+		"UPDATE_ROLLBACK_FAILED":      true,
+		"DELETE_COMPLETE":             false,
+		"REVIEW_IN_PROGRESS":          false,
+		"UPDATE_COMPLETE":             false,
+		"ROLLBACK_IN_PROGRESS":        true,
+		"UPDATE_ROLLBACK_IN_PROGRESS": true,
+	} {
+		if isStackFailed(status) != failed {
+			t.Fatalf("%q expected failed %v", status, failed)
+		}
+	}
+}
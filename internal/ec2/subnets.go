@@ -1,8 +1,10 @@
 package ec2
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"net"
 	"sort"
 	"time"
 
@@ -29,15 +31,28 @@ func (md *embedded) createSubnets() (err error) {
 		return fmt.Errorf("subnets already exist (%q)", md.cfg.SubnetIDs)
 	}
 
+	cidrs := []string{subnetCIDR1, subnetCIDR2, subnetCIDR3}
+	var ipv6CIDRs []string
+	if md.cfg.EnableIPv6 {
+		ipv6CIDRs, err = splitIPv6CIDR(md.cfg.VPCIPv6CIDR, len(cidrs))
+		if err != nil {
+			return err
+		}
+	}
+
 	md.cfg.SubnetIDs = make([]string, 0, 3)
 	md.cfg.SubnetIDToAvailabilityZone = make(map[string]string, 3)
-	for i, cidr := range []string{subnetCIDR1, subnetCIDR2, subnetCIDR3} {
-		var output *ec2.CreateSubnetOutput
-		output, err = md.ec2.CreateSubnet(&ec2.CreateSubnetInput{
+	for i, cidr := range cidrs {
+		input := &ec2.CreateSubnetInput{
 			VpcId:            aws.String(md.cfg.VPCID),
 			CidrBlock:        aws.String(cidr),
 			AvailabilityZone: aws.String(fmt.Sprintf("%s%s", md.cfg.AWSRegion, zoneSfx[i%len(zoneSfx)])),
-		})
+		}
+		if md.cfg.EnableIPv6 {
+			input.Ipv6CidrBlock = aws.String(ipv6CIDRs[i])
+		}
+		var output *ec2.CreateSubnetOutput
+		output, err = md.ec2.CreateSubnet(input)
 		if err != nil {
 			return err
 		}
@@ -60,6 +75,11 @@ func (md *embedded) createSubnets() (err error) {
 			return err
 		}
 	}
+	if md.cfg.EnableIPv6 {
+		if err = md.assignIPv6(); err != nil {
+			return err
+		}
+	}
 	sort.Strings(md.cfg.SubnetIDs)
 	return md.cfg.Sync()
 }
@@ -155,6 +175,9 @@ func (md *embedded) getSubnets() (err error) {
 	md.cfg.SubnetIDs = make([]string, 0, len(output.Subnets))
 	md.cfg.SubnetIDToAvailabilityZone = make(map[string]string)
 	for _, sv := range output.Subnets {
+		if md.cfg.EnableIPv6 && !aws.BoolValue(sv.AssignIpv6AddressOnCreation) {
+			return fmt.Errorf("subnet %q does not assign IPv6 addresses on creation", *sv.SubnetId)
+		}
 		md.cfg.SubnetIDs = append(md.cfg.SubnetIDs, *sv.SubnetId)
 		md.cfg.SubnetIDToAvailabilityZone[*sv.SubnetId] = *sv.AvailabilityZone
 	}
@@ -182,3 +205,45 @@ func (md *embedded) associatePublicIP() (err error) {
 	}
 	return nil
 }
+
+func (md *embedded) assignIPv6() (err error) {
+	for _, id := range md.cfg.SubnetIDs {
+		_, err = md.ec2.ModifySubnetAttribute(&ec2.ModifySubnetAttributeInput{
+			AssignIpv6AddressOnCreation: &ec2.AttributeBooleanValue{
+				Value: aws.Bool(true),
+			},
+			SubnetId: aws.String(id),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to allow IPv6 assign for subnet %q (%v)", id, err)
+		}
+		md.lg.Debug("allowed IPv6 assign for subnet", zap.String("subnet-id", id))
+	}
+	return nil
+}
+
+// splitIPv6CIDR splits the VPC IPv6 CIDR block (e.g. /56) into
+// n subnet blocks of /64, the only size that AWS supports for subnets.
+func splitIPv6CIDR(cidr string, n int) ([]string, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	if ipNet.IP.To4() != nil {
+		return nil, fmt.Errorf("%q is not IPv6 CIDR", cidr)
+	}
+	ones, _ := ipNet.Mask.Size()
+	if ones > 64 || (64-ones < 63 && n > 1<<uint(64-ones)) {
+		return nil, fmt.Errorf("IPv6 CIDR %q is too small for %d subnets", cidr, n)
+	}
+
+	// the subnet index goes to the 8 bytes of network prefix
+	base := binary.BigEndian.Uint64(ipNet.IP[:8])
+	cidrs := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		ip := make(net.IP, net.IPv6len)
+		binary.BigEndian.PutUint64(ip[:8], base+uint64(i))
+		cidrs = append(cidrs, fmt.Sprintf("%s/64", ip))
+	}
+	return cidrs, nil
+}
@@ -54,3 +54,20 @@ func TestSubnets(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestSplitIPv6CIDR(t *testing.T) {
+	cidrs, err := splitIPv6CIDR("2600:1f14:e0e:7f00::/56", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{"2600:1f14:e0e:7f00::/64", "2600:1f14:e0e:7f01::/64", "2600:1f14:e0e:7f02::/64"}
+	if !reflect.DeepEqual(cidrs, exp) {
+		t.Fatalf("expected %v, got %v", exp, cidrs)
+	}
+	if _, err = splitIPv6CIDR("2600:1f14:e0e:7f00::/63", 3); err == nil {
+		t.Fatal("expected error for too small IPv6 CIDR")
+	}
+	if _, err = splitIPv6CIDR("192.168.0.0/16", 3); err == nil {
+		t.Fatal("expected error for IPv4 CIDR")
+	}
+}
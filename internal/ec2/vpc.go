@@ -15,7 +15,8 @@ import (
 func (md *embedded) createVPC() (err error) {
 	var output *ec2.CreateVpcOutput
 	output, err = md.ec2.CreateVpc(&ec2.CreateVpcInput{
-		CidrBlock: aws.String(md.cfg.VPCCIDR),
+		CidrBlock:                   aws.String(md.cfg.VPCCIDR),
+		AmazonProvidedIpv6CidrBlock: aws.Bool(md.cfg.EnableIPv6),
 	})
 	if err != nil {
 		return err
@@ -53,6 +54,11 @@ func (md *embedded) createVPC() (err error) {
 	if err = md.enableDNSHostnames(); err != nil {
 		return err
 	}
+	if md.cfg.EnableIPv6 {
+		if err = md.waitVPCIPv6CIDR(); err != nil {
+			return err
+		}
+	}
 
 	var op *ec2.CreateInternetGatewayOutput
 	op, err = md.ec2.CreateInternetGateway(&ec2.CreateInternetGatewayInput{})
@@ -99,6 +105,16 @@ func (md *embedded) createVPC() (err error) {
 		if err != nil {
 			return err
 		}
+		if md.cfg.EnableIPv6 {
+			_, err = md.ec2.CreateRoute(&ec2.CreateRouteInput{
+				RouteTableId:             tb.RouteTableId,
+				DestinationIpv6CidrBlock: aws.String("::/0"),
+				GatewayId:                aws.String(md.cfg.InternetGatewayID),
+			})
+			if err != nil {
+				return err
+			}
+		}
 		md.lg.Info(
 			"created a route for route table",
 			zap.String("vpc-id", md.cfg.VPCID),
@@ -108,6 +124,41 @@ func (md *embedded) createVPC() (err error) {
 	return md.cfg.Sync()
 }
 
+// waitVPCIPv6CIDR waits until the Amazon-provided IPv6 CIDR block
+// is associated with the VPC, since subnets cannot use it before then.
+func (md *embedded) waitVPCIPv6CIDR() error {
+	for i := 0; i < 30; i++ {
+		output, err := md.ec2.DescribeVpcs(&ec2.DescribeVpcsInput{
+			VpcIds: aws.StringSlice([]string{md.cfg.VPCID}),
+		})
+		if err != nil {
+			return err
+		}
+		if len(output.Vpcs) != 1 {
+			return fmt.Errorf("expected 1 VPC %q, got %d", md.cfg.VPCID, len(output.Vpcs))
+		}
+		for _, assoc := range output.Vpcs[0].Ipv6CidrBlockAssociationSet {
+			if assoc.Ipv6CidrBlockState == nil {
+				continue
+			}
+			switch aws.StringValue(assoc.Ipv6CidrBlockState.State) {
+			case "associated":
+				md.cfg.VPCIPv6CIDR = aws.StringValue(assoc.Ipv6CidrBlock)
+				md.lg.Info(
+					"associated IPv6 CIDR block with VPC",
+					zap.String("vpc-id", md.cfg.VPCID),
+					zap.String("vpc-ipv6-cidr", md.cfg.VPCIPv6CIDR),
+				)
+				return md.cfg.Sync()
+			case "failed":
+				return fmt.Errorf("failed to associate IPv6 CIDR block with VPC %q (%s)", md.cfg.VPCID, aws.StringValue(assoc.Ipv6CidrBlockState.StatusMessage))
+			}
+		}
+		time.Sleep(2 * time.Second)
+	}
+	return fmt.Errorf("IPv6 CIDR block is not associated with VPC %q", md.cfg.VPCID)
+}
+
 func (md *embedded) enableDNSHostnames() (err error) {
 	_, err = md.ec2.ModifyVpcAttribute(&ec2.ModifyVpcAttributeInput{
 		EnableDnsHostnames: &ec2.AttributeBooleanValue{Value: aws.Bool(true)},
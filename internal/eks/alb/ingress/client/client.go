@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -32,6 +35,10 @@ type Client struct {
 	RequestsN int64
 	requestsN *atomic.Int64
 
+	// Schedule is the target request rate for open-loop mode.
+	// If nil, clients run in closed loop until "RequestsN" is exhausted.
+	Schedule *Schedule
+
 	stopc chan struct{}
 }
 
@@ -40,6 +47,10 @@ func New(lg *zap.Logger, ep string, routesN int, clientsN int, requestsN int) (c
 	if routesN == 0 {
 		return nil, errors.New("no routes found")
 	}
+	ep, err = endpointURL(ep)
+	if err != nil {
+		return nil, err
+	}
 	lg.Info("creating client",
 		zap.String("endpoint", ep),
 		zap.Int("routes", routesN),
@@ -62,9 +73,49 @@ func New(lg *zap.Logger, ep string, routesN int, clientsN int, requestsN int) (c
 	}, nil
 }
 
+// endpointURL returns the HTTP URL of the endpoint, which is either a URL
+// or "host[:port]". IPv6 hosts are enclosed in square brackets.
+func endpointURL(ep string) (string, error) {
+	if !strings.Contains(ep, "://") {
+		host, port, err := net.SplitHostPort(ep)
+		if err != nil {
+			// no port, or bare IPv6 address
+			host, port = strings.Trim(ep, "[]"), "80"
+		}
+		if host == "" {
+			return "", fmt.Errorf("no host found in endpoint %q", ep)
+		}
+		ep = "http://" + net.JoinHostPort(host, port)
+	}
+	u, err := url.Parse(ep)
+	if err != nil {
+		return "", err
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("no host found in endpoint %q", ep)
+	}
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+// NewOpenLoop creates the client configuration that issues requests
+// at the scheduled rate, with up to "clientsN" requests in flight.
+func NewOpenLoop(lg *zap.Logger, ep string, routesN int, clientsN int, sc Schedule) (cli *Client, err error) {
+	if err = sc.Validate(); err != nil {
+		return nil, err
+	}
+	cli, err = New(lg, ep, routesN, clientsN, 0)
+	if err != nil {
+		return nil, err
+	}
+	cli.Schedule = &sc
+	lg.Info("configured open-loop schedule", zap.String("schedule", sc.String()))
+	return cli, nil
+}
+
 // TestResult contains test results.
 type TestResult struct {
 	mu       *sync.RWMutex
+	Mode     string
 	Routes   int
 	Clients  int
 	Requests int64
@@ -99,6 +150,59 @@ func (cli *Client) Run() (testResult TestResult) {
 		Requests: cli.RequestsN,
 	}
 
+	if cli.Schedule != nil {
+		testResult.Mode = ModeOpenLoop
+		cli.runOpenLoop(&testResult)
+	} else {
+		testResult.Mode = ModeClosedLoop
+		cli.runClosedLoop(&testResult)
+	}
+
+	took := time.Now().UTC().Sub(now)
+	cli.lg.Info("finished client load tester",
+		zap.Int("clients", cli.ClientsN),
+		zap.Int64("requests", testResult.Requests),
+	)
+
+	// fetch metrics
+	rs, err := http.Get(ts.URL + p)
+	if err != nil {
+		cli.lg.Warn("metrics fetch failed", zap.Error(err))
+	}
+	d, err := ioutil.ReadAll(rs.Body)
+	if err != nil {
+		cli.lg.Warn("metrics fetch failed", zap.Error(err))
+	}
+	if err = rs.Body.Close(); err != nil {
+		cli.lg.Warn("metrics fetch failed", zap.Error(err))
+	}
+
+	r := toResult(string(d))
+	r.endpoint = cli.Endpoint
+	r.routesN = len(cli.Routes)
+	r.requestsN = cli.RequestsN
+	r.clientsN = cli.ClientsN
+
+	if testResult.Mode == ModeOpenLoop {
+		r.requestsN = testResult.Requests
+	}
+	if r.successN > 0 {
+		testResult.QPS = float64(r.successN) / took.Seconds()
+	}
+
+	testResult.Success = int64(r.successN)
+	testResult.Failure = int64(r.failureN)
+	testResult.Result = string(d) +
+		r.String() +
+		fmt.Sprintf("Mode: %s\n", testResult.Mode) +
+		fmt.Sprintf("Took: %v\n", took) +
+		fmt.Sprintf("QPS: %3.f successful requests per second\n", testResult.QPS) +
+		fmt.Sprintf("Error count: %d\n", len(testResult.Errors))
+
+	return testResult
+}
+
+func (cli *Client) runClosedLoop(testResult *TestResult) {
 	cli.wg.Add(cli.ClientsN)
 	for i := 0; i < cli.ClientsN; i++ {
 		go func() {
@@ -118,90 +222,110 @@ func (cli *Client) Run() (testResult TestResult) {
 					cli.lg.Info("request progress", zap.Int64("left", left), zap.Int64("total", cli.RequestsN))
 				}
 
-				route := cli.ChooseRoute()
-				start := time.Now().UTC()
-
-				// request
-				rs, err := http.Get(cli.Endpoint + route)
-				if err != nil {
+				if err := cli.request(cli.ChooseRoute(), time.Now().UTC()); err != nil {
 					testResult.mu.Lock()
 					testResult.Errors = append(testResult.Errors, err)
 					testResult.mu.Unlock()
-					cli.lg.Warn("request failed", zap.Error(err))
-					promFailure.WithLabelValues(cli.Endpoint, route).Inc()
 					if cli.requestsN.Dec() <= 0 {
 						return
 					}
-					continue
 				}
-				if _, err = ioutil.ReadAll(rs.Body); err != nil {
-					testResult.mu.Lock()
-					testResult.Errors = append(testResult.Errors, err)
-					testResult.mu.Unlock()
-					cli.lg.Warn("request failed", zap.Error(err))
-					promFailure.WithLabelValues(cli.Endpoint, route).Inc()
-					if cli.requestsN.Dec() <= 0 {
-						return
-					}
-					continue
-				}
-				if err = rs.Body.Close(); err != nil {
+			}
+		}()
+	}
+	cli.wg.Wait()
+}
+
+// runOpenLoop dispatches requests at their intended send times,
+// independent of how long previous requests took. Requests that
+// cannot be sent on time (all clients busy) wait in the queue,
+// and the wait is included in their latency, so that slow responses
+// do not hide latency (coordinated omission).
+func (cli *Client) runOpenLoop(testResult *TestResult) {
+	sc := *cli.Schedule
+	cli.lg.Info("starting open-loop schedule", zap.String("schedule", sc.String()))
+
+	// buffer up to 1-second worth of requests at the highest rate
+	bufN := int(sc.StartQPS)
+	if int(sc.EndQPS) > bufN {
+		bufN = int(sc.EndQPS)
+	}
+	intendedc := make(chan time.Time, bufN+1)
+
+	cli.wg.Add(cli.ClientsN)
+	for i := 0; i < cli.ClientsN; i++ {
+		go func() {
+			defer cli.wg.Done()
+			for intended := range intendedc {
+				if err := cli.request(cli.ChooseRoute(), intended); err != nil {
 					testResult.mu.Lock()
 					testResult.Errors = append(testResult.Errors, err)
 					testResult.mu.Unlock()
-					cli.lg.Warn("request failed", zap.Error(err))
-					promFailure.WithLabelValues(cli.Endpoint, route).Inc()
-					if cli.requestsN.Dec() <= 0 {
-						return
-					}
-					continue
 				}
-
-				promLat.WithLabelValues(cli.Endpoint, route).Observe(time.Now().UTC().Sub(start).Seconds())
-				promSuccess.WithLabelValues(cli.Endpoint, route).Inc()
 			}
 		}()
 	}
+
+	start := time.Now().UTC()
+	end := start.Add(sc.Duration)
+	var sent int64
+	lastProgress := start
+dispatch:
+	for next := start; next.Before(end); next = sc.Next(start, next) {
+		if d := next.Sub(time.Now().UTC()); d > 0 {
+			select {
+			case <-cli.stopc:
+				break dispatch
+			case <-time.After(d):
+			}
+		}
+		select {
+		case <-cli.stopc:
+			break dispatch
+		case intendedc <- next:
+			sent++
+		}
+		if next.Sub(lastProgress) > 10*time.Second {
+			lastProgress = next
+			cli.lg.Info("request progress",
+				zap.Int64("sent", sent),
+				zap.Float64("target-qps", sc.Rate(next.Sub(start))),
+				zap.Duration("elapsed", next.Sub(start)),
+			)
+		}
+	}
+	close(intendedc)
 	cli.wg.Wait()
 
-	took := time.Now().UTC().Sub(now)
-	cli.lg.Info("finished client load tester",
-		zap.Int("clients", cli.ClientsN),
-		zap.Int64("requests", cli.RequestsN),
-	)
+	testResult.Requests = sent
+}
 
-	// fetch metrics
-	rs, err := http.Get(ts.URL + p)
+// request sends a GET request to the route, and records its latency
+// measured from "start".
+func (cli *Client) request(route string, start time.Time) (err error) {
+	defer func() {
+		if err != nil {
+			cli.lg.Warn("request failed", zap.Error(err))
+			promFailure.WithLabelValues(cli.Endpoint, route).Inc()
+		}
+	}()
+
+	var rs *http.Response
+	rs, err = http.Get(cli.Endpoint + route)
 	if err != nil {
-		cli.lg.Warn("metrics fetch failed", zap.Error(err))
+		return err
 	}
-	d, err := ioutil.ReadAll(rs.Body)
-	if err != nil {
-		cli.lg.Warn("metrics fetch failed", zap.Error(err))
+	if _, err = ioutil.ReadAll(rs.Body); err != nil {
+		rs.Body.Close()
+		return err
 	}
 	if err = rs.Body.Close(); err != nil {
-		cli.lg.Warn("metrics fetch failed", zap.Error(err))
+		return err
 	}
 
-	r := toResult(string(d))
-	r.endpoint = cli.Endpoint
-	r.routesN = len(cli.Routes)
-	r.requestsN = cli.RequestsN
-	r.clientsN = cli.ClientsN
-
-	if r.successN > 0 {
-		testResult.QPS = float64(r.successN) / took.Seconds()
-	}
-
-	testResult.Success = int64(r.successN)
-	testResult.Failure = int64(r.failureN)
-	testResult.Result = string(d) +
-		r.String() +
-		fmt.Sprintf("Took: %v\n", took) +
-		fmt.Sprintf("QPS: %3.f successful requests per second\n", testResult.QPS) +
-		fmt.Sprintf("Error count: %d\n", len(testResult.Errors))
-
-	return testResult
+	promLat.WithLabelValues(cli.Endpoint, route).Observe(time.Now().UTC().Sub(start).Seconds())
+	promSuccess.WithLabelValues(cli.Endpoint, route).Inc()
+	return nil
 }
 
 // ChooseRoute chooses a random route.
@@ -0,0 +1,32 @@
+package client
+
+import "testing"
+
+func TestEndpointURL(t *testing.T) {
+	tt := []struct {
+		ep  string
+		exp string
+		ok  bool
+	}{
+		{"http://a.us-west-2.elb.amazonaws.com/ingress-test", "http://a.us-west-2.elb.amazonaws.com/ingress-test", true},
+		{"http://127.0.0.1:32030/", "http://127.0.0.1:32030", true},
+		{"http://[2600:1f14::1]:32030", "http://[2600:1f14::1]:32030", true},
+		{"10.0.0.1", "http://10.0.0.1:80", true},
+		{"10.0.0.1:32030", "http://10.0.0.1:32030", true},
+		{"2600:1f14::1", "http://[2600:1f14::1]:80", true},
+		{"[2600:1f14::1]", "http://[2600:1f14::1]:80", true},
+		{"[2600:1f14::1]:32030", "http://[2600:1f14::1]:32030", true},
+		{"ingress-test-server.default.svc.cluster.local:80", "http://ingress-test-server.default.svc.cluster.local:80", true},
+		{":32030", "", false},
+		{"http:///ingress-test", "", false},
+	}
+	for i, tv := range tt {
+		u, err := endpointURL(tv.ep)
+		if tv.ok != (err == nil) {
+			t.Fatalf("#%d: %q expected ok %v, got error %v", i, tv.ep, tv.ok, err)
+		}
+		if u != tv.exp {
+			t.Fatalf("#%d: %q expected %q, got %q", i, tv.ep, tv.exp, u)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// ModeClosedLoop runs a fixed number of concurrent clients,
+	// each sending the next request only after the previous one completes.
+	ModeClosedLoop = "closed-loop"
+	// ModeOpenLoop issues requests at a target rate regardless of
+	// response time, and measures latency from the intended send time.
+	ModeOpenLoop = "open-loop"
+)
+
+// Schedule defines the target request rate of open-loop load testing.
+// Constant rate when "StartQPS" equals "EndQPS".
+// Linear ramp from "StartQPS" to "EndQPS" when "Steps" is zero.
+// Otherwise, the rate increases in "Steps" equal-length steps.
+type Schedule struct {
+	// StartQPS is the target requests per second at the beginning.
+	StartQPS float64
+	// EndQPS is the target requests per second at the end.
+	// If zero, it is set to "StartQPS".
+	EndQPS float64
+	// Steps is the number of rate levels between "StartQPS" and "EndQPS".
+	// Must not be 1, unless "StartQPS" equals "EndQPS".
+	Steps int
+	// Duration is the total duration of the test.
+	Duration time.Duration
+}
+
+// Validate returns an error for invalid schedules,
+// and updates empty fields with default values.
+func (s *Schedule) Validate() error {
+	if s.StartQPS <= 0 {
+		return fmt.Errorf("invalid start QPS %f", s.StartQPS)
+	}
+	if s.EndQPS == 0 {
+		s.EndQPS = s.StartQPS
+	}
+	if s.EndQPS < 0 {
+		return fmt.Errorf("invalid end QPS %f", s.EndQPS)
+	}
+	if s.Steps < 0 {
+		return fmt.Errorf("invalid steps %d", s.Steps)
+	}
+	if s.Steps == 1 && s.EndQPS != s.StartQPS {
+		return fmt.Errorf("1 step cannot change rate from %.1f to %.1f QPS (need at least 2 steps)", s.StartQPS, s.EndQPS)
+	}
+	if s.Duration <= 0 {
+		return errors.New("empty schedule duration")
+	}
+	return nil
+}
+
+// Rate returns the target requests per second at the elapsed time.
+func (s Schedule) Rate(elapsed time.Duration) float64 {
+	frac := float64(elapsed) / float64(s.Duration)
+	if frac < 0 {
+		frac = 0
+	}
+	if frac > 1 {
+		frac = 1
+	}
+	if s.Steps > 0 {
+		if s.Steps == 1 {
+			return s.StartQPS
+		}
+		idx := int(frac * float64(s.Steps))
+		if idx >= s.Steps {
+			idx = s.Steps - 1
+		}
+		frac = float64(idx) / float64(s.Steps-1)
+	}
+	return s.StartQPS + (s.EndQPS-s.StartQPS)*frac
+}
+
+// Next returns the intended send time of the request after "prev",
+// where "start" is the beginning of the schedule.
+func (s Schedule) Next(start, prev time.Time) time.Time {
+	qps := s.Rate(prev.Sub(start))
+	if qps <= 0 {
+		return start.Add(s.Duration)
+	}
+	return prev.Add(time.Duration(float64(time.Second) / qps))
+}
+
+func (s Schedule) String() string {
+	switch {
+	case s.StartQPS == s.EndQPS:
+		return fmt.Sprintf("constant %.1f QPS for %v", s.StartQPS, s.Duration)
+	case s.Steps > 0:
+		return fmt.Sprintf("%d steps from %.1f to %.1f QPS for %v", s.Steps, s.StartQPS, s.EndQPS, s.Duration)
+	}
+	return fmt.Sprintf("ramp from %.1f to %.1f QPS for %v", s.StartQPS, s.EndQPS, s.Duration)
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	tests := []struct {
+		sc      Schedule
+		elapsed time.Duration
+		qps     float64
+	}{
+		{Schedule{StartQPS: 100, EndQPS: 100, Duration: time.Minute}, 30 * time.Second, 100},
+		{Schedule{StartQPS: 100, EndQPS: 200, Duration: time.Minute}, 0, 100},
+		{Schedule{StartQPS: 100, EndQPS: 200, Duration: time.Minute}, 30 * time.Second, 150},
+		{Schedule{StartQPS: 100, EndQPS: 200, Duration: time.Minute}, 2 * time.Minute, 200},
+		{Schedule{StartQPS: 100, EndQPS: 300, Steps: 3, Duration: time.Minute}, 10 * time.Second, 100},
+		{Schedule{StartQPS: 100, EndQPS: 300, Steps: 3, Duration: time.Minute}, 30 * time.Second, 200},
+		{Schedule{StartQPS: 100, EndQPS: 300, Steps: 3, Duration: time.Minute}, 50 * time.Second, 300},
+		{Schedule{StartQPS: 100, EndQPS: 300, Steps: 3, Duration: time.Minute}, time.Minute, 300},
+	}
+	for i, tt := range tests {
+		if qps := tt.sc.Rate(tt.elapsed); qps != tt.qps {
+			t.Fatalf("#%d: expected %f, got %f", i, tt.qps, qps)
+		}
+	}
+
+	sc := Schedule{StartQPS: 10, Duration: time.Second}
+	if err := sc.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if sc.EndQPS != 10 {
+		t.Fatalf("expected end QPS 10, got %f", sc.EndQPS)
+	}
+	start := time.Now()
+	if next := sc.Next(start, start); next.Sub(start) != 100*time.Millisecond {
+		t.Fatalf("expected 100ms interval, got %v", next.Sub(start))
+	}
+	if err := (&Schedule{Duration: time.Second}).Validate(); err == nil {
+		t.Fatal("expected error")
+	}
+	if err := (&Schedule{StartQPS: 100, EndQPS: 300, Steps: 1, Duration: time.Second}).Validate(); err == nil {
+		t.Fatal("expected error for 1 step from 100 to 300 QPS")
+	}
+	if err := (&Schedule{StartQPS: 100, Steps: 1, Duration: time.Second}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-k8s-tester/internal/eks/alb/ingress/client"
 	"github.com/aws/aws-k8s-tester/internal/eks/alb/ingress/server"
@@ -36,3 +37,37 @@ func TestIngress(t *testing.T) {
 		t.Fatalf("unexpected %d errors", o.Errors)
 	}
 }
+
+func TestIngressOpenLoop(t *testing.T) {
+	routesN := 3
+
+	// start server
+	mux, err := server.NewMux(context.Background(), zap.NewExample(), routesN, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	// send loads from client to server at a fixed rate
+	cli, err := client.NewOpenLoop(zap.NewExample(), ts.URL, routesN, 10, client.Schedule{
+		StartQPS: 100,
+		Duration: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := cli.Run()
+	fmt.Printf("%+v\n", o)
+
+	if len(o.Errors) != 0 {
+		t.Fatalf("unexpected %d errors", o.Errors)
+	}
+	if o.Mode != client.ModeOpenLoop {
+		t.Fatalf("expected mode %q, got %q", client.ModeOpenLoop, o.Mode)
+	}
+	if o.Requests < 90 || o.Requests > 110 {
+		t.Fatalf("expected about 100 requests, got %d", o.Requests)
+	}
+}
@@ -0,0 +1,139 @@
+package ingress
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// ConfigJobLoadGenerator defines load generator job configuration.
+// Each pod runs "aws-k8s-tester wrk" against the endpoint,
+// and prints its raw output to stdout for the coordinator to collect.
+type ConfigJobLoadGenerator struct {
+	// Name is used for metadata name and for pod selector.
+	Name string
+	// Namespace is the name space to deploy load generators to.
+	Namespace string
+	// Image is the aws-k8s-tester docker image.
+	Image string
+	// Workers is the number of load generator pods to run in parallel.
+	Workers int
+
+	// Endpoint is the endpoint to send loads to.
+	Endpoint string
+	// Threads is the number of "wrk" threads per worker.
+	Threads int
+	// Connections is the number of "wrk" connections per worker.
+	Connections int
+	// Minutes is the number of minutes to send loads.
+	Minutes int
+	// StartAt is the time for all workers to start sending loads.
+	StartAt time.Time
+}
+
+// CreateJobLoadGenerator generates a job that runs load generator pods.
+func CreateJobLoadGenerator(cfg ConfigJobLoadGenerator) (string, error) {
+	if cfg.Name == "" {
+		return "", errors.New("empty Name")
+	}
+	if cfg.Namespace == "" {
+		return "", errors.New("empty Namespace")
+	}
+	if cfg.Image == "" {
+		return "", errors.New("empty Image")
+	}
+	if cfg.Workers == 0 {
+		return "", errors.New("zero Workers")
+	}
+	if cfg.Endpoint == "" {
+		return "", errors.New("empty Endpoint")
+	}
+	if cfg.Minutes == 0 {
+		return "", errors.New("zero Minutes")
+	}
+	if cfg.StartAt.IsZero() {
+		return "", errors.New("empty StartAt")
+	}
+
+	jb := batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "batch/v1",
+			Kind:       "Job",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cfg.Name,
+			Namespace: cfg.Namespace,
+			Labels: map[string]string{
+				"app": cfg.Name,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			Parallelism:  newInt32(cfg.Workers),
+			Completions:  newInt32(cfg.Workers),
+			BackoffLimit: newInt32(0),
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": cfg.Name,
+					},
+				},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					// spread load generators across nodes
+					Affinity: &v1.Affinity{
+						PodAntiAffinity: &v1.PodAntiAffinity{
+							PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{
+								{
+									Weight: 100,
+									PodAffinityTerm: v1.PodAffinityTerm{
+										LabelSelector: &metav1.LabelSelector{
+											MatchLabels: map[string]string{
+												"app": cfg.Name,
+											},
+										},
+										TopologyKey: "kubernetes.io/hostname",
+									},
+								},
+							},
+						},
+					},
+					Containers: []v1.Container{
+						{
+							Name:            cfg.Name,
+							Image:           cfg.Image,
+							ImagePullPolicy: v1.PullAlways,
+							Args: []string{
+								"aws-k8s-tester",
+								"wrk",
+								"--endpoint=" + cfg.Endpoint,
+								fmt.Sprintf("--threads=%d", cfg.Threads),
+								fmt.Sprintf("--connections=%d", cfg.Connections),
+								fmt.Sprintf("--minutes=%d", cfg.Minutes),
+								"--start-at=" + cfg.StartAt.UTC().Format(time.RFC3339),
+								"--output=/tmp/wrk.txt",
+								"--output-csv=false",
+								"--output-stdout=true",
+								"--output-s3-upload=false",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	d, err := yaml.Marshal(jb)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`---
+%s
+
+
+`, string(d)), nil
+}
@@ -0,0 +1,39 @@
+package ingress
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateJobLoadGenerator(t *testing.T) {
+	cfg := ConfigJobLoadGenerator{
+		Name:        "ingress-load-generator",
+		Namespace:   "default",
+		Image:       "607362164682.dkr.ecr.us-west-2.amazonaws.com/aws-k8s-tester",
+		Workers:     5,
+		Endpoint:    "http://test.us-west-2.elb.amazonaws.com",
+		Threads:     2,
+		Connections: 200,
+		Minutes:     3,
+		StartAt:     time.Date(2019, time.March, 1, 10, 30, 0, 0, time.UTC),
+	}
+	d, err := CreateJobLoadGenerator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(d, "parallelism: 5") {
+		t.Fatalf("expected 'parallelism: 5', got %q", d)
+	}
+	if !strings.Contains(d, "--start-at=2019-03-01T10:30:00Z") {
+		t.Fatalf("expected '--start-at=2019-03-01T10:30:00Z', got %q", d)
+	}
+	if !strings.Contains(d, "--output-stdout=true") {
+		t.Fatalf("expected '--output-stdout=true', got %q", d)
+	}
+	if !strings.Contains(d, "--output-s3-upload=false") {
+		t.Fatalf("expected '--output-s3-upload=false', got %q", d)
+	}
+	fmt.Println(d)
+}
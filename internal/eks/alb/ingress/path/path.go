@@ -7,6 +7,8 @@ const (
 	Path = "/ingress-test"
 	// PathMetrics serves ELB ingress workload metrics.
 	PathMetrics = "/ingress-test-metrics"
+	// PathWebSocket is the path to WebSocket echo server.
+	PathWebSocket = "/ingress-test-websocket"
 )
 
 // Create creates a path with index.
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Query parameters to program server behaviors per request.
+// Route specs use the same syntax (e.g. "delay=100ms&delay-distribution=exponential").
+const (
+	QueryStatusCode        = "status-code"
+	QueryErrorRate         = "error-rate"
+	QueryErrorStatusCode   = "error-status-code"
+	QueryDelay             = "delay"
+	QueryDelayDistribution = "delay-distribution"
+	QueryResponseSize      = "response-size"
+	QueryChunks            = "chunks"
+	QueryChunkInterval     = "chunk-interval"
+	QueryCloseConnection   = "close-connection"
+)
+
+// Delay distributions.
+const (
+	// DelayFixed always delays by "Delay".
+	DelayFixed = "fixed"
+	// DelayUniform delays uniformly in [0, 2*Delay).
+	DelayUniform = "uniform"
+	// DelayExponential delays exponentially with mean "Delay".
+	DelayExponential = "exponential"
+)
+
+const (
+	maxDelay         = 5 * time.Minute
+	maxResponseSize  = 1024 * 1024 // 1 MB
+	maxChunks        = 10000
+	maxChunkInterval = time.Minute
+)
+
+// Behavior defines the programmable behaviors of a route.
+type Behavior struct {
+	// StatusCode is the response status code, in [200, 599].
+	// If zero, it is set to 200.
+	StatusCode int
+	// ErrorRate is the fraction of requests, in [0, 1], to fail with "ErrorStatusCode".
+	ErrorRate float64
+	// ErrorStatusCode is the status code of injected errors, in [200, 599].
+	// If zero, it is set to 500.
+	ErrorStatusCode int
+
+	// Delay is the injected latency before sending response headers.
+	Delay time.Duration
+	// DelayDistribution is the distribution of injected latency.
+	// One of "fixed", "uniform", or "exponential".
+	// If empty, it is set to "fixed".
+	DelayDistribution string
+
+	// ResponseSize is the response body size, up to 1 MB.
+	// If zero, the server default is used.
+	ResponseSize int
+	// Chunks is the number of chunks to flush the response body in,
+	// using chunked transfer encoding.
+	Chunks int
+	// ChunkInterval is the interval between chunks, to stream slow body.
+	ChunkInterval time.Duration
+
+	// CloseConnection is true to close the connection after the response,
+	// instead of keeping it alive.
+	CloseConnection bool
+}
+
+// ParseBehavior overrides base behaviors with query parameters,
+// and validates the result.
+func ParseBehavior(base Behavior, q url.Values) (bh Behavior, err error) {
+	bh = base
+	if v := q.Get(QueryStatusCode); v != "" {
+		if bh.StatusCode, err = strconv.Atoi(v); err != nil {
+			return Behavior{}, fmt.Errorf("invalid %q %q (%v)", QueryStatusCode, v, err)
+		}
+	}
+	if v := q.Get(QueryErrorRate); v != "" {
+		if bh.ErrorRate, err = strconv.ParseFloat(v, 64); err != nil {
+			return Behavior{}, fmt.Errorf("invalid %q %q (%v)", QueryErrorRate, v, err)
+		}
+	}
+	if v := q.Get(QueryErrorStatusCode); v != "" {
+		if bh.ErrorStatusCode, err = strconv.Atoi(v); err != nil {
+			return Behavior{}, fmt.Errorf("invalid %q %q (%v)", QueryErrorStatusCode, v, err)
+		}
+	}
+	if v := q.Get(QueryDelay); v != "" {
+		if bh.Delay, err = time.ParseDuration(v); err != nil {
+			return Behavior{}, fmt.Errorf("invalid %q %q (%v)", QueryDelay, v, err)
+		}
+	}
+	if v := q.Get(QueryDelayDistribution); v != "" {
+		bh.DelayDistribution = v
+	}
+	if v := q.Get(QueryResponseSize); v != "" {
+		if bh.ResponseSize, err = strconv.Atoi(v); err != nil {
+			return Behavior{}, fmt.Errorf("invalid %q %q (%v)", QueryResponseSize, v, err)
+		}
+	}
+	if v := q.Get(QueryChunks); v != "" {
+		if bh.Chunks, err = strconv.Atoi(v); err != nil {
+			return Behavior{}, fmt.Errorf("invalid %q %q (%v)", QueryChunks, v, err)
+		}
+	}
+	if v := q.Get(QueryChunkInterval); v != "" {
+		if bh.ChunkInterval, err = time.ParseDuration(v); err != nil {
+			return Behavior{}, fmt.Errorf("invalid %q %q (%v)", QueryChunkInterval, v, err)
+		}
+	}
+	if v := q.Get(QueryCloseConnection); v != "" {
+		if bh.CloseConnection, err = strconv.ParseBool(v); err != nil {
+			return Behavior{}, fmt.Errorf("invalid %q %q (%v)", QueryCloseConnection, v, err)
+		}
+	}
+	return bh, bh.validate()
+}
+
+func (bh *Behavior) validate() error {
+	if bh.StatusCode == 0 {
+		bh.StatusCode = 200
+	}
+	if bh.StatusCode < 200 || bh.StatusCode > 599 {
+		return fmt.Errorf("invalid status code %d", bh.StatusCode)
+	}
+	if bh.ErrorRate < 0 || bh.ErrorRate > 1 {
+		return fmt.Errorf("invalid error rate %f", bh.ErrorRate)
+	}
+	if bh.ErrorStatusCode == 0 {
+		bh.ErrorStatusCode = 500
+	}
+	if bh.ErrorStatusCode < 200 || bh.ErrorStatusCode > 599 {
+		return fmt.Errorf("invalid error status code %d", bh.ErrorStatusCode)
+	}
+	if bh.Delay < 0 || bh.Delay > maxDelay {
+		return fmt.Errorf("invalid delay %v (max %v)", bh.Delay, maxDelay)
+	}
+	switch bh.DelayDistribution {
+	case "":
+		bh.DelayDistribution = DelayFixed
+	case DelayFixed, DelayUniform, DelayExponential:
+	default:
+		return fmt.Errorf("unknown delay distribution %q", bh.DelayDistribution)
+	}
+	if bh.ResponseSize < 0 || bh.ResponseSize > maxResponseSize {
+		return fmt.Errorf("invalid response size %d (max %d)", bh.ResponseSize, maxResponseSize)
+	}
+	if bh.Chunks < 0 || bh.Chunks > maxChunks {
+		return fmt.Errorf("invalid chunks %d (max %d)", bh.Chunks, maxChunks)
+	}
+	if bh.ChunkInterval < 0 || bh.ChunkInterval > maxChunkInterval {
+		return fmt.Errorf("invalid chunk interval %v (max %v)", bh.ChunkInterval, maxChunkInterval)
+	}
+	return nil
+}
+
+// delay returns the latency to inject, sampled from the distribution.
+func (bh Behavior) delay() time.Duration {
+	if bh.Delay == 0 {
+		return 0
+	}
+	var d time.Duration
+	switch bh.DelayDistribution {
+	case DelayUniform:
+		d = time.Duration(rand.Int63n(int64(2 * bh.Delay)))
+	case DelayExponential:
+		d = time.Duration(rand.ExpFloat64() * float64(bh.Delay))
+	default:
+		d = bh.Delay
+	}
+	if d > maxDelay {
+		d = maxDelay
+	}
+	return d
+}
+
+// fail returns true if the request should fail with injected error.
+func (bh Behavior) fail() bool {
+	return bh.ErrorRate > 0 && rand.Float64() < bh.ErrorRate
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http2"
+)
+
+// http2Preface is the client connection preface remainder,
+// after the "PRI * HTTP/2.0" request line and headers are consumed.
+// https://http2.github.io/http2-spec/#rfc.section.3.5
+const http2Preface = "SM\r\n\r\n"
+
+// H2C wraps a handler to serve HTTP/2 over cleartext TCP with prior knowledge,
+// in addition to HTTP/1.1 (e.g. "curl --http2-prior-knowledge").
+func H2C(h http.Handler) http.Handler {
+	h2s := &http2.Server{}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "PRI" || req.RequestURI != "*" || req.Proto != "HTTP/2.0" {
+			h.ServeHTTP(w, req)
+			return
+		}
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "HTTP/2 Not Supported", http.StatusInternalServerError)
+			return
+		}
+		conn, rw, err := hj.Hijack()
+		if err != nil {
+			return
+		}
+		preface := make([]byte, len(http2Preface))
+		if _, err = io.ReadFull(rw, preface); err != nil || !bytes.Equal(preface, []byte(http2Preface)) {
+			conn.Close()
+			return
+		}
+		// HTTP/2 server expects the full client preface
+		rd := io.MultiReader(strings.NewReader(http2.ClientPreface), rw.Reader)
+		h2s.ServeConn(&bufferedConn{Conn: conn, rd: rd}, &http2.ServeConnOpts{Handler: h})
+	})
+}
+
+// bufferedConn reads from the reader instead of the connection,
+// since the hijacked reader may hold buffered client frames.
+type bufferedConn struct {
+	net.Conn
+	rd io.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) { return c.rd.Read(p) }
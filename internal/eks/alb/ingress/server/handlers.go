@@ -3,7 +3,12 @@ package server
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-k8s-tester/internal/eks/alb/ingress/path"
@@ -18,51 +23,188 @@ var responseBody = bytes.Repeat([]byte("0"), 10)
 
 // NewMux returns a new HTTP request multiplexer with registered handlers.
 func NewMux(ctx context.Context, lg *zap.Logger, routesN, responseN int) (*http.ServeMux, error) {
+	return NewMuxWithSpec(ctx, lg, routesN, responseN, nil)
+}
+
+// NewMuxWithSpec returns a new HTTP request multiplexer with registered handlers,
+// programmed by the route spec. The route spec maps a registered path to
+// its default behaviors, in query parameter syntax
+// (e.g. "/ingress-test-0000000" to "delay=100ms&error-rate=0.1").
+// Query parameters of each request override the route defaults.
+func NewMuxWithSpec(ctx context.Context, lg *zap.Logger, routesN, responseN int, spec map[string]string) (*http.ServeMux, error) {
 	responseBody = bytes.Repeat([]byte("0"), responseN)
 
+	routes := []string{path.Path}
+	for i := 0; i < routesN; i++ {
+		routes = append(routes, path.Create(i))
+	}
+	behaviors := make(map[string]Behavior, len(routes))
+	for _, p := range routes {
+		behaviors[p] = Behavior{}
+	}
+	for p, v := range spec {
+		if _, ok := behaviors[p]; !ok {
+			return nil, fmt.Errorf("route spec has unknown path %q", p)
+		}
+		q, err := url.ParseQuery(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route spec %q for %q (%v)", v, p, err)
+		}
+		behaviors[p], err = ParseBehavior(Behavior{}, q)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route spec %q for %q (%v)", v, p, err)
+		}
+		lg.Info("programmed route", zap.String("path", p), zap.String("spec", v))
+	}
+
 	mux := http.NewServeMux()
-	mux.Handle(path.Path, &ctxhandler.ContextAdapter{
+	mux.Handle(path.PathMetrics, promhttp.Handler())
+	mux.Handle(path.PathWebSocket, &ctxhandler.ContextAdapter{
 		Logger:  lg,
 		Ctx:     ctx,
-		Handler: ctxhandler.ContextHandlerFunc(Handler),
+		Handler: ctxhandler.ContextHandlerFunc(WebSocketHandler),
 	})
-	mux.Handle(path.PathMetrics, promhttp.Handler())
 
-	if routesN > 0 {
-		handlers := make(map[string]ctxhandler.ContextHandlerFunc, routesN)
-		for i := 0; i < routesN; i++ {
-			handlers[path.Create(i)] = Handler
-		}
-		for p, h := range handlers {
-			mux.Handle(p, &ctxhandler.ContextAdapter{
-				Logger:  lg,
-				Ctx:     ctx,
-				Handler: h,
-			})
+	sort.Strings(routes)
+	for _, p := range routes {
+		mux.Handle(p, &ctxhandler.ContextAdapter{
+			Logger:  lg,
+			Ctx:     ctx,
+			Handler: NewHandler(behaviors[p]),
+		})
+		if p != path.Path {
 			lg.Info("registered handler", zap.String("path", p))
 		}
+	}
+	if routesN > 0 {
 		lg.Info("finished handler registration", zap.Int("handlers", routesN))
 	}
 	return mux, nil
 }
 
-// Handler handles ingress traffic.
-func Handler(ctx context.Context, w http.ResponseWriter, req *http.Request) (err error) {
+// Handler handles ingress traffic with default behaviors.
+func Handler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	return serve(ctx, w, req, Behavior{})
+}
+
+// NewHandler returns a handler for ingress traffic with the default behaviors.
+func NewHandler(base Behavior) ctxhandler.ContextHandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+		return serve(ctx, w, req, base)
+	}
+}
+
+func serve(ctx context.Context, w http.ResponseWriter, req *http.Request, base Behavior) (err error) {
 	switch req.Method {
-	case http.MethodGet:
-		start := time.Now().UTC()
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut:
+	default:
+		http.Error(w, "Method Not Allowed", 405)
+		return nil
+	}
+
+	start := time.Now().UTC()
+
+	// From, Method, Path
+	promRecv.WithLabelValues(req.RemoteAddr, req.Method, req.RequestURI).Inc()
+
+	bh, err := ParseBehavior(base, req.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
 
-		// From, Method, Path
-		promRecv.WithLabelValues(req.RemoteAddr, req.Method, req.RequestURI).Inc()
+	if d := bh.delay(); d > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-req.Context().Done():
+			return req.Context().Err()
+		case <-time.After(d):
+		}
+	}
 
-		w.WriteHeader(http.StatusOK)
-		_, err = w.Write(responseBody)
+	if bh.CloseConnection {
+		w.Header().Set("Connection", "close")
+	}
 
-		// Path, To
-		promSent.WithLabelValues(req.RequestURI, req.RemoteAddr).Observe(time.Now().UTC().Sub(start).Seconds())
+	switch {
+	case bh.fail():
+		http.Error(w, http.StatusText(bh.ErrorStatusCode), bh.ErrorStatusCode)
+
+	case req.Method == http.MethodPost || req.Method == http.MethodPut:
+		err = echo(w, req, bh)
 
 	default:
-		http.Error(w, "Method Not Allowed", 405)
+		body := responseBody
+		if bh.ResponseSize > 0 {
+			body = bytes.Repeat([]byte("0"), bh.ResponseSize)
+		}
+		err = write(ctx, w, req, bh, body)
+	}
+
+	// Path, To
+	promSent.WithLabelValues(req.RequestURI, req.RemoteAddr).Observe(time.Now().UTC().Sub(start).Seconds())
+	return err
+}
+
+// echo streams the request body back, to test large uploads.
+func echo(w http.ResponseWriter, req *http.Request, bh Behavior) error {
+	if v := req.Header.Get("Content-Type"); v != "" {
+		w.Header().Set("Content-Type", v)
+	}
+	if req.ContentLength >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(req.ContentLength, 10))
 	}
+	w.WriteHeader(bh.StatusCode)
+	_, err := io.Copy(w, req.Body)
 	return err
 }
+
+// write writes the body at once, or in chunks with chunked transfer encoding.
+func write(ctx context.Context, w http.ResponseWriter, req *http.Request, bh Behavior, body []byte) error {
+	if bh.Chunks <= 1 && bh.ChunkInterval == 0 {
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(bh.StatusCode)
+		if req.Method == http.MethodHead {
+			return nil
+		}
+		_, err := w.Write(body)
+		return err
+	}
+
+	w.WriteHeader(bh.StatusCode)
+	if req.Method == http.MethodHead {
+		return nil
+	}
+	flusher, _ := w.(http.Flusher)
+	chunks := bh.Chunks
+	if chunks < 1 {
+		chunks = 1
+	}
+	size := (len(body) + chunks - 1) / chunks
+	for i := 0; i < chunks; i++ {
+		if i > 0 && bh.ChunkInterval > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-req.Context().Done():
+				return req.Context().Err()
+			case <-time.After(bh.ChunkInterval):
+			}
+		}
+		from, to := i*size, (i+1)*size
+		if from > len(body) {
+			from = len(body)
+		}
+		if to > len(body) {
+			to = len(body)
+		}
+		if _, err := w.Write(body[from:to]); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	return nil
+}
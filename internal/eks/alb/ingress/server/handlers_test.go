@@ -3,14 +3,18 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/aws/aws-k8s-tester/internal/eks/alb/ingress/path"
 	"go.uber.org/zap"
+	"golang.org/x/net/http2"
 )
 
 func TestNewMux(t *testing.T) {
@@ -56,3 +60,105 @@ func TestNewMux(t *testing.T) {
 		t.Fatalf("unexpected metrics output %q", string(d))
 	}
 }
+
+func TestBehaviors(t *testing.T) {
+	mux, err := NewMuxWithSpec(context.Background(), zap.NewExample(), 2, 10, map[string]string{
+		path.Create(1): "status-code=503",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	tests := []struct {
+		method   string
+		path     string
+		body     string
+		expCode  int
+		expBody  string
+		expTE    []string
+		expClose bool
+	}{
+		{method: http.MethodGet, path: path.Create(0), expCode: 200, expBody: "0000000000"},
+		{method: http.MethodGet, path: path.Create(0) + "?status-code=202&response-size=3", expCode: 202, expBody: "000"},
+		{method: http.MethodGet, path: path.Create(0) + "?error-rate=1&error-status-code=502", expCode: 502},
+		{method: http.MethodGet, path: path.Create(0) + "?delay=10ms&delay-distribution=uniform", expCode: 200, expBody: "0000000000"},
+		{method: http.MethodGet, path: path.Create(0) + "?chunks=3&chunk-interval=5ms", expCode: 200, expBody: "0000000000", expTE: []string{"chunked"}},
+		{method: http.MethodGet, path: path.Create(0) + "?close-connection=true", expCode: 200, expBody: "0000000000", expClose: true},
+		{method: http.MethodPost, path: path.Create(0), body: "hello world!", expCode: 200, expBody: "hello world!"},
+		{method: http.MethodGet, path: path.Create(1), expCode: 503, expBody: "0000000000"},
+		{method: http.MethodGet, path: path.Create(1) + "?status-code=200", expCode: 200, expBody: "0000000000"},
+		{method: http.MethodGet, path: path.Create(0) + "?delay=bad", expCode: 400},
+		{method: http.MethodGet, path: path.Create(0) + "?delay-distribution=normal&delay=1ms", expCode: 400},
+		{method: http.MethodGet, path: path.Create(0) + "?status-code=101", expCode: 400},
+		{method: http.MethodGet, path: path.Create(0) + "?error-rate=1&error-status-code=199", expCode: 400},
+		{method: http.MethodGet, path: path.Create(0) + "?status-code=600", expCode: 400},
+		{method: http.MethodGet, path: path.Create(0) + "?response-size=1048577", expCode: 400},
+		{method: http.MethodDelete, path: path.Create(0), expCode: 405},
+	}
+	for i, tt := range tests {
+		req, err := http.NewRequest(tt.method, ts.URL+tt.path, strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		d, err := ioutil.ReadAll(rs.Body)
+		rs.Body.Close()
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if rs.StatusCode != tt.expCode {
+			t.Fatalf("#%d: status code expected %d, got %d (%q)", i, tt.expCode, rs.StatusCode, string(d))
+		}
+		if tt.expBody != "" && string(d) != tt.expBody {
+			t.Fatalf("#%d: body expected %q, got %q", i, tt.expBody, string(d))
+		}
+		if tt.expTE != nil && !reflect.DeepEqual(rs.TransferEncoding, tt.expTE) {
+			t.Fatalf("#%d: transfer encoding expected %v, got %v", i, tt.expTE, rs.TransferEncoding)
+		}
+		if rs.Close != tt.expClose {
+			t.Fatalf("#%d: close expected %v, got %v", i, tt.expClose, rs.Close)
+		}
+	}
+
+	if _, err = NewMuxWithSpec(context.Background(), zap.NewExample(), 1, 10, map[string]string{"/unknown": "delay=1s"}); err == nil {
+		t.Fatal("expected error for unknown route spec path")
+	}
+}
+
+func TestH2C(t *testing.T) {
+	mux, err := NewMux(context.Background(), zap.NewExample(), 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(H2C(mux))
+	defer ts.Close()
+
+	cli := &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		},
+	}
+	rs, err := cli.Get(ts.URL + path.Create(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := ioutil.ReadAll(rs.Body)
+	rs.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rs.ProtoMajor != 2 {
+		t.Fatalf("expected HTTP/2, got %q", rs.Proto)
+	}
+	if string(d) != "0000000000" {
+		t.Fatalf("expected %q, got %q", "0000000000", string(d))
+	}
+}
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// https://tools.ietf.org/html/rfc6455
+const (
+	websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+
+	maxWebSocketPayload  = 16 * 1024 * 1024
+	websocketIdleTimeout = 5 * time.Minute
+)
+
+// WebSocketHandler upgrades the connection to WebSocket, and echoes
+// every text or binary message back, to test long-lived connections
+// through load balancers.
+func WebSocketHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	if req.Method != http.MethodGet ||
+		!headerContains(req.Header, "Connection", "upgrade") ||
+		!headerContains(req.Header, "Upgrade", "websocket") {
+		http.Error(w, "WebSocket Upgrade Required", http.StatusUpgradeRequired)
+		return nil
+	}
+	if req.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Unsupported WebSocket Version", http.StatusBadRequest)
+		return nil
+	}
+	key := req.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "Missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "WebSocket Not Supported", http.StatusInternalServerError)
+		return nil
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\n")
+	fmt.Fprintf(rw, "Upgrade: websocket\r\n")
+	fmt.Fprintf(rw, "Connection: Upgrade\r\n")
+	fmt.Fprintf(rw, "Sec-WebSocket-Accept: %s\r\n\r\n", websocketAccept(key))
+	if err = rw.Flush(); err != nil {
+		return err
+	}
+
+	donec := make(chan struct{})
+	defer close(donec)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-donec:
+		}
+	}()
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(websocketIdleTimeout))
+		fin, op, payload, err := readFrame(rw.Reader)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		switch op {
+		case opText, opBinary, opContinuation:
+			// echo fragments as they are, with the same FIN bit and opcode
+			err = writeFrame(rw.Writer, fin, op, payload)
+		case opPing:
+			err = writeFrame(rw.Writer, true, opPong, payload)
+		case opPong:
+		case opClose:
+			writeFrame(rw.Writer, true, opClose, payload)
+			rw.Flush()
+			return nil
+		default:
+			return fmt.Errorf("unknown WebSocket opcode %d", op)
+		}
+		if err != nil {
+			return err
+		}
+		if err = rw.Flush(); err != nil {
+			return err
+		}
+	}
+}
+
+func websocketAccept(key string) string {
+	h := sha1.New()
+	io.WriteString(h, key+websocketGUID)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func headerContains(h http.Header, key, value string) bool {
+	for _, v := range h[http.CanonicalHeaderKey(key)] {
+		for _, s := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readFrame reads one WebSocket frame, and unmasks its payload.
+// "fin" is false for all but the last frame of a fragmented message.
+func readFrame(rd *bufio.Reader) (fin bool, op byte, payload []byte, err error) {
+	var hdr [2]byte
+	if _, err = io.ReadFull(rd, hdr[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = hdr[0]&0x80 != 0
+	op = hdr[0] & 0x0F
+	masked := hdr[1]&0x80 != 0
+	n := uint64(hdr[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(rd, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(rd, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > maxWebSocketPayload {
+		return false, 0, nil, fmt.Errorf("WebSocket payload %d exceeds %d", n, maxWebSocketPayload)
+	}
+	if !masked {
+		// clients must mask all frames sent to the server
+		return false, 0, nil, errors.New("unmasked WebSocket frame from client")
+	}
+	var mask [4]byte
+	if _, err = io.ReadFull(rd, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, n)
+	if _, err = io.ReadFull(rd, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// writeFrame writes one unmasked WebSocket frame.
+func writeFrame(w io.Writer, fin bool, op byte, payload []byte) error {
+	hdr := []byte{op}
+	if fin {
+		hdr[0] |= 0x80
+	}
+	n := len(payload)
+	switch {
+	case n < 126:
+		hdr = append(hdr, byte(n))
+	case n <= 0xFFFF:
+		hdr = append(hdr, 126, 0, 0)
+		binary.BigEndian.PutUint16(hdr[2:], uint16(n))
+	default:
+		hdr = append(hdr, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(hdr[2:], uint64(n))
+	}
+	if _, err := w.Write(hdr); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
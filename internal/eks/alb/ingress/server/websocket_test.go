@@ -0,0 +1,166 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/internal/eks/alb/ingress/path"
+	"go.uber.org/zap"
+)
+
+func TestWebSocket(t *testing.T) {
+	mux, err := NewMux(context.Background(), zap.NewExample(), 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// https://tools.ietf.org/html/rfc6455#section-1.3
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n\r\n", path.PathWebSocket, key)
+
+	rd := bufio.NewReader(conn)
+	rs, err := http.ReadResponse(rd, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rs.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected %d, got %d", http.StatusSwitchingProtocols, rs.StatusCode)
+	}
+	if v := rs.Header.Get("Sec-WebSocket-Accept"); v != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected Sec-WebSocket-Accept %q", v)
+	}
+
+	for _, msg := range [][]byte{[]byte("hello"), bytes.Repeat([]byte("0"), 70000)} {
+		if err = writeMaskedFrame(conn, true, opText, msg); err != nil {
+			t.Fatal(err)
+		}
+		fin, op, payload, err := readServerFrame(rd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !fin || op != opText || !bytes.Equal(payload, msg) {
+			t.Fatalf("unexpected echo (fin %v, opcode %d, %d bytes)", fin, op, len(payload))
+		}
+	}
+
+	// fragmented message must be echoed with the same FIN bits and opcodes,
+	// with a control frame in between fragments
+	fragments := []struct {
+		fin     bool
+		op      byte
+		payload string
+	}{
+		{fin: false, op: opText, payload: "hel"},
+		{fin: false, op: opContinuation, payload: "lo "},
+		{fin: true, op: opContinuation, payload: "world"},
+	}
+	for i, f := range fragments {
+		if err = writeMaskedFrame(conn, f.fin, f.op, []byte(f.payload)); err != nil {
+			t.Fatal(err)
+		}
+		fin, op, payload, err := readServerFrame(rd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fin != f.fin || op != f.op || string(payload) != f.payload {
+			t.Fatalf("#%d: unexpected fragment echo (fin %v, opcode %d, %q)", i, fin, op, string(payload))
+		}
+		if i == 1 {
+			if err = writeMaskedFrame(conn, true, opPing, []byte("ping")); err != nil {
+				t.Fatal(err)
+			}
+			fin, op, _, err = readServerFrame(rd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !fin || op != opPong {
+				t.Fatalf("unexpected pong between fragments (fin %v, opcode %d)", fin, op)
+			}
+		}
+	}
+
+	if err = writeMaskedFrame(conn, true, opPing, []byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	fin, op, payload, err := readServerFrame(rd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fin || op != opPong || string(payload) != "ping" {
+		t.Fatalf("unexpected pong (opcode %d, %q)", op, string(payload))
+	}
+
+	// plain HTTP request must be rejected
+	rs, err = http.Get(ts.URL + path.PathWebSocket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs.Body.Close()
+	if rs.StatusCode != http.StatusUpgradeRequired {
+		t.Fatalf("expected %d, got %d", http.StatusUpgradeRequired, rs.StatusCode)
+	}
+}
+
+func writeMaskedFrame(conn net.Conn, fin bool, op byte, payload []byte) error {
+	var buf bytes.Buffer
+	if err := writeFrame(&buf, fin, op, payload); err != nil {
+		return err
+	}
+	d := buf.Bytes()
+	hdrN := len(d) - len(payload)
+	mask := []byte{1, 2, 3, 4}
+	d[1] |= 0x80
+	masked := append(append(append([]byte{}, d[:hdrN]...), mask...), d[hdrN:]...)
+	for i := range payload {
+		masked[hdrN+4+i] ^= mask[i%4]
+	}
+	_, err := conn.Write(masked)
+	return err
+}
+
+func readServerFrame(rd *bufio.Reader) (fin bool, op byte, payload []byte, err error) {
+	// server frames are unmasked, so re-mask with zero key to reuse the reader
+	var buf bytes.Buffer
+	hdr := make([]byte, 2)
+	if _, err = rd.Read(hdr[:1]); err != nil {
+		return false, 0, nil, err
+	}
+	if hdr[1], err = rd.ReadByte(); err != nil {
+		return false, 0, nil, err
+	}
+	n := int(hdr[1] & 0x7F)
+	ext := 0
+	switch n {
+	case 126:
+		ext = 2
+	case 127:
+		ext = 8
+	}
+	hdr[1] |= 0x80
+	buf.Write(hdr)
+	for i := 0; i < ext; i++ {
+		b, err := rd.ReadByte()
+		if err != nil {
+			return false, 0, nil, err
+		}
+		buf.WriteByte(b)
+	}
+	buf.Write([]byte{0, 0, 0, 0})
+	return readFrame(bufio.NewReader(io.MultiReader(&buf, rd)))
+}
@@ -40,11 +40,8 @@ func (md *embedded) CreateSecurityGroup() error {
 
 	for _, port := range []int64{80, 443} {
 		_, err = md.ec2.AuthorizeSecurityGroupIngress(&ec2.AuthorizeSecurityGroupIngressInput{
-			GroupId:    aws.String(md.cfg.ALBIngressController.ELBv2SecurityGroupIDPortOpen),
-			IpProtocol: aws.String("tcp"),
-			CidrIp:     aws.String("0.0.0.0/0"),
-			FromPort:   aws.Int64(port),
-			ToPort:     aws.Int64(port),
+			GroupId:       aws.String(md.cfg.ALBIngressController.ELBv2SecurityGroupIDPortOpen),
+			IpPermissions: []*ec2.IpPermission{md.portOpenPermission(port)},
 		})
 		if err != nil {
 			md.cfg.ALBIngressController.ELBv2SecurityGroupStatus = err.Error()
@@ -81,11 +78,8 @@ func (md *embedded) DeleteSecurityGroup() error {
 	md.cfg.ALBIngressController.ELBv2SecurityGroupStatus = "DELETING"
 
 	_, err := md.ec2.RevokeSecurityGroupIngress(&ec2.RevokeSecurityGroupIngressInput{
-		GroupId:    aws.String(md.cfg.ALBIngressController.ELBv2SecurityGroupIDPortOpen),
-		IpProtocol: aws.String("tcp"),
-		CidrIp:     aws.String("0.0.0.0/0"),
-		FromPort:   aws.Int64(80),
-		ToPort:     aws.Int64(80),
+		GroupId:       aws.String(md.cfg.ALBIngressController.ELBv2SecurityGroupIDPortOpen),
+		IpPermissions: []*ec2.IpPermission{md.portOpenPermission(80)},
 	})
 	if err != nil {
 		// do not fail the whole function, just logging errors
@@ -98,11 +92,8 @@ func (md *embedded) DeleteSecurityGroup() error {
 		}
 	}
 	_, err = md.ec2.RevokeSecurityGroupIngress(&ec2.RevokeSecurityGroupIngressInput{
-		GroupId:    aws.String(md.cfg.ALBIngressController.ELBv2SecurityGroupIDPortOpen),
-		IpProtocol: aws.String("tcp"),
-		CidrIp:     aws.String("0.0.0.0/0"),
-		FromPort:   aws.Int64(443),
-		ToPort:     aws.Int64(443),
+		GroupId:       aws.String(md.cfg.ALBIngressController.ELBv2SecurityGroupIDPortOpen),
+		IpPermissions: []*ec2.IpPermission{md.portOpenPermission(443)},
 	})
 	if err != nil {
 		// do not fail the whole function, just logging errors
@@ -138,3 +129,18 @@ func (md *embedded) DeleteSecurityGroup() error {
 	md.cfg.ALBIngressController.ELBv2SecurityGroupStatus = "DELETE_COMPLETE"
 	return md.cfg.Sync()
 }
+
+// portOpenPermission returns the ingress permission that opens the port
+// to the internet, including IPv6 for dual-stack load balancers.
+func (md *embedded) portOpenPermission(port int64) *ec2.IpPermission {
+	perm := &ec2.IpPermission{
+		IpProtocol: aws.String("tcp"),
+		IpRanges:   []*ec2.IpRange{{CidrIp: aws.String("0.0.0.0/0")}},
+		FromPort:   aws.Int64(port),
+		ToPort:     aws.Int64(port),
+	}
+	if md.cfg.VPC.EnableIPv6 {
+		perm.Ipv6Ranges = []*ec2.Ipv6Range{{CidrIpv6: aws.String("::/0")}}
+	}
+	return perm
+}
@@ -25,7 +25,7 @@ func (md *embedded) createALBAnnotations(healthCheckPath string) (a map[string]s
 		"alb.ingress.kubernetes.io/scheme":       "internet-facing",
 		"alb.ingress.kubernetes.io/target-type":  md.cfg.ALBIngressController.TargetType,
 		"alb.ingress.kubernetes.io/listen-ports": `[{"HTTP":80,"HTTPS": 443}]`,
-		"alb.ingress.kubernetes.io/subnets":      strings.Join(md.cfg.SubnetIDs, ","),
+		"alb.ingress.kubernetes.io/subnets":      strings.Join(md.cfg.LoadBalancerSubnetIDs(), ","),
 	}
 
 	h, _ := os.Hostname()
@@ -41,6 +41,11 @@ func (md *embedded) createALBAnnotations(healthCheckPath string) (a map[string]s
 	}
 	a["alb.ingress.kubernetes.io/tags"] = strings.Join(ss, ",")
 
+	if md.cfg.VPC.EnableIPv6 {
+		// dual-stack load balancer requires subnets with IPv6 CIDR blocks
+		a["alb.ingress.kubernetes.io/ip-address-type"] = "dualstack"
+	}
+
 	switch md.cfg.ALBIngressController.TargetType {
 	case "instance":
 		// list of security group IDs for ALB with HTTP/HTTPS wide open.
@@ -112,7 +117,7 @@ func (md *embedded) CreateIngressObjects() (err error) {
 			"alb.ingress.kubernetes.io/scheme":       "internet-facing",
 			"alb.ingress.kubernetes.io/target-type":  md.cfg.ALBIngressController.TargetType,
 			"alb.ingress.kubernetes.io/listen-ports": `[{"HTTP":80,"HTTPS": 443}]`,
-			"alb.ingress.kubernetes.io/subnets":      strings.Join(md.cfg.SubnetIDs, ","),
+			"alb.ingress.kubernetes.io/subnets":      strings.Join(md.cfg.LoadBalancerSubnetIDs(), ","),
 		},
 		IngressPaths: []v1beta1.HTTPIngressPath{
 			{
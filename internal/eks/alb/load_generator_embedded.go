@@ -0,0 +1,207 @@
+package alb
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/internal/eks/alb/ingress"
+	"github.com/aws/aws-k8s-tester/internal/eks/alb/ingress/path"
+	"github.com/aws/aws-k8s-tester/pkg/wrk"
+
+	humanize "github.com/dustin/go-humanize"
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	loadGeneratorName      = "ingress-load-generator"
+	loadGeneratorNamespace = "default"
+
+	// loadGeneratorStartDelay is the time given to schedule all load generator
+	// pods and pull images, before they start sending loads in lockstep.
+	loadGeneratorStartDelay = 3 * time.Minute
+)
+
+// RunLoadGenerators launches load generator pods that start in lockstep,
+// waits for their completion, and collects their results.
+// It returns the combined result and the result of each worker.
+func (md *embedded) RunLoadGenerators() (combined wrk.Result, workers []wrk.Result, err error) {
+	now := time.Now().UTC()
+
+	ep := "http://" + md.cfg.ALBIngressController.ELBv2NamespaceToDNSName["default"]
+	if md.cfg.ALBIngressController.TestMode == "ingress-test-server" {
+		ep += path.Path
+	}
+	cfg := ingress.ConfigJobLoadGenerator{
+		Name:        loadGeneratorName,
+		Namespace:   loadGeneratorNamespace,
+		Image:       md.cfg.AWSK8sTesterImage,
+		Workers:     md.cfg.ALBIngressController.TestLoadGenerators,
+		Endpoint:    ep,
+		Threads:     2,
+		Connections: md.cfg.ALBIngressController.TestClients,
+		Minutes:     md.cfg.ALBIngressController.TestScalabilityMinutes,
+		StartAt:     now.Add(loadGeneratorStartDelay),
+	}
+	var d string
+	d, err = ingress.CreateJobLoadGenerator(cfg)
+	if err != nil {
+		return wrk.Result{}, nil, err
+	}
+	if err = ioutil.WriteFile(md.cfg.ALBIngressController.LoadGeneratorSpecPath, []byte(d), 0600); err != nil {
+		return wrk.Result{}, nil, err
+	}
+	defer md.deleteLoadGenerators()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	cmd := md.kubectl.CommandContext(ctx,
+		md.kubectlPath,
+		"--kubeconfig="+md.cfg.KubeConfigPath,
+		"apply",
+		"--filename="+md.cfg.ALBIngressController.LoadGeneratorSpecPath,
+	)
+	kexo, err := cmd.CombinedOutput()
+	cancel()
+	if err != nil {
+		return wrk.Result{}, nil, fmt.Errorf("failed to apply load generators (%v, %q)", err, string(kexo))
+	}
+	md.lg.Info("applied load generators",
+		zap.Int("workers", cfg.Workers),
+		zap.Time("start-at", cfg.StartAt),
+		zap.String("output", string(kexo)),
+	)
+
+	timeout := loadGeneratorStartDelay + time.Duration(cfg.Minutes)*time.Minute + 10*time.Minute
+	if err = md.waitLoadGenerators(cfg.Workers, timeout); err != nil {
+		return wrk.Result{}, nil, err
+	}
+
+	workers, err = md.collectLoadGenerators()
+	if err != nil {
+		return wrk.Result{}, nil, err
+	}
+	if len(workers) != cfg.Workers {
+		return wrk.Result{}, nil, fmt.Errorf("expected %d load generator results, got %d", cfg.Workers, len(workers))
+	}
+	combined = wrk.CombineParallel(workers...)
+
+	md.lg.Info("collected load generator results",
+		zap.Int("workers", len(workers)),
+		zap.Float64("requests-per-sec", combined.RequestsPerSec),
+		zap.String("request-started", humanize.RelTime(now, time.Now().UTC(), "ago", "from now")),
+	)
+	return combined, workers, nil
+}
+
+func (md *embedded) waitLoadGenerators(workers int, timeout time.Duration) error {
+	retryStart := time.Now().UTC()
+	for time.Now().UTC().Sub(retryStart) < timeout {
+		select {
+		case <-md.stopc:
+			return fmt.Errorf("load generators %q aborted", loadGeneratorName)
+		case <-time.After(10 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		cmd := md.kubectl.CommandContext(ctx,
+			md.kubectlPath,
+			"--kubeconfig="+md.cfg.KubeConfigPath,
+			"get", "job", loadGeneratorName,
+			"--namespace="+loadGeneratorNamespace,
+			"--output=yaml",
+		)
+		kexo, err := cmd.CombinedOutput()
+		cancel()
+		if err != nil {
+			md.lg.Warn("failed to get job", zap.String("output", string(kexo)), zap.Error(err))
+			continue
+		}
+		jb := new(batchv1.Job)
+		if err = yaml.Unmarshal(kexo, jb); err != nil {
+			md.lg.Warn("failed to parse job", zap.Error(err))
+			continue
+		}
+		if jb.Status.Failed > 0 {
+			return fmt.Errorf("%d load generator(s) failed", jb.Status.Failed)
+		}
+		md.lg.Info("waiting for load generators",
+			zap.Int32("active", jb.Status.Active),
+			zap.Int32("succeeded", jb.Status.Succeeded),
+			zap.Int("workers", workers),
+		)
+		if int(jb.Status.Succeeded) == workers {
+			return nil
+		}
+	}
+	return fmt.Errorf("load generators %q did not complete in %v", loadGeneratorName, timeout)
+}
+
+func (md *embedded) collectLoadGenerators() (workers []wrk.Result, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	cmd := md.kubectl.CommandContext(ctx,
+		md.kubectlPath,
+		"--kubeconfig="+md.cfg.KubeConfigPath,
+		"get", "pods",
+		"--namespace="+loadGeneratorNamespace,
+		"--selector=app="+loadGeneratorName,
+		"--output=jsonpath={.items[*].metadata.name}",
+	)
+	kexo, err := cmd.CombinedOutput()
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get load generator pods (%v, %q)", err, string(kexo))
+	}
+
+	for _, pod := range strings.Fields(string(kexo)) {
+		ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
+		cmd = md.kubectl.CommandContext(ctx,
+			md.kubectlPath,
+			"--kubeconfig="+md.cfg.KubeConfigPath,
+			"logs", pod,
+			"--namespace="+loadGeneratorNamespace,
+		)
+		var logs []byte
+		logs, err = cmd.CombinedOutput()
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get logs from %q (%v)", pod, err)
+		}
+		outputs := wrk.Extract(string(logs))
+		if len(outputs) == 0 {
+			return nil, fmt.Errorf("no wrk output found in %q logs", pod)
+		}
+		var rs wrk.Result
+		rs, err = wrk.Parse(outputs[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse wrk output from %q (%v)", pod, err)
+		}
+		md.lg.Info("collected load generator result",
+			zap.String("pod", pod),
+			zap.Float64("requests-per-sec", rs.RequestsPerSec),
+		)
+		workers = append(workers, rs)
+	}
+	return workers, nil
+}
+
+func (md *embedded) deleteLoadGenerators() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	cmd := md.kubectl.CommandContext(ctx,
+		md.kubectlPath,
+		"--kubeconfig="+md.cfg.KubeConfigPath,
+		"delete",
+		"--filename="+md.cfg.ALBIngressController.LoadGeneratorSpecPath,
+		"--ignore-not-found",
+	)
+	kexo, err := cmd.CombinedOutput()
+	cancel()
+	if err != nil {
+		md.lg.Warn("failed to delete load generators", zap.String("output", string(kexo)), zap.Error(err))
+		return
+	}
+	md.lg.Info("deleted load generators", zap.String("output", string(kexo)))
+}
@@ -1,5 +1,7 @@
 package alb
 
+import "github.com/aws/aws-k8s-tester/pkg/wrk"
+
 // Plugin defines ALB Ingress Controller deployer operations.
 type Plugin interface {
 	DeployBackend() error
@@ -15,4 +17,8 @@ type Plugin interface {
 	DeleteIngressObjects() error
 
 	TestAWSResources() error
+
+	// RunLoadGenerators runs distributed load generators,
+	// and returns the combined result and the result of each worker.
+	RunLoadGenerators() (wrk.Result, []wrk.Result, error)
 }
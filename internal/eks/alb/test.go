@@ -15,6 +15,12 @@ func (md *embedded) TestAWSResources() error {
 		zap.Int("elbv2-number", len(md.cfg.ALBIngressController.ELBv2NameToARN)),
 	)
 
+	if md.cfg.VPC.EnableIPv6 {
+		if err := md.checkDualStack(); err != nil {
+			return err
+		}
+	}
+
 	for name, arn := range md.cfg.ALBIngressController.ELBv2NameToARN {
 		desc, err := md.elbv2.DescribeTargetGroups(&elbv2.DescribeTargetGroupsInput{
 			LoadBalancerArn: aws.String(arn),
@@ -115,3 +121,25 @@ func (md *embedded) TestAWSResources() error {
 	)
 	return nil
 }
+
+// checkDualStack checks that all load balancers accept IPv6 traffic.
+func (md *embedded) checkDualStack() error {
+	var arns []string
+	for _, arn := range md.cfg.ALBIngressController.ELBv2NameToARN {
+		arns = append(arns, arn)
+	}
+	out, err := md.elbv2.DescribeLoadBalancers(&elbv2.DescribeLoadBalancersInput{
+		LoadBalancerArns: aws.StringSlice(arns),
+	})
+	if err != nil {
+		md.lg.Warn("failed to describe load balancers", zap.Error(err))
+		return err
+	}
+	for _, lb := range out.LoadBalancers {
+		if aws.StringValue(lb.IpAddressType) != elbv2.IpAddressTypeDualstack {
+			return fmt.Errorf("expected %q IP address type for %q, got %q", elbv2.IpAddressTypeDualstack, aws.StringValue(lb.LoadBalancerName), aws.StringValue(lb.IpAddressType))
+		}
+		md.lg.Info("found dual-stack load balancer", zap.String("elbv2-name", aws.StringValue(lb.LoadBalancerName)))
+	}
+	return nil
+}
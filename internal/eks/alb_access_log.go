@@ -0,0 +1,121 @@
+package eks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	alblog "github.com/aws/aws-k8s-tester/internal/alb-log"
+
+	"go.uber.org/zap"
+)
+
+// checkALBAccessLogs downloads ALB access logs delivered for the test window,
+// and cross-checks the ALB-side request and ELB 5xx count against the client-side
+// request and non-2xx/3xx response count. "clientNon2xx3xx" is negative if the
+// client does not count responses by status code, then only requests are compared.
+// Mismatches are only reported, since ALB delivers access logs
+// on a best-effort basis, every 5 minutes.
+// https://docs.aws.amazon.com/elasticloadbalancing/latest/application/load-balancer-access-logs.html
+func (md *embedded) checkALBAccessLogs(start, end time.Time, clientRequests, clientNon2xx3xx int64) (summary string, err error) {
+	dir, err := ioutil.TempDir(os.TempDir(), "alb-access-logs")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	// filter by the test Ingress DNS name, since other Ingress objects
+	// share the same access log prefix
+	dns := md.cfg.ALBIngressController.ELBv2NamespaceToDNSName["default"]
+	prefix := md.cfg.ClusterName + "-kube-system"
+
+	var logs []alblog.Log
+	fetched := make(map[string]bool)
+	retryStart := time.Now().UTC()
+	for time.Now().UTC().Sub(retryStart) < md.cfg.ALBIngressController.TestAccessLogsTimeout {
+		select {
+		case <-md.stopc:
+			return "", fmt.Errorf("ALB access logs check aborted")
+		case <-time.After(time.Minute):
+		}
+
+		var ps []string
+		ps, err = md.s3Plugin.DownloadAccessLogs(prefix, start, dir, fetched)
+		if err != nil {
+			// keep the objects downloaded so far, which are skipped on retry
+			md.lg.Warn("failed to download ALB access logs", zap.Error(err))
+		}
+		for _, p := range ps {
+			var ls []alblog.Log
+			ls, err = alblog.Parse(p)
+			if err != nil {
+				return "", fmt.Errorf("failed to parse ALB access log %q (%v)", p, err)
+			}
+			for _, l := range ls {
+				if !strings.Contains(l.Request, dns) {
+					continue
+				}
+				ts, perr := time.Parse(time.RFC3339Nano, l.Timestamp)
+				if perr != nil || ts.Before(start) || ts.After(end) {
+					continue
+				}
+				logs = append(logs, l)
+			}
+		}
+		md.lg.Info("downloaded ALB access logs",
+			zap.Int("new-objects", len(ps)),
+			zap.Int("objects", len(fetched)),
+			zap.Int("requests", len(logs)),
+			zap.Int64("client-requests", clientRequests),
+		)
+		if int64(len(logs)) >= clientRequests {
+			break
+		}
+	}
+
+	an := alblog.Analyze(logs)
+	md.cfg.ALBIngressController.TestResultAccessLogRequests = int64(an.Total)
+	md.cfg.ALBIngressController.TestResultAccessLogErrors = int64(an.Errors)
+	md.cfg.Sync()
+
+	if err = alblog.SaveCSV(md.cfg.ALBIngressController.AccessLogsOutputToUploadPath, logs); err != nil {
+		return "", err
+	}
+	if md.cfg.ALBIngressController.UploadTesterLogs {
+		if err = md.s3Plugin.UploadToBucketForTests(
+			md.cfg.ALBIngressController.AccessLogsOutputToUploadPath,
+			md.cfg.ALBIngressController.AccessLogsOutputToUploadPathBucket,
+		); err != nil {
+			md.lg.Warn("failed to upload ALB access logs", zap.Error(err))
+		}
+	}
+
+	clientErrors := "unknown"
+	if clientNon2xx3xx >= 0 {
+		clientErrors = fmt.Sprintf("%d", clientNon2xx3xx)
+	}
+	summary = fmt.Sprintf(
+		"ALB access logs: %d requests (client %d), %d ELB 5xx (client non-2xx/3xx %s)\n\n%s",
+		an.Total,
+		clientRequests,
+		an.Errors,
+		clientErrors,
+		an.String(),
+	)
+	if int64(an.Total) != clientRequests || (clientNon2xx3xx >= 0 && int64(an.Errors) != clientNon2xx3xx) {
+		md.lg.Warn("ALB access logs do not match client-side results",
+			zap.Int("access-log-requests", an.Total),
+			zap.Int64("client-requests", clientRequests),
+			zap.Int("access-log-errors", an.Errors),
+			zap.String("client-non-2xx-3xx", clientErrors),
+		)
+	} else {
+		md.lg.Info("ALB access logs match client-side results",
+			zap.Int("requests", an.Total),
+			zap.Int("errors", an.Errors),
+		)
+	}
+	return summary, nil
+}
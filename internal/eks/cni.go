@@ -1,68 +1,59 @@
 package eks
 
 import (
-	"context"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
 	"strings"
 	"time"
 
-	"github.com/aws/aws-k8s-tester/pkg/httputil"
 	"go.uber.org/zap"
-	"k8s.io/utils/exec"
 )
 
-// https://github.com/aws/amazon-vpc-cni-k8s/releases
-func (md *embedded) upgradeCNI() error {
-	d, err := httputil.Download(md.lg, os.Stdout, "https://raw.githubusercontent.com/aws/amazon-vpc-cni-k8s/master/config/v1.3/aws-k8s-cni.yaml")
-	if err != nil {
-		return err
+// TestCNI runs the VPC CNI functional tests against the worker nodes,
+// and records each test result in the configuration.
+func (md *embedded) TestCNI() error {
+	if md.cfg.CNI == nil {
+		return errors.New("CNI test is not configured")
 	}
-	var f *os.File
-	f, err = ioutil.TempFile(os.TempDir(), "cni-v1.3.yaml")
-	if err != nil {
-		return err
+	if len(md.cfg.ClusterState.WorkerNodes) == 0 {
+		return errors.New("no worker node found for CNI test")
 	}
-	if _, err = f.Write(d); err != nil {
+
+	now := time.Now().UTC()
+	if err := md.cniPlugin.CreateTestResources(); err != nil {
 		return err
 	}
-	cmPath := f.Name()
-	f.Close()
-
-	retryStart := time.Now().UTC()
-	for time.Now().UTC().Sub(retryStart) < 5*time.Minute {
-		select {
-		case <-md.stopc:
-			return nil
-		default:
+	defer func() {
+		if err := md.cniPlugin.DeleteTestResources(); err != nil {
+			md.lg.Warn("failed to delete CNI test resources", zap.Error(err))
 		}
-
-		// TODO: use "k8s.io/client-go"
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		var kexo []byte
-		kexo, err = exec.New().CommandContext(ctx,
-			md.cfg.KubectlPath,
-			"--kubeconfig="+md.cfg.KubeConfigPath,
-			"apply", "--filename="+cmPath,
-		).CombinedOutput()
-		cancel()
-		if err != nil {
-			if strings.Contains(err.Error(), "unknown flag:") {
-				return fmt.Errorf("unknown flag %s", string(kexo))
-			}
-			md.lg.Warn("failed to upgrade CNI",
-				zap.String("output", string(kexo)),
-				zap.Error(err),
-			)
-
-			time.Sleep(5 * time.Second)
+	}()
+
+	var errs []string
+	for _, test := range []struct {
+		name string
+		run  func() error
+	}{
+		{"pod-connectivity", md.cniPlugin.TestPodConnectivity},
+		{"ip-allocation", md.cniPlugin.TestIPAllocation},
+		{"snat", md.cniPlugin.TestSNAT},
+		{"warm-pool", md.cniPlugin.TestWarmPool},
+	} {
+		if err := test.run(); err != nil {
+			md.lg.Warn("CNI test failed", zap.String("test", test.name), zap.Error(err))
+			errs = append(errs, fmt.Sprintf("%s: %v", test.name, err))
 			continue
 		}
-
-		md.lg.Info("upgraded CNI using kubectl", zap.String("output", string(kexo)))
-		break
+		md.lg.Info("CNI test passed", zap.String("test", test.name))
 	}
 
+	md.lg.Info("CNI test finished",
+		zap.String("manifest-version", md.cfg.CNI.ManifestVersion),
+		zap.Int("failed", len(errs)),
+		zap.Duration("took", time.Now().UTC().Sub(now)),
+	)
+	if len(errs) > 0 {
+		return fmt.Errorf("CNI test failed (%s)", strings.Join(errs, ", "))
+	}
 	return nil
 }
@@ -0,0 +1,28 @@
+// Package cni implements Amazon VPC CNI plugin deployer and tester.
+package cni
+
+// Plugin defines Amazon VPC CNI plugin deployer and tester operations.
+type Plugin interface {
+	// Apply applies the CNI manifest from the configured source.
+	// It is no-op if no manifest source is configured.
+	Apply() error
+
+	// CreateTestResources creates the test namespace and
+	// a test server DaemonSet on every worker node.
+	CreateTestResources() error
+	// DeleteTestResources deletes all test resources.
+	DeleteTestResources() error
+
+	// TestPodConnectivity checks pod-to-pod connectivity
+	// across worker nodes and subnets.
+	TestPodConnectivity() error
+	// TestIPAllocation checks the maximum pods of each worker node
+	// against the per-instance ENI limits, and pod IP uniqueness.
+	TestIPAllocation() error
+	// TestSNAT checks that pod traffic to the internet is
+	// SNATed to the worker node IP.
+	TestSNAT() error
+	// TestWarmPool scales test pods to the maximum pods of a worker node,
+	// and measures how long it takes for all pod IPs to be assigned.
+	TestWarmPool() error
+}
@@ -0,0 +1,191 @@
+package cni
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+	"github.com/aws/aws-k8s-tester/pkg/httputil"
+
+	"go.uber.org/zap"
+	"k8s.io/utils/exec"
+)
+
+type embedded struct {
+	stopc chan struct{}
+
+	lg  *zap.Logger
+	cfg *eksconfig.Config
+
+	kubectl     exec.Interface
+	kubectlPath string
+}
+
+// NewEmbedded creates a new Plugin using "kubectl".
+func NewEmbedded(stopc chan struct{}, lg *zap.Logger, cfg *eksconfig.Config, kubectlPath string) Plugin {
+	return &embedded{
+		stopc:       stopc,
+		lg:          lg,
+		cfg:         cfg,
+		kubectl:     exec.New(),
+		kubectlPath: kubectlPath,
+	}
+}
+
+func (md *embedded) run(timeout time.Duration, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	args = append([]string{"--kubeconfig=" + md.cfg.KubeConfigPath}, args...)
+	return md.kubectl.CommandContext(ctx, md.kubectlPath, args...).CombinedOutput()
+}
+
+func (md *embedded) Apply() error {
+	p := md.cfg.CNI.ManifestPath
+	if p == "" {
+		if md.cfg.CNI.ManifestURL == "" {
+			md.lg.Info("no CNI manifest source; keeping CNI installed by EKS")
+			return nil
+		}
+		d, err := httputil.Download(md.lg, os.Stdout, md.cfg.CNI.ManifestURL)
+		if err != nil {
+			return err
+		}
+		p, err = fileutil.WriteTempFile(d)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(p)
+	}
+
+	var err error
+	retryStart := time.Now().UTC()
+	for time.Now().UTC().Sub(retryStart) < 5*time.Minute {
+		select {
+		case <-md.stopc:
+			return nil
+		default:
+		}
+
+		var kexo []byte
+		kexo, err = md.run(10*time.Second, "apply", "--filename="+p)
+		if err != nil {
+			if strings.Contains(err.Error(), "unknown flag:") {
+				return fmt.Errorf("unknown flag %s", string(kexo))
+			}
+			md.lg.Warn("failed to apply CNI manifest",
+				zap.String("output", string(kexo)),
+				zap.Error(err),
+			)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		md.lg.Info("applied CNI manifest",
+			zap.String("manifest-path", md.cfg.CNI.ManifestPath),
+			zap.String("manifest-url", md.cfg.CNI.ManifestURL),
+			zap.String("output", string(kexo)),
+		)
+		return nil
+	}
+	return err
+}
+
+func (md *embedded) CreateTestResources() error {
+	spec, err := createTestServerSpec(md.cfg.CNI.TestImage)
+	if err != nil {
+		return err
+	}
+	if err = md.applySpec(spec); err != nil {
+		return err
+	}
+	_, err = md.waitPods("app="+testServerName, -1)
+	return err
+}
+
+func (md *embedded) DeleteTestResources() error {
+	out, err := md.run(5*time.Minute, "delete", "namespace", testNamespace, "--ignore-not-found")
+	if err != nil {
+		return fmt.Errorf("failed to delete CNI test namespace %v (%q)", err, string(out))
+	}
+	md.lg.Info("deleted CNI test namespace", zap.String("output", string(out)))
+	return nil
+}
+
+func (md *embedded) applySpec(spec string) error {
+	p, err := fileutil.WriteTempFile([]byte(spec))
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(p)
+
+	out, err := md.run(time.Minute, "apply", "--filename="+p)
+	if err != nil {
+		return fmt.Errorf("failed to apply CNI test spec %v (%q)", err, string(out))
+	}
+	md.lg.Info("applied CNI test spec", zap.String("output", string(out)))
+	return nil
+}
+
+// listPods lists pods with the label selector in the test namespace.
+// If the selector is empty, it lists pods in all namespaces.
+func (md *embedded) listPods(selector string) ([]pod, error) {
+	args := []string{"get", "pods", "-o", "json"}
+	if selector == "" {
+		args = append(args, "--all-namespaces")
+	} else {
+		args = append(args, "--namespace="+testNamespace, "--selector="+selector)
+	}
+	out, err := md.run(time.Minute, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods %v (%q)", err, string(out))
+	}
+	return parsePods(out)
+}
+
+// waitPods waits until the expected number of pods are running with pod IPs.
+// If expected is negative, it waits for the number of worker nodes.
+func (md *embedded) waitPods(selector string, expected int) ([]pod, error) {
+	if expected < 0 {
+		expected = len(md.cfg.ClusterState.WorkerNodes)
+	}
+	retryStart := time.Now().UTC()
+	for time.Now().UTC().Sub(retryStart) < md.cfg.CNI.TestTimeout {
+		select {
+		case <-md.stopc:
+			return nil, errors.New("CNI test aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		pods, err := md.listPods(selector)
+		if err != nil {
+			md.lg.Warn("failed to list CNI test pods", zap.Error(err))
+			continue
+		}
+		ready := 0
+		for _, p := range pods {
+			if p.Phase == "Running" && p.PodIP != "" {
+				ready++
+			}
+		}
+		md.lg.Info("waiting for CNI test pods",
+			zap.String("selector", selector),
+			zap.Int("expected", expected),
+			zap.Int("ready", ready),
+		)
+		if expected > 0 && ready >= expected {
+			return pods, nil
+		}
+	}
+	return nil, fmt.Errorf("CNI test pods %q not ready after %v", selector, md.cfg.CNI.TestTimeout)
+}
+
+// podExec runs a command in the test pod.
+func (md *embedded) podExec(podName string, args ...string) ([]byte, error) {
+	args = append([]string{"exec", "--namespace=" + testNamespace, podName, "--"}, args...)
+	return md.run(time.Minute, args...)
+}
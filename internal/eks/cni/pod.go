@@ -0,0 +1,80 @@
+package cni
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+type pod struct {
+	Namespace   string
+	Name        string
+	NodeName    string
+	HostNetwork bool
+	HostIP      string
+	PodIP       string
+	Phase       string
+}
+
+type podList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			NodeName    string `json:"nodeName"`
+			HostNetwork bool   `json:"hostNetwork"`
+		} `json:"spec"`
+		Status struct {
+			Phase  string `json:"phase"`
+			HostIP string `json:"hostIP"`
+			PodIP  string `json:"podIP"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// parsePods parses "kubectl get pods -o json" output,
+// sorted by namespace and name.
+func parsePods(d []byte) ([]pod, error) {
+	var pl podList
+	if err := json.Unmarshal(d, &pl); err != nil {
+		return nil, err
+	}
+	pods := make([]pod, 0, len(pl.Items))
+	for _, item := range pl.Items {
+		pods = append(pods, pod{
+			Namespace:   item.Metadata.Namespace,
+			Name:        item.Metadata.Name,
+			NodeName:    item.Spec.NodeName,
+			HostNetwork: item.Spec.HostNetwork,
+			HostIP:      item.Status.HostIP,
+			PodIP:       item.Status.PodIP,
+			Phase:       item.Status.Phase,
+		})
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+	return pods, nil
+}
+
+// duplicatePodIPs returns pod IPs assigned to more than one active pod.
+// Host network pods share the node IP, thus ignored.
+func duplicatePodIPs(pods []pod) map[string][]string {
+	ipToPods := make(map[string][]string)
+	for _, p := range pods {
+		if p.HostNetwork || p.PodIP == "" || p.Phase == "Succeeded" || p.Phase == "Failed" {
+			continue
+		}
+		ipToPods[p.PodIP] = append(ipToPods[p.PodIP], p.Namespace+"/"+p.Name)
+	}
+	for ip, names := range ipToPods {
+		if len(names) < 2 {
+			delete(ipToPods, ip)
+		}
+	}
+	return ipToPods
+}
@@ -0,0 +1,146 @@
+package cni
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const testPods = `{
+  "items": [
+    {
+      "metadata": {"name": "aws-node-1", "namespace": "kube-system"},
+      "spec": {"nodeName": "ip-192-168-1-1.us-west-2.compute.internal", "hostNetwork": true},
+      "status": {"phase": "Running", "hostIP": "192.168.1.1", "podIP": "192.168.1.1"}
+    },
+    {
+      "metadata": {"name": "kube-proxy-1", "namespace": "kube-system"},
+      "spec": {"nodeName": "ip-192-168-1-1.us-west-2.compute.internal", "hostNetwork": true},
+      "status": {"phase": "Running", "hostIP": "192.168.1.1", "podIP": "192.168.1.1"}
+    },
+    {
+      "metadata": {"name": "cni-test-server-b", "namespace": "aws-k8s-tester-cni"},
+      "spec": {"nodeName": "ip-192-168-1-1.us-west-2.compute.internal"},
+      "status": {"phase": "Running", "hostIP": "192.168.1.1", "podIP": "192.168.1.10"}
+    },
+    {
+      "metadata": {"name": "cni-test-server-a", "namespace": "aws-k8s-tester-cni"},
+      "spec": {"nodeName": "ip-192-168-2-2.us-west-2.compute.internal"},
+      "status": {"phase": "Running", "hostIP": "192.168.2.2", "podIP": "192.168.2.20"}
+    },
+    {
+      "metadata": {"name": "job-1", "namespace": "default"},
+      "spec": {"nodeName": "ip-192-168-2-2.us-west-2.compute.internal"},
+      "status": {"phase": "Succeeded", "hostIP": "192.168.2.2", "podIP": "192.168.2.20"}
+    }
+  ]
+}`
+
+func TestParsePods(t *testing.T) {
+	pods, err := parsePods([]byte(testPods))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods) != 5 {
+		t.Fatalf("expected 5 pods, got %d", len(pods))
+	}
+	exp := pod{
+		Namespace: "aws-k8s-tester-cni",
+		Name:      "cni-test-server-a",
+		NodeName:  "ip-192-168-2-2.us-west-2.compute.internal",
+		HostIP:    "192.168.2.2",
+		PodIP:     "192.168.2.20",
+		Phase:     "Running",
+	}
+	if !reflect.DeepEqual(pods[0], exp) {
+		t.Fatalf("expected %+v, got %+v", exp, pods[0])
+	}
+
+	// host network pods and completed pods do not hold pod IPs
+	if dup := duplicatePodIPs(pods); len(dup) != 0 {
+		t.Fatalf("unexpected duplicate pod IPs %v", dup)
+	}
+	pods[2].Phase = "Running"
+	dup := duplicatePodIPs(pods)
+	if !reflect.DeepEqual(dup, map[string][]string{"192.168.2.20": {"aws-k8s-tester-cni/cni-test-server-a", "default/job-1"}}) {
+		t.Fatalf("unexpected duplicate pod IPs %v", dup)
+	}
+}
+
+func TestNodes(t *testing.T) {
+	nodes, err := parseNodes([]byte("ip-2 58 m3.xlarge\nip-1 29 m5.large\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []node{
+		{name: "ip-1", instanceType: "m5.large", maxPods: 29},
+		{name: "ip-2", instanceType: "m3.xlarge", maxPods: 58},
+	}
+	if !reflect.DeepEqual(nodes, exp) {
+		t.Fatalf("expected %+v, got %+v", exp, nodes)
+	}
+	if err = checkMaxPods(nodes); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes[0].maxPods = 110
+	if err = checkMaxPods(nodes); err == nil || !strings.Contains(err.Error(), "allocatable pods 110, expected 29") {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if _, err = parseNodes([]byte("ip-1 29\n")); err == nil {
+		t.Fatal("expected error for missing instance type")
+	}
+}
+
+func TestCheckPodPairs(t *testing.T) {
+	a := pod{Name: "a", NodeName: "ip-1"}
+	b := pod{Name: "b", NodeName: "ip-2"}
+	c := pod{Name: "c", NodeName: "ip-3"}
+	nodeToSubnet := map[string]string{"ip-1": "subnet-1", "ip-2": "subnet-1", "ip-3": "subnet-2"}
+	tt := []struct {
+		pairs  []podPair
+		errMsg string
+	}{
+		{nil, "no pod pair"},
+		{[]podPair{{src: a, dst: b}, {src: a, dst: c}, {src: c, dst: b}}, ""},
+		{[]podPair{{src: a, dst: b}, {src: a, dst: c, err: errors.New("timeout")}}, "across subnets failed for 1 pair(s): a (ip-1) -> c (ip-3): timeout"},
+		{[]podPair{{src: a, dst: b, err: errors.New("timeout")}, {src: a, dst: c}}, "connectivity failed for 1 pair(s)"},
+		{[]podPair{{src: a, dst: b}, {src: b, dst: a}}, "no connected pod pair across 2 subnets"},
+		{[]podPair{{src: a, dst: pod{Name: "d", NodeName: "ip-4"}}}, `unknown subnet of worker node "ip-4"`},
+	}
+	for i, tv := range tt {
+		err := checkPodPairs(zap.NewExample(), tv.pairs, nodeToSubnet)
+		if tv.errMsg == "" {
+			if err != nil {
+				t.Fatalf("#%d: unexpected error %v", i, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tv.errMsg) {
+			t.Fatalf("#%d: expected error %q, got %v", i, tv.errMsg, err)
+		}
+	}
+}
+
+func TestSpec(t *testing.T) {
+	s, err := createWarmPoolSpec("busybox:1.29", "ip-1", 27)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"replicas: 27", "nodeName: ip-1", "image: busybox:1.29", "namespace: aws-k8s-tester-cni"} {
+		if !strings.Contains(s, v) {
+			t.Fatalf("%q not found in %s", v, s)
+		}
+	}
+	s, err = createTestServerSpec("busybox:1.29")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(s, "httpd -f -p 8080 -h /www") {
+		t.Fatalf("unexpected test server spec %s", s)
+	}
+}
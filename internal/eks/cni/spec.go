@@ -0,0 +1,129 @@
+package cni
+
+import (
+	"bytes"
+	"text/template"
+)
+
+const (
+	testNamespace    = "aws-k8s-tester-cni"
+	testServerName   = "cni-test-server"
+	testServerPort   = 8080
+	testWarmPoolName = "cni-test-warm-pool"
+)
+
+type testSpec struct {
+	Namespace     string
+	ServerName    string
+	ServerPort    int
+	WarmPoolName  string
+	Image         string
+	NodeName      string
+	WarmPoolPodsN int
+}
+
+func createTestServerSpec(image string) (string, error) {
+	return executeTemplate(testServerTemplate, testSpec{
+		Namespace:  testNamespace,
+		ServerName: testServerName,
+		ServerPort: testServerPort,
+		Image:      image,
+	})
+}
+
+func createWarmPoolSpec(image, nodeName string, podsN int) (string, error) {
+	return executeTemplate(warmPoolTemplate, testSpec{
+		Namespace:     testNamespace,
+		WarmPoolName:  testWarmPoolName,
+		Image:         image,
+		NodeName:      nodeName,
+		WarmPoolPodsN: podsN,
+	})
+}
+
+func executeTemplate(txt string, ts testSpec) (string, error) {
+	tpl := template.Must(template.New("cniTestTemplate").Parse(txt))
+	buf := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buf, ts); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// testServerTemplate runs a HTTP server on every worker node,
+// which responds with its node name.
+const testServerTemplate = `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{ .Namespace }}
+
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: {{ .ServerName }}
+  namespace: {{ .Namespace }}
+  labels:
+    app: {{ .ServerName }}
+spec:
+  selector:
+    matchLabels:
+      app: {{ .ServerName }}
+  template:
+    metadata:
+      labels:
+        app: {{ .ServerName }}
+    spec:
+      terminationGracePeriodSeconds: 0
+      containers:
+      - name: server
+        image: {{ .Image }}
+        command:
+        - sh
+        - -c
+        - mkdir -p /www && echo -n ${NODE_NAME} > /www/index.html && httpd -f -p {{ .ServerPort }} -h /www
+        env:
+        - name: NODE_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: spec.nodeName
+        ports:
+        - containerPort: {{ .ServerPort }}
+
+`
+
+// warmPoolTemplate pins pods to a worker node,
+// bypassing the scheduler, to fill up the node's pod IPs.
+const warmPoolTemplate = `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .WarmPoolName }}
+  namespace: {{ .Namespace }}
+  labels:
+    app: {{ .WarmPoolName }}
+spec:
+  replicas: {{ .WarmPoolPodsN }}
+  selector:
+    matchLabels:
+      app: {{ .WarmPoolName }}
+  template:
+    metadata:
+      labels:
+        app: {{ .WarmPoolName }}
+    spec:
+      nodeName: {{ .NodeName }}
+      terminationGracePeriodSeconds: 0
+      containers:
+      - name: sleep
+        image: {{ .Image }}
+        command:
+        - sleep
+        - "3600"
+        resources:
+          requests:
+            cpu: 1m
+            memory: 4Mi
+
+`
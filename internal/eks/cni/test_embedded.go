@@ -0,0 +1,294 @@
+package cni
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/awsapi/ec2"
+
+	"go.uber.org/zap"
+)
+
+func (md *embedded) TestPodConnectivity() (err error) {
+	defer func() { md.setResult(&md.cfg.CNI.TestResultPodConnectivity, err) }()
+
+	pods, err := md.listPods("app=" + testServerName)
+	if err != nil {
+		return err
+	}
+	nodeToSubnet := make(map[string]string)
+	for _, iv := range md.cfg.ClusterState.WorkerNodes {
+		nodeToSubnet[iv.PrivateDNSName] = iv.SubnetID
+	}
+
+	var pairs []podPair
+	for _, src := range pods {
+		for _, dst := range pods {
+			if src.NodeName == dst.NodeName {
+				continue
+			}
+			ep := "http://" + net.JoinHostPort(dst.PodIP, strconv.Itoa(testServerPort)) + "/"
+			out, perr := md.podExec(src.Name, "wget", "-q", "-O", "-", "-T", "5", ep)
+			if perr == nil && strings.TrimSpace(string(out)) != dst.NodeName {
+				perr = fmt.Errorf("unexpected response %q", string(out))
+			}
+			pairs = append(pairs, podPair{src: src, dst: dst, err: perr})
+		}
+	}
+	return checkPodPairs(md.lg, pairs, nodeToSubnet)
+}
+
+// podPair is the connectivity check result from a pod to another pod.
+type podPair struct {
+	src, dst pod
+	err      error
+}
+
+// checkPodPairs requires every pod pair to connect, and at least one
+// connected pair across subnets when the worker nodes are in multiple subnets.
+func checkPodPairs(lg *zap.Logger, pairs []podPair, nodeToSubnet map[string]string) error {
+	if len(pairs) == 0 {
+		return errors.New("no pod pair across worker nodes (need at least 2 worker nodes)")
+	}
+	var errs, crossErrs []string
+	crossSubnetPairs := 0
+	subnets := make(map[string]struct{})
+	for _, subnet := range nodeToSubnet {
+		subnets[subnet] = struct{}{}
+	}
+	for _, pp := range pairs {
+		srcSubnet, ok := nodeToSubnet[pp.src.NodeName]
+		if !ok {
+			return fmt.Errorf("unknown subnet of worker node %q", pp.src.NodeName)
+		}
+		dstSubnet, ok := nodeToSubnet[pp.dst.NodeName]
+		if !ok {
+			return fmt.Errorf("unknown subnet of worker node %q", pp.dst.NodeName)
+		}
+
+		cross := srcSubnet != dstSubnet
+		if pp.err != nil {
+			msg := fmt.Sprintf("%s (%s) -> %s (%s): %v", pp.src.Name, pp.src.NodeName, pp.dst.Name, pp.dst.NodeName, pp.err)
+			if cross {
+				crossErrs = append(crossErrs, msg)
+			} else {
+				errs = append(errs, msg)
+			}
+			continue
+		}
+		if cross {
+			crossSubnetPairs++
+		}
+	}
+	if len(crossErrs) > 0 {
+		return fmt.Errorf("pod-to-pod connectivity across subnets failed for %d pair(s): %s", len(crossErrs), strings.Join(crossErrs, ", "))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("pod-to-pod connectivity failed for %d pair(s): %s", len(errs), strings.Join(errs, ", "))
+	}
+	if len(subnets) > 1 && crossSubnetPairs == 0 {
+		return fmt.Errorf("no connected pod pair across %d subnets", len(subnets))
+	}
+	lg.Info("pod-to-pod connectivity",
+		zap.Int("pairs", len(pairs)),
+		zap.Int("cross-subnet-pairs", crossSubnetPairs),
+		zap.Int("subnets", len(subnets)),
+	)
+	return nil
+}
+
+// node is a worker node with its maximum pods.
+type node struct {
+	name         string
+	instanceType string
+	maxPods      int64
+}
+
+func (md *embedded) listNodes() ([]node, error) {
+	out, err := md.run(time.Minute,
+		"get", "nodes",
+		"-o", `jsonpath={range .items[*]}{.metadata.name} {.status.allocatable.pods} {.metadata.labels.beta\.kubernetes\.io/instance-type}{"\n"}{end}`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes %v (%q)", err, string(out))
+	}
+	return parseNodes(out)
+}
+
+func parseNodes(out []byte) (nodes []node, err error) {
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected node line %q", line)
+		}
+		var maxPods int64
+		maxPods, err = strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse max pods %q (%v)", line, err)
+		}
+		nodes = append(nodes, node{name: fields[0], maxPods: maxPods, instanceType: fields[2]})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].name < nodes[j].name })
+	return nodes, nil
+}
+
+// checkMaxPods checks node's allocatable pods against the ENI limits.
+func checkMaxPods(nodes []node) error {
+	var errs []string
+	for _, n := range nodes {
+		v, ok := ec2.InstanceTypes[n.instanceType]
+		if !ok || v.MaxPods == 0 {
+			errs = append(errs, fmt.Sprintf("%s: unknown max pods for %q", n.name, n.instanceType))
+			continue
+		}
+		if n.maxPods != v.MaxPods {
+			errs = append(errs, fmt.Sprintf("%s: %q allocatable pods %d, expected %d", n.name, n.instanceType, n.maxPods, v.MaxPods))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func (md *embedded) TestIPAllocation() (err error) {
+	defer func() { md.setResult(&md.cfg.CNI.TestResultIPAllocation, err) }()
+
+	nodes, err := md.listNodes()
+	if err != nil {
+		return err
+	}
+	if err = checkMaxPods(nodes); err != nil {
+		return err
+	}
+
+	pods, err := md.listPods("")
+	if err != nil {
+		return err
+	}
+	if dup := duplicatePodIPs(pods); len(dup) > 0 {
+		return fmt.Errorf("duplicate pod IPs %v", dup)
+	}
+	md.lg.Info("pod IP allocation", zap.Int("nodes", len(nodes)), zap.Int("pods", len(pods)))
+	return nil
+}
+
+func (md *embedded) TestSNAT() (err error) {
+	defer func() { md.setResult(&md.cfg.CNI.TestResultSNAT, err) }()
+
+	pods, err := md.listPods("app=" + testServerName)
+	if err != nil {
+		return err
+	}
+	nodeToPublicIP := make(map[string]string)
+	for _, iv := range md.cfg.ClusterState.WorkerNodes {
+		nodeToPublicIP[iv.PrivateDNSName] = iv.PublicIP
+	}
+
+	var errs []string
+	for _, p := range pods {
+		out, perr := md.podExec(p.Name, "wget", "-q", "-O", "-", "-T", "10", md.cfg.CNI.TestSNATURL)
+		if perr != nil {
+			errs = append(errs, fmt.Sprintf("%s (%s): %v %q", p.Name, p.NodeName, perr, string(out)))
+			continue
+		}
+		// pod traffic to the internet must be SNATed to the node primary IP,
+		// and then to the node public IP by the internet gateway
+		srcIP := strings.TrimSpace(string(out))
+		if exp := nodeToPublicIP[p.NodeName]; exp != "" && srcIP != exp {
+			errs = append(errs, fmt.Sprintf("%s (%s): source IP %q, expected node public IP %q", p.Name, p.NodeName, srcIP, exp))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("SNAT failed: %s", strings.Join(errs, ", "))
+	}
+	md.lg.Info("SNAT", zap.Int("pods", len(pods)), zap.String("url", md.cfg.CNI.TestSNATURL))
+	return nil
+}
+
+func (md *embedded) TestWarmPool() (err error) {
+	defer func() { md.setResult(&md.cfg.CNI.TestResultWarmPool, err) }()
+
+	nodes, err := md.listNodes()
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return errors.New("no worker node found")
+	}
+	n := nodes[0]
+
+	pods, err := md.listPods("")
+	if err != nil {
+		return err
+	}
+	var used int64
+	for _, p := range pods {
+		if p.NodeName == n.name && p.Phase != "Succeeded" && p.Phase != "Failed" {
+			used++
+		}
+	}
+	podsN := int(n.maxPods - used)
+	if podsN <= 0 {
+		return fmt.Errorf("node %q has no room for pods (max %d, used %d)", n.name, n.maxPods, used)
+	}
+
+	spec, err := createWarmPoolSpec(md.cfg.CNI.TestImage, n.name, podsN)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	if err = md.applySpec(spec); err != nil {
+		return err
+	}
+	if _, err = md.waitPods("app="+testWarmPoolName, podsN); err != nil {
+		return err
+	}
+	took := time.Now().UTC().Sub(now)
+	md.cfg.CNI.TestResultWarmPoolScaleUpTook = took.String()
+
+	// "FailedCreatePodSandBox" events indicate IP allocation delays
+	// while ipamd attaches more ENIs to the node
+	out, err := md.run(time.Minute,
+		"get", "events",
+		"--namespace="+testNamespace,
+		"--field-selector=reason=FailedCreatePodSandBox",
+		"-o", "name",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get events %v (%q)", err, string(out))
+	}
+	sandboxFailures := len(strings.Fields(string(out)))
+
+	md.lg.Info("IP warm pool",
+		zap.String("node", n.name),
+		zap.String("instance-type", n.instanceType),
+		zap.Int("pods", podsN),
+		zap.Int64("max-pods", n.maxPods),
+		zap.Int("sandbox-failures", sandboxFailures),
+		zap.Duration("took", took),
+	)
+
+	out, err = md.run(5*time.Minute, "delete", "deployment", testWarmPoolName, "--namespace="+testNamespace)
+	if err != nil {
+		return fmt.Errorf("failed to delete warm pool deployment %v (%q)", err, string(out))
+	}
+	return nil
+}
+
+func (md *embedded) setResult(s *string, err error) {
+	if err != nil {
+		*s = "Failed: " + err.Error()
+	} else {
+		*s = "Passed"
+	}
+	md.cfg.Sync()
+}
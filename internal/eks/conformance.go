@@ -0,0 +1,81 @@
+package eks
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/internal/eks/conformance"
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+
+	"go.uber.org/zap"
+)
+
+// TestConformance runs the upstream Kubernetes conformance e2e suite
+// in the cluster, and copies the e2e log and junit results
+// to the artifact directory, if not empty.
+func (md *embedded) TestConformance(artifactDir string) error {
+	if md.cfg.Conformance == nil {
+		return errors.New("conformance test is not configured")
+	}
+	if md.cfg.Conformance.Image == "" {
+		return fmt.Errorf("no conformance image for Kubernetes version %q", md.cfg.KubernetesVersion)
+	}
+
+	rs, err := conformance.Test(conformance.Config{
+		Logger:     md.lg,
+		Run:        md.kubectlRun,
+		Stopc:      md.stopc,
+		Image:      md.cfg.Conformance.Image,
+		Focus:      md.cfg.Conformance.Focus,
+		Skip:       md.cfg.Conformance.Skip,
+		Parallel:   md.cfg.Conformance.Parallel,
+		Timeout:    md.cfg.Conformance.Timeout,
+		ResultsDir: md.cfg.Conformance.ResultsDir,
+	})
+	if err != nil {
+		md.cfg.Conformance.TestResultStatus = "Failed"
+		md.cfg.Sync()
+		return err
+	}
+
+	md.cfg.Conformance.TestResultStatus = rs.Status()
+	md.cfg.Conformance.TestResultTotal = rs.Total
+	md.cfg.Conformance.TestResultPassed = rs.Passed
+	md.cfg.Conformance.TestResultFailed = rs.Failed
+	md.cfg.Conformance.TestResultSkipped = rs.Skipped
+	md.cfg.Conformance.TestResultFailedTests = rs.FailedTests
+	md.cfg.Conformance.TestResultTook = rs.Took.String()
+	md.cfg.Sync()
+
+	fis, err := ioutil.ReadDir(md.cfg.Conformance.ResultsDir)
+	if err != nil {
+		return err
+	}
+	for _, fi := range fis {
+		p := filepath.Join(md.cfg.Conformance.ResultsDir, fi.Name())
+		if artifactDir != "" {
+			// junit files must be in the top-level artifact directory
+			// to be picked up by Prow and TestGrid
+			if err = fileutil.Copy(p, filepath.Join(artifactDir, fi.Name())); err != nil {
+				return err
+			}
+		}
+		if md.cfg.Conformance.UploadResults {
+			if err = md.s3Plugin.UploadToBucketForTests(
+				p,
+				filepath.Join(md.cfg.Conformance.ResultsDirBucket, fi.Name()),
+			); err != nil {
+				md.lg.Warn("failed to upload conformance result", zap.String("path", p), zap.Error(err))
+			}
+			time.Sleep(30 * time.Millisecond)
+		}
+	}
+
+	if rs.Status() != "Passed" {
+		return fmt.Errorf("conformance tests failed: %s", rs.String())
+	}
+	return nil
+}
@@ -0,0 +1,395 @@
+// Package conformance runs the upstream Kubernetes conformance e2e suite
+// inside the cluster, and downloads its e2e log and junit results.
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// Namespace is the namespace to run conformance tests in.
+	Namespace = "aws-k8s-tester-conformance"
+	podName   = "e2e"
+
+	// resultsDir is the results directory inside the conformance pod.
+	resultsDir = "/tmp/results"
+	// resultsImage is the sidecar image to keep the results
+	// volume available after the e2e container exits.
+	resultsImage = "busybox:1.29"
+)
+
+// Config defines conformance test configuration.
+type Config struct {
+	Logger *zap.Logger
+	// Run runs a "kubectl" command and returns its combined output.
+	Run func(ctx context.Context, args ...string) ([]byte, error)
+	// Stopc aborts the test wait.
+	Stopc chan struct{}
+
+	Image    string
+	Focus    string
+	Skip     string
+	Parallel bool
+	Timeout  time.Duration
+
+	// ResultsDir is the local directory to download results to.
+	ResultsDir string
+
+	// PollInterval is the interval to check the test completion.
+	// If zero, it is set to 30 seconds.
+	PollInterval time.Duration
+}
+
+// Result is the summary of junit results.
+type Result struct {
+	Total       int
+	Passed      int
+	Failed      int
+	Skipped     int
+	FailedTests []string
+	Took        time.Duration
+}
+
+// Status returns "Passed" if there is no failed test case.
+func (r Result) Status() string {
+	if r.Failed > 0 || r.Total == 0 {
+		return "Failed"
+	}
+	return "Passed"
+}
+
+func (r Result) String() string {
+	s := fmt.Sprintf("%s (total %d, passed %d, failed %d, skipped %d, took %v)",
+		r.Status(), r.Total, r.Passed, r.Failed, r.Skipped, r.Took)
+	for _, name := range r.FailedTests {
+		s += "\n  [FAIL] " + name
+	}
+	return s
+}
+
+// Test runs the conformance e2e image in the cluster, waits for its completion,
+// downloads the e2e log and junit results to "ResultsDir", and summarizes them.
+// Test resources are deleted afterwards.
+func Test(cfg Config) (rs Result, err error) {
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	if cfg.Run == nil {
+		return Result{}, errors.New("empty kubectl runner")
+	}
+	if cfg.Image == "" {
+		return Result{}, errors.New("empty conformance image")
+	}
+	if cfg.ResultsDir == "" {
+		return Result{}, errors.New("empty results directory")
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = 30 * time.Second
+	}
+	if err = os.MkdirAll(cfg.ResultsDir, 0700); err != nil {
+		return Result{}, err
+	}
+
+	spec, err := createSpec(cfg)
+	if err != nil {
+		return Result{}, err
+	}
+	p, err := fileutil.WriteTempFile([]byte(spec))
+	if err != nil {
+		return Result{}, err
+	}
+	defer os.RemoveAll(p)
+
+	start := time.Now().UTC()
+
+	var out []byte
+	out, err = run(cfg, time.Minute, "apply", "--filename="+p)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to create conformance pod %v (%q)", err, string(out))
+	}
+	cfg.Logger.Info("created conformance pod",
+		zap.String("image", cfg.Image),
+		zap.String("focus", cfg.Focus),
+		zap.String("skip", cfg.Skip),
+		zap.String("output", string(out)),
+	)
+	defer func() {
+		dout, derr := run(cfg, 5*time.Minute, "delete", "--filename="+p, "--ignore-not-found")
+		if derr != nil {
+			cfg.Logger.Warn("failed to delete conformance pod", zap.String("output", string(dout)), zap.Error(derr))
+		} else {
+			cfg.Logger.Info("deleted conformance pod", zap.String("output", string(dout)))
+		}
+	}()
+
+	if err = wait(cfg); err != nil {
+		return Result{}, err
+	}
+	if err = download(cfg); err != nil {
+		return Result{}, err
+	}
+
+	rs, err = ParseResultsDir(cfg.ResultsDir)
+	if err != nil {
+		return Result{}, err
+	}
+	rs.Took = time.Now().UTC().Sub(start)
+	cfg.Logger.Info("finished conformance tests", zap.String("result", rs.String()))
+	return rs, nil
+}
+
+func run(cfg Config, timeout time.Duration, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return cfg.Run(ctx, args...)
+}
+
+func execResults(cfg Config, args ...string) ([]byte, error) {
+	args = append([]string{"exec", "--namespace=" + Namespace, podName, "--container=results", "--"}, args...)
+	return run(cfg, 5*time.Minute, args...)
+}
+
+// wait waits until the e2e container writes the "done" file,
+// or exits without writing it.
+func wait(cfg Config) error {
+	start := time.Now().UTC()
+	for time.Now().UTC().Sub(start) < cfg.Timeout {
+		select {
+		case <-cfg.Stopc:
+			return errors.New("conformance test wait aborted")
+		case <-time.After(cfg.PollInterval):
+		}
+
+		if _, err := execResults(cfg, "cat", resultsDir+"/done"); err == nil {
+			cfg.Logger.Info("conformance tests completed", zap.Duration("took", time.Now().UTC().Sub(start)))
+			return nil
+		}
+
+		out, err := run(cfg, time.Minute,
+			"get", "pod", podName,
+			"--namespace="+Namespace,
+			"-o", `jsonpath={.status.containerStatuses[?(@.name=="e2e")].state.terminated.exitCode}`,
+		)
+		if err != nil {
+			cfg.Logger.Warn("failed to get conformance pod", zap.String("output", string(out)), zap.Error(err))
+			continue
+		}
+		if code := strings.TrimSpace(string(out)); code != "" {
+			// check once more in case "done" was written right before exit
+			if _, err = execResults(cfg, "cat", resultsDir+"/done"); err == nil {
+				return nil
+			}
+			logs, _ := run(cfg, time.Minute, "logs", "--namespace="+Namespace, podName, "--container=e2e", "--tail=30")
+			return fmt.Errorf("conformance e2e container exited with %s without results (%q)", code, string(logs))
+		}
+		cfg.Logger.Info("waiting for conformance tests", zap.Duration("elapsed", time.Now().UTC().Sub(start)))
+	}
+	return fmt.Errorf("conformance tests did not complete in %v", cfg.Timeout)
+}
+
+// download downloads the e2e log and junit results.
+func download(cfg Config) error {
+	out, err := execResults(cfg, "ls", resultsDir)
+	if err != nil {
+		return fmt.Errorf("failed to list conformance results %v (%q)", err, string(out))
+	}
+	n := 0
+	for _, name := range strings.Fields(string(out)) {
+		if name != "e2e.log" && !isJUnit(name) {
+			continue
+		}
+		var d []byte
+		d, err = execResults(cfg, "cat", resultsDir+"/"+name)
+		if err != nil {
+			return fmt.Errorf("failed to download %q %v", name, err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(cfg.ResultsDir, name), d, 0600); err != nil {
+			return err
+		}
+		cfg.Logger.Info("downloaded conformance result", zap.String("path", filepath.Join(cfg.ResultsDir, name)))
+		n++
+	}
+	if n == 0 {
+		return fmt.Errorf("no conformance result found in %q", string(out))
+	}
+	return nil
+}
+
+func isJUnit(name string) bool {
+	return strings.HasPrefix(name, "junit") && strings.HasSuffix(name, ".xml")
+}
+
+type junitTestSuite struct {
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name    string    `xml:"name,attr"`
+	Failure *struct{} `xml:"failure"`
+	Skipped *struct{} `xml:"skipped"`
+}
+
+// ParseResultsDir parses all junit files in the directory.
+func ParseResultsDir(dir string) (rs Result, err error) {
+	var fis []os.FileInfo
+	fis, err = ioutil.ReadDir(dir)
+	if err != nil {
+		return Result{}, err
+	}
+	found := false
+	for _, fi := range fis {
+		if fi.IsDir() || !isJUnit(fi.Name()) {
+			continue
+		}
+		var d []byte
+		d, err = ioutil.ReadFile(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return Result{}, err
+		}
+		if err = parseJUnit(d, &rs); err != nil {
+			return Result{}, fmt.Errorf("failed to parse %q (%v)", fi.Name(), err)
+		}
+		found = true
+	}
+	if !found {
+		return Result{}, fmt.Errorf("no junit file found in %q", dir)
+	}
+	sort.Strings(rs.FailedTests)
+	return rs, nil
+}
+
+func parseJUnit(d []byte, rs *Result) error {
+	var ts junitTestSuite
+	if err := xml.Unmarshal(d, &ts); err != nil {
+		return err
+	}
+	for _, tc := range ts.TestCases {
+		rs.Total++
+		switch {
+		case tc.Failure != nil:
+			rs.Failed++
+			rs.FailedTests = append(rs.FailedTests, tc.Name)
+		case tc.Skipped != nil:
+			rs.Skipped++
+		default:
+			rs.Passed++
+		}
+	}
+	return nil
+}
+
+func createSpec(cfg Config) (string, error) {
+	tpl := template.Must(template.New("conformanceTemplate").Parse(conformanceTemplate))
+	buf := bytes.NewBuffer(nil)
+	parallel := "n"
+	if cfg.Parallel {
+		parallel = "y"
+	}
+	if err := tpl.Execute(buf, struct {
+		Namespace    string
+		PodName      string
+		Image        string
+		Focus        string
+		Skip         string
+		Parallel     string
+		ResultsDir   string
+		ResultsImage string
+	}{
+		Namespace:    Namespace,
+		PodName:      podName,
+		Image:        cfg.Image,
+		Focus:        fmt.Sprintf("%q", cfg.Focus),
+		Skip:         fmt.Sprintf("%q", cfg.Skip),
+		Parallel:     parallel,
+		ResultsDir:   resultsDir,
+		ResultsImage: resultsImage,
+	}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// conformanceTemplate runs the e2e image with cluster-admin service account.
+// The "results" sidecar keeps the results volume readable with "kubectl exec"
+// after the e2e container exits.
+const conformanceTemplate = `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{ .Namespace }}
+
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: {{ .Namespace }}
+  namespace: {{ .Namespace }}
+
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: {{ .Namespace }}
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cluster-admin
+subjects:
+- kind: ServiceAccount
+  name: {{ .Namespace }}
+  namespace: {{ .Namespace }}
+
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: {{ .PodName }}
+  namespace: {{ .Namespace }}
+spec:
+  serviceAccountName: {{ .Namespace }}
+  restartPolicy: Never
+  containers:
+  - name: e2e
+    image: {{ .Image }}
+    imagePullPolicy: Always
+    env:
+    - name: E2E_FOCUS
+      value: {{ .Focus }}
+    - name: E2E_SKIP
+      value: {{ .Skip }}
+    - name: E2E_PARALLEL
+      value: "{{ .Parallel }}"
+    - name: RESULTS_DIR
+      value: {{ .ResultsDir }}
+    volumeMounts:
+    - name: results
+      mountPath: {{ .ResultsDir }}
+  - name: results
+    image: {{ .ResultsImage }}
+    command:
+    - sh
+    - -c
+    - while true; do sleep 3600; done
+    volumeMounts:
+    - name: results
+      mountPath: {{ .ResultsDir }}
+  volumes:
+  - name: results
+    emptyDir: {}
+
+`
@@ -0,0 +1,167 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testJUnit = `<?xml version="1.0" encoding="UTF-8"?>
+  <testsuite tests="4" failures="1" time="1516.3">
+      <testcase name="[sig-network] Services should serve a basic endpoint from pods  [Conformance]" classname="Kubernetes e2e suite" time="20.1"></testcase>
+      <testcase name="[sig-network] DNS should provide DNS for services  [Conformance]" classname="Kubernetes e2e suite" time="30.2">
+          <failure type="Failure">/go/src/k8s.io/kubernetes/test/e2e/network/dns_common.go:497
+Expected error:
+    timed out waiting for the condition</failure>
+      </testcase>
+      <testcase name="[sig-storage] Dynamic Provisioning [Feature:StorageProvider]" classname="Kubernetes e2e suite" time="0">
+          <skipped></skipped>
+      </testcase>
+      <testcase name="[sig-apps] Deployment deployment should support rollover [Conformance]" classname="Kubernetes e2e suite" time="25.3"></testcase>
+  </testsuite>`
+
+func TestParseResultsDir(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "conformance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err = ParseResultsDir(dir); err == nil {
+		t.Fatal("expected error for empty results")
+	}
+
+	if err = ioutil.WriteFile(filepath.Join(dir, "junit_01.xml"), []byte(testJUnit), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "e2e.log"), []byte("log"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	rs, err := ParseResultsDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := Result{
+		Total:       4,
+		Passed:      2,
+		Failed:      1,
+		Skipped:     1,
+		FailedTests: []string{"[sig-network] DNS should provide DNS for services  [Conformance]"},
+	}
+	if !reflect.DeepEqual(rs, exp) {
+		t.Fatalf("expected %+v, got %+v", exp, rs)
+	}
+	if rs.Status() != "Failed" {
+		t.Fatalf("expected 'Failed', got %q", rs.Status())
+	}
+}
+
+func TestTest(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "conformance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var spec string
+	polls, deleted := 0, false
+	run := func(ctx context.Context, args ...string) ([]byte, error) {
+		cmd := strings.Join(args, " ")
+		switch {
+		case strings.HasPrefix(cmd, "apply --filename="):
+			d, err := ioutil.ReadFile(strings.TrimPrefix(cmd, "apply --filename="))
+			if err != nil {
+				return nil, err
+			}
+			spec = string(d)
+			return []byte("pod/e2e created"), nil
+		case strings.HasPrefix(cmd, "delete --filename="):
+			deleted = true
+			return nil, nil
+		case strings.HasSuffix(cmd, "cat /tmp/results/done"):
+			polls++
+			if polls < 3 {
+				return []byte("No such file or directory"), errors.New("exit status 1")
+			}
+			return []byte("/tmp/results/e2e.tar.gz"), nil
+		case strings.HasPrefix(cmd, "get pod e2e"):
+			return nil, nil
+		case strings.HasSuffix(cmd, "ls /tmp/results"):
+			return []byte("done\ne2e.log\ne2e.tar.gz\njunit_01.xml\n"), nil
+		case strings.HasSuffix(cmd, "cat /tmp/results/junit_01.xml"):
+			return []byte(testJUnit), nil
+		case strings.HasSuffix(cmd, "cat /tmp/results/e2e.log"):
+			return []byte("Ran 3 of 1000 Specs"), nil
+		}
+		return nil, errors.New("unexpected command " + cmd)
+	}
+
+	rs, err := Test(Config{
+		Run:          run,
+		Image:        "gcr.io/heptio-images/kube-conformance:v1.11.5",
+		Focus:        `\[Conformance\]`,
+		Skip:         `Alpha|\[(Disruptive|Feature:[^\]]+|Flaky)\]`,
+		Timeout:      time.Minute,
+		ResultsDir:   dir,
+		PollInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rs.Total != 4 || rs.Failed != 1 {
+		t.Fatalf("unexpected result %+v", rs)
+	}
+	if !deleted {
+		t.Fatal("conformance pod not deleted")
+	}
+	if !strings.Contains(spec, `value: "\\[Conformance\\]"`) {
+		t.Fatalf("unexpected focus in spec %s", spec)
+	}
+	if !strings.Contains(spec, "image: gcr.io/heptio-images/kube-conformance:v1.11.5") {
+		t.Fatalf("unexpected image in spec %s", spec)
+	}
+	for _, name := range []string{"junit_01.xml", "e2e.log"} {
+		if _, err = os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = os.Stat(filepath.Join(dir, "e2e.tar.gz")); err == nil {
+		t.Fatal("unexpected e2e.tar.gz download")
+	}
+}
+
+func TestTestExitWithoutResults(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "conformance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	run := func(ctx context.Context, args ...string) ([]byte, error) {
+		cmd := strings.Join(args, " ")
+		switch {
+		case strings.HasSuffix(cmd, "cat /tmp/results/done"):
+			return nil, errors.New("exit status 1")
+		case strings.HasPrefix(cmd, "get pod e2e"):
+			return []byte("1"), nil
+		}
+		return nil, nil
+	}
+	_, err = Test(Config{
+		Run:          run,
+		Image:        "gcr.io/heptio-images/kube-conformance:v1.11.5",
+		Focus:        `\[Conformance\]`,
+		Timeout:      time.Minute,
+		ResultsDir:   dir,
+		PollInterval: time.Millisecond,
+	})
+	if err == nil || !strings.Contains(err.Error(), "exited with 1") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
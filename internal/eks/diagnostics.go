@@ -0,0 +1,34 @@
+package eks
+
+import (
+	"github.com/aws/aws-k8s-tester/internal/eks/diagnostics"
+
+	"go.uber.org/zap"
+)
+
+// collectDiagnostics collects pod logs, events, and resource descriptions
+// of all namespaces via Kubernetes API, since most ALB test failures
+// are only visible in pod logs, which node-level logs do not include.
+func (md *embedded) collectDiagnostics() error {
+	return diagnostics.Collect(diagnostics.Config{
+		Logger:         md.lg,
+		KubectlPath:    md.cfg.KubectlPath,
+		KubeConfigPath: md.cfg.KubeConfigPath,
+		OutputPath:     md.cfg.DiagnosticsOutputToUploadPath,
+		Prefix:         md.cfg.ClusterName + "-diagnostics",
+	})
+}
+
+func (md *embedded) uploadDiagnostics() error {
+	if err := md.collectDiagnostics(); err != nil {
+		return err
+	}
+	if err := md.s3Plugin.UploadToBucketForTests(
+		md.cfg.DiagnosticsOutputToUploadPath,
+		md.cfg.DiagnosticsOutputToUploadPathBucket,
+	); err != nil {
+		return err
+	}
+	md.lg.Info("uploaded cluster diagnostics", zap.String("url", md.cfg.DiagnosticsOutputToUploadPathURL))
+	return nil
+}
@@ -0,0 +1,473 @@
+// Package diagnostics collects cluster-wide diagnostics via Kubernetes API,
+// such as pod logs, events, and resource descriptions, into a tarball.
+package diagnostics
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/utils/exec"
+)
+
+// Config defines diagnostics collector configuration.
+type Config struct {
+	Logger *zap.Logger
+
+	// KubectlPath is the "kubectl" binary path.
+	KubectlPath string
+	// KubeConfigPath is the KUBECONFIG path.
+	KubeConfigPath string
+
+	// OutputPath is the tarball (.tar.gz) path to write.
+	OutputPath string
+	// Prefix is the top-level directory name inside the tarball.
+	// If empty, it is set to "diagnostics".
+	Prefix string
+
+	// Timeout is the timeout for each "kubectl" command.
+	// If zero, it is set to 30 seconds.
+	Timeout time.Duration
+}
+
+// Run is the function type to run a "kubectl" command,
+// and return its output.
+type Run func(ctx context.Context, args ...string) ([]byte, error)
+
+// Component defines a cluster add-on whose logs are collected
+// under "components/<name>" for quick access, in addition to the
+// per-namespace pod logs.
+type Component struct {
+	Name      string
+	Namespace string
+	// Labels are pod label key-value pairs to match.
+	Labels map[string]string
+	// Files are the container file paths to read with "kubectl exec"
+	// (e.g. host logs mounted into the container).
+	Files []string
+}
+
+// Components is the list of add-ons that EKS tests depend on.
+var Components = []Component{
+	{
+		Name:      "aws-node",
+		Namespace: "kube-system",
+		Labels:    map[string]string{"k8s-app": "aws-node"},
+		// https://github.com/aws/amazon-vpc-cni-k8s/blob/master/config/v1.3/aws-k8s-cni.yaml
+		Files: []string{
+			"/host/var/log/aws-routed-eni/ipamd.log",
+			"/host/var/log/aws-routed-eni/plugin.log",
+		},
+	},
+	{
+		Name:      "kube-proxy",
+		Namespace: "kube-system",
+		Labels:    map[string]string{"k8s-app": "kube-proxy"},
+	},
+	{
+		Name:      "coredns",
+		Namespace: "kube-system",
+		Labels:    map[string]string{"k8s-app": "kube-dns"},
+	},
+	{
+		Name:      "alb-ingress-controller",
+		Namespace: "kube-system",
+		Labels:    map[string]string{"app": "alb-ingress-controller"},
+	},
+}
+
+// clusterResources is the list of resources to list across all namespaces.
+// Secrets are excluded on purpose.
+var clusterResources = []string{
+	"nodes",
+	"namespaces",
+	"pods",
+	"deployments",
+	"daemonsets",
+	"replicasets",
+	"statefulsets",
+	"jobs",
+	"services",
+	"endpoints",
+	"ingresses",
+	"configmaps",
+	"serviceaccounts",
+	"persistentvolumes",
+	"persistentvolumeclaims",
+	"storageclasses",
+	"clusterroles",
+	"clusterrolebindings",
+	"roles",
+	"rolebindings",
+	"customresourcedefinitions",
+}
+
+// describeResources is the list of namespaced resources to describe.
+var describeResources = []string{
+	"pods",
+	"deployments",
+	"services",
+	"ingresses",
+}
+
+// Collect collects diagnostics using "kubectl" and writes the tarball.
+// Failed commands do not abort the collection. Instead, their errors
+// are recorded in "errors.txt" inside the tarball.
+func Collect(cfg Config) error {
+	if cfg.KubectlPath == "" {
+		return fmt.Errorf("empty kubectl path")
+	}
+	ex := exec.New()
+	run := func(ctx context.Context, args ...string) ([]byte, error) {
+		args = append([]string{"--kubeconfig=" + cfg.KubeConfigPath}, args...)
+		return ex.CommandContext(ctx, cfg.KubectlPath, args...).CombinedOutput()
+	}
+	return CollectWithRun(cfg, run)
+}
+
+// CollectWithRun collects diagnostics using the given command runner.
+func CollectWithRun(cfg Config, run Run) (err error) {
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	if cfg.OutputPath == "" {
+		return fmt.Errorf("empty output path")
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "diagnostics"
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if err = os.MkdirAll(filepath.Dir(cfg.OutputPath), 0700); err != nil {
+		return err
+	}
+
+	var f *os.File
+	f, err = os.Create(cfg.OutputPath)
+	if err != nil {
+		return err
+	}
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+
+	c := &collector{
+		cfg:  cfg,
+		run:  run,
+		tw:   tw,
+		now:  time.Now().UTC(),
+		errs: make([]string, 0),
+	}
+	err = c.collect()
+
+	if cerr := tw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := gw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.RemoveAll(cfg.OutputPath)
+		return err
+	}
+
+	cfg.Logger.Info("collected diagnostics",
+		zap.String("output-path", cfg.OutputPath),
+		zap.Int("files", c.files),
+		zap.Int("errors", len(c.errs)),
+	)
+	return nil
+}
+
+type collector struct {
+	cfg   Config
+	run   Run
+	tw    *tar.Writer
+	now   time.Time
+	files int
+	errs  []string
+}
+
+func (c *collector) collect() (err error) {
+	for _, v := range []struct {
+		name string
+		args []string
+	}{
+		{"cluster/version.txt", []string{"version"}},
+		{"cluster/cluster-info.txt", []string{"cluster-info"}},
+		{"cluster/api-resources.txt", []string{"api-resources", "-o", "wide"}},
+		{"cluster/api-versions.txt", []string{"api-versions"}},
+		{"cluster/nodes.describe.txt", []string{"describe", "nodes"}},
+		{"cluster/events.txt", []string{"get", "events", "--all-namespaces", "--sort-by=.lastTimestamp", "-o", "wide"}},
+	} {
+		if err = c.save(v.name, v.args...); err != nil {
+			return err
+		}
+	}
+	for _, res := range clusterResources {
+		if err = c.save(
+			"cluster/resources/"+res+".yaml",
+			"get", res, "--all-namespaces", "-o", "yaml",
+		); err != nil {
+			return err
+		}
+	}
+
+	var pods []pod
+	pods, err = c.listPods()
+	if err != nil {
+		c.record("list pods", err)
+		err = nil
+	}
+
+	var nss []string
+	nss, err = c.listNamespaces()
+	if err != nil {
+		c.record("list namespaces", err)
+		err = nil
+	}
+	for _, ns := range namespaces(nss, pods) {
+		if err = c.save(
+			"namespaces/"+ns+"/events.txt",
+			"get", "events", "--namespace="+ns, "--sort-by=.lastTimestamp", "-o", "wide",
+		); err != nil {
+			return err
+		}
+		for _, res := range describeResources {
+			if err = c.save(
+				"namespaces/"+ns+"/"+res+".describe.txt",
+				"describe", res, "--namespace="+ns,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, p := range pods {
+		dir := "namespaces/" + p.Namespace + "/pods/" + p.Name
+		var comps []Component
+		for _, comp := range Components {
+			if comp.match(p) {
+				comps = append(comps, comp)
+			}
+		}
+		for _, ct := range p.containers() {
+			// previous logs only exist for restarted containers
+			suffixes := []string{".log"}
+			if ct.restarts > 0 {
+				suffixes = append(suffixes, ".previous.log")
+			}
+			for _, suffix := range suffixes {
+				args := []string{"logs", "--namespace=" + p.Namespace, p.Name, "--container=" + ct.name, "--timestamps"}
+				if suffix == ".previous.log" {
+					args = append(args, "--previous")
+				}
+				var out []byte
+				out, err = c.output(args...)
+				if err != nil {
+					c.record(strings.Join(args, " "), err)
+				}
+				if err = c.add(dir+"/"+ct.name+suffix, out); err != nil {
+					return err
+				}
+				for _, comp := range comps {
+					if err = c.add("components/"+comp.Name+"/"+p.Name+"."+ct.name+suffix, out); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		for _, comp := range comps {
+			for _, fpath := range comp.Files {
+				args := []string{"exec", "--namespace=" + p.Namespace, p.Name, "--", "cat", fpath}
+				if err = c.save("components/"+comp.Name+"/"+p.Name+"."+filepath.Base(fpath), args...); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if len(c.errs) > 0 {
+		err = c.add("errors.txt", []byte(strings.Join(c.errs, "\n")+"\n"))
+	}
+	return err
+}
+
+// save runs the command, and writes its output to the tarball
+// even if the command fails.
+func (c *collector) save(name string, args ...string) error {
+	out, err := c.output(args...)
+	if err != nil {
+		c.record(strings.Join(args, " "), err)
+	}
+	return c.add(name, out)
+}
+
+func (c *collector) output(args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.Timeout)
+	out, err := c.run(ctx, args...)
+	cancel()
+	return out, err
+}
+
+func (c *collector) record(cmd string, err error) {
+	c.cfg.Logger.Debug("diagnostics command failed", zap.String("command", cmd), zap.Error(err))
+	c.errs = append(c.errs, fmt.Sprintf("kubectl %s: %v", cmd, err))
+}
+
+func (c *collector) add(name string, d []byte) error {
+	if err := c.tw.WriteHeader(&tar.Header{
+		Name:    c.cfg.Prefix + "/" + name,
+		Mode:    0600,
+		Size:    int64(len(d)),
+		ModTime: c.now,
+	}); err != nil {
+		return err
+	}
+	if _, err := c.tw.Write(d); err != nil {
+		return err
+	}
+	c.files++
+	return nil
+}
+
+func (c *collector) listPods() ([]pod, error) {
+	out, err := c.output("get", "pods", "--all-namespaces", "-o", "json")
+	if err != nil {
+		return nil, fmt.Errorf("%v (%q)", err, string(out))
+	}
+	return parsePods(out)
+}
+
+func (c *collector) listNamespaces() ([]string, error) {
+	out, err := c.output("get", "namespaces", "-o", "jsonpath={.items[*].metadata.name}")
+	if err != nil {
+		return nil, fmt.Errorf("%v (%q)", err, string(out))
+	}
+	return strings.Fields(string(out)), nil
+}
+
+type pod struct {
+	Namespace string
+	Name      string
+	Labels    map[string]string
+
+	initContainers []string
+	specContainers []string
+	restarts       map[string]int
+}
+
+type container struct {
+	name     string
+	restarts int
+}
+
+func (p pod) containers() (cs []container) {
+	for _, name := range append(append([]string{}, p.initContainers...), p.specContainers...) {
+		cs = append(cs, container{name: name, restarts: p.restarts[name]})
+	}
+	return cs
+}
+
+func (comp Component) match(p pod) bool {
+	if comp.Namespace != "" && comp.Namespace != p.Namespace {
+		return false
+	}
+	if len(comp.Labels) == 0 {
+		return false
+	}
+	for k, v := range comp.Labels {
+		if p.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+type podList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string            `json:"name"`
+			Namespace string            `json:"namespace"`
+			Labels    map[string]string `json:"labels"`
+		} `json:"metadata"`
+		Spec struct {
+			InitContainers []struct {
+				Name string `json:"name"`
+			} `json:"initContainers"`
+			Containers []struct {
+				Name string `json:"name"`
+			} `json:"containers"`
+		} `json:"spec"`
+		Status struct {
+			InitContainerStatuses []containerStatus `json:"initContainerStatuses"`
+			ContainerStatuses     []containerStatus `json:"containerStatuses"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+type containerStatus struct {
+	Name         string `json:"name"`
+	RestartCount int    `json:"restartCount"`
+}
+
+func parsePods(d []byte) ([]pod, error) {
+	var pl podList
+	if err := json.Unmarshal(d, &pl); err != nil {
+		return nil, err
+	}
+	pods := make([]pod, 0, len(pl.Items))
+	for _, item := range pl.Items {
+		p := pod{
+			Namespace: item.Metadata.Namespace,
+			Name:      item.Metadata.Name,
+			Labels:    item.Metadata.Labels,
+			restarts:  make(map[string]int),
+		}
+		for _, ct := range item.Spec.InitContainers {
+			p.initContainers = append(p.initContainers, ct.Name)
+		}
+		for _, ct := range item.Spec.Containers {
+			p.specContainers = append(p.specContainers, ct.Name)
+		}
+		for _, st := range append(item.Status.InitContainerStatuses, item.Status.ContainerStatuses...) {
+			p.restarts[st.Name] = st.RestartCount
+		}
+		pods = append(pods, p)
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+	return pods, nil
+}
+
+// namespaces returns the sorted union of the listed namespaces and
+// the namespaces of the pods, always including "default" and "kube-system".
+func namespaces(listed []string, pods []pod) (nss []string) {
+	m := map[string]struct{}{"default": {}, "kube-system": {}}
+	for _, ns := range listed {
+		m[ns] = struct{}{}
+	}
+	for _, p := range pods {
+		m[p.Namespace] = struct{}{}
+	}
+	for ns := range m {
+		nss = append(nss, ns)
+	}
+	sort.Strings(nss)
+	return nss
+}
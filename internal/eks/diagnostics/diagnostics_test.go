@@ -0,0 +1,145 @@
+package diagnostics
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPods = `{
+  "items": [
+    {
+      "metadata": {"name": "aws-node-abcde", "namespace": "kube-system", "labels": {"k8s-app": "aws-node"}},
+      "spec": {"containers": [{"name": "aws-node"}]},
+      "status": {"containerStatuses": [{"name": "aws-node", "restartCount": 2}]}
+    },
+    {
+      "metadata": {"name": "ingress-test-server-1", "namespace": "default", "labels": {"app": "ingress-test-server"}},
+      "spec": {"initContainers": [{"name": "init"}], "containers": [{"name": "server"}]},
+      "status": {"containerStatuses": [{"name": "server", "restartCount": 0}]}
+    }
+  ]
+}`
+
+func TestCollect(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "diagnostics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	run := func(ctx context.Context, args ...string) ([]byte, error) {
+		cmd := strings.Join(args, " ")
+		switch {
+		case cmd == "get pods --all-namespaces -o json":
+			return []byte(testPods), nil
+		case cmd == "get namespaces -o jsonpath={.items[*].metadata.name}":
+			return []byte("default kube-public kube-system"), nil
+		case strings.HasPrefix(cmd, "describe ingresses"):
+			return []byte("No resources found."), errors.New("exit status 1")
+		}
+		return []byte("output of " + cmd), nil
+	}
+
+	p := filepath.Join(dir, "diagnostics.tar.gz")
+	if err = CollectWithRun(Config{OutputPath: p, Prefix: "test"}, run); err != nil {
+		t.Fatal(err)
+	}
+
+	files := readTarball(t, p)
+	for _, name := range []string{
+		"test/cluster/api-resources.txt",
+		"test/cluster/nodes.describe.txt",
+		"test/cluster/events.txt",
+		"test/cluster/resources/ingresses.yaml",
+		"test/namespaces/kube-public/events.txt",
+		"test/namespaces/default/pods.describe.txt",
+		"test/namespaces/default/ingresses.describe.txt",
+		"test/namespaces/default/pods/ingress-test-server-1/init.log",
+		"test/namespaces/default/pods/ingress-test-server-1/server.log",
+		"test/namespaces/kube-system/pods/aws-node-abcde/aws-node.log",
+		"test/namespaces/kube-system/pods/aws-node-abcde/aws-node.previous.log",
+		"test/components/aws-node/aws-node-abcde.aws-node.log",
+		"test/components/aws-node/aws-node-abcde.aws-node.previous.log",
+		"test/components/aws-node/aws-node-abcde.ipamd.log",
+		"test/errors.txt",
+	} {
+		if _, ok := files[name]; !ok {
+			t.Fatalf("%q not found in %v", name, files)
+		}
+	}
+	for _, name := range []string{
+		"test/namespaces/default/pods/ingress-test-server-1/server.previous.log",
+		"test/cluster/resources/secrets.yaml",
+	} {
+		if _, ok := files[name]; ok {
+			t.Fatalf("unexpected %q", name)
+		}
+	}
+	if !strings.Contains(files["test/errors.txt"], "kubectl describe ingresses --namespace=default: exit status 1") {
+		t.Fatalf("unexpected errors.txt %q", files["test/errors.txt"])
+	}
+	exp := "output of logs --namespace=kube-system aws-node-abcde --container=aws-node --timestamps --previous"
+	if v := files["test/components/aws-node/aws-node-abcde.aws-node.previous.log"]; v != exp {
+		t.Fatalf("expected %q, got %q", exp, v)
+	}
+}
+
+func TestCollectListPodsFail(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "diagnostics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	run := func(ctx context.Context, args ...string) ([]byte, error) {
+		return []byte("Unable to connect to the server"), errors.New("exit status 1")
+	}
+	p := filepath.Join(dir, "diagnostics.tar.gz")
+	if err = CollectWithRun(Config{OutputPath: p}, run); err != nil {
+		t.Fatal(err)
+	}
+	files := readTarball(t, p)
+	if _, ok := files["diagnostics/namespaces/kube-system/events.txt"]; !ok {
+		t.Fatalf("expected default namespaces, got %v", files)
+	}
+	if !strings.Contains(files["diagnostics/errors.txt"], "list pods") {
+		t.Fatalf("unexpected errors.txt %q", files["diagnostics/errors.txt"])
+	}
+}
+
+func readTarball(t *testing.T, p string) map[string]string {
+	f, err := os.Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	files := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[hdr.Name] = string(d)
+	}
+	return files
+}
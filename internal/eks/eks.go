@@ -39,8 +39,10 @@ func (md *embedded) createCluster() error {
 		Version: aws.String(md.cfg.KubernetesVersion),
 		RoleArn: aws.String(md.cfg.ClusterState.ServiceRoleWithPolicyARN),
 		ResourcesVpcConfig: &awseks.VpcConfigRequest{
-			SubnetIds:        aws.StringSlice(md.cfg.SubnetIDs),
-			SecurityGroupIds: aws.StringSlice([]string{md.cfg.SecurityGroupID}),
+			SubnetIds:             aws.StringSlice(md.cfg.SubnetIDs),
+			SecurityGroupIds:      aws.StringSlice([]string{md.cfg.SecurityGroupID}),
+			EndpointPrivateAccess: aws.Bool(md.cfg.VPC.EndpointPrivateAccess),
+			EndpointPublicAccess:  aws.Bool(!md.cfg.VPC.DisableEndpointPublicAccess),
 		},
 	})
 	if err != nil {
@@ -1,11 +1,20 @@
 // Package s3 implements S3 plugin.
 package s3
 
+import "time"
+
 // Plugin defines S3 plugin.
 type Plugin interface {
 	CreateBucketForAccessLogs() error
 	BucketForAccessLogs() string
+	// DownloadAccessLogs downloads access log objects under the prefix
+	// that were delivered since the given time, decompresses them into
+	// the directory, and returns the local file paths.
+	// Object keys in "fetched" are skipped, and downloaded keys are added to it.
+	// On error, it returns the local file paths downloaded so far.
+	DownloadAccessLogs(prefix string, since time.Time, dir string, fetched map[string]bool) ([]string, error)
 	BucketForTests() string
+	// UploadToBucketForTests uploads a local file to the artifact store.
 	UploadToBucketForTests(localPath, s3Path string) error
 	DeleteBucket(bucket string) error
 }
@@ -1,15 +1,18 @@
 package s3
 
 import (
-	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/artifact"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
@@ -26,18 +29,21 @@ type embedded struct {
 	cfg      *eksconfig.Config
 	s3       s3iface.S3API
 	existing map[string]struct{}
+	store    artifact.Store
 
 	bucketForTests      string
 	bucketForAccessLogs string
 }
 
 // NewEmbedded creates a new Plugin using AWS CLI.
-func NewEmbedded(lg *zap.Logger, cfg *eksconfig.Config, s3 s3iface.S3API) Plugin {
+// Test artifacts are uploaded to the artifact store.
+func NewEmbedded(lg *zap.Logger, cfg *eksconfig.Config, s3 s3iface.S3API, store artifact.Store) Plugin {
 	return &embedded{
 		lg:                  lg,
 		cfg:                 cfg,
 		s3:                  s3,
 		existing:            make(map[string]struct{}),
+		store:               store,
 		bucketForTests:      cfg.Tag,
 		bucketForAccessLogs: cfg.Tag + "-access-logs",
 	}
@@ -139,122 +145,7 @@ func (md *embedded) BucketForAccessLogs() string {
 }
 
 func (md *embedded) UploadToBucketForTests(localPath, s3Path string) error {
-	md.mu.Lock()
-	defer md.mu.Unlock()
-
-	bucket := md.bucketForTests
-
-	_, ok := md.existing[bucket]
-	var err error
-	if !ok {
-		for i := 0; i < 30; i++ {
-			retry := false
-			_, err = md.s3.CreateBucket(&s3.CreateBucketInput{
-				Bucket: aws.String(bucket),
-				CreateBucketConfiguration: &s3.CreateBucketConfiguration{
-					LocationConstraint: aws.String(md.cfg.AWSRegion),
-				},
-				// https://docs.aws.amazon.com/AmazonS3/latest/dev/acl-overview.html#canned-acl
-				// vs. "public-read"
-				ACL: aws.String("private"),
-			})
-			if err != nil {
-				exist := false
-				// https://docs.aws.amazon.com/AWSEC2/latest/APIReference/errors-overview.html
-				if aerr, ok := err.(awserr.Error); ok {
-					switch aerr.Code() {
-					case s3.ErrCodeBucketAlreadyExists:
-						md.lg.Warn("bucket already exists", zap.String("bucket", bucket), zap.Error(err))
-						exist, err = true, nil
-					case s3.ErrCodeBucketAlreadyOwnedByYou:
-						md.lg.Warn("bucket already owned by me", zap.String("bucket", bucket), zap.Error(err))
-						exist, err = true, nil
-					default:
-						if strings.Contains(err.Error(), "OperationAborted: A conflicting conditional operation is currently in progress against this resource. Please try again.") ||
-							request.IsErrorRetryable(err) ||
-							request.IsErrorThrottle(err) {
-							retry = true
-						} else {
-							md.lg.Warn("failed to create bucket", zap.String("bucket", bucket), zap.String("code", aerr.Code()), zap.Error(err))
-							return err
-						}
-					}
-				}
-				if !retry && !exist {
-					return err
-				}
-				if err != nil {
-					md.lg.Warn("retrying S3 bucket creation", zap.Error(err))
-					time.Sleep(5 * time.Second)
-					continue
-				}
-			}
-
-			h, _ := os.Hostname()
-			tags := []*s3.Tag{{Key: aws.String("HOSTNAME"), Value: aws.String(h)}}
-			if md.cfg.Tag != "" && md.cfg.ClusterName != "" {
-				tags = append(tags, &s3.Tag{Key: aws.String(md.cfg.Tag), Value: aws.String(md.cfg.ClusterName)})
-			}
-			_, err = md.s3.PutBucketTagging(&s3.PutBucketTaggingInput{
-				Bucket:  aws.String(bucket),
-				Tagging: &s3.Tagging{TagSet: tags},
-			})
-			if err != nil {
-				return err
-			}
-
-			if md.cfg.UploadBucketExpireDays > 0 {
-				if err = md.addLifecycle(bucket, md.cfg.UploadBucketExpireDays); err != nil {
-					return err
-				}
-			}
-
-			break
-		}
-
-		md.existing[bucket] = struct{}{}
-		md.lg.Info("created bucket", zap.String("bucket", bucket))
-	}
-
-	var d []byte
-	d, err = ioutil.ReadFile(localPath)
-	if err != nil {
-		return err
-	}
-
-	h, _ := os.Hostname()
-	_, err = md.s3.PutObject(&s3.PutObjectInput{
-		Bucket:  aws.String(bucket),
-		Key:     aws.String(s3Path),
-		Body:    bytes.NewReader(d),
-		Expires: aws.Time(time.Now().UTC().Add(24 * time.Hour)),
-
-		// https://docs.aws.amazon.com/AmazonS3/latest/dev/acl-overview.html#canned-acl
-		// vs. "public-read"
-		ACL: aws.String("private"),
-
-		Metadata: map[string]*string{
-			md.cfg.Tag: aws.String(bucket),
-			"HOSTNAME": aws.String(h),
-		},
-	})
-	if err == nil {
-		md.lg.Debug("uploaded",
-			zap.String("bucket", bucket),
-			zap.String("local-path", localPath),
-			zap.String("remote-path", s3Path),
-			zap.String("size", humanize.Bytes(uint64(len(d)))),
-		)
-	} else {
-		md.lg.Warn("failed to upload",
-			zap.String("bucket", bucket),
-			zap.String("local-path", localPath),
-			zap.String("remote-path", s3Path),
-			zap.String("size", humanize.Bytes(uint64(len(d)))),
-			zap.Error(err),
-		)
-	}
-	return err
+	return md.store.Upload(localPath, s3Path)
 }
 
 func (md *embedded) DeleteBucket(bucket string) error {
@@ -316,3 +207,80 @@ func (md *embedded) addLifecycle(bucket string, days int) error {
 	_, err := md.s3.PutBucketLifecycleConfiguration(input)
 	return err
 }
+
+func (md *embedded) DownloadAccessLogs(prefix string, since time.Time, dir string, fetched map[string]bool) (ps []string, err error) {
+	md.mu.RLock()
+	defer md.mu.RUnlock()
+
+	bucket := md.bucketForAccessLogs
+
+	var keys []string
+	err = md.s3.ListObjectsV2Pages(
+		&s3.ListObjectsV2Input{
+			Bucket: aws.String(bucket),
+			Prefix: aws.String(prefix),
+		},
+		func(out *s3.ListObjectsV2Output, lastPage bool) bool {
+			for _, obj := range out.Contents {
+				if aws.TimeValue(obj.LastModified).Before(since) || fetched[aws.StringValue(obj.Key)] {
+					continue
+				}
+				keys = append(keys, aws.StringValue(obj.Key))
+			}
+			return true
+		},
+	)
+	if err != nil {
+		md.lg.Warn("failed to list access logs", zap.String("bucket", bucket), zap.String("prefix", prefix), zap.Error(err))
+		return nil, err
+	}
+
+	for _, key := range keys {
+		var out *s3.GetObjectOutput
+		out, err = md.s3.GetObject(&s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			md.lg.Warn("failed to download access log", zap.String("bucket", bucket), zap.String("key", key), zap.Error(err))
+			return ps, err
+		}
+
+		var rd io.Reader = out.Body
+		p := filepath.Join(dir, filepath.Base(key))
+		if strings.HasSuffix(key, ".gz") {
+			var gr *gzip.Reader
+			gr, err = gzip.NewReader(out.Body)
+			if err != nil {
+				out.Body.Close()
+				return ps, fmt.Errorf("failed to decompress %q (%v)", key, err)
+			}
+			rd = gr
+			p = strings.TrimSuffix(p, ".gz")
+		}
+		var d []byte
+		d, err = ioutil.ReadAll(rd)
+		out.Body.Close()
+		if err != nil {
+			return ps, fmt.Errorf("failed to read %q (%v)", key, err)
+		}
+		if err = ioutil.WriteFile(p, d, 0600); err != nil {
+			return ps, err
+		}
+		md.lg.Debug("downloaded",
+			zap.String("bucket", bucket),
+			zap.String("remote-path", key),
+			zap.String("local-path", p),
+			zap.String("size", humanize.Bytes(uint64(len(d)))),
+		)
+		ps = append(ps, p)
+		fetched[key] = true
+	}
+
+	md.lg.Info("downloaded access logs",
+		zap.String("bucket", bucket),
+		zap.String("prefix", prefix),
+		zap.Int("objects", len(ps)),
+	)
+	return ps, nil
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/artifact"
 	"github.com/aws/aws-k8s-tester/pkg/awsapi"
 
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -36,7 +37,16 @@ func TestEmbedded(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	sp := NewEmbedded(zap.NewExample(), cfg, s3.New(ss))
+	st, err := artifact.NewS3(zap.NewExample(), artifact.Config{
+		Kind:   artifact.KindS3,
+		S3API:  s3.New(ss),
+		Region: cfg.AWSRegion,
+		Bucket: cfg.Tag,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp := NewEmbedded(zap.NewExample(), cfg, s3.New(ss), st)
 	if err = sp.CreateBucketForAccessLogs(); err != nil {
 		t.Fatal(err)
 	}
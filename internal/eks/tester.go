@@ -22,7 +22,9 @@ import (
 	"github.com/aws/aws-k8s-tester/internal/eks/alb"
 	"github.com/aws/aws-k8s-tester/internal/eks/alb/ingress/client"
 	"github.com/aws/aws-k8s-tester/internal/eks/alb/ingress/path"
+	"github.com/aws/aws-k8s-tester/internal/eks/cni"
 	"github.com/aws/aws-k8s-tester/internal/eks/s3"
+	"github.com/aws/aws-k8s-tester/pkg/artifact"
 	"github.com/aws/aws-k8s-tester/pkg/awsapi"
 	"github.com/aws/aws-k8s-tester/pkg/fileutil"
 	"github.com/aws/aws-k8s-tester/pkg/httputil"
@@ -75,10 +77,12 @@ type embedded struct {
 
 	ec2InstancesLogMu *sync.RWMutex
 
+	store    artifact.Store
 	s3Plugin s3.Plugin
 
 	// for plugins, sub-project implementation
 	albPlugin alb.Plugin
+	cniPlugin cni.Plugin
 
 	// TODO: add EBS (with CSI) plugin
 	// TODO: add KMS plugin
@@ -217,17 +221,46 @@ func newTesterEmbedded(cfg *eksconfig.Config) (ekstester.Tester, error) {
 	if len(md.cfg.Tag) > 42 {
 		md.cfg.Tag = md.cfg.Tag[:42]
 	}
-	md.cfg.LogOutputToUploadPathURL = genS3URL(md.cfg.AWSRegion, md.cfg.Tag, md.cfg.LogOutputToUploadPathBucket)
-	md.cfg.ConfigPathURL = genS3URL(md.cfg.AWSRegion, md.cfg.Tag, md.cfg.ConfigPathBucket)
-	md.cfg.KubeConfigPathURL = genS3URL(md.cfg.AWSRegion, md.cfg.Tag, md.cfg.KubeConfigPathBucket)
+
+	s3API := awss3.New(md.ss)
+	tags := map[string]string{}
+	if md.cfg.Tag != "" && md.cfg.ClusterName != "" {
+		tags[md.cfg.Tag] = md.cfg.ClusterName
+	}
+	md.store, err = artifact.New(md.lg, artifact.Config{
+		Kind:       md.cfg.ArtifactStore,
+		S3API:      s3API,
+		Region:     md.cfg.AWSRegion,
+		Bucket:     md.cfg.Tag,
+		Tags:       tags,
+		ExpireDays: md.cfg.UploadBucketExpireDays,
+		Dir:        md.cfg.ArtifactStoreDir,
+		URL:        md.cfg.ArtifactStoreURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	md.store = artifact.WithManifest(md.store, md.cfg.ArtifactManifestPath, md.cfg.ArtifactManifestPathBucket)
+
+	md.cfg.LogOutputToUploadPathURL = md.store.URL(md.cfg.LogOutputToUploadPathBucket)
+	md.cfg.ConfigPathURL = md.store.URL(md.cfg.ConfigPathBucket)
+	md.cfg.KubeConfigPathURL = md.store.URL(md.cfg.KubeConfigPathBucket)
+	md.cfg.ArtifactManifestPathURL = md.store.URL(md.cfg.ArtifactManifestPathBucket)
+	md.cfg.DiagnosticsOutputToUploadPathURL = md.store.URL(md.cfg.DiagnosticsOutputToUploadPathBucket)
+	if md.cfg.Conformance != nil {
+		md.cfg.Conformance.ResultsDirURL = md.store.URL(md.cfg.Conformance.ResultsDirBucket)
+	}
 	if md.cfg.ALBIngressController != nil {
-		md.cfg.ALBIngressController.IngressTestServerDeploymentServiceSpecPathURL = genS3URL(md.cfg.AWSRegion, md.cfg.Tag, md.cfg.ALBIngressController.IngressTestServerDeploymentServiceSpecPathBucket)
-		md.cfg.ALBIngressController.IngressControllerSpecPathURL = genS3URL(md.cfg.AWSRegion, md.cfg.Tag, md.cfg.ALBIngressController.IngressControllerSpecPathBucket)
-		md.cfg.ALBIngressController.IngressObjectSpecPathURL = genS3URL(md.cfg.AWSRegion, md.cfg.Tag, md.cfg.ALBIngressController.IngressObjectSpecPathBucket)
-		md.cfg.ALBIngressController.ScalabilityOutputToUploadPathURL = genS3URL(md.cfg.AWSRegion, md.cfg.Tag, md.cfg.ALBIngressController.ScalabilityOutputToUploadPathBucket)
-		md.cfg.ALBIngressController.MetricsOutputToUploadPathURL = genS3URL(md.cfg.AWSRegion, md.cfg.Tag, md.cfg.ALBIngressController.MetricsOutputToUploadPathBucket)
+		md.cfg.ALBIngressController.IngressTestServerDeploymentServiceSpecPathURL = md.store.URL(md.cfg.ALBIngressController.IngressTestServerDeploymentServiceSpecPathBucket)
+		md.cfg.ALBIngressController.IngressControllerSpecPathURL = md.store.URL(md.cfg.ALBIngressController.IngressControllerSpecPathBucket)
+		md.cfg.ALBIngressController.IngressObjectSpecPathURL = md.store.URL(md.cfg.ALBIngressController.IngressObjectSpecPathBucket)
+		md.cfg.ALBIngressController.ScalabilityOutputToUploadPathURL = md.store.URL(md.cfg.ALBIngressController.ScalabilityOutputToUploadPathBucket)
+		md.cfg.ALBIngressController.MetricsOutputToUploadPathURL = md.store.URL(md.cfg.ALBIngressController.MetricsOutputToUploadPathBucket)
+		md.cfg.ALBIngressController.LoadGeneratorSpecPathURL = md.store.URL(md.cfg.ALBIngressController.LoadGeneratorSpecPathBucket)
+		md.cfg.ALBIngressController.AccessLogsOutputToUploadPathURL = md.store.URL(md.cfg.ALBIngressController.AccessLogsOutputToUploadPathBucket)
 	}
-	md.s3Plugin = s3.NewEmbedded(md.lg, md.cfg, awss3.New(md.ss))
+	md.s3Plugin = s3.NewEmbedded(md.lg, md.cfg, s3API, md.store)
+	md.cniPlugin = cni.NewEmbedded(md.stopc, lg, md.cfg, md.cfg.KubectlPath)
 
 	if cfg.ALBIngressController.Enable {
 		md.albPlugin = alb.NewEmbedded(md.stopc, lg, md.cfg, md.cfg.KubectlPath, md.im, md.ec2, elbv2.New(md.ss), md.s3Plugin)
@@ -270,23 +303,12 @@ func newTesterEmbedded(cfg *eksconfig.Config) (ekstester.Tester, error) {
 		StackName: aws.String(md.cfg.ClusterState.CFStackVPCName),
 	})
 	if err == nil && len(do.Stacks) == 1 {
-		for _, op := range do.Stacks[0].Outputs {
-			if *op.OutputKey == "VpcId" {
-				md.cfg.VPCID = *op.OutputValue
-				lg.Info("found existing VPC stack VPC ID", zap.String("id", md.cfg.VPCID))
-				continue
-			}
-			if *op.OutputKey == "SubnetIds" {
-				vv := *op.OutputValue
-				md.cfg.SubnetIDs = strings.Split(vv, ",")
-				lg.Info("found existing VPC stack Subnet IDs", zap.Strings("ids", md.cfg.SubnetIDs))
-				continue
-			}
-			if *op.OutputKey == "SecurityGroups" {
-				md.cfg.SecurityGroupID = *op.OutputValue
-				lg.Info("found existing VPC stack security group", zap.String("id", md.cfg.SecurityGroupID))
-			}
-		}
+		md.updateVPCStackOutputs(do.Stacks[0].Outputs)
+		lg.Info("found existing VPC stack",
+			zap.String("vpc-id", md.cfg.VPCID),
+			zap.Strings("subnet-ids", md.cfg.SubnetIDs),
+			zap.String("security-group-id", md.cfg.SecurityGroupID),
+		)
 	}
 
 	// to connect to an existing cluster
@@ -410,7 +432,7 @@ func (md *embedded) Up() (err error) {
 	if err = catchStopc(md.lg, md.stopc, termChan, md.createCluster); err != nil {
 		return err
 	}
-	if err = catchStopc(md.lg, md.stopc, termChan, md.upgradeCNI); err != nil {
+	if err = catchStopc(md.lg, md.stopc, termChan, md.cniPlugin.Apply); err != nil {
 		return err
 	}
 	if err = catchStopc(md.lg, md.stopc, termChan, md.createKeyPair); err != nil {
@@ -429,6 +451,12 @@ func (md *embedded) Up() (err error) {
 	md.cfg.Sync()
 	md.cfg.SetClusterUpTook(time.Now().UTC().Sub(now))
 
+	if md.cfg.CNI.Test {
+		if err = md.TestCNI(); err != nil {
+			return err
+		}
+	}
+
 	if md.cfg.UploadWorkerNodeLogs {
 		if err = md.uploadWorkerNodeLogs(); err != nil {
 			md.lg.Warn("failed to upload worker node logs", zap.Error(err))
@@ -528,6 +556,15 @@ func (md *embedded) down() (err error) {
 			md.lg.Warn("failed to upload worker node logs", zap.Error(err))
 		}
 	}
+	if md.cfg.UploadDiagnostics {
+		md.lg.Info(
+			"uploading cluster diagnostics before shutdown",
+			zap.String("cluster-name", md.cfg.ClusterName),
+		)
+		if err = md.uploadDiagnostics(); err != nil {
+			md.lg.Warn("failed to upload cluster diagnostics", zap.Error(err))
+		}
+	}
 
 	md.lg.Info("Down", zap.String("cluster-name", md.cfg.ClusterName))
 	var errs []string
@@ -597,6 +634,8 @@ func (md *embedded) down() (err error) {
 }
 
 // IsUp returns an error if the cluster is not up and running.
+// With Spot worker nodes, it also checks interruption notices,
+// and waits for replacement worker nodes if configured.
 func (md *embedded) IsUp() (err error) {
 	if md.cfg.ClusterName == "" {
 		return errors.New("cannot check empty cluster")
@@ -612,6 +651,11 @@ func (md *embedded) IsUp() (err error) {
 	md.cfg.ClusterState.Created = *do.Cluster.CreatedAt
 	md.cfg.PlatformVersion = *do.Cluster.PlatformVersion
 	if md.cfg.ClusterState.Status == "ACTIVE" {
+		if md.cfg.Spot != nil && md.cfg.Spot.Enable && len(md.cfg.ClusterState.WorkerNodes) > 0 {
+			if err = md.checkSpotInterruptions(); err != nil {
+				return err
+			}
+		}
 		return md.cfg.Sync()
 	}
 	return fmt.Errorf("cluster %q status is %q",
@@ -638,6 +682,16 @@ func (md *embedded) GetClusterCreated(v string) (time.Time, error) {
 // Let default kubetest log dumper handle all artifact uploads.
 // See https://github.com/kubernetes/test-infra/pull/9811/files#r225776067.
 func (md *embedded) DumpClusterLogs(artifactDir, _ string) (err error) {
+	// pod logs and events are collected even if node SSH is disabled
+	if err = md.collectDiagnostics(); err != nil {
+		md.lg.Warn("failed to collect cluster diagnostics", zap.Error(err))
+	} else if err = fileutil.Copy(
+		md.cfg.DiagnosticsOutputToUploadPath,
+		filepath.Join(artifactDir, md.cfg.DiagnosticsOutputToUploadPathBucket),
+	); err != nil {
+		return err
+	}
+
 	err = md.GetWorkerNodeLogs()
 	if err != nil {
 		return err
@@ -689,30 +743,89 @@ func (md *embedded) TestALBCorrectness() error {
 		md.stopc) {
 		return fmt.Errorf("failed to HTTP Get %q", ep)
 	}
+	if md.cfg.VPC.EnableIPv6 {
+		// only "dualstack." prefixed DNS name resolves to IPv6 addresses
+		dep := "http://dualstack." + md.cfg.ALBIngressController.ELBv2NamespaceToDNSName["default"]
+		if md.cfg.ALBIngressController.TestMode == "ingress-test-server" {
+			dep += path.Path
+		}
+		// dial AAAA record over "tcp6", in case of IPv4 fallback
+		if err := httputil.CheckGetIPv6(
+			md.lg,
+			dep,
+			strings.Repeat("0", md.cfg.ALBIngressController.TestResponseSize),
+			30,
+			5*time.Second,
+			md.stopc); err != nil {
+			return err
+		}
+	}
 	return md.albPlugin.TestAWSResources()
 }
 
 func (md *embedded) TestALBQPS() error {
 	ep := "http://" + md.cfg.ALBIngressController.ELBv2NamespaceToDNSName["default"]
+	start := time.Now().UTC()
 
 	var rs client.TestResult
 	var rbytes []byte
-	switch md.cfg.ALBIngressController.TestMode {
-	case "ingress-test-server":
-		cli, err := client.New(
-			md.lg,
-			ep,
-			md.cfg.ALBIngressController.TestServerRoutes,
-			md.cfg.ALBIngressController.TestClients,
-			md.cfg.ALBIngressController.TestClientRequests,
-		)
+	var combined wrk.Result
+	switch {
+	case md.cfg.ALBIngressController.TestLoadGenerators > 0:
+		var workers []wrk.Result
+		var err error
+		combined, workers, err = md.albPlugin.RunLoadGenerators()
+		if err != nil {
+			return err
+		}
+		for i, v := range workers {
+			rbytes = append(rbytes, []byte(fmt.Sprintf("Load generator #%d:\n%s\n\n", i, v.Output))...)
+		}
+		rbytes = append(rbytes, []byte(fmt.Sprintf(
+			"Combined %d load generators:\nRequests/sec: %.2f\nTransfer/sec: %s\nTotal requests: %d\nSocket errors: connect %d, read %d, write %d, timeout %d\nNon-2xx or 3xx responses: %d\n",
+			len(workers),
+			combined.RequestsPerSec,
+			combined.TransferPerSec,
+			combined.TotalRequests,
+			combined.ErrorsConnect,
+			combined.ErrorsRead,
+			combined.ErrorsWrite,
+			combined.ErrorsTimeout,
+			combined.ErrorsNon2xx3xx,
+		))...)
+
+	case md.cfg.ALBIngressController.TestMode == "ingress-test-server":
+		var cli *client.Client
+		var err error
+		if md.cfg.ALBIngressController.TestClientMode == client.ModeOpenLoop {
+			cli, err = client.NewOpenLoop(
+				md.lg,
+				ep,
+				md.cfg.ALBIngressController.TestServerRoutes,
+				md.cfg.ALBIngressController.TestClients,
+				client.Schedule{
+					StartQPS: md.cfg.ALBIngressController.TestClientOpenLoopStartQPS,
+					EndQPS:   md.cfg.ALBIngressController.TestClientOpenLoopEndQPS,
+					Steps:    md.cfg.ALBIngressController.TestClientOpenLoopSteps,
+					Duration: time.Duration(md.cfg.ALBIngressController.TestClientOpenLoopMinutes) * time.Minute,
+				},
+			)
+		} else {
+			cli, err = client.New(
+				md.lg,
+				ep,
+				md.cfg.ALBIngressController.TestServerRoutes,
+				md.cfg.ALBIngressController.TestClients,
+				md.cfg.ALBIngressController.TestClientRequests,
+			)
+		}
 		if err != nil {
 			return err
 		}
 		rs = cli.Run()
 		rbytes = []byte(rs.Result)
 
-	case "nginx":
+	case md.cfg.ALBIngressController.TestMode == "nginx":
 		// wrk --threads 2 --connections 200 --duration 15s --latency http://127.0.0.1
 		args := []string{
 			"--threads", "2",
@@ -731,6 +844,7 @@ func (md *embedded) TestALBQPS() error {
 		md.lg.Info("finished wrk", zap.String("command", strings.Join(args, " ")))
 	}
 
+	end := time.Now().UTC()
 	fmt.Printf("TestALBQPS Result: %q\n\n%s\n\n", ep, string(rbytes))
 
 	if err := ioutil.WriteFile(
@@ -747,19 +861,55 @@ func (md *embedded) TestALBQPS() error {
 		}
 	}
 
-	if md.cfg.ALBIngressController.TestMode == "ingress-test-server" {
+	// ingress test client does not count responses by status code
+	var requests, non2xx3xx int64 = 0, -1
+	switch {
+	case md.cfg.ALBIngressController.TestLoadGenerators > 0:
+		requests = int64(combined.TotalRequests)
+		non2xx3xx = combined.ErrorsNon2xx3xx
+		md.cfg.ALBIngressController.TestResultQPS = combined.RequestsPerSec
+		md.cfg.ALBIngressController.TestResultFailures = combined.ErrorsConnect + combined.ErrorsWrite + combined.ErrorsRead + combined.ErrorsTimeout
+	case md.cfg.ALBIngressController.TestMode == "ingress-test-server":
+		requests = rs.Success + rs.Failure
 		md.cfg.ALBIngressController.TestResultQPS = rs.QPS
 		md.cfg.ALBIngressController.TestResultFailures = rs.Failure
-	} else {
+	default:
 		pv, perr := wrk.Parse(string(rbytes))
 		if perr != nil {
 			md.lg.Warn("failed to parse 'wrk' command output", zap.String("output", string(rbytes)), zap.Error(perr))
 		}
+		requests = int64(pv.TotalRequests)
+		non2xx3xx = pv.ErrorsNon2xx3xx
 		md.cfg.ALBIngressController.TestResultQPS = pv.RequestsPerSec
 		md.cfg.ALBIngressController.TestResultFailures = pv.ErrorsConnect + pv.ErrorsWrite + pv.ErrorsRead + pv.ErrorsTimeout
 	}
 	md.cfg.Sync()
 
+	if md.cfg.LogAccess && md.cfg.ALBIngressController.TestAccessLogsTimeout > 0 {
+		summary, err := md.checkALBAccessLogs(start, end, requests, non2xx3xx)
+		if err != nil {
+			md.lg.Warn("failed to check ALB access logs", zap.Error(err))
+		} else {
+			fmt.Printf("TestALBQPS ALB access logs: %q\n\n%s\n\n", ep, summary)
+			rbytes = append(rbytes, []byte("\n\n"+summary)...)
+			if err = ioutil.WriteFile(
+				md.cfg.ALBIngressController.ScalabilityOutputToUploadPath,
+				rbytes,
+				0600,
+			); err != nil {
+				return err
+			}
+			if md.cfg.ALBIngressController.UploadTesterLogs {
+				if err = md.s3Plugin.UploadToBucketForTests(
+					md.cfg.ALBIngressController.ScalabilityOutputToUploadPath,
+					md.cfg.ALBIngressController.ScalabilityOutputToUploadPathBucket,
+				); err != nil {
+					md.lg.Warn("failed to upload ALB scalability output", zap.Error(err))
+				}
+			}
+		}
+	}
+
 	if int64(len(rs.Errors)) > md.cfg.ALBIngressController.TestClientErrorThreshold {
 		return fmt.Errorf("expected errors under threshold %d, got %v", md.cfg.ALBIngressController.TestClientErrorThreshold, rs.Errors)
 	}
@@ -816,9 +966,6 @@ func (md *embedded) uploadTesterLogs() (err error) {
 
 // TODO: parallelize for >100 nodes?
 func (md *embedded) uploadWorkerNodeLogs() (err error) {
-	if !md.cfg.EnableWorkerNodeSSH {
-		return nil
-	}
 	err = md.GetWorkerNodeLogs()
 	if err != nil {
 		return err
@@ -875,6 +1022,15 @@ func (md *embedded) uploadALBTesterLogs() (err error) {
 			return err
 		}
 	}
+	if md.cfg.ALBIngressController.TestLoadGenerators > 0 {
+		err = md.s3Plugin.UploadToBucketForTests(
+			md.cfg.ALBIngressController.LoadGeneratorSpecPath,
+			md.cfg.ALBIngressController.LoadGeneratorSpecPathBucket,
+		)
+		if err != nil {
+			return err
+		}
+	}
 	if md.cfg.ALBIngressController.TestMetrics {
 		return md.s3Plugin.UploadToBucketForTests(
 			md.cfg.ALBIngressController.MetricsOutputToUploadPath,
@@ -884,12 +1040,6 @@ func (md *embedded) uploadALBTesterLogs() (err error) {
 	return nil
 }
 
-// genS3URL returns S3 URL path.
-// e.g. https://s3-us-west-2.amazonaws.com/aws-k8s-tester-20180925/hello-world
-func genS3URL(region, bucket, s3Path string) string {
-	return fmt.Sprintf("https://s3-%s.amazonaws.com/%s/%s", region, bucket, s3Path)
-}
-
 var httpTransport *http.Transport
 
 func init() {
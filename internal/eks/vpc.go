@@ -15,18 +15,18 @@ import (
 )
 
 func (md *embedded) createVPC() error {
+	if md.cfg.VPC.UseExisting {
+		return md.checkExistingVPC()
+	}
 	if md.cfg.ClusterState.CFStackVPCName == "" {
 		return errors.New("cannot create empty VPC stack")
 	}
 
 	now := time.Now().UTC()
 	h, _ := os.Hostname()
-	v := vpcStack{
-		Description:       md.cfg.ClusterName + "-vpc-stack",
-		Tag:               md.cfg.Tag,
-		TagValue:          md.cfg.ClusterName,
-		Hostname:          h,
-		SecurityGroupName: md.cfg.ClusterName + "-security-group",
+	v, err := newVPCStack(md.cfg, h)
+	if err != nil {
+		return err
 	}
 	s, err := createVPCTemplate(v)
 	if err != nil {
@@ -95,20 +95,7 @@ func (md *embedded) createVPC() error {
 			return fmt.Errorf("failed to create %q (%q)", md.cfg.ClusterState.CFStackVPCName, md.cfg.ClusterState.CFStackVPCStatus)
 		}
 
-		for _, op := range do.Stacks[0].Outputs {
-			if *op.OutputKey == "VpcId" {
-				md.cfg.VPCID = *op.OutputValue
-				continue
-			}
-			if *op.OutputKey == "SubnetIds" {
-				vv := *op.OutputValue
-				md.cfg.SubnetIDs = strings.Split(vv, ",")
-				continue
-			}
-			if *op.OutputKey == "SecurityGroups" {
-				md.cfg.SecurityGroupID = *op.OutputValue
-			}
-		}
+		md.updateVPCStackOutputs(do.Stacks[0].Outputs)
 
 		if md.cfg.ClusterState.CFStackVPCStatus == "CREATE_COMPLETE" {
 			_, err = md.ec2.CreateTags(&ec2.CreateTagsInput{
@@ -150,11 +137,32 @@ func (md *embedded) createVPC() error {
 		zap.String("stack-name", md.cfg.ClusterState.CFStackVPCName),
 		zap.String("stack-status", md.cfg.ClusterState.CFStackVPCStatus),
 		zap.String("vpc-id", md.cfg.VPCID),
+		zap.Strings("public-subnet-ids", md.cfg.VPC.PublicSubnetIDs),
+		zap.Strings("private-subnet-ids", md.cfg.VPC.PrivateSubnetIDs),
 		zap.String("request-started", humanize.RelTime(now, time.Now().UTC(), "ago", "from now")),
 	)
 	return md.cfg.Sync()
 }
 
+// updateVPCStackOutputs updates VPC, subnet, and security group IDs
+// from the VPC stack outputs.
+func (md *embedded) updateVPCStackOutputs(ops []*cloudformation.Output) {
+	for _, op := range ops {
+		switch *op.OutputKey {
+		case "VpcId":
+			md.cfg.VPCID = *op.OutputValue
+		case "SubnetIds":
+			md.cfg.SubnetIDs = strings.Split(*op.OutputValue, ",")
+		case "PublicSubnetIds":
+			md.cfg.VPC.PublicSubnetIDs = strings.Split(*op.OutputValue, ",")
+		case "PrivateSubnetIds":
+			md.cfg.VPC.PrivateSubnetIDs = strings.Split(*op.OutputValue, ",")
+		case "SecurityGroups":
+			md.cfg.SecurityGroupID = *op.OutputValue
+		}
+	}
+}
+
 func (md *embedded) deleteVPC() error {
 	if !md.cfg.ClusterState.StatusVPCCreated {
 		return nil
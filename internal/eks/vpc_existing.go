@@ -0,0 +1,230 @@
+package eks
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"go.uber.org/zap"
+)
+
+// checkExistingVPC validates the existing VPC and its subnets,
+// and classifies the subnets into public and private by their routes.
+// The existing VPC is never created nor deleted by the tester.
+func (md *embedded) checkExistingVPC() error {
+	vo, err := md.ec2.DescribeVpcs(&ec2.DescribeVpcsInput{
+		VpcIds: aws.StringSlice([]string{md.cfg.VPCID}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe VPC %q (%v)", md.cfg.VPCID, err)
+	}
+	if len(vo.Vpcs) != 1 {
+		return fmt.Errorf("expected 1 VPC %q, got %d", md.cfg.VPCID, len(vo.Vpcs))
+	}
+	for _, attr := range []string{"enableDnsSupport", "enableDnsHostnames"} {
+		ao, aerr := md.ec2.DescribeVpcAttribute(&ec2.DescribeVpcAttributeInput{
+			VpcId:     aws.String(md.cfg.VPCID),
+			Attribute: aws.String(attr),
+		})
+		if aerr != nil {
+			return fmt.Errorf("failed to describe VPC %q attribute %q (%v)", md.cfg.VPCID, attr, aerr)
+		}
+		enabled := false
+		switch attr {
+		case "enableDnsSupport":
+			enabled = ao.EnableDnsSupport != nil && aws.BoolValue(ao.EnableDnsSupport.Value)
+		case "enableDnsHostnames":
+			enabled = ao.EnableDnsHostnames != nil && aws.BoolValue(ao.EnableDnsHostnames.Value)
+		}
+		if !enabled {
+			return fmt.Errorf("VPC %q must enable %q for worker nodes to join the cluster", md.cfg.VPCID, attr)
+		}
+	}
+
+	so, err := md.ec2.DescribeSubnets(&ec2.DescribeSubnetsInput{
+		SubnetIds: aws.StringSlice(md.cfg.SubnetIDs),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe subnets %v (%v)", md.cfg.SubnetIDs, err)
+	}
+	ro, err := md.ec2.DescribeRouteTables(&ec2.DescribeRouteTablesInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("vpc-id"), Values: aws.StringSlice([]string{md.cfg.VPCID})},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe route tables in VPC %q (%v)", md.cfg.VPCID, err)
+	}
+
+	if err = checkSubnets(md.cfg.VPCID, md.cfg.SubnetIDs, so.Subnets); err != nil {
+		return err
+	}
+	if md.cfg.VPC.EnableIPv6 {
+		if err = checkSubnetsIPv6(so.Subnets); err != nil {
+			return err
+		}
+	}
+	public, private, err := classifySubnets(so.Subnets, ro.RouteTables)
+	if err != nil {
+		return err
+	}
+	warnings, err := checkSubnetTags(md.cfg.ClusterName, so.Subnets, public)
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		md.lg.Warn("found subnet without load balancer tag", zap.String("warning", w))
+	}
+	if md.cfg.VPC.PrivateWorkerNodes && len(private) == 0 {
+		return fmt.Errorf("PrivateWorkerNodes requires private subnets, but %v are all public", md.cfg.SubnetIDs)
+	}
+
+	md.cfg.VPC.PublicSubnetIDs, md.cfg.VPC.PrivateSubnetIDs = public, private
+	md.lg.Info("using existing VPC",
+		zap.String("vpc-id", md.cfg.VPCID),
+		zap.Strings("public-subnet-ids", public),
+		zap.Strings("private-subnet-ids", private),
+		zap.String("security-group-id", md.cfg.SecurityGroupID),
+	)
+	return md.cfg.Sync()
+}
+
+// checkSubnets checks that all subnets exist in the VPC,
+// spanning at least 2 availability zones as EKS requires.
+func checkSubnets(vpcID string, subnetIDs []string, subnets []*ec2.Subnet) error {
+	if len(subnets) != len(subnetIDs) {
+		return fmt.Errorf("expected %d subnets %v, got %d", len(subnetIDs), subnetIDs, len(subnets))
+	}
+	azs := make(map[string]struct{})
+	for _, sn := range subnets {
+		if aws.StringValue(sn.VpcId) != vpcID {
+			return fmt.Errorf("subnet %q is in VPC %q, not in %q", aws.StringValue(sn.SubnetId), aws.StringValue(sn.VpcId), vpcID)
+		}
+		azs[aws.StringValue(sn.AvailabilityZone)] = struct{}{}
+	}
+	if len(azs) < 2 {
+		return fmt.Errorf("subnets %v must span at least 2 availability zones, got %d", subnetIDs, len(azs))
+	}
+	return nil
+}
+
+// checkSubnetsIPv6 checks that all subnets have associated IPv6 CIDR blocks,
+// and assign IPv6 addresses to worker nodes on launch.
+func checkSubnetsIPv6(subnets []*ec2.Subnet) error {
+	var errs []string
+	for _, sn := range subnets {
+		id := aws.StringValue(sn.SubnetId)
+		associated := false
+		for _, assoc := range sn.Ipv6CidrBlockAssociationSet {
+			if assoc.Ipv6CidrBlockState != nil && aws.StringValue(assoc.Ipv6CidrBlockState.State) == "associated" {
+				associated = true
+				break
+			}
+		}
+		switch {
+		case !associated:
+			errs = append(errs, fmt.Sprintf("%s: no IPv6 CIDR block", id))
+		case !aws.BoolValue(sn.AssignIpv6AddressOnCreation):
+			errs = append(errs, fmt.Sprintf("%s: IPv6 address is not assigned on creation", id))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("EnableIPv6 requires dual-stack subnets (%s)", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// classifySubnets classifies the subnets by the default route of
+// their route tables. Subnets routed to an internet gateway are public,
+// and subnets routed to a NAT gateway (or a NAT instance) are private.
+// Subnets without explicit route table association use the main route table.
+func classifySubnets(subnets []*ec2.Subnet, routeTables []*ec2.RouteTable) (public, private []string, err error) {
+	var main *ec2.RouteTable
+	subnetToRT := make(map[string]*ec2.RouteTable)
+	for _, rt := range routeTables {
+		for _, assoc := range rt.Associations {
+			if aws.BoolValue(assoc.Main) {
+				main = rt
+			}
+			if assoc.SubnetId != nil {
+				subnetToRT[*assoc.SubnetId] = rt
+			}
+		}
+	}
+
+	var errs []string
+	for _, sn := range subnets {
+		id := aws.StringValue(sn.SubnetId)
+		rt, ok := subnetToRT[id]
+		if !ok {
+			rt = main
+		}
+		if rt == nil {
+			errs = append(errs, fmt.Sprintf("%s: no route table", id))
+			continue
+		}
+		switch defaultRouteTarget(rt) {
+		case "internet-gateway":
+			public = append(public, id)
+		case "nat":
+			private = append(private, id)
+		default:
+			errs = append(errs, fmt.Sprintf("%s: no default route to internet or NAT gateway in %q", id, aws.StringValue(rt.RouteTableId)))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, nil, errors.New(strings.Join(errs, ", "))
+	}
+	sort.Strings(public)
+	sort.Strings(private)
+	return public, private, nil
+}
+
+func defaultRouteTarget(rt *ec2.RouteTable) string {
+	for _, r := range rt.Routes {
+		if aws.StringValue(r.DestinationCidrBlock) != "0.0.0.0/0" || aws.StringValue(r.State) == "blackhole" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(aws.StringValue(r.GatewayId), "igw-"):
+			return "internet-gateway"
+		case r.NatGatewayId != nil, r.InstanceId != nil, r.NetworkInterfaceId != nil:
+			return "nat"
+		}
+	}
+	return ""
+}
+
+// checkSubnetTags checks the subnet tags that Kubernetes uses to discover
+// subnets for the cluster and its load balancers. The cluster tag, if any,
+// must be "shared" or "owned". Missing load balancer role tags are returned
+// as warnings, since only load balancer provisioning depends on them.
+// https://docs.aws.amazon.com/eks/latest/userguide/network_reqs.html
+func checkSubnetTags(clusterName string, subnets []*ec2.Subnet, public []string) (warnings []string, err error) {
+	isPublic := make(map[string]bool)
+	for _, id := range public {
+		isPublic[id] = true
+	}
+	clusterTag := "kubernetes.io/cluster/" + clusterName
+	for _, sn := range subnets {
+		id := aws.StringValue(sn.SubnetId)
+		tags := make(map[string]string)
+		for _, tag := range sn.Tags {
+			tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+		if v, ok := tags[clusterTag]; ok && v != "shared" && v != "owned" {
+			return nil, fmt.Errorf("subnet %q tag %q must be 'shared' or 'owned', got %q", id, clusterTag, v)
+		}
+		roleTag := "kubernetes.io/role/internal-elb"
+		if isPublic[id] {
+			roleTag = "kubernetes.io/role/elb"
+		}
+		if _, ok := tags[roleTag]; !ok {
+			warnings = append(warnings, fmt.Sprintf("%s: missing %q tag", id, roleTag))
+		}
+	}
+	return warnings, nil
+}
@@ -0,0 +1,103 @@
+package eks
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestExistingVPCSubnets(t *testing.T) {
+	subnets := []*ec2.Subnet{
+		{SubnetId: aws.String("subnet-a"), VpcId: aws.String("vpc-1"), AvailabilityZone: aws.String("us-west-2a"), Tags: []*ec2.Tag{
+			{Key: aws.String("kubernetes.io/role/elb"), Value: aws.String("1")},
+		}},
+		{SubnetId: aws.String("subnet-b"), VpcId: aws.String("vpc-1"), AvailabilityZone: aws.String("us-west-2b"), Tags: []*ec2.Tag{
+			{Key: aws.String("kubernetes.io/cluster/test"), Value: aws.String("shared")},
+		}},
+		{SubnetId: aws.String("subnet-c"), VpcId: aws.String("vpc-1"), AvailabilityZone: aws.String("us-west-2b")},
+	}
+	routeTables := []*ec2.RouteTable{
+		{
+			RouteTableId: aws.String("rtb-main"),
+			Associations: []*ec2.RouteTableAssociation{{Main: aws.Bool(true)}},
+			Routes: []*ec2.Route{
+				{DestinationCidrBlock: aws.String("10.0.0.0/16"), GatewayId: aws.String("local")},
+				{DestinationCidrBlock: aws.String("0.0.0.0/0"), GatewayId: aws.String("igw-1")},
+			},
+		},
+		{
+			RouteTableId: aws.String("rtb-private"),
+			Associations: []*ec2.RouteTableAssociation{
+				{SubnetId: aws.String("subnet-b")},
+				{SubnetId: aws.String("subnet-c")},
+			},
+			Routes: []*ec2.Route{
+				{DestinationCidrBlock: aws.String("10.0.0.0/16"), GatewayId: aws.String("local")},
+				{DestinationCidrBlock: aws.String("0.0.0.0/0"), NatGatewayId: aws.String("nat-1")},
+			},
+		},
+	}
+
+	if err := checkSubnets("vpc-1", []string{"subnet-a", "subnet-b", "subnet-c"}, subnets); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSubnets("vpc-2", []string{"subnet-a", "subnet-b", "subnet-c"}, subnets); err == nil {
+		t.Fatal("expected error for subnets in another VPC")
+	}
+	if err := checkSubnets("vpc-1", []string{"subnet-b", "subnet-c"}, subnets[1:]); err == nil {
+		t.Fatal("expected error for subnets in 1 availability zone")
+	}
+
+	public, private, err := classifySubnets(subnets, routeTables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(public, []string{"subnet-a"}) {
+		t.Fatalf("unexpected public subnets %v", public)
+	}
+	if !reflect.DeepEqual(private, []string{"subnet-b", "subnet-c"}) {
+		t.Fatalf("unexpected private subnets %v", private)
+	}
+
+	warnings, err := checkSubnetTags("test", subnets, public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "subnet-b") || !strings.Contains(warnings[1], "kubernetes.io/role/internal-elb") {
+		t.Fatalf("unexpected warnings %v", warnings)
+	}
+	subnets[1].Tags[0].Value = aws.String("owned-by-someone")
+	if _, err = checkSubnetTags("test", subnets, public); err == nil {
+		t.Fatal("expected error for invalid cluster tag")
+	}
+
+	// isolated subnet without default route
+	routeTables[1].Routes = routeTables[1].Routes[:1]
+	if _, _, err = classifySubnets(subnets, routeTables); err == nil || !strings.Contains(err.Error(), "subnet-b") {
+		t.Fatalf("expected error for subnets without default route, got %v", err)
+	}
+}
+
+func TestExistingVPCSubnetsIPv6(t *testing.T) {
+	associated := []*ec2.SubnetIpv6CidrBlockAssociation{{
+		Ipv6CidrBlock:      aws.String("2600:1f14:e0e:7f00::/64"),
+		Ipv6CidrBlockState: &ec2.SubnetCidrBlockState{State: aws.String("associated")},
+	}}
+	subnets := []*ec2.Subnet{
+		{SubnetId: aws.String("subnet-a"), AssignIpv6AddressOnCreation: aws.Bool(true), Ipv6CidrBlockAssociationSet: associated},
+		{SubnetId: aws.String("subnet-b"), AssignIpv6AddressOnCreation: aws.Bool(true), Ipv6CidrBlockAssociationSet: associated},
+	}
+	if err := checkSubnetsIPv6(subnets); err != nil {
+		t.Fatal(err)
+	}
+
+	subnets[0].AssignIpv6AddressOnCreation = aws.Bool(false)
+	subnets[1].Ipv6CidrBlockAssociationSet = nil
+	err := checkSubnetsIPv6(subnets)
+	if err == nil || !strings.Contains(err.Error(), "subnet-a: IPv6 address") || !strings.Contains(err.Error(), "subnet-b: no IPv6") {
+		t.Fatalf("expected error for IPv4-only subnets, got %v", err)
+	}
+}
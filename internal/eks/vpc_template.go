@@ -2,7 +2,12 @@ package eks
 
 import (
 	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
 	"text/template"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
 )
 
 func createVPCTemplate(v vpcStack) (string, error) {
@@ -20,10 +25,151 @@ type vpcStack struct {
 	TagValue          string
 	Hostname          string
 	SecurityGroupName string
+	ClusterName       string
+
+	CIDR        string
+	Subnets     []vpcSubnet
+	NATGateways []vpcNATGateway
+	// EnableIPv6 is true to assign IPv6 CIDR blocks to the VPC and subnets.
+	EnableIPv6 bool
+}
+
+// vpcSubnet is a subnet in the VPC stack.
+type vpcSubnet struct {
+	// Name is the CloudFormation logical ID.
+	Name string
+	CIDR string
+	// AZ is the index of availability zone in the region.
+	AZ     int
+	Public bool
+	// RouteTable is the logical ID of route table for the subnet.
+	RouteTable string
+}
+
+// vpcNATGateway is a NAT gateway in a public subnet,
+// and the route table for private subnets.
+type vpcNATGateway struct {
+	// Name is the CloudFormation logical ID.
+	Name string
+	// Subnet is the logical ID of public subnet.
+	Subnet string
+	// RouteTable is the logical ID of private route table.
+	RouteTable string
+}
+
+// PublicSubnets returns the public subnets.
+func (v vpcStack) PublicSubnets() (ss []vpcSubnet) {
+	for _, s := range v.Subnets {
+		if s.Public {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
+
+// PrivateSubnets returns the private subnets.
+func (v vpcStack) PrivateSubnets() (ss []vpcSubnet) {
+	for _, s := range v.Subnets {
+		if !s.Public {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
+
+// newVPCStack lays out subnets and NAT gateways from the VPC configuration.
+// Public subnets come first, spread over availability zones,
+// and each subnet takes an equal-sized block of the VPC CIDR.
+func newVPCStack(cfg *eksconfig.Config, hostname string) (vpcStack, error) {
+	v := vpcStack{
+		Description:       cfg.ClusterName + "-vpc-stack",
+		Tag:               cfg.Tag,
+		TagValue:          cfg.ClusterName,
+		Hostname:          hostname,
+		SecurityGroupName: cfg.ClusterName + "-security-group",
+		ClusterName:       cfg.ClusterName,
+		CIDR:              cfg.VPC.CIDR,
+		EnableIPv6:        cfg.VPC.EnableIPv6,
+	}
+
+	azs, pub, priv := cfg.VPC.AvailabilityZones, cfg.VPC.PublicSubnetsPerAZ, cfg.VPC.PrivateSubnetsPerAZ
+	cidrs, err := splitVPCCIDR(cfg.VPC.CIDR, azs*(pub+priv))
+	if err != nil {
+		return vpcStack{}, err
+	}
+
+	firstPublic := make(map[int]string)
+	for i := 0; i < azs*pub; i++ {
+		name := fmt.Sprintf("PublicSubnet%02d", i+1)
+		az := i % azs
+		if _, ok := firstPublic[az]; !ok {
+			firstPublic[az] = name
+		}
+		v.Subnets = append(v.Subnets, vpcSubnet{
+			Name:       name,
+			CIDR:       cidrs[i],
+			AZ:         az,
+			Public:     true,
+			RouteTable: "RouteTable",
+		})
+	}
+	if priv == 0 {
+		return v, nil
+	}
+
+	natAZs := 1
+	if cfg.VPC.NATGatewayPerAZ {
+		natAZs = azs
+	}
+	for az := 0; az < natAZs; az++ {
+		v.NATGateways = append(v.NATGateways, vpcNATGateway{
+			Name:       fmt.Sprintf("NATGateway%02d", az+1),
+			Subnet:     firstPublic[az],
+			RouteTable: fmt.Sprintf("PrivateRouteTable%02d", az+1),
+		})
+	}
+	for i := 0; i < azs*priv; i++ {
+		az := i % azs
+		v.Subnets = append(v.Subnets, vpcSubnet{
+			Name:       fmt.Sprintf("PrivateSubnet%02d", i+1),
+			CIDR:       cidrs[azs*pub+i],
+			AZ:         az,
+			Public:     false,
+			RouteTable: v.NATGateways[az%natAZs].RouteTable,
+		})
+	}
+	return v, nil
+}
+
+// splitVPCCIDR splits the VPC CIDR block into n subnets of equal size.
+func splitVPCCIDR(cidr string, n int) ([]string, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	ip := ipNet.IP.To4()
+	if ip == nil {
+		return nil, fmt.Errorf("VPC CIDR %q is not IPv4", cidr)
+	}
+	ones, _ := ipNet.Mask.Size()
+	subnetOnes := ones + eksconfig.VPCSubnetNewBits(n)
+	if subnetOnes > 28 {
+		return nil, fmt.Errorf("VPC CIDR %q is too small for %d subnets", cidr, n)
+	}
+
+	base, size := binary.BigEndian.Uint32(ip), uint32(1)<<uint(32-subnetOnes)
+	cidrs := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		sip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(sip, base+uint32(i)*size)
+		cidrs = append(cidrs, fmt.Sprintf("%s/%d", sip, subnetOnes))
+	}
+	return cidrs, nil
 }
 
 // https://docs.aws.amazon.com/eks/latest/userguide/getting-started.html
 // https://amazon-eks.s3-us-west-2.amazonaws.com/cloudformation/2018-08-30/amazon-eks-vpc-sample.yaml
+// https://docs.aws.amazon.com/eks/latest/userguide/network_reqs.html
 const vpcStackTemplate = `---
 AWSTemplateFormatVersion: '2010-09-09'
 Description: {{ .Description }}
@@ -32,36 +178,9 @@ Parameters:
 
   VpcBlock:
     Type: String
-    Default: 192.168.0.0/16
+    Default: {{ .CIDR }}
     Description: The CIDR range for the VPC. This should be a valid private (RFC 1918) CIDR range
 
-  Subnet01Block:
-    Type: String
-    Default: 192.168.64.0/18
-    Description: CidrBlock for subnet 01 within the VPC
-
-  Subnet02Block:
-    Type: String
-    Default: 192.168.128.0/18
-    Description: CidrBlock for subnet 02 within the VPC
-
-  Subnet03Block:
-    Type: String
-    Default: 192.168.192.0/18
-    Description: CidrBlock for subnet 03 within the VPC
-
-Metadata:
-  AWS::CloudFormation::Interface:
-    ParameterGroups:
-      -
-        Label:
-          default: "Worker Network Configuration"
-        Parameters:
-          - VpcBlock
-          - Subnet01Block
-          - Subnet02Block
-          - Subnet03Block
-
 Resources:
   VPC:
     Type: AWS::EC2::VPC
@@ -74,7 +193,13 @@ Resources:
         Value: {{ .TagValue }}
       - Key: HOSTNAME
         Value: {{ .Hostname }}
-
+{{ if .EnableIPv6 }}
+  VPCIPv6CidrBlock:
+    Type: AWS::EC2::VPCCidrBlock
+    Properties:
+      VpcId: !Ref VPC
+      AmazonProvidedIpv6CidrBlock: true
+{{ end }}
   InternetGateway:
     Type: "AWS::EC2::InternetGateway"
     Properties:
@@ -111,91 +236,103 @@ Resources:
       RouteTableId: !Ref RouteTable
       DestinationCidrBlock: 0.0.0.0/0
       GatewayId: !Ref InternetGateway
+{{ if .EnableIPv6 }}
+  RouteIPv6:
+    DependsOn: VPCGatewayAttachment
+    Type: AWS::EC2::Route
+    Properties:
+      RouteTableId: !Ref RouteTable
+      DestinationIpv6CidrBlock: '::/0'
+      GatewayId: !Ref InternetGateway
+{{ if .NATGateways }}
+  EgressOnlyInternetGateway:
+    Type: AWS::EC2::EgressOnlyInternetGateway
+    Properties:
+      VpcId: !Ref VPC
+{{ end }}{{ end }}{{ range .NATGateways }}
+  {{ .Name }}EIP:
+    DependsOn: VPCGatewayAttachment
+    Type: AWS::EC2::EIP
+    Properties:
+      Domain: vpc
 
-  Subnet01:
-    Type: AWS::EC2::Subnet
-    Metadata:
-      Comment: Subnet 01
+  {{ .Name }}:
+    Type: AWS::EC2::NatGateway
     Properties:
-      AvailabilityZone:
-        Fn::Select:
-        - '0'
-        - Fn::GetAZs:
-            Ref: AWS::Region
-      CidrBlock:
-        Ref: Subnet01Block
-      VpcId:
-        Ref: VPC
+      AllocationId: !GetAtt {{ .Name }}EIP.AllocationId
+      SubnetId: !Ref {{ .Subnet }}
       Tags:
-      - Key: Name
-        Value: !Sub "{{ .TagValue }}-Subnet01"
-      - Key: {{ .Tag }}
-        Value: {{ .TagValue }}
+      - Key: {{ $.Tag }}
+        Value: {{ $.TagValue }}
       - Key: HOSTNAME
-        Value: {{ .Hostname }}
+        Value: {{ $.Hostname }}
 
-  Subnet02:
-    Type: AWS::EC2::Subnet
-    Metadata:
-      Comment: Subnet 02
+  {{ .RouteTable }}:
+    Type: AWS::EC2::RouteTable
     Properties:
-      AvailabilityZone:
-        Fn::Select:
-        - '1'
-        - Fn::GetAZs:
-            Ref: AWS::Region
-      CidrBlock:
-        Ref: Subnet02Block
-      VpcId:
-        Ref: VPC
+      VpcId: !Ref VPC
       Tags:
       - Key: Name
-        Value: !Sub "{{ .TagValue }}-Subnet02"
-      - Key: {{ .Tag }}
-        Value: {{ .TagValue }}
+        Value: Private Subnets
+      - Key: Network
+        Value: Private
+      - Key: {{ $.Tag }}
+        Value: {{ $.TagValue }}
       - Key: HOSTNAME
-        Value: {{ .Hostname }}
+        Value: {{ $.Hostname }}
 
-  Subnet03:
+  {{ .RouteTable }}Route:
+    Type: AWS::EC2::Route
+    Properties:
+      RouteTableId: !Ref {{ .RouteTable }}
+      DestinationCidrBlock: 0.0.0.0/0
+      NatGatewayId: !Ref {{ .Name }}
+{{ if $.EnableIPv6 }}
+  {{ .RouteTable }}IPv6Route:
+    Type: AWS::EC2::Route
+    Properties:
+      RouteTableId: !Ref {{ .RouteTable }}
+      DestinationIpv6CidrBlock: '::/0'
+      EgressOnlyInternetGatewayId: !Ref EgressOnlyInternetGateway
+{{ end }}{{ end }}{{ range $i, $s := .Subnets }}
+  {{ .Name }}:
+{{- if $.EnableIPv6 }}
+    DependsOn: VPCIPv6CidrBlock
+{{- end }}
     Type: AWS::EC2::Subnet
     Metadata:
-      Comment: Subnet 03
+      Comment: {{ .Name }}
     Properties:
       AvailabilityZone:
         Fn::Select:
-        - '2'
+        - '{{ .AZ }}'
         - Fn::GetAZs:
             Ref: AWS::Region
-      CidrBlock:
-        Ref: Subnet03Block
+      CidrBlock: {{ .CIDR }}
+{{- if $.EnableIPv6 }}
+      Ipv6CidrBlock: !Select [ {{ $i }}, !Cidr [ !Select [ 0, !GetAtt VPC.Ipv6CidrBlocks ], {{ len $.Subnets }}, 64 ] ]
+      AssignIpv6AddressOnCreation: true
+{{- end }}
       VpcId:
         Ref: VPC
       Tags:
       - Key: Name
-        Value: !Sub "{{ .TagValue }}-Subnet03"
-      - Key: {{ .Tag }}
-        Value: {{ .TagValue }}
+        Value: !Sub "{{ $.TagValue }}-{{ .Name }}"
+      - Key: {{ $.Tag }}
+        Value: {{ $.TagValue }}
       - Key: HOSTNAME
-        Value: {{ .Hostname }}
+        Value: {{ $.Hostname }}
+      - Key: kubernetes.io/cluster/{{ $.ClusterName }}
+        Value: shared
+      - Key: {{ if .Public }}kubernetes.io/role/elb{{ else }}kubernetes.io/role/internal-elb{{ end }}
+        Value: '1'
 
-  Subnet01RouteTableAssociation:
+  {{ .Name }}RouteTableAssociation:
     Type: AWS::EC2::SubnetRouteTableAssociation
     Properties:
-      SubnetId: !Ref Subnet01
-      RouteTableId: !Ref RouteTable
-
-  Subnet02RouteTableAssociation:
-    Type: AWS::EC2::SubnetRouteTableAssociation
-    Properties:
-      SubnetId: !Ref Subnet02
-      RouteTableId: !Ref RouteTable
-
-  Subnet03RouteTableAssociation:
-    Type: AWS::EC2::SubnetRouteTableAssociation
-    Properties:
-      SubnetId: !Ref Subnet03
-      RouteTableId: !Ref RouteTable
-
+      SubnetId: !Ref {{ .Name }}
+      RouteTableId: !Ref {{ .RouteTable }}
+{{ end }}
   ControlPlaneSecurityGroup:
     Type: AWS::EC2::SecurityGroup
     Properties:
@@ -212,8 +349,16 @@ Outputs:
 
   SubnetIds:
     Description: All subnets in the VPC
-    Value: !Join [ ",", [ !Ref Subnet01, !Ref Subnet02, !Ref Subnet03 ] ]
-
+    Value: !Join [ ",", [ {{ range $i, $s := .Subnets }}{{ if $i }}, {{ end }}!Ref {{ $s.Name }}{{ end }} ] ]
+{{ if .PublicSubnets }}
+  PublicSubnetIds:
+    Description: Public subnets in the VPC
+    Value: !Join [ ",", [ {{ range $i, $s := .PublicSubnets }}{{ if $i }}, {{ end }}!Ref {{ $s.Name }}{{ end }} ] ]
+{{ end }}{{ if .PrivateSubnets }}
+  PrivateSubnetIds:
+    Description: Private subnets in the VPC
+    Value: !Join [ ",", [ {{ range $i, $s := .PrivateSubnets }}{{ if $i }}, {{ end }}!Ref {{ $s.Name }}{{ end }} ] ]
+{{ end }}
   SecurityGroups:
     Description: Security group for the cluster control plane communication with worker nodes
     Value: !Join [ ",", [ !Ref ControlPlaneSecurityGroup ] ]
@@ -1,21 +1,174 @@
 package eks
 
 import (
-	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"sigs.k8s.io/yaml"
 )
 
 func TestVPCTemplate(t *testing.T) {
-	v := vpcStack{
-		Description:       "test",
-		Tag:               "aws-k8s-tester",
-		TagValue:          "aws-k8s-tester",
-		Hostname:          "hostname",
-		SecurityGroupName: "security",
+	tests := []struct {
+		vpc       eksconfig.VPC
+		subnets   []string
+		nat       int
+		outputs   []string
+		privateRT map[string]string
+	}{
+		{
+			vpc:     eksconfig.VPC{CIDR: "192.168.0.0/16", AvailabilityZones: 3, PublicSubnetsPerAZ: 1},
+			subnets: []string{"PublicSubnet01", "PublicSubnet02", "PublicSubnet03"},
+			outputs: []string{"PublicSubnetIds", "SecurityGroups", "SubnetIds", "VpcId"},
+		},
+		{
+			vpc:     eksconfig.VPC{CIDR: "10.0.0.0/16", AvailabilityZones: 2, PublicSubnetsPerAZ: 1, PrivateSubnetsPerAZ: 2},
+			subnets: []string{"PublicSubnet01", "PublicSubnet02", "PrivateSubnet01", "PrivateSubnet02", "PrivateSubnet03", "PrivateSubnet04"},
+			nat:     1,
+			outputs: []string{"PrivateSubnetIds", "PublicSubnetIds", "SecurityGroups", "SubnetIds", "VpcId"},
+			privateRT: map[string]string{
+				"PrivateSubnet01": "PrivateRouteTable01",
+				"PrivateSubnet02": "PrivateRouteTable01",
+			},
+		},
+		{
+			vpc:     eksconfig.VPC{CIDR: "10.0.0.0/16", AvailabilityZones: 2, PublicSubnetsPerAZ: 1, PrivateSubnetsPerAZ: 1, NATGatewayPerAZ: true},
+			subnets: []string{"PublicSubnet01", "PublicSubnet02", "PrivateSubnet01", "PrivateSubnet02"},
+			nat:     2,
+			outputs: []string{"PrivateSubnetIds", "PublicSubnetIds", "SecurityGroups", "SubnetIds", "VpcId"},
+			privateRT: map[string]string{
+				"PrivateSubnet01": "PrivateRouteTable01",
+				"PrivateSubnet02": "PrivateRouteTable02",
+			},
+		},
+		{
+			vpc:     eksconfig.VPC{CIDR: "10.0.0.0/16", AvailabilityZones: 2, PublicSubnetsPerAZ: 1, PrivateSubnetsPerAZ: 1, EnableIPv6: true},
+			subnets: []string{"PublicSubnet01", "PublicSubnet02", "PrivateSubnet01", "PrivateSubnet02"},
+			nat:     1,
+			outputs: []string{"PrivateSubnetIds", "PublicSubnetIds", "SecurityGroups", "SubnetIds", "VpcId"},
+			privateRT: map[string]string{
+				"PrivateSubnet01": "PrivateRouteTable01",
+				"PrivateSubnet02": "PrivateRouteTable01",
+			},
+		},
+	}
+	for i, tt := range tests {
+		vpc := tt.vpc
+		cfg := &eksconfig.Config{Tag: "aws-k8s-tester", ClusterName: "test-cluster", VPC: &vpc}
+		v, err := newVPCStack(cfg, "hostname")
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if len(v.NATGateways) != tt.nat {
+			t.Fatalf("#%d: expected %d NAT gateways, got %d", i, tt.nat, len(v.NATGateways))
+		}
+		s, err := createVPCTemplate(v)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+
+		var tmpl struct {
+			Resources map[string]struct {
+				Type       string                 `json:"Type"`
+				Properties map[string]interface{} `json:"Properties"`
+			} `json:"Resources"`
+			Outputs map[string]interface{} `json:"Outputs"`
+		}
+		if err = yaml.Unmarshal([]byte(s), &tmpl); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+
+		var subnets []string
+		for _, sn := range v.Subnets {
+			subnets = append(subnets, sn.Name)
+			if tmpl.Resources[sn.Name].Type != "AWS::EC2::Subnet" {
+				t.Fatalf("#%d: expected subnet %q, got %+v", i, sn.Name, tmpl.Resources[sn.Name])
+			}
+			if assign := tmpl.Resources[sn.Name].Properties["AssignIpv6AddressOnCreation"]; (assign == true) != vpc.EnableIPv6 {
+				t.Fatalf("#%d: %q expected IPv6 assign %v, got %v", i, sn.Name, vpc.EnableIPv6, assign)
+			}
+			assoc := tmpl.Resources[sn.Name+"RouteTableAssociation"].Properties
+			if assoc["RouteTableId"] != sn.RouteTable {
+				t.Fatalf("#%d: %q expected route table %q, got %v", i, sn.Name, sn.RouteTable, assoc["RouteTableId"])
+			}
+			if exp, ok := tt.privateRT[sn.Name]; ok && sn.RouteTable != exp {
+				t.Fatalf("#%d: %q expected route table %q, got %q", i, sn.Name, exp, sn.RouteTable)
+			}
+			if sn.Public && sn.RouteTable != "RouteTable" {
+				t.Fatalf("#%d: public subnet %q must use public route table, got %q", i, sn.Name, sn.RouteTable)
+			}
+		}
+		if !reflect.DeepEqual(subnets, tt.subnets) {
+			t.Fatalf("#%d: expected subnets %v, got %v", i, tt.subnets, subnets)
+		}
+
+		ipv6 := map[string]string{
+			"VPCIPv6CidrBlock": "AWS::EC2::VPCCidrBlock",
+			"RouteIPv6":        "AWS::EC2::Route",
+		}
+		if tt.nat > 0 {
+			ipv6["EgressOnlyInternetGateway"] = "AWS::EC2::EgressOnlyInternetGateway"
+			for _, nat := range v.NATGateways {
+				ipv6[nat.RouteTable+"IPv6Route"] = "AWS::EC2::Route"
+			}
+		}
+		for k, typ := range ipv6 {
+			r, ok := tmpl.Resources[k]
+			if ok != vpc.EnableIPv6 || (ok && r.Type != typ) {
+				t.Fatalf("#%d: expected IPv6 resource %q %v, got %+v", i, k, vpc.EnableIPv6, r)
+			}
+		}
+
+		var outputs []string
+		for k := range tmpl.Outputs {
+			outputs = append(outputs, k)
+		}
+		sort.Strings(outputs)
+		if !reflect.DeepEqual(outputs, tt.outputs) {
+			t.Fatalf("#%d: expected outputs %v, got %v", i, tt.outputs, outputs)
+		}
+
+		declared := map[string]struct{}{"VpcBlock": {}}
+		for k := range tmpl.Resources {
+			declared[k] = struct{}{}
+		}
+		for _, m := range refRegex.FindAllStringSubmatch(s, -1) {
+			ref := m[1] + m[2]
+			if strings.HasPrefix(ref, "AWS::") {
+				continue
+			}
+			if _, ok := declared[ref]; !ok {
+				t.Fatalf("#%d: undeclared reference %q", i, ref)
+			}
+		}
+		if !strings.Contains(s, "kubernetes.io/cluster/test-cluster") {
+			t.Fatalf("#%d: expected cluster tag", i)
+		}
+	}
+}
+
+func TestSplitVPCCIDR(t *testing.T) {
+	tests := []struct {
+		cidr string
+		n    int
+		exp  []string
+	}{
+		{"192.168.0.0/16", 3, []string{"192.168.0.0/18", "192.168.64.0/18", "192.168.128.0/18"}},
+		{"10.0.0.0/16", 6, []string{"10.0.0.0/19", "10.0.32.0/19", "10.0.64.0/19", "10.0.96.0/19", "10.0.128.0/19", "10.0.160.0/19"}},
+		{"10.1.0.0/24", 1, []string{"10.1.0.0/24"}},
+	}
+	for i, tt := range tests {
+		cidrs, err := splitVPCCIDR(tt.cidr, tt.n)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if !reflect.DeepEqual(cidrs, tt.exp) {
+			t.Fatalf("#%d: expected %v, got %v", i, tt.exp, cidrs)
+		}
 	}
-	s, err := createVPCTemplate(v)
-	if err != nil {
-		t.Fatal(err)
+	if _, err := splitVPCCIDR("10.0.0.0/26", 6); err == nil {
+		t.Fatal("expected error for too small VPC CIDR")
 	}
-	fmt.Println(s)
 }
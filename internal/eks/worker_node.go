@@ -13,6 +13,7 @@ import (
 
 	"github.com/aws/aws-k8s-tester/ec2config"
 	internalec2 "github.com/aws/aws-k8s-tester/internal/ec2"
+	ec2types "github.com/aws/aws-k8s-tester/pkg/awsapi/ec2"
 	"github.com/aws/aws-k8s-tester/pkg/fileutil"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
@@ -34,12 +35,39 @@ func (md *embedded) createWorkerNode() error {
 	now := time.Now().UTC()
 	h, _ := os.Hostname()
 
-	s, err := createWorkerNodeTemplateFromURL(md.lg)
+	var s string
+	var err error
+	if err = md.findWorkerNodeAMI(); err != nil {
+		return err
+	}
+	if md.cfg.WorkerNodeCFTemplatePath != "" {
+		s, err = readWorkerNodeTemplate(md.cfg.WorkerNodeCFTemplatePath)
+	} else {
+		s, err = createWorkerNodeTemplate(md.cfg.WorkerNodeCFTemplateVersion, workerNodeStack{
+			Description:                          md.cfg.ClusterName + "-worker-node-stack",
+			Tag:                                  md.cfg.Tag,
+			TagValue:                             md.cfg.ClusterName,
+			Hostname:                             h,
+			EnableWorkerNodeSSH:                  md.cfg.EnableWorkerNodeSSH,
+			EnableWorkerNodePrivilegedPortAccess: md.cfg.EnableWorkerNodePrivilegedPortAccess,
+			PrivateSubnets:                       md.cfg.VPC.PrivateWorkerNodes,
+			Spot:                                 workerNodeSpot(md.cfg.Spot),
+		})
+	}
 	if err != nil {
 		return err
 	}
+	declared, err := parseTemplateParameters(s)
+	if err != nil {
+		return fmt.Errorf("failed to parse worker node template parameters (%v)", err)
+	}
+	md.lg.Info("created worker node template",
+		zap.String("template-path", md.cfg.WorkerNodeCFTemplatePath),
+		zap.String("template-version", md.cfg.WorkerNodeCFTemplateVersion),
+		zap.Strings("parameters", declared),
+	)
 
-	subnetIDs := md.cfg.SubnetIDs
+	subnetIDs := md.cfg.WorkerNodeSubnetIDs()
 	if !md.cfg.EnableWorkerNodeHA {
 		subnetIDs = subnetIDs[:1]
 		md.lg.Info("HA mode is disabled", zap.Strings("subnet-ids", subnetIDs))
@@ -60,7 +88,7 @@ func (md *embedded) createWorkerNode() error {
 
 		TemplateBody: aws.String(s),
 
-		Parameters: []*cloudformation.Parameter{
+		Parameters: filterTemplateParameters(declared, []*cloudformation.Parameter{
 			{
 				ParameterKey:   aws.String("ClusterName"),
 				ParameterValue: aws.String(md.cfg.ClusterName),
@@ -109,7 +137,7 @@ func (md *embedded) createWorkerNode() error {
 				ParameterKey:   aws.String("ClusterControlPlaneSecurityGroup"),
 				ParameterValue: aws.String(md.cfg.SecurityGroupID),
 			},
-		},
+		}),
 
 		Capabilities: aws.StringSlice([]string{"CAPABILITY_IAM"}),
 	})
@@ -436,6 +464,26 @@ func (md *embedded) createWorkerNode() error {
 	return md.cfg.Sync()
 }
 
+// findWorkerNodeAMI looks up the latest Amazon EKS-optimized AMI
+// of the worker node instance type architecture, if not specified.
+func (md *embedded) findWorkerNodeAMI() (err error) {
+	if md.cfg.WorkerNodeAMI != "" {
+		return nil
+	}
+	arch := ec2types.InstanceTypes[md.cfg.WorkerNodeInstanceType].Architecture
+	name := ec2types.EKSImageName(md.cfg.KubernetesVersion, arch)
+	md.cfg.WorkerNodeAMI, err = ec2types.LatestImageID(md.ec2, ec2types.EKSOwner, name)
+	if err != nil {
+		return err
+	}
+	md.lg.Info("found worker node AMI",
+		zap.String("image-id", md.cfg.WorkerNodeAMI),
+		zap.String("image-name", name),
+		zap.String("architecture", arch),
+	)
+	return md.cfg.Sync()
+}
+
 func (md *embedded) deleteWorkerNode() error {
 	if !md.cfg.ClusterState.StatusWorkerNodeCreated {
 		return nil
@@ -630,6 +678,57 @@ func (md *embedded) checkASG() (err error) {
 	return nil
 }
 
+// checkSpotInterruptions records Spot interruption notices of worker nodes,
+// and replaces interrupted worker nodes if configured. The Auto Scaling group
+// launches replacement nodes, which join the cluster on bootstrap.
+func (md *embedded) checkSpotInterruptions() (err error) {
+	var ids []string
+	ids, err = internalec2.CheckSpotInterruptions(md.ec2, md.cfg.ClusterState.WorkerNodes)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	md.lg.Warn("found Spot interruptions", zap.Strings("instance-ids", ids))
+	if !md.cfg.Spot.ReplaceInterrupted {
+		return md.cfg.Sync()
+	}
+
+	retryStart := time.Now().UTC()
+	if err = internalec2.ReplaceInstances(md.lg, md.asg, ids); err != nil {
+		return err
+	}
+	if md.cfg.ClusterState.InterruptedWorkerNodes == nil {
+		md.cfg.ClusterState.InterruptedWorkerNodes = make(map[string]ec2config.Instance)
+	}
+	for _, id := range ids {
+		iv := md.cfg.ClusterState.WorkerNodes[id]
+		iv.SpotInterruption.Replaced = true
+		md.cfg.ClusterState.InterruptedWorkerNodes[id] = iv
+		delete(md.cfg.ClusterState.WorkerNodes, id)
+	}
+	md.cfg.Sync()
+
+	for time.Now().UTC().Sub(retryStart) < 10*time.Minute {
+		select {
+		case <-md.stopc:
+			return errors.New("interrupted while replacing worker nodes")
+		default:
+		}
+		if err = md.checkASG(); err == nil {
+			md.lg.Info("replaced interrupted worker nodes",
+				zap.Strings("instance-ids", ids),
+				zap.String("request-started", humanize.RelTime(retryStart, time.Now().UTC(), "ago", "from now")),
+			)
+			return md.cfg.Sync()
+		}
+		md.lg.Info("waiting for replacement worker nodes", zap.Error(err))
+		time.Sleep(15 * time.Second)
+	}
+	return fmt.Errorf("failed to replace interrupted worker nodes %v (%v)", ids, err)
+}
+
 // https://docs.aws.amazon.com/eks/latest/userguide/getting-started.html
 const configMapNodeAuthTempl = `---
 apiVersion: v1
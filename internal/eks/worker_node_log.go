@@ -12,19 +12,28 @@ import (
 	"go.uber.org/zap"
 )
 
+// GetWorkerNodeLogs fetches worker node logs over SSH.
+// If node SSH is disabled, it fetches the same set of logs
+// through the API server, using a privileged DaemonSet.
 func (md *embedded) GetWorkerNodeLogs() (err error) {
-	if !md.cfg.EnableWorkerNodeSSH {
-		return errors.New("node SSH is not enabled")
-	}
-
 	var fpathToS3Path map[string]string
-	fpathToS3Path, err = fetchWorkerNodeLogs(
-		md.lg,
-		"ec2-user", // for Amazon Linux 2
-		md.cfg.ClusterName,
-		md.cfg.WorkerNodePrivateKeyPath,
-		md.cfg.ClusterState.WorkerNodes,
-	)
+	if md.cfg.EnableWorkerNodeSSH {
+		fpathToS3Path, err = fetchWorkerNodeLogs(
+			md.lg,
+			"ec2-user", // for Amazon Linux 2
+			md.cfg.ClusterName,
+			md.cfg.WorkerNodePrivateKeyPath,
+			md.cfg.ClusterState.WorkerNodes,
+		)
+	} else {
+		fpathToS3Path, err = md.getWorkerNodeLogsViaDaemonSet()
+		if len(fpathToS3Path) == 0 && err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		md.lg.Warn("failed to fetch some worker node logs", zap.Error(err))
+	}
 
 	md.ec2InstancesLogMu.Lock()
 	md.cfg.ClusterState.WorkerNodeLogs = fpathToS3Path
@@ -202,25 +211,7 @@ func fetchWorkerNodeLog(
 		return nil, err
 	}
 
-	/*
-		auditd.service                                        loaded    active   running Security Auditing Service
-		auth-rpcgss-module.service                            loaded    inactive dead    Kernel Module supporting RPCSEC_GSS
-	*/
-	var svcs []string
-	for _, line := range strings.Split(string(out), "\n") {
-		fields := strings.Fields(line)
-		if len(fields) == 0 || fields[0] == "" || len(fields) < 5 {
-			continue
-		}
-		if fields[1] == "not-found" {
-			continue
-		}
-		if fields[2] == "inactive" {
-			continue
-		}
-		svcs = append(svcs, fields[0])
-	}
-	for _, svc := range svcs {
+	for _, svc := range parseSystemdServices(out) {
 		cmd = "sudo journalctl --no-pager --output=cat -u " + svc
 		lg.Debug(
 			"fetching systemd service log",
@@ -278,15 +269,7 @@ func fetchWorkerNodeLog(
 		)
 		return nil, err
 	}
-	var varLogs []string
-	for _, line := range strings.Split(string(out), "\n") {
-		if len(line) == 0 {
-			// last value
-			continue
-		}
-		varLogs = append(varLogs, line)
-	}
-	for _, p := range varLogs {
+	for _, p := range parseVarLogs(out) {
 		cmd = "sudo cat " + p
 		lg.Debug(
 			"fetching /var/log",
@@ -328,6 +311,42 @@ func fetchWorkerNodeLog(
 	return fpathToS3Path, nil
 }
 
+// parseSystemdServices parses the output of
+// "systemctl list-units -t service --no-pager --no-legend --all",
+// and returns the active services.
+func parseSystemdServices(out []byte) (svcs []string) {
+	/*
+		auditd.service                                        loaded    active   running Security Auditing Service
+		auth-rpcgss-module.service                            loaded    inactive dead    Kernel Module supporting RPCSEC_GSS
+	*/
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] == "" || len(fields) < 5 {
+			continue
+		}
+		if fields[1] == "not-found" {
+			continue
+		}
+		if fields[2] == "inactive" {
+			continue
+		}
+		svcs = append(svcs, fields[0])
+	}
+	return svcs
+}
+
+// parseVarLogs parses the output of "find /var/log ! -type d".
+func parseVarLogs(out []byte) (varLogs []string) {
+	for _, line := range strings.Split(string(out), "\n") {
+		if len(line) == 0 {
+			// last value
+			continue
+		}
+		varLogs = append(varLogs, line)
+	}
+	return varLogs
+}
+
 func fetchWorkerNodeLogs(
 	lg *zap.Logger,
 	userName string,
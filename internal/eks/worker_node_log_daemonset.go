@@ -0,0 +1,335 @@
+package eks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+
+	"go.uber.org/zap"
+	"k8s.io/utils/exec"
+)
+
+const (
+	nodeLogCollectorName      = "aws-k8s-tester-node-log-collector"
+	nodeLogCollectorNamespace = "kube-system"
+	nodeLogCollectorImage     = "busybox:1.29"
+)
+
+// nodeLogCollectorYAML is the DaemonSet spec to read node logs
+// through the API server ("kubectl exec"), when node SSH is disabled.
+// "/var/log" (including persistent journal in "/var/log/journal") is mounted
+// read-only. "hostPID" and "privileged" are required to run "journalctl" and
+// "systemctl" in the host mount namespace with "nsenter", since the container
+// image does not ship systemd.
+const nodeLogCollectorYAML = `---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: ` + nodeLogCollectorName + `
+  namespace: ` + nodeLogCollectorNamespace + `
+  labels:
+    app: ` + nodeLogCollectorName + `
+spec:
+  selector:
+    matchLabels:
+      app: ` + nodeLogCollectorName + `
+  template:
+    metadata:
+      labels:
+        app: ` + nodeLogCollectorName + `
+    spec:
+      hostPID: true
+      terminationGracePeriodSeconds: 0
+      tolerations:
+      - operator: Exists
+      containers:
+      - name: collector
+        image: ` + nodeLogCollectorImage + `
+        command:
+        - sleep
+        - "3600"
+        securityContext:
+          privileged: true
+        volumeMounts:
+        - name: varlog
+          mountPath: /var/log
+          readOnly: true
+      volumes:
+      - name: varlog
+        hostPath:
+          path: /var/log
+
+`
+
+// kubectlRun runs a "kubectl" command and returns its combined output.
+type kubectlRun func(ctx context.Context, args ...string) ([]byte, error)
+
+func (md *embedded) kubectlRun(ctx context.Context, args ...string) ([]byte, error) {
+	args = append([]string{"--kubeconfig=" + md.cfg.KubeConfigPath}, args...)
+	return exec.New().CommandContext(ctx, md.cfg.KubectlPath, args...).CombinedOutput()
+}
+
+// getWorkerNodeLogsViaDaemonSet deploys a short-lived privileged DaemonSet,
+// and fetches the same set of worker node logs as SSH, in the same layout.
+func (md *embedded) getWorkerNodeLogsViaDaemonSet() (fpathToS3Path map[string]string, err error) {
+	p, err := fileutil.WriteTempFile([]byte(nodeLogCollectorYAML))
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(p)
+
+	var out []byte
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	out, err = md.kubectlRun(ctx, "apply", "--filename="+p)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create node log collector %v (%q)", err, string(out))
+	}
+	md.lg.Info("created node log collector", zap.String("output", string(out)))
+
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		dout, derr := md.kubectlRun(ctx, "delete", "--filename="+p, "--ignore-not-found")
+		cancel()
+		if derr != nil {
+			md.lg.Warn("failed to delete node log collector", zap.String("output", string(dout)), zap.Error(derr))
+		} else {
+			md.lg.Info("deleted node log collector", zap.String("output", string(dout)))
+		}
+	}()
+
+	if err = waitNodeLogCollector(md.lg, md.kubectlRun, md.stopc, 5*time.Minute); err != nil {
+		return nil, err
+	}
+
+	return fetchWorkerNodeLogsViaDaemonSet(
+		md.lg,
+		md.kubectlRun,
+		md.cfg.ClusterName,
+		md.cfg.ClusterState.WorkerNodes,
+	)
+}
+
+// waitNodeLogCollector waits until all scheduled DaemonSet pods are ready.
+func waitNodeLogCollector(lg *zap.Logger, run kubectlRun, stopc chan struct{}, timeout time.Duration) error {
+	retryStart := time.Now().UTC()
+	for time.Now().UTC().Sub(retryStart) < timeout {
+		select {
+		case <-stopc:
+			return errors.New("node log collector wait aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		out, err := run(ctx,
+			"get", "daemonset", nodeLogCollectorName,
+			"--namespace="+nodeLogCollectorNamespace,
+			"-o", "jsonpath={.status.desiredNumberScheduled} {.status.numberReady}",
+		)
+		cancel()
+		if err != nil {
+			lg.Warn("failed to get node log collector", zap.String("output", string(out)), zap.Error(err))
+			continue
+		}
+		fields := strings.Fields(string(out))
+		if len(fields) != 2 {
+			continue
+		}
+		desired, _ := strconv.Atoi(fields[0])
+		ready, _ := strconv.Atoi(fields[1])
+		lg.Info("waiting for node log collector", zap.Int("desired", desired), zap.Int("ready", ready))
+		if desired > 0 && desired == ready {
+			return nil
+		}
+	}
+	return fmt.Errorf("node log collector not ready after %v", timeout)
+}
+
+// fetchWorkerNodeLogsViaDaemonSet fetches logs from every node log collector pod.
+// Node names are mapped to EC2 instances by private DNS name, so that file names
+// and remote paths match the SSH log collector.
+func fetchWorkerNodeLogsViaDaemonSet(
+	lg *zap.Logger,
+	run kubectlRun,
+	clusterName string,
+	workerNodes map[string]ec2config.Instance) (fpathToS3Path map[string]string, err error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	out, err := run(ctx,
+		"get", "pods",
+		"--namespace="+nodeLogCollectorNamespace,
+		"--selector=app="+nodeLogCollectorName,
+		"-o", `jsonpath={range .items[*]}{.metadata.name} {.spec.nodeName}{"\n"}{end}`,
+	)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node log collector pods %v (%q)", err, string(out))
+	}
+
+	nodeToInstance := make(map[string]ec2config.Instance)
+	for _, iv := range workerNodes {
+		nodeToInstance[iv.PrivateDNSName] = iv
+	}
+
+	c := make(chan fetchResponse)
+	const batchN = 200
+
+	fpathToS3Path = make(map[string]string)
+	possibleErrors := make(map[string]int)
+
+	i, total := 0, 0
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		podName, nodeName := fields[0], fields[1]
+
+		// same prefix as SSH log collector, if the instance is known
+		pfx := nodeName
+		if iv, ok := nodeToInstance[nodeName]; ok {
+			pfx = strings.TrimSpace(fmt.Sprintf("%s-%s", iv.InstanceID, iv.PublicIP))
+		}
+
+		go func() {
+			fm, e := fetchWorkerNodeLogViaPod(lg, run, clusterName, podName, pfx)
+			c <- fetchResponse{data: fm, err: e}
+		}()
+		i++
+		total++
+		if i == batchN {
+			joinData(c, fpathToS3Path, i, possibleErrors)
+			i = 0
+		}
+	}
+	if i > 0 {
+		joinData(c, fpathToS3Path, i, possibleErrors)
+	}
+	if total == 0 {
+		return nil, errors.New("no node log collector pod found")
+	}
+
+	if len(possibleErrors) > 0 {
+		var sb strings.Builder
+		for strErr, occ := range possibleErrors {
+			sb.WriteString(fmt.Sprintf("%v: %v, ", strErr, occ))
+		}
+		err = errors.New(sb.String())
+	}
+	return fpathToS3Path, err
+}
+
+func fetchWorkerNodeLogViaPod(
+	lg *zap.Logger,
+	run kubectlRun,
+	clusterName string,
+	podName string,
+	pfx string) (fpathToS3Path map[string]string, err error) {
+	fpathToS3Path = make(map[string]string)
+
+	podExec := func(args ...string) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+		defer cancel()
+		args = append([]string{"exec", "--namespace=" + nodeLogCollectorNamespace, podName, "--"}, args...)
+		out, err := run(ctx, args...)
+		if err != nil {
+			lg.Warn(
+				"failed to run command",
+				zap.String("pod", podName),
+				zap.String("cmd", strings.Join(args, " ")),
+				zap.Error(err),
+			)
+		}
+		return out, err
+	}
+	// run in the host mount namespace
+	hostExec := func(args ...string) ([]byte, error) {
+		return podExec(append([]string{"nsenter", "--target=1", "--mount", "--"}, args...)...)
+	}
+	write := func(name string, out []byte) error {
+		fpath, err := fileutil.WriteToTempDir(pfx+name, out)
+		if err != nil {
+			lg.Warn("failed to write output", zap.String("pod", podName), zap.Error(err))
+			return err
+		}
+		fpathToS3Path[fpath] = filepath.Join(clusterName, pfx, filepath.Base(fpath))
+		return nil
+	}
+
+	lg.Info("fetching worker node logs via pod", zap.String("pod", podName), zap.String("prefix", pfx))
+
+	// https://github.com/awslabs/amazon-eks-ami/blob/master/files/logrotate-kube-proxy
+	out, err := podExec("cat", "/var/log/kube-proxy.log")
+	if err != nil {
+		return nil, err
+	}
+	if err = write(".kube-proxy.log", out); err != nil {
+		return nil, err
+	}
+
+	// kernel logs
+	out, err = hostExec("journalctl", "--no-pager", "--output=short-precise", "-k")
+	if err != nil {
+		return nil, err
+	}
+	if err = write(".kernel.log", out); err != nil {
+		return nil, err
+	}
+
+	// full journal logs (e.g. disk mounts)
+	out, err = hostExec("journalctl", "--no-pager", "--output=short-precise")
+	if err != nil {
+		return nil, err
+	}
+	if err = write(".journal.log", out); err != nil {
+		return nil, err
+	}
+
+	// other systemd services
+	out, err = hostExec("systemctl", "list-units", "-t", "service", "--no-pager", "--no-legend", "--all")
+	if err != nil {
+		return nil, err
+	}
+	for _, svc := range parseSystemdServices(out) {
+		out, err = hostExec("journalctl", "--no-pager", "--output=cat", "-u", svc)
+		if err != nil {
+			continue
+		}
+		if len(out) == 0 {
+			lg.Info("empty log", zap.String("service", svc))
+			continue
+		}
+		if err = write("."+svc+".log", out); err != nil {
+			return nil, err
+		}
+	}
+
+	// other /var/log
+	out, err = podExec("find", "/var/log", "!", "-type", "d")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range parseVarLogs(out) {
+		out, err = podExec("cat", p)
+		if err != nil {
+			continue
+		}
+		if len(out) == 0 {
+			lg.Info("empty log", zap.String("path", p))
+			continue
+		}
+		if err = write(strings.Replace(p, "/", ".", -1), out); err != nil {
+			return nil, err
+		}
+	}
+
+	return fpathToS3Path, nil
+}
@@ -0,0 +1,81 @@
+package eks
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
+
+	"go.uber.org/zap"
+)
+
+func TestFetchWorkerNodeLogsViaDaemonSet(t *testing.T) {
+	run := func(ctx context.Context, args ...string) ([]byte, error) {
+		cmd := strings.Join(args, " ")
+		if strings.HasPrefix(cmd, "get pods") {
+			return []byte("collector-a ip-192-168-1-1.us-west-2.compute.internal\ncollector-b ip-192-168-2-2.us-west-2.compute.internal\n"), nil
+		}
+		if !strings.HasPrefix(cmd, "exec --namespace=kube-system collector-") {
+			return nil, errors.New("unexpected command " + cmd)
+		}
+		cmd = cmd[strings.Index(cmd, " -- ")+4:]
+		switch cmd {
+		case "nsenter --target=1 --mount -- systemctl list-units -t service --no-pager --no-legend --all":
+			return []byte(`kubelet.service loaded active running Kubernetes Kubelet
+auth-rpcgss-module.service loaded inactive dead Kernel Module supporting RPCSEC_GSS
+`), nil
+		case "find /var/log ! -type d":
+			return []byte("/var/log/messages\n/var/log/empty\n"), nil
+		case "cat /var/log/empty":
+			return nil, nil
+		}
+		return []byte(cmd), nil
+	}
+
+	workerNodes := map[string]ec2config.Instance{
+		"i-a": {InstanceID: "i-a", PublicIP: "1.1.1.1", PrivateDNSName: "ip-192-168-1-1.us-west-2.compute.internal"},
+	}
+	fpathToS3Path, err := fetchWorkerNodeLogsViaDaemonSet(zap.NewNop(), run, "test-cluster", workerNodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s3PathToFpath := make(map[string]string)
+	for fpath, s3Path := range fpathToS3Path {
+		defer os.RemoveAll(fpath)
+		s3PathToFpath[s3Path] = fpath
+	}
+
+	for _, pfx := range []string{"i-a-1.1.1.1", "ip-192-168-2-2.us-west-2.compute.internal"} {
+		for _, name := range []string{
+			".kube-proxy.log",
+			".kernel.log",
+			".journal.log",
+			".kubelet.service.log",
+			".var.log.messages",
+		} {
+			s3Path := filepath.Join("test-cluster", pfx, pfx+name)
+			if _, ok := s3PathToFpath[s3Path]; !ok {
+				t.Fatalf("%q not found in %v", s3Path, fpathToS3Path)
+			}
+		}
+		for _, name := range []string{".auth-rpcgss-module.service.log", ".var.log.empty"} {
+			s3Path := filepath.Join("test-cluster", pfx, pfx+name)
+			if _, ok := s3PathToFpath[s3Path]; ok {
+				t.Fatalf("unexpected %q", s3Path)
+			}
+		}
+	}
+
+	d, err := ioutil.ReadFile(s3PathToFpath[filepath.Join("test-cluster", "i-a-1.1.1.1", "i-a-1.1.1.1.kernel.log")])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "nsenter --target=1 --mount -- journalctl --no-pager --output=short-precise -k"; string(d) != exp {
+		t.Fatalf("expected %q, got %q", exp, string(d))
+	}
+}
@@ -2,47 +2,73 @@ package eks
 
 import (
 	"bytes"
-	"os"
+	"fmt"
+	"io/ioutil"
+	"sort"
 	"text/template"
 
-	"github.com/aws/aws-k8s-tester/pkg/httputil"
-	"go.uber.org/zap"
+	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"sigs.k8s.io/yaml"
 )
 
-func createWorkerNodeTemplateFromURL(lg *zap.Logger) (string, error) {
-	d, err := httputil.Download(lg, os.Stdout, workerNodeStackTemplateURL)
-	if err != nil {
-		return "", nil
-	}
-	return string(d) + `
-  NodeSecurityGroup:
-    Description: The security group for the node group
-    Value: !Ref NodeSecurityGroup
-`, nil
+// workerNodeStackTemplateVersion is the set of features
+// in each release of upstream worker node group template.
+// https://github.com/awslabs/amazon-eks-ami/blob/master/amazon-eks-nodegroup.yaml
+type workerNodeStackTemplateVersion struct {
+	// BootstrapArguments is true to pass extra arguments to "/etc/eks/bootstrap.sh".
+	BootstrapArguments bool
+	// DesiredCapacity is true to set the ASG desired capacity separately.
+	// Otherwise, the desired capacity is the ASG max size.
+	DesiredCapacity bool
+	// ExtensionAPIServer is true to allow control plane to
+	// reach pods running extension API servers on port 443.
+	ExtensionAPIServer bool
 }
 
-// https://docs.aws.amazon.com/eks/latest/userguide/launch-workers.html
-// https://github.com/awslabs/amazon-eks-ami/blob/master/amazon-eks-nodegroup.yaml
-const workerNodeStackTemplateURL = "https://amazon-eks.s3-us-west-2.amazonaws.com/cloudformation/2019-02-11/amazon-eks-nodegroup.yaml"
+// workerNodeStackTemplateVersions is the embedded worker node group templates,
+// keyed by the upstream release version (see "eksconfig.Config.WorkerNodeCFTemplateVersion").
+// https://amazon-eks.s3-us-west-2.amazonaws.com/cloudformation/2018-08-30/amazon-eks-nodegroup.yaml
+// https://amazon-eks.s3-us-west-2.amazonaws.com/cloudformation/2019-02-11/amazon-eks-nodegroup.yaml
+var workerNodeStackTemplateVersions = map[string]workerNodeStackTemplateVersion{
+	"2018-08-30": {},
+	"2019-02-11": {BootstrapArguments: true, DesiredCapacity: true, ExtensionAPIServer: true},
+}
 
-//
-//
-//
-//
-//
-//
-//
-//
-//
-//
-//
-//
-//
-//
-//
+type workerNodeStack struct {
+	workerNodeStackTemplateVersion
+
+	Description                          string
+	Tag                                  string
+	TagValue                             string
+	Hostname                             string
+	EnableWorkerNodeSSH                  bool
+	EnableWorkerNodePrivilegedPortAccess bool
+	// PrivateSubnets is true to launch worker nodes without public IPs.
+	PrivateSubnets bool
+	// Spot is non-nil to launch worker nodes with a mixed instances policy,
+	// which requires a launch template instead of a launch configuration.
+	Spot *ec2config.Spot
+}
+
+// workerNodeSpot returns the Spot options for worker node template,
+// or nil if Spot instances are disabled.
+func workerNodeSpot(sp *ec2config.Spot) *ec2config.Spot {
+	if sp == nil || !sp.Enable {
+		return nil
+	}
+	return sp
+}
+
+// createWorkerNodeTemplate renders the embedded worker node group template
+// of the version.
+func createWorkerNodeTemplate(version string, v workerNodeStack) (string, error) {
+	tv, ok := workerNodeStackTemplateVersions[version]
+	if !ok {
+		return "", fmt.Errorf("unknown worker node template version %q", version)
+	}
+	v.workerNodeStackTemplateVersion = tv
 
-// TODO: not working...
-func _createWorkerNodeTemplate(v workerNodeStack) (string, error) {
 	tpl := template.Must(template.New("workerNodeStackTemplate").Parse(workerNodeStackTemplate))
 	buf := bytes.NewBuffer(nil)
 	if err := tpl.Execute(buf, v); err != nil {
@@ -51,22 +77,67 @@ func _createWorkerNodeTemplate(v workerNodeStack) (string, error) {
 	return buf.String(), nil
 }
 
-type workerNodeStack struct {
-	Description                          string
-	Tag                                  string
-	TagValue                             string
-	Hostname                             string
-	EnableWorkerNodeSSH                  bool
-	EnableWorkerNodePrivilegedPortAccess bool
+// readWorkerNodeTemplate reads the local worker node group template,
+// and checks that it outputs the resources that the tester looks up.
+func readWorkerNodeTemplate(p string) (string, error) {
+	d, err := ioutil.ReadFile(p)
+	if err != nil {
+		return "", err
+	}
+	var tmpl struct {
+		Outputs map[string]interface{} `json:"Outputs"`
+	}
+	if err = yaml.Unmarshal(d, &tmpl); err != nil {
+		return "", fmt.Errorf("failed to parse worker node template %q (%v)", p, err)
+	}
+	for _, k := range []string{"NodeInstanceRole", "NodeSecurityGroup"} {
+		if _, ok := tmpl.Outputs[k]; !ok {
+			return "", fmt.Errorf("worker node template %q does not output %q", p, k)
+		}
+	}
+	return string(d), nil
+}
+
+// parseTemplateParameters returns the sorted parameter keys
+// declared in the CloudFormation template.
+func parseTemplateParameters(s string) ([]string, error) {
+	var tmpl struct {
+		Parameters map[string]interface{} `json:"Parameters"`
+	}
+	if err := yaml.Unmarshal([]byte(s), &tmpl); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(tmpl.Parameters))
+	for k := range tmpl.Parameters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// filterTemplateParameters drops the parameters not declared in the template,
+// since CloudFormation rejects undeclared parameters (e.g. older templates
+// without "NodeAutoScalingGroupDesiredCapacity").
+func filterTemplateParameters(declared []string, params []*cloudformation.Parameter) []*cloudformation.Parameter {
+	keys := make(map[string]struct{}, len(declared))
+	for _, k := range declared {
+		keys[k] = struct{}{}
+	}
+	filtered := make([]*cloudformation.Parameter, 0, len(params))
+	for _, p := range params {
+		if _, ok := keys[*p.ParameterKey]; ok {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
 }
 
-// TODO: this does not work...
-//  	CREATE_FAILED 	AWS::AutoScaling::AutoScalingGroup 	NodeGroup 	PropagateAtLaunch not found in properties
-//
 // Make sure to keep this up-to-date
 // https://github.com/awslabs/amazon-eks-ami/blob/master/amazon-eks-nodegroup.yaml
-// https://docs.aws.amazon.com/eks/latest/userguide/getting-started.html
-// https://amazon-eks.s3-us-west-2.amazonaws.com/cloudformation/2018-08-30/amazon-eks-nodegroup.yaml
+// https://docs.aws.amazon.com/eks/latest/userguide/launch-workers.html
+//
+// "NodeInstanceType" has no "AllowedValues", since eksconfig validates the
+// instance type against "pkg/awsapi/ec2.InstanceTypes".
 const workerNodeStackTemplate = `---
 AWSTemplateFormatVersion: '2010-09-09'
 Description: {{ .Description }}
@@ -85,88 +156,6 @@ Parameters:
     Description: EC2 instance type for the node instances
     Type: String
     Default: t2.medium
-    AllowedValues:
-    - t2.small
-    - t2.medium
-    - t2.large
-    - t2.xlarge
-    - t2.2xlarge
-    - t3.nano
-    - t3.micro
-    - t3.small
-    - t3.medium
-    - t3.large
-    - t3.xlarge
-    - t3.2xlarge
-    - m3.medium
-    - m3.large
-    - m3.xlarge
-    - m3.2xlarge
-    - m4.large
-    - m4.xlarge
-    - m4.2xlarge
-    - m4.4xlarge
-    - m4.10xlarge
-    - m5.large
-    - m5.xlarge
-    - m5.2xlarge
-    - m5.4xlarge
-    - m5.12xlarge
-    - m5.24xlarge
-    - c4.large
-    - c4.xlarge
-    - c4.2xlarge
-    - c4.4xlarge
-    - c4.8xlarge
-    - c5.large
-    - c5.xlarge
-    - c5.2xlarge
-    - c5.4xlarge
-    - c5.9xlarge
-    - c5.18xlarge
-    - i3.large
-    - i3.xlarge
-    - i3.2xlarge
-    - i3.4xlarge
-    - i3.8xlarge
-    - i3.16xlarge
-    - r3.xlarge
-    - r3.2xlarge
-    - r3.4xlarge
-    - r3.8xlarge
-    - r4.large
-    - r4.xlarge
-    - r4.2xlarge
-    - r4.4xlarge
-    - r4.8xlarge
-    - r4.16xlarge
-    - x1.16xlarge
-    - x1.32xlarge
-    - p2.xlarge
-    - p2.8xlarge
-    - p2.16xlarge
-    - p3.2xlarge
-    - p3.8xlarge
-    - p3.16xlarge
-    - r5.large
-    - r5.xlarge
-    - r5.2xlarge
-    - r5.4xlarge
-    - r5.12xlarge
-    - r5.24xlarge
-    - r5d.large
-    - r5d.xlarge
-    - r5d.2xlarge
-    - r5d.4xlarge
-    - r5d.12xlarge
-    - r5d.24xlarge
-    - z1d.large
-    - z1d.xlarge
-    - z1d.2xlarge
-    - z1d.3xlarge
-    - z1d.6xlarge
-    - z1d.12xlarge
-    ConstraintDescription: Must be a valid EC2 instance type
 
   NodeAutoScalingGroupMinSize:
     Type: Number
@@ -177,7 +166,12 @@ Parameters:
     Type: Number
     Description: Maximum size of Node Group ASG.
     Default: 3
-
+{{ if .DesiredCapacity }}
+  NodeAutoScalingGroupDesiredCapacity:
+    Type: Number
+    Description: Desired capacity of Node Group ASG.
+    Default: 3
+{{ end }}
   NodeVolumeSize:
     Type: Number
     Description: Node volume size
@@ -187,11 +181,11 @@ Parameters:
     Description: The cluster name provided when the cluster was created. If it is incorrect, nodes will not be able to join the cluster.
     Type: String
 
-  BootstrapArguments:
+{{ if .BootstrapArguments }}  BootstrapArguments:
     Description: Arguments to pass to the bootstrap script. See files/bootstrap.sh in https://github.com/awslabs/amazon-eks-ami
     Default: ""
     Type: String
-
+{{ end }}
   NodeGroupName:
     Description: Unique identifier for the Node Group.
     Type: String
@@ -223,12 +217,13 @@ Metadata:
         Parameters:
           - NodeGroupName
           - NodeAutoScalingGroupMinSize
-          - NodeAutoScalingGroupMaxSize
+          - NodeAutoScalingGroupMaxSize{{ if .DesiredCapacity }}
+          - NodeAutoScalingGroupDesiredCapacity{{ end }}
           - NodeInstanceType
           - NodeImageId
           - NodeVolumeSize
-          - KeyName
-          - BootstrapArguments
+          - KeyName{{ if .BootstrapArguments }}
+          - BootstrapArguments{{ end }}
       -
         Label:
           default: "Worker Network Configuration"
@@ -296,7 +291,7 @@ Resources:
       GroupId: !Ref NodeSecurityGroup
       SourceSecurityGroupId: !Ref ClusterControlPlaneSecurityGroup
       IpProtocol: tcp
-      FromPort: {{ if .EnableWorkerNodePrivilegedPortAccess }} 1 {{else}} 1025 {{end}}
+      FromPort: {{ if .EnableWorkerNodePrivilegedPortAccess }}1{{ else }}1025{{ end }}
       ToPort: 65535
 
   ControlPlaneEgressToNodeSecurityGroup:
@@ -307,10 +302,10 @@ Resources:
       GroupId: !Ref ClusterControlPlaneSecurityGroup
       DestinationSecurityGroupId: !Ref NodeSecurityGroup
       IpProtocol: tcp
-      FromPort: {{ if .EnableWorkerNodePrivilegedPortAccess }} 1 {{else}} 1025 {{end}}
+      FromPort: {{ if .EnableWorkerNodePrivilegedPortAccess }}1{{ else }}1025{{ end }}
       ToPort: 65535
 
-  NodeSecurityGroupFromControlPlaneOn443Ingress:
+{{ if .ExtensionAPIServer }}  NodeSecurityGroupFromControlPlaneOn443Ingress:
     Type: AWS::EC2::SecurityGroupIngress
     DependsOn: NodeSecurityGroup
     Properties:
@@ -331,7 +326,7 @@ Resources:
       IpProtocol: tcp
       FromPort: 443
       ToPort: 443
-
+{{ end }}
   ClusterControlPlaneSecurityGroupIngress:
     Type: AWS::EC2::SecurityGroupIngress
     DependsOn: NodeSecurityGroup
@@ -343,22 +338,44 @@ Resources:
       ToPort: 443
       FromPort: 443
 
-{{ if .EnableWorkerNodeSSH }}  ClusterControlPlaneSecurityGroupIngress22:
+{{ if .EnableWorkerNodeSSH }}  NodeSecurityGroupSSHIngress:
     Type: AWS::EC2::SecurityGroupIngress
     DependsOn: NodeSecurityGroup
     Properties:
       Description: Allow SSH access to worker nodes
-      GroupId: !Ref ClusterControlPlaneSecurityGroup
-      SourceSecurityGroupId: !Ref NodeSecurityGroup
+      GroupId: !Ref NodeSecurityGroup
+      CidrIp: 0.0.0.0/0
       IpProtocol: tcp
+      FromPort: 22
       ToPort: 22
-      FromPort: 22{{ end }}
 
-  NodeGroup:
+{{ end }}  NodeGroup:
     Type: AWS::AutoScaling::AutoScalingGroup
     Properties:
-      DesiredCapacity: !Ref NodeAutoScalingGroupMaxSize
+      DesiredCapacity: !Ref {{ if .DesiredCapacity }}NodeAutoScalingGroupDesiredCapacity{{ else }}NodeAutoScalingGroupMaxSize{{ end }}
+{{- if .Spot }}
+      MixedInstancesPolicy:
+        InstancesDistribution:
+          OnDemandAllocationStrategy: prioritized
+          OnDemandBaseCapacity: {{ .Spot.OnDemandBaseCapacity }}
+          OnDemandPercentageAboveBaseCapacity: {{ .Spot.OnDemandPercentageAboveBaseCapacity }}
+          SpotAllocationStrategy: lowest-price
+          SpotInstancePools: {{ .Spot.InstancePools }}
+{{- if .Spot.MaxPrice }}
+          SpotMaxPrice: '{{ .Spot.MaxPrice }}'
+{{- end }}
+        LaunchTemplate:
+          LaunchTemplateSpecification:
+            LaunchTemplateId: !Ref NodeLaunchTemplate
+            Version: !GetAtt NodeLaunchTemplate.LatestVersionNumber
+          Overrides:
+          - InstanceType: !Ref NodeInstanceType
+{{- range .Spot.InstanceTypes }}
+          - InstanceType: {{ . }}
+{{- end }}
+{{- else }}
       LaunchConfigurationName: !Ref NodeLaunchConfig
+{{- end }}
       MinSize: !Ref NodeAutoScalingGroupMinSize
       MaxSize: !Ref NodeAutoScalingGroupMaxSize
       VPCZoneIdentifier:
@@ -372,17 +389,50 @@ Resources:
         PropagateAtLaunch: 'true'
       - Key: {{ .Tag }}
         Value: {{ .TagValue }}
+        PropagateAtLaunch: 'true'
       - Key: HOSTNAME
         Value: {{ .Hostname }}
+        PropagateAtLaunch: 'true'
     UpdatePolicy:
       AutoScalingRollingUpdate:
         MinInstancesInService: '1'
         MaxBatchSize: '1'
 
-  NodeLaunchConfig:
+{{ if .Spot }}  NodeLaunchTemplate:
+    Type: AWS::EC2::LaunchTemplate
+    Properties:
+      LaunchTemplateData:
+        IamInstanceProfile:
+          Arn: !GetAtt NodeInstanceProfile.Arn
+        ImageId: !Ref NodeImageId
+        InstanceType: !Ref NodeInstanceType
+        KeyName: !Ref KeyName
+        NetworkInterfaces:
+        - DeviceIndex: 0
+          AssociatePublicIpAddress: {{ if .PrivateSubnets }}false{{ else }}true{{ end }}
+          DeleteOnTermination: true
+          Groups:
+          - !Ref NodeSecurityGroup
+        BlockDeviceMappings:
+          - DeviceName: /dev/xvda
+            Ebs:
+              VolumeSize: !Ref NodeVolumeSize
+              VolumeType: gp2
+              DeleteOnTermination: true
+        UserData:
+          Fn::Base64:
+            !Sub |
+              #!/bin/bash
+              set -o xtrace
+              /etc/eks/bootstrap.sh ${ClusterName}{{ if .BootstrapArguments }} ${BootstrapArguments}{{ end }}
+              /opt/aws/bin/cfn-signal --exit-code $? \
+                       --stack  ${AWS::StackName} \
+                       --resource NodeGroup  \
+                       --region ${AWS::Region}
+{{ else }}  NodeLaunchConfig:
     Type: AWS::AutoScaling::LaunchConfiguration
     Properties:
-      AssociatePublicIpAddress: 'true'
+      AssociatePublicIpAddress: '{{ if .PrivateSubnets }}false{{ else }}true{{ end }}'
       IamInstanceProfile: !Ref NodeInstanceProfile
       ImageId: !Ref NodeImageId
       InstanceType: !Ref NodeInstanceType
@@ -400,12 +450,12 @@ Resources:
           !Sub |
             #!/bin/bash
             set -o xtrace
-            /etc/eks/bootstrap.sh ${ClusterName} ${BootstrapArguments}
+            /etc/eks/bootstrap.sh ${ClusterName}{{ if .BootstrapArguments }} ${BootstrapArguments}{{ end }}
             /opt/aws/bin/cfn-signal --exit-code $? \
                      --stack  ${AWS::StackName} \
                      --resource NodeGroup  \
                      --region ${AWS::Region}
-
+{{ end }}
 Outputs:
   NodeInstanceRole:
     Description: The node instance role
@@ -1,29 +1,244 @@
 package eks
 
 import (
-	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"sigs.k8s.io/yaml"
 )
 
 func TestWorkerNodeTemplate(t *testing.T) {
-	v := workerNodeStack{
-		Description:                          "test",
-		Tag:                                  "aws-k8s-tester",
-		TagValue:                             "aws-k8s-tester",
-		Hostname:                             "hostname",
-		EnableWorkerNodeSSH:                  true,
-		EnableWorkerNodePrivilegedPortAccess: false,
-	}
-	s, err := _createWorkerNodeTemplate(v)
+	tests := []struct {
+		version string
+		v       workerNodeStack
+		params  []string
+	}{
+		{
+			version: "2018-08-30",
+			v: workerNodeStack{
+				Description:                          "test",
+				Tag:                                  "aws-k8s-tester",
+				TagValue:                             "aws-k8s-tester",
+				Hostname:                             "hostname",
+				EnableWorkerNodeSSH:                  false,
+				EnableWorkerNodePrivilegedPortAccess: true,
+			},
+			params: []string{
+				"ClusterControlPlaneSecurityGroup",
+				"ClusterName",
+				"KeyName",
+				"NodeAutoScalingGroupMaxSize",
+				"NodeAutoScalingGroupMinSize",
+				"NodeGroupName",
+				"NodeImageId",
+				"NodeInstanceType",
+				"NodeVolumeSize",
+				"Subnets",
+				"VpcId",
+			},
+		},
+		{
+			version: "2019-02-11",
+			v: workerNodeStack{
+				Description:                          "test",
+				Tag:                                  "aws-k8s-tester",
+				TagValue:                             "aws-k8s-tester",
+				Hostname:                             "hostname",
+				EnableWorkerNodeSSH:                  true,
+				EnableWorkerNodePrivilegedPortAccess: false,
+			},
+			params: []string{
+				"BootstrapArguments",
+				"ClusterControlPlaneSecurityGroup",
+				"ClusterName",
+				"KeyName",
+				"NodeAutoScalingGroupDesiredCapacity",
+				"NodeAutoScalingGroupMaxSize",
+				"NodeAutoScalingGroupMinSize",
+				"NodeGroupName",
+				"NodeImageId",
+				"NodeInstanceType",
+				"NodeVolumeSize",
+				"Subnets",
+				"VpcId",
+			},
+		},
+		{
+			version: "2019-02-11",
+			v: workerNodeStack{
+				Description:    "test",
+				Tag:            "aws-k8s-tester",
+				TagValue:       "aws-k8s-tester",
+				Hostname:       "hostname",
+				PrivateSubnets: true,
+				Spot: &ec2config.Spot{
+					Enable:                              true,
+					InstanceTypes:                       []string{"m5.xlarge", "c5.xlarge"},
+					OnDemandPercentageAboveBaseCapacity: 50,
+					InstancePools:                       2,
+				},
+			},
+			params: []string{
+				"BootstrapArguments",
+				"ClusterControlPlaneSecurityGroup",
+				"ClusterName",
+				"KeyName",
+				"NodeAutoScalingGroupDesiredCapacity",
+				"NodeAutoScalingGroupMaxSize",
+				"NodeAutoScalingGroupMinSize",
+				"NodeGroupName",
+				"NodeImageId",
+				"NodeInstanceType",
+				"NodeVolumeSize",
+				"Subnets",
+				"VpcId",
+			},
+		},
+	}
+	for i, tt := range tests {
+		s, err := createWorkerNodeTemplate(tt.version, tt.v)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+
+		params, err := parseTemplateParameters(s)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if !reflect.DeepEqual(params, tt.params) {
+			t.Fatalf("#%d: expected parameters %v, got %v", i, tt.params, params)
+		}
+
+		var tmpl struct {
+			Resources map[string]struct {
+				Type       string                 `json:"Type"`
+				Properties map[string]interface{} `json:"Properties"`
+			} `json:"Resources"`
+			Outputs map[string]interface{} `json:"Outputs"`
+		}
+		if err = yaml.Unmarshal([]byte(s), &tmpl); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		for _, k := range []string{"NodeInstanceRole", "NodeSecurityGroup"} {
+			if _, ok := tmpl.Outputs[k]; !ok {
+				t.Fatalf("#%d: expected output %q", i, k)
+			}
+		}
+
+		// every reference must resolve to a parameter, resource, or pseudo parameter
+		declared := make(map[string]struct{})
+		for _, k := range params {
+			declared[k] = struct{}{}
+		}
+		for k := range tmpl.Resources {
+			declared[k] = struct{}{}
+		}
+		for _, m := range refRegex.FindAllStringSubmatch(s, -1) {
+			ref := m[1] + m[2]
+			if strings.HasPrefix(ref, "AWS::") {
+				continue
+			}
+			if _, ok := declared[ref]; !ok {
+				t.Fatalf("#%d: undeclared reference %q", i, ref)
+			}
+		}
+
+		// "PropagateAtLaunch" is required for all auto scaling group tags
+		tags, _ := tmpl.Resources["NodeGroup"].Properties["Tags"].([]interface{})
+		if len(tags) == 0 {
+			t.Fatalf("#%d: expected NodeGroup tags", i)
+		}
+		for _, tag := range tags {
+			if _, ok := tag.(map[string]interface{})["PropagateAtLaunch"]; !ok {
+				t.Fatalf("#%d: tag %v has no PropagateAtLaunch", i, tag)
+			}
+		}
+
+		// mixed instances policy requires a launch template
+		ng := tmpl.Resources["NodeGroup"].Properties
+		_, lt := tmpl.Resources["NodeLaunchTemplate"]
+		_, lc := tmpl.Resources["NodeLaunchConfig"]
+		_, mixed := ng["MixedInstancesPolicy"]
+		_, lcName := ng["LaunchConfigurationName"]
+		spot := tt.v.Spot != nil
+		if lt != spot || mixed != spot || lc == spot || lcName == spot {
+			t.Fatalf("#%d: expected Spot %v, got launch template %v, mixed instances policy %v, launch configuration %v", i, spot, lt, mixed, lc)
+		}
+		if spot {
+			overrides := ng["MixedInstancesPolicy"].(map[string]interface{})["LaunchTemplate"].(map[string]interface{})["Overrides"].([]interface{})
+			if len(overrides) != 1+len(tt.v.Spot.InstanceTypes) {
+				t.Fatalf("#%d: unexpected overrides %v", i, overrides)
+			}
+			if !strings.Contains(s, "AssociatePublicIpAddress: false") {
+				t.Fatalf("#%d: expected no public IP in private subnets", i)
+			}
+		}
+
+		_, ok := tmpl.Resources["NodeSecurityGroupSSHIngress"]
+		if ok != tt.v.EnableWorkerNodeSSH {
+			t.Fatalf("#%d: expected SSH ingress %v, got %v", i, tt.v.EnableWorkerNodeSSH, ok)
+		}
+		fromPort := tmpl.Resources["NodeSecurityGroupFromControlPlaneIngress"].Properties["FromPort"]
+		if tt.v.EnableWorkerNodePrivilegedPortAccess && fromPort != float64(1) {
+			t.Fatalf("#%d: expected FromPort 1, got %v", i, fromPort)
+		}
+		if !tt.v.EnableWorkerNodePrivilegedPortAccess && fromPort != float64(1025) {
+			t.Fatalf("#%d: expected FromPort 1025, got %v", i, fromPort)
+		}
+	}
+
+	if _, err := createWorkerNodeTemplate("2017-01-01", workerNodeStack{}); err == nil {
+		t.Fatal("expected error for unknown template version")
+	}
+}
+
+var refRegex = regexp.MustCompile(`!(?:Ref|GetAtt) ([A-Za-z0-9:]+)|\$\{([A-Za-z0-9:]+)\}`)
+
+func TestFilterTemplateParameters(t *testing.T) {
+	params := filterTemplateParameters(
+		[]string{"ClusterName", "NodeAutoScalingGroupMaxSize"},
+		[]*cloudformation.Parameter{
+			{ParameterKey: aws.String("ClusterName"), ParameterValue: aws.String("a")},
+			{ParameterKey: aws.String("NodeAutoScalingGroupMaxSize"), ParameterValue: aws.String("3")},
+			{ParameterKey: aws.String("NodeAutoScalingGroupDesiredCapacity"), ParameterValue: aws.String("2")},
+		},
+	)
+	if len(params) != 2 || *params[0].ParameterKey != "ClusterName" || *params[1].ParameterKey != "NodeAutoScalingGroupMaxSize" {
+		t.Fatalf("unexpected parameters %v", params)
+	}
+}
+
+func TestReadWorkerNodeTemplate(t *testing.T) {
+	f, err := ioutil.TempFile(os.TempDir(), "worker-node-template")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if v.EnableWorkerNodeSSH && !strings.Contains(s, "ClusterControlPlaneSecurityGroupIngress22") {
-		t.Fatal("expected 'ClusterControlPlaneSecurityGroupIngress22'")
+	p := f.Name()
+	f.Close()
+	defer os.RemoveAll(p)
+
+	if err = ioutil.WriteFile(p, []byte("Outputs:\n  NodeInstanceRole:\n    Value: !GetAtt NodeInstanceRole.Arn\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = readWorkerNodeTemplate(p); err == nil || !strings.Contains(err.Error(), "NodeSecurityGroup") {
+		t.Fatalf("expected missing NodeSecurityGroup output error, got %v", err)
 	}
-	if v.EnableWorkerNodePrivilegedPortAccess && !strings.Contains(s, "FromPort: 1025") {
-		t.Fatal("expected 'NodeSecurityGroupFromControlPlaneIngress' and 'ControlPlaneEgressToNodeSecurityGroup' to have FromPort = 1025")
+
+	s, err := createWorkerNodeTemplate("2019-02-11", workerNodeStack{Tag: "a", TagValue: "b", Hostname: "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(p, []byte(s), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = readWorkerNodeTemplate(p); err != nil {
+		t.Fatal(err)
 	}
-	fmt.Println(s)
 }
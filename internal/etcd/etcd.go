@@ -15,6 +15,7 @@ import (
 	etcdplugin "github.com/aws/aws-k8s-tester/etcdconfig/plugins"
 	"github.com/aws/aws-k8s-tester/internal/ec2"
 	"github.com/aws/aws-k8s-tester/internal/ssh"
+	ec2types "github.com/aws/aws-k8s-tester/pkg/awsapi/ec2"
 	"github.com/aws/aws-k8s-tester/pkg/fileutil"
 	"github.com/aws/aws-k8s-tester/pkg/zaputil"
 	"github.com/aws/aws-k8s-tester/storagetester"
@@ -64,8 +65,8 @@ func (md *embedded) Create() (err error) {
 		zap.Strings("plugins", md.cfg.EC2.Plugins),
 	)
 	md.cfg.Tag = md.cfg.EC2.Tag + "-etcd"
-	md.cfg.ConfigPathURL = genS3URL(md.cfg.EC2.AWSRegion, md.cfg.Tag, md.cfg.EC2.ConfigPathBucket)
-	md.cfg.LogOutputToUploadPathURL = genS3URL(md.cfg.EC2.AWSRegion, md.cfg.Tag, md.cfg.EC2.LogOutputToUploadPathBucket)
+	md.cfg.ConfigPathURL = md.ec2Deployer.ArtifactURL(md.cfg.EC2.ConfigPathBucket)
+	md.cfg.LogOutputToUploadPathURL = md.ec2Deployer.ArtifactURL(md.cfg.EC2.LogOutputToUploadPathBucket)
 
 	if err = md.ec2Deployer.Create(); err != nil {
 		return err
@@ -672,7 +673,7 @@ func (md *embedded) Restart(id, ver string) (err error) {
 
 	md.lg.Info("installing etcd", zap.String("ver", ver))
 	var installScript string
-	installScript, err = etcdplugin.CreateInstallScript(ver)
+	installScript, err = etcdplugin.CreateInstallScript(ver, ec2types.GoArch(md.cfg.EC2.InstanceType))
 	if err != nil {
 		return err
 	}
@@ -952,7 +953,7 @@ func (md *embedded) MemberAdd(ver string) (err error) {
 
 	md.lg.Info("installing etcd", zap.String("ver", ver))
 	var installScript string
-	installScript, err = etcdplugin.CreateInstallScript(ver)
+	installScript, err = etcdplugin.CreateInstallScript(ver, ec2types.GoArch(md.cfg.EC2.InstanceType))
 	if err != nil {
 		return err
 	}
@@ -1186,9 +1187,3 @@ func fetchLog(
 	fpathToS3Path[etcdLogPath] = fmt.Sprintf("%s/%s-etcd.server.log", clusterName, id)
 	return fpathToS3Path, nil
 }
-
-// genS3URL returns S3 URL path.
-// e.g. https://s3-us-west-2.amazonaws.com/aws-k8s-tester-20180925/hello-world
-func genS3URL(region, bucket, s3Path string) string {
-	return fmt.Sprintf("https://s3-%s.amazonaws.com/%s/%s", region, bucket, s3Path)
-}
@@ -14,6 +14,15 @@ import (
 
 /*
 RUN_AWS_TESTS=1 go test -v -timeout 2h -run TestKubeadm
+
+highly-available control plane, with failover test:
+
+RUN_AWS_TESTS=1 \
+AWS_K8S_TESTER_KUBEADM_HA_MODE=stacked \
+AWS_K8S_TESTER_EC2_MASTER_NODES_CLUSTER_SIZE=3 \
+AWS_K8S_TESTER_EC2_MASTER_NODES_PLUGINS=update-amazon-linux-2,install-start-docker-amazon-linux-2,install-kubeadm-amazon-linux-2-1.15.0 \
+AWS_K8S_TESTER_EC2_WORKER_NODES_PLUGINS=update-amazon-linux-2,install-start-docker-amazon-linux-2,install-kubeadm-amazon-linux-2-1.15.0 \
+go test -v -timeout 2h -run TestKubeadm
 */
 func TestKubeadm(t *testing.T) {
 	if os.Getenv("RUN_AWS_TESTS") != "1" {
@@ -21,6 +30,9 @@ func TestKubeadm(t *testing.T) {
 	}
 
 	cfg := kubeadmconfig.NewDefault()
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
 	if err := cfg.ValidateAndSetDefaults(); err != nil {
 		t.Fatal(err)
 	}
@@ -35,6 +47,13 @@ func TestKubeadm(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	if cfg.HAMode != "" {
+		if err = dp.Failover(); err != nil {
+			dp.Terminate()
+			t.Fatal(err)
+		}
+	}
+
 	fmt.Printf("EC2MasterNodes SSH:\n%s\n\n", cfg.EC2MasterNodes.SSHCommands())
 	fmt.Printf("EC2WorkerNodes SSH:\n%s\n\n", cfg.EC2WorkerNodes.SSHCommands())
 
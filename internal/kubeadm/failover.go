@@ -0,0 +1,205 @@
+package kubeadm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/internal/ec2"
+	"github.com/aws/aws-k8s-tester/internal/ssh"
+	"github.com/aws/aws-sdk-go/aws"
+	awsec2 "github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"go.uber.org/zap"
+)
+
+// number of consecutive successful API server checks
+// after the stopped master node is out of service
+const failoverChecks = 10
+
+// load balancer health check of API servers,
+// in seconds between checks and consecutive failures
+const (
+	healthCheckInterval           = 10
+	healthCheckUnhealthyThreshold = 2
+)
+
+// failoverMaxDowntime is the maximum duration of API server check failures
+// until the load balancer takes the stopped master node out of service.
+const failoverMaxDowntime = healthCheckUnhealthyThreshold * healthCheckInterval * time.Second
+
+func (md *embedded) Failover() (err error) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+
+	if md.cfg.HAMode == "" {
+		return errors.New("failover requires HAMode")
+	}
+	masterIDs := instanceIDs(md.cfg.EC2MasterNodes.Instances)
+	if len(masterIDs) < 2 {
+		return fmt.Errorf("failover expects at least 2 master nodes, got %d", len(masterIDs))
+	}
+
+	// check API server from the "kubeadm init" node, with admin KUBECONFIG
+	// pointing to the load balancer, and stop the last master node
+	checkTarget := md.cfg.EC2MasterNodes.Instances[masterIDs[0]]
+	stopID := masterIDs[len(masterIDs)-1]
+
+	var ss ssh.SSH
+	ss, err = ssh.New(ssh.Config{
+		Logger:        md.lg,
+		KeyPath:       md.cfg.EC2MasterNodes.KeyPath,
+		PublicIP:      checkTarget.PublicIP,
+		PublicDNSName: checkTarget.PublicDNSName,
+		UserName:      md.cfg.EC2MasterNodes.UserName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create a SSH to %q(%q) (error %v)", md.cfg.EC2MasterNodes.ClusterName, checkTarget.InstanceID, err)
+	}
+	if err = ss.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to %q(%q) (error %v)", md.cfg.EC2MasterNodes.ClusterName, checkTarget.InstanceID, err)
+	}
+	defer ss.Close()
+
+	if err = checkAPIServer(ss); err != nil {
+		return fmt.Errorf("API server is not available before failover (%v)", err)
+	}
+
+	md.lg.Info("stopping master node", zap.String("instance-id", stopID))
+	if _, err = md.ec2API.StopInstances(&awsec2.StopInstancesInput{
+		InstanceIds: aws.StringSlice([]string{stopID}),
+	}); err != nil {
+		return err
+	}
+	defer func() {
+		// do not leave the master node stopped on failures
+		if serr := md.startMasterNode(stopID); serr != nil {
+			if err == nil {
+				err = serr
+			} else {
+				md.lg.Warn("failed to start master node", zap.String("instance-id", stopID), zap.Error(serr))
+			}
+		}
+	}()
+
+	// requests to the stopped master node may fail until the load balancer
+	// health check takes it out of service, and then API server must stay available
+	failures, successes := 0, 0
+	var firstFailure time.Time
+	retryStart := time.Now().UTC()
+	for successes < failoverChecks {
+		if time.Now().UTC().Sub(retryStart) > 10*time.Minute {
+			return fmt.Errorf("timed out waiting for failover of master node %q (%d failures)", stopID, failures)
+		}
+		time.Sleep(5 * time.Second)
+
+		var state string
+		state, err = md.instanceHealth(stopID)
+		if err != nil {
+			return err
+		}
+		err = checkAPIServer(ss)
+		switch {
+		case state != "OutOfService" && err != nil:
+			failures++
+			if firstFailure.IsZero() {
+				firstFailure = time.Now().UTC()
+			}
+			if took := time.Now().UTC().Sub(firstFailure); took > failoverMaxDowntime {
+				return fmt.Errorf("API server is not available for %v (> %v) before master node %q is out of service (%v)", took, failoverMaxDowntime, stopID, err)
+			}
+			md.lg.Warn("API server check failed before failover", zap.String("state", state), zap.Int("failures", failures), zap.Error(err))
+		case state != "OutOfService":
+			md.lg.Info("waiting on load balancer health check", zap.String("instance-id", stopID), zap.String("state", state))
+		case err != nil:
+			return fmt.Errorf("API server is not available with master node %q out of service (%v)", stopID, err)
+		default:
+			successes++
+		}
+	}
+	md.lg.Info("API server is available after failover",
+		zap.String("stopped-instance-id", stopID),
+		zap.Int("failures-before-failover", failures),
+		zap.Int("successes-after-failover", successes),
+	)
+	return nil
+}
+
+// checkAPIServer checks API server health through the load balancer.
+func checkAPIServer(ss ssh.SSH) error {
+	out, err := ss.Run(
+		"sudo kubectl --kubeconfig=/etc/kubernetes/admin.conf --request-timeout=5s get --raw=/healthz",
+		ssh.WithTimeout(15*time.Second),
+	)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(out)) != "ok" {
+		return fmt.Errorf("unexpected healthz output %q", string(out))
+	}
+	return nil
+}
+
+// instanceHealth returns the load balancer state of the instance,
+// "InService", "OutOfService", or "Unknown".
+func (md *embedded) instanceHealth(id string) (string, error) {
+	out, err := md.elbv1.DescribeInstanceHealth(&elb.DescribeInstanceHealthInput{
+		LoadBalancerName: aws.String(md.cfg.LoadBalancerName),
+		Instances:        []*elb.Instance{{InstanceId: aws.String(id)}},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(out.InstanceStates) != 1 {
+		return "", fmt.Errorf("expected 1 instance state, got %d", len(out.InstanceStates))
+	}
+	return aws.StringValue(out.InstanceStates[0].State), nil
+}
+
+// startMasterNode starts the stopped master node, updates its public IP,
+// and waits until the load balancer puts it back in service.
+func (md *embedded) startMasterNode(id string) (err error) {
+	md.lg.Info("starting master node", zap.String("instance-id", id))
+	if _, err = md.ec2API.StartInstances(&awsec2.StartInstancesInput{
+		InstanceIds: aws.StringSlice([]string{id}),
+	}); err != nil {
+		return err
+	}
+	if err = md.ec2API.WaitUntilInstanceRunning(&awsec2.DescribeInstancesInput{
+		InstanceIds: aws.StringSlice([]string{id}),
+	}); err != nil {
+		return err
+	}
+
+	var out *awsec2.DescribeInstancesOutput
+	out, err = md.ec2API.DescribeInstances(&awsec2.DescribeInstancesInput{
+		InstanceIds: aws.StringSlice([]string{id}),
+	})
+	if err != nil {
+		return err
+	}
+	for _, rv := range out.Reservations {
+		for _, iv := range rv.Instances {
+			// public IP changes on restart
+			md.cfg.EC2MasterNodes.Instances[id] = ec2.ConvertEC2Instance(iv)
+		}
+	}
+	md.cfg.Sync()
+
+	retryStart := time.Now().UTC()
+	for time.Now().UTC().Sub(retryStart) < 10*time.Minute {
+		var state string
+		state, err = md.instanceHealth(id)
+		if err != nil {
+			return err
+		}
+		if state == "InService" {
+			md.lg.Info("master node is back in service", zap.String("instance-id", id), zap.String("public-ip", md.cfg.EC2MasterNodes.Instances[id].PublicIP))
+			return md.cfg.Sync()
+		}
+		md.lg.Info("waiting on master node to be in service", zap.String("instance-id", id), zap.String("state", state))
+		time.Sleep(10 * time.Second)
+	}
+	return fmt.Errorf("master node %q is not in service", id)
+}
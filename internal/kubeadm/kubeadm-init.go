@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-k8s-tester/ec2config/osprofile"
 	"github.com/aws/aws-k8s-tester/internal/ssh"
 	"github.com/aws/aws-k8s-tester/kubeadmconfig"
 	"go.uber.org/zap"
@@ -18,6 +19,7 @@ func runKubeadmInit(
 	target ec2config.Instance,
 	filePathToSend string,
 	kubeadmJoin *kubeadmconfig.KubeadmJoin,
+	rt *osprofile.ContainerRuntime,
 ) (err error) {
 	kubeadmJoin.Target = fmt.Sprintf("%s:6443", target.PrivateIP)
 	var ss ssh.SSH
@@ -66,7 +68,6 @@ func runKubeadmInit(
 	lg.Info("started kubeadm init", zap.String("id", target.InstanceID))
 
 	retryStart := time.Now().UTC()
-joinReady:
 	for time.Now().UTC().Sub(retryStart) < 10*time.Minute {
 		var kubeadmInitOut []byte
 		kubeadmInitOut, err = ss.Run(
@@ -88,52 +89,26 @@ joinReady:
 			time.Sleep(15 * time.Second)
 			continue
 		}
-
-		for _, line := range strings.Split(output, "\n") {
-			line = strings.TrimSpace(line)
-			if !strings.Contains(line, "--token") || !strings.Contains(line, "--discovery-token-ca-cert-hash") {
-				continue
-			}
-			kubeadmJoin.RawCommand = line
-			break joinReady
+		if kubeadmJoin.ParseInitOutput(output) == nil {
+			break
 		}
+		time.Sleep(15 * time.Second)
 	}
 
 	if kubeadmJoin.RawCommand == "" {
 		return errors.New("kubeadm join failed")
 	}
-	prevToken, prevHash := false, false
-	for _, field := range strings.Fields(kubeadmJoin.RawCommand) {
-		if field == "--token" {
-			prevToken = true
-			continue
-		}
-		if prevToken {
-			kubeadmJoin.Token = field
-			prevToken = false
-			continue
-		}
-		if field == "--discovery-token-ca-cert-hash" {
-			prevHash = true
-			continue
-		}
-		if prevHash {
-			kubeadmJoin.DiscoveryTokenCACertHash = field
-			prevHash = true
-			continue
-		}
-	}
 
 	lg.Info("checking kube-controller-manager")
 	retryStart = time.Now().UTC()
 	for time.Now().UTC().Sub(retryStart) < 10*time.Minute {
-		var dockerPsOutput []byte
-		dockerPsOutput, err = ss.Run(
-			"sudo docker ps",
+		var psOutput []byte
+		psOutput, err = ss.Run(
+			rt.ListCommand,
 			ssh.WithRetry(15, 5*time.Second),
 			ssh.WithTimeout(15*time.Second),
 		)
-		output := string(dockerPsOutput)
+		output := string(psOutput)
 		fmt.Printf("\n\n%s\n\n", output)
 		if strings.Contains(output, "kube-controller-manager") {
 			break
@@ -148,7 +123,7 @@ joinReady:
 	for time.Now().UTC().Sub(retryStart) < 10*time.Minute {
 		var podsOutput []byte
 		podsOutput, err = ss.Run(
-			"kubectl --kubeconfig=/home/ec2-user/.kube/config get pods --all-namespaces",
+			"kubectl --kubeconfig="+kubeconfigPath(ec2Config)+" get pods --all-namespaces",
 			ssh.WithRetry(15, 5*time.Second),
 			ssh.WithTimeout(15*time.Second),
 		)
@@ -165,7 +140,7 @@ joinReady:
 
 	var flannelOutputRole []byte
 	flannelOutputRole, err = ss.Run(
-		"kubectl --kubeconfig=/home/ec2-user/.kube/config apply -f https://raw.githubusercontent.com/coreos/flannel/master/Documentation/k8s-manifests/kube-flannel-rbac.yml",
+		"kubectl --kubeconfig="+kubeconfigPath(ec2Config)+" apply -f https://raw.githubusercontent.com/coreos/flannel/master/Documentation/k8s-manifests/kube-flannel-rbac.yml",
 		ssh.WithRetry(15, 5*time.Second),
 		ssh.WithTimeout(15*time.Second),
 	)
@@ -175,7 +150,7 @@ joinReady:
 	fmt.Println("flannelOutputRole:", string(flannelOutputRole))
 	var flannelOutput []byte
 	flannelOutput, err = ss.Run(
-		"kubectl --kubeconfig=/home/ec2-user/.kube/config apply -f https://raw.githubusercontent.com/coreos/flannel/master/Documentation/kube-flannel.yml",
+		"kubectl --kubeconfig="+kubeconfigPath(ec2Config)+" apply -f https://raw.githubusercontent.com/coreos/flannel/master/Documentation/kube-flannel.yml",
 		ssh.WithRetry(15, 5*time.Second),
 		ssh.WithTimeout(15*time.Second),
 	)
@@ -189,7 +164,7 @@ joinReady:
 	for time.Now().UTC().Sub(retryStart) < 10*time.Minute {
 		var podsOutput []byte
 		podsOutput, err = ss.Run(
-			"kubectl --kubeconfig=/home/ec2-user/.kube/config get pods --all-namespaces",
+			"kubectl --kubeconfig="+kubeconfigPath(ec2Config)+" get pods --all-namespaces",
 			ssh.WithRetry(15, 5*time.Second),
 			ssh.WithTimeout(15*time.Second),
 		)
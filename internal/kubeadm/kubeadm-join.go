@@ -7,7 +7,6 @@ import (
 
 	"github.com/aws/aws-k8s-tester/ec2config"
 	"github.com/aws/aws-k8s-tester/internal/ssh"
-	"github.com/aws/aws-k8s-tester/kubeadmconfig"
 	"go.uber.org/zap"
 )
 
@@ -15,13 +14,8 @@ func runKubeadmJoin(
 	lg *zap.Logger,
 	ec2Config ec2config.Config,
 	target ec2config.Instance,
-	kubeadmJoin *kubeadmconfig.KubeadmJoin,
+	joinCmd string,
 ) (err error) {
-	var joinCmd string
-	joinCmd, err = kubeadmJoin.Command()
-	if err != nil {
-		return err
-	}
 	lg.Info("kubeadm join command is ready", zap.String("command", joinCmd))
 
 	var ss ssh.SSH
@@ -50,7 +44,8 @@ func runKubeadmJoin(
 		return err
 	}
 	output := string(joinOutput)
-	if !strings.Contains(output, "[discovery] Successfully established connection with API Server") || !strings.Contains(output, "This node has joined the cluster:") {
+	// "This node has joined the cluster and a new control plane instance was created:" for master nodes
+	if !strings.Contains(output, "This node has joined the cluster") {
 		return fmt.Errorf("failed to join cluster (%q)", output)
 	}
 	lg.Info("node has joined master", zap.String("id", target.InstanceID), zap.String("output", string(joinOutput)))
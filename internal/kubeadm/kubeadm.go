@@ -6,19 +6,25 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/aws/aws-k8s-tester/ec2config"
 	"github.com/aws/aws-k8s-tester/internal/ec2"
+	"github.com/aws/aws-k8s-tester/internal/etcd"
 	"github.com/aws/aws-k8s-tester/internal/ssh"
 	"github.com/aws/aws-k8s-tester/kubeadmconfig"
 	"github.com/aws/aws-k8s-tester/pkg/awsapi"
 	"github.com/aws/aws-k8s-tester/pkg/fileutil"
 	"github.com/aws/aws-k8s-tester/pkg/zaputil"
+	"github.com/aws/aws-k8s-tester/storagetester"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
+	awsec2 "github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/aws/aws-sdk-go/service/elb"
 	"github.com/aws/aws-sdk-go/service/elb/elbiface"
 	"github.com/aws/aws-sdk-go/service/elbv2"
@@ -31,6 +37,9 @@ import (
 type Deployer interface {
 	Create() error
 	Terminate() error
+	// Failover stops one master node in HA mode, and verifies that
+	// the API server stays available through the load balancer.
+	Failover() error
 }
 
 type embedded struct {
@@ -40,10 +49,13 @@ type embedded struct {
 
 	ec2MasterNodesDeployer ec2.Deployer
 	ec2WorkerNodesDeployer ec2.Deployer
+	// only for "external" HA mode
+	etcdTester storagetester.Tester
 
-	ss    *session.Session
-	elbv1 elbiface.ELBAPI     // for classic ELB
-	elbv2 elbv2iface.ELBV2API // for ALB or NLB
+	ss     *session.Session
+	ec2API ec2iface.EC2API
+	elbv1  elbiface.ELBAPI     // for classic ELB
+	elbv2  elbv2iface.ELBV2API // for ALB or NLB
 }
 
 // NewDeployer creates a new embedded kubeadm tester.
@@ -67,6 +79,7 @@ func NewDeployer(cfg *kubeadmconfig.Config) (Deployer, error) {
 	if err != nil {
 		return nil, err
 	}
+	md.ec2API = awsec2.New(md.ss)
 	md.elbv1 = elb.New(md.ss)
 	md.elbv2 = elbv2.New(md.ss)
 
@@ -78,6 +91,12 @@ func NewDeployer(cfg *kubeadmconfig.Config) (Deployer, error) {
 	if err != nil {
 		return nil, err
 	}
+	if md.cfg.HAMode == kubeadmconfig.HAModeExternal {
+		md.etcdTester, err = etcd.NewTester(md.cfg.ETCDNodes)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return md, cfg.Sync()
 }
@@ -88,9 +107,9 @@ func (md *embedded) Create() (err error) {
 
 	now := time.Now().UTC()
 
-	md.cfg.ConfigPathURL = genS3URL(md.cfg.AWSRegion, md.cfg.Tag, md.cfg.EC2MasterNodes.ConfigPathBucket)
-	md.cfg.KubeConfigPathURL = genS3URL(md.cfg.AWSRegion, md.cfg.Tag, md.cfg.KubeConfigPathBucket)
-	md.cfg.LogOutputToUploadPathURL = genS3URL(md.cfg.AWSRegion, md.cfg.Tag, md.cfg.EC2MasterNodes.LogOutputToUploadPathBucket)
+	md.cfg.ConfigPathURL = md.ec2MasterNodesDeployer.ArtifactURL(md.cfg.EC2MasterNodes.ConfigPathBucket)
+	md.cfg.KubeConfigPathURL = md.ec2MasterNodesDeployer.ArtifactURL(md.cfg.KubeConfigPathBucket)
+	md.cfg.LogOutputToUploadPathURL = md.ec2MasterNodesDeployer.ArtifactURL(md.cfg.EC2MasterNodes.LogOutputToUploadPathBucket)
 
 	defer func() {
 		if err != nil {
@@ -111,13 +130,49 @@ func (md *embedded) Create() (err error) {
 	md.cfg.EC2WorkerNodes.VPCID = md.cfg.EC2MasterNodes.VPCID
 	// prevent VPC double-delete
 	md.cfg.EC2WorkerNodes.VPCCreated = false
+	if md.etcdTester != nil {
+		md.cfg.ETCDNodes.EC2.VPCID = md.cfg.EC2MasterNodes.VPCID
+		md.cfg.ETCDNodes.EC2Bastion.VPCID = md.cfg.EC2MasterNodes.VPCID
+		md.cfg.ETCDNodes.EC2.VPCCreated = false
+		md.cfg.ETCDNodes.EC2Bastion.VPCCreated = false
+	}
 	md.cfg.Sync()
 
-	if err = md.ec2WorkerNodesDeployer.Create(); err != nil {
-		return err
+	errc, ess, cnt := make(chan error), make([]string, 0), 1
+	var mu sync.Mutex
+	if md.etcdTester != nil {
+		cnt++
+		go func() {
+			if err := md.etcdTester.Create(); err != nil {
+				errc <- fmt.Errorf("failed to create etcd cluster (%v)", err)
+				return
+			}
+			mu.Lock()
+			md.cfg.ETCDNodesCreated = true
+			mu.Unlock()
+			errc <- nil
+		}()
+	}
+	go func() {
+		if err := md.ec2WorkerNodesDeployer.Create(); err != nil {
+			errc <- fmt.Errorf("failed to create worker nodes (%v)", err)
+			return
+		}
+		mu.Lock()
+		md.cfg.EC2WorkerNodesCreated = true
+		mu.Unlock()
+		errc <- nil
+	}()
+	for i := 0; i < cnt; i++ {
+		if err = <-errc; err != nil {
+			ess = append(ess, err.Error())
+		}
 	}
-	md.cfg.EC2WorkerNodesCreated = true
+	// sync once after all goroutines are done, to not race on config
 	md.cfg.Sync()
+	if len(ess) > 0 {
+		return errors.New(strings.Join(ess, ", "))
+	}
 
 	md.lg.Info(
 		"deployed EC2 instances",
@@ -134,17 +189,32 @@ func (md *embedded) Create() (err error) {
 	if err = md.cfg.ValidateAndSetDefaults(); err != nil {
 		return err
 	}
+	if md.cfg.HAMode == kubeadmconfig.HAModeExternal {
+		if err = md.cfg.SetExternalEtcdEndpoints(); err != nil {
+			return err
+		}
+		md.lg.Info("set external etcd endpoints", zap.Strings("endpoints", md.cfg.KubeadmInit.ExternalEtcdEndpoints))
+		md.cfg.Sync()
+	}
 
+	// load balancer in front of API servers on master nodes
+	apiServerPort := int64(md.cfg.KubeadmInit.APIServerBindPort)
+	var scheme *string
+	if md.cfg.HAMode != "" {
+		// control plane endpoint for nodes in the VPC
+		scheme = aws.String("internal")
+	}
 	var elbOut *elb.CreateLoadBalancerOutput
 	elbOut, err = md.elbv1.CreateLoadBalancer(&elb.CreateLoadBalancerInput{
 		LoadBalancerName: aws.String(md.cfg.LoadBalancerName),
+		Scheme:           scheme,
 		SecurityGroups:   aws.StringSlice(md.cfg.EC2MasterNodes.SecurityGroupIDs),
 		Subnets:          aws.StringSlice(md.cfg.EC2MasterNodes.SubnetIDs),
 		Listeners: []*elb.Listener{
 			{
-				InstancePort:     aws.Int64(443),
+				InstancePort:     aws.Int64(apiServerPort),
 				InstanceProtocol: aws.String("TCP"),
-				LoadBalancerPort: aws.Int64(443),
+				LoadBalancerPort: aws.Int64(apiServerPort),
 				Protocol:         aws.String("TCP"),
 			},
 		},
@@ -157,24 +227,26 @@ func (md *embedded) Create() (err error) {
 	}
 	md.cfg.LoadBalancerCreated = true
 	md.cfg.LoadBalancerDNSName = *elbOut.DNSName
-	md.cfg.LoadBalancerURL = md.cfg.LoadBalancerDNSName
-	if !strings.HasPrefix(md.cfg.LoadBalancerURL, "https://") {
-		md.cfg.LoadBalancerURL = "https://" + md.cfg.LoadBalancerURL
-	}
+	md.cfg.LoadBalancerURL = fmt.Sprintf("https://%s:%d", md.cfg.LoadBalancerDNSName, apiServerPort)
 	md.cfg.Sync()
 	md.lg.Info("created load balancer", zap.String("name", md.cfg.LoadBalancerName), zap.String("dns-name", md.cfg.LoadBalancerDNSName))
 
-	instances := make([]*elb.Instance, 0, len(md.cfg.EC2MasterNodes.Instances)+len(md.cfg.EC2WorkerNodes.Instances))
-	for _, iv := range md.cfg.EC2MasterNodes.Instances {
-		instances = append(instances, &elb.Instance{
-			InstanceId: aws.String(iv.InstanceID),
-		})
-	}
-	for _, iv := range md.cfg.EC2WorkerNodes.Instances {
-		instances = append(instances, &elb.Instance{
-			InstanceId: aws.String(iv.InstanceID),
-		})
+	// take stopped or failed API servers out of service
+	if _, err = md.elbv1.ConfigureHealthCheck(&elb.ConfigureHealthCheckInput{
+		LoadBalancerName: aws.String(md.cfg.LoadBalancerName),
+		HealthCheck: &elb.HealthCheck{
+			Target:             aws.String(fmt.Sprintf("TCP:%d", apiServerPort)),
+			Interval:           aws.Int64(healthCheckInterval),
+			Timeout:            aws.Int64(5),
+			HealthyThreshold:   aws.Int64(2),
+			UnhealthyThreshold: aws.Int64(healthCheckUnhealthyThreshold),
+		},
+	}); err != nil {
+		return err
 	}
+	md.lg.Info("configured load balancer health check", zap.String("name", md.cfg.LoadBalancerName), zap.Int64("port", apiServerPort))
+
+	instances := md.loadBalancerInstances()
 	if _, err = md.elbv1.RegisterInstancesWithLoadBalancer(&elb.RegisterInstancesWithLoadBalancerInput{
 		LoadBalancerName: aws.String(md.cfg.LoadBalancerName),
 		Instances:        instances,
@@ -209,18 +281,43 @@ func (md *embedded) Create() (err error) {
 	}
 	md.lg.Info("step 1-3. successfully ran 'master node kubelet'")
 
+	if md.cfg.HAMode != "" {
+		md.cfg.KubeadmInit.ControlPlaneEndpoint = fmt.Sprintf("%s:%d", md.cfg.LoadBalancerDNSName, apiServerPort)
+		md.cfg.Sync()
+	}
 	var kubeadmInitScript string
-	kubeadmInitScript, err = md.cfg.KubeadmInit.Script()
+	kubeadmInitScript, err = md.cfg.KubeadmInit.Script(md.cfg.EC2MasterNodes.UserName)
 	if err != nil {
 		return err
 	}
-	for _, target := range md.cfg.EC2MasterNodes.Instances {
-		if err = runKubeadmInit(md.lg, *md.cfg.EC2MasterNodes, target, kubeadmInitScript, md.cfg.KubeadmJoin); err != nil {
+	masterIDs := instanceIDs(md.cfg.EC2MasterNodes.Instances)
+	if len(masterIDs) == 0 {
+		return errors.New("no master node found")
+	}
+	initTarget := md.cfg.EC2MasterNodes.Instances[masterIDs[0]]
+	if err = runKubeadmInit(md.lg, *md.cfg.EC2MasterNodes, initTarget, kubeadmInitScript, md.cfg.KubeadmJoin, md.cfg.Runtime()); err != nil {
+		return err
+	}
+	md.lg.Info("step 1-4. successfully ran 'master node kubeadm init'")
+
+	if md.cfg.HAMode != "" {
+		// nodes join through the load balancer
+		md.cfg.KubeadmJoin.Target = md.cfg.KubeadmInit.ControlPlaneEndpoint
+		md.cfg.Sync()
+
+		var controlPlaneJoinCmd string
+		controlPlaneJoinCmd, err = md.cfg.KubeadmJoin.ControlPlaneCommand()
+		if err != nil {
 			return err
 		}
-		break
+		// one by one, to add etcd members in stacked mode
+		for _, id := range masterIDs[1:] {
+			if err = runKubeadmJoin(md.lg, *md.cfg.EC2MasterNodes, md.cfg.EC2MasterNodes.Instances[id], controlPlaneJoinCmd); err != nil {
+				return err
+			}
+		}
+		md.lg.Info("step 1-5. successfully ran 'master node kubeadm join --control-plane'", zap.Int("master-nodes", len(masterIDs)))
 	}
-	md.lg.Info("step 1-4. successfully ran 'master node kubeadm init'")
 	////////////////////////////////////////////////////////////////////////
 
 	////////////////////////////////////////////////////////////////////////
@@ -247,8 +344,13 @@ func (md *embedded) Create() (err error) {
 	}
 	md.lg.Info("step 2-3. successfully ran 'master node kubelet'")
 
+	var joinCmd string
+	joinCmd, err = md.cfg.KubeadmJoin.Command()
+	if err != nil {
+		return err
+	}
 	for _, target := range md.cfg.EC2WorkerNodes.Instances {
-		if err = runKubeadmJoin(md.lg, *md.cfg.EC2WorkerNodes, target, md.cfg.KubeadmJoin); err != nil {
+		if err = runKubeadmJoin(md.lg, *md.cfg.EC2WorkerNodes, target, joinCmd); err != nil {
 			return err
 		}
 	}
@@ -257,11 +359,8 @@ func (md *embedded) Create() (err error) {
 
 	////////////////////////////////////////////////////////////////////////
 	var kubeconfigOutput []byte
-	for _, target := range md.cfg.EC2MasterNodes.Instances {
-		if kubeconfigOutput, err = fetchKubeconfig(md.lg, *md.cfg.EC2MasterNodes, target); err != nil {
-			return err
-		}
-		break
+	if kubeconfigOutput, err = fetchKubeconfig(md.lg, *md.cfg.EC2MasterNodes, initTarget); err != nil {
+		return err
 	}
 	md.lg.Info("step 3-1. successfully fetched KUBECONFIG from master node")
 
@@ -302,17 +401,7 @@ func (md *embedded) terminate() (err error) {
 	ess := make([]string, 0)
 
 	if md.cfg.LoadBalancerRegistered {
-		instances := make([]*elb.Instance, 0, len(md.cfg.EC2MasterNodes.Instances)+len(md.cfg.EC2WorkerNodes.Instances))
-		for _, iv := range md.cfg.EC2MasterNodes.Instances {
-			instances = append(instances, &elb.Instance{
-				InstanceId: aws.String(iv.InstanceID),
-			})
-		}
-		for _, iv := range md.cfg.EC2WorkerNodes.Instances {
-			instances = append(instances, &elb.Instance{
-				InstanceId: aws.String(iv.InstanceID),
-			})
-		}
+		instances := md.loadBalancerInstances()
 		if _, err := md.elbv1.DeregisterInstancesFromLoadBalancer(&elb.DeregisterInstancesFromLoadBalancerInput{
 			LoadBalancerName: aws.String(md.cfg.LoadBalancerName),
 			Instances:        instances,
@@ -335,6 +424,16 @@ func (md *embedded) terminate() (err error) {
 		md.lg.Info("deleted load balancer", zap.String("name", md.cfg.LoadBalancerName))
 	}
 
+	// terminate etcd nodes first in order to remove VPC dependency safely
+	var eerr error
+	if md.cfg.ETCDNodesCreated && md.etcdTester != nil {
+		if eerr = md.etcdTester.Terminate(); eerr != nil {
+			md.lg.Warn("failed to terminate etcd nodes", zap.Error(eerr))
+		} else {
+			md.cfg.ETCDNodesCreated = false
+		}
+	}
+
 	if err = md.ec2WorkerNodesDeployer.Terminate(); err != nil {
 		md.lg.Warn("failed to terminate EC2 worker nodes", zap.Error(err))
 	}
@@ -346,9 +445,38 @@ func (md *embedded) terminate() (err error) {
 			err = merr
 		}
 	}
+	if eerr != nil {
+		if err != nil {
+			err = fmt.Errorf("%v, etcd nodes error %v", err, eerr)
+		} else {
+			err = eerr
+		}
+	}
 	return err
 }
 
+// instanceIDs returns the instance IDs in order, so that
+// "kubeadm init" runs on the same master node on every call.
+func instanceIDs(instances map[string]ec2config.Instance) []string {
+	ids := make([]string, 0, len(instances))
+	for id := range instances {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// loadBalancerInstances returns the master nodes that run API servers.
+func (md *embedded) loadBalancerInstances() []*elb.Instance {
+	instances := make([]*elb.Instance, 0, len(md.cfg.EC2MasterNodes.Instances))
+	for _, iv := range md.cfg.EC2MasterNodes.Instances {
+		instances = append(instances, &elb.Instance{
+			InstanceId: aws.String(iv.InstanceID),
+		})
+	}
+	return instances
+}
+
 func (md *embedded) uploadLogs() (err error) {
 	if md.cfg.UploadKubeConfig && md.cfg.EC2MasterNodesCreated {
 		err := md.ec2MasterNodesDeployer.UploadToBucketForTests(md.cfg.KubeConfigPath, md.cfg.KubeConfigPathBucket)
@@ -365,6 +493,8 @@ func (md *embedded) uploadLogs() (err error) {
 			md.cfg.EC2MasterNodes.UserName,
 			md.cfg.ClusterName,
 			md.cfg.EC2MasterNodes.KeyPath,
+			md.cfg.Runtime().Service,
+			md.cfg.EC2MasterNodes.Profile().LogPaths,
 			md.cfg.EC2MasterNodes.Instances,
 		)
 		md.cfg.LogsMasterNodes = fpathToS3PathMasterNodes
@@ -378,10 +508,12 @@ func (md *embedded) uploadLogs() (err error) {
 	if md.cfg.UploadTesterLogs && len(md.cfg.EC2WorkerNodes.Instances) > 0 && md.cfg.EC2WorkerNodesCreated {
 		fpathToS3PathWorkerNodes, err := fetchLogs(
 			md.lg,
-			md.cfg.EC2MasterNodes.UserName,
+			md.cfg.EC2WorkerNodes.UserName,
 			md.cfg.ClusterName,
-			md.cfg.EC2MasterNodes.KeyPath,
-			md.cfg.EC2MasterNodes.Instances,
+			md.cfg.EC2WorkerNodes.KeyPath,
+			md.cfg.Runtime().Service,
+			md.cfg.EC2WorkerNodes.Profile().LogPaths,
+			md.cfg.EC2WorkerNodes.Instances,
 		)
 		md.cfg.LogsWorkerNodes = fpathToS3PathWorkerNodes
 		if err == nil {
@@ -415,11 +547,13 @@ func fetchLogs(
 	userName string,
 	clusterName string,
 	privateKeyPath string,
+	runtimeService string,
+	logPaths []string,
 	nodes map[string]ec2config.Instance) (fpathToS3Path map[string]string, err error) {
 	fpathToS3Path = make(map[string]string)
 	for _, iv := range nodes {
 		var fm map[string]string
-		fm, err = fetchLog(lg, userName, clusterName, privateKeyPath, iv)
+		fm, err = fetchLog(lg, userName, clusterName, privateKeyPath, runtimeService, logPaths, iv)
 		if err != nil {
 			return nil, err
 		}
@@ -435,6 +569,8 @@ func fetchLog(
 	userName string,
 	clusterName string,
 	privateKeyPath string,
+	runtimeService string,
+	logPaths []string,
 	inst ec2config.Instance) (fpathToS3Path map[string]string, err error) {
 	id, ip := inst.InstanceID, inst.PublicIP
 
@@ -485,11 +621,46 @@ func fetchLog(
 	lg.Info("downloaded kubeadm log", zap.String("path", kubeletLogPath))
 	fpathToS3Path = make(map[string]string)
 	fpathToS3Path[kubeletLogPath] = fmt.Sprintf("%s/%s-kubelet.log", clusterName, id)
-	return fpathToS3Path, nil
-}
 
-// genS3URL returns S3 URL path.
-// e.g. https://s3-us-west-2.amazonaws.com/aws-k8s-tester-20180925/hello-world
-func genS3URL(region, bucket, s3Path string) string {
-	return fmt.Sprintf("https://s3-%s.amazonaws.com/%s/%s", region, bucket, s3Path)
+	out, err = sh.Run(
+		fmt.Sprintf("sudo journalctl --no-pager -u %s.service", runtimeService),
+		ssh.WithRetry(15, 5*time.Second),
+		ssh.WithTimeout(15*time.Second),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var runtimeLogPath string
+	runtimeLogPath, err = fileutil.WriteTempFile(out)
+	if err != nil {
+		return nil, err
+	}
+	lg.Info("downloaded container runtime log", zap.String("path", runtimeLogPath), zap.String("service", runtimeService))
+	fpathToS3Path[runtimeLogPath] = fmt.Sprintf("%s/%s-%s.log", clusterName, id, runtimeService)
+
+	// OS logs of the profile (e.g. "/var/log/messages")
+	for _, logPath := range logPaths {
+		out, err = sh.Run(
+			"sudo cat "+logPath,
+			ssh.WithRetry(15, 5*time.Second),
+			ssh.WithTimeout(15*time.Second),
+		)
+		if err != nil {
+			lg.Warn(
+				"failed to fetch log",
+				zap.String("instance-id", id),
+				zap.String("log-path", logPath),
+				zap.Error(err),
+			)
+			continue
+		}
+		var fpath string
+		fpath, err = fileutil.WriteTempFile(out)
+		if err != nil {
+			return nil, err
+		}
+		lg.Info("downloaded kubeadm log", zap.String("path", fpath), zap.String("log-path", logPath))
+		fpathToS3Path[fpath] = fmt.Sprintf("%s/%s-%s", clusterName, id, filepath.Base(logPath))
+	}
+	return fpathToS3Path, nil
 }
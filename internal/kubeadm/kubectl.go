@@ -9,6 +9,11 @@ import (
 	"go.uber.org/zap"
 )
 
+// kubeconfigPath returns the KUBECONFIG path in the home directory of the user.
+func kubeconfigPath(ec2Config ec2config.Config) string {
+	return fmt.Sprintf("/home/%s/.kube/config", ec2Config.UserName)
+}
+
 func fetchKubeconfig(
 	lg *zap.Logger,
 	ec2Config ec2config.Config,
@@ -32,7 +37,7 @@ func fetchKubeconfig(
 
 	var nodesOutput []byte
 	nodesOutput, err = ss.Run(
-		"kubectl --kubeconfig=/home/ec2-user/.kube/config get nodes",
+		"kubectl --kubeconfig="+kubeconfigPath(ec2Config)+" get nodes",
 		ssh.WithRetry(15, 5*time.Second),
 		ssh.WithTimeout(15*time.Second),
 	)
@@ -43,7 +48,7 @@ func fetchKubeconfig(
 
 	lg.Info("fetching KUBECONFIG")
 	kubeconfigOutput, err = ss.Run(
-		"cat /home/ec2-user/.kube/config",
+		"cat "+kubeconfigPath(ec2Config),
 		ssh.WithRetry(15, 5*time.Second),
 		ssh.WithTimeout(15*time.Second),
 	)
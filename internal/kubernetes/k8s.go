@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -96,9 +97,9 @@ func (md *embedded) Create() (err error) {
 
 	now := time.Now().UTC()
 
-	md.cfg.ConfigPathURL = genS3URL(md.cfg.EC2MasterNodes.AWSRegion, md.cfg.Tag, md.cfg.EC2MasterNodes.ConfigPathBucket)
-	md.cfg.KubeConfigPathURL = genS3URL(md.cfg.EC2MasterNodes.AWSRegion, md.cfg.Tag, md.cfg.KubeConfigPathBucket)
-	md.cfg.LogOutputToUploadPathURL = genS3URL(md.cfg.EC2MasterNodes.AWSRegion, md.cfg.Tag, md.cfg.EC2MasterNodes.LogOutputToUploadPathBucket)
+	md.cfg.ConfigPathURL = md.ec2MasterNodesDeployer.ArtifactURL(md.cfg.EC2MasterNodes.ConfigPathBucket)
+	md.cfg.KubeConfigPathURL = md.ec2MasterNodesDeployer.ArtifactURL(md.cfg.KubeConfigPathBucket)
+	md.cfg.LogOutputToUploadPathURL = md.ec2MasterNodesDeployer.ArtifactURL(md.cfg.EC2MasterNodes.LogOutputToUploadPathBucket)
 
 	defer func() {
 		if err != nil {
@@ -790,6 +791,8 @@ func (md *embedded) terminate() error {
 			md.cfg.EC2MasterNodes.UserName,
 			md.cfg.ClusterName,
 			md.cfg.EC2MasterNodes.KeyPath,
+			md.cfg.Runtime().Service,
+			md.cfg.EC2MasterNodes.Profile().LogPaths,
 			md.cfg.EC2MasterNodes.Instances,
 		)
 		md.cfg.LogsMasterNodes = fpathToS3PathMasterNodes
@@ -803,10 +806,12 @@ func (md *embedded) terminate() error {
 	if md.cfg.UploadTesterLogs && len(md.cfg.EC2WorkerNodes.Instances) > 0 && md.cfg.EC2WorkerNodesCreated {
 		fpathToS3PathWorkerNodes, err := fetchLogs(
 			md.lg,
-			md.cfg.EC2MasterNodes.UserName,
+			md.cfg.EC2WorkerNodes.UserName,
 			md.cfg.ClusterName,
-			md.cfg.EC2MasterNodes.KeyPath,
-			md.cfg.EC2MasterNodes.Instances,
+			md.cfg.EC2WorkerNodes.KeyPath,
+			md.cfg.Runtime().Service,
+			md.cfg.EC2WorkerNodes.Profile().LogPaths,
+			md.cfg.EC2WorkerNodes.Instances,
 		)
 		md.cfg.LogsWorkerNodes = fpathToS3PathWorkerNodes
 		if err == nil {
@@ -939,11 +944,13 @@ func fetchLogs(
 	userName string,
 	clusterName string,
 	privateKeyPath string,
+	runtimeService string,
+	logPaths []string,
 	nodes map[string]ec2config.Instance) (fpathToS3Path map[string]string, err error) {
 	fpathToS3Path = make(map[string]string)
 	for _, iv := range nodes {
 		var fm map[string]string
-		fm, err = fetchLog(lg, userName, clusterName, privateKeyPath, iv)
+		fm, err = fetchLog(lg, userName, clusterName, privateKeyPath, runtimeService, logPaths, iv)
 		if err != nil {
 			return nil, err
 		}
@@ -959,6 +966,8 @@ func fetchLog(
 	userName string,
 	clusterName string,
 	privateKeyPath string,
+	runtimeService string,
+	logPaths []string,
 	inst ec2config.Instance) (fpathToS3Path map[string]string, err error) {
 	id, ip := inst.InstanceID, inst.PublicIP
 
@@ -1009,11 +1018,47 @@ func fetchLog(
 	lg.Info("downloaded kubernetes log", zap.String("path", kubeletLogPath))
 	fpathToS3Path = make(map[string]string)
 	fpathToS3Path[kubeletLogPath] = fmt.Sprintf("%s/%s-kubelet.log", clusterName, id)
-	return fpathToS3Path, nil
-}
 
-// genS3URL returns S3 URL path.
-// e.g. https://s3-us-west-2.amazonaws.com/aws-k8s-tester-20180925/hello-world
-func genS3URL(region, bucket, s3Path string) string {
-	return fmt.Sprintf("https://s3-%s.amazonaws.com/%s/%s", region, bucket, s3Path)
+	out, err = sh.Run(
+		fmt.Sprintf("sudo journalctl --no-pager -u %s.service", runtimeService),
+		ssh.WithRetry(15, 5*time.Second),
+		ssh.WithTimeout(15*time.Second),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var runtimeLogPath string
+	runtimeLogPath, err = fileutil.WriteTempFile(out)
+	if err != nil {
+		return nil, err
+	}
+	lg.Info("downloaded container runtime log", zap.String("path", runtimeLogPath), zap.String("service", runtimeService))
+	fpathToS3Path[runtimeLogPath] = fmt.Sprintf("%s/%s-%s.log", clusterName, id, runtimeService)
+
+	// OS logs of the profile (e.g. "/var/log/messages")
+	for _, logPath := range logPaths {
+		out, err = sh.Run(
+			"sudo cat "+logPath,
+			ssh.WithRetry(15, 5*time.Second),
+			ssh.WithTimeout(15*time.Second),
+		)
+		if err != nil {
+			// OS log paths are best-effort, and may not exist on every image
+			lg.Warn(
+				"failed to fetch log",
+				zap.String("instance-id", id),
+				zap.String("log-path", logPath),
+				zap.Error(err),
+			)
+			continue
+		}
+		var fpath string
+		fpath, err = fileutil.WriteTempFile(out)
+		if err != nil {
+			return nil, err
+		}
+		lg.Info("downloaded kubernetes log", zap.String("path", fpath), zap.String("log-path", logPath))
+		fpathToS3Path[fpath] = fmt.Sprintf("%s/%s-%s", clusterName, id, filepath.Base(logPath))
+	}
+	return fpathToS3Path, nil
 }
@@ -0,0 +1,167 @@
+package prow
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Gap is the coverage of a job category that GCP runs.
+type Gap struct {
+	// Category is the job category shared by AWS and GCP jobs.
+	Category string `json:"category"`
+	// Type is the job type of GCP job.
+	Type string `json:"type"`
+	// GCP is the job ID of GCP provider.
+	GCP string `json:"gcp"`
+	// AWS is the job ID of AWS provider.
+	// Empty if AWS has no counterpart.
+	AWS string `json:"aws,omitempty"`
+
+	// GCPInterval is the GCP job run interval, for periodic jobs.
+	GCPInterval time.Duration `json:"gcp-interval,omitempty"`
+	// AWSInterval is the AWS job run interval, for periodic jobs.
+	AWSInterval time.Duration `json:"aws-interval,omitempty"`
+	// MissingBranches is the list of branches that GCP job runs with,
+	// but AWS job does not.
+	MissingBranches []string `json:"missing-branches,omitempty"`
+}
+
+// Missing returns true if AWS has no counterpart.
+func (g Gap) Missing() bool { return g.AWS == "" }
+
+// IntervalDiffers returns true if AWS counterpart runs at a different interval.
+func (g Gap) IntervalDiffers() bool { return g.AWS != "" && g.GCPInterval != g.AWSInterval }
+
+// Covered returns true if AWS counterpart runs at the same interval
+// with all the branches of GCP job.
+func (g Gap) Covered() bool {
+	return !g.Missing() && !g.IntervalDiffers() && len(g.MissingBranches) == 0
+}
+
+// Coverage is the AWS coverage of job categories that GCP runs.
+type Coverage struct {
+	// Commit is the test-infra git commit SHA the jobs were loaded from.
+	Commit string `json:"commit"`
+	// Categories is the number of categories that GCP runs.
+	Categories int `json:"categories"`
+	// Covered is the number of categories that AWS fully covers.
+	Covered int `json:"covered"`
+	// Missing is the number of categories that AWS does not run at all.
+	Missing int `json:"missing"`
+	// Gaps is the list of all categories that GCP runs, sorted by
+	// job type and category.
+	Gaps []Gap `json:"gaps"`
+}
+
+// AnalyzeCoverage compares AWS jobs against GCP jobs of the same category.
+func AnalyzeCoverage(commit string, categoryToProviderToJob map[string]map[string]Job) Coverage {
+	cv := Coverage{Commit: commit, Gaps: []Gap{}}
+	for category, providerToJob := range categoryToProviderToJob {
+		gcp, ok := providerToJob[ProviderGCP]
+		if !ok {
+			continue
+		}
+		g := Gap{
+			Category:    category,
+			Type:        gcp.Type,
+			GCP:         gcp.ID,
+			GCPInterval: gcp.Interval,
+		}
+		if aws, ok := providerToJob[ProviderAWS]; ok {
+			g.AWS = aws.ID
+			g.AWSInterval = aws.Interval
+			g.MissingBranches = missingBranches(gcp.Branches, aws.Branches)
+		}
+
+		cv.Categories++
+		switch {
+		case g.Missing():
+			cv.Missing++
+		case g.Covered():
+			cv.Covered++
+		}
+		cv.Gaps = append(cv.Gaps, g)
+	}
+	sort.Slice(cv.Gaps, func(i, j int) bool {
+		a, b := cv.Gaps[i], cv.Gaps[j]
+		if a.Type != b.Type {
+			return typeOrder(a.Type) < typeOrder(b.Type)
+		}
+		return a.Category < b.Category
+	})
+	return cv
+}
+
+// CategoryToProviderToJob groups jobs by category and provider.
+func (ss Jobs) CategoryToProviderToJob() map[string]map[string]Job {
+	m := make(map[string]map[string]Job)
+	for _, job := range ss {
+		if _, ok := m[job.Category]; !ok {
+			m[job.Category] = make(map[string]Job)
+		}
+		m[job.Category][job.Provider] = job
+	}
+	return m
+}
+
+// missingBranches returns the branches in "gcp" that are not in "aws".
+// Jobs with no branches run with all branches.
+func missingBranches(gcp, aws []string) (ss []string) {
+	if len(aws) == 0 {
+		return nil
+	}
+	if len(gcp) == 0 {
+		return []string{"*"}
+	}
+	all := make(map[string]struct{}, len(aws))
+	for _, v := range aws {
+		all[v] = struct{}{}
+	}
+	for _, v := range gcp {
+		if _, ok := all[v]; !ok {
+			ss = append(ss, v)
+		}
+	}
+	sort.Strings(ss)
+	return ss
+}
+
+func typeOrder(tp string) int {
+	switch tp {
+	case TypePresubmit:
+		return 0
+	case TypePostsubmit:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (cv Coverage) String() string {
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString(fmt.Sprintf("test-infra commit: %s\n", cv.Commit))
+	buf.WriteString(fmt.Sprintf("GCP categories: %d, AWS covered: %d, AWS missing: %d\n\n", cv.Categories, cv.Covered, cv.Missing))
+	buf.WriteString(fmt.Sprintf("%-12s | %-50s | %-50s | %s\n", "TYPE", "GCP", "AWS", "GAP"))
+	for _, g := range cv.Gaps {
+		if g.Covered() {
+			continue
+		}
+		var gaps []string
+		aws := g.AWS
+		if g.Missing() {
+			aws = "N/A"
+			gaps = append(gaps, "missing")
+		}
+		if g.IntervalDiffers() {
+			gaps = append(gaps, fmt.Sprintf("interval %v (GCP %v)", g.AWSInterval, g.GCPInterval))
+		}
+		if len(g.MissingBranches) > 0 {
+			gaps = append(gaps, fmt.Sprintf("branches %s", strings.Join(g.MissingBranches, ",")))
+		}
+		buf.WriteString(fmt.Sprintf("%-12s | %-50s | %-50s | %s\n", g.Type, g.GCP, aws, strings.Join(gaps, "; ")))
+	}
+	return buf.String()
+}
@@ -0,0 +1,85 @@
+package prow
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeCoverage(t *testing.T) {
+	jobs := Jobs{
+		{Type: TypePresubmit, Category: "pull-kubernetes-e2e", Provider: ProviderAWS, ID: "pull-kubernetes-e2e-aws", Branches: []string{"master"}},
+		{Type: TypePresubmit, Category: "pull-kubernetes-e2e", Provider: ProviderGCP, ID: "pull-kubernetes-e2e-gce", Branches: []string{"master", "release-1.13"}},
+		{Type: TypePeriodic, Category: "ci-kubernetes-e2e-kops", Provider: ProviderAWS, ID: "ci-kubernetes-e2e-kops-aws", Interval: 2 * time.Hour},
+		{Type: TypePeriodic, Category: "ci-kubernetes-e2e-kops", Provider: ProviderGCP, ID: "ci-kubernetes-e2e-kops-gce", Interval: time.Hour},
+		{Type: TypePeriodic, Category: "ci-kubernetes-kubemark-500", Provider: ProviderGCP, ID: "ci-kubernetes-kubemark-500-gce", Interval: time.Hour},
+		{Type: TypePostsubmit, Category: "post-cluster-api-provider-test", Provider: ProviderAWS, ID: "post-cluster-api-provider-aws-test"},
+		{Type: TypePostsubmit, Category: "post-cluster-api-provider-test", Provider: ProviderGCP, ID: "post-cluster-api-provider-gcp-test"},
+		{Type: TypePresubmit, Category: "pull-community-verify", Provider: ProviderNotCategorized, ID: "pull-community-verify"},
+	}
+	cv := AnalyzeCoverage("abc", jobs.CategoryToProviderToJob())
+	if cv.Categories != 4 || cv.Covered != 1 || cv.Missing != 1 {
+		t.Fatalf("unexpected coverage %+v", cv)
+	}
+	cats := []string{}
+	for _, g := range cv.Gaps {
+		cats = append(cats, g.Category)
+	}
+	expected := []string{"pull-kubernetes-e2e", "post-cluster-api-provider-test", "ci-kubernetes-e2e-kops", "ci-kubernetes-kubemark-500"}
+	if !reflect.DeepEqual(cats, expected) {
+		t.Fatalf("expected %v, got %v", expected, cats)
+	}
+	if !reflect.DeepEqual(cv.Gaps[0].MissingBranches, []string{"release-1.13"}) {
+		t.Fatalf("unexpected missing branches %v", cv.Gaps[0].MissingBranches)
+	}
+	if !cv.Gaps[2].IntervalDiffers() {
+		t.Fatalf("expected interval mismatch %+v", cv.Gaps[2])
+	}
+	if !cv.Gaps[3].Missing() {
+		t.Fatalf("expected missing %+v", cv.Gaps[3])
+	}
+	t.Log(cv.String())
+
+	dir, err := ioutil.TempDir(os.TempDir(), "prow-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Now().UTC()
+	prev := Snapshot{Git: Git{CommitSHA: "abc", CommitTimeUTC: now.Add(-time.Hour)}, Jobs: jobs}
+	// AWS adds kubemark, and drops cluster-api post-submit
+	cur := Snapshot{Git: Git{CommitSHA: "def", CommitTimeUTC: now}, Jobs: append(Jobs{
+		{Type: TypePeriodic, Category: "ci-kubernetes-kubemark-500", Provider: ProviderAWS, ID: "ci-kubernetes-kubemark-500-aws", Interval: time.Hour},
+	}, jobs[:5]...)}
+	cur.Jobs = append(cur.Jobs, jobs[6:]...)
+	for _, sn := range []Snapshot{cur, prev} {
+		if _, err = SaveSnapshot(dir, sn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ss, err := LoadSnapshots(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss) != 2 || ss[0].Git.CommitSHA != "abc" || ss[1].Git.CommitSHA != "def" {
+		t.Fatalf("unexpected snapshots %+v", ss)
+	}
+
+	df := DiffSnapshots(ss[0], ss[1])
+	if !reflect.DeepEqual(df.AddedJobs, []string{"ci-kubernetes-kubemark-500-aws"}) {
+		t.Fatalf("unexpected added jobs %v", df.AddedJobs)
+	}
+	if !reflect.DeepEqual(df.RemovedJobs, []string{"post-cluster-api-provider-aws-test"}) {
+		t.Fatalf("unexpected removed jobs %v", df.RemovedJobs)
+	}
+	if !reflect.DeepEqual(df.ClosedGaps, []string{"ci-kubernetes-kubemark-500"}) {
+		t.Fatalf("unexpected closed gaps %v", df.ClosedGaps)
+	}
+	if !reflect.DeepEqual(df.OpenedGaps, []string{"post-cluster-api-provider-test"}) {
+		t.Fatalf("unexpected opened gaps %v", df.OpenedGaps)
+	}
+	t.Log(df.String())
+}
@@ -2,6 +2,7 @@ package prow
 
 import (
 	"crypto/tls"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -14,8 +15,15 @@ import (
 
 // DownloadJobsUpstream downloads all Prow configurations from upstream "kubernetes/test-infra".
 func DownloadJobsUpstream(lg *zap.Logger) (dir string, paths []string, err error) {
+	return DownloadJobsUpstreamAt(lg, "master")
+}
+
+// DownloadJobsUpstreamAt downloads all Prow configurations from upstream
+// "kubernetes/test-infra" at the git reference (e.g. branch or commit SHA).
+// Use the commit SHA from "FetchGit" to download the jobs of the exact commit.
+func DownloadJobsUpstreamAt(lg *zap.Logger, ref string) (dir string, paths []string, err error) {
 	http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-	u := "https://github.com/kubernetes/test-infra/archive/master.zip"
+	u := fmt.Sprintf("https://github.com/kubernetes/test-infra/archive/%s.zip", ref)
 	lg.Info("downloading", zap.String("url", u))
 
 	var resp *http.Response
@@ -23,6 +31,10 @@ func DownloadJobsUpstream(lg *zap.Logger) (dir string, paths []string, err error
 	if err != nil {
 		return "", nil, err
 	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return "", nil, fmt.Errorf("failed to download %q (%s)", u, resp.Status)
+	}
 	var d []byte
 	d, err = ioutil.ReadAll(resp.Body)
 	if err != nil {
@@ -52,7 +64,7 @@ func DownloadJobsUpstream(lg *zap.Logger) (dir string, paths []string, err error
 	if err = unzip(lg, p, dir); err != nil {
 		return "", nil, err
 	}
-	dir = filepath.Join(dir, "test-infra-master")
+	dir = filepath.Join(dir, "test-infra-"+ref)
 
 	// https://github.com/kubernetes/test-infra/blob/master/prow/config.yaml
 	// but this is being moved to "config/jobs/*"
@@ -0,0 +1,167 @@
+package prow
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Filter selects jobs by their fields.
+// Empty fields match all jobs, and non-empty fields are compared
+// case-insensitively.
+type Filter struct {
+	// Type is pre-submit, post-submit, or periodic.
+	Type string
+	// Category is the job category.
+	Category string
+	// Provider is AWS, GCP, or Not-Categorized.
+	Provider string
+	// Branch matches jobs that run with the git branch.
+	// Jobs with no branches run with all branches.
+	Branch string
+}
+
+// Match returns true if the job matches the filter.
+func (f Filter) Match(job Job) bool {
+	if f.Type != "" && !strings.EqualFold(f.Type, job.Type) {
+		return false
+	}
+	if f.Category != "" && !strings.EqualFold(f.Category, job.Category) {
+		return false
+	}
+	if f.Provider != "" && !strings.EqualFold(f.Provider, job.Provider) {
+		return false
+	}
+	if f.Branch != "" && len(job.Branches) > 0 {
+		found := false
+		for _, b := range job.Branches {
+			if strings.EqualFold(f.Branch, b) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns the jobs that match the filter, in the same order.
+func (ss Jobs) Filter(f Filter) Jobs {
+	rs := make(Jobs, 0, len(ss))
+	for _, job := range ss {
+		if f.Match(job) {
+			rs = append(rs, job)
+		}
+	}
+	return rs
+}
+
+// jobRecord is the exported form of a job.
+type jobRecord struct {
+	Type      string   `json:"type"`
+	Group     string   `json:"group"`
+	Category  string   `json:"category"`
+	Provider  string   `json:"provider"`
+	ID        string   `json:"id"`
+	Branches  []string `json:"branches"`
+	Interval  string   `json:"interval,omitempty"`
+	URL       string   `json:"url"`
+	StatusURL string   `json:"status-url"`
+	Result    *Result  `json:"result,omitempty"`
+}
+
+func toJobRecord(job Job, results map[string]Result) jobRecord {
+	rc := jobRecord{
+		Type:      job.Type,
+		Group:     job.Group,
+		Category:  job.Category,
+		Provider:  job.Provider,
+		ID:        job.ID,
+		Branches:  job.Branches,
+		URL:       job.URL,
+		StatusURL: job.StatusURL,
+	}
+	if rc.Branches == nil {
+		rc.Branches = []string{}
+	}
+	if job.Interval > 0 {
+		rc.Interval = job.Interval.String()
+	}
+	if rs, ok := results[job.ID]; ok {
+		rc.Result = &rs
+	}
+	return rc
+}
+
+// WriteJSON writes jobs as a JSON array, with their results if any.
+// "results" is keyed on job ID, and can be nil.
+func (ss Jobs) WriteJSON(w io.Writer, results map[string]Result) error {
+	rs := make([]jobRecord, 0, len(ss))
+	for _, job := range ss {
+		rs = append(rs, toJobRecord(job, results))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rs)
+}
+
+var csvHeader = []string{
+	"type",
+	"group",
+	"category",
+	"provider",
+	"id",
+	"branches",
+	"interval",
+	"url",
+	"status-url",
+	"builds",
+	"pass-rate",
+	"flake-rate",
+	"last-success",
+}
+
+// WriteCSV writes jobs in CSV with a header row, with their results if any.
+// Multiple branches are separated by ";".
+func (ss Jobs) WriteCSV(w io.Writer, results map[string]Result) error {
+	wr := csv.NewWriter(w)
+	if err := wr.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, job := range ss {
+		rc := toJobRecord(job, results)
+		builds, passRate, flakeRate, lastSuccess := "", "", "", ""
+		if rc.Result != nil {
+			builds = fmt.Sprintf("%d", rc.Result.Builds)
+			passRate = fmt.Sprintf("%.3f", rc.Result.PassRate)
+			flakeRate = fmt.Sprintf("%.3f", rc.Result.FlakeRate)
+			if !rc.Result.LastSuccess.IsZero() {
+				lastSuccess = rc.Result.LastSuccess.Format(time.RFC3339)
+			}
+		}
+		if err := wr.Write([]string{
+			rc.Type,
+			rc.Group,
+			rc.Category,
+			rc.Provider,
+			rc.ID,
+			strings.Join(rc.Branches, ";"),
+			rc.Interval,
+			rc.URL,
+			rc.StatusURL,
+			builds,
+			passRate,
+			flakeRate,
+			lastSuccess,
+		}); err != nil {
+			return err
+		}
+	}
+	wr.Flush()
+	return wr.Error()
+}
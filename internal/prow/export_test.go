@@ -0,0 +1,80 @@
+package prow
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testJobs = Jobs{
+	{Type: TypePresubmit, Category: "pull-kubernetes-e2e", Provider: ProviderAWS, ID: "pull-kubernetes-e2e-aws", Branches: []string{"master"}},
+	{Type: TypePresubmit, Category: "pull-kubernetes-e2e", Provider: ProviderGCP, ID: "pull-kubernetes-e2e-gce", Branches: []string{"master", "release-1.13"}},
+	{Type: TypePeriodic, Category: "ci-kubernetes-e2e-kops", Provider: ProviderAWS, ID: "ci-kubernetes-e2e-kops-aws", Interval: time.Hour},
+}
+
+func TestJobsFilter(t *testing.T) {
+	tests := []struct {
+		f   Filter
+		ids []string
+	}{
+		{Filter{}, []string{"pull-kubernetes-e2e-aws", "pull-kubernetes-e2e-gce", "ci-kubernetes-e2e-kops-aws"}},
+		{Filter{Provider: "aws"}, []string{"pull-kubernetes-e2e-aws", "ci-kubernetes-e2e-kops-aws"}},
+		{Filter{Type: TypePresubmit, Category: "pull-kubernetes-e2e"}, []string{"pull-kubernetes-e2e-aws", "pull-kubernetes-e2e-gce"}},
+		// jobs with no branches run with all branches
+		{Filter{Branch: "release-1.13"}, []string{"pull-kubernetes-e2e-gce", "ci-kubernetes-e2e-kops-aws"}},
+		{Filter{Provider: ProviderNotCategorized}, []string{}},
+	}
+	for i, tt := range tests {
+		ids := []string{}
+		for _, job := range testJobs.Filter(tt.f) {
+			ids = append(ids, job.ID)
+		}
+		if !reflect.DeepEqual(ids, tt.ids) {
+			t.Fatalf("#%d: expected %v, got %v", i, tt.ids, ids)
+		}
+	}
+}
+
+func TestJobsWrite(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	if err := testJobs.WriteJSON(buf, map[string]Result{"ci-kubernetes-e2e-kops-aws": {Builds: 4, Passed: 3, PassRate: 0.75}}); err != nil {
+		t.Fatal(err)
+	}
+	var rs []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &rs); err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 3 {
+		t.Fatalf("expected 3 jobs, got %d", len(rs))
+	}
+	if rs[2]["interval"] != "1h0m0s" {
+		t.Fatalf("expected interval '1h0m0s', got %v", rs[2]["interval"])
+	}
+	if _, ok := rs[0]["result"]; ok {
+		t.Fatalf("unexpected result %v", rs[0]["result"])
+	}
+	if rv, ok := rs[2]["result"].(map[string]interface{}); !ok || rv["pass-rate"] != 0.75 {
+		t.Fatalf("expected pass rate 0.75, got %v", rs[2]["result"])
+	}
+
+	buf.Reset()
+	if err := testJobs.WriteCSV(buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 rows, got %d", len(rows))
+	}
+	if !reflect.DeepEqual(rows[0], csvHeader) {
+		t.Fatalf("expected header %v, got %v", csvHeader, rows[0])
+	}
+	if rows[2][5] != "master;release-1.13" {
+		t.Fatalf("expected branches 'master;release-1.13', got %q", rows[2][5])
+	}
+}
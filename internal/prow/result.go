@@ -0,0 +1,305 @@
+package prow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultResultURL is the upstream Prow job artifacts bucket.
+const DefaultResultURL = "https://storage.googleapis.com/kubernetes-jenkins"
+
+// ErrResultNotFound is returned when an artifact does not exist.
+var ErrResultNotFound = errors.New("result not found")
+
+// ResultSource fetches Prow job artifacts in GCS bucket layout,
+// by the path relative to the bucket root
+// (e.g. "logs/ci-kubernetes-e2e-kops-aws/latest-build.txt").
+type ResultSource interface {
+	Fetch(p string) ([]byte, error)
+}
+
+type httpSource struct {
+	url string
+	cli *http.Client
+}
+
+// NewHTTPResultSource creates a result source that fetches artifacts
+// under the base URL (e.g. "https://storage.googleapis.com/kubernetes-jenkins").
+func NewHTTPResultSource(baseURL string) ResultSource {
+	return &httpSource{
+		url: strings.TrimSuffix(baseURL, "/"),
+		cli: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *httpSource) Fetch(p string) ([]byte, error) {
+	resp, err := s.cli.Get(s.url + "/" + p)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrResultNotFound
+	default:
+		return nil, fmt.Errorf("failed to fetch %q (%s)", p, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+type localSource struct {
+	dir string
+}
+
+// NewLocalResultSource creates a result source that reads artifacts
+// under the local directory, in the same layout as the bucket.
+func NewLocalResultSource(dir string) ResultSource {
+	return &localSource{dir: dir}
+}
+
+func (s *localSource) Fetch(p string) ([]byte, error) {
+	d, err := ioutil.ReadFile(filepath.Join(s.dir, filepath.FromSlash(p)))
+	if os.IsNotExist(err) {
+		return nil, ErrResultNotFound
+	}
+	return d, err
+}
+
+// Build is the result of a job run.
+type Build struct {
+	// ID is the build number.
+	ID string `json:"id"`
+	// Started is the time when the build started.
+	Started time.Time `json:"started"`
+	// Finished is the time when the build finished.
+	Finished time.Time `json:"finished"`
+	// Passed is true if the build passed.
+	Passed bool `json:"passed"`
+	// Result is the build result (e.g. "SUCCESS", "FAILURE").
+	Result string `json:"result"`
+}
+
+// started.json
+type startedJSON struct {
+	Timestamp int64 `json:"timestamp"`
+}
+
+// finished.json
+type finishedJSON struct {
+	Timestamp int64  `json:"timestamp"`
+	Passed    *bool  `json:"passed"`
+	Result    string `json:"result"`
+}
+
+// resultPrefix returns the artifact directory of the job.
+// Pre-submit jobs are indexed under "pr-logs/directory".
+func resultPrefix(job Job) string {
+	if job.Type == TypePresubmit {
+		return "pr-logs/directory/" + job.ID
+	}
+	return "logs/" + job.ID
+}
+
+// buildPath returns the artifact directory of the build.
+// Pre-submit builds are indexed with "<build>.txt" pointer files,
+// whose content is the build directory under "pr-logs/pull"
+// (e.g. "gs://kubernetes-jenkins/pr-logs/pull/12345/pull-kubernetes-e2e-aws/10").
+func buildPath(src ResultSource, job Job, id int64) (string, error) {
+	prefix := resultPrefix(job)
+	if job.Type != TypePresubmit {
+		return fmt.Sprintf("%s/%d", prefix, id), nil
+	}
+	d, err := src.Fetch(fmt.Sprintf("%s/%d.txt", prefix, id))
+	if err != nil {
+		return "", err
+	}
+	p := strings.TrimSpace(string(d))
+	if strings.HasPrefix(p, "gs://") {
+		// trim the bucket name, relative to the result source
+		p = strings.TrimPrefix(p, "gs://")
+		idx := strings.Index(p, "/")
+		if idx == -1 {
+			return "", fmt.Errorf("invalid build pointer %q of %q build %d", string(d), job.ID, id)
+		}
+		p = p[idx+1:]
+	}
+	return strings.TrimSuffix(p, "/"), nil
+}
+
+// FetchBuilds fetches up to "n" latest finished builds of the job,
+// newest first. Builds that are missing or still running are skipped.
+func FetchBuilds(src ResultSource, job Job, n int) (bs []Build, err error) {
+	prefix := resultPrefix(job)
+	var d []byte
+	d, err = src.Fetch(prefix + "/latest-build.txt")
+	if err != nil {
+		return nil, err
+	}
+	var latest int64
+	latest, err = strconv.ParseInt(strings.TrimSpace(string(d)), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse latest build %q (%v)", string(d), err)
+	}
+
+	// build numbers may not be contiguous, look back at most 2x
+	for id := latest; id > 0 && id > latest-int64(2*n) && len(bs) < n; id-- {
+		b := Build{ID: strconv.FormatInt(id, 10)}
+		var dir string
+		dir, err = buildPath(src, job, id)
+		if err == ErrResultNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		d, err = src.Fetch(dir + "/finished.json")
+		if err == ErrResultNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var fv finishedJSON
+		if err = json.Unmarshal(d, &fv); err != nil {
+			return nil, fmt.Errorf("failed to parse finished.json of %q build %d (%v)", job.ID, id, err)
+		}
+		b.Finished = time.Unix(fv.Timestamp, 0).UTC()
+		b.Result = fv.Result
+		if fv.Passed != nil {
+			b.Passed = *fv.Passed
+		} else {
+			b.Passed = fv.Result == "SUCCESS"
+		}
+
+		d, err = src.Fetch(dir + "/started.json")
+		switch err {
+		case nil:
+			var sv startedJSON
+			if err = json.Unmarshal(d, &sv); err != nil {
+				return nil, fmt.Errorf("failed to parse started.json of %q build %d (%v)", job.ID, id, err)
+			}
+			b.Started = time.Unix(sv.Timestamp, 0).UTC()
+		case ErrResultNotFound:
+		default:
+			return nil, err
+		}
+		bs = append(bs, b)
+	}
+	return bs, nil
+}
+
+// Result summarizes the latest builds of a job.
+type Result struct {
+	// Builds is the number of finished builds.
+	Builds int `json:"builds"`
+	// Passed is the number of passed builds.
+	Passed int `json:"passed"`
+	// PassRate is the ratio of passed builds, between 0 and 1.
+	PassRate float64 `json:"pass-rate"`
+	// FlakeRate is the ratio of consecutive builds that flipped
+	// between pass and fail, between 0 and 1.
+	FlakeRate float64 `json:"flake-rate"`
+	// LastSuccess is the finish time of the latest passed build.
+	// Zero if none of the builds passed.
+	LastSuccess time.Time `json:"last-success,omitempty"`
+	// LastBuild is the latest build.
+	LastBuild Build `json:"last-build"`
+}
+
+// Summarize computes the result of builds, newest first.
+func Summarize(bs []Build) (rs Result) {
+	rs.Builds = len(bs)
+	if rs.Builds == 0 {
+		return rs
+	}
+	rs.LastBuild = bs[0]
+	flips := 0
+	for i, b := range bs {
+		if b.Passed {
+			rs.Passed++
+			if rs.LastSuccess.IsZero() {
+				rs.LastSuccess = b.Finished
+			}
+		}
+		if i > 0 && bs[i-1].Passed != b.Passed {
+			flips++
+		}
+	}
+	rs.PassRate = float64(rs.Passed) / float64(rs.Builds)
+	if rs.Builds > 1 {
+		rs.FlakeRate = float64(flips) / float64(rs.Builds-1)
+	}
+	return rs
+}
+
+// String returns the one-line summary.
+func (rs Result) String() string {
+	if rs.Builds == 0 {
+		return "no results"
+	}
+	last := "never"
+	if !rs.LastSuccess.IsZero() {
+		last = rs.LastSuccess.String()
+	}
+	return fmt.Sprintf("pass %.1f%%, flake %.1f%% (%d builds), last success %s",
+		rs.PassRate*100,
+		rs.FlakeRate*100,
+		rs.Builds,
+		last,
+	)
+}
+
+// FetchResults fetches the latest "n" builds of all jobs with
+// "concurrency" parallel fetches, and returns the results keyed on job ID.
+// Jobs without any results are omitted.
+func FetchResults(lg *zap.Logger, src ResultSource, jobs Jobs, n, concurrency int) map[string]Result {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var mu sync.Mutex
+	rs := make(map[string]Result, len(jobs))
+
+	jobc := make(chan Job)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobc {
+				bs, err := FetchBuilds(src, job, n)
+				if err != nil {
+					if err != ErrResultNotFound {
+						lg.Warn("failed to fetch builds", zap.String("id", job.ID), zap.Error(err))
+					}
+					continue
+				}
+				if len(bs) == 0 {
+					continue
+				}
+				mu.Lock()
+				rs[job.ID] = Summarize(bs)
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, job := range jobs {
+		jobc <- job
+	}
+	close(jobc)
+	wg.Wait()
+
+	lg.Info("fetched job results", zap.Int("jobs", len(jobs)), zap.Int("results", len(rs)))
+	return rs
+}
@@ -0,0 +1,113 @@
+package prow
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func writeBuild(t *testing.T, dir, prefix string, id int, started int64, finished string) {
+	p := filepath.Join(dir, filepath.FromSlash(prefix), fmt.Sprintf("%d", id))
+	if err := os.MkdirAll(p, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(p, "started.json"), []byte(fmt.Sprintf(`{"timestamp": %d}`, started)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if finished == "" {
+		return
+	}
+	if err := ioutil.WriteFile(filepath.Join(p, "finished.json"), []byte(finished), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFetchResults(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "prow-results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	periodic := Job{Type: TypePeriodic, ID: "ci-kubernetes-e2e-kops-aws"}
+	presubmit := Job{Type: TypePresubmit, ID: "pull-kubernetes-e2e-aws"}
+	missing := Job{Type: TypePeriodic, ID: "ci-kubernetes-e2e-missing"}
+
+	// newest first: 7 (running), 6 pass, 5 fail, 4 (missing), 3 pass, 2 pass
+	prefix := "logs/" + periodic.ID
+	if err = os.MkdirAll(filepath.Join(dir, prefix), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, prefix, "latest-build.txt"), []byte("7\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	writeBuild(t, dir, prefix, 7, 1700, "")
+	writeBuild(t, dir, prefix, 6, 1600, `{"timestamp": 1660, "passed": true, "result": "SUCCESS"}`)
+	writeBuild(t, dir, prefix, 5, 1500, `{"timestamp": 1560, "passed": false, "result": "FAILURE"}`)
+	writeBuild(t, dir, prefix, 3, 1300, `{"timestamp": 1360, "result": "SUCCESS"}`)
+	writeBuild(t, dir, prefix, 2, 1200, `{"timestamp": 1260, "result": "SUCCESS"}`)
+
+	prefix = "pr-logs/directory/" + presubmit.ID
+	if err = os.MkdirAll(filepath.Join(dir, prefix), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, prefix, "latest-build.txt"), []byte("10"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// pre-submit builds are indexed with pointers to "pr-logs/pull"
+	if err = ioutil.WriteFile(filepath.Join(dir, prefix, "10.txt"), []byte("gs://kubernetes-jenkins/pr-logs/pull/12345/"+presubmit.ID+"/10\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, prefix, "9.txt"), []byte("gs://kubernetes-jenkins/pr-logs/pull/12344/"+presubmit.ID+"/9"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	writeBuild(t, dir, "pr-logs/pull/12345/"+presubmit.ID, 10, 1000, `{"timestamp": 1060, "passed": false, "result": "FAILURE"}`)
+	writeBuild(t, dir, "pr-logs/pull/12344/"+presubmit.ID, 9, 900, `{"timestamp": 960, "passed": true, "result": "SUCCESS"}`)
+
+	ts := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer ts.Close()
+
+	for _, src := range []ResultSource{NewLocalResultSource(dir), NewHTTPResultSource(ts.URL)} {
+		bs, err := FetchBuilds(src, periodic, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bs) != 3 || bs[0].ID != "6" || bs[2].ID != "3" {
+			t.Fatalf("unexpected builds %+v", bs)
+		}
+		if !bs[0].Started.Equal(time.Unix(1600, 0)) || !bs[0].Finished.Equal(time.Unix(1660, 0)) {
+			t.Fatalf("unexpected build times %+v", bs[0])
+		}
+
+		rs := FetchResults(zap.NewExample(), src, Jobs{periodic, presubmit, missing}, 10, 2)
+		if len(rs) != 2 {
+			t.Fatalf("expected 2 results, got %+v", rs)
+		}
+		r := rs[periodic.ID]
+		if r.Builds != 4 || r.Passed != 3 || r.PassRate != 0.75 {
+			t.Fatalf("unexpected result %+v", r)
+		}
+		// pass, fail, pass, pass
+		if r.FlakeRate != 2.0/3.0 {
+			t.Fatalf("expected flake rate 2/3, got %f", r.FlakeRate)
+		}
+		if !r.LastSuccess.Equal(time.Unix(1660, 0)) {
+			t.Fatalf("unexpected last success %v", r.LastSuccess)
+		}
+		r = rs[presubmit.ID]
+		if r.Builds != 2 || r.PassRate != 0.5 || !r.LastSuccess.Equal(time.Unix(960, 0)) || r.LastBuild.Result != "FAILURE" {
+			t.Fatalf("unexpected result %+v", r)
+		}
+		if !r.LastBuild.Started.Equal(time.Unix(1000, 0)) {
+			t.Fatalf("unexpected last build %+v", r.LastBuild)
+		}
+		t.Log(r.String())
+	}
+}
@@ -0,0 +1,161 @@
+package prow
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Snapshot is the list of jobs loaded from a test-infra git commit.
+type Snapshot struct {
+	// Git is the test-infra git information the jobs were loaded from.
+	Git Git `json:"git"`
+	// Jobs is the list of all jobs.
+	Jobs Jobs `json:"jobs"`
+}
+
+// Coverage returns the AWS coverage of the snapshot.
+func (sn Snapshot) Coverage() Coverage {
+	return AnalyzeCoverage(sn.Git.CommitSHA, sn.Jobs.CategoryToProviderToJob())
+}
+
+// SaveSnapshot saves the snapshot to the directory, keyed on its git commit.
+// It overwrites the previous snapshot of the same commit.
+func SaveSnapshot(dir string, sn Snapshot) (p string, err error) {
+	if sn.Git.CommitSHA == "" {
+		return "", errors.New("empty git commit SHA")
+	}
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	var d []byte
+	d, err = json.Marshal(sn)
+	if err != nil {
+		return "", err
+	}
+	p = filepath.Join(dir, sn.Git.CommitSHA+".json")
+	return p, ioutil.WriteFile(p, d, 0600)
+}
+
+// LoadSnapshots loads all snapshots from the directory,
+// sorted by commit time, oldest first.
+func LoadSnapshots(dir string) (ss []Snapshot, err error) {
+	var ps []string
+	ps, err = filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range ps {
+		var d []byte
+		d, err = ioutil.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		var sn Snapshot
+		if err = json.Unmarshal(d, &sn); err != nil {
+			return nil, fmt.Errorf("failed to parse %q (%v)", p, err)
+		}
+		ss = append(ss, sn)
+	}
+	sort.Slice(ss, func(i, j int) bool {
+		return ss[i].Git.CommitTimeUTC.Before(ss[j].Git.CommitTimeUTC)
+	})
+	return ss, nil
+}
+
+// SnapshotDiff is the difference between two successive snapshots.
+type SnapshotDiff struct {
+	// From is the test-infra git commit SHA of previous snapshot.
+	From string `json:"from"`
+	// To is the test-infra git commit SHA of current snapshot.
+	To string `json:"to"`
+
+	// AddedJobs is the list of job IDs that are only in current snapshot.
+	AddedJobs []string `json:"added-jobs,omitempty"`
+	// RemovedJobs is the list of job IDs that are only in previous snapshot.
+	RemovedJobs []string `json:"removed-jobs,omitempty"`
+
+	// ClosedGaps is the list of categories that AWS newly covers.
+	ClosedGaps []string `json:"closed-gaps,omitempty"`
+	// OpenedGaps is the list of categories that AWS no longer covers,
+	// or that GCP newly runs without AWS coverage.
+	OpenedGaps []string `json:"opened-gaps,omitempty"`
+
+	// Previous is the coverage of previous snapshot.
+	Previous Coverage `json:"previous"`
+	// Current is the coverage of current snapshot.
+	Current Coverage `json:"current"`
+}
+
+// DiffSnapshots compares jobs and AWS coverage between two snapshots.
+func DiffSnapshots(prev, cur Snapshot) SnapshotDiff {
+	df := SnapshotDiff{
+		From:     prev.Git.CommitSHA,
+		To:       cur.Git.CommitSHA,
+		Previous: prev.Coverage(),
+		Current:  cur.Coverage(),
+	}
+
+	prevIDs, curIDs := make(map[string]struct{}), make(map[string]struct{})
+	for _, job := range prev.Jobs {
+		prevIDs[job.ID] = struct{}{}
+	}
+	for _, job := range cur.Jobs {
+		curIDs[job.ID] = struct{}{}
+		if _, ok := prevIDs[job.ID]; !ok {
+			df.AddedJobs = append(df.AddedJobs, job.ID)
+		}
+	}
+	for _, job := range prev.Jobs {
+		if _, ok := curIDs[job.ID]; !ok {
+			df.RemovedJobs = append(df.RemovedJobs, job.ID)
+		}
+	}
+
+	prevCovered := make(map[string]bool)
+	for _, g := range df.Previous.Gaps {
+		prevCovered[g.Category] = g.Covered()
+	}
+	for _, g := range df.Current.Gaps {
+		covered, ok := prevCovered[g.Category]
+		switch {
+		case g.Covered() && ok && !covered:
+			df.ClosedGaps = append(df.ClosedGaps, g.Category)
+		case !g.Covered() && (!ok || covered):
+			df.OpenedGaps = append(df.OpenedGaps, g.Category)
+		}
+	}
+
+	sort.Strings(df.AddedJobs)
+	sort.Strings(df.RemovedJobs)
+	sort.Strings(df.ClosedGaps)
+	sort.Strings(df.OpenedGaps)
+	return df
+}
+
+func (df SnapshotDiff) String() string {
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString(fmt.Sprintf("test-infra commit: %s -> %s\n", df.From, df.To))
+	buf.WriteString(fmt.Sprintf("AWS covered: %d -> %d (of %d -> %d GCP categories)\n",
+		df.Previous.Covered, df.Current.Covered,
+		df.Previous.Categories, df.Current.Categories,
+	))
+	for _, v := range []struct {
+		name string
+		ss   []string
+	}{
+		{"Added jobs", df.AddedJobs},
+		{"Removed jobs", df.RemovedJobs},
+		{"Closed gaps", df.ClosedGaps},
+		{"Opened gaps", df.OpenedGaps},
+	} {
+		buf.WriteString(fmt.Sprintf("%s (%d): %s\n", v.name, len(v.ss), strings.Join(v.ss, ", ")))
+	}
+	return buf.String()
+}
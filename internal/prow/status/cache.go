@@ -0,0 +1,103 @@
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/internal/prow"
+
+	"go.uber.org/zap"
+)
+
+const cacheFileName = "prow-status.json"
+
+// cache is the last good snapshot persisted on disk.
+type cache struct {
+	Updated      time.Time              `json:"updated"`
+	GitK8s       prow.Git               `json:"git-kubernetes"`
+	GitTestInfra prow.Git               `json:"git-test-infra"`
+	Jobs         prow.Jobs              `json:"jobs"`
+	Results      map[string]prow.Result `json:"results,omitempty"`
+}
+
+// save writes the current data to the cache directory.
+// It writes to a temporary file first, so that a crash
+// does not leave a partial cache behind.
+func (s *status) save() error {
+	s.mu.RLock()
+	c := cache{
+		GitK8s:       s.gitK8s,
+		GitTestInfra: s.gitTestInfra,
+		Jobs:         s.jobs,
+		Results:      s.results,
+	}
+	s.mu.RUnlock()
+	c.Updated, _ = s.lastUpdated()
+
+	d, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(s.cfg.CacheDir, 0700); err != nil {
+		return err
+	}
+	p := filepath.Join(s.cfg.CacheDir, cacheFileName)
+	if err = ioutil.WriteFile(p+".tmp", d, 0600); err != nil {
+		return err
+	}
+	if err = os.Rename(p+".tmp", p); err != nil {
+		return err
+	}
+	s.lg.Info("saved cache", zap.String("path", p), zap.Int("jobs", len(c.Jobs)))
+	return nil
+}
+
+// load reads the last good snapshot from the cache directory,
+// and renders it. It returns no error if there is no cache.
+func (s *status) load() error {
+	if s.cfg.CacheDir == "" {
+		return nil
+	}
+	p := filepath.Join(s.cfg.CacheDir, cacheFileName)
+	d, err := ioutil.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var c cache
+	if err = json.Unmarshal(d, &c); err != nil {
+		return err
+	}
+	if len(c.Jobs) == 0 {
+		return errors.New("empty jobs in cache")
+	}
+
+	all := make(map[string]prow.Job, len(c.Jobs))
+	for _, job := range c.Jobs {
+		all[job.ID] = job
+	}
+	s.mu.Lock()
+	s.gitK8s, s.gitTestInfra = c.GitK8s, c.GitTestInfra
+	s.jobs, s.all, s.categoryToProviderToJob = c.Jobs, all, c.Jobs.CategoryToProviderToJob()
+	s.results = c.Results
+	s.mu.Unlock()
+
+	s.statusMu.Lock()
+	s.statusUpdated = c.Updated
+	s.statusMu.Unlock()
+
+	s.render(nil)
+	s.lg.Info("loaded cache",
+		zap.String("path", p),
+		zap.Int("jobs", len(c.Jobs)),
+		zap.Time("updated", c.Updated),
+		zap.String("test-infra-commit", c.GitTestInfra.CommitSHA),
+	)
+	return nil
+}
@@ -2,9 +2,11 @@ package status
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 
+	"github.com/aws/aws-k8s-tester/internal/prow"
 	"github.com/aws/aws-k8s-tester/pkg/ctxhandler"
 
 	"go.uber.org/zap"
@@ -15,6 +17,13 @@ const (
 	Path = "/prow-status"
 	// PathSummary serves prow status summary in plain text.
 	PathSummary = "/prow-status-summary"
+	// PathAPIJobs serves prow jobs in JSON or CSV.
+	// Query parameters "type", "category", "provider", and "branch"
+	// filter jobs, and "format" is either "json" (default) or "csv".
+	PathAPIJobs = "/api/jobs"
+	// PathAPICoverage serves AWS coverage of GCP job categories,
+	// in JSON (default) or in plain text with "format=text".
+	PathAPICoverage = "/api/coverage"
 	// pathReadiness to serve readiness.
 	pathReadiness = "/prow-status-readiness"
 	// pathLiveness to serve liveness.
@@ -27,11 +36,27 @@ const statusKey key = 0
 
 // NewMux returns HTTP request multiplexer with registered handlers.
 func NewMux(ctx context.Context, lg *zap.Logger) *http.ServeMux {
-	s := newStatus(lg)
+	return NewMuxWithConfig(ctx, lg, Config{})
+}
+
+// NewMuxWithConfig returns HTTP request multiplexer with registered handlers.
+// It serves the cached snapshot if any, otherwise refreshes before returning.
+// Then, it keeps refreshing in background until the context is canceled.
+func NewMuxWithConfig(ctx context.Context, lg *zap.Logger, cfg Config) *http.ServeMux {
+	s := newStatus(lg, cfg)
 
-	lg.Info("refreshing on initial start")
-	s.refresh()
-	lg.Info("refreshed on initial start")
+	if err := s.load(); err != nil {
+		lg.Warn("failed to load cache", zap.String("dir", cfg.CacheDir), zap.Error(err))
+	}
+	s.mu.RLock()
+	cached := len(s.all) > 0
+	s.mu.RUnlock()
+	if !cached {
+		lg.Info("refreshing on initial start")
+		s.refresh()
+		lg.Info("refreshed on initial start")
+	}
+	go s.run(ctx)
 
 	ctx = context.WithValue(ctx, statusKey, s)
 	mux := http.NewServeMux()
@@ -45,6 +70,16 @@ func NewMux(ctx context.Context, lg *zap.Logger) *http.ServeMux {
 		Ctx:     ctx,
 		Handler: ctxhandler.ContextHandlerFunc(handlerPathSummary),
 	})
+	mux.Handle(PathAPIJobs, &ctxhandler.ContextAdapter{
+		Logger:  lg,
+		Ctx:     ctx,
+		Handler: ctxhandler.ContextHandlerFunc(handlerPathAPIJobs),
+	})
+	mux.Handle(PathAPICoverage, &ctxhandler.ContextAdapter{
+		Logger:  lg,
+		Ctx:     ctx,
+		Handler: ctxhandler.ContextHandlerFunc(handlerPathAPICoverage),
+	})
 	mux.Handle(pathReadiness, &ctxhandler.ContextAdapter{
 		Logger:  lg,
 		Ctx:     ctx,
@@ -62,7 +97,6 @@ func handlerPath(ctx context.Context, w http.ResponseWriter, req *http.Request)
 	switch req.Method {
 	case http.MethodGet:
 		s := ctx.Value(statusKey).(*status)
-		s.refresh()
 		s.statusMu.RLock()
 		txt := s.statusHTMLHead +
 			s.statusHTMLUpdateMsg +
@@ -92,6 +126,69 @@ func handlerPathSummary(ctx context.Context, w http.ResponseWriter, req *http.Re
 	}
 }
 
+func handlerPathAPIJobs(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	switch req.Method {
+	case http.MethodGet:
+		q := req.URL.Query()
+		f := prow.Filter{
+			Type:     q.Get("type"),
+			Category: q.Get("category"),
+			Provider: q.Get("provider"),
+			Branch:   q.Get("branch"),
+		}
+		s := ctx.Value(statusKey).(*status)
+		s.mu.RLock()
+		jobs := s.jobs.Filter(f)
+		results := s.results
+		s.mu.RUnlock()
+
+		switch format := q.Get("format"); format {
+		case "", "json":
+			w.Header().Set("Content-Type", "application/json")
+			return jobs.WriteJSON(w, results)
+		case "csv":
+			w.Header().Set("Content-Type", "text/csv")
+			return jobs.WriteCSV(w, results)
+		default:
+			http.Error(w, fmt.Sprintf("unknown format %q", format), http.StatusBadRequest)
+			return fmt.Errorf("unknown format %q", format)
+		}
+
+	default:
+		http.Error(w, "Method Not Allowed", 405)
+		return fmt.Errorf("Method %q Not Allowed", req.Method)
+	}
+}
+
+func handlerPathAPICoverage(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	switch req.Method {
+	case http.MethodGet:
+		s := ctx.Value(statusKey).(*status)
+		s.mu.RLock()
+		cv := prow.AnalyzeCoverage(s.gitTestInfra.CommitSHA, s.categoryToProviderToJob)
+		s.mu.RUnlock()
+
+		switch format := req.URL.Query().Get("format"); format {
+		case "", "json":
+			w.Header().Set("Content-Type", "application/json")
+			return json.NewEncoder(w).Encode(cv)
+		case "text":
+			_, err := w.Write([]byte(cv.String()))
+			return err
+		default:
+			http.Error(w, fmt.Sprintf("unknown format %q", format), http.StatusBadRequest)
+			return fmt.Errorf("unknown format %q", format)
+		}
+
+	default:
+		http.Error(w, "Method Not Allowed", 405)
+		return fmt.Errorf("Method %q Not Allowed", req.Method)
+	}
+}
+
+// handlerPathReadiness returns 200 when there is data to serve, even if
+// it is stale (e.g. the last snapshot from cache on restarts), and 503
+// when there is no data.
 func handlerPathReadiness(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
 	switch req.Method {
 	case http.MethodGet:
@@ -99,10 +196,20 @@ func handlerPathReadiness(ctx context.Context, w http.ResponseWriter, req *http.
 		s.mu.RLock()
 		ok := len(s.all) > 0
 		s.mu.RUnlock()
-		if ok {
+		if !ok {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, err := w.Write([]byte("NOT READY: no data\n"))
+			return err
+		}
+		updated, stale := s.lastUpdated()
+		if stale {
 			w.WriteHeader(http.StatusOK)
+			_, err := fmt.Fprintf(w, "READY (STALE): last refreshed at %s (threshold %v)\n", updated, s.cfg.StaleThreshold)
+			return err
 		}
-		return nil
+		w.WriteHeader(http.StatusOK)
+		_, err := fmt.Fprintf(w, "READY: last refreshed at %s\n", updated)
+		return err
 
 	default:
 		http.Error(w, "Method Not Allowed", 405)
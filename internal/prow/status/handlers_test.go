@@ -2,13 +2,18 @@ package status
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-k8s-tester/internal/prow"
+	"github.com/aws/aws-k8s-tester/pkg/ctxhandler"
 	"go.uber.org/zap"
 )
 
@@ -21,29 +26,27 @@ func TestNewMux(t *testing.T) {
 	ts := httptest.NewServer(mux)
 	defer ts.Close()
 
-	_, err := http.Get(ts.URL + Path)
+	rs, err := http.Get(ts.URL + Path)
 	if err != nil {
 		t.Fatal(err)
 	}
-	// expect rate limit
-	_, err = http.Get(ts.URL + Path)
+	var body []byte
+	body, err = ioutil.ReadAll(rs.Body)
 	if err != nil {
 		t.Fatal(err)
 	}
-	var rs *http.Response
-	rs, err = http.Get(ts.URL + Path)
-	if err != nil {
-		t.Fatal(err)
+	if !strings.Contains(string(body), "last update was ") {
+		t.Fatal("'last update was' expected")
 	}
-	var body []byte
-	body, err = ioutil.ReadAll(rs.Body)
+	fmt.Println(string(body))
+
+	rs, err = http.Get(ts.URL + pathReadiness)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !strings.Contains(string(body), "rate limit exceeded ") {
-		t.Fatal("'rate limit exceeded' expected")
+	if rs.StatusCode != http.StatusOK {
+		t.Errorf("expected status code %v, got %v", http.StatusOK, rs.StatusCode)
 	}
-	fmt.Println(string(body))
 
 	rs, err = http.Get(ts.URL + "/not-exists")
 	if err != nil {
@@ -53,3 +56,165 @@ func TestNewMux(t *testing.T) {
 		t.Errorf("expected status code %v, got %v", http.StatusNotFound, rs.StatusCode)
 	}
 }
+
+func TestAPIJobs(t *testing.T) {
+	s := newStatus(zap.NewExample(), Config{})
+	s.jobs = prow.Jobs{
+		{Type: prow.TypePresubmit, Category: "pull-kubernetes-e2e", Provider: prow.ProviderAWS, ID: "pull-kubernetes-e2e-aws", Branches: []string{"master"}},
+		{Type: prow.TypePresubmit, Category: "pull-kubernetes-e2e", Provider: prow.ProviderGCP, ID: "pull-kubernetes-e2e-gce", Branches: []string{"master"}},
+	}
+	s.results = map[string]prow.Result{
+		"pull-kubernetes-e2e-gce": {Builds: 2, Passed: 1, PassRate: 0.5, FlakeRate: 1},
+	}
+	ts := httptest.NewServer(&ctxhandler.ContextAdapter{
+		Logger:  zap.NewExample(),
+		Ctx:     context.WithValue(context.Background(), statusKey, s),
+		Handler: ctxhandler.ContextHandlerFunc(handlerPathAPIJobs),
+	})
+	defer ts.Close()
+
+	tests := []struct {
+		query    string
+		code     int
+		contains []string
+		excludes []string
+	}{
+		{"", http.StatusOK, []string{`"id": "pull-kubernetes-e2e-aws"`, `"id": "pull-kubernetes-e2e-gce"`}, nil},
+		{"?provider=GCP", http.StatusOK, []string{`"id": "pull-kubernetes-e2e-gce"`, `"pass-rate": 0.5`}, []string{"pull-kubernetes-e2e-aws"}},
+		{"?provider=AWS&format=csv", http.StatusOK, []string{"type,group,category", "pull-kubernetes-e2e-aws"}, []string{"pull-kubernetes-e2e-gce"}},
+		{"?branch=release-1.13", http.StatusOK, []string{"[]"}, []string{"pull-kubernetes-e2e"}},
+		{"?format=xml", http.StatusBadRequest, nil, nil},
+	}
+	for i, tt := range tests {
+		rs, err := http.Get(ts.URL + PathAPIJobs + tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := ioutil.ReadAll(rs.Body)
+		rs.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rs.StatusCode != tt.code {
+			t.Fatalf("#%d: expected status code %d, got %d", i, tt.code, rs.StatusCode)
+		}
+		for _, v := range tt.contains {
+			if !strings.Contains(string(d), v) {
+				t.Fatalf("#%d: expected %q in %q", i, v, string(d))
+			}
+		}
+		for _, v := range tt.excludes {
+			if strings.Contains(string(d), v) {
+				t.Fatalf("#%d: unexpected %q in %q", i, v, string(d))
+			}
+		}
+	}
+}
+
+func TestCacheReadiness(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "prow-status-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := Config{RefreshInterval: time.Hour, CacheDir: dir}
+	s := newStatus(zap.NewExample(), cfg)
+	ts := httptest.NewServer(&ctxhandler.ContextAdapter{
+		Logger:  zap.NewExample(),
+		Ctx:     context.WithValue(context.Background(), statusKey, s),
+		Handler: ctxhandler.ContextHandlerFunc(handlerPathReadiness),
+	})
+	defer ts.Close()
+
+	check := func(code int, prefix string) {
+		rs, err := http.Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := ioutil.ReadAll(rs.Body)
+		rs.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rs.StatusCode != code || !strings.HasPrefix(string(d), prefix) {
+			t.Fatalf("expected %d %q, got %d %q", code, prefix, rs.StatusCode, string(d))
+		}
+	}
+	check(http.StatusServiceUnavailable, "NOT READY")
+
+	s.gitK8s = prow.Git{CommitSHA: "c98aa0a5c1e897a2eaa9f2ed7a5a980114ceedfc"}
+	s.gitTestInfra = prow.Git{CommitSHA: "81934b1c32c2e897a2eaa9f2ed7a5a980114ceed"}
+	s.jobs = prow.Jobs{
+		{Type: prow.TypePresubmit, Category: "pull-kubernetes-e2e", Provider: prow.ProviderAWS, ID: "pull-kubernetes-e2e-aws"},
+		{Type: prow.TypePresubmit, Category: "pull-kubernetes-e2e", Provider: prow.ProviderGCP, ID: "pull-kubernetes-e2e-gce"},
+	}
+	s.all = map[string]prow.Job{"pull-kubernetes-e2e-aws": s.jobs[0], "pull-kubernetes-e2e-gce": s.jobs[1]}
+	s.statusUpdated = time.Now().UTC().Add(-3 * time.Hour)
+	check(http.StatusOK, "READY (STALE)")
+	if err = s.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// restart from cache
+	s2 := newStatus(zap.NewExample(), cfg)
+	if err = s2.load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(s2.all) != 2 || s2.gitTestInfra.CommitSHA != "81934b1c32c2e897a2eaa9f2ed7a5a980114ceed" || len(s2.categoryToProviderToJob["pull-kubernetes-e2e"]) != 2 {
+		t.Fatalf("unexpected cached status %+v", s2)
+	}
+	if !strings.Contains(s2.statusHTMLJobRows, "pull-kubernetes-e2e-gce") {
+		t.Fatalf("expected rendered job rows, got %q", s2.statusHTMLJobRows)
+	}
+	if _, stale := s2.lastUpdated(); !stale {
+		t.Fatal("expected stale cache")
+	}
+
+	s.statusMu.Lock()
+	s.statusUpdated = time.Now().UTC()
+	s.statusMu.Unlock()
+	check(http.StatusOK, "READY: ")
+}
+
+func TestFetchDegraded(t *testing.T) {
+	s := newStatus(zap.NewExample(), Config{RefreshInterval: time.Hour})
+	s.gitK8s = prow.Git{Name: "kubernetes", CommitSHA: "c98aa0a5c1e897a2eaa9f2ed7a5a980114ceedfc"}
+	s.gitTestInfra = prow.Git{Name: "test-infra", CommitSHA: "81934b1c32c2e897a2eaa9f2ed7a5a980114ceed"}
+	s.jobs = prow.Jobs{{Type: prow.TypePresubmit, Category: "pull-kubernetes-e2e", Provider: prow.ProviderAWS, ID: "pull-kubernetes-e2e-aws"}}
+	s.all = map[string]prow.Job{"pull-kubernetes-e2e-aws": s.jobs[0]}
+
+	// only 'kubernetes' git fails, and 'test-infra' commit is unchanged
+	s.fetchGit = func(remoteGit, branch string) (prow.Git, error) {
+		if strings.HasSuffix(remoteGit, "/kubernetes") {
+			return prow.Git{}, errors.New("rate limited")
+		}
+		return prow.Git{Name: "test-infra", CommitSHA: "81934b1c32c2e897a2eaa9f2ed7a5a980114ceed"}, nil
+	}
+	updated, err := s.fetch()
+	if !updated || err == nil || !strings.Contains(err.Error(), "rate limited") {
+		t.Fatalf("expected updated with error, got %v, %v", updated, err)
+	}
+	if s.gitK8s.CommitSHA != "c98aa0a5c1e897a2eaa9f2ed7a5a980114ceedfc" || len(s.all) != 1 {
+		t.Fatalf("expected previous data, got %+v", s)
+	}
+	if _, stale := s.lastUpdated(); stale {
+		t.Fatal("expected fresh data")
+	}
+
+	// 'test-infra' git fails, previous jobs are kept
+	s.statusUpdated = time.Time{}
+	s.fetchGit = func(remoteGit, branch string) (prow.Git, error) {
+		return prow.Git{}, errors.New("rate limited")
+	}
+	updated, err = s.fetch()
+	if updated || err == nil {
+		t.Fatalf("expected not updated with error, got %v, %v", updated, err)
+	}
+	if s.gitTestInfra.CommitSHA != "81934b1c32c2e897a2eaa9f2ed7a5a980114ceed" || len(s.all) != 1 {
+		t.Fatalf("expected previous data, got %+v", s)
+	}
+	if _, stale := s.lastUpdated(); !stale {
+		t.Fatal("expected stale data")
+	}
+}
@@ -226,6 +226,7 @@ func createJobRows(
 	jobs prow.Jobs,
 	all map[string]prow.Job,
 	categoryToProviderToJob map[string]map[string]prow.Job,
+	results map[string]prow.Result,
 ) string {
 	rows := make([]jobRow, 0, len(jobs))
 	for category, providerToJob := range categoryToProviderToJob {
@@ -245,6 +246,7 @@ func createJobRows(
 				job.ID,
 				job.StatusURL,
 			)
+			row.ProviderAWS += resultCell(job, results)
 		} else {
 			row.ProviderAWS = "N/A"
 		}
@@ -256,6 +258,7 @@ func createJobRows(
 				job.ID,
 				job.StatusURL,
 			)
+			row.ProviderGCP += resultCell(job, results)
 		}
 		if job, ok = providerToJob[prow.ProviderNotCategorized]; ok {
 			row.Type = job.Type
@@ -265,6 +268,7 @@ func createJobRows(
 				job.ID,
 				job.StatusURL,
 			)
+			row.ProviderNotCategorized += resultCell(job, results)
 		}
 		rows = append(rows, row)
 	}
@@ -277,6 +281,24 @@ func createJobRows(
 	return html.UnescapeString(buf.String())
 }
 
+// resultCell returns the job result summary in HTML, if any.
+func resultCell(job prow.Job, results map[string]prow.Result) string {
+	rs, ok := results[job.ID]
+	if !ok {
+		return ""
+	}
+	last := "never"
+	if !rs.LastSuccess.IsZero() {
+		last = humanize.Time(rs.LastSuccess)
+	}
+	return fmt.Sprintf("<br>pass %.1f%%, flake %.1f%% (%d builds)<br>last success %s",
+		rs.PassRate*100,
+		rs.FlakeRate*100,
+		rs.Builds,
+		last,
+	)
+}
+
 const upstreamHTMLEnd = `
 </body>
 </html>
@@ -1,8 +1,11 @@
 package status
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,8 +14,42 @@ import (
 	"go.uber.org/zap"
 )
 
+// Config configures the status server.
+type Config struct {
+	// Results configures job result ingestion.
+	Results ResultConfig
+	// RefreshInterval is the interval to refresh jobs in background.
+	// If zero, defaults to 3 hours.
+	RefreshInterval time.Duration
+	// StaleThreshold is the age of the last successful refresh, after which
+	// the data is refreshed immediately on start, and readiness notes stale
+	// data while still serving it. If zero, defaults to twice "RefreshInterval".
+	StaleThreshold time.Duration
+	// CacheDir is the directory to persist the last good snapshot,
+	// so that restarts serve immediately. If empty, nothing is persisted.
+	CacheDir string
+}
+
+// ResultConfig configures job result ingestion.
+type ResultConfig struct {
+	// Source is the job artifacts source.
+	// If nil, job results are not fetched.
+	Source prow.ResultSource
+	// Builds is the number of latest builds to fetch per job.
+	Builds int
+}
+
+const (
+	defaultRefreshInterval = 3 * time.Hour
+	resultFetchConcurrency = 20
+)
+
 type status struct {
-	lg *zap.Logger
+	lg  *zap.Logger
+	cfg Config
+
+	// fetchGit fetches the latest commit of the git branch.
+	fetchGit func(remoteGit, branch string) (prow.Git, error)
 
 	mu                      sync.RWMutex
 	gitK8s                  prow.Git
@@ -20,9 +57,9 @@ type status struct {
 	jobs                    prow.Jobs
 	all                     map[string]prow.Job
 	categoryToProviderToJob map[string]map[string]prow.Job
+	results                 map[string]prow.Result
 
 	statusMu            sync.RWMutex
-	statusUpdateLimit   time.Duration
 	statusUpdated       time.Time
 	statusHTMLHead      string
 	statusHTMLUpdateMsg string
@@ -31,12 +68,19 @@ type status struct {
 	statusHTMLEnd       string
 }
 
-func newStatus(lg *zap.Logger) *status {
+func newStatus(lg *zap.Logger, cfg Config) *status {
+	if cfg.RefreshInterval == 0 {
+		cfg.RefreshInterval = defaultRefreshInterval
+	}
+	if cfg.StaleThreshold == 0 {
+		cfg.StaleThreshold = 2 * cfg.RefreshInterval
+	}
 	return &status{
-		lg: lg,
+		lg:  lg,
+		cfg: cfg,
+
+		fetchGit: prow.FetchGit,
 
-		statusUpdated:       time.Now().UTC().Add(-3 * time.Hour),
-		statusUpdateLimit:   3 * time.Hour, // max 1 request for every 3-hour
 		statusHTMLHead:      htmlHead,
 		statusHTMLUpdateMsg: "",
 		statusHTMLGitRows:   "",
@@ -48,9 +92,13 @@ func newStatus(lg *zap.Logger) *status {
 func (s *status) getSummary() string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	n, awsN, gcpN, notCategorizedN, awsPct, gcpPct, notCategorizedPct := s.count()
+	return createUpdateMsgSummary(n, awsN, gcpN, notCategorizedN, awsPct, gcpPct, notCategorizedPct)
+}
 
-	n := int64(len(s.all))
-	awsN, gcpN, notCategorizedN := int64(0), int64(0), int64(0)
+// count must be called with "s.mu" held.
+func (s *status) count() (n, awsN, gcpN, notCategorizedN int64, awsPct, gcpPct, notCategorizedPct float64) {
+	n = int64(len(s.all))
 	for _, v := range s.all {
 		switch v.Provider {
 		case prow.ProviderAWS:
@@ -61,110 +109,147 @@ func (s *status) getSummary() string {
 			notCategorizedN++
 		}
 	}
-	awsPct := (float64(awsN) / float64(n)) * 100
-	gcpPct := (float64(gcpN) / float64(n)) * 100
-	notCategorizedPct := (float64(notCategorizedN) / float64(n)) * 100
+	awsPct = (float64(awsN) / float64(n)) * 100
+	gcpPct = (float64(gcpN) / float64(n)) * 100
+	notCategorizedPct = (float64(notCategorizedN) / float64(n)) * 100
+	return n, awsN, gcpN, notCategorizedN, awsPct, gcpPct, notCategorizedPct
+}
 
-	return createUpdateMsgSummary(n, awsN, gcpN, notCategorizedN, awsPct, gcpPct, notCategorizedPct)
+// lastUpdated returns the time of the last successful refresh,
+// and whether it is older than the stale threshold.
+func (s *status) lastUpdated() (updated time.Time, stale bool) {
+	s.statusMu.RLock()
+	updated = s.statusUpdated
+	s.statusMu.RUnlock()
+	return updated, time.Now().UTC().Sub(updated) > s.cfg.StaleThreshold
 }
 
+// run refreshes in background until the context is canceled.
+// It refreshes immediately if the current data is stale.
+func (s *status) run(ctx context.Context) {
+	if _, stale := s.lastUpdated(); stale {
+		s.refresh()
+	}
+	ticker := time.NewTicker(s.cfg.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.lg.Info("stopped background refresh", zap.Error(ctx.Err()))
+			return
+		case <-ticker.C:
+			s.refresh()
+		}
+	}
+}
+
+// refresh fetches the latest git commits, and reloads jobs only when
+// the test-infra commit has changed. Job results are always reloaded.
 func (s *status) refresh() {
-	s.mu.Lock()
 	s.lg.Info("refreshing")
-	defer func() {
-		s.mu.Unlock()
+	updated, err := s.fetch()
+	if err != nil {
+		s.lg.Warn("failed to refresh", zap.Bool("updated", updated), zap.Error(err))
+	} else {
 		s.lg.Info("refreshed")
-	}()
+	}
+	s.render(err)
+	if updated && s.cfg.CacheDir != "" {
+		if err = s.save(); err != nil {
+			s.lg.Warn("failed to save cache", zap.String("dir", s.cfg.CacheDir), zap.Error(err))
+		}
+	}
+}
 
-	n := int64(len(s.all))
-	awsN, gcpN, notCategorizedN := int64(0), int64(0), int64(0)
-	for _, v := range s.all {
-		switch v.Provider {
-		case prow.ProviderAWS:
-			awsN++
-		case prow.ProviderGCP:
-			gcpN++
-		case prow.ProviderNotCategorized:
-			notCategorizedN++
+// fetch fetches each source separately. On failures, the previous data of
+// the source is kept, and the errors are returned together. It returns true
+// if the jobs are up-to-date with the latest test-infra commit.
+func (s *status) fetch() (updated bool, err error) {
+	s.mu.RLock()
+	gitK8s, gitTestInfra := s.gitK8s, s.gitTestInfra
+	jobs, all, categoryToProviderToJob := s.jobs, s.all, s.categoryToProviderToJob
+	results := s.results
+	s.mu.RUnlock()
+
+	var errs []string
+	git, gerr := s.fetchGit("https://github.com/kubernetes/kubernetes", "master")
+	if gerr != nil {
+		s.lg.Warn("failed to fetch 'kubernetes' git", zap.Error(gerr))
+		errs = append(errs, fmt.Sprintf("failed to fetch 'kubernetes' git (%v)", gerr))
+	} else {
+		gitK8s = git
+	}
+
+	git, gerr = s.fetchGit("https://github.com/kubernetes/test-infra", "master")
+	switch {
+	case gerr != nil:
+		// cannot tell if jobs have changed, keep the previous jobs
+		s.lg.Warn("failed to fetch 'test-infra' git", zap.Error(gerr))
+		errs = append(errs, fmt.Sprintf("failed to fetch 'test-infra' git (%v)", gerr))
+
+	case len(all) > 0 && gitTestInfra.CommitSHA == git.CommitSHA:
+		s.lg.Info("'test-infra' commit unchanged; skipping job download", zap.String("commit", git.CommitSHA))
+		updated = true
+
+	default:
+		j, a, c, jerr := s.downloadJobs(git.CommitSHA)
+		if jerr != nil {
+			s.lg.Warn("failed to fetch jobs", zap.String("commit", git.CommitSHA), zap.Error(jerr))
+			errs = append(errs, fmt.Sprintf("failed to fetch jobs (%v)", jerr))
+			break
 		}
+		gitTestInfra = git
+		jobs, all, categoryToProviderToJob = j, a, c
+		updated = true
 	}
-	awsPct := (float64(awsN) / float64(n)) * 100
-	gcpPct := (float64(gcpN) / float64(n)) * 100
-	notCategorizedPct := (float64(notCategorizedN) / float64(n)) * 100
 
-	s.statusMu.RLock()
-	took := time.Now().UTC().Sub(s.statusUpdated)
-	ok := took < s.statusUpdateLimit
-	s.statusMu.RUnlock()
-	if ok {
-		s.statusMu.Lock()
-		s.statusHTMLUpdateMsg = createUpdateMsg(n, awsN, gcpN, notCategorizedN, awsPct, gcpPct, notCategorizedPct, s.statusUpdated, fmt.Errorf("rate limit exceeded %v", s.statusUpdateLimit))
-		s.statusMu.Unlock()
-		return
+	if s.cfg.Results.Source != nil && s.cfg.Results.Builds > 0 && len(jobs) > 0 {
+		results = prow.FetchResults(s.lg, s.cfg.Results.Source, jobs, s.cfg.Results.Builds, resultFetchConcurrency)
 	}
 
-	var err error
-	s.gitK8s, err = prow.FetchGit("https://github.com/kubernetes/kubernetes", "master")
-	if err != nil {
-		s.lg.Warn("failed to fetch 'kubernetes' git", zap.Error(err))
+	s.mu.Lock()
+	s.gitK8s, s.gitTestInfra = gitK8s, gitTestInfra
+	s.jobs, s.all, s.categoryToProviderToJob = jobs, all, categoryToProviderToJob
+	s.results = results
+	s.mu.Unlock()
+
+	if updated {
 		s.statusMu.Lock()
-		s.statusHTMLUpdateMsg = createUpdateMsg(n, awsN, gcpN, notCategorizedN, awsPct, gcpPct, notCategorizedPct, s.statusUpdated, err)
+		s.statusUpdated = time.Now().UTC()
 		s.statusMu.Unlock()
-		return
 	}
-	s.gitTestInfra, err = prow.FetchGit("https://github.com/kubernetes/test-infra", "master")
-	if err != nil {
-		s.lg.Warn("failed to fetch 'test-infra' git", zap.Error(err))
-		s.statusMu.Lock()
-		s.statusHTMLUpdateMsg = createUpdateMsg(n, awsN, gcpN, notCategorizedN, awsPct, gcpPct, notCategorizedPct, s.statusUpdated, err)
-		s.statusMu.Unlock()
-		return
+	if len(errs) > 0 {
+		err = errors.New(strings.Join(errs, ", "))
 	}
+	return updated, err
+}
 
+// downloadJobs downloads and loads the jobs of the test-infra commit.
+func (s *status) downloadJobs(commit string) (jobs prow.Jobs, all map[string]prow.Job, categoryToProviderToJob map[string]map[string]prow.Job, err error) {
 	var dir string
 	var paths []string
-	dir, paths, err = prow.DownloadJobsUpstream(s.lg)
+	dir, paths, err = prow.DownloadJobsUpstreamAt(s.lg, commit)
 	if err != nil {
-		s.lg.Warn("failed to download 'test-infra' git", zap.Error(err))
-		s.statusMu.Lock()
-		s.statusHTMLUpdateMsg = createUpdateMsg(n, awsN, gcpN, notCategorizedN, awsPct, gcpPct, notCategorizedPct, s.statusUpdated, err)
-		s.statusMu.Unlock()
-		return
+		return nil, nil, nil, err
 	}
 	defer os.RemoveAll(dir)
+	return prow.LoadJobs(s.lg, paths)
+}
 
-	s.jobs, s.all, s.categoryToProviderToJob, err = prow.LoadJobs(s.lg, paths)
-	if err != nil {
-		s.lg.Warn("failed to fetch jobs", zap.Error(err))
-		s.statusMu.Lock()
-		s.statusHTMLUpdateMsg = createUpdateMsg(n, awsN, gcpN, notCategorizedN, awsPct, gcpPct, notCategorizedPct, s.statusUpdated, err)
-		s.statusMu.Unlock()
-		return
-	}
-
-	n = int64(len(s.all))
-	awsN, gcpN, notCategorizedN = int64(0), int64(0), int64(0)
-	for _, v := range s.all {
-		switch v.Provider {
-		case prow.ProviderAWS:
-			awsN++
-		case prow.ProviderGCP:
-			gcpN++
-		case prow.ProviderNotCategorized:
-			notCategorizedN++
-		}
-	}
-	awsPct = (float64(awsN) / float64(n)) * 100
-	gcpPct = (float64(gcpN) / float64(n)) * 100
-	notCategorizedPct = (float64(notCategorizedN) / float64(n)) * 100
+// render updates the HTML page with the current data.
+// Non-nil error is shown with the last update time.
+func (s *status) render(err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n, awsN, gcpN, notCategorizedN, awsPct, gcpPct, notCategorizedPct := s.count()
 
-	now := time.Now().UTC()
 	s.statusMu.Lock()
-	s.statusUpdated = now
+	defer s.statusMu.Unlock()
 	s.statusHTMLHead = htmlHead
-	s.statusHTMLUpdateMsg = createUpdateMsg(n, awsN, gcpN, notCategorizedN, awsPct, gcpPct, notCategorizedPct, s.statusUpdated, nil)
-	s.statusHTMLGitRows = createGitRows(now, []prow.Git{s.gitK8s, s.gitTestInfra})
-	s.statusHTMLJobRows = createJobRows(s.jobs, s.all, s.categoryToProviderToJob)
+	s.statusHTMLUpdateMsg = createUpdateMsg(n, awsN, gcpN, notCategorizedN, awsPct, gcpPct, notCategorizedPct, s.statusUpdated, err)
+	if len(s.all) > 0 {
+		s.statusHTMLGitRows = createGitRows(time.Now().UTC(), []prow.Git{s.gitK8s, s.gitTestInfra})
+		s.statusHTMLJobRows = createJobRows(s.jobs, s.all, s.categoryToProviderToJob, s.results)
+	}
 	s.statusHTMLEnd = upstreamHTMLEnd
-	s.statusMu.Unlock()
 }
@@ -9,11 +9,14 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-k8s-tester/ec2config/osprofile"
+	"github.com/aws/aws-k8s-tester/etcdconfig"
 	"github.com/blang/semver"
 	"k8s.io/client-go/util/homedir"
 	"sigs.k8s.io/yaml"
@@ -54,6 +57,23 @@ type Config struct {
 	KubeadmInit *KubeadmInit `json:"kubeadm-init"`
 	// KubeadmJoin is the "kubeadm join" configuration.
 	KubeadmJoin *KubeadmJoin `json:"kubeadm-join"`
+	// ContainerRuntime is the container runtime of master and worker nodes,
+	// "docker", "containerd", or "cri-o". EC2 plugins must install it
+	// (e.g. "install-start-containerd"), unless the OS profile ships with one.
+	// It sets kubelet CRI endpoint and cgroup driver, and kubeadm CRI socket.
+	ContainerRuntime string `json:"container-runtime"`
+
+	// HAMode is the highly-available control plane mode, empty for a single master node.
+	// "stacked" runs etcd members on master nodes, and "external" creates a separate
+	// etcd cluster "ETCDNodes". Master nodes are put behind the load balancer, and
+	// extra master nodes join with "kubeadm join --control-plane", downloading the
+	// certificates uploaded by "kubeadm init --upload-certs" (requires kubeadm 1.15+).
+	HAMode string `json:"ha-mode"`
+	// ETCDNodes defines the external etcd cluster for "external" HA mode.
+	ETCDNodes *etcdconfig.Config `json:"etcd-nodes"`
+	// ETCDNodesCreated is true to indicate that etcd nodes have been created,
+	// thus needs clean-up on test complete.
+	ETCDNodesCreated bool `json:"etcd-nodes-created"`
 
 	// ConfigPath is the configuration file path.
 	// Must be left empty, and let deployer auto-populate this field.
@@ -113,6 +133,17 @@ type Config struct {
 	TestTimeout time.Duration `json:"test-timeout"`
 }
 
+const (
+	// HAModeStacked runs etcd members on master nodes, managed by kubeadm.
+	HAModeStacked = "stacked"
+	// HAModeExternal runs etcd on separate nodes, created by etcd tester.
+	HAModeExternal = "external"
+)
+
+// minimum kubeadm version for "kubeadm init --upload-certs"
+// and "kubeadm join --control-plane"
+var haMinVer = semver.MustParse("1.15.0")
+
 // NewDefault returns a copy of the default configuration.
 func NewDefault() *Config {
 	vv := defaultConfig
@@ -135,6 +166,15 @@ func init() {
 	defaultConfig.ClusterName = defaultConfig.Tag + "-" + randString(5)
 	defaultConfig.LoadBalancerName = defaultConfig.ClusterName + "-lb"
 
+	// copy EC2 configurations, not to overwrite defaults shared with other etcd users
+	defaultConfig.ETCDNodes = etcdconfig.NewDefault()
+	etcdNodes, etcdBastionNodes := *defaultConfig.ETCDNodes.EC2, *defaultConfig.ETCDNodes.EC2Bastion
+	defaultConfig.ETCDNodes.EC2, defaultConfig.ETCDNodes.EC2Bastion = &etcdNodes, &etcdBastionNodes
+	defaultConfig.ETCDNodes.EC2.AWSRegion = defaultConfig.AWSRegion
+	defaultConfig.ETCDNodes.EC2.Tag = defaultConfig.Tag + "-etcd-nodes"
+	defaultConfig.ETCDNodes.EC2.ClusterName = defaultConfig.ClusterName + "-etcd-nodes"
+	defaultConfig.ETCDNodes.EC2Bastion.Tag = defaultConfig.Tag + "-etcd-bastion-nodes"
+	defaultConfig.ETCDNodes.EC2Bastion.ClusterName = defaultConfig.ClusterName + "-etcd-bastion-nodes"
 	defaultConfig.EC2MasterNodes.AWSRegion = defaultConfig.AWSRegion
 	defaultConfig.EC2MasterNodes.Tag = defaultConfig.Tag + "-master-nodes"
 	defaultConfig.EC2MasterNodes.ClusterName = defaultConfig.ClusterName + "-master-nodes"
@@ -145,6 +185,11 @@ func init() {
 	// keep in-sync with the default value in https://godoc.org/k8s.io/kubernetes/test/e2e/framework#GetSigner
 	defaultConfig.EC2MasterNodes.KeyPath = filepath.Join(homedir.HomeDir(), ".ssh", "kube_aws_rsa")
 	defaultConfig.EC2WorkerNodes.KeyPath = defaultConfig.EC2MasterNodes.KeyPath
+	defaultConfig.ETCDNodes.EC2.KeyPath = defaultConfig.EC2MasterNodes.KeyPath
+	defaultConfig.ETCDNodes.EC2Bastion.KeyPath = defaultConfig.EC2MasterNodes.KeyPath
+
+	// only used in "external" HA mode, to tolerate one member failure
+	defaultConfig.ETCDNodes.ClusterSize = 3
 
 	// use single node cluster for now
 	defaultConfig.EC2MasterNodes.ClusterSize = 1
@@ -177,14 +222,14 @@ func init() {
 	// ImageID:  "ami-032509850cf9ee54e"
 	// UserName: "ec2-user"
 	defaultConfig.EC2MasterNodes.Plugins = []string{
-		"update-amazon-linux-2",
-		"install-start-docker-amazon-linux-2",
-		"install-kubeadm-amazon-linux-2-" + kubeadmVer.String(),
+		"update",
+		"install-start-docker",
+		"install-kubeadm-" + kubeadmVer.String(),
 	}
 	defaultConfig.EC2WorkerNodes.Plugins = []string{
-		"update-amazon-linux-2",
-		"install-start-docker-amazon-linux-2",
-		"install-kubeadm-amazon-linux-2-" + kubeadmVer.String(),
+		"update",
+		"install-start-docker",
+		"install-kubeadm-" + kubeadmVer.String(),
 	}
 }
 
@@ -221,6 +266,8 @@ var defaultConfig = Config{
 	KubeadmInit: newDefaultKubeadmInit(),
 	KubeadmJoin: newDefaultKubeadmJoin(),
 
+	ContainerRuntime: osprofile.Docker,
+
 	LogDebug: false,
 	// default, stderr, stdout, or file name
 	// log file named with cluster name will be added automatically
@@ -308,12 +355,21 @@ const (
 	envPfxKubelet     = "AWS_K8S_TESTER_KUBEADM_KUBELET_"
 	envPfxKubeadmInit = "AWS_K8S_TESTER_KUBEADM_KUBEADM_INIT_"
 	envPfxKubeadmJoin = "AWS_K8S_TESTER_KUBEADM_KUBEADM_JOIN_"
+	envPfxETCDNodes   = "AWS_K8S_TESTER_KUBEADM_ETCD_NODES_"
 	envPfxMasterNodes = "AWS_K8S_TESTER_EC2_MASTER_NODES_"
 	envPfxWorkerNodes = "AWS_K8S_TESTER_EC2_WORKER_NODES_"
 )
 
 // UpdateFromEnvs updates fields from environmental variables.
 func (cfg *Config) UpdateFromEnvs() error {
+	// configurations before HA mode may not have etcd nodes
+	if cfg.ETCDNodes != nil {
+		cfg.ETCDNodes.EnvPrefix = envPfxETCDNodes
+		if err := cfg.ETCDNodes.UpdateFromEnvs(); err != nil {
+			return err
+		}
+	}
+
 	cfg.EC2MasterNodes.EnvPrefix = envPfxMasterNodes
 	if err := cfg.EC2MasterNodes.UpdateFromEnvs(); err != nil {
 		return err
@@ -446,66 +502,47 @@ func (cfg *Config) ValidateAndSetDefaults() (err error) {
 		}
 	}
 
-	okAMZLnx, okDocker, okKubeadm := false, false, false
-	for _, v := range cfg.EC2MasterNodes.Plugins {
-		if v == "update-amazon-linux-2" {
-			okAMZLnx = true
-			continue
-		}
-		if strings.HasPrefix(v, "install-start-docker-amazon-linux-2") {
-			okDocker = true
-			continue
-		}
-		if strings.HasPrefix(v, "install-kubeadm-amazon-linux-2-") {
-			okKubeadm = true
-			continue
-		}
+	if cfg.ContainerRuntime == "" {
+		cfg.ContainerRuntime = osprofile.Docker
 	}
-	if !okAMZLnx {
-		return errors.New("EC2MasterNodes Plugin 'update-amazon-linux-2' not found")
-	}
-	if !okDocker {
-		return errors.New("EC2MasterNodes Plugin 'install-start-docker-amazon-linux-2' not found")
+	rt, err := osprofile.GetContainerRuntime(cfg.ContainerRuntime)
+	if err != nil {
+		return err
 	}
-	if !okKubeadm {
-		return errors.New("EC2MasterNodes Plugin 'install-kubeadm-amazon-linux-2' not found")
+	if err = cfg.EC2MasterNodes.ValidatePlugins(rt, "install-kubeadm"); err != nil {
+		return fmt.Errorf("EC2MasterNodes %v", err)
 	}
-	okAMZLnx, okDocker, okKubeadm = false, false, false
-	for _, v := range cfg.EC2WorkerNodes.Plugins {
-		if v == "update-amazon-linux-2" {
-			okAMZLnx = true
-			continue
-		}
-		if strings.HasPrefix(v, "install-start-docker-amazon-linux-2") {
-			okDocker = true
-			continue
-		}
-		if strings.HasPrefix(v, "install-kubeadm-amazon-linux-2-") {
-			okKubeadm = true
-			continue
-		}
+	if err = cfg.EC2WorkerNodes.ValidatePlugins(rt, "install-kubeadm"); err != nil {
+		return fmt.Errorf("EC2WorkerNodes %v", err)
 	}
-	if !okAMZLnx {
-		return errors.New("EC2WorkerNodes Plugin 'update-amazon-linux-2' not found")
+	cfg.Kubelet.ContainerRuntime, cfg.Kubelet.ContainerRuntimeEndpoint = "", ""
+	if rt.Endpoint != "" {
+		cfg.Kubelet.ContainerRuntime = "remote"
+		cfg.Kubelet.ContainerRuntimeEndpoint = rt.Endpoint
 	}
-	if !okDocker {
-		return errors.New("EC2WorkerNodes Plugin 'install-start-docker-amazon-linux-2' not found")
+	if rt.CgroupDriver != "" {
+		cfg.Kubelet.CgroupDriver = rt.CgroupDriver
 	}
-	if !okKubeadm {
-		return errors.New("EC2WorkerNodes Plugin 'install-kubeadm-amazon-linux-2' not found")
+	cfg.KubeadmInit.CRISocket = rt.Endpoint
+	cfg.KubeadmJoin.CRISocket = rt.Endpoint
+
+	if err = cfg.validateHA(); err != nil {
+		return err
 	}
 
 	if !cfg.EC2MasterNodes.Wait {
 		return errors.New("Set EC2MasterNodes Wait to true")
 	}
-	if cfg.EC2MasterNodes.UserName != "ec2-user" {
-		return fmt.Errorf("EC2MasterNodes.UserName expected 'ec2-user' user name, got %q", cfg.EC2MasterNodes.UserName)
-	}
 	if !cfg.EC2WorkerNodes.Wait {
 		return errors.New("Set EC2WorkerNodes Wait to true")
 	}
-	if cfg.EC2WorkerNodes.UserName != "ec2-user" {
-		return fmt.Errorf("EC2WorkerNodes.UserName expected 'ec2-user' user name, got %q", cfg.EC2WorkerNodes.UserName)
+
+	// master and worker nodes share the kubelet configuration
+	if cfg.EC2MasterNodes.UserName != cfg.EC2WorkerNodes.UserName {
+		return fmt.Errorf("EC2MasterNodes.UserName %q does not match EC2WorkerNodes.UserName %q", cfg.EC2MasterNodes.UserName, cfg.EC2WorkerNodes.UserName)
+	}
+	if cfg.EC2MasterNodes.UserName != "" {
+		cfg.Kubelet.UserName = cfg.EC2MasterNodes.UserName
 	}
 
 	// to prevent "ValidationError: LoadBalancer name cannot be longer than 32 characters"
@@ -562,3 +599,100 @@ func randString(n int) string {
 	}
 	return string(b)
 }
+
+// Runtime returns the container runtime of the configuration.
+// It falls back to Docker if "ContainerRuntime" is invalid.
+func (cfg *Config) Runtime() *osprofile.ContainerRuntime {
+	rt, err := osprofile.GetContainerRuntime(cfg.ContainerRuntime)
+	if err != nil {
+		rt, _ = osprofile.GetContainerRuntime(osprofile.Docker)
+	}
+	return rt
+}
+
+// validateHA returns an error if the master nodes cannot tolerate a failure
+// in the HA mode, and sets up the external etcd cluster.
+func (cfg *Config) validateHA() (err error) {
+	if cfg.HAMode != HAModeExternal {
+		cfg.KubeadmInit.ExternalEtcdEndpoints = nil
+	}
+	switch cfg.HAMode {
+	case "":
+		return nil
+
+	case HAModeStacked:
+		// etcd quorum must survive one master node failure
+		if cfg.EC2MasterNodes.ClusterSize < 3 || cfg.EC2MasterNodes.ClusterSize%2 == 0 {
+			return fmt.Errorf("HAMode %q expects odd EC2MasterNodes.ClusterSize >= 3, got %d", cfg.HAMode, cfg.EC2MasterNodes.ClusterSize)
+		}
+
+	case HAModeExternal:
+		if cfg.EC2MasterNodes.ClusterSize < 2 {
+			return fmt.Errorf("HAMode %q expects EC2MasterNodes.ClusterSize >= 2, got %d", cfg.HAMode, cfg.EC2MasterNodes.ClusterSize)
+		}
+		if cfg.ETCDNodes == nil {
+			return errors.New("ETCDNodes configuration not found")
+		}
+		// share the same SSH key with master nodes
+		cfg.ETCDNodes.EC2.AWSRegion = cfg.AWSRegion
+		cfg.ETCDNodes.EC2.KeyName = cfg.EC2MasterNodes.KeyName
+		cfg.ETCDNodes.EC2.KeyPath = cfg.EC2MasterNodes.KeyPath
+		cfg.ETCDNodes.EC2.KeyCreateSkip = true
+		cfg.ETCDNodes.EC2.KeyCreated = false
+		cfg.ETCDNodes.EC2Bastion.AWSRegion = cfg.AWSRegion
+		cfg.ETCDNodes.EC2Bastion.KeyName = cfg.EC2MasterNodes.KeyName
+		cfg.ETCDNodes.EC2Bastion.KeyPath = cfg.EC2MasterNodes.KeyPath
+		cfg.ETCDNodes.EC2Bastion.KeyCreateSkip = true
+		cfg.ETCDNodes.EC2Bastion.KeyCreated = false
+		if err = cfg.ETCDNodes.ValidateAndSetDefaults(); err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("unknown HAMode %q (must be %q or %q)", cfg.HAMode, HAModeStacked, HAModeExternal)
+	}
+
+	var ver semver.Version
+	ver, err = kubeadmVersion(cfg.EC2MasterNodes)
+	if err != nil {
+		return err
+	}
+	if ver.LT(haMinVer) {
+		return fmt.Errorf("HAMode %q expects kubeadm >= %s, got %s", cfg.HAMode, haMinVer, ver)
+	}
+	return nil
+}
+
+// SetExternalEtcdEndpoints sets "kubeadm init" external etcd endpoints
+// to the client URLs of etcd nodes, in "external" HA mode.
+// Must be called after etcd nodes are created.
+func (cfg *Config) SetExternalEtcdEndpoints() error {
+	if cfg.HAMode != HAModeExternal {
+		return fmt.Errorf("HAMode %q does not use external etcd", cfg.HAMode)
+	}
+	eps := cfg.ETCDNodes.ClientURLs()
+	if len(eps) == 0 {
+		return errors.New("external etcd endpoints not found (etcd nodes not created?)")
+	}
+	sort.Strings(eps)
+	cfg.KubeadmInit.ExternalEtcdEndpoints = eps
+	return nil
+}
+
+// kubeadmVersion returns the version of the "install-kubeadm" plugin
+// (e.g. "1.15.0" for "install-kubeadm-amazon-linux-2-1.15.0").
+func kubeadmVersion(ec *ec2config.Config) (semver.Version, error) {
+	for _, v := range ec.Plugins {
+		if !strings.HasPrefix(v, "install-kubeadm-") {
+			continue
+		}
+		fields := strings.Split(v, "-")
+		for i, f := range fields {
+			if strings.Contains(f, ".") && f[0] >= '0' && f[0] <= '9' {
+				return semver.Make(strings.Join(fields[i:], "-"))
+			}
+		}
+		return semver.Version{}, fmt.Errorf("version not found in plugin %q", v)
+	}
+	return semver.Version{}, errors.New("Plugin 'install-kubeadm' not found")
+}
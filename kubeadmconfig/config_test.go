@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-k8s-tester/etcdconfig"
 	"sigs.k8s.io/yaml"
 )
 
@@ -100,3 +103,222 @@ func TestEnv(t *testing.T) {
 	}
 	fmt.Println(string(d))
 }
+
+func TestETCDNodesEnv(t *testing.T) {
+	cfg := NewDefault()
+	etcdCfg := *cfg.ETCDNodes
+	etcdNodes, etcdBastionNodes := *etcdCfg.EC2, *etcdCfg.EC2Bastion
+	etcdCfg.EC2, etcdCfg.EC2Bastion = &etcdNodes, &etcdBastionNodes
+	cfg.ETCDNodes = &etcdCfg
+
+	// etcd tester environmental variables must be ignored
+	os.Setenv("AWS_K8S_TESTER_ETCD_CLUSTER_NAME", "etcd-tester")
+	os.Setenv("AWS_K8S_TESTER_EC2_ETCD_NODES_CLUSTER_SIZE", "7")
+	os.Setenv("AWS_K8S_TESTER_KUBEADM_ETCD_NODES_CLUSTER_NAME", "kubeadm-etcd")
+	os.Setenv("AWS_K8S_TESTER_KUBEADM_ETCD_NODES_EC2_CLUSTER_SIZE", "5")
+	defer func() {
+		os.Unsetenv("AWS_K8S_TESTER_ETCD_CLUSTER_NAME")
+		os.Unsetenv("AWS_K8S_TESTER_EC2_ETCD_NODES_CLUSTER_SIZE")
+		os.Unsetenv("AWS_K8S_TESTER_KUBEADM_ETCD_NODES_CLUSTER_NAME")
+		os.Unsetenv("AWS_K8S_TESTER_KUBEADM_ETCD_NODES_EC2_CLUSTER_SIZE")
+	}()
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ETCDNodes.ClusterName != "kubeadm-etcd" {
+		t.Fatalf("ETCDNodes.ClusterName expected 'kubeadm-etcd', got %q", cfg.ETCDNodes.ClusterName)
+	}
+	if cfg.ETCDNodes.EC2.ClusterSize != 5 {
+		t.Fatalf("ETCDNodes.EC2.ClusterSize expected 5, got %d", cfg.ETCDNodes.EC2.ClusterSize)
+	}
+}
+
+func TestOSProfile(t *testing.T) {
+	cfg := NewDefault()
+	master, worker := *cfg.EC2MasterNodes, *cfg.EC2WorkerNodes
+	cfg.EC2MasterNodes, cfg.EC2WorkerNodes = &master, &worker
+	kubelet := *cfg.Kubelet
+	cfg.Kubelet = &kubelet
+	for _, ec := range []*ec2config.Config{cfg.EC2MasterNodes, cfg.EC2WorkerNodes} {
+		ec.OSProfile = "ubuntu"
+		ec.UserName = "ubuntu"
+		ec.Plugins = []string{"update", "install-start-docker", "install-kubeadm-1.13.1"}
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Kubelet.UserName != "ubuntu" {
+		t.Fatalf("unexpected Kubelet.UserName %q", cfg.Kubelet.UserName)
+	}
+
+	cfg.EC2WorkerNodes.Plugins = []string{"update", "install-kubeadm-1.13.1"}
+	if err := cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for missing container runtime plugin")
+	}
+
+	cfg.EC2WorkerNodes.OSProfile = "container-optimized"
+	cfg.EC2WorkerNodes.Plugins = []string{"install-kubeadm-1.13.1"}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestContainerRuntime(t *testing.T) {
+	cfg := NewDefault()
+	master, worker := *cfg.EC2MasterNodes, *cfg.EC2WorkerNodes
+	cfg.EC2MasterNodes, cfg.EC2WorkerNodes = &master, &worker
+	kubelet, kubeadmInit, kubeadmJoin := *cfg.Kubelet, *cfg.KubeadmInit, *cfg.KubeadmJoin
+	cfg.Kubelet, cfg.KubeadmInit, cfg.KubeadmJoin = &kubelet, &kubeadmInit, &kubeadmJoin
+	cfg.ContainerRuntime = "containerd"
+	if err := cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for missing containerd plugin")
+	}
+
+	for _, ec := range []*ec2config.Config{cfg.EC2MasterNodes, cfg.EC2WorkerNodes} {
+		ec.Plugins = []string{"update-amazon-linux-2", "install-start-containerd", "install-kubeadm-1.13.1"}
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	fs, err := cfg.Kubelet.Flags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	flags := strings.Join(fs, " ")
+	for _, f := range []string{
+		"--container-runtime=remote",
+		"--container-runtime-endpoint=unix:///run/containerd/containerd.sock",
+		"--cgroup-driver=systemd",
+	} {
+		if !strings.Contains(flags, f) {
+			t.Fatalf("expected %q in %q", f, flags)
+		}
+	}
+	fs, err = cfg.KubeadmInit.Flags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(strings.Join(fs, " "), "--cri-socket=unix:///run/containerd/containerd.sock") {
+		t.Fatalf("expected CRI socket in %v", fs)
+	}
+	if cfg.Runtime().Service != "containerd" {
+		t.Fatalf("unexpected runtime %+v", cfg.Runtime())
+	}
+
+	cfg.ContainerRuntime = "docker"
+	for _, ec := range []*ec2config.Config{cfg.EC2MasterNodes, cfg.EC2WorkerNodes} {
+		ec.Plugins = []string{"update-amazon-linux-2", "install-start-docker-amazon-linux-2", "install-kubeadm-1.13.1"}
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Kubelet.ContainerRuntime != "" || cfg.Kubelet.ContainerRuntimeEndpoint != "" || cfg.KubeadmInit.CRISocket != "" {
+		t.Fatalf("unexpected CRI configuration for docker %+v", cfg.Kubelet)
+	}
+
+	cfg.ContainerRuntime = "rkt"
+	if err := cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for unknown container runtime")
+	}
+}
+
+func TestHAMode(t *testing.T) {
+	cfg := NewDefault()
+	master, worker := *cfg.EC2MasterNodes, *cfg.EC2WorkerNodes
+	cfg.EC2MasterNodes, cfg.EC2WorkerNodes = &master, &worker
+	kubeadmInit := *cfg.KubeadmInit
+	cfg.KubeadmInit = &kubeadmInit
+	cfg.HAMode = HAModeStacked
+	cfg.EC2MasterNodes.ClusterSize = 3
+	if err := cfg.ValidateAndSetDefaults(); err == nil || !strings.Contains(err.Error(), "kubeadm >= 1.15.0") {
+		t.Fatalf("expected kubeadm version error, got %v", err)
+	}
+
+	for _, ec := range []*ec2config.Config{cfg.EC2MasterNodes, cfg.EC2WorkerNodes} {
+		ec.Plugins = []string{"update-amazon-linux-2", "install-start-docker-amazon-linux-2", "install-kubeadm-amazon-linux-2-1.15.0"}
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	cfg.EC2MasterNodes.ClusterSize = 2
+	if err := cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for even number of stacked master nodes")
+	}
+	cfg.HAMode = "ring"
+	if err := cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for unknown HA mode")
+	}
+
+	etcdNodes := *cfg.ETCDNodes
+	cfg.ETCDNodes = &etcdNodes
+	cfg.HAMode = HAModeExternal
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ETCDNodes.EC2.KeyName != cfg.EC2MasterNodes.KeyName || !cfg.ETCDNodes.EC2.KeyCreateSkip {
+		t.Fatalf("expected etcd nodes to share master node SSH key, got %+v", cfg.ETCDNodes.EC2)
+	}
+	if err := cfg.SetExternalEtcdEndpoints(); err == nil {
+		t.Fatal("expected error for external etcd endpoints before etcd nodes are created")
+	}
+	cfg.ETCDNodes.ClusterState = map[string]etcdconfig.ETCD{
+		"i-3": {AdvertiseClientURLs: "http://192.168.1.12:2379"},
+		"i-2": {AdvertiseClientURLs: "http://192.168.1.11:2379"},
+		"i-1": {AdvertiseClientURLs: "http://192.168.1.10:2379"},
+	}
+	if err := cfg.SetExternalEtcdEndpoints(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	cfg.KubeadmInit.ControlPlaneEndpoint = "internal-my-lb.us-west-2.elb.amazonaws.com:6443"
+	ext, err := cfg.KubeadmInit.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(ext, `etcd:
+  external:
+    endpoints:
+    - http://192.168.1.10:2379
+    - http://192.168.1.11:2379
+    - http://192.168.1.12:2379
+`) {
+		t.Fatalf("expected external etcd in %q", ext)
+	}
+
+	cfg.HAMode = HAModeStacked
+	cfg.EC2MasterNodes.ClusterSize = 3
+	cfg.KubeadmInit.ControlPlaneEndpoint = "internal-my-lb.us-west-2.elb.amazonaws.com:6443"
+	cfg.KubeadmInit.ExternalEtcdEndpoints = []string{"http://192.168.1.10:2379", "http://192.168.1.11:2379"}
+	s, err := cfg.KubeadmInit.Script("ec2-user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{
+		"controlPlaneEndpoint: internal-my-lb.us-west-2.elb.amazonaws.com:6443",
+		"bindPort: 6443",
+		"podSubnet: 10.244.0.0/16",
+		"    - http://192.168.1.11:2379",
+		"kubeadm init --config=/etc/kubernetes/kubeadm-config.yaml --upload-certs",
+	} {
+		if !strings.Contains(s, v) {
+			t.Fatalf("expected %q in %q", v, s)
+		}
+	}
+	d, err := cfg.KubeadmInit.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range strings.Split(d, "---\n") {
+		var m map[string]interface{}
+		if err = yaml.Unmarshal([]byte(doc), &m); err != nil {
+			t.Fatalf("invalid kubeadm config %q (%v)", doc, err)
+		}
+	}
+
+	if _, err = kubeadmVersion(&ec2config.Config{Plugins: []string{"install-kubeadm-1.15.0-rc.1"}}); err != nil {
+		t.Fatal(err)
+	}
+}
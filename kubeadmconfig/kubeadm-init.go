@@ -2,6 +2,7 @@ package kubeadmconfig
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
@@ -17,6 +18,14 @@ type KubeadmInit struct {
 	APIServerBindPort         int    `json:"apiserver-bind-port,omitempty" kubeadm-init:"apiserver-bind-port"`
 	PodNetworkCIDR            string `json:"pod-network-cidr,omitempty" kubeadm-init:"pod-network-cidr"`
 	ServiceCIDR               string `json:"service-cidr,omitempty" kubeadm-init:"service-cidr"`
+	// CRISocket is the CRI socket of the container runtime, empty for Docker.
+	CRISocket string `json:"cri-socket,omitempty" kubeadm-init:"cri-socket"`
+
+	// ControlPlaneEndpoint is the load balancer endpoint of the API servers
+	// in HA mode, shared by all master nodes. Set by the deployer.
+	ControlPlaneEndpoint string `json:"control-plane-endpoint,omitempty"` // read-only to user
+	// ExternalEtcdEndpoints is the list of etcd client URLs in "external" HA mode.
+	ExternalEtcdEndpoints []string `json:"external-etcd-endpoints,omitempty"` // read-only to user
 }
 
 var defaultKubeadmInit = KubeadmInit{
@@ -34,8 +43,24 @@ func newDefaultKubeadmInit() *KubeadmInit {
 	return &copied
 }
 
-// Script returns the service file setup script.
-func (ka *KubeadmInit) Script() (s string, err error) {
+// Script returns the service file setup script,
+// with the KUBECONFIG directory of the user.
+// In HA mode, "kubeadm init" runs with the configuration file
+// and uploads control plane certificates for extra master nodes.
+func (ka *KubeadmInit) Script(userName string) (s string, err error) {
+	if ka.ControlPlaneEndpoint != "" {
+		var cfg string
+		cfg, err = ka.Config()
+		if err != nil {
+			return "", err
+		}
+		return createScriptInit(scriptInit{
+			Exec:     "/usr/bin/kubeadm",
+			Config:   cfg,
+			UserName: userName,
+		})
+	}
+
 	var fs []string
 	fs, err = ka.Flags()
 	if err != nil {
@@ -44,10 +69,53 @@ func (ka *KubeadmInit) Script() (s string, err error) {
 	return createScriptInit(scriptInit{
 		Exec:     "/usr/bin/kubeadm",
 		Flags:    strings.Join(fs, " "),
-		UserName: "ec2-user",
+		UserName: userName,
 	})
 }
 
+// Config returns the "kubeadm init --config" configuration file,
+// with the control plane endpoint and external etcd endpoints.
+// https://kubernetes.io/docs/setup/production-environment/tools/kubeadm/high-availability/
+func (ka *KubeadmInit) Config() (string, error) {
+	if ka.ControlPlaneEndpoint == "" {
+		return "", errors.New("unknown control plane endpoint")
+	}
+	tpl := template.Must(template.New("kubeadmConfigTmpl").Parse(kubeadmConfigTmpl))
+	buf := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buf, ka); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+const kubeadmConfigTmpl = `apiVersion: kubeadm.k8s.io/v1beta2
+kind: InitConfiguration
+localAPIEndpoint:
+{{- if .APIServerAdvertiseAddress }}
+  advertiseAddress: {{ .APIServerAdvertiseAddress }}
+{{- end }}
+  bindPort: {{ .APIServerBindPort }}
+{{- if .CRISocket }}
+nodeRegistration:
+  criSocket: {{ .CRISocket }}
+{{- end }}
+---
+apiVersion: kubeadm.k8s.io/v1beta2
+kind: ClusterConfiguration
+controlPlaneEndpoint: {{ .ControlPlaneEndpoint }}
+networking:
+  podSubnet: {{ .PodNetworkCIDR }}
+  serviceSubnet: {{ .ServiceCIDR }}
+{{- if .ExternalEtcdEndpoints }}
+etcd:
+  external:
+    endpoints:
+{{- range .ExternalEtcdEndpoints }}
+    - {{ . }}
+{{- end }}
+{{- end }}
+`
+
 func createScriptInit(si scriptInit) (string, error) {
 	tpl := template.Must(template.New("scriptInitTmpl").Parse(scriptInitTmpl))
 	buf := bytes.NewBuffer(nil)
@@ -60,6 +128,7 @@ func createScriptInit(si scriptInit) (string, error) {
 type scriptInit struct {
 	Exec     string
 	Flags    string
+	Config   string
 	UserName string
 }
 
@@ -67,16 +136,21 @@ type scriptInit struct {
 const scriptInitTmpl = `#!/usr/bin/env bash
 
 sudo touch /var/log/kubeadm-init.log
-sudo mkdir -p /home/ec2-user/.kube
+sudo mkdir -p /home/{{ .UserName }}/.kube
 sudo mkdir -p /etc/kubernetes/pki/
-sudo kubeadm init {{ .Flags }} 1>>/var/log/kubeadm-init.log 2>&1
-`
+{{ if .Config }}
+cat <<EOF > /tmp/kubeadm-config.yaml
+{{ .Config }}EOF
+sudo cp /tmp/kubeadm-config.yaml /etc/kubernetes/kubeadm-config.yaml
+sudo kubeadm init --config=/etc/kubernetes/kubeadm-config.yaml --upload-certs 1>>/var/log/kubeadm-init.log 2>&1
+{{ else }}sudo kubeadm init {{ .Flags }} 1>>/var/log/kubeadm-init.log 2>&1
+{{ end }}`
 
 /*
-sudo cp -i /etc/kubernetes/admin.conf /home/ec2-user/.kube/config
-sudo chown $(id -u):$(id -g) /home/ec2-user/.kube/config
-sudo chown {{ .UserName }}:{{ .UserName }} /home/ec2-user/.kube/config
-find /home/ec2-user/.kube/ 1>>/var/log/kubeadm-init.log 2>&1
+sudo cp -i /etc/kubernetes/admin.conf /home/{{ .UserName }}/.kube/config
+sudo chown $(id -u):$(id -g) /home/{{ .UserName }}/.kube/config
+sudo chown {{ .UserName }}:{{ .UserName }} /home/{{ .UserName }}/.kube/config
+find /home/{{ .UserName }}/.kube/ 1>>/var/log/kubeadm-init.log 2>&1
 */
 
 // Flags returns the list of "kubeadm init" flags.
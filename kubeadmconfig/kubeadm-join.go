@@ -17,6 +17,12 @@ type KubeadmJoin struct {
 	Token                    string `json:"token,omitempty" kubeadm-join:"token"`
 	DiscoveryTokenCACertHash string `json:"discovery-token-ca-cert-hash,omitempty" kubeadm-join:"discovery-token-ca-cert-hash"`
 	IgnorePreflightErrors    string `json:"ignore-preflight-errors,omitempty" kubeadm-join:"ignore-preflight-errors"`
+	// CRISocket is the CRI socket of the container runtime, empty for Docker.
+	CRISocket string `json:"cri-socket,omitempty" kubeadm-join:"cri-socket"`
+
+	// CertificateKey is the key to decrypt the control plane certificates
+	// uploaded by "kubeadm init --upload-certs", to join extra master nodes in HA mode.
+	CertificateKey string `json:"certificate-key,omitempty"` // read-only to user
 }
 
 var defaultKubeadmJoin = KubeadmJoin{
@@ -80,6 +86,54 @@ func (ka *KubeadmJoin) Command() (cmd string, err error) {
 	return cmd, nil
 }
 
+// ControlPlaneCommand returns the "kubeadm join" command for extra master nodes,
+// which downloads the control plane certificates with the certificate key.
+func (ka *KubeadmJoin) ControlPlaneCommand() (cmd string, err error) {
+	if ka.CertificateKey == "" {
+		return "", errors.New("unknown 'kubeadm join' certificate key")
+	}
+	cmd, err = ka.Command()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s --control-plane --certificate-key=%s", cmd, ka.CertificateKey), nil
+}
+
+// ParseInitOutput parses "kubeadm join" commands from "kubeadm init" output,
+// and updates the raw command, token, discovery token CA cert hash,
+// and certificate key if printed with "--upload-certs".
+// Commands continued over multiple lines with a trailing backslash are joined.
+func (ka *KubeadmJoin) ParseInitOutput(output string) error {
+	output = strings.Replace(output, "\\\n", " ", -1)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.Contains(line, "kubeadm join ") ||
+			!strings.Contains(line, "--token") ||
+			!strings.Contains(line, "--discovery-token-ca-cert-hash") {
+			continue
+		}
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields)-1; i++ {
+			switch fields[i] {
+			case "--token":
+				ka.Token = fields[i+1]
+			case "--discovery-token-ca-cert-hash":
+				ka.DiscoveryTokenCACertHash = fields[i+1]
+			case "--certificate-key":
+				ka.CertificateKey = fields[i+1]
+			}
+		}
+		// worker node command, without "--control-plane"
+		if !strings.Contains(line, "--control-plane") {
+			ka.RawCommand = strings.Join(fields, " ")
+		}
+	}
+	if ka.RawCommand == "" || ka.Token == "" || ka.DiscoveryTokenCACertHash == "" {
+		return errors.New("'kubeadm join' command not found")
+	}
+	return nil
+}
+
 func (ka *KubeadmJoin) updateFromEnvs(pfx string) error {
 	cc := *ka
 	tp, vv := reflect.TypeOf(&cc).Elem(), reflect.ValueOf(&cc).Elem()
@@ -0,0 +1,55 @@
+package kubeadmconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseInitOutput(t *testing.T) {
+	// kubeadm 1.13
+	ka := newDefaultKubeadmJoin()
+	if err := ka.ParseInitOutput(`Your Kubernetes master has initialized successfully!
+
+  kubeadm join 192.168.1.10:6443 --token abcdef.0123456789abcdef --discovery-token-ca-cert-hash sha256:aaa
+`); err != nil {
+		t.Fatal(err)
+	}
+	if ka.Token != "abcdef.0123456789abcdef" || ka.DiscoveryTokenCACertHash != "sha256:aaa" || ka.CertificateKey != "" {
+		t.Fatalf("unexpected kubeadm join %+v", ka)
+	}
+
+	// kubeadm 1.15 with "--upload-certs"
+	ka = newDefaultKubeadmJoin()
+	if err := ka.ParseInitOutput(`You can now join any number of the control-plane node running the following command on each as root:
+
+  kubeadm join my-lb:6443 --token abcdef.0123456789abcdef \
+    --discovery-token-ca-cert-hash sha256:bbb \
+    --control-plane --certificate-key ccc
+
+Then you can join any number of worker nodes by running the following on each as root:
+
+kubeadm join my-lb:6443 --token abcdef.0123456789abcdef \
+    --discovery-token-ca-cert-hash sha256:bbb
+`); err != nil {
+		t.Fatal(err)
+	}
+	if ka.Token != "abcdef.0123456789abcdef" || ka.DiscoveryTokenCACertHash != "sha256:bbb" || ka.CertificateKey != "ccc" {
+		t.Fatalf("unexpected kubeadm join %+v", ka)
+	}
+	if strings.Contains(ka.RawCommand, "--control-plane") {
+		t.Fatalf("unexpected control plane command %q", ka.RawCommand)
+	}
+
+	ka.Target = "my-lb:6443"
+	cmd, err := ka.ControlPlaneCommand()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(cmd, "--control-plane --certificate-key=ccc") {
+		t.Fatalf("unexpected control plane command %q", cmd)
+	}
+
+	if err = newDefaultKubeadmJoin().ParseInitOutput("[init] Using Kubernetes version: v1.15.0"); err == nil {
+		t.Fatal("expected error")
+	}
+}
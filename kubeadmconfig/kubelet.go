@@ -24,6 +24,11 @@ type Kubelet struct {
 	ClientCAFile        string `json:"client-ca-file,omitempty" kubelet:"client-ca-file"`
 	CloudProvider       string `json:"cloud-provider,omitempty" kubelet:"cloud-provider"`
 
+	// ContainerRuntime is "remote" for CRI runtimes, or empty for dockershim.
+	ContainerRuntime string `json:"container-runtime,omitempty" kubelet:"container-runtime"`
+	// ContainerRuntimeEndpoint is the CRI socket of the "remote" container runtime.
+	ContainerRuntimeEndpoint string `json:"container-runtime-endpoint,omitempty" kubelet:"container-runtime-endpoint"`
+
 	// ClusterDNS is a comma-separated list of DNS server IP addresses.
 	// See https://kubernetes.io/docs/tasks/administer-cluster/dns-custom-nameservers/#introduction for more detail.
 	ClusterDNS string `json:"cluster-dns,omitempty" kubelet:"cluster-dns"`
@@ -4,13 +4,28 @@ package plugins
 import (
 	"bytes"
 	"text/template"
+
+	"github.com/aws/aws-k8s-tester/ec2config/osprofile"
 )
 
-// CreateInstall creates kubeadm install script.
-func CreateInstall(ver string) (string, error) {
-	tpl := template.Must(template.New("installKubeadmAmazonLinux2Template").Parse(installKubeadmAmazonLinux2Template))
+// CreateInstall creates kubeadm install script for the OS profile,
+// with kubelet started after the container runtime.
+// The architecture is either "amd64" or "arm64".
+func CreateInstall(p *osprofile.Profile, rt *osprofile.ContainerRuntime, ver, arch string) (string, error) {
+	deps, err := p.KubernetesDependencies(arch)
+	if err != nil {
+		return "", err
+	}
+	tpl := template.Must(template.New("installKubeadmTemplate").Parse(installKubeadmTemplate))
 	buf := bytes.NewBuffer(nil)
-	kv := kubeadmInfo{Version: ver}
+	kv := kubeadmInfo{
+		OSProfile:      p.Name,
+		Dependencies:   deps,
+		SystemdUnitDir: p.SystemdUnitDir,
+		RuntimeService: rt.Service,
+		Version:        ver,
+		Arch:           arch,
+	}
 	if err := tpl.Execute(buf, kv); err != nil {
 		return "", err
 	}
@@ -18,46 +33,26 @@ func CreateInstall(ver string) (string, error) {
 }
 
 type kubeadmInfo struct {
-	Version string
+	OSProfile      string
+	Dependencies   string
+	SystemdUnitDir string
+	RuntimeService string
+	Version        string
+	Arch           string
 }
 
 // https://kubernetes.io/docs/setup/independent/install-kubeadm/
-const installKubeadmAmazonLinux2Template = `
-
-################################## install kubeadm on Amazon Linux 2
-
-cat <<EOF > /tmp/kubernetes.repo
-[kubernetes]
-name=Kubernetes
-baseurl=https://packages.cloud.google.com/yum/repos/kubernetes-el7-x86_64
-enabled=1
-gpgcheck=1
-repo_gpgcheck=0
-gpgkey=https://packages.cloud.google.com/yum/doc/yum-key.gpg https://packages.cloud.google.com/yum/doc/rpm-package-key.gpg
-exclude=kube*
-EOF
-sudo cp /tmp/kubernetes.repo /etc/yum.repos.d/kubernetes.repo
-
-cat <<EOF > /tmp/k8s.conf
-net.bridge.bridge-nf-call-ip6tables = 1
-net.bridge.bridge-nf-call-iptables = 1
-EOF
-sudo cp /tmp/k8s.conf /etc/sysctl.d/k8s.conf
-
-sudo sysctl --system
-sudo sysctl net.bridge.bridge-nf-call-iptables=1
+const installKubeadmTemplate = `
 
-# Set SELinux in permissive mode (effectively disabling it)
-setenforce 0
-sudo sed -i 's/^SELINUX=enforcing$/SELINUX=permissive/' /etc/selinux/config
+################################## install kubeadm on {{ .OSProfile }}
 
-sudo yum install -y cri-tools ebtables kubernetes-cni socat iproute-tc
+{{ .Dependencies }}
 
 RELEASE=v{{ .Version }}
 
 cd /usr/bin
 sudo rm -f /usr/bin/{kubeadm,kubelet,kubectl}
-sudo curl -L --remote-name-all https://storage.googleapis.com/kubernetes-release/release/${RELEASE}/bin/linux/amd64/{kubeadm,kubelet,kubectl}
+sudo curl -L --remote-name-all https://storage.googleapis.com/kubernetes-release/release/${RELEASE}/bin/linux/{{ .Arch }}/{kubeadm,kubelet,kubectl}
 sudo chmod +x {kubeadm,kubelet,kubectl}
 cd ${HOME}
 
@@ -72,7 +67,7 @@ cat <<EOF > /tmp/kubelet.service
 [Unit]
 Description=kubelet
 Documentation=http://kubernetes.io/docs/
-After=docker.service
+After={{ .RuntimeService }}.service
 
 [Service]
 EnvironmentFile=/etc/sysconfig/kubelet
@@ -88,9 +83,9 @@ WantedBy=multi-user.target
 EOF
 cat /tmp/kubelet.service
 
-sudo rm -f /etc/systemd/system/kubelet.service.d/10-kubeadm.conf
-sudo mkdir -p /etc/systemd/system/kubelet.service.d
-sudo cp /tmp/kubelet.service /etc/systemd/system/kubelet.service
+sudo rm -f {{ .SystemdUnitDir }}/kubelet.service.d/10-kubeadm.conf
+sudo mkdir -p {{ .SystemdUnitDir }}/kubelet.service.d
+sudo cp /tmp/kubelet.service {{ .SystemdUnitDir }}/kubelet.service
 
 sudo systemctl daemon-reload
 sudo systemctl stop kubelet.service || true
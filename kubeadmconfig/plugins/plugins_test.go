@@ -2,13 +2,54 @@ package plugins
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/aws/aws-k8s-tester/ec2config/osprofile"
 )
 
 func TestCreateInstallStart(t *testing.T) {
-	s1, err := CreateInstall("1.13.1")
+	p, err := osprofile.Get(osprofile.AmazonLinux2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt, err := osprofile.GetContainerRuntime(osprofile.Docker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1, err := CreateInstall(p, rt, "1.13.1", "arm64")
 	if err != nil {
 		t.Fatal(err)
 	}
+	if !strings.Contains(s1, "kubernetes-el7-aarch64") || !strings.Contains(s1, "/bin/linux/arm64/") {
+		t.Fatalf("unexpected arm64 install script %q", s1)
+	}
 	fmt.Println(s1)
+
+	p, err = osprofile.Get(osprofile.Ubuntu)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt, err = osprofile.GetContainerRuntime(osprofile.Containerd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := CreateInstall(p, rt, "1.13.1", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(s2, "sudo apt-get install -y cri-tools") || strings.Contains(s2, "yum") {
+		t.Fatalf("unexpected Ubuntu install script %q", s2)
+	}
+	if !strings.Contains(s2, "After=containerd.service") {
+		t.Fatalf("expected kubelet after containerd in %q", s2)
+	}
+
+	p, err = osprofile.Get(osprofile.Custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = CreateInstall(p, rt, "1.13.1", "amd64"); err == nil {
+		t.Fatal("expected error for custom OS profile")
+	}
 }
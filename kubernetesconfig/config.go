@@ -9,13 +9,16 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-k8s-tester/ec2config/osprofile"
 	"github.com/aws/aws-k8s-tester/etcdconfig"
+	ec2types "github.com/aws/aws-k8s-tester/pkg/awsapi/ec2"
 	"k8s.io/client-go/util/homedir"
 	"sigs.k8s.io/yaml"
 )
@@ -61,6 +64,12 @@ type Config struct {
 	KubeScheduler          *KubeScheduler          `json:"kube-scheduler"`
 	CloudControllerManager *CloudControllerManager `json:"cloud-controller-manager"`
 
+	// ContainerRuntime is the container runtime of master and worker nodes,
+	// "docker", "containerd", or "cri-o". EC2 plugins must install it
+	// (e.g. "install-start-containerd"), unless the OS profile ships with one.
+	// It sets kubelet CRI endpoint and cgroup driver.
+	ContainerRuntime string `json:"container-runtime,omitempty"`
+
 	// ConfigPath is the configuration file path.
 	// Must be left empty, and let deployer auto-populate this field.
 	// Deployer is expected to update this file with latest status,
@@ -150,6 +159,9 @@ func init() {
 	if runtime.GOOS == "darwin" {
 		defaultConfig.AWSK8sTesterDownloadURL = strings.Replace(defaultConfig.AWSK8sTesterDownloadURL, "linux", "darwin", -1)
 	}
+	if runtime.GOARCH == "arm64" {
+		defaultConfig.AWSK8sTesterDownloadURL = strings.Replace(defaultConfig.AWSK8sTesterDownloadURL, "amd64", "arm64", -1)
+	}
 
 	defaultConfig.Tag = genTag()
 	defaultConfig.ClusterName = defaultConfig.Tag + "-" + randString(5)
@@ -201,14 +213,14 @@ func init() {
 	// ImageID:  "ami-032509850cf9ee54e"
 	// UserName: "ec2-user"
 	defaultConfig.EC2MasterNodes.Plugins = []string{
-		"update-amazon-linux-2",
-		"install-start-docker-amazon-linux-2",
-		"install-kubernetes-amazon-linux-2",
+		"update",
+		"install-start-docker",
+		"install-kubernetes",
 	}
 	defaultConfig.EC2WorkerNodes.Plugins = []string{
-		"update-amazon-linux-2",
-		"install-start-docker-amazon-linux-2",
-		"install-kubernetes-amazon-linux-2",
+		"update",
+		"install-start-docker",
+		"install-kubernetes",
 	}
 }
 
@@ -253,6 +265,8 @@ var defaultConfig = Config{
 	KubeScheduler:          newDefaultKubeScheduler(),
 	CloudControllerManager: newDefaultCloudControllerManager(),
 
+	ContainerRuntime: osprofile.Docker,
+
 	LogDebug: false,
 	// default, stderr, stdout, or file name
 	// log file named with cluster name will be added automatically
@@ -547,67 +561,42 @@ func (cfg *Config) ValidateAndSetDefaults() (err error) {
 		}
 	}
 
-	okAMZLnx, okDocker, okKubernetes := false, false, false
-	for _, v := range cfg.EC2MasterNodes.Plugins {
-		if v == "update-amazon-linux-2" {
-			okAMZLnx = true
-			continue
-		}
-		if strings.HasPrefix(v, "install-start-docker-amazon-linux-2") {
-			okDocker = true
-			continue
-		}
-		if strings.HasPrefix(v, "install-kubernetes-amazon-linux-2") {
-			okKubernetes = true
-			continue
-		}
+	if cfg.ContainerRuntime == "" {
+		cfg.ContainerRuntime = osprofile.Docker
 	}
-	if !okAMZLnx {
-		return errors.New("EC2MasterNodes Plugin 'update-amazon-linux-2' not found")
+	rt, err := osprofile.GetContainerRuntime(cfg.ContainerRuntime)
+	if err != nil {
+		return err
 	}
-	if !okDocker {
-		return errors.New("EC2MasterNodes Plugin 'install-start-docker-amazon-linux-2' not found")
+	if err = cfg.EC2MasterNodes.ValidatePlugins(rt, "install-kubernetes"); err != nil {
+		return fmt.Errorf("EC2MasterNodes %v", err)
 	}
-	if !okKubernetes {
-		return errors.New("EC2MasterNodes Plugin 'install-kubernetes-amazon-linux-2' not found")
+	if err = cfg.EC2WorkerNodes.ValidatePlugins(rt, "install-kubernetes"); err != nil {
+		return fmt.Errorf("EC2WorkerNodes %v", err)
 	}
-	okAMZLnx, okDocker, okKubernetes = false, false, false
-	for _, v := range cfg.EC2WorkerNodes.Plugins {
-		if v == "update-amazon-linux-2" {
-			okAMZLnx = true
-			continue
-		}
-		if strings.HasPrefix(v, "install-start-docker-amazon-linux-2") {
-			okDocker = true
-			continue
+	for _, kb := range []*Kubelet{cfg.KubeletMasterNodes, cfg.KubeletWorkerNodes} {
+		kb.ContainerRuntimeService = rt.Service
+		// reset for docker, which is installed with the kubelet default "cgroupfs"
+		kb.ContainerRuntime, kb.ContainerRuntimeEndpoint, kb.CgroupDriver = "", "", rt.CgroupDriver
+		if rt.Endpoint != "" {
+			kb.ContainerRuntime = "remote"
+			kb.ContainerRuntimeEndpoint = rt.Endpoint
 		}
-		if strings.HasPrefix(v, "install-kubernetes-amazon-linux-2") {
-			okKubernetes = true
-			continue
-		}
-	}
-	if !okAMZLnx {
-		return errors.New("EC2WorkerNodes Plugin 'update-amazon-linux-2' not found")
-	}
-	if !okDocker {
-		return errors.New("EC2WorkerNodes Plugin 'install-start-docker-amazon-linux-2' not found")
-	}
-	if !okKubernetes {
-		return errors.New("EC2MasterNodes Plugin 'install-kubernetes-amazon-linux-2' not found")
 	}
 
 	if !cfg.EC2MasterNodes.Wait {
 		return errors.New("Set EC2MasterNodes Wait to true")
 	}
-	if cfg.EC2MasterNodes.UserName != "ec2-user" {
-		return fmt.Errorf("EC2MasterNodes.UserName expected 'ec2-user' user name, got %q", cfg.EC2MasterNodes.UserName)
-	}
 	if !cfg.EC2WorkerNodes.Wait {
 		return errors.New("Set EC2WorkerNodes Wait to true")
 	}
-	if cfg.EC2WorkerNodes.UserName != "ec2-user" {
-		return fmt.Errorf("EC2WorkerNodes.UserName expected 'ec2-user' user name, got %q", cfg.EC2WorkerNodes.UserName)
+
+	// master and worker nodes share the kubectl download
+	arch := ec2types.GoArch(cfg.EC2MasterNodes.InstanceType)
+	if wa := ec2types.GoArch(cfg.EC2WorkerNodes.InstanceType); arch != wa {
+		return fmt.Errorf("EC2MasterNodes architecture %q does not match EC2WorkerNodes architecture %q", arch, wa)
 	}
+	cfg.setArch(arch)
 
 	// to prevent "ValidationError: LoadBalancer name cannot be longer than 32 characters"
 	if len(cfg.LoadBalancerName) > 31 {
@@ -652,6 +641,28 @@ func (cfg *Config) ValidateAndSetDefaults() (err error) {
 	return cfg.Sync()
 }
 
+var archRegex = regexp.MustCompile(`(/linux/|pause-)(amd64|arm64)`)
+
+// setArch updates the architecture of Kubernetes release downloads
+// (e.g. ".../bin/linux/amd64/kubelet") and pause container images.
+func (cfg *Config) setArch(arch string) {
+	for _, p := range []*string{
+		&cfg.KubeletMasterNodes.DownloadURL,
+		&cfg.KubeletMasterNodes.PodInfraContainerImage,
+		&cfg.KubeletWorkerNodes.DownloadURL,
+		&cfg.KubeletWorkerNodes.PodInfraContainerImage,
+		&cfg.KubeProxyMasterNodes.DownloadURL,
+		&cfg.KubeProxyWorkerNodes.DownloadURL,
+		&cfg.Kubectl.DownloadURL,
+		&cfg.KubeAPIServer.DownloadURL,
+		&cfg.KubeControllerManager.DownloadURL,
+		&cfg.KubeScheduler.DownloadURL,
+		&cfg.CloudControllerManager.DownloadURL,
+	} {
+		*p = archRegex.ReplaceAllString(*p, "${1}"+arch)
+	}
+}
+
 const ll = "0123456789abcdefghijklmnopqrstuvwxyz"
 
 func randString(n int) string {
@@ -772,3 +783,13 @@ func (cfg *Config) DownloadsWorker() (ds []Download) {
 		},
 	}
 }
+
+// Runtime returns the container runtime of the configuration.
+// It falls back to Docker if "ContainerRuntime" is invalid.
+func (cfg *Config) Runtime() *osprofile.ContainerRuntime {
+	rt, err := osprofile.GetContainerRuntime(cfg.ContainerRuntime)
+	if err != nil {
+		rt, _ = osprofile.GetContainerRuntime(osprofile.Docker)
+	}
+	return rt
+}
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-k8s-tester/ec2config"
 	"sigs.k8s.io/yaml"
 )
 
@@ -215,3 +217,81 @@ func TestEnv(t *testing.T) {
 	}
 	fmt.Println(string(d))
 }
+
+func TestSetArch(t *testing.T) {
+	cfg := NewDefault()
+	cfg.setArch("arm64")
+	if !strings.HasSuffix(cfg.KubeletWorkerNodes.DownloadURL, "/bin/linux/arm64/kubelet") {
+		t.Fatalf("unexpected kubelet download URL %q", cfg.KubeletWorkerNodes.DownloadURL)
+	}
+	if cfg.KubeletWorkerNodes.PodInfraContainerImage != "k8s.gcr.io/pause-arm64:3.0" {
+		t.Fatalf("unexpected pod infra container image %q", cfg.KubeletWorkerNodes.PodInfraContainerImage)
+	}
+	for _, d := range append(cfg.DownloadsMaster(), cfg.DownloadsWorker()...) {
+		if strings.Contains(d.DownloadURL, "amd64") {
+			t.Fatalf("unexpected amd64 download URL %q", d.DownloadURL)
+		}
+	}
+	cfg.setArch("amd64")
+	if !strings.HasSuffix(cfg.Kubectl.DownloadURL, "/bin/linux/amd64/kubectl") {
+		t.Fatalf("unexpected kubectl download URL %q", cfg.Kubectl.DownloadURL)
+	}
+}
+
+func TestContainerRuntime(t *testing.T) {
+	cfg := NewDefault()
+	master, worker := *cfg.EC2MasterNodes, *cfg.EC2WorkerNodes
+	cfg.EC2MasterNodes, cfg.EC2WorkerNodes = &master, &worker
+	kubeletMaster, kubeletWorker := *cfg.KubeletMasterNodes, *cfg.KubeletWorkerNodes
+	cfg.KubeletMasterNodes, cfg.KubeletWorkerNodes = &kubeletMaster, &kubeletWorker
+	cfg.ContainerRuntime = "cri-o"
+	for _, ec := range []*ec2config.Config{cfg.EC2MasterNodes, cfg.EC2WorkerNodes} {
+		ec.Plugins = []string{"update-amazon-linux-2", "install-start-cri-o-1.13", "install-kubernetes-amazon-linux-2"}
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	fs, err := cfg.KubeletWorkerNodes.Flags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	flags := strings.Join(fs, " ")
+	for _, f := range []string{
+		"--container-runtime=remote",
+		"--container-runtime-endpoint=unix:///var/run/crio/crio.sock",
+		"--cgroup-driver=systemd",
+	} {
+		if !strings.Contains(flags, f) {
+			t.Fatalf("expected %q in %q", f, flags)
+		}
+	}
+	svc, err := cfg.KubeletMasterNodes.Service()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(svc, "After=crio.service") {
+		t.Fatalf("expected kubelet after crio in %q", svc)
+	}
+
+	cfg.EC2WorkerNodes.Plugins = []string{"update-amazon-linux-2", "install-start-docker-amazon-linux-2", "install-kubernetes-amazon-linux-2"}
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for missing cri-o plugin")
+	}
+
+	// switching back to docker resets CRI flags and cgroup driver
+	cfg.ContainerRuntime = "docker"
+	cfg.EC2MasterNodes.Plugins = cfg.EC2WorkerNodes.Plugins
+	if err = cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	fs, err = cfg.KubeletWorkerNodes.Flags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	flags = strings.Join(fs, " ")
+	for _, f := range []string{"--container-runtime", "--cgroup-driver"} {
+		if strings.Contains(flags, f) {
+			t.Fatalf("unexpected %q in %q", f, flags)
+		}
+	}
+}
@@ -18,6 +18,16 @@ type Kubelet struct {
 
 	// UserName is the user name used for running init scripts or SSH access.
 	UserName string `json:"user-name,omitempty"`
+	// ContainerRuntimeService is the systemd service of the container runtime,
+	// to start kubelet after.
+	ContainerRuntimeService string `json:"container-runtime-service,omitempty"` // read-only to user
+
+	// CgroupDriver is the cgroup driver that the container runtime is configured with.
+	CgroupDriver string `json:"cgroup-driver,omitempty" kubelet:"cgroup-driver"`
+	// ContainerRuntime is "remote" for CRI runtimes, or empty for dockershim.
+	ContainerRuntime string `json:"container-runtime,omitempty" kubelet:"container-runtime"`
+	// ContainerRuntimeEndpoint is the CRI socket of the "remote" container runtime.
+	ContainerRuntimeEndpoint string `json:"container-runtime-endpoint,omitempty" kubelet:"container-runtime-endpoint"`
 
 	AllowPrivileged bool   `json:"allow-privileged" kubelet:"allow-privileged"`
 	AnonymousAuth   bool   `json:"anonymous-auth" kubelet:"anonymous-auth"`
@@ -187,7 +197,10 @@ func (kb *Kubelet) updateFromEnvs(pfx string) error {
 func (kb *Kubelet) Service() (s string, err error) {
 	tpl := template.Must(template.New("kubeletTemplate").Parse(kubeletTemplate))
 	buf := bytes.NewBuffer(nil)
-	kv := kubeletTemplateInfo{KubeletPath: kb.Path}
+	kv := kubeletTemplateInfo{KubeletPath: kb.Path, ContainerRuntimeService: kb.ContainerRuntimeService}
+	if kv.ContainerRuntimeService == "" {
+		kv.ContainerRuntimeService = "docker"
+	}
 	if err := tpl.Execute(buf, kv); err != nil {
 		return "", err
 	}
@@ -195,7 +208,8 @@ func (kb *Kubelet) Service() (s string, err error) {
 }
 
 type kubeletTemplateInfo struct {
-	KubeletPath string
+	KubeletPath             string
+	ContainerRuntimeService string
 }
 
 const kubeletTemplate = `#!/usr/bin/env bash
@@ -209,7 +223,7 @@ cat <<EOF > /tmp/kubelet.service
 [Unit]
 Description=kubelet: The Kubernetes Node Agent
 Documentation=http://kubernetes.io/docs/
-After=docker.service
+After={{ .ContainerRuntimeService }}.service
 
 [Service]
 EnvironmentFile=/etc/sysconfig/kubelet
@@ -1,40 +1,39 @@
 // Package plugins implements Kubernetes plugins.
 package plugins
 
-// CreateInstall creates Kubernetes install script.
-func CreateInstall() string {
-	return installKubernetesAmazonLinux2Template
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/aws/aws-k8s-tester/ec2config/osprofile"
+)
+
+// CreateInstall creates Kubernetes install script for the OS profile.
+// The architecture is either "amd64" or "arm64".
+func CreateInstall(p *osprofile.Profile, arch string) (string, error) {
+	deps, err := p.KubernetesDependencies(arch)
+	if err != nil {
+		return "", err
+	}
+	tpl := template.Must(template.New("installKubernetesTemplate").Parse(installKubernetesTemplate))
+	buf := bytes.NewBuffer(nil)
+	kv := kubernetesInfo{OSProfile: p.Name, Dependencies: deps}
+	if err := tpl.Execute(buf, kv); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
 }
 
-const installKubernetesAmazonLinux2Template = `
-
-################################## install Kubernetes on Amazon Linux 2
-
-cat <<EOF > /tmp/kubernetes.repo
-[kubernetes]
-name=Kubernetes
-baseurl=https://packages.cloud.google.com/yum/repos/kubernetes-el7-x86_64
-enabled=1
-gpgcheck=1
-repo_gpgcheck=0
-gpgkey=https://packages.cloud.google.com/yum/doc/yum-key.gpg https://packages.cloud.google.com/yum/doc/rpm-package-key.gpg
-exclude=kube*
-EOF
-sudo cp /tmp/kubernetes.repo /etc/yum.repos.d/kubernetes.repo
-
-cat <<EOF > /tmp/k8s.conf
-net.bridge.bridge-nf-call-ip6tables = 1
-net.bridge.bridge-nf-call-iptables = 1
-EOF
-sudo cp /tmp/k8s.conf /etc/sysctl.d/k8s.conf
-sudo sysctl --system
-sudo sysctl net.bridge.bridge-nf-call-iptables=1
-
-# Set SELinux in permissive mode (effectively disabling it)
-setenforce 0
-sudo sed -i 's/^SELINUX=enforcing$/SELINUX=permissive/' /etc/selinux/config
-
-sudo yum install -y cri-tools ebtables kubernetes-cni socat iproute-tc
+type kubernetesInfo struct {
+	OSProfile    string
+	Dependencies string
+}
+
+const installKubernetesTemplate = `
+
+################################## install Kubernetes on {{ .OSProfile }}
+
+{{ .Dependencies }}
 crictl --version
 
 sudo rm -rf /srv/kubernetes/
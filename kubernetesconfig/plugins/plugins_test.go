@@ -2,10 +2,23 @@ package plugins
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/aws/aws-k8s-tester/ec2config/osprofile"
 )
 
 func TestCreateInstall(t *testing.T) {
-	s1 := CreateInstall()
+	p, err := osprofile.Get(osprofile.AmazonLinux2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1, err := CreateInstall(p, "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(s1, "kubernetes-el7-x86_64") {
+		t.Fatalf("unexpected amd64 install script %q", s1)
+	}
 	fmt.Println(s1)
 }
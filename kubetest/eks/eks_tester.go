@@ -65,6 +65,33 @@ func (tr *tester) TestALBMetrics() (err error) {
 	return err
 }
 
+func (tr *tester) TestConformance(artifactDir string) (err error) {
+	if _, err = tr.LoadConfig(); err != nil {
+		return err
+	}
+	_, err = tr.ctrl.Output(osexec.Command(
+		tr.cfg.AWSK8sTesterPath,
+		"eks",
+		"--path="+tr.cfg.ConfigPath,
+		"test", "conformance",
+		artifactDir,
+	))
+	return err
+}
+
+func (tr *tester) TestCNI() (err error) {
+	if _, err = tr.LoadConfig(); err != nil {
+		return err
+	}
+	_, err = tr.ctrl.Output(osexec.Command(
+		tr.cfg.AWSK8sTesterPath,
+		"eks",
+		"--path="+tr.cfg.ConfigPath,
+		"test", "cni",
+	))
+	return err
+}
+
 // UploadToBucketForTests uploads a local file to aws-k8s-tester S3 bucket.
 func (tr *tester) UploadToBucketForTests(localPath, s3Path string) (err error) {
 	_, err = tr.ctrl.Output(osexec.Command(
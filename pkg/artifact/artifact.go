@@ -0,0 +1,69 @@
+// Package artifact implements test artifact storage backends.
+package artifact
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"go.uber.org/zap"
+)
+
+// Store defines artifact storage.
+type Store interface {
+	// Kind returns the kind of the store.
+	Kind() string
+	// Upload uploads a local file to the remote path.
+	Upload(localPath, remotePath string) error
+	// URL returns the URL of the remote path.
+	URL(remotePath string) string
+}
+
+const (
+	// KindS3 stores artifacts in an S3 bucket.
+	KindS3 = "s3"
+	// KindLocal copies artifacts to a local directory.
+	KindLocal = "local"
+	// KindHTTP uploads artifacts with HTTP PUT requests
+	// (e.g. Google Cloud Storage XML API).
+	KindHTTP = "http"
+)
+
+// Config defines artifact store configuration.
+type Config struct {
+	// Kind is the kind of the store.
+	// One of "s3", "local", or "http".
+	Kind string
+
+	// S3API is the S3 client for "s3" store.
+	S3API s3iface.S3API
+	// Region is the AWS region for "s3" store.
+	Region string
+	// Bucket is the bucket name for "s3" store.
+	Bucket string
+	// Tags are the bucket tags for "s3" store.
+	Tags map[string]string
+	// ExpireDays is the number of days for objects in "s3" store to expire.
+	// Set 0 to not expire.
+	ExpireDays int
+
+	// Dir is the directory for "local" store.
+	Dir string
+
+	// URL is the base URL for "http" store.
+	// e.g. "https://storage.googleapis.com/my-bucket"
+	URL string
+}
+
+// New creates a new artifact store.
+func New(lg *zap.Logger, cfg Config) (Store, error) {
+	switch cfg.Kind {
+	case KindS3:
+		return NewS3(lg, cfg)
+	case KindLocal:
+		return NewLocal(lg, cfg.Dir)
+	case KindHTTP:
+		return NewHTTP(lg, cfg.URL)
+	default:
+		return nil, fmt.Errorf("unknown artifact store %q", cfg.Kind)
+	}
+}
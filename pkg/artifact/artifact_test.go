@@ -0,0 +1,129 @@
+package artifact
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestLocal(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "artifact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src.txt")
+	if err = ioutil.WriteFile(src, []byte("hello world!"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := New(zap.NewExample(), Config{Kind: KindLocal, Dir: filepath.Join(dir, "store")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st = WithManifest(st, filepath.Join(dir, "manifest.json"), "cluster/manifest.json")
+	if err = st.Upload(src, "cluster/a/hello.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.Upload(src, "cluster/b/hello.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := ioutil.ReadFile(filepath.Join(dir, "store", "cluster", "a", "hello.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(d) != "hello world!" {
+		t.Fatalf("unexpected content %q", string(d))
+	}
+	if u := st.URL("cluster/a/hello.txt"); u != "file://"+filepath.Join(dir, "store", "cluster", "a", "hello.txt") {
+		t.Fatalf("unexpected URL %q", u)
+	}
+
+	d, err = ioutil.ReadFile(filepath.Join(dir, "store", "cluster", "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mf Manifest
+	if err = json.Unmarshal(d, &mf); err != nil {
+		t.Fatal(err)
+	}
+	if mf.Kind != KindLocal {
+		t.Fatalf("manifest kind expected %q, got %q", KindLocal, mf.Kind)
+	}
+	if len(mf.Artifacts) != 2 {
+		t.Fatalf("manifest artifacts expected 2, got %+v", mf.Artifacts)
+	}
+	if mf.Artifacts[0].RemotePath != "cluster/a/hello.txt" || mf.Artifacts[0].Size != 12 {
+		t.Fatalf("unexpected manifest entry %+v", mf.Artifacts[0])
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	var mu sync.Mutex
+	uploads := make(map[string]string)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if req.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		d, _ := ioutil.ReadAll(req.Body)
+		mu.Lock()
+		uploads[req.URL.Path] = string(d)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	f, err := ioutil.TempFile(os.TempDir(), "artifact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("hello world!"))
+	src := f.Name()
+	f.Close()
+	defer os.RemoveAll(src)
+
+	st, err := New(zap.NewExample(), Config{Kind: KindHTTP, URL: ts.URL + "/bucket/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = st.Upload(src, "cluster/hello.txt"); err == nil {
+		t.Fatal("expected unauthorized error")
+	}
+
+	os.Setenv(AuthorizationEnv, "Bearer test-token")
+	defer os.Unsetenv(AuthorizationEnv)
+	if err = st.Upload(src, "cluster/hello.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if uploads["/bucket/cluster/hello.txt"] != "hello world!" {
+		t.Fatalf("unexpected uploads %v", uploads)
+	}
+	if u := st.URL("cluster/hello.txt"); u != ts.URL+"/bucket/cluster/hello.txt" {
+		t.Fatalf("unexpected URL %q", u)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(zap.NewExample(), Config{Kind: "unknown"}); err == nil {
+		t.Fatal("expected error for unknown store")
+	}
+	if _, err := New(zap.NewExample(), Config{Kind: KindS3}); err == nil {
+		t.Fatal("expected error for S3 store without API")
+	}
+	if _, err := New(zap.NewExample(), Config{Kind: KindHTTP, URL: "ftp://a"}); err == nil {
+		t.Fatal("expected error for unknown scheme")
+	}
+}
@@ -0,0 +1,94 @@
+package artifact
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"go.uber.org/zap"
+)
+
+// AuthorizationEnv is the environmental variable for the "Authorization"
+// header of "http" store requests (e.g. "Bearer <OAuth 2.0 token>").
+// It is read from the environment, so that credentials never get
+// written to configuration files.
+const AuthorizationEnv = "AWS_K8S_TESTER_ARTIFACT_STORE_AUTHORIZATION"
+
+type httpStore struct {
+	lg  *zap.Logger
+	url string
+	cli *http.Client
+}
+
+// NewHTTP creates a new artifact store that uploads files with HTTP PUT
+// requests to the base URL (e.g. Google Cloud Storage XML API).
+func NewHTTP(lg *zap.Logger, baseURL string) (Store, error) {
+	if baseURL == "" {
+		return nil, errors.New("HTTP artifact store requires URL")
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("HTTP artifact store URL %q has unknown scheme %q", baseURL, u.Scheme)
+	}
+	return &httpStore{
+		lg:  lg,
+		url: strings.TrimSuffix(baseURL, "/"),
+		cli: &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+func (st *httpStore) Kind() string { return KindHTTP }
+
+func (st *httpStore) URL(remotePath string) string {
+	return st.url + "/" + strings.TrimPrefix(remotePath, "/")
+}
+
+func (st *httpStore) Upload(localPath, remotePath string) error {
+	d, err := ioutil.ReadFile(localPath)
+	if err != nil {
+		return err
+	}
+
+	ep := st.URL(remotePath)
+	req, err := http.NewRequest(http.MethodPut, ep, bytes.NewReader(d))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if auth := os.Getenv(AuthorizationEnv); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := st.cli.Do(req)
+	if err != nil {
+		st.lg.Warn("failed to upload", zap.String("local-path", localPath), zap.String("url", ep), zap.Error(err))
+		return err
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		st.lg.Warn("failed to upload",
+			zap.String("local-path", localPath),
+			zap.String("url", ep),
+			zap.String("status", resp.Status),
+		)
+		return fmt.Errorf("failed to upload %q to %q (%s, %q)", localPath, ep, resp.Status, string(body))
+	}
+
+	st.lg.Debug("uploaded",
+		zap.String("local-path", localPath),
+		zap.String("url", ep),
+		zap.String("size", humanize.Bytes(uint64(len(d)))),
+	)
+	return nil
+}
@@ -0,0 +1,54 @@
+package artifact
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+
+	"go.uber.org/zap"
+)
+
+type localStore struct {
+	lg  *zap.Logger
+	dir string
+}
+
+// NewLocal creates a new artifact store that copies files to a local directory.
+func NewLocal(lg *zap.Logger, dir string) (Store, error) {
+	if dir == "" {
+		return nil, errors.New("local artifact store requires directory")
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &localStore{lg: lg, dir: dir}, nil
+}
+
+func (st *localStore) Kind() string { return KindLocal }
+
+func (st *localStore) URL(remotePath string) string {
+	return "file://" + filepath.Join(st.dir, filepath.FromSlash(remotePath))
+}
+
+func (st *localStore) Upload(localPath, remotePath string) error {
+	dst := filepath.Join(st.dir, filepath.FromSlash(remotePath))
+	if err := fileutil.Copy(localPath, dst); err != nil {
+		st.lg.Warn("failed to copy",
+			zap.String("local-path", localPath),
+			zap.String("remote-path", dst),
+			zap.Error(err),
+		)
+		return err
+	}
+	st.lg.Debug("copied",
+		zap.String("local-path", localPath),
+		zap.String("remote-path", dst),
+	)
+	return nil
+}
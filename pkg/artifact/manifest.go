@@ -0,0 +1,86 @@
+package artifact
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Manifest lists all artifacts uploaded to a store.
+type Manifest struct {
+	Kind      string  `json:"kind"`
+	Artifacts []Entry `json:"artifacts"`
+}
+
+// Entry is an uploaded artifact.
+type Entry struct {
+	RemotePath string    `json:"remote-path"`
+	URL        string    `json:"url"`
+	Size       int64     `json:"size"`
+	SHA256     string    `json:"sha256"`
+	UploadedAt time.Time `json:"uploaded-at"`
+}
+
+type manifestStore struct {
+	Store
+
+	mu         sync.Mutex
+	localPath  string
+	remotePath string
+	entries    map[string]Entry
+}
+
+// WithManifest wraps a store to record every upload in a manifest file.
+// The manifest is written to the local path and uploaded to the remote path
+// after each upload, so that it is always up-to-date with the store.
+func WithManifest(st Store, localPath, remotePath string) Store {
+	return &manifestStore{
+		Store:      st,
+		localPath:  localPath,
+		remotePath: remotePath,
+		entries:    make(map[string]Entry),
+	}
+}
+
+func (st *manifestStore) Upload(localPath, remotePath string) error {
+	if err := st.Store.Upload(localPath, remotePath); err != nil {
+		return err
+	}
+
+	d, err := ioutil.ReadFile(localPath)
+	if err != nil {
+		return err
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.entries[remotePath] = Entry{
+		RemotePath: remotePath,
+		URL:        st.Store.URL(remotePath),
+		Size:       int64(len(d)),
+		SHA256:     fmt.Sprintf("%x", sha256.Sum256(d)),
+		UploadedAt: time.Now().UTC(),
+	}
+
+	mf := Manifest{Kind: st.Store.Kind(), Artifacts: make([]Entry, 0, len(st.entries))}
+	for _, v := range st.entries {
+		mf.Artifacts = append(mf.Artifacts, v)
+	}
+	sort.Slice(mf.Artifacts, func(i, j int) bool {
+		return mf.Artifacts[i].RemotePath < mf.Artifacts[j].RemotePath
+	})
+	var md []byte
+	md, err = json.MarshalIndent(mf, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(st.localPath, md, 0600); err != nil {
+		return err
+	}
+	return st.Store.Upload(st.localPath, st.remotePath)
+}
@@ -0,0 +1,200 @@
+package artifact
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	humanize "github.com/dustin/go-humanize"
+	"go.uber.org/zap"
+)
+
+type s3Store struct {
+	mu      sync.Mutex
+	lg      *zap.Logger
+	cfg     Config
+	s3      s3iface.S3API
+	created bool
+}
+
+// NewS3 creates a new artifact store backed by an S3 bucket.
+// The bucket is created on the first upload.
+func NewS3(lg *zap.Logger, cfg Config) (Store, error) {
+	if cfg.S3API == nil {
+		return nil, errors.New("S3 artifact store requires S3 API")
+	}
+	if cfg.Region == "" {
+		return nil, errors.New("S3 artifact store requires region")
+	}
+	if cfg.Bucket == "" {
+		return nil, errors.New("S3 artifact store requires bucket")
+	}
+	return &s3Store{lg: lg, cfg: cfg, s3: cfg.S3API}, nil
+}
+
+func (st *s3Store) Kind() string { return KindS3 }
+
+// URL returns the S3 URL path.
+// e.g. https://s3-us-west-2.amazonaws.com/aws-k8s-tester-20180925/hello-world
+func (st *s3Store) URL(remotePath string) string {
+	return fmt.Sprintf("https://s3-%s.amazonaws.com/%s/%s", st.cfg.Region, st.cfg.Bucket, remotePath)
+}
+
+func (st *s3Store) Upload(localPath, remotePath string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	bucket := st.cfg.Bucket
+	if !st.created {
+		if err := st.createBucket(); err != nil {
+			return err
+		}
+		st.created = true
+		st.lg.Info("created bucket", zap.String("bucket", bucket))
+	}
+
+	d, err := ioutil.ReadFile(localPath)
+	if err != nil {
+		return err
+	}
+
+	h, _ := os.Hostname()
+	_, err = st.s3.PutObject(&s3.PutObjectInput{
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(remotePath),
+		Body:    bytes.NewReader(d),
+		Expires: aws.Time(time.Now().UTC().Add(24 * time.Hour)),
+
+		// https://docs.aws.amazon.com/AmazonS3/latest/dev/acl-overview.html#canned-acl
+		// vs. "public-read"
+		ACL: aws.String("private"),
+
+		Metadata: map[string]*string{
+			"HOSTNAME": aws.String(h),
+		},
+	})
+	if err == nil {
+		st.lg.Debug("uploaded",
+			zap.String("bucket", bucket),
+			zap.String("local-path", localPath),
+			zap.String("remote-path", remotePath),
+			zap.String("size", humanize.Bytes(uint64(len(d)))),
+		)
+	} else {
+		st.lg.Warn("failed to upload",
+			zap.String("bucket", bucket),
+			zap.String("local-path", localPath),
+			zap.String("remote-path", remotePath),
+			zap.String("size", humanize.Bytes(uint64(len(d)))),
+			zap.Error(err),
+		)
+	}
+	return err
+}
+
+func (st *s3Store) createBucket() (err error) {
+	bucket := st.cfg.Bucket
+	for i := 0; i < 30; i++ {
+		retry := false
+		_, err = st.s3.CreateBucket(&s3.CreateBucketInput{
+			Bucket: aws.String(bucket),
+			CreateBucketConfiguration: &s3.CreateBucketConfiguration{
+				LocationConstraint: aws.String(st.cfg.Region),
+			},
+			// https://docs.aws.amazon.com/AmazonS3/latest/dev/acl-overview.html#canned-acl
+			// vs. "public-read"
+			ACL: aws.String("private"),
+		})
+		if err != nil {
+			exist := false
+			// https://docs.aws.amazon.com/AWSEC2/latest/APIReference/errors-overview.html
+			if aerr, ok := err.(awserr.Error); ok {
+				switch aerr.Code() {
+				case s3.ErrCodeBucketAlreadyExists:
+					st.lg.Warn("bucket already exists", zap.String("bucket", bucket), zap.Error(err))
+					exist, err = true, nil
+				case s3.ErrCodeBucketAlreadyOwnedByYou:
+					st.lg.Warn("bucket already owned by me", zap.String("bucket", bucket), zap.Error(err))
+					exist, err = true, nil
+				default:
+					if strings.Contains(err.Error(), "OperationAborted: A conflicting conditional operation is currently in progress against this resource. Please try again.") ||
+						request.IsErrorRetryable(err) ||
+						request.IsErrorThrottle(err) {
+						retry = true
+					} else {
+						st.lg.Warn("failed to create bucket", zap.String("bucket", bucket), zap.String("code", aerr.Code()), zap.Error(err))
+						return err
+					}
+				}
+			}
+			if !retry && !exist {
+				return err
+			}
+			if err != nil {
+				st.lg.Warn("retrying S3 bucket creation", zap.Error(err))
+				time.Sleep(5 * time.Second)
+				continue
+			}
+		}
+
+		h, _ := os.Hostname()
+		tags := []*s3.Tag{{Key: aws.String("HOSTNAME"), Value: aws.String(h)}}
+		keys := make([]string, 0, len(st.cfg.Tags))
+		for k := range st.cfg.Tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			tags = append(tags, &s3.Tag{Key: aws.String(k), Value: aws.String(st.cfg.Tags[k])})
+		}
+		_, err = st.s3.PutBucketTagging(&s3.PutBucketTaggingInput{
+			Bucket:  aws.String(bucket),
+			Tagging: &s3.Tagging{TagSet: tags},
+		})
+		if err != nil {
+			return err
+		}
+
+		if st.cfg.ExpireDays > 0 {
+			if err = st.addLifecycle(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return err
+}
+
+func (st *s3Store) addLifecycle() error {
+	daysUntilExp := int64(st.cfg.ExpireDays)
+	input := &s3.PutBucketLifecycleConfigurationInput{
+		Bucket: aws.String(st.cfg.Bucket),
+		LifecycleConfiguration: &s3.BucketLifecycleConfiguration{
+			Rules: []*s3.LifecycleRule{
+				{
+					AbortIncompleteMultipartUpload: &s3.AbortIncompleteMultipartUpload{
+						DaysAfterInitiation: &daysUntilExp,
+					},
+					Expiration: &s3.LifecycleExpiration{
+						Days: &daysUntilExp,
+					},
+					ID:     aws.String(fmt.Sprintf("ObjectLifecycleOf%vDays", st.cfg.ExpireDays)),
+					Status: aws.String("Enabled"),
+				},
+			},
+		},
+	}
+	_, err := st.s3.PutBucketLifecycleConfiguration(input)
+	return err
+}
@@ -0,0 +1,56 @@
+package ec2
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+const (
+	// AmazonOwner is the owner alias of Amazon Linux 2 AMIs.
+	AmazonOwner = "amazon"
+	// EKSOwner is the owner account ID of Amazon EKS-optimized AMIs.
+	EKSOwner = "602401143452"
+)
+
+// AmazonLinux2ImageName returns the Amazon Linux 2 AMI name pattern
+// for the architecture ("x86_64" or "arm64").
+func AmazonLinux2ImageName(arch string) string {
+	return fmt.Sprintf("amzn2-ami-hvm-*-%s-gp2", arch)
+}
+
+// EKSImageName returns the Amazon EKS-optimized AMI name pattern
+// for the Kubernetes version (e.g. "1.11") and architecture.
+// https://docs.aws.amazon.com/eks/latest/userguide/eks-optimized-ami.html
+func EKSImageName(ver, arch string) string {
+	if arch == "arm64" {
+		return fmt.Sprintf("amazon-eks-arm64-node-%s-v*", ver)
+	}
+	return fmt.Sprintf("amazon-eks-node-%s-v*", ver)
+}
+
+// LatestImageID returns the most recently created available AMI
+// of the owner, whose name matches the pattern.
+func LatestImageID(svc ec2iface.EC2API, owner, name string) (string, error) {
+	out, err := svc.DescribeImages(&ec2.DescribeImagesInput{
+		Owners: aws.StringSlice([]string{owner}),
+		Filters: []*ec2.Filter{
+			{Name: aws.String("name"), Values: aws.StringSlice([]string{name})},
+			{Name: aws.String("state"), Values: aws.StringSlice([]string{"available"})},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(out.Images) == 0 {
+		return "", fmt.Errorf("no image found for %q (owner %q)", name, owner)
+	}
+	// creation date is in ISO 8601 format
+	sort.Slice(out.Images, func(i, j int) bool {
+		return aws.StringValue(out.Images[i].CreationDate) > aws.StringValue(out.Images[j].CreationDate)
+	})
+	return aws.StringValue(out.Images[0].ImageId), nil
+}
@@ -0,0 +1,50 @@
+package ec2
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+type fakeImagesEC2 struct {
+	ec2iface.EC2API
+	input  *ec2.DescribeImagesInput
+	images []*ec2.Image
+}
+
+func (f *fakeImagesEC2) DescribeImages(input *ec2.DescribeImagesInput) (*ec2.DescribeImagesOutput, error) {
+	f.input = input
+	return &ec2.DescribeImagesOutput{Images: f.images}, nil
+}
+
+func TestLatestImageID(t *testing.T) {
+	svc := &fakeImagesEC2{images: []*ec2.Image{
+		{ImageId: aws.String("ami-1"), CreationDate: aws.String("2018-11-01T00:00:00.000Z")},
+		{ImageId: aws.String("ami-3"), CreationDate: aws.String("2019-01-10T00:00:00.000Z")},
+		{ImageId: aws.String("ami-2"), CreationDate: aws.String("2018-12-20T00:00:00.000Z")},
+	}}
+	name := EKSImageName("1.11", "arm64")
+	if name != "amazon-eks-arm64-node-1.11-v*" {
+		t.Fatalf("unexpected image name %q", name)
+	}
+	id, err := LatestImageID(svc, EKSOwner, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "ami-3" {
+		t.Fatalf("expected ami-3, got %q", id)
+	}
+	if aws.StringValue(svc.input.Owners[0]) != EKSOwner {
+		t.Fatalf("unexpected owners %v", aws.StringValueSlice(svc.input.Owners))
+	}
+	if aws.StringValue(svc.input.Filters[0].Values[0]) != name {
+		t.Fatalf("unexpected filter %v", svc.input.Filters[0])
+	}
+
+	svc.images = nil
+	if _, err = LatestImageID(svc, AmazonOwner, AmazonLinux2ImageName("arm64")); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -6,4 +6,4 @@ if ! [[ "$0" =~ instance-types.gen.sh ]]; then
   exit 255
 fi
 
-go run ./instance-types
+go run ./instance-types "$@"
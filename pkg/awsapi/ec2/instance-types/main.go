@@ -1,4 +1,4 @@
-// Copied from https://github.com/kubernetes/autoscaler/blob/master/cluster-autoscaler/cloudprovider/aws/ec2_instance_types/gen.go
+// Originally copied from https://github.com/kubernetes/autoscaler/blob/master/cluster-autoscaler/cloudprovider/aws/ec2_instance_types/gen.go
 
 /*
 Copyright 2017 The Kubernetes Authors.
@@ -16,23 +16,31 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-// instance-types auto-generates EC2 instance types from AWS API.
+// instance-types generates EC2 instance types from local files, without
+// any network access. It starts from the current "InstanceTypes", and
+// merges the AWS Price List offer file of a region, and the output of
+// "aws ec2 describe-instance-types --output json", if given.
+//
+//	curl -o /tmp/offer.json https://pricing.us-east-1.amazonaws.com/offers/v1.0/aws/AmazonEC2/current/us-east-1/index.json
+//	aws ec2 describe-instance-types --region us-east-1 --output json > /tmp/describe.json
+//	go run ./instance-types -offer-file /tmp/offer.json -describe-file /tmp/describe.json
 package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
 	"time"
 
-	"github.com/aws/aws-k8s-tester/pkg/httputil"
-
-	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-k8s-tester/pkg/awsapi/ec2"
 	"go.uber.org/zap"
 )
 
@@ -46,8 +54,13 @@ func init() {
 	}
 }
 
-type response struct {
+// offer is the AWS Price List offer file of EC2 in a region.
+// https://docs.aws.amazon.com/awsaccountbilling/latest/aboutv2/reading-an-offer.html
+type offer struct {
 	Products map[string]product `json:"products"`
+	Terms    struct {
+		OnDemand map[string]map[string]offerTerm `json:"OnDemand"`
+	} `json:"terms"`
 }
 
 type product struct {
@@ -55,22 +68,52 @@ type product struct {
 }
 
 type productAttributes struct {
-	InstanceType string `json:"instanceType"`
-	VCPU         string `json:"vcpu"`
-	Memory       string `json:"memory"`
-	GPU          string `json:"gpu"`
+	InstanceType       string `json:"instanceType"`
+	VCPU               string `json:"vcpu"`
+	Memory             string `json:"memory"`
+	GPU                string `json:"gpu"`
+	NetworkPerformance string `json:"networkPerformance"`
+	OperatingSystem    string `json:"operatingSystem"`
+	Tenancy            string `json:"tenancy"`
+	PreInstalledSW     string `json:"preInstalledSw"`
+	CapacityStatus     string `json:"capacitystatus"`
 }
 
-type instanceType struct {
-	InstanceType string
-	VCPU         int64
-	Memory       int64
-	GPU          int64
-	MaxPods      int64
+type offerTerm struct {
+	PriceDimensions map[string]struct {
+		Unit         string            `json:"unit"`
+		PricePerUnit map[string]string `json:"pricePerUnit"`
+	} `json:"priceDimensions"`
+}
+
+// describeOutput is the output of "aws ec2 describe-instance-types".
+type describeOutput struct {
+	InstanceTypes []struct {
+		InstanceType  string `json:"InstanceType"`
+		ProcessorInfo struct {
+			SupportedArchitectures []string `json:"SupportedArchitectures"`
+		} `json:"ProcessorInfo"`
+		VCPUInfo struct {
+			DefaultVCPUs int64 `json:"DefaultVCpus"`
+		} `json:"VCpuInfo"`
+		MemoryInfo struct {
+			SizeInMiB int64 `json:"SizeInMiB"`
+		} `json:"MemoryInfo"`
+		GPUInfo struct {
+			GPUs []struct {
+				Count int64 `json:"Count"`
+			} `json:"Gpus"`
+		} `json:"GpuInfo"`
+		NetworkInfo struct {
+			NetworkPerformance        string `json:"NetworkPerformance"`
+			MaximumNetworkInterfaces  int64  `json:"MaximumNetworkInterfaces"`
+			IPv4AddressesPerInterface int64  `json:"Ipv4AddressesPerInterface"`
+		} `json:"NetworkInfo"`
+	} `json:"InstanceTypes"`
 }
 
 var (
-	tmpl = fmt.Sprintf(`/*
+	tmpl = `/*
 Copyright 2017 The Kubernetes Authors.
 
 Licensed under the Apache License, Version 2.0 (the "License");
@@ -88,151 +131,288 @@ limitations under the License.
 
 // This file was generated by go generate; DO NOT EDIT
 
-// generated at %v
+// generated at {{ .GeneratedAt }}
 
 package ec2
 
 // InstanceType is an EC2 instance type.
 type InstanceType struct {
 	InstanceType string
+	// Architecture is the processor architecture, "x86_64" or "arm64".
+	Architecture string
 	VCPU         int64
 	MemoryMb     int64
 	GPU          int64
-	MaxPods      int64
+	// NetworkPerformance is the network performance (e.g. "Up to 10 Gigabit").
+	NetworkPerformance string
+	// ENIs is the maximum number of network interfaces.
+	ENIs int64
+	// IPv4PerENI is the maximum number of IPv4 addresses per network interface.
+	IPv4PerENI int64
+	// MaxPods is the maximum number of pods with Amazon VPC CNI plugin
+	// (see "MaxPods" function).
+	MaxPods int64
+	// PricePerHour is the On-Demand Linux price per hour in USD,
+	// in the region of the offer file. Zero if unknown.
+	PricePerHour float64
+	// Complete is true if the network interface limits and the price
+	// are known. Incomplete instance types are skipped by "Find".
+	Complete bool
 }
 
 // InstanceTypes is a map of EC2 resources.
 var InstanceTypes = map[string]*InstanceType{
 {{- range .InstanceTypes }}
 	"{{ .InstanceType }}": {
-		InstanceType: "{{ .InstanceType }}",
-		VCPU:         {{ .VCPU }},
-		MemoryMb:     {{ .Memory }},
-		GPU:          {{ .GPU }},
-		MaxPods:      {{ .MaxPods }},
+		InstanceType:       "{{ .InstanceType }}",
+		Architecture:       "{{ .Architecture }}",
+		VCPU:               {{ .VCPU }},
+		MemoryMb:           {{ .MemoryMb }},
+		GPU:                {{ .GPU }},
+		NetworkPerformance: "{{ .NetworkPerformance }}",
+		ENIs:               {{ .ENIs }},
+		IPv4PerENI:         {{ .IPv4PerENI }},
+		MaxPods:            {{ .MaxPods }},
+		PricePerHour:       {{ .PricePerHour }},
+		Complete:           {{ .Complete }},
 	},
 {{- end }}
 }
-`, time.Now().UTC())
+`
 
 	pkgTmpl = template.Must(template.New("").Parse(tmpl))
 )
 
 func main() {
-	maxPodsData, merr := httputil.Download(lg, os.Stdout, "https://raw.githubusercontent.com/awslabs/amazon-eks-ami/master/files/eni-max-pods.txt")
-	if merr != nil {
-		lg.Fatal("failed to download ENI max pods", zap.Error(merr))
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: go run ./instance-types [-offer-file FILE] [-describe-file FILE] [-output FILE]\n")
+		flag.PrintDefaults()
 	}
-	instanceToMaxPods := make(map[string]int64)
-	for _, line := range strings.Split(string(maxPodsData), "\n") {
-		if strings.HasPrefix(line, "# ") {
-			continue
+	offerPath := flag.String("offer-file", "", "AWS Price List offer file of EC2 in a region (index.json)")
+	describePath := flag.String("describe-file", "", "output of 'aws ec2 describe-instance-types --output json'")
+	outputPath := flag.String("output", "instance_types.go", "generated file path")
+	flag.Parse()
+
+	instanceTypes := make(map[string]*ec2.InstanceType, len(ec2.InstanceTypes))
+	for k, v := range ec2.InstanceTypes {
+		vv := *v
+		instanceTypes[k] = &vv
+	}
+
+	if *offerPath != "" {
+		d, err := ioutil.ReadFile(*offerPath)
+		if err != nil {
+			lg.Fatal("failed to read offer file", zap.Error(err))
 		}
-		fields := strings.Fields(line)
-		if len(fields) != 2 {
-			lg.Warn("skipping line", zap.String("line", line))
-			continue
+		var o offer
+		if err = json.Unmarshal(d, &o); err != nil {
+			lg.Fatal("failed to parse offer file", zap.String("path", *offerPath), zap.Error(err))
 		}
-		n, err := strconv.ParseInt(fields[1], 10, 64)
+		n := mergeOffer(instanceTypes, o)
+		lg.Info("merged offer file", zap.String("path", *offerPath), zap.Int("instance-types", n))
+	}
+	if *describePath != "" {
+		d, err := ioutil.ReadFile(*describePath)
 		if err != nil {
-			lg.Fatal("failed to parse int", zap.Error(err))
+			lg.Fatal("failed to read describe file", zap.Error(err))
+		}
+		var o describeOutput
+		if err = json.Unmarshal(d, &o); err != nil {
+			lg.Fatal("failed to parse describe file", zap.String("path", *describePath), zap.Error(err))
 		}
-		instanceToMaxPods[fields[0]] = n
+		n := mergeDescribe(instanceTypes, o)
+		lg.Info("merged describe file", zap.String("path", *describePath), zap.Int("instance-types", n))
 	}
+	finalize(instanceTypes)
 
-	reg, err := regexp.Compile("[^0-9\\.]+")
+	f, err := os.Create(*outputPath)
 	if err != nil {
-		lg.Fatal("failed to compile regex", zap.Error(err))
+		lg.Fatal("failed to create output", zap.String("path", *outputPath), zap.Error(err))
 	}
+	defer f.Close()
 
-	instanceTypes := make(map[string]*instanceType)
-
-	resolver := endpoints.DefaultResolver()
-	partitions := resolver.(endpoints.EnumPartitions).Partitions()
-	lg.Info("partitions", zap.Int("total", len(partitions)))
-	for _, p := range partitions {
-		lg.Info("partition", zap.String("id", p.ID()), zap.Int("total-regions", len(p.Regions())))
-		for _, r := range p.Regions() {
-			u := "https://pricing.us-east-1.amazonaws.com/offers/v1.0/aws/AmazonEC2/current/" + r.ID() + "/index.json"
-			d, err := httputil.Download(lg, os.Stdout, u)
-			if err != nil {
-				lg.Warn("failed to download", zap.String("url", u), zap.String("data", string(d)), zap.Error(err))
-				continue
+	if err = pkgTmpl.Execute(f, struct {
+		GeneratedAt   time.Time
+		InstanceTypes []*ec2.InstanceType
+	}{
+		GeneratedAt:   time.Now().UTC(),
+		InstanceTypes: sortInstanceTypes(instanceTypes),
+	}); err != nil {
+		lg.Fatal("failed to write template", zap.Error(err))
+	}
+
+	if err := exec.Command("gofmt", "-w", *outputPath).Run(); err != nil {
+		lg.Fatal("failed to 'gofmt'", zap.Error(err))
+	}
+
+	lg.Info("done!", zap.String("output", *outputPath), zap.Int("instance-types", len(instanceTypes)))
+}
+
+var numRegex = regexp.MustCompile(`[^0-9\.]+`)
+
+// mergeOffer merges the offer file attributes and On-Demand Linux prices
+// of shared tenancy instances. It returns the number of merged products.
+func mergeOffer(instanceTypes map[string]*ec2.InstanceType, o offer) (n int) {
+	for sku, p := range o.Products {
+		attr := p.Attributes
+		if !isInstanceType(attr.InstanceType) {
+			continue
+		}
+		v := getOrCreate(instanceTypes, attr.InstanceType)
+		if attr.VCPU != "" {
+			if iv, err := strconv.ParseInt(attr.VCPU, 10, 64); err == nil {
+				v.VCPU = iv
+			} else {
+				lg.Warn("failed to parse integer", zap.String("string", attr.VCPU), zap.Error(err))
 			}
+		}
+		if attr.Memory != "" && attr.Memory != "NA" {
+			if mem, err := strconv.ParseFloat(numRegex.ReplaceAllString(attr.Memory, ""), 64); err == nil {
+				v.MemoryMb = int64(mem * 1024)
+			} else {
+				lg.Warn("failed to parse float", zap.String("string", attr.Memory), zap.Error(err))
+			}
+		}
+		if attr.GPU != "" {
+			if iv, err := strconv.ParseInt(attr.GPU, 10, 64); err == nil {
+				v.GPU = iv
+			} else {
+				lg.Warn("failed to parse integer", zap.String("string", attr.GPU), zap.Error(err))
+			}
+		}
+		if attr.NetworkPerformance != "" && attr.NetworkPerformance != "NA" {
+			v.NetworkPerformance = attr.NetworkPerformance
+		}
+		n++
 
-			var rs response
-			err = json.Unmarshal(d, &rs)
-			if err != nil {
-				if len(d) < 250 {
-					lg.Warn("contents are missing?", zap.String("url", u), zap.String("data", string(d)), zap.Error(err))
-					continue
-				}
-				lg.Warn("failed to unmarshal", zap.String("url", u), zap.String("data", string(d)), zap.Error(err))
+		// other products of the same instance type have different
+		// prices for Windows, dedicated tenancy, or reserved capacity
+		if attr.OperatingSystem != "Linux" || attr.Tenancy != "Shared" || attr.PreInstalledSW != "NA" {
+			continue
+		}
+		if attr.CapacityStatus != "" && attr.CapacityStatus != "Used" {
+			continue
+		}
+		if price, ok := onDemandPrice(o.Terms.OnDemand[sku]); ok {
+			v.PricePerHour = price
+		}
+	}
+	return n
+}
+
+// onDemandPrice returns the hourly USD price of the On-Demand terms.
+func onDemandPrice(terms map[string]offerTerm) (float64, bool) {
+	for _, term := range terms {
+		for _, dim := range term.PriceDimensions {
+			if dim.Unit != "Hrs" {
 				continue
 			}
-
-			for _, product := range rs.Products {
-				attr := product.Attributes
-				if attr.InstanceType == "" || strings.Contains(attr.InstanceType, " ") || (strings.ToLower(attr.InstanceType) != attr.InstanceType) {
-					continue
-				}
-				instanceTypes[attr.InstanceType] = &instanceType{
-					InstanceType: attr.InstanceType,
-				}
-				if attr.Memory != "" && attr.Memory != "NA" && !strings.HasSuffix(attr.Memory, " Mbps") {
-					parsed := strings.TrimSpace(reg.ReplaceAllString(attr.Memory, ""))
-					mem, err := strconv.ParseFloat(parsed, 64)
-					if err == nil {
-						instanceTypes[attr.InstanceType].Memory = int64(mem * float64(1024))
-					} else {
-						lg.Warn("failed to parse float", zap.String("string", attr.Memory), zap.Error(err))
-					}
-				}
-				if attr.VCPU != "" {
-					iv, err := strconv.ParseInt(attr.VCPU, 10, 64)
-					if err == nil {
-						instanceTypes[attr.InstanceType].VCPU = iv
-					} else {
-						lg.Warn("failed to parse integer", zap.String("string", attr.VCPU), zap.Error(err))
-					}
-				}
-				if attr.GPU != "" {
-					iv, err := strconv.ParseInt(attr.GPU, 10, 64)
-					if err == nil {
-						instanceTypes[attr.InstanceType].GPU = iv
-					} else {
-						lg.Warn("failed to parse integer", zap.String("string", attr.GPU), zap.Error(err))
-					}
-				}
-				if !strings.Contains(attr.InstanceType, ".") {
-					continue
-				}
-				v, ok := instanceToMaxPods[attr.InstanceType]
-				if !ok {
-					lg.Warn("failed to find max pods", zap.String("instance-type", attr.InstanceType))
-				}
-				instanceTypes[attr.InstanceType].MaxPods = v
+			price, err := strconv.ParseFloat(dim.PricePerUnit["USD"], 64)
+			if err != nil || price <= 0 {
+				continue
 			}
+			return price, true
 		}
 	}
+	return 0, false
+}
 
-	f, err := os.Create("instance_types.go")
-	if err != nil {
-		lg.Fatal("failed to write 'instance_types.go'", zap.Error(err))
+// mergeDescribe merges the instance types from EC2 API, which are
+// the source of truth for architecture and network interface limits.
+// It returns the number of merged instance types.
+func mergeDescribe(instanceTypes map[string]*ec2.InstanceType, o describeOutput) (n int) {
+	for _, it := range o.InstanceTypes {
+		if !isInstanceType(it.InstanceType) {
+			continue
+		}
+		v := getOrCreate(instanceTypes, it.InstanceType)
+		for _, arch := range it.ProcessorInfo.SupportedArchitectures {
+			// "i386" is also supported by some x86_64 types
+			if arch == "x86_64" || arch == "arm64" {
+				v.Architecture = arch
+			}
+		}
+		if it.VCPUInfo.DefaultVCPUs > 0 {
+			v.VCPU = it.VCPUInfo.DefaultVCPUs
+		}
+		if it.MemoryInfo.SizeInMiB > 0 {
+			v.MemoryMb = it.MemoryInfo.SizeInMiB
+		}
+		if len(it.GPUInfo.GPUs) > 0 {
+			v.GPU = 0
+			for _, gpu := range it.GPUInfo.GPUs {
+				v.GPU += gpu.Count
+			}
+		}
+		if it.NetworkInfo.NetworkPerformance != "" {
+			v.NetworkPerformance = it.NetworkInfo.NetworkPerformance
+		}
+		if it.NetworkInfo.MaximumNetworkInterfaces > 0 && it.NetworkInfo.IPv4AddressesPerInterface > 0 {
+			v.ENIs = it.NetworkInfo.MaximumNetworkInterfaces
+			v.IPv4PerENI = it.NetworkInfo.IPv4AddressesPerInterface
+		}
+		n++
 	}
-	defer f.Close()
+	return n
+}
 
-	if err = pkgTmpl.Execute(f, struct {
-		InstanceTypes map[string]*instanceType
-	}{
-		InstanceTypes: instanceTypes,
-	}); err != nil {
-		lg.Fatal("failed to write template", zap.Error(err))
+// finalize sets the architecture by instance family if unknown,
+// computes the max pods from network interface limits, and flags
+// instance types whose network interface limits or price are unknown.
+func finalize(instanceTypes map[string]*ec2.InstanceType) {
+	for k, v := range instanceTypes {
+		if !isInstanceType(k) {
+			// drop instance families (e.g. "a1") from old offer files
+			delete(instanceTypes, k)
+			continue
+		}
+		if v.Architecture == "" {
+			v.Architecture = "x86_64"
+			if strings.HasPrefix(k, "a1.") {
+				v.Architecture = "arm64"
+			}
+		}
+		if pods := ec2.MaxPods(v.ENIs, v.IPv4PerENI); pods > 0 {
+			if v.MaxPods > 0 && v.MaxPods != pods {
+				lg.Info("updating max pods", zap.String("instance-type", k), zap.Int64("from", v.MaxPods), zap.Int64("to", pods))
+			}
+			v.MaxPods = pods
+		}
+		if v.MaxPods == 0 {
+			lg.Warn("failed to find max pods", zap.String("instance-type", k))
+		}
+		v.Complete = v.ENIs > 0 && v.IPv4PerENI > 0 && v.PricePerHour > 0
+		if !v.Complete {
+			lg.Warn("incomplete instance type",
+				zap.String("instance-type", k),
+				zap.Int64("enis", v.ENIs),
+				zap.Int64("ipv4-per-eni", v.IPv4PerENI),
+				zap.Float64("price-per-hour", v.PricePerHour),
+			)
+		}
 	}
+}
 
-	if err := exec.Command("go", "fmt", "instance_types.go").Run(); err != nil {
-		lg.Fatal("failed to 'gofmt'", zap.Error(err))
+// isInstanceType returns true if the name is an instance type
+// with size (e.g. "m5.large"), not an instance family.
+func isInstanceType(s string) bool {
+	return s != "" && strings.Contains(s, ".") && !strings.Contains(s, " ") && strings.ToLower(s) == s
+}
+
+func getOrCreate(instanceTypes map[string]*ec2.InstanceType, k string) *ec2.InstanceType {
+	v, ok := instanceTypes[k]
+	if !ok {
+		v = &ec2.InstanceType{InstanceType: k}
+		instanceTypes[k] = v
 	}
+	return v
+}
 
-	lg.Info("done!")
+func sortInstanceTypes(instanceTypes map[string]*ec2.InstanceType) []*ec2.InstanceType {
+	vs := make([]*ec2.InstanceType, 0, len(instanceTypes))
+	for _, v := range instanceTypes {
+		vs = append(vs, v)
+	}
+	sort.Slice(vs, func(i, j int) bool { return vs[i].InstanceType < vs[j].InstanceType })
+	return vs
 }
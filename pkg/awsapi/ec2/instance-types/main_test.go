@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/pkg/awsapi/ec2"
+)
+
+const testOffer = `{
+  "products": {
+    "SKU1": {"attributes": {"instanceType": "m5.large", "vcpu": "2", "memory": "8 GiB", "networkPerformance": "Up to 10 Gigabit", "operatingSystem": "Linux", "tenancy": "Shared", "preInstalledSw": "NA", "capacitystatus": "Used"}},
+    "SKU2": {"attributes": {"instanceType": "m5.large", "vcpu": "2", "memory": "8 GiB", "operatingSystem": "Windows", "tenancy": "Shared", "preInstalledSw": "NA", "capacitystatus": "Used"}},
+    "SKU3": {"attributes": {"instanceType": "m5", "vcpu": "96"}}
+  },
+  "terms": {
+    "OnDemand": {
+      "SKU1": {"SKU1.A": {"priceDimensions": {"SKU1.A.B": {"unit": "Hrs", "pricePerUnit": {"USD": "0.0960000000"}}}}},
+      "SKU2": {"SKU2.A": {"priceDimensions": {"SKU2.A.B": {"unit": "Hrs", "pricePerUnit": {"USD": "0.1880000000"}}}}}
+    }
+  }
+}`
+
+const testDescribe = `{
+  "InstanceTypes": [
+    {
+      "InstanceType": "a1.large",
+      "ProcessorInfo": {"SupportedArchitectures": ["arm64"]},
+      "VCpuInfo": {"DefaultVCpus": 2},
+      "MemoryInfo": {"SizeInMiB": 4096},
+      "NetworkInfo": {"NetworkPerformance": "Up to 10 Gigabit", "MaximumNetworkInterfaces": 3, "Ipv4AddressesPerInterface": 10}
+    },
+    {
+      "InstanceType": "m5.large",
+      "ProcessorInfo": {"SupportedArchitectures": ["i386", "x86_64"]},
+      "NetworkInfo": {"MaximumNetworkInterfaces": 3, "Ipv4AddressesPerInterface": 10}
+    }
+  ]
+}`
+
+func TestGenerate(t *testing.T) {
+	var o offer
+	if err := json.Unmarshal([]byte(testOffer), &o); err != nil {
+		t.Fatal(err)
+	}
+	var d describeOutput
+	if err := json.Unmarshal([]byte(testDescribe), &d); err != nil {
+		t.Fatal(err)
+	}
+
+	instanceTypes := map[string]*ec2.InstanceType{
+		"c5":       {InstanceType: "c5"},
+		"t2.micro": {InstanceType: "t2.micro", VCPU: 1, MemoryMb: 1024, MaxPods: 4},
+	}
+	if n := mergeOffer(instanceTypes, o); n != 2 {
+		t.Fatalf("expected 2 merged products, got %d", n)
+	}
+	if n := mergeDescribe(instanceTypes, d); n != 2 {
+		t.Fatalf("expected 2 merged instance types, got %d", n)
+	}
+	finalize(instanceTypes)
+
+	exp := map[string]ec2.InstanceType{
+		"a1.large": {
+			InstanceType:       "a1.large",
+			Architecture:       "arm64",
+			VCPU:               2,
+			MemoryMb:           4096,
+			NetworkPerformance: "Up to 10 Gigabit",
+			ENIs:               3,
+			IPv4PerENI:         10,
+			MaxPods:            29,
+		},
+		"m5.large": {
+			InstanceType:       "m5.large",
+			Architecture:       "x86_64",
+			VCPU:               2,
+			MemoryMb:           8192,
+			NetworkPerformance: "Up to 10 Gigabit",
+			ENIs:               3,
+			IPv4PerENI:         10,
+			MaxPods:            29,
+			PricePerHour:       0.096,
+			Complete:           true,
+		},
+		"t2.micro": {
+			InstanceType: "t2.micro",
+			Architecture: "x86_64",
+			VCPU:         1,
+			MemoryMb:     1024,
+			MaxPods:      4,
+		},
+	}
+	if len(instanceTypes) != len(exp) {
+		t.Fatalf("expected %d instance types, got %d", len(exp), len(instanceTypes))
+	}
+	for k, v := range exp {
+		av, ok := instanceTypes[k]
+		if !ok {
+			t.Fatalf("%q not found", k)
+		}
+		if *av != v {
+			t.Fatalf("%q expected %+v, got %+v", k, v, *av)
+		}
+	}
+}
@@ -16,1391 +16,2193 @@ limitations under the License.
 
 // This file was generated by go generate; DO NOT EDIT
 
-// generated at 2018-12-21 00:47:11.090767 +0000 UTC
+// generated at 2026-10-19 18:13:46.655628307 +0000 UTC
 
 package ec2
 
 // InstanceType is an EC2 instance type.
 type InstanceType struct {
 	InstanceType string
+	// Architecture is the processor architecture, "x86_64" or "arm64".
+	Architecture string
 	VCPU         int64
 	MemoryMb     int64
 	GPU          int64
-	MaxPods      int64
+	// NetworkPerformance is the network performance (e.g. "Up to 10 Gigabit").
+	NetworkPerformance string
+	// ENIs is the maximum number of network interfaces.
+	ENIs int64
+	// IPv4PerENI is the maximum number of IPv4 addresses per network interface.
+	IPv4PerENI int64
+	// MaxPods is the maximum number of pods with Amazon VPC CNI plugin
+	// (see "MaxPods" function).
+	MaxPods int64
+	// PricePerHour is the On-Demand Linux price per hour in USD,
+	// in the region of the offer file. Zero if unknown.
+	PricePerHour float64
+	// Complete is true if the network interface limits and the price
+	// are known. Incomplete instance types are skipped by "Find".
+	Complete bool
 }
 
 // InstanceTypes is a map of EC2 resources.
 var InstanceTypes = map[string]*InstanceType{
-	"a1": {
-		InstanceType: "a1",
-		VCPU:         16,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
-	},
 	"a1.2xlarge": {
-		InstanceType: "a1.2xlarge",
-		VCPU:         8,
-		MemoryMb:     16384,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "a1.2xlarge",
+		Architecture:       "arm64",
+		VCPU:               8,
+		MemoryMb:           16384,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.204,
+		Complete:           true,
 	},
 	"a1.4xlarge": {
-		InstanceType: "a1.4xlarge",
-		VCPU:         16,
-		MemoryMb:     32768,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "a1.4xlarge",
+		Architecture:       "arm64",
+		VCPU:               16,
+		MemoryMb:           32768,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       0.408,
+		Complete:           true,
 	},
 	"a1.large": {
-		InstanceType: "a1.large",
-		VCPU:         2,
-		MemoryMb:     4096,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "a1.large",
+		Architecture:       "arm64",
+		VCPU:               2,
+		MemoryMb:           4096,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               3,
+		IPv4PerENI:         10,
+		MaxPods:            29,
+		PricePerHour:       0.051,
+		Complete:           true,
 	},
 	"a1.medium": {
-		InstanceType: "a1.medium",
-		VCPU:         1,
-		MemoryMb:     2048,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "a1.medium",
+		Architecture:       "arm64",
+		VCPU:               1,
+		MemoryMb:           2048,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               2,
+		IPv4PerENI:         4,
+		MaxPods:            8,
+		PricePerHour:       0.0255,
+		Complete:           true,
 	},
 	"a1.xlarge": {
-		InstanceType: "a1.xlarge",
-		VCPU:         4,
-		MemoryMb:     8192,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "a1.xlarge",
+		Architecture:       "arm64",
+		VCPU:               4,
+		MemoryMb:           8192,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.102,
+		Complete:           true,
 	},
 	"c1.medium": {
-		InstanceType: "c1.medium",
-		VCPU:         2,
-		MemoryMb:     1740,
-		GPU:          0,
-		MaxPods:      12,
+		InstanceType:       "c1.medium",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           1740,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            12,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"c1.xlarge": {
-		InstanceType: "c1.xlarge",
-		VCPU:         8,
-		MemoryMb:     7168,
-		GPU:          0,
-		MaxPods:      58,
-	},
-	"c3": {
-		InstanceType: "c3",
-		VCPU:         32,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "c1.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           7168,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"c3.2xlarge": {
-		InstanceType: "c3.2xlarge",
-		VCPU:         8,
-		MemoryMb:     15360,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "c3.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           15360,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"c3.4xlarge": {
-		InstanceType: "c3.4xlarge",
-		VCPU:         16,
-		MemoryMb:     30720,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "c3.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           30720,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"c3.8xlarge": {
-		InstanceType: "c3.8xlarge",
-		VCPU:         32,
-		MemoryMb:     61440,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "c3.8xlarge",
+		Architecture:       "x86_64",
+		VCPU:               32,
+		MemoryMb:           61440,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"c3.large": {
-		InstanceType: "c3.large",
-		VCPU:         2,
-		MemoryMb:     3840,
-		GPU:          0,
-		MaxPods:      29,
+		InstanceType:       "c3.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           3840,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            29,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"c3.xlarge": {
-		InstanceType: "c3.xlarge",
-		VCPU:         4,
-		MemoryMb:     7680,
-		GPU:          0,
-		MaxPods:      58,
-	},
-	"c4": {
-		InstanceType: "c4",
-		VCPU:         36,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "c3.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           7680,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"c4.2xlarge": {
-		InstanceType: "c4.2xlarge",
-		VCPU:         8,
-		MemoryMb:     15360,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "c4.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           15360,
+		GPU:                0,
+		NetworkPerformance: "High",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.398,
+		Complete:           true,
 	},
 	"c4.4xlarge": {
-		InstanceType: "c4.4xlarge",
-		VCPU:         16,
-		MemoryMb:     30720,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "c4.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           30720,
+		GPU:                0,
+		NetworkPerformance: "High",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       0.796,
+		Complete:           true,
 	},
 	"c4.8xlarge": {
-		InstanceType: "c4.8xlarge",
-		VCPU:         36,
-		MemoryMb:     61440,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "c4.8xlarge",
+		Architecture:       "x86_64",
+		VCPU:               36,
+		MemoryMb:           61440,
+		GPU:                0,
+		NetworkPerformance: "10 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       1.591,
+		Complete:           true,
 	},
 	"c4.large": {
-		InstanceType: "c4.large",
-		VCPU:         2,
-		MemoryMb:     3840,
-		GPU:          0,
-		MaxPods:      29,
+		InstanceType:       "c4.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           3840,
+		GPU:                0,
+		NetworkPerformance: "Moderate",
+		ENIs:               3,
+		IPv4PerENI:         10,
+		MaxPods:            29,
+		PricePerHour:       0.1,
+		Complete:           true,
 	},
 	"c4.xlarge": {
-		InstanceType: "c4.xlarge",
-		VCPU:         4,
-		MemoryMb:     7680,
-		GPU:          0,
-		MaxPods:      58,
-	},
-	"c5": {
-		InstanceType: "c5",
-		VCPU:         72,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "c4.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           7680,
+		GPU:                0,
+		NetworkPerformance: "High",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.199,
+		Complete:           true,
 	},
 	"c5.18xlarge": {
-		InstanceType: "c5.18xlarge",
-		VCPU:         72,
-		MemoryMb:     147456,
-		GPU:          0,
-		MaxPods:      737,
+		InstanceType:       "c5.18xlarge",
+		Architecture:       "x86_64",
+		VCPU:               72,
+		MemoryMb:           147456,
+		GPU:                0,
+		NetworkPerformance: "25 Gigabit",
+		ENIs:               15,
+		IPv4PerENI:         50,
+		MaxPods:            737,
+		PricePerHour:       3.06,
+		Complete:           true,
 	},
 	"c5.2xlarge": {
-		InstanceType: "c5.2xlarge",
-		VCPU:         8,
-		MemoryMb:     16384,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "c5.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           16384,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.34,
+		Complete:           true,
 	},
 	"c5.4xlarge": {
-		InstanceType: "c5.4xlarge",
-		VCPU:         16,
-		MemoryMb:     32768,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "c5.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           32768,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       0.68,
+		Complete:           true,
 	},
 	"c5.9xlarge": {
-		InstanceType: "c5.9xlarge",
-		VCPU:         36,
-		MemoryMb:     73728,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "c5.9xlarge",
+		Architecture:       "x86_64",
+		VCPU:               36,
+		MemoryMb:           73728,
+		GPU:                0,
+		NetworkPerformance: "10 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       1.53,
+		Complete:           true,
 	},
 	"c5.large": {
-		InstanceType: "c5.large",
-		VCPU:         2,
-		MemoryMb:     4096,
-		GPU:          0,
-		MaxPods:      29,
+		InstanceType:       "c5.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           4096,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               3,
+		IPv4PerENI:         10,
+		MaxPods:            29,
+		PricePerHour:       0.085,
+		Complete:           true,
 	},
 	"c5.xlarge": {
-		InstanceType: "c5.xlarge",
-		VCPU:         4,
-		MemoryMb:     8192,
-		GPU:          0,
-		MaxPods:      58,
-	},
-	"c5d": {
-		InstanceType: "c5d",
-		VCPU:         72,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "c5.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           8192,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.17,
+		Complete:           true,
 	},
 	"c5d.18xlarge": {
-		InstanceType: "c5d.18xlarge",
-		VCPU:         72,
-		MemoryMb:     147456,
-		GPU:          0,
-		MaxPods:      737,
+		InstanceType:       "c5d.18xlarge",
+		Architecture:       "x86_64",
+		VCPU:               72,
+		MemoryMb:           147456,
+		GPU:                0,
+		NetworkPerformance: "25 Gigabit",
+		ENIs:               15,
+		IPv4PerENI:         50,
+		MaxPods:            737,
+		PricePerHour:       3.456,
+		Complete:           true,
 	},
 	"c5d.2xlarge": {
-		InstanceType: "c5d.2xlarge",
-		VCPU:         8,
-		MemoryMb:     16384,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "c5d.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           16384,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.384,
+		Complete:           true,
 	},
 	"c5d.4xlarge": {
-		InstanceType: "c5d.4xlarge",
-		VCPU:         16,
-		MemoryMb:     32768,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "c5d.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           32768,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       0.768,
+		Complete:           true,
 	},
 	"c5d.9xlarge": {
-		InstanceType: "c5d.9xlarge",
-		VCPU:         36,
-		MemoryMb:     73728,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "c5d.9xlarge",
+		Architecture:       "x86_64",
+		VCPU:               36,
+		MemoryMb:           73728,
+		GPU:                0,
+		NetworkPerformance: "10 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       1.728,
+		Complete:           true,
 	},
 	"c5d.large": {
-		InstanceType: "c5d.large",
-		VCPU:         2,
-		MemoryMb:     4096,
-		GPU:          0,
-		MaxPods:      29,
+		InstanceType:       "c5d.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           4096,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               3,
+		IPv4PerENI:         10,
+		MaxPods:            29,
+		PricePerHour:       0.096,
+		Complete:           true,
 	},
 	"c5d.xlarge": {
-		InstanceType: "c5d.xlarge",
-		VCPU:         4,
-		MemoryMb:     8192,
-		GPU:          0,
-		MaxPods:      58,
-	},
-	"c5n": {
-		InstanceType: "c5n",
-		VCPU:         72,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "c5d.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           8192,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.192,
+		Complete:           true,
 	},
 	"c5n.18xlarge": {
-		InstanceType: "c5n.18xlarge",
-		VCPU:         72,
-		MemoryMb:     196608,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "c5n.18xlarge",
+		Architecture:       "x86_64",
+		VCPU:               72,
+		MemoryMb:           196608,
+		GPU:                0,
+		NetworkPerformance: "100 Gigabit",
+		ENIs:               15,
+		IPv4PerENI:         50,
+		MaxPods:            737,
+		PricePerHour:       3.888,
+		Complete:           true,
 	},
 	"c5n.2xlarge": {
-		InstanceType: "c5n.2xlarge",
-		VCPU:         8,
-		MemoryMb:     21504,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "c5n.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           21504,
+		GPU:                0,
+		NetworkPerformance: "Up to 25 Gigabit",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.432,
+		Complete:           true,
 	},
 	"c5n.4xlarge": {
-		InstanceType: "c5n.4xlarge",
-		VCPU:         16,
-		MemoryMb:     43008,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "c5n.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           43008,
+		GPU:                0,
+		NetworkPerformance: "Up to 25 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       0.864,
+		Complete:           true,
 	},
 	"c5n.9xlarge": {
-		InstanceType: "c5n.9xlarge",
-		VCPU:         36,
-		MemoryMb:     98304,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "c5n.9xlarge",
+		Architecture:       "x86_64",
+		VCPU:               36,
+		MemoryMb:           98304,
+		GPU:                0,
+		NetworkPerformance: "50 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       1.944,
+		Complete:           true,
 	},
 	"c5n.large": {
-		InstanceType: "c5n.large",
-		VCPU:         2,
-		MemoryMb:     5376,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "c5n.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           5376,
+		GPU:                0,
+		NetworkPerformance: "Up to 25 Gigabit",
+		ENIs:               3,
+		IPv4PerENI:         10,
+		MaxPods:            29,
+		PricePerHour:       0.108,
+		Complete:           true,
 	},
 	"c5n.xlarge": {
-		InstanceType: "c5n.xlarge",
-		VCPU:         4,
-		MemoryMb:     10752,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "c5n.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           10752,
+		GPU:                0,
+		NetworkPerformance: "Up to 25 Gigabit",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.216,
+		Complete:           true,
 	},
 	"cc2.8xlarge": {
-		InstanceType: "cc2.8xlarge",
-		VCPU:         32,
-		MemoryMb:     61952,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "cc2.8xlarge",
+		Architecture:       "x86_64",
+		VCPU:               32,
+		MemoryMb:           61952,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"cr1.8xlarge": {
-		InstanceType: "cr1.8xlarge",
-		VCPU:         32,
-		MemoryMb:     249856,
-		GPU:          0,
-		MaxPods:      234,
-	},
-	"d2": {
-		InstanceType: "d2",
-		VCPU:         36,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "cr1.8xlarge",
+		Architecture:       "x86_64",
+		VCPU:               32,
+		MemoryMb:           249856,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"d2.2xlarge": {
-		InstanceType: "d2.2xlarge",
-		VCPU:         8,
-		MemoryMb:     62464,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "d2.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           62464,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"d2.4xlarge": {
-		InstanceType: "d2.4xlarge",
-		VCPU:         16,
-		MemoryMb:     124928,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "d2.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           124928,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"d2.8xlarge": {
-		InstanceType: "d2.8xlarge",
-		VCPU:         36,
-		MemoryMb:     249856,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "d2.8xlarge",
+		Architecture:       "x86_64",
+		VCPU:               36,
+		MemoryMb:           249856,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"d2.xlarge": {
-		InstanceType: "d2.xlarge",
-		VCPU:         4,
-		MemoryMb:     31232,
-		GPU:          0,
-		MaxPods:      58,
-	},
-	"f1": {
-		InstanceType: "f1",
-		VCPU:         64,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "d2.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           31232,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"f1.16xlarge": {
-		InstanceType: "f1.16xlarge",
-		VCPU:         64,
-		MemoryMb:     999424,
-		GPU:          0,
-		MaxPods:      394,
+		InstanceType:       "f1.16xlarge",
+		Architecture:       "x86_64",
+		VCPU:               64,
+		MemoryMb:           999424,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            394,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"f1.2xlarge": {
-		InstanceType: "f1.2xlarge",
-		VCPU:         8,
-		MemoryMb:     124928,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "f1.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           124928,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"f1.4xlarge": {
-		InstanceType: "f1.4xlarge",
-		VCPU:         16,
-		MemoryMb:     249856,
-		GPU:          0,
-		MaxPods:      234,
-	},
-	"g2": {
-		InstanceType: "g2",
-		VCPU:         32,
-		MemoryMb:     0,
-		GPU:          4,
-		MaxPods:      0,
+		InstanceType:       "f1.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           249856,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"g2.2xlarge": {
-		InstanceType: "g2.2xlarge",
-		VCPU:         8,
-		MemoryMb:     15360,
-		GPU:          1,
-		MaxPods:      58,
+		InstanceType:       "g2.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           15360,
+		GPU:                1,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"g2.8xlarge": {
-		InstanceType: "g2.8xlarge",
-		VCPU:         32,
-		MemoryMb:     61440,
-		GPU:          4,
-		MaxPods:      234,
-	},
-	"g3": {
-		InstanceType: "g3",
-		VCPU:         64,
-		MemoryMb:     0,
-		GPU:          4,
-		MaxPods:      0,
+		InstanceType:       "g2.8xlarge",
+		Architecture:       "x86_64",
+		VCPU:               32,
+		MemoryMb:           61440,
+		GPU:                4,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"g3.16xlarge": {
-		InstanceType: "g3.16xlarge",
-		VCPU:         64,
-		MemoryMb:     499712,
-		GPU:          4,
-		MaxPods:      737,
+		InstanceType:       "g3.16xlarge",
+		Architecture:       "x86_64",
+		VCPU:               64,
+		MemoryMb:           499712,
+		GPU:                4,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            737,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"g3.4xlarge": {
-		InstanceType: "g3.4xlarge",
-		VCPU:         16,
-		MemoryMb:     124928,
-		GPU:          1,
-		MaxPods:      234,
+		InstanceType:       "g3.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           124928,
+		GPU:                1,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"g3.8xlarge": {
-		InstanceType: "g3.8xlarge",
-		VCPU:         32,
-		MemoryMb:     249856,
-		GPU:          2,
-		MaxPods:      234,
+		InstanceType:       "g3.8xlarge",
+		Architecture:       "x86_64",
+		VCPU:               32,
+		MemoryMb:           249856,
+		GPU:                2,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"g3s.xlarge": {
-		InstanceType: "g3s.xlarge",
-		VCPU:         4,
-		MemoryMb:     31232,
-		GPU:          0,
-		MaxPods:      58,
-	},
-	"h1": {
-		InstanceType: "h1",
-		VCPU:         64,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "g3s.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           31232,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"h1.16xlarge": {
-		InstanceType: "h1.16xlarge",
-		VCPU:         64,
-		MemoryMb:     262144,
-		GPU:          0,
-		MaxPods:      737,
+		InstanceType:       "h1.16xlarge",
+		Architecture:       "x86_64",
+		VCPU:               64,
+		MemoryMb:           262144,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            737,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"h1.2xlarge": {
-		InstanceType: "h1.2xlarge",
-		VCPU:         8,
-		MemoryMb:     32768,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "h1.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           32768,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"h1.4xlarge": {
-		InstanceType: "h1.4xlarge",
-		VCPU:         16,
-		MemoryMb:     65536,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "h1.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           65536,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"h1.8xlarge": {
-		InstanceType: "h1.8xlarge",
-		VCPU:         32,
-		MemoryMb:     131072,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "h1.8xlarge",
+		Architecture:       "x86_64",
+		VCPU:               32,
+		MemoryMb:           131072,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"hs1.8xlarge": {
-		InstanceType: "hs1.8xlarge",
-		VCPU:         17,
-		MemoryMb:     119808,
-		GPU:          0,
-		MaxPods:      234,
-	},
-	"i2": {
-		InstanceType: "i2",
-		VCPU:         32,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "hs1.8xlarge",
+		Architecture:       "x86_64",
+		VCPU:               17,
+		MemoryMb:           119808,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"i2.2xlarge": {
-		InstanceType: "i2.2xlarge",
-		VCPU:         8,
-		MemoryMb:     62464,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "i2.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           62464,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"i2.4xlarge": {
-		InstanceType: "i2.4xlarge",
-		VCPU:         16,
-		MemoryMb:     124928,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "i2.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           124928,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"i2.8xlarge": {
-		InstanceType: "i2.8xlarge",
-		VCPU:         32,
-		MemoryMb:     249856,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "i2.8xlarge",
+		Architecture:       "x86_64",
+		VCPU:               32,
+		MemoryMb:           249856,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"i2.xlarge": {
-		InstanceType: "i2.xlarge",
-		VCPU:         4,
-		MemoryMb:     31232,
-		GPU:          0,
-		MaxPods:      58,
-	},
-	"i3": {
-		InstanceType: "i3",
-		VCPU:         64,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "i2.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           31232,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"i3.16xlarge": {
-		InstanceType: "i3.16xlarge",
-		VCPU:         64,
-		MemoryMb:     499712,
-		GPU:          0,
-		MaxPods:      737,
+		InstanceType:       "i3.16xlarge",
+		Architecture:       "x86_64",
+		VCPU:               64,
+		MemoryMb:           499712,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            737,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"i3.2xlarge": {
-		InstanceType: "i3.2xlarge",
-		VCPU:         8,
-		MemoryMb:     62464,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "i3.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           62464,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"i3.4xlarge": {
-		InstanceType: "i3.4xlarge",
-		VCPU:         16,
-		MemoryMb:     124928,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "i3.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           124928,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"i3.8xlarge": {
-		InstanceType: "i3.8xlarge",
-		VCPU:         32,
-		MemoryMb:     249856,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "i3.8xlarge",
+		Architecture:       "x86_64",
+		VCPU:               32,
+		MemoryMb:           249856,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"i3.large": {
-		InstanceType: "i3.large",
-		VCPU:         2,
-		MemoryMb:     15616,
-		GPU:          0,
-		MaxPods:      29,
+		InstanceType:       "i3.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           15616,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            29,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"i3.metal": {
-		InstanceType: "i3.metal",
-		VCPU:         72,
-		MemoryMb:     524288,
-		GPU:          0,
-		MaxPods:      737,
+		InstanceType:       "i3.metal",
+		Architecture:       "x86_64",
+		VCPU:               72,
+		MemoryMb:           524288,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            737,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"i3.xlarge": {
-		InstanceType: "i3.xlarge",
-		VCPU:         4,
-		MemoryMb:     31232,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "i3.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           31232,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"m1.large": {
-		InstanceType: "m1.large",
-		VCPU:         2,
-		MemoryMb:     7680,
-		GPU:          0,
-		MaxPods:      29,
+		InstanceType:       "m1.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           7680,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            29,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"m1.medium": {
-		InstanceType: "m1.medium",
-		VCPU:         1,
-		MemoryMb:     3840,
-		GPU:          0,
-		MaxPods:      12,
+		InstanceType:       "m1.medium",
+		Architecture:       "x86_64",
+		VCPU:               1,
+		MemoryMb:           3840,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            12,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"m1.small": {
-		InstanceType: "m1.small",
-		VCPU:         1,
-		MemoryMb:     1740,
-		GPU:          0,
-		MaxPods:      8,
+		InstanceType:       "m1.small",
+		Architecture:       "x86_64",
+		VCPU:               1,
+		MemoryMb:           1740,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            8,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"m1.xlarge": {
-		InstanceType: "m1.xlarge",
-		VCPU:         4,
-		MemoryMb:     15360,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "m1.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           15360,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"m2.2xlarge": {
-		InstanceType: "m2.2xlarge",
-		VCPU:         4,
-		MemoryMb:     35020,
-		GPU:          0,
-		MaxPods:      118,
+		InstanceType:       "m2.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           35020,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            118,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"m2.4xlarge": {
-		InstanceType: "m2.4xlarge",
-		VCPU:         8,
-		MemoryMb:     70041,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "m2.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           70041,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"m2.xlarge": {
-		InstanceType: "m2.xlarge",
-		VCPU:         2,
-		MemoryMb:     17510,
-		GPU:          0,
-		MaxPods:      58,
-	},
-	"m3": {
-		InstanceType: "m3",
-		VCPU:         8,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "m2.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           17510,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"m3.2xlarge": {
-		InstanceType: "m3.2xlarge",
-		VCPU:         8,
-		MemoryMb:     30720,
-		GPU:          0,
-		MaxPods:      118,
+		InstanceType:       "m3.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           30720,
+		GPU:                0,
+		NetworkPerformance: "High",
+		ENIs:               4,
+		IPv4PerENI:         30,
+		MaxPods:            118,
+		PricePerHour:       0.532,
+		Complete:           true,
 	},
 	"m3.large": {
-		InstanceType: "m3.large",
-		VCPU:         2,
-		MemoryMb:     7680,
-		GPU:          0,
-		MaxPods:      29,
+		InstanceType:       "m3.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           7680,
+		GPU:                0,
+		NetworkPerformance: "Moderate",
+		ENIs:               3,
+		IPv4PerENI:         10,
+		MaxPods:            29,
+		PricePerHour:       0.133,
+		Complete:           true,
 	},
 	"m3.medium": {
-		InstanceType: "m3.medium",
-		VCPU:         1,
-		MemoryMb:     3840,
-		GPU:          0,
-		MaxPods:      12,
+		InstanceType:       "m3.medium",
+		Architecture:       "x86_64",
+		VCPU:               1,
+		MemoryMb:           3840,
+		GPU:                0,
+		NetworkPerformance: "Moderate",
+		ENIs:               2,
+		IPv4PerENI:         6,
+		MaxPods:            12,
+		PricePerHour:       0.067,
+		Complete:           true,
 	},
 	"m3.xlarge": {
-		InstanceType: "m3.xlarge",
-		VCPU:         4,
-		MemoryMb:     15360,
-		GPU:          0,
-		MaxPods:      58,
-	},
-	"m4": {
-		InstanceType: "m4",
-		VCPU:         40,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "m3.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           15360,
+		GPU:                0,
+		NetworkPerformance: "High",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.266,
+		Complete:           true,
 	},
 	"m4.10xlarge": {
-		InstanceType: "m4.10xlarge",
-		VCPU:         40,
-		MemoryMb:     163840,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "m4.10xlarge",
+		Architecture:       "x86_64",
+		VCPU:               40,
+		MemoryMb:           163840,
+		GPU:                0,
+		NetworkPerformance: "10 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       2,
+		Complete:           true,
 	},
 	"m4.16xlarge": {
-		InstanceType: "m4.16xlarge",
-		VCPU:         64,
-		MemoryMb:     262144,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "m4.16xlarge",
+		Architecture:       "x86_64",
+		VCPU:               64,
+		MemoryMb:           262144,
+		GPU:                0,
+		NetworkPerformance: "25 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       3.2,
+		Complete:           true,
 	},
 	"m4.2xlarge": {
-		InstanceType: "m4.2xlarge",
-		VCPU:         8,
-		MemoryMb:     32768,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "m4.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           32768,
+		GPU:                0,
+		NetworkPerformance: "High",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.4,
+		Complete:           true,
 	},
 	"m4.4xlarge": {
-		InstanceType: "m4.4xlarge",
-		VCPU:         16,
-		MemoryMb:     65536,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "m4.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           65536,
+		GPU:                0,
+		NetworkPerformance: "High",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       0.8,
+		Complete:           true,
 	},
 	"m4.large": {
-		InstanceType: "m4.large",
-		VCPU:         2,
-		MemoryMb:     8192,
-		GPU:          0,
-		MaxPods:      20,
+		InstanceType:       "m4.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           8192,
+		GPU:                0,
+		NetworkPerformance: "Moderate",
+		ENIs:               2,
+		IPv4PerENI:         10,
+		MaxPods:            20,
+		PricePerHour:       0.1,
+		Complete:           true,
 	},
 	"m4.xlarge": {
-		InstanceType: "m4.xlarge",
-		VCPU:         4,
-		MemoryMb:     16384,
-		GPU:          0,
-		MaxPods:      58,
-	},
-	"m5": {
-		InstanceType: "m5",
-		VCPU:         96,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "m4.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           16384,
+		GPU:                0,
+		NetworkPerformance: "High",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.2,
+		Complete:           true,
 	},
 	"m5.12xlarge": {
-		InstanceType: "m5.12xlarge",
-		VCPU:         48,
-		MemoryMb:     196608,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "m5.12xlarge",
+		Architecture:       "x86_64",
+		VCPU:               48,
+		MemoryMb:           196608,
+		GPU:                0,
+		NetworkPerformance: "10 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       2.304,
+		Complete:           true,
 	},
 	"m5.24xlarge": {
-		InstanceType: "m5.24xlarge",
-		VCPU:         96,
-		MemoryMb:     393216,
-		GPU:          0,
-		MaxPods:      737,
+		InstanceType:       "m5.24xlarge",
+		Architecture:       "x86_64",
+		VCPU:               96,
+		MemoryMb:           393216,
+		GPU:                0,
+		NetworkPerformance: "25 Gigabit",
+		ENIs:               15,
+		IPv4PerENI:         50,
+		MaxPods:            737,
+		PricePerHour:       4.608,
+		Complete:           true,
 	},
 	"m5.2xlarge": {
-		InstanceType: "m5.2xlarge",
-		VCPU:         8,
-		MemoryMb:     32768,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "m5.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           32768,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.384,
+		Complete:           true,
 	},
 	"m5.4xlarge": {
-		InstanceType: "m5.4xlarge",
-		VCPU:         16,
-		MemoryMb:     65536,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "m5.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           65536,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       0.768,
+		Complete:           true,
 	},
 	"m5.large": {
-		InstanceType: "m5.large",
-		VCPU:         2,
-		MemoryMb:     8192,
-		GPU:          0,
-		MaxPods:      29,
+		InstanceType:       "m5.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           8192,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               3,
+		IPv4PerENI:         10,
+		MaxPods:            29,
+		PricePerHour:       0.096,
+		Complete:           true,
 	},
 	"m5.xlarge": {
-		InstanceType: "m5.xlarge",
-		VCPU:         4,
-		MemoryMb:     16384,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "m5.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           16384,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.192,
+		Complete:           true,
 	},
 	"m5a.12xlarge": {
-		InstanceType: "m5a.12xlarge",
-		VCPU:         48,
-		MemoryMb:     196608,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "m5a.12xlarge",
+		Architecture:       "x86_64",
+		VCPU:               48,
+		MemoryMb:           196608,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"m5a.24xlarge": {
-		InstanceType: "m5a.24xlarge",
-		VCPU:         96,
-		MemoryMb:     393216,
-		GPU:          0,
-		MaxPods:      737,
+		InstanceType:       "m5a.24xlarge",
+		Architecture:       "x86_64",
+		VCPU:               96,
+		MemoryMb:           393216,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            737,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"m5a.2xlarge": {
-		InstanceType: "m5a.2xlarge",
-		VCPU:         8,
-		MemoryMb:     32768,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "m5a.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           32768,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"m5a.4xlarge": {
-		InstanceType: "m5a.4xlarge",
-		VCPU:         16,
-		MemoryMb:     65536,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "m5a.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           65536,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"m5a.large": {
-		InstanceType: "m5a.large",
-		VCPU:         2,
-		MemoryMb:     8192,
-		GPU:          0,
-		MaxPods:      29,
+		InstanceType:       "m5a.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           8192,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            29,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"m5a.xlarge": {
-		InstanceType: "m5a.xlarge",
-		VCPU:         4,
-		MemoryMb:     16384,
-		GPU:          0,
-		MaxPods:      58,
-	},
-	"m5d": {
-		InstanceType: "m5d",
-		VCPU:         96,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "m5a.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           16384,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"m5d.12xlarge": {
-		InstanceType: "m5d.12xlarge",
-		VCPU:         48,
-		MemoryMb:     196608,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "m5d.12xlarge",
+		Architecture:       "x86_64",
+		VCPU:               48,
+		MemoryMb:           196608,
+		GPU:                0,
+		NetworkPerformance: "10 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       2.712,
+		Complete:           true,
 	},
 	"m5d.24xlarge": {
-		InstanceType: "m5d.24xlarge",
-		VCPU:         96,
-		MemoryMb:     393216,
-		GPU:          0,
-		MaxPods:      737,
+		InstanceType:       "m5d.24xlarge",
+		Architecture:       "x86_64",
+		VCPU:               96,
+		MemoryMb:           393216,
+		GPU:                0,
+		NetworkPerformance: "25 Gigabit",
+		ENIs:               15,
+		IPv4PerENI:         50,
+		MaxPods:            737,
+		PricePerHour:       5.424,
+		Complete:           true,
 	},
 	"m5d.2xlarge": {
-		InstanceType: "m5d.2xlarge",
-		VCPU:         8,
-		MemoryMb:     32768,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "m5d.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           32768,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.452,
+		Complete:           true,
 	},
 	"m5d.4xlarge": {
-		InstanceType: "m5d.4xlarge",
-		VCPU:         16,
-		MemoryMb:     65536,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "m5d.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           65536,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       0.904,
+		Complete:           true,
 	},
 	"m5d.large": {
-		InstanceType: "m5d.large",
-		VCPU:         2,
-		MemoryMb:     8192,
-		GPU:          0,
-		MaxPods:      29,
+		InstanceType:       "m5d.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           8192,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               3,
+		IPv4PerENI:         10,
+		MaxPods:            29,
+		PricePerHour:       0.113,
+		Complete:           true,
 	},
 	"m5d.xlarge": {
-		InstanceType: "m5d.xlarge",
-		VCPU:         4,
-		MemoryMb:     16384,
-		GPU:          0,
-		MaxPods:      58,
-	},
-	"p2": {
-		InstanceType: "p2",
-		VCPU:         64,
-		MemoryMb:     0,
-		GPU:          16,
-		MaxPods:      0,
+		InstanceType:       "m5d.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           16384,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.226,
+		Complete:           true,
 	},
 	"p2.16xlarge": {
-		InstanceType: "p2.16xlarge",
-		VCPU:         64,
-		MemoryMb:     786432,
-		GPU:          16,
-		MaxPods:      234,
+		InstanceType:       "p2.16xlarge",
+		Architecture:       "x86_64",
+		VCPU:               64,
+		MemoryMb:           786432,
+		GPU:                16,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"p2.8xlarge": {
-		InstanceType: "p2.8xlarge",
-		VCPU:         32,
-		MemoryMb:     499712,
-		GPU:          8,
-		MaxPods:      234,
+		InstanceType:       "p2.8xlarge",
+		Architecture:       "x86_64",
+		VCPU:               32,
+		MemoryMb:           499712,
+		GPU:                8,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"p2.xlarge": {
-		InstanceType: "p2.xlarge",
-		VCPU:         4,
-		MemoryMb:     62464,
-		GPU:          1,
-		MaxPods:      58,
-	},
-	"p3": {
-		InstanceType: "p3",
-		VCPU:         64,
-		MemoryMb:     499712,
-		GPU:          8,
-		MaxPods:      0,
+		InstanceType:       "p2.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           62464,
+		GPU:                1,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"p3.16xlarge": {
-		InstanceType: "p3.16xlarge",
-		VCPU:         64,
-		MemoryMb:     499712,
-		GPU:          8,
-		MaxPods:      234,
+		InstanceType:       "p3.16xlarge",
+		Architecture:       "x86_64",
+		VCPU:               64,
+		MemoryMb:           499712,
+		GPU:                8,
+		NetworkPerformance: "25 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       24.48,
+		Complete:           true,
 	},
 	"p3.2xlarge": {
-		InstanceType: "p3.2xlarge",
-		VCPU:         8,
-		MemoryMb:     62464,
-		GPU:          1,
-		MaxPods:      58,
+		InstanceType:       "p3.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           62464,
+		GPU:                1,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       3.06,
+		Complete:           true,
 	},
 	"p3.8xlarge": {
-		InstanceType: "p3.8xlarge",
-		VCPU:         32,
-		MemoryMb:     249856,
-		GPU:          4,
-		MaxPods:      234,
-	},
-	"p3dn": {
-		InstanceType: "p3dn",
-		VCPU:         96,
-		MemoryMb:     786432,
-		GPU:          8,
-		MaxPods:      0,
+		InstanceType:       "p3.8xlarge",
+		Architecture:       "x86_64",
+		VCPU:               32,
+		MemoryMb:           249856,
+		GPU:                4,
+		NetworkPerformance: "10 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       12.24,
+		Complete:           true,
 	},
 	"p3dn.24xlarge": {
-		InstanceType: "p3dn.24xlarge",
-		VCPU:         96,
-		MemoryMb:     786432,
-		GPU:          8,
-		MaxPods:      0,
-	},
-	"r3": {
-		InstanceType: "r3",
-		VCPU:         32,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "p3dn.24xlarge",
+		Architecture:       "x86_64",
+		VCPU:               96,
+		MemoryMb:           786432,
+		GPU:                8,
+		NetworkPerformance: "100 Gigabit",
+		ENIs:               15,
+		IPv4PerENI:         50,
+		MaxPods:            737,
+		PricePerHour:       31.212,
+		Complete:           true,
 	},
 	"r3.2xlarge": {
-		InstanceType: "r3.2xlarge",
-		VCPU:         8,
-		MemoryMb:     62464,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "r3.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           62464,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r3.4xlarge": {
-		InstanceType: "r3.4xlarge",
-		VCPU:         16,
-		MemoryMb:     124928,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "r3.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           124928,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r3.8xlarge": {
-		InstanceType: "r3.8xlarge",
-		VCPU:         32,
-		MemoryMb:     249856,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "r3.8xlarge",
+		Architecture:       "x86_64",
+		VCPU:               32,
+		MemoryMb:           249856,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r3.large": {
-		InstanceType: "r3.large",
-		VCPU:         2,
-		MemoryMb:     15616,
-		GPU:          0,
-		MaxPods:      29,
+		InstanceType:       "r3.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           15616,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            29,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r3.xlarge": {
-		InstanceType: "r3.xlarge",
-		VCPU:         4,
-		MemoryMb:     31232,
-		GPU:          0,
-		MaxPods:      58,
-	},
-	"r4": {
-		InstanceType: "r4",
-		VCPU:         64,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "r3.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           31232,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r4.16xlarge": {
-		InstanceType: "r4.16xlarge",
-		VCPU:         64,
-		MemoryMb:     499712,
-		GPU:          0,
-		MaxPods:      737,
+		InstanceType:       "r4.16xlarge",
+		Architecture:       "x86_64",
+		VCPU:               64,
+		MemoryMb:           499712,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            737,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r4.2xlarge": {
-		InstanceType: "r4.2xlarge",
-		VCPU:         8,
-		MemoryMb:     62464,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "r4.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           62464,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r4.4xlarge": {
-		InstanceType: "r4.4xlarge",
-		VCPU:         16,
-		MemoryMb:     124928,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "r4.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           124928,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r4.8xlarge": {
-		InstanceType: "r4.8xlarge",
-		VCPU:         32,
-		MemoryMb:     249856,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "r4.8xlarge",
+		Architecture:       "x86_64",
+		VCPU:               32,
+		MemoryMb:           249856,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r4.large": {
-		InstanceType: "r4.large",
-		VCPU:         2,
-		MemoryMb:     15616,
-		GPU:          0,
-		MaxPods:      29,
+		InstanceType:       "r4.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           15616,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            29,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r4.xlarge": {
-		InstanceType: "r4.xlarge",
-		VCPU:         4,
-		MemoryMb:     31232,
-		GPU:          0,
-		MaxPods:      58,
-	},
-	"r5": {
-		InstanceType: "r5",
-		VCPU:         96,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "r4.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           31232,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r5.12xlarge": {
-		InstanceType: "r5.12xlarge",
-		VCPU:         48,
-		MemoryMb:     393216,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "r5.12xlarge",
+		Architecture:       "x86_64",
+		VCPU:               48,
+		MemoryMb:           393216,
+		GPU:                0,
+		NetworkPerformance: "10 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       3.024,
+		Complete:           true,
 	},
 	"r5.24xlarge": {
-		InstanceType: "r5.24xlarge",
-		VCPU:         96,
-		MemoryMb:     786432,
-		GPU:          0,
-		MaxPods:      737,
+		InstanceType:       "r5.24xlarge",
+		Architecture:       "x86_64",
+		VCPU:               96,
+		MemoryMb:           786432,
+		GPU:                0,
+		NetworkPerformance: "25 Gigabit",
+		ENIs:               15,
+		IPv4PerENI:         50,
+		MaxPods:            737,
+		PricePerHour:       6.048,
+		Complete:           true,
 	},
 	"r5.2xlarge": {
-		InstanceType: "r5.2xlarge",
-		VCPU:         8,
-		MemoryMb:     65536,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "r5.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           65536,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.504,
+		Complete:           true,
 	},
 	"r5.4xlarge": {
-		InstanceType: "r5.4xlarge",
-		VCPU:         16,
-		MemoryMb:     131072,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "r5.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           131072,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       1.008,
+		Complete:           true,
 	},
 	"r5.large": {
-		InstanceType: "r5.large",
-		VCPU:         2,
-		MemoryMb:     16384,
-		GPU:          0,
-		MaxPods:      29,
+		InstanceType:       "r5.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           16384,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               3,
+		IPv4PerENI:         10,
+		MaxPods:            29,
+		PricePerHour:       0.126,
+		Complete:           true,
 	},
 	"r5.xlarge": {
-		InstanceType: "r5.xlarge",
-		VCPU:         4,
-		MemoryMb:     32768,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "r5.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           32768,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0.252,
+		Complete:           true,
 	},
 	"r5a.12xlarge": {
-		InstanceType: "r5a.12xlarge",
-		VCPU:         48,
-		MemoryMb:     393216,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "r5a.12xlarge",
+		Architecture:       "x86_64",
+		VCPU:               48,
+		MemoryMb:           393216,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r5a.24xlarge": {
-		InstanceType: "r5a.24xlarge",
-		VCPU:         96,
-		MemoryMb:     786432,
-		GPU:          0,
-		MaxPods:      737,
+		InstanceType:       "r5a.24xlarge",
+		Architecture:       "x86_64",
+		VCPU:               96,
+		MemoryMb:           786432,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            737,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r5a.2xlarge": {
-		InstanceType: "r5a.2xlarge",
-		VCPU:         8,
-		MemoryMb:     65536,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "r5a.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           65536,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r5a.4xlarge": {
-		InstanceType: "r5a.4xlarge",
-		VCPU:         16,
-		MemoryMb:     131072,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "r5a.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           131072,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r5a.large": {
-		InstanceType: "r5a.large",
-		VCPU:         2,
-		MemoryMb:     16384,
-		GPU:          0,
-		MaxPods:      29,
+		InstanceType:       "r5a.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           16384,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            29,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r5a.xlarge": {
-		InstanceType: "r5a.xlarge",
-		VCPU:         4,
-		MemoryMb:     32768,
-		GPU:          0,
-		MaxPods:      58,
-	},
-	"r5d": {
-		InstanceType: "r5d",
-		VCPU:         96,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "r5a.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           32768,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r5d.12xlarge": {
-		InstanceType: "r5d.12xlarge",
-		VCPU:         48,
-		MemoryMb:     393216,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "r5d.12xlarge",
+		Architecture:       "x86_64",
+		VCPU:               48,
+		MemoryMb:           393216,
+		GPU:                0,
+		NetworkPerformance: "10 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r5d.24xlarge": {
-		InstanceType: "r5d.24xlarge",
-		VCPU:         96,
-		MemoryMb:     786432,
-		GPU:          0,
-		MaxPods:      737,
+		InstanceType:       "r5d.24xlarge",
+		Architecture:       "x86_64",
+		VCPU:               96,
+		MemoryMb:           786432,
+		GPU:                0,
+		NetworkPerformance: "25 Gigabit",
+		ENIs:               15,
+		IPv4PerENI:         50,
+		MaxPods:            737,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r5d.2xlarge": {
-		InstanceType: "r5d.2xlarge",
-		VCPU:         8,
-		MemoryMb:     65536,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "r5d.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           65536,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r5d.4xlarge": {
-		InstanceType: "r5d.4xlarge",
-		VCPU:         16,
-		MemoryMb:     131072,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "r5d.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           131072,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               8,
+		IPv4PerENI:         30,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r5d.large": {
-		InstanceType: "r5d.large",
-		VCPU:         2,
-		MemoryMb:     16384,
-		GPU:          0,
-		MaxPods:      29,
+		InstanceType:       "r5d.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           16384,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               3,
+		IPv4PerENI:         10,
+		MaxPods:            29,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"r5d.xlarge": {
-		InstanceType: "r5d.xlarge",
-		VCPU:         4,
-		MemoryMb:     32768,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "r5d.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           32768,
+		GPU:                0,
+		NetworkPerformance: "Up to 10 Gigabit",
+		ENIs:               4,
+		IPv4PerENI:         15,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"t1.micro": {
-		InstanceType: "t1.micro",
-		VCPU:         1,
-		MemoryMb:     627,
-		GPU:          0,
-		MaxPods:      4,
+		InstanceType:       "t1.micro",
+		Architecture:       "x86_64",
+		VCPU:               1,
+		MemoryMb:           627,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            4,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"t2.2xlarge": {
-		InstanceType: "t2.2xlarge",
-		VCPU:         8,
-		MemoryMb:     32768,
-		GPU:          0,
-		MaxPods:      44,
+		InstanceType:       "t2.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           32768,
+		GPU:                0,
+		NetworkPerformance: "Moderate",
+		ENIs:               3,
+		IPv4PerENI:         15,
+		MaxPods:            44,
+		PricePerHour:       0.3712,
+		Complete:           true,
 	},
 	"t2.large": {
-		InstanceType: "t2.large",
-		VCPU:         2,
-		MemoryMb:     8192,
-		GPU:          0,
-		MaxPods:      35,
+		InstanceType:       "t2.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           8192,
+		GPU:                0,
+		NetworkPerformance: "Moderate",
+		ENIs:               3,
+		IPv4PerENI:         12,
+		MaxPods:            35,
+		PricePerHour:       0.0928,
+		Complete:           true,
 	},
 	"t2.medium": {
-		InstanceType: "t2.medium",
-		VCPU:         2,
-		MemoryMb:     4096,
-		GPU:          0,
-		MaxPods:      17,
+		InstanceType:       "t2.medium",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           4096,
+		GPU:                0,
+		NetworkPerformance: "Low to Moderate",
+		ENIs:               3,
+		IPv4PerENI:         6,
+		MaxPods:            17,
+		PricePerHour:       0.0464,
+		Complete:           true,
 	},
 	"t2.micro": {
-		InstanceType: "t2.micro",
-		VCPU:         1,
-		MemoryMb:     1024,
-		GPU:          0,
-		MaxPods:      4,
+		InstanceType:       "t2.micro",
+		Architecture:       "x86_64",
+		VCPU:               1,
+		MemoryMb:           1024,
+		GPU:                0,
+		NetworkPerformance: "Low to Moderate",
+		ENIs:               2,
+		IPv4PerENI:         2,
+		MaxPods:            4,
+		PricePerHour:       0.0116,
+		Complete:           true,
 	},
 	"t2.nano": {
-		InstanceType: "t2.nano",
-		VCPU:         1,
-		MemoryMb:     512,
-		GPU:          0,
-		MaxPods:      4,
+		InstanceType:       "t2.nano",
+		Architecture:       "x86_64",
+		VCPU:               1,
+		MemoryMb:           512,
+		GPU:                0,
+		NetworkPerformance: "Low to Moderate",
+		ENIs:               2,
+		IPv4PerENI:         2,
+		MaxPods:            4,
+		PricePerHour:       0.0058,
+		Complete:           true,
 	},
 	"t2.small": {
-		InstanceType: "t2.small",
-		VCPU:         1,
-		MemoryMb:     2048,
-		GPU:          0,
-		MaxPods:      8,
+		InstanceType:       "t2.small",
+		Architecture:       "x86_64",
+		VCPU:               1,
+		MemoryMb:           2048,
+		GPU:                0,
+		NetworkPerformance: "Low to Moderate",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            8,
+		PricePerHour:       0.023,
+		Complete:           false,
 	},
 	"t2.xlarge": {
-		InstanceType: "t2.xlarge",
-		VCPU:         4,
-		MemoryMb:     16384,
-		GPU:          0,
-		MaxPods:      44,
+		InstanceType:       "t2.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           16384,
+		GPU:                0,
+		NetworkPerformance: "Moderate",
+		ENIs:               3,
+		IPv4PerENI:         15,
+		MaxPods:            44,
+		PricePerHour:       0.1856,
+		Complete:           true,
 	},
 	"t3.2xlarge": {
-		InstanceType: "t3.2xlarge",
-		VCPU:         8,
-		MemoryMb:     32768,
-		GPU:          0,
-		MaxPods:      44,
+		InstanceType:       "t3.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           32768,
+		GPU:                0,
+		NetworkPerformance: "Up to 5 Gigabit",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            44,
+		PricePerHour:       0.3328,
+		Complete:           false,
 	},
 	"t3.large": {
-		InstanceType: "t3.large",
-		VCPU:         2,
-		MemoryMb:     8192,
-		GPU:          0,
-		MaxPods:      35,
+		InstanceType:       "t3.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           8192,
+		GPU:                0,
+		NetworkPerformance: "Up to 5 Gigabit",
+		ENIs:               3,
+		IPv4PerENI:         12,
+		MaxPods:            35,
+		PricePerHour:       0.0832,
+		Complete:           true,
 	},
 	"t3.medium": {
-		InstanceType: "t3.medium",
-		VCPU:         2,
-		MemoryMb:     4096,
-		GPU:          0,
-		MaxPods:      17,
+		InstanceType:       "t3.medium",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           4096,
+		GPU:                0,
+		NetworkPerformance: "Up to 5 Gigabit",
+		ENIs:               3,
+		IPv4PerENI:         6,
+		MaxPods:            17,
+		PricePerHour:       0.0416,
+		Complete:           true,
 	},
 	"t3.micro": {
-		InstanceType: "t3.micro",
-		VCPU:         2,
-		MemoryMb:     1024,
-		GPU:          0,
-		MaxPods:      4,
+		InstanceType:       "t3.micro",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           1024,
+		GPU:                0,
+		NetworkPerformance: "Up to 5 Gigabit",
+		ENIs:               2,
+		IPv4PerENI:         2,
+		MaxPods:            4,
+		PricePerHour:       0.0104,
+		Complete:           true,
 	},
 	"t3.nano": {
-		InstanceType: "t3.nano",
-		VCPU:         2,
-		MemoryMb:     512,
-		GPU:          0,
-		MaxPods:      4,
+		InstanceType:       "t3.nano",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           512,
+		GPU:                0,
+		NetworkPerformance: "Up to 5 Gigabit",
+		ENIs:               2,
+		IPv4PerENI:         2,
+		MaxPods:            4,
+		PricePerHour:       0.0052,
+		Complete:           true,
 	},
 	"t3.small": {
-		InstanceType: "t3.small",
-		VCPU:         2,
-		MemoryMb:     2048,
-		GPU:          0,
-		MaxPods:      8,
+		InstanceType:       "t3.small",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           2048,
+		GPU:                0,
+		NetworkPerformance: "Up to 5 Gigabit",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            8,
+		PricePerHour:       0.0208,
+		Complete:           false,
 	},
 	"t3.xlarge": {
-		InstanceType: "t3.xlarge",
-		VCPU:         4,
-		MemoryMb:     16384,
-		GPU:          0,
-		MaxPods:      44,
-	},
-	"u-12tb1": {
-		InstanceType: "u-12tb1",
-		VCPU:         448,
-		MemoryMb:     12582912,
-		GPU:          0,
-		MaxPods:      0,
-	},
-	"u-6tb1": {
-		InstanceType: "u-6tb1",
-		VCPU:         448,
-		MemoryMb:     6291456,
-		GPU:          0,
-		MaxPods:      0,
-	},
-	"u-9tb1": {
-		InstanceType: "u-9tb1",
-		VCPU:         448,
-		MemoryMb:     9437184,
-		GPU:          0,
-		MaxPods:      0,
-	},
-	"x1": {
-		InstanceType: "x1",
-		VCPU:         128,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "t3.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           16384,
+		GPU:                0,
+		NetworkPerformance: "Up to 5 Gigabit",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            44,
+		PricePerHour:       0.1664,
+		Complete:           false,
 	},
 	"x1.16xlarge": {
-		InstanceType: "x1.16xlarge",
-		VCPU:         64,
-		MemoryMb:     999424,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "x1.16xlarge",
+		Architecture:       "x86_64",
+		VCPU:               64,
+		MemoryMb:           999424,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"x1.32xlarge": {
-		InstanceType: "x1.32xlarge",
-		VCPU:         128,
-		MemoryMb:     1998848,
-		GPU:          0,
-		MaxPods:      234,
-	},
-	"x1e": {
-		InstanceType: "x1e",
-		VCPU:         128,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "x1.32xlarge",
+		Architecture:       "x86_64",
+		VCPU:               128,
+		MemoryMb:           1998848,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"x1e.16xlarge": {
-		InstanceType: "x1e.16xlarge",
-		VCPU:         64,
-		MemoryMb:     1998848,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "x1e.16xlarge",
+		Architecture:       "x86_64",
+		VCPU:               64,
+		MemoryMb:           1998848,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"x1e.2xlarge": {
-		InstanceType: "x1e.2xlarge",
-		VCPU:         8,
-		MemoryMb:     249856,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "x1e.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           249856,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"x1e.32xlarge": {
-		InstanceType: "x1e.32xlarge",
-		VCPU:         128,
-		MemoryMb:     3997696,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "x1e.32xlarge",
+		Architecture:       "x86_64",
+		VCPU:               128,
+		MemoryMb:           3997696,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"x1e.4xlarge": {
-		InstanceType: "x1e.4xlarge",
-		VCPU:         16,
-		MemoryMb:     499712,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "x1e.4xlarge",
+		Architecture:       "x86_64",
+		VCPU:               16,
+		MemoryMb:           499712,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"x1e.8xlarge": {
-		InstanceType: "x1e.8xlarge",
-		VCPU:         32,
-		MemoryMb:     999424,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "x1e.8xlarge",
+		Architecture:       "x86_64",
+		VCPU:               32,
+		MemoryMb:           999424,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"x1e.xlarge": {
-		InstanceType: "x1e.xlarge",
-		VCPU:         4,
-		MemoryMb:     124928,
-		GPU:          0,
-		MaxPods:      29,
-	},
-	"z1d": {
-		InstanceType: "z1d",
-		VCPU:         48,
-		MemoryMb:     0,
-		GPU:          0,
-		MaxPods:      0,
+		InstanceType:       "x1e.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           124928,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            29,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"z1d.12xlarge": {
-		InstanceType: "z1d.12xlarge",
-		VCPU:         48,
-		MemoryMb:     393216,
-		GPU:          0,
-		MaxPods:      737,
+		InstanceType:       "z1d.12xlarge",
+		Architecture:       "x86_64",
+		VCPU:               48,
+		MemoryMb:           393216,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            737,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"z1d.2xlarge": {
-		InstanceType: "z1d.2xlarge",
-		VCPU:         8,
-		MemoryMb:     65536,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "z1d.2xlarge",
+		Architecture:       "x86_64",
+		VCPU:               8,
+		MemoryMb:           65536,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"z1d.3xlarge": {
-		InstanceType: "z1d.3xlarge",
-		VCPU:         12,
-		MemoryMb:     98304,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "z1d.3xlarge",
+		Architecture:       "x86_64",
+		VCPU:               12,
+		MemoryMb:           98304,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"z1d.6xlarge": {
-		InstanceType: "z1d.6xlarge",
-		VCPU:         24,
-		MemoryMb:     196608,
-		GPU:          0,
-		MaxPods:      234,
+		InstanceType:       "z1d.6xlarge",
+		Architecture:       "x86_64",
+		VCPU:               24,
+		MemoryMb:           196608,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            234,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"z1d.large": {
-		InstanceType: "z1d.large",
-		VCPU:         2,
-		MemoryMb:     16384,
-		GPU:          0,
-		MaxPods:      29,
+		InstanceType:       "z1d.large",
+		Architecture:       "x86_64",
+		VCPU:               2,
+		MemoryMb:           16384,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            29,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 	"z1d.xlarge": {
-		InstanceType: "z1d.xlarge",
-		VCPU:         4,
-		MemoryMb:     32768,
-		GPU:          0,
-		MaxPods:      58,
+		InstanceType:       "z1d.xlarge",
+		Architecture:       "x86_64",
+		VCPU:               4,
+		MemoryMb:           32768,
+		GPU:                0,
+		NetworkPerformance: "",
+		ENIs:               0,
+		IPv4PerENI:         0,
+		MaxPods:            58,
+		PricePerHour:       0,
+		Complete:           false,
 	},
 }
@@ -0,0 +1,79 @@
+package ec2
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MaxPods returns the maximum number of pods with Amazon VPC CNI plugin,
+// where each pod uses a secondary IPv4 address of an ENI, plus 2 pods
+// ("aws-node" and "kube-proxy") with the host network. It returns 0
+// if the network interface limits are unknown.
+// https://github.com/awslabs/amazon-eks-ami/blob/master/files/eni-max-pods.txt
+func MaxPods(enis, ipv4PerENI int64) int64 {
+	if enis <= 0 || ipv4PerENI <= 0 {
+		return 0
+	}
+	return enis*(ipv4PerENI-1) + 2
+}
+
+// GoArch returns the architecture of the instance type in Go and
+// release artifact naming (e.g. "linux-amd64"), "amd64" or "arm64".
+// It returns "amd64" if the instance type is unknown.
+func GoArch(instanceType string) string {
+	if v, ok := InstanceTypes[instanceType]; ok && v.Architecture == "arm64" {
+		return "arm64"
+	}
+	return "amd64"
+}
+
+// Query defines the minimum requirements of an instance type.
+// Zero values match any instance type.
+type Query struct {
+	// Architecture is the processor architecture, "x86_64" or "arm64".
+	Architecture string
+	MinVCPU      int64
+	MinMemoryMb  int64
+	MinGPU       int64
+	MinPods      int64
+}
+
+// Match returns true if the instance type meets the requirements.
+func (q Query) Match(v *InstanceType) bool {
+	if q.Architecture != "" && v.Architecture != q.Architecture {
+		return false
+	}
+	return v.VCPU >= q.MinVCPU &&
+		v.MemoryMb >= q.MinMemoryMb &&
+		v.GPU >= q.MinGPU &&
+		v.MaxPods >= q.MinPods
+}
+
+// Find returns the complete instance types that meet the requirements,
+// sorted by price per hour, and then by name. Instance types of unknown
+// network interface limits or price are skipped (see "Complete").
+func Find(q Query) []*InstanceType {
+	var vs []*InstanceType
+	for _, v := range InstanceTypes {
+		if v.Complete && q.Match(v) {
+			vs = append(vs, v)
+		}
+	}
+	sort.Slice(vs, func(i, j int) bool {
+		if vs[i].PricePerHour == vs[j].PricePerHour {
+			return vs[i].InstanceType < vs[j].InstanceType
+		}
+		return vs[i].PricePerHour < vs[j].PricePerHour
+	})
+	return vs
+}
+
+// Cheapest returns the cheapest complete instance type
+// that meets the requirements.
+func Cheapest(q Query) (*InstanceType, error) {
+	vs := Find(q)
+	if len(vs) == 0 {
+		return nil, fmt.Errorf("no complete instance type found for %+v", q)
+	}
+	return vs[0], nil
+}
@@ -0,0 +1,129 @@
+package ec2
+
+import "testing"
+
+func TestMaxPods(t *testing.T) {
+	tests := []struct {
+		enis, ips int64
+		pods      int64
+	}{
+		{0, 0, 0},
+		{3, 0, 0},
+		{2, 2, 4},
+		{3, 10, 29},
+		{4, 15, 58},
+		{8, 30, 234},
+		{15, 50, 737},
+	}
+	for i, tt := range tests {
+		if pods := MaxPods(tt.enis, tt.ips); pods != tt.pods {
+			t.Fatalf("#%d: expected %d, got %d", i, tt.pods, pods)
+		}
+	}
+}
+
+func TestInstanceTypes(t *testing.T) {
+	for k, v := range InstanceTypes {
+		if k != v.InstanceType {
+			t.Fatalf("key %q != InstanceType %q", k, v.InstanceType)
+		}
+		if v.Architecture != "x86_64" && v.Architecture != "arm64" {
+			t.Fatalf("%q unexpected Architecture %q", k, v.Architecture)
+		}
+		if v.ENIs > 0 && MaxPods(v.ENIs, v.IPv4PerENI) != v.MaxPods {
+			t.Fatalf("%q expected MaxPods %d, got %d", k, MaxPods(v.ENIs, v.IPv4PerENI), v.MaxPods)
+		}
+		if complete := v.ENIs > 0 && v.IPv4PerENI > 0 && v.PricePerHour > 0; complete != v.Complete {
+			t.Fatalf("%q expected Complete %v, got %v", k, complete, v.Complete)
+		}
+	}
+}
+
+func TestGoArch(t *testing.T) {
+	tests := map[string]string{
+		"m5.large": "amd64",
+		"a1.large": "arm64",
+		"unknown":  "amd64",
+	}
+	for tp, exp := range tests {
+		if arch := GoArch(tp); arch != exp {
+			t.Fatalf("%q expected %q, got %q", tp, exp, arch)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	v := &InstanceType{
+		InstanceType: "m5.large",
+		Architecture: "x86_64",
+		VCPU:         2,
+		MemoryMb:     8192,
+		MaxPods:      29,
+	}
+	tests := []struct {
+		q     Query
+		match bool
+	}{
+		{Query{}, true},
+		{Query{Architecture: "x86_64", MinVCPU: 2, MinMemoryMb: 8192, MinPods: 29}, true},
+		{Query{Architecture: "arm64"}, false},
+		{Query{MinVCPU: 4}, false},
+		{Query{MinMemoryMb: 16384}, false},
+		{Query{MinGPU: 1}, false},
+		{Query{MinPods: 30}, false},
+	}
+	for i, tt := range tests {
+		if ok := tt.q.Match(v); ok != tt.match {
+			t.Fatalf("#%d: expected %v, got %v", i, tt.match, ok)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	q := Query{MinVCPU: 4, MinPods: 50}
+	vs := Find(q)
+	if len(vs) == 0 {
+		t.Fatal("no instance type found")
+	}
+	for i, v := range vs {
+		if !q.Match(v) {
+			t.Fatalf("%q does not match %+v", v.InstanceType, q)
+		}
+		if !v.Complete {
+			t.Fatalf("%q is incomplete %+v", v.InstanceType, v)
+		}
+		if i == 0 {
+			continue
+		}
+		if p := vs[i-1].PricePerHour; p > v.PricePerHour {
+			t.Fatalf("%q (%v) is sorted before %q (%v)", vs[i-1].InstanceType, p, v.InstanceType, v.PricePerHour)
+		}
+	}
+
+	v, err := Cheapest(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != vs[0] {
+		t.Fatalf("expected %q, got %q", vs[0].InstanceType, v.InstanceType)
+	}
+
+	v, err = Cheapest(Query{Architecture: "arm64", MinVCPU: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.InstanceType != "a1.large" {
+		t.Fatalf("expected a1.large, got %q", v.InstanceType)
+	}
+
+	if _, err = Cheapest(Query{MinVCPU: 1 << 20}); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// r4 instance types have known max pods, but no network interface limits or price
+	for _, v := range Find(Query{}) {
+		if v.InstanceType == "r4.large" {
+			t.Fatalf("unexpected incomplete %q", v.InstanceType)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Package envutil implements environmental variable utilities.
+package envutil
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UpdateFromEnvs updates the fields of the struct that the pointer
+// points to, from environmental variables with the prefix.
+// The variable name of each field is the prefix followed by its
+// upper-cased JSON tag with "-" replaced by "_".
+func UpdateFromEnvs(pfx string, ptr interface{}) error {
+	tp, vv := reflect.TypeOf(ptr).Elem(), reflect.ValueOf(ptr).Elem()
+	for i := 0; i < tp.NumField(); i++ {
+		jv := tp.Field(i).Tag.Get("json")
+		if jv == "" {
+			continue
+		}
+		jv = strings.Replace(jv, ",omitempty", "", -1)
+		jv = strings.ToUpper(strings.Replace(jv, "-", "_", -1))
+		env := pfx + jv
+		if os.Getenv(env) == "" {
+			continue
+		}
+		sv := os.Getenv(env)
+
+		switch vv.Field(i).Type().Kind() {
+		case reflect.String:
+			vv.Field(i).SetString(sv)
+
+		case reflect.Bool:
+			bb, err := strconv.ParseBool(sv)
+			if err != nil {
+				return fmt.Errorf("failed to parse %q (%q, %v)", sv, env, err)
+			}
+			vv.Field(i).SetBool(bb)
+
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			if vv.Field(i).Type() == reflect.TypeOf(time.Duration(0)) {
+				dv, err := time.ParseDuration(sv)
+				if err != nil {
+					return fmt.Errorf("failed to parse %q (%q, %v)", sv, env, err)
+				}
+				vv.Field(i).SetInt(int64(dv))
+				continue
+			}
+			iv, err := strconv.ParseInt(sv, 10, 64)
+			if err != nil {
+				return fmt.Errorf("failed to parse %q (%q, %v)", sv, env, err)
+			}
+			vv.Field(i).SetInt(iv)
+
+		case reflect.Float32, reflect.Float64:
+			fv, err := strconv.ParseFloat(sv, 64)
+			if err != nil {
+				return fmt.Errorf("failed to parse %q (%q, %v)", sv, env, err)
+			}
+			vv.Field(i).SetFloat(fv)
+
+		case reflect.Slice:
+			if vv.Field(i).Type().Elem().Kind() != reflect.String {
+				return fmt.Errorf("%q (%v) is not supported as an env", env, vv.Field(i).Type())
+			}
+			ss := strings.Split(sv, ",")
+			slice := reflect.MakeSlice(reflect.TypeOf([]string{}), len(ss), len(ss))
+			for j := range ss {
+				slice.Index(j).SetString(ss[j])
+			}
+			vv.Field(i).Set(slice)
+
+		default:
+			return fmt.Errorf("%q (%v) is not supported as an env", env, vv.Field(i).Type())
+		}
+	}
+	return nil
+}
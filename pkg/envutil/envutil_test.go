@@ -0,0 +1,57 @@
+package envutil
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUpdateFromEnvs(t *testing.T) {
+	type config struct {
+		Name     string        `json:"name"`
+		Enable   bool          `json:"enable,omitempty"`
+		Count    int           `json:"count,omitempty"`
+		Rate     float64       `json:"rate,omitempty"`
+		Timeout  time.Duration `json:"timeout,omitempty"`
+		Subnets  []string      `json:"subnets,omitempty"`
+		Untagged string
+	}
+
+	os.Setenv("TEST_ENVUTIL_NAME", "hello")
+	os.Setenv("TEST_ENVUTIL_ENABLE", "true")
+	os.Setenv("TEST_ENVUTIL_COUNT", "10")
+	os.Setenv("TEST_ENVUTIL_RATE", "0.5")
+	os.Setenv("TEST_ENVUTIL_TIMEOUT", "3m")
+	os.Setenv("TEST_ENVUTIL_SUBNETS", "a,b")
+	defer func() {
+		os.Unsetenv("TEST_ENVUTIL_NAME")
+		os.Unsetenv("TEST_ENVUTIL_ENABLE")
+		os.Unsetenv("TEST_ENVUTIL_COUNT")
+		os.Unsetenv("TEST_ENVUTIL_RATE")
+		os.Unsetenv("TEST_ENVUTIL_TIMEOUT")
+		os.Unsetenv("TEST_ENVUTIL_SUBNETS")
+	}()
+
+	cfg := config{Untagged: "keep"}
+	if err := UpdateFromEnvs("TEST_ENVUTIL_", &cfg); err != nil {
+		t.Fatal(err)
+	}
+	exp := config{
+		Name:     "hello",
+		Enable:   true,
+		Count:    10,
+		Rate:     0.5,
+		Timeout:  3 * time.Minute,
+		Subnets:  []string{"a", "b"},
+		Untagged: "keep",
+	}
+	if !reflect.DeepEqual(cfg, exp) {
+		t.Fatalf("expected %+v, got %+v", exp, cfg)
+	}
+
+	os.Setenv("TEST_ENVUTIL_COUNT", "ten")
+	if err := UpdateFromEnvs("TEST_ENVUTIL_", &cfg); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -1,8 +1,12 @@
 package httputil
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
 	"time"
 
 	"go.uber.org/zap"
@@ -10,13 +14,61 @@ import (
 
 // CheckGet retries until HTTP response returns the expected output.
 func CheckGet(lg *zap.Logger, u, exp string, retries int, interval time.Duration, stopc chan struct{}) bool {
+	return checkGet(lg, http.DefaultClient, u, exp, retries, interval, stopc)
+}
+
+// CheckGetIPv6 is CheckGet over IPv6, dialing the AAAA record of the host
+// regardless of IPv4 addresses. It returns an error if no AAAA record exists.
+func CheckGetIPv6(lg *zap.Logger, u, exp string, retries int, interval time.Duration, stopc chan struct{}) error {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return err
+	}
+	port := pu.Port()
+	if port == "" {
+		port = "80"
+		if pu.Scheme == "https" {
+			port = "443"
+		}
+	}
+	ips, err := net.LookupIP(pu.Hostname())
+	if err != nil {
+		return err
+	}
+	var ipv6 net.IP
+	for _, ip := range ips {
+		if ip.To4() == nil {
+			ipv6 = ip
+			break
+		}
+	}
+	if ipv6 == nil {
+		return fmt.Errorf("no AAAA record found for %q", pu.Hostname())
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	addr := net.JoinHostPort(ipv6.String(), port)
+	cli := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "tcp6", addr)
+			},
+		},
+	}
+	if !checkGet(lg, cli, u, exp, retries, interval, stopc) {
+		return fmt.Errorf("failed to HTTP Get %q over IPv6 %q", u, addr)
+	}
+	return nil
+}
+
+func checkGet(lg *zap.Logger, cli *http.Client, u, exp string, retries int, interval time.Duration, stopc chan struct{}) bool {
 	for retries > 0 {
 		select {
 		case <-stopc:
 			return false
 		default:
 		}
-		resp, err := http.Get(u)
+		resp, err := cli.Get(u)
 		if err != nil {
 			lg.Warn(
 				"HTTP Get failed",
@@ -1,6 +1,7 @@
 package httputil
 
 import (
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -20,3 +21,25 @@ func TestCheckGet(t *testing.T) {
 		t.Fatal("unexpected response")
 	}
 }
+
+func TestCheckGetIPv6(t *testing.T) {
+	if err := CheckGetIPv6(zap.NewExample(), "http://127.0.0.1/hello", "OK", 1, time.Second, nil); err == nil {
+		t.Fatal("expected error for no AAAA record")
+	}
+
+	ln, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 is not available (%v)", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("OK"))
+	})
+	ts := httptest.NewUnstartedServer(mux)
+	ts.Listener = ln
+	ts.Start()
+	defer ts.Close()
+	if err = CheckGetIPv6(zap.NewExample(), ts.URL+"/hello", "OK", 10, time.Second, nil); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -25,6 +25,9 @@ type Config struct {
 	// when time.Now().Minute() == StartAtMinute.
 	// Useful for simulating multiple workers.
 	StartAtMinute int
+	// StartAt is non-zero, to run "wrk" command at the specified time.
+	// Useful for starting multiple workers in lockstep.
+	StartAt time.Time
 
 	/////////////////////////////////
 	// "wrk" command configuration //
@@ -75,6 +78,23 @@ func Run(cfg Config) (rs Result, err error) {
 		}
 	}
 
+	if !cfg.StartAt.IsZero() {
+		if d := cfg.StartAt.Sub(time.Now().UTC()); d > 0 {
+			cfg.Logger.Info(
+				"waiting until start time",
+				zap.Time("start-at", cfg.StartAt),
+				zap.Duration("wait", d),
+			)
+			time.Sleep(d)
+		} else {
+			cfg.Logger.Warn(
+				"start time has already passed",
+				zap.Time("start-at", cfg.StartAt),
+				zap.Duration("late", -d),
+			)
+		}
+	}
+
 	cfg.Logger.Info(
 		"starting 'wrk' command",
 		zap.Int("threads", cfg.Threads),
@@ -128,6 +148,7 @@ func Run(cfg Config) (rs Result, err error) {
 //		99%  885.55ms
 //	  230174 requests in 15.01s, 8.83GB read
 //	  Socket errors: connect 0, read 0, write 0, timeout 60
+//	  Non-2xx or 3xx responses: 12
 //	Requests/sec:  15335.21
 //	Transfer/sec:    602.56MB
 //
@@ -165,6 +186,10 @@ type Result struct {
 	ErrorsRead    int64
 	ErrorsWrite   int64
 	ErrorsTimeout int64
+
+	// ErrorsNon2xx3xx is the number of HTTP responses
+	// with status code other than 2xx or 3xx.
+	ErrorsNon2xx3xx int64
 }
 
 var header = []string{
@@ -197,6 +222,7 @@ var header = []string{
 	"errors-read",
 	"errors-write",
 	"errors-timeout",
+	"errors-non-2xx-3xx",
 }
 
 // Parse parses "wrk" command output.
@@ -308,6 +334,10 @@ func Parse(s string) (rs Result, err error) {
 			rs.ErrorsWrite, _ = strconv.ParseInt(strings.Fields(fields[2])[1], 10, 64)
 			rs.ErrorsTimeout, _ = strconv.ParseInt(strings.Fields(fields[3])[1], 10, 64)
 
+		case strings.HasPrefix(line, "Non-2xx or 3xx responses:"):
+			line = strings.TrimSpace(strings.Replace(line, "Non-2xx or 3xx responses:", "", -1))
+			rs.ErrorsNon2xx3xx, _ = strconv.ParseInt(line, 10, 64)
+
 		case strings.HasPrefix(line, "Requests/sec:"):
 			fields := strings.Fields(line)
 			if len(fields) != 2 {
@@ -367,6 +397,7 @@ func Combine(rss ...Result) (rs Result) {
 		rs.ErrorsRead += v.ErrorsRead
 		rs.ErrorsWrite += v.ErrorsWrite
 		rs.ErrorsTimeout += v.ErrorsTimeout
+		rs.ErrorsNon2xx3xx += v.ErrorsNon2xx3xx
 	}
 
 	rs.Latency50Pct = 0
@@ -377,6 +408,56 @@ func Combine(rss ...Result) (rs Result) {
 	return rs
 }
 
+// CombineParallel combines results of multiple workers that ran
+// concurrently against the same endpoint. Unlike "Combine", the
+// throughput fields are the sum of all workers.
+func CombineParallel(rss ...Result) (rs Result) {
+	rs = Combine(rss...)
+	rs.Threads, rs.Connections = 0, 0
+	rs.RequestsPerSec, rs.TransferPerSecBytes = 0, 0
+	for _, v := range rss {
+		rs.Threads += v.Threads
+		rs.Connections += v.Connections
+		rs.RequestsPerSec += v.RequestsPerSec
+		rs.TransferPerSecBytes += v.TransferPerSecBytes
+	}
+	rs.TransferPerSec = humanize.Bytes(rs.TransferPerSecBytes)
+	rs.Output = ""
+	return rs
+}
+
+const (
+	// OutputBegin marks the beginning of "wrk" command output
+	// in a worker's log stream.
+	OutputBegin = "-----BEGIN WRK OUTPUT-----"
+	// OutputEnd marks the end of "wrk" command output
+	// in a worker's log stream.
+	OutputEnd = "-----END WRK OUTPUT-----"
+)
+
+// Wrap surrounds the raw "wrk" command output with markers,
+// so that it can be extracted from mixed logs (e.g. "kubectl logs").
+func Wrap(output string) string {
+	return fmt.Sprintf("%s\n%s\n%s\n", OutputBegin, strings.TrimSpace(output), OutputEnd)
+}
+
+// Extract returns all raw "wrk" command outputs wrapped with markers.
+func Extract(logs string) (outputs []string) {
+	for {
+		idx := strings.Index(logs, OutputBegin)
+		if idx == -1 {
+			return outputs
+		}
+		logs = logs[idx+len(OutputBegin):]
+		idx = strings.Index(logs, OutputEnd)
+		if idx == -1 {
+			return outputs
+		}
+		outputs = append(outputs, strings.TrimSpace(logs[:idx]))
+		logs = logs[idx+len(OutputEnd):]
+	}
+}
+
 // ToCSV converts a list of Result to a CSV file.
 func ToCSV(output string, rss ...Result) error {
 	rows := make([][]string, 0, len(rss))
@@ -411,6 +492,8 @@ func ToCSV(output string, rss ...Result) error {
 			fmt.Sprintf("%d", v.ErrorsRead),    // "errors-read"
 			fmt.Sprintf("%d", v.ErrorsWrite),   // "errors-write"
 			fmt.Sprintf("%d", v.ErrorsTimeout), // "errors-timeout"
+
+			fmt.Sprintf("%d", v.ErrorsNon2xx3xx), // "errors-non-2xx-3xx"
 		})
 	}
 	return csvutil.Save(header, rows, output)
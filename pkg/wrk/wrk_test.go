@@ -104,6 +104,7 @@ Latency Distribution
    99%    1.39s
 148205 requests in 10.03s, 5.69GB read
 Socket errors: connect 0, read 0, write 0, timeout 60
+Non-2xx or 3xx responses: 12
 Requests/sec:  14775.76
 Transfer/sec:    580.57MB
 `
@@ -162,4 +163,53 @@ Transfer/sec:    580.57MB
 	if rs2.TransferPerSecBytes != 580570000 {
 		t.Fatalf("TransferPerSecBytes expected 580570000, got %d", rs2.TransferPerSecBytes)
 	}
+	if rs2.ErrorsTimeout != 60 {
+		t.Fatalf("ErrorsTimeout expected 60, got %d", rs2.ErrorsTimeout)
+	}
+	if rs2.ErrorsNon2xx3xx != 12 {
+		t.Fatalf("ErrorsNon2xx3xx expected 12, got %d", rs2.ErrorsNon2xx3xx)
+	}
+	if rs1.ErrorsNon2xx3xx != 0 {
+		t.Fatalf("ErrorsNon2xx3xx expected 0, got %d", rs1.ErrorsNon2xx3xx)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	out1 := `Running 1m test @ http://a
+  2 threads and 100 connections
+Requests/sec:   1000.00
+Transfer/sec:     40.00MB
+`
+	out2 := `Running 1m test @ http://a
+  2 threads and 100 connections
+Requests/sec:   3000.00
+Transfer/sec:     60.00MB
+`
+	logs := `{"level":"info","msg":"starting 'wrk' command"}
+` + Wrap(out1) + `{"level":"info","msg":"completed 'wrk' command"}
+` + Wrap(out2) + OutputBegin + "\nincomplete"
+
+	outputs := Extract(logs)
+	if len(outputs) != 2 {
+		t.Fatalf("expected 2 outputs, got %d", len(outputs))
+	}
+
+	rss := make([]Result, 0, len(outputs))
+	for _, v := range outputs {
+		rs, err := Parse(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rss = append(rss, rs)
+	}
+	rs := CombineParallel(rss...)
+	if rs.RequestsPerSec != 4000 {
+		t.Fatalf("RequestsPerSec expected 4000, got %f", rs.RequestsPerSec)
+	}
+	if rs.Connections != 200 {
+		t.Fatalf("Connections expected 200, got %d", rs.Connections)
+	}
+	if rs.Threads != 4 {
+		t.Fatalf("Threads expected 4, got %d", rs.Threads)
+	}
 }